@@ -9,7 +9,6 @@ import (
 	"neoagent/internal/core/options"
 	"neoagent/internal/core/reporter"
 	"neoagent/internal/core/runner"
-	"neoagent/internal/core/scanner/alive"
 
 	"github.com/spf13/cobra"
 )
@@ -32,19 +31,17 @@ func NewIpAliveScanCmd() *cobra.Command {
 
 			task := opts.ToTask()
 
-			// 1. 初始化 RunnerManager
+			// 1. 初始化 RunnerManager (已注册全部标准扫描器)
 			manager := runner.NewRunnerManager()
-			// 2. 注册 IpAliveScanner
-			manager.Register(alive.NewIpAliveScanner())
 
-			// 3. 执行任务
+			// 2. 执行任务
 			fmt.Printf("[*] Starting IP Alive Scan on %s...\n", task.Target)
 			results, err := manager.Execute(context.Background(), task)
 			if err != nil {
 				return err
 			}
 
-			// 4. 输出结果 (使用 ConsoleReporter)
+			// 3. 输出结果 (使用 ConsoleReporter)
 			console := reporter.NewConsoleReporter()
 			console.PrintResults(results)
 
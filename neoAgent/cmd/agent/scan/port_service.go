@@ -29,19 +29,25 @@ func NewPortScanCmd() *cobra.Command {
 
 			task := opts.ToTask()
 
-			// 1. 初始化 RunnerManager
+			// 1. 初始化 RunnerManager (已注册全部标准扫描器)
 			manager := runner.NewRunnerManager()
-			// 2. 注册 PortServiceScanner
-			manager.Register(port_service.NewPortServiceScanner())
 
-			// 3. 执行任务
+			// 2. 执行任务
 			pterm.Info.Printf("Starting detailed port scan: %s (Ports: %s)...\n", task.Target, task.PortRange)
 			results, err := manager.Execute(context.Background(), task)
 			if err != nil {
 				return err
 			}
 
-			// 4. 输出结果 (使用 ConsoleReporter)
+			// 输出最终采用的探测超时 (基于 RTT 自适应)
+			if r, err := manager.Get(task.Type); err == nil {
+				if ps, ok := r.(*port_service.PortServiceScanner); ok {
+					stats := ps.ProbeTimeoutStats()
+					pterm.Info.Printf("Probe timeout: %v (median RTT %v, %d samples)\n", stats.Timeout, stats.MedianRTT, stats.Samples)
+				}
+			}
+
+			// 3. 输出结果 (使用 ConsoleReporter)
 			console := reporter.NewConsoleReporter()
 			console.PrintResults(results)
 
@@ -65,6 +71,7 @@ func NewPortScanCmd() *cobra.Command {
 	flags.StringVarP(&opts.Target, "target", "t", opts.Target, "扫描目标")
 	flags.StringVarP(&opts.Port, "port", "p", opts.Port, "端口范围 (e.g., 80,443,1-1000)")
 	flags.IntVarP(&opts.Rate, "rate", "r", opts.Rate, "扫描速率 (并发数)")
+	flags.IntVar(&opts.MaxSockets, "max-sockets", opts.MaxSockets, "同时打开的连接数上限 (默认 1024，防止临时端口耗尽)")
 	flags.BoolVarP(&opts.ServiceDetect, "service-detect", "s", opts.ServiceDetect, "启用服务版本识别")
 
 	cmd.MarkFlagRequired("target")
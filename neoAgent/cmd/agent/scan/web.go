@@ -72,6 +72,11 @@ func NewWebScanCmd() *cobra.Command {
 	// 添加截图参数
 	flags.BoolVar(&screenshot, "screenshot", false, "启用网页截图")
 
+	// HTTP Basic 认证探测
+	flags.BoolVar(&opts.BasicAuth, "basic-auth", false, "遇到 HTTP Basic 认证时尝试弱口令 (找到一个成功后即停止)")
+	flags.StringVarP(&opts.Users, "users", "u", "", "Basic 认证用户名列表 (逗号分隔, 默认使用内置字典)")
+	flags.StringVar(&opts.Pass, "pass", "", "Basic 认证密码列表 (逗号分隔, 默认使用内置字典)")
+
 	cmd.MarkFlagRequired("target")
 
 	return cmd
@@ -33,6 +33,7 @@ type App struct {
 	config        *config.Config
 	logger        *logger.LoggerManager
 	masterService client.MasterService
+	forwarder     *client.ResultForwarder
 	runnerManager *runner.RunnerManager
 	taskService   task.AgentTaskService
 }
@@ -59,11 +60,12 @@ func NewApp() (*App, error) {
 
 	// 初始化各模块
 	clientModule := setup.SetupClient(cfg)
-	coreModule := setup.SetupCore()
+	coreModule := setup.SetupCore(cfg)
 
 	// 初始化任务服务（因为ServerModule依赖它）
 	taskService := task.NewAgentTaskService(
 		clientModule.MasterService,
+		clientModule.ResultForwarder,
 		coreModule.RunnerManager,
 		adapter.NewTaskTranslator(),
 		cfg,
@@ -77,6 +79,7 @@ func NewApp() (*App, error) {
 		config:        cfg,
 		logger:        loggerManager,
 		masterService: clientModule.MasterService,
+		forwarder:     clientModule.ResultForwarder,
 		runnerManager: coreModule.RunnerManager,
 		taskService:   taskService,
 	}, nil
@@ -110,6 +113,11 @@ func (a *App) Start() error {
 
 	logger.Infof("NeoAgent started successfully on port %d", a.config.Server.Port)
 
+	// 启动扫描结果上报器（磁盘缓冲中的历史批次在连接恢复后重放）
+	if a.forwarder != nil {
+		a.forwarder.Start(context.Background())
+	}
+
 	// 启动Master服务交互（后台运行）
 	if a.masterService != nil && a.config.Agent != nil && a.config.Agent.AutoRegister {
 		go a.startMasterService(context.Background())
@@ -131,6 +139,13 @@ func (a *App) Stop(ctx context.Context) error {
 		return fmt.Errorf("failed to stop HTTP server: %w", err)
 	}
 
+	// 停止结果上报器，未发送的结果写入磁盘缓冲
+	if a.forwarder != nil {
+		if err := a.forwarder.Close(ctx); err != nil {
+			logger.Warnf("Failed to flush pending results: %v", err)
+		}
+	}
+
 	logger.Info("NeoAgent stopped successfully")
 	return nil
 }
@@ -2,7 +2,10 @@ package setup
 
 import (
 	"fmt"
+	"path/filepath"
+
 	"neoagent/internal/config"
+	"neoagent/internal/pkg/logger"
 	"neoagent/internal/service/client"
 )
 
@@ -10,12 +13,33 @@ import (
 func SetupClient(cfg *config.Config) *ClientModule {
 	// 初始化Master服务
 	var masterSvc client.MasterService
+	var forwarder *client.ResultForwarder
 	if cfg.Master != nil {
 		masterURL := fmt.Sprintf("%s://%s:%d", cfg.Master.Protocol, cfg.Master.Address, cfg.Master.Port)
 		masterSvc = client.NewMasterService(masterURL)
+
+		// 初始化扫描结果上报器，磁盘缓冲不可用时退回到随任务完成状态上报结果 (见 task.processTask)
+		results := cfg.Master.Results
+		spoolDir := results.SpoolDir
+		if spoolDir == "" && cfg.Agent != nil {
+			spoolDir = filepath.Join(cfg.Agent.DataDir, "results")
+		}
+		f, err := client.NewResultForwarder(masterSvc, client.ResultForwarderConfig{
+			BatchSize:     results.BatchSize,
+			FlushInterval: results.FlushInterval,
+			MaxRetries:    results.MaxRetries,
+			RetryBackoff:  results.RetryBackoff,
+			SpoolDir:      spoolDir,
+		})
+		if err != nil {
+			logger.Warnf("Failed to init result forwarder: %v", err)
+		} else {
+			forwarder = f
+		}
 	}
 
 	return &ClientModule{
-		MasterService: masterSvc,
+		MasterService:   masterSvc,
+		ResultForwarder: forwarder,
 	}
 }
@@ -1,16 +1,25 @@
 package setup
 
 import (
+	"neoagent/internal/config"
 	"neoagent/internal/core/runner"
+	"neoagent/internal/pkg/logger"
 )
 
 // SetupCore 初始化核心扫描模块
-func SetupCore() *CoreModule {
+func SetupCore(cfg *config.Config) *CoreModule {
 	// 初始化扫描引擎和Runner
 	// NewRunnerManager 内部已经使用 factory 包统一加载了所有标准扫描器
 	// 包括：Alive, Port, Service, OS, Brute
 	runnerMgr := runner.NewRunnerManager()
 
+	// 加载外部服务指纹库 (可选)，加载失败时继续使用内置规则，不影响启动
+	if cfg.Agent != nil && cfg.Agent.FingerprintFile != "" {
+		if err := runnerMgr.LoadServiceFingerprints(cfg.Agent.FingerprintFile); err != nil {
+			logger.Warnf("[Setup] load service fingerprints failed, using built-in rules: %v", err)
+		}
+	}
+
 	return &CoreModule{
 		RunnerManager: runnerMgr,
 	}
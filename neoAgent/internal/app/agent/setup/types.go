@@ -15,7 +15,8 @@ type CoreModule struct {
 
 // ClientModule 客户端通信模块
 type ClientModule struct {
-	MasterService client.MasterService
+	MasterService   client.MasterService
+	ResultForwarder *client.ResultForwarder // 扫描结果上报器 (Master 未配置或磁盘缓冲不可用时为 nil)
 }
 
 // ServerModule 服务器模块
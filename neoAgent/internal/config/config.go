@@ -125,6 +125,16 @@ type MasterConfig struct {
 	MaxReconnectAttempts  int           `yaml:"max_reconnect_attempts" mapstructure:"max_reconnect_attempts"` // 最大重连次数
 	SkipTLSVerify         bool          `yaml:"skip_tls_verify" mapstructure:"skip_tls_verify"`               // 跳过TLS验证
 	TokenSecret           string        `yaml:"token_secret" mapstructure:"token_secret"`                     // 全局注册密钥
+	Results               ResultsConfig `yaml:"results" mapstructure:"results"`                               // 扫描结果上报配置
+}
+
+// ResultsConfig 扫描结果上报配置
+type ResultsConfig struct {
+	BatchSize     int           `yaml:"batch_size" mapstructure:"batch_size"`         // 单批最多结果数
+	FlushInterval time.Duration `yaml:"flush_interval" mapstructure:"flush_interval"` // 未满批时的最长等待时间 (同时为磁盘缓冲重放间隔)
+	MaxRetries    int           `yaml:"max_retries" mapstructure:"max_retries"`       // 单批发送失败重试次数，超过后写入磁盘缓冲
+	RetryBackoff  time.Duration `yaml:"retry_backoff" mapstructure:"retry_backoff"`   // 首次重试等待时间，之后指数增长
+	SpoolDir      string        `yaml:"spool_dir" mapstructure:"spool_dir"`           // 磁盘缓冲目录 (为空时使用 {data_dir}/results)
 }
 
 // AgentConfig Agent配置
@@ -138,6 +148,7 @@ type AgentConfig struct {
 	TempDir            string        `yaml:"temp_dir" mapstructure:"temp_dir"`                       // 临时目录
 	LogDir             string        `yaml:"log_dir" mapstructure:"log_dir"`                         // 日志目录
 	DataDir            string        `yaml:"data_dir" mapstructure:"data_dir"`                       // 数据目录
+	FingerprintFile    string        `yaml:"fingerprint_file" mapstructure:"fingerprint_file"`       // 服务指纹库文件路径 (为空时使用内置规则)
 	MaxConcurrentTasks int           `yaml:"max_concurrent_tasks" mapstructure:"max_concurrent_tasks"` // 最大并发任务数
 	TaskTimeout        time.Duration `yaml:"task_timeout" mapstructure:"task_timeout"`               // 任务超时时间
 	AutoRegister       bool          `yaml:"auto_register" mapstructure:"auto_register"`             // 是否自动注册
@@ -481,6 +492,18 @@ func loadFromEnv(config *Config) error {
 		config.Master.TLS.KeyFile = tlsKeyPath
 	}
 	
+	if batchSize := os.Getenv("MASTER_RESULT_BATCH_SIZE"); batchSize != "" {
+		if n, err := strconv.Atoi(batchSize); err == nil {
+			config.Master.Results.BatchSize = n
+		}
+	}
+	
+	if flushInterval := os.Getenv("MASTER_RESULT_FLUSH_INTERVAL"); flushInterval != "" {
+		if d, err := time.ParseDuration(flushInterval); err == nil {
+			config.Master.Results.FlushInterval = d
+		}
+	}
+	
 	// Agent配置
 	if config.Agent == nil {
 		config.Agent = &AgentConfig{}
@@ -510,6 +533,10 @@ func loadFromEnv(config *Config) error {
 		config.Agent.DataDir = dataDir
 	}
 	
+	if fingerprintFile := os.Getenv("AGENT_FINGERPRINT_FILE"); fingerprintFile != "" {
+		config.Agent.FingerprintFile = fingerprintFile
+	}
+	
 	// 安全配置
 	if config.Security == nil {
 		config.Security = &SecurityConfig{}
@@ -639,6 +666,22 @@ func setDefaults(config *Config) {
 		config.Master.MaxReconnectAttempts = 10
 	}
 	
+	if config.Master.Results.BatchSize == 0 {
+		config.Master.Results.BatchSize = 100
+	}
+	
+	if config.Master.Results.FlushInterval == 0 {
+		config.Master.Results.FlushInterval = 5 * time.Second
+	}
+	
+	if config.Master.Results.MaxRetries == 0 {
+		config.Master.Results.MaxRetries = 3
+	}
+	
+	if config.Master.Results.RetryBackoff == 0 {
+		config.Master.Results.RetryBackoff = 1 * time.Second
+	}
+	
 	// Agent默认配置
 	if config.Agent == nil {
 		config.Agent = &AgentConfig{}
@@ -190,6 +190,10 @@ func (cl *ConfigLoader) setDefaults() {
 	cl.viper.SetDefault("master.reconnect_interval", "5s")
 	cl.viper.SetDefault("master.max_reconnect_attempts", 10)
 	cl.viper.SetDefault("master.skip_tls_verify", false)
+	cl.viper.SetDefault("master.results.batch_size", 100)
+	cl.viper.SetDefault("master.results.flush_interval", "5s")
+	cl.viper.SetDefault("master.results.max_retries", 3)
+	cl.viper.SetDefault("master.results.retry_backoff", "1s")
 	
 	// Agent默认值
 	cl.viper.SetDefault("agent.type", "worker")
@@ -16,7 +16,8 @@ type Dialer interface {
 
 // DefaultDialer 默认直连拨号器
 type DefaultDialer struct {
-	Timeout time.Duration
+	Timeout       time.Duration
+	SocketOptions SocketOptions
 }
 
 func NewDefaultDialer(timeout time.Duration) *DefaultDialer {
@@ -29,5 +30,5 @@ func (d *DefaultDialer) DialContext(ctx context.Context, network, address string
 	dialer := &net.Dialer{
 		Timeout: d.Timeout,
 	}
-	return dialer.DialContext(ctx, network, address)
+	return d.SocketOptions.dial(ctx, dialer, network, address)
 }
@@ -0,0 +1,50 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"syscall"
+)
+
+// SocketOptions 出站 TCP 连接的套接字选项，零值表示不修改任何选项
+// 高速率短连接场景 (如端口扫描) 下每次探测都会新建并关闭一个连接，
+// 大量连接停留在 TIME_WAIT 会耗尽本地临时端口，可通过以下选项缓解
+type SocketOptions struct {
+	// Linger 是否设置 SO_LINGER；LingerSec 为 0 时关闭连接直接发送 RST，不进入 TIME_WAIT
+	Linger    bool
+	LingerSec int
+	// ReuseAddr 设置 SO_REUSEADDR，允许复用处于 TIME_WAIT 的本地地址
+	ReuseAddr bool
+}
+
+// WithSocketOptions 返回应用了套接字选项的拨号器
+// 仅对直连拨号器 (*DefaultDialer) 生效；代理拨号器的本地连接指向代理服务器，原样返回
+func WithSocketOptions(d Dialer, opts SocketOptions) Dialer {
+	dd, ok := d.(*DefaultDialer)
+	if !ok {
+		return d
+	}
+	return &DefaultDialer{Timeout: dd.Timeout, SocketOptions: opts}
+}
+
+// dial 按套接字选项建立连接
+// 平台不支持的选项会被忽略，不影响连接本身
+func (o SocketOptions) dial(ctx context.Context, d *net.Dialer, network, address string) (net.Conn, error) {
+	if o.ReuseAddr {
+		d.Control = func(network, address string, c syscall.RawConn) error {
+			c.Control(setReuseAddr)
+			return nil
+		}
+	}
+
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	if o.Linger {
+		if tc, ok := conn.(*net.TCPConn); ok {
+			tc.SetLinger(o.LingerSec)
+		}
+	}
+	return conn, nil
+}
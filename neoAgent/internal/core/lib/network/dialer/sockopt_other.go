@@ -0,0 +1,6 @@
+//go:build !unix && !windows
+
+package dialer
+
+// setReuseAddr 当前平台不支持设置 SO_REUSEADDR，保持默认行为
+func setReuseAddr(fd uintptr) {}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package dialer
+
+import "syscall"
+
+// setReuseAddr 在拨号前设置 SO_REUSEADDR，失败时忽略
+func setReuseAddr(fd uintptr) {
+	syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+}
@@ -152,3 +152,56 @@ func TestAdaptiveLimiter_DynamicAdjustment(t *testing.T) {
 		t.Fatal("Channel should have 1 token")
 	}
 }
+
+func TestAdaptiveTimeout(t *testing.T) {
+	a := NewAdaptiveTimeout(2*time.Second, 100*time.Millisecond, 3*time.Second)
+
+	// 没有样本时使用保守的初始超时
+	if a.Timeout() != 2*time.Second {
+		t.Fatalf("Expected initial timeout 2s, got %v", a.Timeout())
+	}
+
+	// 样本不足时逐步收紧，不会一次降到目标值
+	a.Update(50 * time.Millisecond)
+	first := a.Timeout()
+	if first >= 2*time.Second || first <= 150*time.Millisecond {
+		t.Fatalf("Expected timeout between target and initial after one sample, got %v", first)
+	}
+
+	// 样本充足后收敛到 中位数 * 3 = 150ms
+	for i := 0; i < defaultTimeoutWarmup; i++ {
+		a.Update(50 * time.Millisecond)
+	}
+	if a.Timeout() != 150*time.Millisecond {
+		t.Fatalf("Expected converged timeout 150ms, got %v", a.Timeout())
+	}
+
+	// 少量异常慢的样本不影响中位数
+	a.Update(5 * time.Second)
+	a.Update(5 * time.Second)
+	if a.Timeout() != 150*time.Millisecond {
+		t.Fatalf("Outliers should not inflate timeout, got %v", a.Timeout())
+	}
+
+	stats := a.Stats()
+	if stats.MedianRTT != 50*time.Millisecond || stats.Samples != defaultTimeoutWarmup+3 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestAdaptiveTimeout_Bounds(t *testing.T) {
+	a := NewAdaptiveTimeout(time.Second, 100*time.Millisecond, 2*time.Second)
+	for i := 0; i < defaultTimeoutWindow; i++ {
+		a.Update(time.Millisecond)
+	}
+	if a.Timeout() != 100*time.Millisecond {
+		t.Fatalf("Expected timeout clamped to min 100ms, got %v", a.Timeout())
+	}
+
+	for i := 0; i < defaultTimeoutWindow; i++ {
+		a.Update(time.Second)
+	}
+	if a.Timeout() != 2*time.Second {
+		t.Fatalf("Expected timeout clamped to max 2s, got %v", a.Timeout())
+	}
+}
@@ -0,0 +1,118 @@
+package qos
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeoutMultiplier = 3  // 超时 = 中位数 RTT * 倍数
+	defaultTimeoutWindow     = 64 // 参与中位数计算的最近样本数
+	defaultTimeoutWarmup     = 8  // 样本数达到该值后完全采用中位数推导的超时
+)
+
+// AdaptiveTimeout 基于 RTT 中位数的自适应连接超时
+// 初始使用保守超时，随着样本累积逐步收敛到 "中位数 RTT * 倍数"，
+// 使用中位数而非均值，少量异常慢的样本不会拉高超时；结果始终限制在 [min, max] 内
+type AdaptiveTimeout struct {
+	initial    time.Duration
+	min        time.Duration
+	max        time.Duration
+	multiplier float64
+	warmup     int
+
+	samples []time.Duration // 环形缓冲区，保存最近 defaultTimeoutWindow 个样本
+	next    int             // 下一个写入位置
+	count   int             // 累计样本数
+	median  time.Duration
+	timeout time.Duration
+	mu      sync.RWMutex
+}
+
+// TimeoutStats 自适应超时的统计快照
+type TimeoutStats struct {
+	Timeout   time.Duration // 当前采用的超时
+	MedianRTT time.Duration // 最近窗口内的 RTT 中位数
+	Samples   int           // 累计样本数
+}
+
+// NewAdaptiveTimeout 创建自适应超时
+// initial: 没有样本时使用的保守超时; min/max: 超时上下限
+func NewAdaptiveTimeout(initial, min, max time.Duration) *AdaptiveTimeout {
+	if min <= 0 {
+		min = minRTO
+	}
+	if max < min {
+		max = min
+	}
+	a := &AdaptiveTimeout{
+		initial:    clampDuration(initial, min, max),
+		min:        min,
+		max:        max,
+		multiplier: defaultTimeoutMultiplier,
+		warmup:     defaultTimeoutWarmup,
+		samples:    make([]time.Duration, 0, defaultTimeoutWindow),
+	}
+	a.timeout = a.initial
+	return a
+}
+
+// Update 记录一次 RTT 测量值并重新计算超时
+func (a *AdaptiveTimeout) Update(rtt time.Duration) {
+	if rtt <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.samples) < cap(a.samples) {
+		a.samples = append(a.samples, rtt)
+	} else {
+		a.samples[a.next] = rtt
+	}
+	a.next = (a.next + 1) % cap(a.samples)
+	a.count++
+
+	sorted := append([]time.Duration(nil), a.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	a.median = sorted[len(sorted)/2]
+
+	// 样本不足时在保守超时与目标超时之间线性过渡，避免凭少数样本把超时压得过低
+	target := clampDuration(time.Duration(float64(a.median)*a.multiplier), a.min, a.max)
+	weight := 1.0
+	if a.count < a.warmup {
+		weight = float64(a.count) / float64(a.warmup)
+	}
+	a.timeout = clampDuration(a.initial+time.Duration(weight*float64(target-a.initial)), a.min, a.max)
+}
+
+// Timeout 获取当前建议的连接超时
+func (a *AdaptiveTimeout) Timeout() time.Duration {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.timeout
+}
+
+// Stats 获取当前统计快照
+func (a *AdaptiveTimeout) Stats() TimeoutStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return TimeoutStats{
+		Timeout:   a.timeout,
+		MedianRTT: a.median,
+		Samples:   a.count,
+	}
+}
+
+// clampDuration 将 d 限制在 [min, max] 内
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
@@ -48,19 +48,20 @@ func (r IpAliveResult) Rows() [][]string {
 
 // PortServiceResult 端口服务扫描结果
 type PortServiceResult struct {
-	IP         string `json:"ip"`
-	Port       int    `json:"port"`
-	Protocol   string `json:"protocol"`
-	Status     string `json:"status"` // Open/Closed
-	Service    string `json:"service"`
-	Product    string `json:"product,omitempty"`
-	Version    string `json:"version,omitempty"`
-	Info       string `json:"info,omitempty"`
-	Hostname   string `json:"hostname,omitempty"`
-	OS         string `json:"os,omitempty"`
-	DeviceType string `json:"device_type,omitempty"`
-	CPE        string `json:"cpe,omitempty"`
-	Banner     string `json:"banner,omitempty"`
+	IP            string `json:"ip"`
+	AddressFamily string `json:"address_family,omitempty"` // ipv4/ipv6，目标为域名时为空
+	Port          int    `json:"port"`
+	Protocol      string `json:"protocol"`
+	Status        string `json:"status"` // Open/Closed/error(扫描器内部错误)
+	Service       string `json:"service"`
+	Product       string `json:"product,omitempty"`
+	Version       string `json:"version,omitempty"`
+	Info          string `json:"info,omitempty"`
+	Hostname      string `json:"hostname,omitempty"`
+	OS            string `json:"os,omitempty"`
+	DeviceType    string `json:"device_type,omitempty"`
+	CPE           string `json:"cpe,omitempty"`
+	Banner        string `json:"banner,omitempty"`
 }
 
 func (r PortServiceResult) Headers() []string {
@@ -109,6 +110,16 @@ type WebResult struct {
 	TechStack       []string          `json:"tech_stack,omitempty"` // 识别到的技术栈
 	Screenshot      string            `json:"screenshot,omitempty"` // Base64
 	Favicon         string            `json:"favicon,omitempty"`    // Base64
+	BasicAuth       *WebBasicAuth     `json:"basic_auth,omitempty"` // HTTP Basic 认证探测结果 (仅在启用且遇到 Basic 挑战时返回)
+}
+
+// WebBasicAuth HTTP Basic 认证探测结果
+type WebBasicAuth struct {
+	Realm    string `json:"realm,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Success  bool   `json:"success"`
+	Attempts int    `json:"attempts"` // 已尝试的凭据数
 }
 
 // Headers 实现 TabularData 接口
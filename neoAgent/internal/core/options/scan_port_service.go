@@ -11,6 +11,7 @@ type PortScanOptions struct {
 	Target        string
 	Port          string
 	Rate          int
+	MaxSockets    int // 同时打开的连接数上限，0 表示使用扫描器默认值
 	ServiceDetect bool
 	Output        OutputOptions
 }
@@ -38,6 +39,9 @@ func (o *PortScanOptions) ToTask() *model.Task {
 	task.Timeout = 1 * time.Hour
 
 	task.Params["rate"] = o.Rate
+	if o.MaxSockets > 0 {
+		task.Params["max_sockets"] = o.MaxSockets
+	}
 	task.Params["service_detect"] = o.ServiceDetect
 
 	o.Output.ApplyToParams(task.Params)
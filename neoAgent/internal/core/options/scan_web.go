@@ -12,6 +12,11 @@ type WebScanOptions struct {
 	Ports  string
 	Path   string
 	Method string
+
+	BasicAuth bool   // --basic-auth: 遇到 Basic 认证挑战时探测凭据
+	Users     string // --users
+	Pass      string // --pass
+
 	Output OutputOptions
 }
 
@@ -37,6 +42,15 @@ func (o *WebScanOptions) ToTask() *model.Task {
 
 	task.Params["path"] = o.Path
 	task.Params["method"] = o.Method
+	if o.BasicAuth {
+		task.Params["basic_auth"] = true
+		if o.Users != "" {
+			task.Params["users"] = o.Users
+		}
+		if o.Pass != "" {
+			task.Params["passwords"] = o.Pass
+		}
+	}
 
 	o.Output.ApplyToParams(task.Params)
 
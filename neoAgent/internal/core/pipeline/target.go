@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"neoagent/internal/pkg/logger"
+	"neoagent/internal/pkg/utils"
 )
 
 // TargetGenerator 目标生成器
@@ -56,8 +57,12 @@ func parseAndSend(target string, out chan<- string) {
 		return
 	}
 
-	// 1. CIDR (e.g., 192.168.1.0/24)
+	// 1. CIDR (e.g., 192.168.1.0/24, fd00::/120)
 	if _, ipNet, err := net.ParseCIDR(target); err == nil {
+		if err := utils.CheckCIDRExpandable(ipNet); err != nil {
+			logger.Warn(fmt.Sprintf("Skipping target: %v", err))
+			return
+		}
 		for ip := ipNet.IP.Mask(ipNet.Mask); ipNet.Contains(ip); inc(ip) {
 			// 简单的过滤网络地址和广播地址逻辑
 			// 这里为了简化，全部发送，由后续 Alive 模块去过滤
@@ -22,6 +22,21 @@ func (r *ServiceRunner) Name() model.TaskType {
 	return model.TaskTypeServiceScan
 }
 
+// Validate 校验任务参数，与端口扫描相同
+func (r *ServiceRunner) Validate(task *model.Task) error {
+	return r.scanner.Validate(task)
+}
+
+// Prepare 加载服务识别规则
+func (r *ServiceRunner) Prepare(ctx context.Context, task *model.Task) error {
+	return r.scanner.Prepare(ctx, task)
+}
+
+// LoadFingerprintFile 加载外部服务指纹库
+func (r *ServiceRunner) LoadFingerprintFile(path string) error {
+	return r.scanner.LoadFingerprintFile(path)
+}
+
 // Run 执行服务扫描
 func (r *ServiceRunner) Run(ctx context.Context, task *model.Task) ([]*model.TaskResult, error) {
 	// Service Scan 隐含开启服务探测
@@ -33,3 +48,13 @@ func (r *ServiceRunner) Run(ctx context.Context, task *model.Task) ([]*model.Tas
 	
 	return r.scanner.Run(ctx, task)
 }
+
+// RunStream 流式执行服务扫描
+func (r *ServiceRunner) RunStream(ctx context.Context, task *model.Task, out chan<- *model.TaskResult) error {
+	if task.Params == nil {
+		task.Params = make(map[string]interface{})
+	}
+	task.Params["service_detect"] = true
+
+	return r.scanner.RunStream(ctx, task, out)
+}
@@ -3,73 +3,83 @@ package runner
 import (
 	"context"
 	"fmt"
-	"sync"
 
 	"neoagent/internal/core/factory"
 	"neoagent/internal/core/model"
+	"neoagent/internal/core/scanner"
 )
 
 // RunnerManager 管理所有的 Runner
+// 按任务类型的查找与分发由 scanner.Registry 完成，RunnerManager 负责装配标准扫描器
 type RunnerManager struct {
-	runners map[model.TaskType]Runner
-	mu      sync.RWMutex
+	registry *scanner.Registry
 }
 
+// NewRunnerManager 创建 RunnerManager 并注册所有标准扫描器
+// 同一任务类型重复注册属于装配错误，启动时直接 panic
 func NewRunnerManager() *RunnerManager {
 	m := &RunnerManager{
-		runners: make(map[model.TaskType]Runner),
+		registry: scanner.NewRegistry(),
 	}
-
-	// 初始化并注册 BruteScanner
-	// 使用 Factory 获取全功能 BruteScanner
-	bs := factory.NewFullBruteScanner()
-	m.Register(bs)
-
-	// 注册 AliveScanner
-	aliveScanner := factory.NewAliveScanner()
-	m.Register(aliveScanner)
-
-	// 注册 PortScanner
-	portScanner := factory.NewPortScanner()
-	m.Register(portScanner)
-
-	// 注册 ServiceScanner (使用 Adapter)
-	// ServiceScanner 复用 PortScanner 的底层逻辑，但通过 Adapter 调整行为
-	serviceRunner := NewServiceRunner(factory.NewPortScanner())
-	m.Register(serviceRunner)
-
-	// 注册 OsScanner (使用 Adapter)
-	osScanner := factory.NewOsScanner()
-	osRunner := NewOsRunner(osScanner)
-	m.Register(osRunner)
-
+	m.registry.MustRegister(defaultRunners()...)
 	return m
 }
 
-// Register 注册一个 Runner
-func (m *RunnerManager) Register(runner Runner) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.runners[runner.Name()] = runner
+// defaultRunners 标准扫描器列表，新增扫描器在此追加
+func defaultRunners() []Runner {
+	return []Runner{
+		// 使用 Factory 获取全功能 BruteScanner
+		factory.NewFullBruteScanner(),
+		factory.NewAliveScanner(),
+		factory.NewPortScanner(),
+		// ServiceScanner 复用 PortScanner 的底层逻辑，但通过 Adapter 调整行为
+		NewServiceRunner(factory.NewPortScanner()),
+		NewOsRunner(factory.NewOsScanner()),
+	}
+}
+
+// Register 注册一个 Runner，任务类型已被注册时返回错误
+func (m *RunnerManager) Register(runner Runner) error {
+	return m.registry.Register(runner)
 }
 
 // Get 获取指定类型的 Runner
 func (m *RunnerManager) Get(taskType model.TaskType) (Runner, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	if runner, ok := m.runners[taskType]; ok {
-		return runner, nil
-	}
-	return nil, fmt.Errorf("no runner found for task type: %s", taskType)
+	return m.registry.Get(taskType)
 }
 
 // Execute 执行任务
 func (m *RunnerManager) Execute(ctx context.Context, task *model.Task) ([]*model.TaskResult, error) {
-	runner, err := m.Get(task.Type)
-	if err != nil {
-		return nil, err
+	return m.registry.Dispatch(ctx, task)
+}
+
+// ExecuteStream 流式执行任务，结果边扫描边写入 out，out 在返回前关闭
+func (m *RunnerManager) ExecuteStream(ctx context.Context, task *model.Task, out chan<- *model.TaskResult) error {
+	return m.registry.DispatchStream(ctx, task, out)
+}
+
+// fingerprintLoader 支持加载外部服务指纹库的 Runner
+type fingerprintLoader interface {
+	LoadFingerprintFile(path string) error
+}
+
+// LoadServiceFingerprints 为所有支持服务识别的 Runner 加载外部指纹库
+func (m *RunnerManager) LoadServiceFingerprints(path string) error {
+	for _, taskType := range m.registry.Types() {
+		r, err := m.registry.Get(taskType)
+		if err != nil {
+			return err
+		}
+		if l, ok := r.(fingerprintLoader); ok {
+			if err := l.LoadFingerprintFile(path); err != nil {
+				return fmt.Errorf("%s: %w", taskType, err)
+			}
+		}
 	}
+	return nil
+}
 
-	return runner.Run(ctx, task)
+// Registry 返回底层扫描器注册表
+func (m *RunnerManager) Registry() *scanner.Registry {
+	return m.registry
 }
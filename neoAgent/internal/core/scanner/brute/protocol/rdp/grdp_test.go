@@ -0,0 +1,20 @@
+package grdp
+
+import (
+	"testing"
+
+	"neoagent/test/mocklab"
+)
+
+// RDP 服务只返回 5 字节数据时，协议解析不应 panic (slice bounds out of range)，只返回错误
+func TestLogin_ShortResponse(t *testing.T) {
+	lab, err := mocklab.Start()
+	if err != nil {
+		t.Fatalf("start mocklab: %v", err)
+	}
+	defer lab.Close()
+
+	if err := Login(lab.Addr(lab.RDP), "", "administrator", mocklab.Password); err == nil {
+		t.Fatal("expected login against truncated RDP response to fail")
+	}
+}
@@ -1,17 +1,31 @@
 package protocol
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	"neoagent/internal/core/scanner/brute"
+)
 
-	"github.com/redis/go-redis/v9"
+const (
+	redisTimeout     = 3 * time.Second // 连接与单次交互的最长等待时间
+	redisDefaultUser = "default"       // Redis 6.0+ ACL 默认用户
+	redisMaxDepth    = 4               // RESP 嵌套层数上限，防止异常响应导致深度递归
 )
 
 // RedisCracker 实现 Redis 协议爆破
+//
+// 检测原理:
+// 1. 优先发送 RESP3 握手 `HELLO 3 AUTH <user> <pass>`，认证成功时服务端返回 map (server/version/proto...)。
+// 2. Redis 6.0 以下不支持 HELLO (返回 -ERR unknown command / -NOPROTO)，回退到 `AUTH [user] <pass>`，返回 +OK 即成功。
+// 3. 任何 -ERR/-WRONGPASS 等错误回复都不视为成功；读写截止时间受 ctx 约束，连接后不回复的服务不会挂起。
 type RedisCracker struct{}
 
 // NewRedisCracker 创建 Redis 爆破器
@@ -31,41 +45,154 @@ func (c *RedisCracker) Mode() brute.AuthMode {
 
 // Check 验证 Redis 凭据
 func (c *RedisCracker) Check(ctx context.Context, host string, port int, auth brute.Auth) (bool, error) {
-	addr := fmt.Sprintf("%s:%d", host, port)
-
-	// 配置 Redis 客户端
-	// 注意: Redis 6.0+ 支持 ACL (用户名+密码)，但传统 Redis 只有密码
-	// AuthModeOnlyPass 模式下 Username 为空，go-redis 会自动处理
-	opts := &redis.Options{
-		Addr:     addr,
-		Password: auth.Password, // 如果为空字符串，go-redis 不会发送 AUTH 命令
-		Username: auth.Username, // 如果为空，兼容旧版
-		DB:       0,
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
 
-		// 关键: 设置超时，快速失败
-		DialTimeout:  3 * time.Second,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
+	d := net.Dialer{Timeout: redisTimeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false, c.handleError(err)
+	}
+	defer conn.Close()
 
-		// 禁用重试，避免浪费时间
-		MaxRetries: 0,
+	// 截止时间取 ctx 截止时间与 redisTimeout 中较早者；ctx 提前取消时立即打断阻塞的读写
+	deadline := time.Now().Add(redisTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
 	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
 
-	client := redis.NewClient(opts)
-	defer client.Close()
+	r := bufio.NewReader(conn)
 
-	// 执行 PING 命令验证连接和认证
-	// go-redis 在执行命令时会自动处理连接建立和 AUTH
-	// 如果配置了 Password 但不需要密码，或者密码错误，这里会报错
+	// 1. RESP3 握手 (AuthModeOnlyPass 下用户名为空，使用默认用户)
+	user := auth.Username
+	if user == "" {
+		user = redisDefaultUser
+	}
+	reply, err := redisCommand(conn, r, "HELLO", "3", "AUTH", user, auth.Password)
+	if err != nil {
+		return false, c.handleError(err)
+	}
+	if reply.err == "" {
+		// 认证成功时 RESP3 返回 map，RESP2 兼容实现返回数组
+		if reply.kind == '%' || reply.kind == '*' {
+			return true, nil
+		}
+		return false, brute.ErrProtocolError
+	}
+	if !redisHelloUnsupported(reply.err) {
+		return false, c.handleError(errors.New(reply.err))
+	}
 
-	// 使用传入的 context 进行控制
-	cmd := client.Ping(ctx)
-	if err := cmd.Err(); err != nil {
+	// 2. 旧版本不支持 HELLO，回退到 AUTH
+	args := []string{"AUTH", auth.Password}
+	if auth.Username != "" {
+		args = []string{"AUTH", auth.Username, auth.Password}
+	}
+	reply, err = redisCommand(conn, r, args...)
+	if err != nil {
 		return false, c.handleError(err)
 	}
+	if reply.err != "" {
+		return false, c.handleError(errors.New(reply.err))
+	}
+	if reply.kind == '+' && reply.str == "OK" {
+		return true, nil
+	}
+	return false, brute.ErrProtocolError
+}
+
+// redisHelloUnsupported 服务端不支持 HELLO 命令或 RESP3 协议
+func redisHelloUnsupported(errMsg string) bool {
+	msg := strings.ToLower(errMsg)
+	return strings.HasPrefix(msg, "noproto") || strings.Contains(msg, "unknown command")
+}
+
+// redisReply RESP 回复
+// kind 为类型前缀 (+ - : $ * % ~ _ 等)，str 为标量值，err 为错误回复内容，m 为 map 回复中的标量键值
+type redisReply struct {
+	kind  byte
+	str   string
+	err   string
+	items []redisReply
+	m     map[string]string
+}
+
+// redisCommand 以 RESP 数组格式发送命令并读取一个回复
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) (redisReply, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return redisReply{}, err
+	}
+	return readRedisReply(r, 0)
+}
+
+// readRedisReply 解析一个 RESP2/RESP3 回复
+func readRedisReply(r *bufio.Reader, depth int) (redisReply, error) {
+	if depth > redisMaxDepth {
+		return redisReply{}, errors.New("redis: invalid response: nesting too deep")
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return redisReply{}, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return redisReply{}, errors.New("redis: invalid response: empty line")
+	}
 
-	// 成功 (PONG)
-	return true, nil
+	reply := redisReply{kind: line[0]}
+	payload := line[1:]
+	switch reply.kind {
+	case '+', ':', ',', '#', '(':
+		reply.str = payload
+	case '-', '!':
+		reply.err = payload
+	case '_':
+		// RESP3 null
+	case '$', '=':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return redisReply{}, fmt.Errorf("redis: invalid response: bad bulk length %q", payload)
+		}
+		if n < 0 {
+			return reply, nil // RESP2 null bulk
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return redisReply{}, err
+		}
+		reply.str = string(buf[:n])
+	case '*', '~', '>', '%', '|':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return redisReply{}, fmt.Errorf("redis: invalid response: bad aggregate length %q", payload)
+		}
+		if reply.kind == '%' || reply.kind == '|' {
+			n *= 2 // map/attribute 为键值对
+		}
+		for i := 0; i < n; i++ {
+			item, err := readRedisReply(r, depth+1)
+			if err != nil {
+				return redisReply{}, err
+			}
+			reply.items = append(reply.items, item)
+		}
+		if reply.kind == '%' {
+			reply.m = make(map[string]string, n/2)
+			for i := 0; i+1 < len(reply.items); i += 2 {
+				reply.m[reply.items[i].str] = reply.items[i+1].str
+			}
+		}
+	default:
+		return redisReply{}, fmt.Errorf("redis: invalid response: unexpected reply %q", line)
+	}
+	return reply, nil
 }
 
 // handleError 将底层错误转换为标准错误
@@ -94,8 +221,7 @@ func (c *RedisCracker) handleError(err error) error {
 		strings.Contains(msg, "network is unreachable") ||
 		strings.Contains(msg, "connection reset") ||
 		strings.Contains(msg, "target machine actively refused") || // Windows
-		strings.Contains(msg, "failed to dial") || // go-redis generic dial error
-		strings.Contains(msg, "connection pool") || // go-redis connection pool error
+		strings.Contains(msg, "failed to dial") ||
 		strings.Contains(msg, "connectex") || // Windows connect exception
 		strings.Contains(msg, "only one usage of each socket address") || // Windows Bind Error
 		strings.Contains(msg, "context deadline exceeded") ||
@@ -1,13 +1,16 @@
 package protocol
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"neoagent/internal/core/scanner/brute"
+	"neoagent/test/mocklab"
 )
 
 func TestRedisCracker_HandleError(t *testing.T) {
@@ -68,11 +71,6 @@ func TestRedisCracker_Check_NetworkError(t *testing.T) {
 	// port := l.Addr().(*net.TCPAddr).Port
 	// l.Close() // 关闭监听
 
-	// 为了确保端口真的关闭，我们使用一个极不可能开放的端口
-	// 或者，我们只需要知道它会返回错误。
-	// go-redis 的 Dial 逻辑比较复杂，包含重试和连接池
-	// 我们直接 Mock 一下或者只测试 handleError 逻辑可能更好，但集成测试也很有价值。
-
 	// 使用一个随机端口
 	l, _ := net.Listen("tcp", "127.0.0.1:0")
 	port := l.Addr().(*net.TCPAddr).Port
@@ -81,15 +79,14 @@ func TestRedisCracker_Check_NetworkError(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	// go-redis 会尝试重试，可能会比较慢，我们在 Check 里面设置了 DialTimeout=3s
-	// 这里 context 也是 1s
+	// Check 的连接超时为 3s，这里 context 为 1s
 	success, err := c.Check(ctx, "127.0.0.1", port, brute.Auth{Password: "123"})
 
 	if success {
 		t.Error("Expected failure, got success")
 	}
 
-	// 此时 err 应该是 "failed to dial... connectex..."
+	// 此时 err 应该是 "connection refused" (Windows 下为 "connectex...")
 	// handleError 应该返回 ErrConnectionFailed
 	if err != brute.ErrConnectionFailed {
 		t.Errorf("Expected ErrConnectionFailed, got %v", err)
@@ -126,8 +123,155 @@ func TestRedisCracker_Check_ProtocolError(t *testing.T) {
 		t.Error("Expected failure, got success")
 	}
 
-	// go-redis 遇到非法响应会报错
+	// 非 RESP 响应应报协议错误
 	if err == nil {
 		t.Error("Expected error, got nil")
 	}
 }
+
+// 基于 mocklab 的 HELLO 3 AUTH 流程: 正确密码返回成功，错误密码视为认证失败而非连接错误
+func TestRedisCracker_Check_MockLab(t *testing.T) {
+	lab, err := mocklab.Start()
+	if err != nil {
+		t.Fatalf("start mocklab: %v", err)
+	}
+	defer lab.Close()
+
+	c := NewRedisCracker()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	success, err := c.Check(ctx, lab.Host, lab.Redis, brute.Auth{Password: mocklab.Password})
+	if err != nil || !success {
+		t.Fatalf("expected credential found, got success=%v err=%v", success, err)
+	}
+
+	success, err = c.Check(ctx, lab.Host, lab.Redis, brute.Auth{Password: "wrong"})
+	if err != nil || success {
+		t.Fatalf("expected auth failure, got success=%v err=%v", success, err)
+	}
+}
+
+// 接受连接但从不回复的服务: Check 应在 ctx 截止时间后返回连接错误，而不是挂起
+func TestRedisCracker_Check_SilentServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("Failed to listen")
+	}
+	defer l.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err1 := l.Accept()
+		if err1 == nil {
+			defer conn.Close()
+			<-done
+		}
+	}()
+
+	c := NewRedisCracker()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	success, err := c.Check(ctx, "127.0.0.1", l.Addr().(*net.TCPAddr).Port, brute.Auth{Password: "123"})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Check did not honor ctx deadline, took %v", elapsed)
+	}
+	if success || err != brute.ErrConnectionFailed {
+		t.Fatalf("expected ErrConnectionFailed, got success=%v err=%v", success, err)
+	}
+}
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		kind    byte
+		wantErr bool
+	}{
+		{name: "Simple String", input: "+OK\r\n", kind: '+'},
+		{name: "Error", input: "-WRONGPASS invalid username-password pair\r\n", kind: '-'},
+		{name: "RESP3 Map", input: "%2\r\n$6\r\nserver\r\n$5\r\nredis\r\n$5\r\nproto\r\n:3\r\n", kind: '%'},
+		{name: "RESP2 Array", input: "*2\r\n$6\r\nserver\r\n$5\r\nredis\r\n", kind: '*'},
+		{name: "Null", input: "_\r\n", kind: '_'},
+		{name: "HTTP Response", input: "HTTP/1.1 400 Bad Request\r\n", wantErr: true},
+		{name: "Bad Length", input: "$abc\r\n", wantErr: true},
+		{name: "Truncated Map", input: "%1\r\n$6\r\nserver\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := readRedisReply(bufio.NewReader(strings.NewReader(tt.input)), 0)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", reply)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reply.kind != tt.kind {
+				t.Fatalf("kind = %q, want %q", reply.kind, tt.kind)
+			}
+		})
+	}
+
+	reply, _ := readRedisReply(bufio.NewReader(strings.NewReader(tests[2].input)), 0)
+	if reply.m["server"] != "redis" || reply.m["proto"] != "3" {
+		t.Fatalf("unexpected map reply: %v", reply.m)
+	}
+}
+
+// Redis 6.0 以下不支持 HELLO: 回退到 AUTH，+OK 为成功，-ERR 为认证失败
+func TestRedisCracker_Check_LegacyAuthFallback(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("Failed to listen")
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err1 := l.Accept()
+			if err1 != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err2 := r.ReadString('\n')
+					if err2 != nil {
+						return
+					}
+					// 只关心命令名与密码参数，其余 RESP 行忽略
+					switch strings.TrimSpace(line) {
+					case "HELLO":
+						conn.Write([]byte("-ERR unknown command 'HELLO'\r\n"))
+					case "legacy":
+						conn.Write([]byte("+OK\r\n"))
+					case "wrong":
+						conn.Write([]byte("-ERR invalid password\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+
+	c := NewRedisCracker()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	success, err := c.Check(ctx, "127.0.0.1", port, brute.Auth{Password: "legacy"})
+	if err != nil || !success {
+		t.Fatalf("expected credential found, got success=%v err=%v", success, err)
+	}
+	success, err = c.Check(ctx, "127.0.0.1", port, brute.Auth{Password: "wrong"})
+	if err != nil || success {
+		t.Fatalf("expected auth failure, got success=%v err=%v", success, err)
+	}
+}
@@ -2,7 +2,7 @@
  * 核心扫描器接口定义
  * @author: Sun977
  * @date: 2026.01.21
- * @description: 定义所有扫描能力的通用接口。
+ * @description: 定义所有扫描能力的通用接口与生命周期钩子。
  */
 
 package scanner
@@ -12,17 +12,29 @@ import (
 	"neoagent/internal/core/model"
 )
 
-// Scanner 是所有扫描能力的基类接口
-// 无论是 Native Port Scan 还是 Wrapper Nmap，都必须实现此接口
+// Scanner 是所有扫描能力的通用契约
+// 无论是 Native Port Scan 还是 Wrapper Nmap，都应实现此接口
+// 注册表分发任务时的生命周期: Validate -> Prepare(可选) -> Run -> Cleanup(可选，总会执行)
 type Scanner interface {
-	// Name 返回扫描器的唯一标识 (e.g., "native_port_scanner")
-	Name() string
+	Runner
 
-	// Type 返回支持的任务类型
-	Type() model.TaskType
+	// Validate 校验任务参数，失败时不会执行 Prepare/Run
+	Validate(task *model.Task) error
+}
+
+// Preparer 可选钩子: Run 之前准备资源 (加载规则、打开连接等)
+type Preparer interface {
+	Prepare(ctx context.Context, task *model.Task) error
+}
+
+// Cleaner 可选钩子: 任务结束后释放资源 (套接字、临时文件等)
+// Prepare 失败、Run 返回错误或 panic 时同样会调用
+type Cleaner interface {
+	Cleanup(task *model.Task)
+}
 
-	// Scan 执行扫描任务
-	// ctx: 用于超时控制和取消
-	// task: 任务详情
-	Scan(ctx context.Context, task *model.Task) (*model.TaskResult, error)
+// Streamer 可选能力: 流式输出结果，边扫描边返回，适用于大范围扫描(控制内存、实时进度)
+// 实现须保证: out 在返回前关闭且只关闭一次；ctx 取消后停止产生结果并返回
+type Streamer interface {
+	RunStream(ctx context.Context, task *model.Task, out chan<- *model.TaskResult) error
 }
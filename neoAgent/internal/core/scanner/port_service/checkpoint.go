@@ -0,0 +1,205 @@
+package port_service
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"neoagent/internal/pkg/logger"
+)
+
+const (
+	checkpointVersion  = "NSCK1"         // 检查点文件格式版本
+	checkpointInterval = 2 * time.Second // 两次落盘的最小间隔
+	checkpointDirName  = "neoagent-ckpt" // 默认检查点目录名(位于系统临时目录下)
+	checkpointWindow   = 4096            // 默认领先窗口: 最多允许在低水位之后多少个下标内发起探测
+)
+
+// defaultCheckpointDir 默认检查点目录
+func defaultCheckpointDir() string {
+	return filepath.Join(os.TempDir(), checkpointDirName)
+}
+
+// checkpointFingerprint 任务指纹，目标或端口范围变化后旧检查点不再适用
+func checkpointFingerprint(target, portRange string) uint32 {
+	return crc32.ChecksumIEEE([]byte(target + "|" + portRange))
+}
+
+// encodeCheckpoint 检查点格式为单行文本: NSCK1 <next> <fingerprint> <crc>
+// next 为第一个尚未完成的端口下标，crc 校验前三个字段
+func encodeCheckpoint(next int, fingerprint uint32) string {
+	body := fmt.Sprintf("%s %d %08x", checkpointVersion, next, fingerprint)
+	return fmt.Sprintf("%s %08x\n", body, crc32.ChecksumIEEE([]byte(body)))
+}
+
+// decodeCheckpoint 解析检查点，格式错误、校验失败或指纹不符时返回 false
+func decodeCheckpoint(data string, fingerprint uint32, total int) (int, bool) {
+	fields := strings.Fields(data)
+	if len(fields) != 4 || fields[0] != checkpointVersion {
+		return 0, false
+	}
+	body := strings.Join(fields[:3], " ")
+	if fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(body))) != fields[3] {
+		return 0, false
+	}
+	if fields[2] != fmt.Sprintf("%08x", fingerprint) {
+		return 0, false
+	}
+	next, err := strconv.Atoi(fields[1])
+	if err != nil || next < 0 || next > total {
+		return 0, false
+	}
+	return next, true
+}
+
+// checkpointTracker 记录端口完成情况，按"连续完成的最高下标"落盘
+// 并发探测乱序完成，只有下标 next 之前的端口全部完成后才推进 next，恢复时从 next 继续；
+// next 之后已完成的下标暂存在 ahead 中。某个低下标探测迟迟不结束时 next 停滞，
+// 因此发起探测前需经 WaitWindow 限制在 [next, next+window) 内，ahead 的规模不超过 window
+type checkpointTracker struct {
+	mu          sync.Mutex
+	path        string
+	fingerprint uint32
+	total       int
+	next        int              // 低水位: 该下标之前的端口全部完成
+	ahead       map[int]struct{} // 已完成但尚未与低水位连续的下标
+	window      int              // 领先窗口大小
+	advanced    chan struct{}    // 低水位推进时关闭并替换，唤醒 WaitWindow
+	saved       int
+	lastSave    time.Time
+	now         func() time.Time
+}
+
+// newCheckpointTracker 创建检查点记录器并读取已有检查点，检查点不存在或不可读时从头开始
+// window 不大于 0 时使用默认领先窗口
+func newCheckpointTracker(dir, taskID, target, portRange string, total, window int) *checkpointTracker {
+	if window <= 0 {
+		window = checkpointWindow
+	}
+	t := &checkpointTracker{
+		path:        filepath.Join(dir, checkpointFileName(taskID)),
+		fingerprint: checkpointFingerprint(target, portRange),
+		total:       total,
+		ahead:       make(map[int]struct{}),
+		window:      window,
+		advanced:    make(chan struct{}),
+		now:         time.Now,
+	}
+	t.lastSave = t.now()
+
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return t
+	}
+	next, ok := decodeCheckpoint(string(data), t.fingerprint, total)
+	if !ok {
+		return t
+	}
+	t.next, t.saved = next, next
+	return t
+}
+
+// checkpointFileName 任务ID中的路径分隔符等字符替换为下划线
+func checkpointFileName(taskID string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, taskID)
+	return safe + ".ckpt"
+}
+
+// Start 恢复起点: 第一个尚未完成的端口下标
+func (t *checkpointTracker) Start() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.next
+}
+
+// MarkDone 标记端口已完成，距上次落盘超过 checkpointInterval 时写入检查点
+func (t *checkpointTracker) MarkDone(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if index < t.next || index >= t.total {
+		return
+	}
+	t.ahead[index] = struct{}{}
+	prev := t.next
+	for {
+		if _, ok := t.ahead[t.next]; !ok {
+			break
+		}
+		delete(t.ahead, t.next)
+		t.next++
+	}
+	if t.next > prev {
+		close(t.advanced)
+		t.advanced = make(chan struct{})
+	}
+	if t.next > t.saved && t.now().Sub(t.lastSave) >= checkpointInterval {
+		t.saveLocked()
+	}
+}
+
+// WaitWindow 等待下标进入领先窗口 [next, next+window)，上下文取消时返回错误
+func (t *checkpointTracker) WaitWindow(ctx context.Context, index int) error {
+	for {
+		t.mu.Lock()
+		if index < t.next+t.window {
+			t.mu.Unlock()
+			return nil
+		}
+		advanced := t.advanced
+		t.mu.Unlock()
+
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Flush 立即写入检查点(任务中断时调用)
+func (t *checkpointTracker) Flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.next > t.saved {
+		t.saveLocked()
+	}
+}
+
+// Remove 任务成功完成后删除检查点
+func (t *checkpointTracker) Remove() {
+	if err := os.Remove(t.path); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("[PortService] remove checkpoint %s failed: %v", t.path, err)
+	}
+}
+
+// saveLocked 先写临时文件再重命名，避免写入中途崩溃留下半个文件
+func (t *checkpointTracker) saveLocked() {
+	t.lastSave = t.now()
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		logger.Warnf("[PortService] create checkpoint dir failed: %v", err)
+		return
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(encodeCheckpoint(t.next, t.fingerprint)), 0o644); err != nil {
+		logger.Warnf("[PortService] write checkpoint %s failed: %v", t.path, err)
+		return
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		logger.Warnf("[PortService] save checkpoint %s failed: %v", t.path, err)
+		return
+	}
+	t.saved = t.next
+}
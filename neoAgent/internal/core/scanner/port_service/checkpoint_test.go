@@ -0,0 +1,258 @@
+package port_service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"neoagent/internal/core/lib/network/dialer"
+	"neoagent/internal/core/model"
+)
+
+// mockProbeScanner 探测函数替换为内存实现: 记录探测过的端口，端口号为 50 的倍数时视为开放
+func mockProbeScanner(t *testing.T, onProbe func(ctx context.Context, port int)) (*PortServiceScanner, func() []int) {
+	t.Helper()
+	s := NewPortServiceScanner()
+	s.SetCheckpointDir(t.TempDir())
+
+	var mu sync.Mutex
+	var probed []int
+	s.probe = func(ctx context.Context, ip string, port int, timeout time.Duration, opts dialer.SocketOptions) bool {
+		mu.Lock()
+		probed = append(probed, port)
+		mu.Unlock()
+		if onProbe != nil {
+			onProbe(ctx, port)
+		}
+		return ctx.Err() == nil && port%50 == 0
+	}
+	return s, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), probed...)
+	}
+}
+
+func collect(t *testing.T, s *PortServiceScanner, ctx context.Context, task *model.Task) ([]*model.TaskResult, error) {
+	t.Helper()
+	out := make(chan *model.TaskResult)
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.RunStream(ctx, task, out) }()
+	var results []*model.TaskResult
+	for r := range out {
+		results = append(results, r)
+	}
+	return results, <-errCh
+}
+
+func TestPortServiceScanner_ResumeFromCheckpoint(t *testing.T) {
+	task := &model.Task{
+		ID:        "task/resume-1",
+		Target:    "10.0.0.1",
+		PortRange: "1-400",
+		Params:    map[string]interface{}{"resume": true},
+	}
+
+	// 第一次执行: 端口 120 之后的探测挂起，前 119 个端口计入检查点后模拟中断
+	ctx, cancel := context.WithCancel(context.Background())
+	var finished sync.WaitGroup
+	finished.Add(119)
+	go func() {
+		finished.Wait()
+		cancel()
+	}()
+	first, _ := mockProbeScanner(t, func(ctx context.Context, port int) {
+		if port >= 120 {
+			<-ctx.Done()
+		}
+	})
+	first.onPortDone = func(index int) {
+		if index < 119 { // 下标 0-118 对应端口 1-119
+			finished.Done()
+		}
+	}
+	_, err := collect(t, first, ctx, task)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected interrupted scan to return context.Canceled, got %v", err)
+	}
+
+	path := filepath.Join(first.checkpointDir, checkpointFileName(task.ID))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("checkpoint not written: %v", err)
+	}
+	next, ok := decodeCheckpoint(string(data), checkpointFingerprint(task.Target, task.PortRange), 400)
+	if !ok || next < 119 || next >= 400 {
+		t.Fatalf("unexpected checkpoint %q (next=%d ok=%v)", data, next, ok)
+	}
+
+	// 第二次执行: 同一任务ID从检查点继续，检查点之前的端口不再探测
+	second, probed := mockProbeScanner(t, nil)
+	second.SetCheckpointDir(first.checkpointDir)
+	results, err := collect(t, second, context.Background(), task)
+	if err != nil {
+		t.Fatalf("resumed scan failed: %v", err)
+	}
+	seen := make(map[int]bool)
+	for _, port := range probed() {
+		if port <= next {
+			t.Fatalf("port %d before checkpoint (next index %d) was probed again", port, next)
+		}
+		seen[port] = true
+	}
+	for port := next + 1; port <= 400; port++ {
+		if !seen[port] {
+			t.Fatalf("port %d after checkpoint was not probed", port)
+		}
+	}
+	for _, r := range results {
+		if p := r.Result.(*model.PortServiceResult).Port; p <= next {
+			t.Fatalf("result for port %d before checkpoint emitted again", p)
+		}
+	}
+
+	// 成功完成后删除检查点
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint should be removed after success, stat err = %v", err)
+	}
+}
+
+func TestPortServiceScanner_CorruptCheckpointRestarts(t *testing.T) {
+	s, probed := mockProbeScanner(t, nil)
+	task := &model.Task{
+		ID:        "corrupt",
+		Target:    "10.0.0.1",
+		PortRange: "1-100",
+		Params:    map[string]interface{}{"resume": true},
+	}
+	path := filepath.Join(s.checkpointDir, checkpointFileName(task.ID))
+	valid := encodeCheckpoint(50, checkpointFingerprint(task.Target, task.PortRange))
+	// 篡改下标后 CRC 不匹配
+	if err := os.WriteFile(path, []byte("NSCK1 90"+valid[len("NSCK1 50"):]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := collect(t, s, context.Background(), task); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if got := len(probed()); got != 100 {
+		t.Fatalf("corrupt checkpoint should be ignored, probed %d ports, want 100", got)
+	}
+}
+
+func TestDecodeCheckpoint(t *testing.T) {
+	fp := checkpointFingerprint("10.0.0.1", "1-100")
+	if next, ok := decodeCheckpoint(encodeCheckpoint(42, fp), fp, 100); !ok || next != 42 {
+		t.Fatalf("round trip failed: next=%d ok=%v", next, ok)
+	}
+	for name, data := range map[string]string{
+		"empty":          "",
+		"garbage":        "\x00\x01not a checkpoint",
+		"truncated":      encodeCheckpoint(42, fp)[:10],
+		"other_task":     encodeCheckpoint(42, checkpointFingerprint("10.0.0.2", "1-100")),
+		"out_of_range":   encodeCheckpoint(101, fp),
+		"future_version": "NSCK9" + encodeCheckpoint(42, fp)[5:],
+	} {
+		if _, ok := decodeCheckpoint(data, fp, 100); ok {
+			t.Errorf("%s: expected checkpoint to be rejected", name)
+		}
+	}
+}
+
+func TestCheckpointTracker_OutOfOrderCompletion(t *testing.T) {
+	tr := newCheckpointTracker(t.TempDir(), "out-of-order", "10.0.0.1", "1-1000000", 1000000, 0)
+
+	// 乱序完成: 低水位之后的下标暂存，补齐缺口后连续推进
+	for _, idx := range []int{2, 1, 4} {
+		tr.MarkDone(idx)
+	}
+	if tr.Start() != 0 || len(tr.ahead) != 3 {
+		t.Fatalf("next=%d ahead=%d, want 0 and 3", tr.Start(), len(tr.ahead))
+	}
+	tr.MarkDone(0)
+	if tr.Start() != 3 || len(tr.ahead) != 1 {
+		t.Fatalf("next=%d ahead=%d, want 3 and 1", tr.Start(), len(tr.ahead))
+	}
+
+	// 重复或越界的下标不影响状态
+	tr.MarkDone(1)
+	tr.MarkDone(1000000)
+	if tr.Start() != 3 || len(tr.ahead) != 1 {
+		t.Fatalf("next=%d ahead=%d after stale marks, want 3 and 1", tr.Start(), len(tr.ahead))
+	}
+}
+
+// 低下标探测停滞时，发起探测的下标被限制在领先窗口内，已完成的下标不会无限堆积
+func TestPortServiceScanner_StalledPortBoundsCheckpointWindow(t *testing.T) {
+	const window = 50
+	release := make(chan struct{})
+	var started atomic.Int32
+	s, probed := mockProbeScanner(t, func(ctx context.Context, port int) {
+		started.Add(1)
+		if port == 1 {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+		}
+	})
+	s.checkpointWindow = window
+
+	task := &model.Task{ID: "stalled", Target: "10.0.0.1", PortRange: "1-1000", Params: map[string]interface{}{"resume": true}}
+	done := make(chan error, 1)
+	go func() {
+		_, err := collect(t, s, context.Background(), task)
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for started.Load() < window && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := started.Load(); n != window {
+		t.Fatalf("%d probes started while port 1 stalled, want %d", n, window)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if n := len(probed()); n != 1000 {
+		t.Fatalf("probed %d ports, want 1000", n)
+	}
+}
+
+func TestCheckpointTracker_WaitWindow(t *testing.T) {
+	tr := newCheckpointTracker(t.TempDir(), "window", "10.0.0.1", "1-100", 100, 4)
+	if err := tr.WaitWindow(context.Background(), 3); err != nil {
+		t.Fatalf("index inside window: %v", err)
+	}
+
+	// 下标 0 未完成时窗口外的下标一直等待
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := tr.WaitWindow(ctx, 4); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected index outside window to wait, got %v", err)
+	}
+
+	// 低水位推进后唤醒
+	waited := make(chan error, 1)
+	go func() { waited <- tr.WaitWindow(context.Background(), 5) }()
+	for _, idx := range []int{1, 2, 3, 0} {
+		tr.MarkDone(idx)
+	}
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("wait after low watermark advanced: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitWindow not woken after low watermark advanced")
+	}
+}
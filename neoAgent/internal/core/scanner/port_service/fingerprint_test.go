@@ -0,0 +1,117 @@
+package port_service
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"neoagent/internal/core/model"
+	"neoagent/test/mocklab"
+)
+
+// bannerServer 在 address 上监听，连接建立后立即发送 banner
+func bannerServer(t *testing.T, network, address, banner string) int {
+	t.Helper()
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		t.Skipf("listen %s %s: %v", network, address, err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(banner))
+			conn.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestPortServiceScanner_LoadFingerprintFile(t *testing.T) {
+	s := NewPortServiceScanner()
+	if err := s.LoadFingerprintFile(filepath.Join("testdata", "service-probes.sample")); err != nil {
+		t.Fatalf("LoadFingerprintFile failed: %v", err)
+	}
+
+	port := bannerServer(t, "tcp", "127.0.0.1:0", "SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1\r\n")
+	results, err := s.Run(context.Background(), &model.Task{
+		ID:        "fingerprint-task",
+		Target:    "127.0.0.1",
+		PortRange: strconv.Itoa(port),
+		Params:    map[string]interface{}{"service_detect": true},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	got := results[0].Result.(*model.PortServiceResult)
+	if got.Service != "ssh" || got.Product != "OpenSSH" || got.Version != "8.9p1 Ubuntu 3ubuntu0.1" {
+		t.Fatalf("unexpected fingerprint: service=%q product=%q version=%q", got.Service, got.Product, got.Version)
+	}
+}
+
+func TestPortServiceScanner_LoadFingerprintFileSkipsMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probes")
+	content := "Probe TCP broken\n" + // 探针定义错误，其下规则一并跳过
+		"match orphan m|^ORPHAN|\n" +
+		"Probe TCP NULL q||\n" +
+		"match bad m|^(unclosed|\n" + // 正则错误
+		"match missing-delimiter ^NEO\n" + // 格式错误
+		"match neoscan-echo m|^NEOSCAN-ECHO ([\\d.]+)| v/$1/\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewPortServiceScanner()
+	if err := s.LoadFingerprintFile(path); err != nil {
+		t.Fatalf("malformed entries should be skipped, got error: %v", err)
+	}
+	probe, ok := s.gonmapEngine.Probes["NULL"]
+	if !ok || len(s.gonmapEngine.Probes) != 1 {
+		t.Fatalf("expected only NULL probe, got %v", s.gonmapEngine.ProbeSort)
+	}
+	if len(probe.MatchGroup) != 1 || probe.MatchGroup[0].Service != "neoscan-echo" {
+		t.Fatalf("expected only the valid match to be loaded, got %d matches", len(probe.MatchGroup))
+	}
+
+	// 没有任何有效探针时返回错误
+	if err := os.WriteFile(path, []byte("Probe TCP broken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewPortServiceScanner().LoadFingerprintFile(path); err == nil {
+		t.Fatal("expected error for fingerprint file without valid probes")
+	}
+}
+
+// 内置指纹库识别 mocklab 的 SSH Banner
+func TestPortServiceScanner_MockLabSSH(t *testing.T) {
+	lab, err := mocklab.Start()
+	if err != nil {
+		t.Fatalf("start mocklab: %v", err)
+	}
+	defer lab.Close()
+
+	results, err := NewPortServiceScanner().Run(context.Background(), &model.Task{
+		ID:        "mocklab-ssh",
+		Target:    lab.Host,
+		PortRange: strconv.Itoa(lab.SSH),
+		Params:    map[string]interface{}{"service_detect": true},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if got := results[0].Result.(*model.PortServiceResult); got.Service != "ssh" {
+		t.Fatalf("expected ssh, got service=%q product=%q", got.Service, got.Product)
+	}
+}
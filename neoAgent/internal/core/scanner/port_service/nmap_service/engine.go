@@ -3,7 +3,9 @@ package nmap_service
 import (
 	"context"
 	"fmt"
+	"net"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,7 +37,8 @@ func (e *Engine) LoadRules(content string) error {
 		return err
 	}
 
-	// 构建 Port -> Probe 映射
+	// 构建 Port -> Probe 映射 (重新加载时清空旧映射)
+	e.PortProbeMap = make(map[int][]string)
 	for _, probe := range e.Probes {
 		for _, port := range probe.Ports {
 			e.PortProbeMap[port] = append(e.PortProbeMap[port], probe.Name)
@@ -172,7 +175,7 @@ func (e *Engine) prioritizeProbes(probes []string, startIndex int, service strin
 }
 
 func (e *Engine) sendProbe(ctx context.Context, ip string, port int, probe *Probe, timeout time.Duration) ([]byte, error) {
-	address := fmt.Sprintf("%s:%d", ip, port)
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
 	d := dialer.Get() // 使用核心网络库
 
 	// 优化超时策略：连接超时短，读写超时长
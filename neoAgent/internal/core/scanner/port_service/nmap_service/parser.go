@@ -36,13 +36,16 @@ var (
 )
 
 // ParseNmapServiceProbes 解析 Nmap 服务探测规则内容
+// 格式错误的条目(探针定义、match/softmatch 规则)跳过并记录警告，探针定义错误时其下的规则一并跳过；
+// 没有任何有效探针时返回错误
 func ParseNmapServiceProbes(content string) (map[string]*Probe, []string, error) {
 	lines := strings.Split(content, "\n")
 	probes := make(map[string]*Probe)
 	var probeSort []string
 	var currentProbe *Probe
 
-	for _, line := range lines {
+	for i, line := range lines {
+		lineNo := i + 1
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
@@ -53,35 +56,45 @@ func ParseNmapServiceProbes(content string) (map[string]*Probe, []string, error)
 				probes[currentProbe.Name] = currentProbe
 				probeSort = append(probeSort, currentProbe.Name)
 			}
+			currentProbe = nil
 			p, err := parseProbeLine(line)
 			if err != nil {
-				logger.Warn(fmt.Sprintf("Failed to parse probe line: %s, error: %v", line, err))
+				logger.Warnf("[Gonmap] line %d: skip malformed probe %q: %v", lineNo, line, err)
 				continue
 			}
 			currentProbe = p
 			continue
 		}
 
+		// 首个探针之前的全局指令(如 Exclude)不处理
 		if currentProbe == nil {
 			continue
 		}
 
 		if strings.HasPrefix(line, "match ") {
-			m := parseMatchLine(line[6:], false)
-			if m != nil {
-				currentProbe.MatchGroup = append(currentProbe.MatchGroup, m)
+			m, err := parseMatchLine(line[6:], false)
+			if err != nil {
+				logger.Warnf("[Gonmap] line %d: skip malformed match in probe %s: %v", lineNo, currentProbe.Name, err)
+				continue
 			}
+			currentProbe.MatchGroup = append(currentProbe.MatchGroup, m)
 		} else if strings.HasPrefix(line, "softmatch ") {
-			m := parseMatchLine(line[10:], true)
-			if m != nil {
-				currentProbe.SoftMatchGroup = append(currentProbe.SoftMatchGroup, m)
+			m, err := parseMatchLine(line[10:], true)
+			if err != nil {
+				logger.Warnf("[Gonmap] line %d: skip malformed softmatch in probe %s: %v", lineNo, currentProbe.Name, err)
+				continue
 			}
+			currentProbe.SoftMatchGroup = append(currentProbe.SoftMatchGroup, m)
 		} else if strings.HasPrefix(line, "ports ") {
 			currentProbe.Ports = ParsePortList(line[6:])
 		} else if strings.HasPrefix(line, "sslports ") {
 			currentProbe.SslPorts = ParsePortList(line[9:])
 		} else if strings.HasPrefix(line, "rarity ") {
-			r, _ := strconv.Atoi(line[7:])
+			r, err := strconv.Atoi(line[7:])
+			if err != nil {
+				logger.Warnf("[Gonmap] line %d: skip malformed rarity in probe %s: %q", lineNo, currentProbe.Name, line)
+				continue
+			}
 			currentProbe.Rarity = r
 		} else if strings.HasPrefix(line, "fallback ") {
 			currentProbe.Fallback = strings.Split(line[9:], ",")
@@ -94,6 +107,9 @@ func ParseNmapServiceProbes(content string) (map[string]*Probe, []string, error)
 		probeSort = append(probeSort, currentProbe.Name)
 	}
 
+	if len(probes) == 0 {
+		return nil, nil, errors.New("no valid probe found")
+	}
 	return probes, probeSort, nil
 }
 
@@ -120,7 +136,7 @@ func parseProbeLine(line string) (*Probe, error) {
 	}, nil
 }
 
-func parseMatchLine(line string, isSoft bool) *Match {
+func parseMatchLine(line string, isSoft bool) (*Match, error) {
 	var regx *regexp.Regexp
 	for _, r := range matchRegexps {
 		if r.MatchString(line) {
@@ -129,7 +145,7 @@ func parseMatchLine(line string, isSoft bool) *Match {
 		}
 	}
 	if regx == nil {
-		return nil
+		return nil, errors.New("invalid match format")
 	}
 
 	args := regx.FindStringSubmatch(line)
@@ -141,8 +157,7 @@ func parseMatchLine(line string, isSoft bool) *Match {
 	// Compile regex
 	re, err := compilePattern(pattern, opt)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("Invalid regex pattern: %s, error: %v", pattern, err))
-		return nil
+		return nil, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
 	}
 
 	return &Match{
@@ -151,7 +166,7 @@ func parseMatchLine(line string, isSoft bool) *Match {
 		Pattern:             pattern,
 		PatternRegexp:       re,
 		VersionInfoTemplate: info,
-	}
+	}, nil
 }
 
 func compilePattern(pattern, opt string) (*regexp2.Regexp, error) {
@@ -3,45 +3,155 @@ package port_service
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"runtime/debug"
+	"strconv"
 	"sync"
 	"time"
 
 	"neoagent/internal/core/lib/network/dialer"
 	"neoagent/internal/core/lib/network/qos"
 	"neoagent/internal/core/model"
+	"neoagent/internal/core/scanner"
 	"neoagent/internal/core/scanner/port_service/nmap_service"
+	"neoagent/internal/pkg/logger"
+	"neoagent/internal/pkg/utils"
 )
 
 const (
 	ScannerName    = "port_service_scanner"
 	DefaultTimeout = 2 * time.Second
+
+	// PortStatusError 扫描器内部错误(探测过程 panic)时的端口状态
+	PortStatusError = "error"
+
+	streamBufferSize = 64 // Run 汇总流式结果时的通道缓冲
+
+	minProbeTimeout = 100 * time.Millisecond // 端口探测超时下限
+	maxProbeTimeout = 3 * time.Second        // 端口探测超时上限，防止异常 RTT 拉长整体耗时
+)
+
+// 编译期检查: PortServiceScanner 实现 Scanner 契约、Prepare 钩子与流式输出
+var (
+	_ scanner.Scanner  = (*PortServiceScanner)(nil)
+	_ scanner.Preparer = (*PortServiceScanner)(nil)
+	_ scanner.Streamer = (*PortServiceScanner)(nil)
 )
 
 // PortServiceScanner 端口服务扫描器
 // 实现了 Scanner 接口，整合了 TCP Connect 扫描与 Nmap 服务识别逻辑
 type PortServiceScanner struct {
 	gonmapEngine *nmap_service.Engine
-	rttEstimator *qos.RttEstimator
-	limiter      *qos.AdaptiveLimiter
 
 	initOnce sync.Once
 	initErr  error
+
+	// probeStats 最近一次结束的扫描的探测超时统计
+	probeStats   qos.TimeoutStats
+	probeStatsMu sync.Mutex
+
+	// probe 端口连通性探测，默认 TCP Connect，测试时可替换
+	probe func(ctx context.Context, ip string, port int, timeout time.Duration, opts dialer.SocketOptions) bool
+	// checkpointDir 检查点目录(任务参数 resume=true 时启用)
+	checkpointDir string
+	// checkpointWindow 检查点领先窗口，为 0 时使用默认值，测试时可调小
+	checkpointWindow int
+	// onPortDone 端口计入检查点后的回调 (下标)，测试时用于同步
+	onPortDone func(index int)
+}
+
+// scanRun 单次扫描的运行状态，由任务参数决定
+// 同一扫描器实例可能并发执行多个任务，这些状态不能放在扫描器上共享
+type scanRun struct {
+	limiter    *qos.AdaptiveLimiter // 并发控制，任务参数 rate 覆盖初始值与上限
+	sockOpts   dialer.SocketOptions // 端口探测连接的套接字选项，由任务参数 socket_linger/socket_reuse 覆盖
+	rttTimeout *qos.AdaptiveTimeout // 端口探测超时，随本次扫描的 RTT 样本收敛，不受其他目标网络状况影响
+}
+
+// newScanRun 根据任务参数创建运行状态
+func newScanRun(params map[string]interface{}) (*scanRun, int) {
+	// 初始并发 100，最小 10，最大 2000
+	limiter := qos.NewAdaptiveLimiter(100, 10, 2000)
+	// 如果用户指定了 rate，我们将其作为 Initial 和 Max
+	if val, ok := params["rate"]; ok {
+		if rate := utils.InterfaceToInt(val, 0); rate > 0 {
+			limiter = qos.NewAdaptiveLimiter(rate, 10, rate*2)
+		}
+	}
+	// 连接数上限与套接字选项，避免高速率下临时端口耗尽
+	maxSockets, sockOpts := parseSocketParams(params)
+	return &scanRun{limiter: limiter, sockOpts: sockOpts, rttTimeout: newProbeTimeout()}, maxSockets
+}
+
+// newProbeTimeout 创建端口探测超时
+// 初始使用保守的 DefaultTimeout，随 RTT 样本累积收敛到中位数 RTT 的倍数
+func newProbeTimeout() *qos.AdaptiveTimeout {
+	return qos.NewAdaptiveTimeout(DefaultTimeout, minProbeTimeout, maxProbeTimeout)
 }
 
 func NewPortServiceScanner() *PortServiceScanner {
-	return &PortServiceScanner{
-		gonmapEngine: nmap_service.NewEngine(),
-		rttEstimator: qos.NewRttEstimator(),
-		// 初始并发 100，最小 10，最大 2000
-		limiter: qos.NewAdaptiveLimiter(100, 10, 2000),
+	s := &PortServiceScanner{
+		gonmapEngine:  nmap_service.NewEngine(),
+		probeStats:    newProbeTimeout().Stats(),
+		checkpointDir: defaultCheckpointDir(),
 	}
+	s.probe = s.isPortOpen
+	return s
+}
+
+// SetCheckpointDir 设置检查点目录，默认位于系统临时目录
+func (s *PortServiceScanner) SetCheckpointDir(dir string) {
+	if dir != "" {
+		s.checkpointDir = dir
+	}
+}
+
+// LoadFingerprintFile 从 nmap-service-probes 格式的文件加载服务指纹库，替换内置规则
+// 格式错误的条目跳过并记录警告；文件不可读或不含有效探针时返回错误，原有规则保持不变
+func (s *PortServiceScanner) LoadFingerprintFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read fingerprint file %s: %w", path, err)
+	}
+	engine := nmap_service.NewEngine()
+	if err := engine.LoadRules(string(content)); err != nil {
+		return fmt.Errorf("load fingerprint file %s: %w", path, err)
+	}
+
+	// 已加载外部指纹库，不再加载内置规则
+	s.initOnce.Do(func() {})
+	s.gonmapEngine = engine
+	logger.Infof("[PortService] loaded %d service probes from %s", len(engine.Probes), path)
+	return nil
 }
 
 func (s *PortServiceScanner) Name() model.TaskType {
 	return model.TaskTypePortScan
 }
 
+// Validate 校验任务参数: 目标与端口范围必填，目标为网段时需可展开，端口范围需能解析出至少一个端口
+func (s *PortServiceScanner) Validate(task *model.Task) error {
+	if task.Target == "" {
+		return fmt.Errorf("target is required")
+	}
+	if _, err := expandTargets(task.Target); err != nil {
+		return err
+	}
+	if task.PortRange == "" {
+		return fmt.Errorf("port range is required")
+	}
+	if len(nmap_service.ParsePortList(task.PortRange)) == 0 {
+		return fmt.Errorf("invalid port range: %s", task.PortRange)
+	}
+	return nil
+}
+
+// Prepare 在执行前加载服务识别规则，规则只加载一次
+func (s *PortServiceScanner) Prepare(ctx context.Context, task *model.Task) error {
+	return s.ensureInit()
+}
+
 // ensureInit 确保规则已加载
 func (s *PortServiceScanner) ensureInit() error {
 	s.initOnce.Do(func() {
@@ -69,17 +179,43 @@ func (s *PortServiceScanner) ensureInit() error {
 	return nil
 }
 
+// Run 执行端口服务扫描，扫描完成后一次性返回全部结果
+// 基于 RunStream 实现，保持原有切片返回的调用方式
 func (s *PortServiceScanner) Run(ctx context.Context, task *model.Task) ([]*model.TaskResult, error) {
-	if err := s.ensureInit(); err != nil {
+	out := make(chan *model.TaskResult, streamBufferSize)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.RunStream(ctx, task, out)
+	}()
+
+	results := make([]*model.TaskResult, 0)
+	for result := range out {
+		results = append(results, result)
+	}
+	if err := <-errCh; err != nil {
 		return nil, err
 	}
+	return results, nil
+}
+
+// RunStream 流式执行端口服务扫描，每发现一个开放端口立即写入 out
+// out 由 RunStream 关闭且只关闭一次(包括参数错误直接返回的情况)；
+// ctx 取消后不再发起新的探测、不再写入结果，等待进行中的探测退出后返回 ctx.Err()
+// 任务参数 resume=true 时按任务ID记录检查点: 中断后以同一任务ID重新执行，从第一个未完成的端口继续
+// (检查点之前的结果已在上次执行中输出，不会重复产生)，成功完成后删除检查点
+func (s *PortServiceScanner) RunStream(ctx context.Context, task *model.Task, out chan<- *model.TaskResult) error {
+	defer close(out)
+
+	if err := s.ensureInit(); err != nil {
+		return err
+	}
 
 	target := task.Target
 	portRange := task.PortRange
 	if portRange == "" {
 		// 默认扫描 Top 1000? 或者报错
 		// 这里假设调用方已处理好
-		return nil, fmt.Errorf("port range is required")
+		return fmt.Errorf("port range is required")
 	}
 
 	// 解析参数
@@ -90,74 +226,121 @@ func (s *PortServiceScanner) Run(ctx context.Context, task *model.Task) ([]*mode
 		}
 	}
 
+	// 解析目标列表 (单个 IPv4/IPv6 地址、CIDR 网段或域名)
+	hosts, err := expandTargets(target)
+	if err != nil {
+		return err
+	}
+
 	// 解析端口列表(使用专门的解析函数,没使用utils.ParseIntList,因为有 -p top100 这种定制情况)
 	ports := nmap_service.ParsePortList(portRange)
 	// ports := utils.ParseIntList(portRange)
 
-	// 并发控制参数 (覆盖默认值)
-	// 如果用户指定了 rate，我们将其作为 Initial 和 Max
-	if val, ok := task.Params["rate"]; ok {
-		var rate int
-		if v, ok := val.(float64); ok {
-			rate = int(v)
-		} else if v, ok := val.(int); ok {
-			rate = v
-		}
-		if rate > 0 {
-			// 重置 limiter
-			s.limiter = qos.NewAdaptiveLimiter(rate, 10, rate*2)
+	// 探测按 主机 x 端口 展开，下标 i 对应 hosts[i/len(ports)] 的 ports[i%len(ports)]
+	total := len(hosts) * len(ports)
+
+	// 断点续扫 (可选)
+	var tracker *checkpointTracker
+	start := 0
+	if resume, ok := task.Params["resume"].(bool); ok && resume && task.ID != "" {
+		tracker = newCheckpointTracker(s.checkpointDir, task.ID, target, portRange, total, s.checkpointWindow)
+		start = tracker.Start()
+		if start > 0 {
+			logger.Infof("[PortService] task %s resumed from checkpoint: %d/%d ports done", task.ID, start, total)
 		}
 	}
 
-	results := make([]*model.TaskResult, 0)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
+	// 并发控制与套接字选项 (覆盖默认值)，仅作用于本次扫描
+	run, maxSockets := newScanRun(task.Params)
+	sockets := make(chan struct{}, maxSockets)
 
-	for _, port := range ports {
-		wg.Add(1)
+	var wg sync.WaitGroup
+	var scanErr error
 
+	for i := start; i < total; i++ {
+		// 断点续扫时发起探测的下标不能超出检查点的领先窗口，避免低下标探测停滞时已完成下标无限堆积
+		if tracker != nil {
+			if err := tracker.WaitWindow(ctx, i); err != nil {
+				scanErr = err
+				break
+			}
+		}
 		// 获取并发令牌 (带上下文超时)
-		if err := s.limiter.Acquire(ctx); err != nil {
-			wg.Done()
-			return nil, err // 上下文取消
+		if err := run.limiter.Acquire(ctx); err != nil {
+			scanErr = err // 上下文取消，停止发起新的探测
+			break
 		}
+		wg.Add(1)
 
-		go func(p int) {
+		go func(idx int, host string, p int) {
 			defer wg.Done()
-			defer s.limiter.Release()
+			defer run.limiter.Release()
+
+			// 只有在上下文未取消时完成的端口才计入检查点，取消导致的失败需要续扫时重新探测
+			completed := false
+			defer func() {
+				if completed && tracker != nil {
+					tracker.MarkDone(idx)
+					if s.onPortDone != nil {
+						s.onPortDone(idx)
+					}
+				}
+			}()
+
+			// 单个端口的探测或服务识别 panic 时只影响该端口: 记录日志并输出内部错误结果，不中断整个扫描
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Errorf("[PortService] PANIC RECOVERED while probing %s: %v\n%s", net.JoinHostPort(host, strconv.Itoa(p)), r, debug.Stack())
+					select {
+					case out <- internalErrorResult(task.ID, host, p, r):
+						completed = true
+					case <-ctx.Done():
+					}
+				}
+			}()
 
-			// 动态获取当前 RTO
-			timeout := s.rttEstimator.Timeout()
+			// 占用一个连接名额直到该端口处理结束 (探测与服务识别各自串行建立连接)
+			select {
+			case sockets <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sockets }()
+
+			// 动态获取当前超时 (基于 RTT 中位数)
+			timeout := run.rttTimeout.Timeout()
 
 			// 1. 基础端口连通性检查 (TCP Connect)
 			// 测量 RTT
 			start := time.Now()
-			isOpen := s.isPortOpen(ctx, target, p, timeout)
+			isOpen := s.probe(ctx, host, p, timeout, run.sockOpts)
 			duration := time.Since(start)
 
 			if isOpen {
 				// 成功连接：更新 RTT，增加并发
-				s.rttEstimator.Update(duration)
-				s.limiter.OnSuccess()
+				run.rttTimeout.Update(duration)
+				run.limiter.OnSuccess()
 			} else {
 				// 连接失败
 				// 如果是因为超时失败的，才应该惩罚
 				// 这里简化逻辑：如果是网络不可达，其实也会很快返回，不算超时
 				// 只有当 duration 接近 timeout 时，才认为是拥塞导致的丢包
 				if duration >= timeout {
-					s.limiter.OnFailure()
+					run.limiter.OnFailure()
 				}
 				// 端口关闭，直接返回
+				completed = ctx.Err() == nil
 				return
 			}
 
 			// 端口开放，构建基础结果
 			portResult := &model.PortServiceResult{
-				IP:       target,
-				Port:     p,
-				Protocol: "tcp",
-				Status:   "open",
-				Service:  "unknown",
+				IP:            host,
+				AddressFamily: addressFamily(host),
+				Port:          p,
+				Protocol:      "tcp",
+				Status:        "open",
+				Service:       "unknown",
 			}
 
 			// 2. 服务识别 (如果启用)
@@ -169,7 +352,7 @@ func (s *PortServiceScanner) Run(ctx context.Context, task *model.Task) ([]*mode
 					scanTimeout = DefaultTimeout
 				}
 
-				fp, err := s.gonmapEngine.Scan(ctx, target, p, scanTimeout)
+				fp, err := s.gonmapEngine.Scan(ctx, host, p, scanTimeout)
 				if err == nil && fp != nil {
 					portResult.Service = fp.Service
 					portResult.Product = fp.ProductName
@@ -190,20 +373,69 @@ func (s *PortServiceScanner) Run(ctx context.Context, task *model.Task) ([]*mode
 				CompletedAt: time.Now(),
 			}
 
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}(port)
+			// 上下文取消后丢弃结果，避免调用方停止读取时阻塞
+			select {
+			case out <- result:
+				completed = true
+			case <-ctx.Done():
+			}
+		}(i, hosts[i/len(ports)], ports[i%len(ports)])
 	}
 
+	// 等待进行中的探测结束后才能关闭 out
 	wg.Wait()
-	return results, nil
+	if scanErr == nil {
+		scanErr = ctx.Err()
+	}
+	if tracker != nil {
+		if scanErr == nil {
+			tracker.Remove()
+		} else {
+			tracker.Flush()
+		}
+	}
+
+	stats := run.rttTimeout.Stats()
+	s.probeStatsMu.Lock()
+	s.probeStats = stats
+	s.probeStatsMu.Unlock()
+	logger.Infof("[PortService] task %s finished: probe timeout %v (median RTT %v, %d samples), concurrency %d",
+		task.ID, stats.Timeout, stats.MedianRTT, stats.Samples, run.limiter.CurrentLimit())
+	return scanErr
+}
+
+// ProbeTimeoutStats 获取最近一次结束的扫描的探测超时统计 (最终采用的超时、RTT 中位数与样本数)
+func (s *PortServiceScanner) ProbeTimeoutStats() qos.TimeoutStats {
+	s.probeStatsMu.Lock()
+	defer s.probeStatsMu.Unlock()
+	return s.probeStats
+}
+
+// internalErrorResult 探测过程中 panic 时的结果: 端口状态为 error，与关闭/过滤的端口(不输出结果)区分
+func internalErrorResult(taskID, host string, port int, r interface{}) *model.TaskResult {
+	now := time.Now()
+	return &model.TaskResult{
+		TaskID: taskID,
+		Status: model.TaskStatusFailed,
+		Result: &model.PortServiceResult{
+			IP:            host,
+			AddressFamily: addressFamily(host),
+			Port:          port,
+			Protocol:      "tcp",
+			Status:        PortStatusError,
+			Service:       "unknown",
+		},
+		Error:       fmt.Sprintf("internal error: %v", r),
+		ExecutedAt:  now,
+		CompletedAt: now,
+	}
 }
 
 // isPortOpen 检查端口是否开放 (TCP Connect)
-func (s *PortServiceScanner) isPortOpen(ctx context.Context, ip string, port int, timeout time.Duration) bool {
-	address := fmt.Sprintf("%s:%d", ip, port)
-	d := dialer.Get()
+func (s *PortServiceScanner) isPortOpen(ctx context.Context, ip string, port int, timeout time.Duration, opts dialer.SocketOptions) bool {
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	// 直连时应用套接字选项 (SO_LINGER/SO_REUSEADDR)，走代理时不生效
+	d := dialer.WithSocketOptions(dialer.Get(), opts)
 
 	// 创建带超时的上下文
 	connCtx, cancel := context.WithTimeout(ctx, timeout)
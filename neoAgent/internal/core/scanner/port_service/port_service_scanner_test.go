@@ -2,10 +2,15 @@ package port_service
 
 import (
 	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"neoagent/internal/config"
+	"neoagent/internal/core/lib/network/dialer"
 	"neoagent/internal/core/model"
 	"neoagent/internal/pkg/logger"
 )
@@ -110,3 +115,96 @@ func TestFunctional_LocalScan(t *testing.T) {
 		}
 	}
 }
+
+func TestPortServiceScanner_RunStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	openPort := ln.Addr().(*net.TCPAddr).Port
+
+	task := &model.Task{
+		ID:        "stream-task",
+		Target:    "127.0.0.1",
+		PortRange: strconv.Itoa(openPort),
+		Params:    map[string]interface{}{"service_detect": false},
+	}
+
+	out := make(chan *model.TaskResult)
+	errCh := make(chan error, 1)
+	go func() { errCh <- NewPortServiceScanner().RunStream(context.Background(), task, out) }()
+
+	var got []*model.TaskResult
+	for result := range out {
+		got = append(got, result)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("RunStream failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Result.(*model.PortServiceResult).Port != openPort {
+		t.Fatalf("expected open port %d, got %+v", openPort, got)
+	}
+}
+
+func TestPortServiceScanner_RunStream_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	task := &model.Task{ID: "cancelled-task", Target: "127.0.0.1", PortRange: "1-1000"}
+	out := make(chan *model.TaskResult)
+	err := NewPortServiceScanner().RunStream(ctx, task, out)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// 通道已关闭
+	if _, ok := <-out; ok {
+		t.Fatal("expected out to be closed")
+	}
+}
+
+// 探测超时按扫描独立收敛: 低延迟目标收敛出的超时不会带入下一次扫描，下一次扫描仍从保守的默认值开始
+func TestPortServiceScanner_ProbeTimeoutPerRun(t *testing.T) {
+	const slowRTT = 200 * time.Millisecond
+
+	s := NewPortServiceScanner()
+	var mu sync.Mutex
+	var slowTimeouts []time.Duration
+	s.probe = func(ctx context.Context, ip string, port int, timeout time.Duration, opts dialer.SocketOptions) bool {
+		if ip == "10.0.0.2" {
+			mu.Lock()
+			slowTimeouts = append(slowTimeouts, timeout)
+			mu.Unlock()
+			time.Sleep(slowRTT)
+		}
+		return true
+	}
+
+	// 低延迟目标: 超时收敛到下限
+	fast := &model.Task{ID: "fast-run", Target: "10.0.0.1", PortRange: "1-50"}
+	if _, err := s.Run(context.Background(), fast); err != nil {
+		t.Fatalf("fast run failed: %v", err)
+	}
+	stats := s.ProbeTimeoutStats()
+	if stats.Timeout != minProbeTimeout || stats.Samples != 50 {
+		t.Fatalf("fast run: expected timeout %v after 50 samples, got %+v", minProbeTimeout, stats)
+	}
+
+	// 高延迟目标: 从默认超时开始，只依据本次扫描的 RTT 收敛
+	slow := &model.Task{ID: "slow-run", Target: "10.0.0.2", PortRange: "1-10"}
+	if _, err := s.Run(context.Background(), slow); err != nil {
+		t.Fatalf("slow run failed: %v", err)
+	}
+	for i, timeout := range slowTimeouts {
+		if timeout < 3*slowRTT {
+			t.Fatalf("slow run probe %d used timeout %v, shorter than %v", i, timeout, 3*slowRTT)
+		}
+	}
+	if slowTimeouts[0] != DefaultTimeout {
+		t.Fatalf("slow run should start from %v, got %v", DefaultTimeout, slowTimeouts[0])
+	}
+	stats = s.ProbeTimeoutStats()
+	if stats.Samples != 10 || stats.Timeout < 3*slowRTT {
+		t.Fatalf("slow run: expected 10 samples and timeout >= %v, got %+v", 3*slowRTT, stats)
+	}
+}
@@ -0,0 +1,66 @@
+package port_service
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"neoagent/internal/core/lib/network/dialer"
+	"neoagent/internal/core/model"
+	"neoagent/test/mocklab"
+)
+
+// mocklab 的 RDP 服务只返回 5 字节，模拟按固定长度解析响应的探测在短读时 panic (slice bounds out of range)，
+// 扫描应继续完成，RDP 端口输出内部错误结果，其他端口结果不受影响
+func TestPortServiceScanner_RecoverProbePanic(t *testing.T) {
+	lab, err := mocklab.Start()
+	if err != nil {
+		t.Fatalf("start mocklab: %v", err)
+	}
+	defer lab.Close()
+
+	s := NewPortServiceScanner()
+	s.probe = func(ctx context.Context, ip string, port int, timeout time.Duration, opts dialer.SocketOptions) bool {
+		if port != lab.RDP {
+			return s.isPortOpen(ctx, ip, port, timeout, opts)
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+		if err != nil {
+			return false
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		resp := buf[:n:n]
+		_ = resp[4:11] // TPKT 头(4 字节)之后按 X.224 头读取 7 字节，短读时越界
+
+		return true
+	}
+
+	results, err := s.Run(context.Background(), &model.Task{
+		ID:        "recover-task",
+		Target:    lab.Host,
+		PortRange: strconv.Itoa(lab.SSH) + "," + strconv.Itoa(lab.RDP),
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	byPort := make(map[int]*model.TaskResult)
+	for _, r := range results {
+		byPort[r.Result.(*model.PortServiceResult).Port] = r
+	}
+	if r, ok := byPort[lab.SSH]; !ok || r.Status != model.TaskStatusSuccess {
+		t.Fatalf("SSH port should be reported open, got %+v", r)
+	}
+	r, ok := byPort[lab.RDP]
+	if !ok {
+		t.Fatal("RDP port should be reported after panic")
+	}
+	if r.Status != model.TaskStatusFailed || r.Error == "" || r.Result.(*model.PortServiceResult).Status != PortStatusError {
+		t.Fatalf("RDP port should be marked as internal error, got status=%s error=%q", r.Status, r.Error)
+	}
+}
@@ -0,0 +1,41 @@
+package port_service
+
+import (
+	"neoagent/internal/core/lib/network/dialer"
+	"neoagent/internal/pkg/utils"
+)
+
+// defaultMaxSockets 同时打开的探测连接数上限
+// 与 QoS 并发 (rate) 相互独立: rate 控制发起探测的速度，该上限防止大量慢速连接同时占用本地临时端口
+const defaultMaxSockets = 1024
+
+// defaultSocketOptions 端口探测连接默认关闭时直接发送 RST 并允许地址复用，避免 TIME_WAIT 堆积
+func defaultSocketOptions() dialer.SocketOptions {
+	return dialer.SocketOptions{Linger: true, LingerSec: 0, ReuseAddr: true}
+}
+
+// parseSocketParams 解析连接相关的任务参数
+//   - "max_sockets": int, 同时打开的连接数上限，<=0 时使用 defaultMaxSockets
+//   - "socket_linger": int, SO_LINGER 秒数，默认 0 (RST 关闭)；<0 时不设置，使用系统默认的四次挥手
+//   - "socket_reuse": bool, 是否设置 SO_REUSEADDR，默认 true
+func parseSocketParams(params map[string]interface{}) (int, dialer.SocketOptions) {
+	maxSockets := defaultMaxSockets
+	opts := defaultSocketOptions()
+	if params == nil {
+		return maxSockets, opts
+	}
+
+	if val, ok := params["max_sockets"]; ok {
+		if n := utils.InterfaceToInt(val, 0); n > 0 {
+			maxSockets = n
+		}
+	}
+	if val, ok := params["socket_linger"]; ok {
+		opts.LingerSec = utils.InterfaceToInt(val, opts.LingerSec)
+		opts.Linger = opts.LingerSec >= 0
+	}
+	if v, ok := params["socket_reuse"].(bool); ok {
+		opts.ReuseAddr = v
+	}
+	return maxSockets, opts
+}
@@ -0,0 +1,131 @@
+package port_service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"neoagent/internal/core/lib/network/dialer"
+	"neoagent/internal/core/model"
+)
+
+func TestParseSocketParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     map[string]interface{}
+		maxSockets int
+		opts       dialer.SocketOptions
+	}{
+		{name: "defaults", params: nil, maxSockets: defaultMaxSockets, opts: defaultSocketOptions()},
+		{name: "invalid max_sockets", params: map[string]interface{}{"max_sockets": -5}, maxSockets: defaultMaxSockets, opts: defaultSocketOptions()},
+		{
+			name:       "override",
+			params:     map[string]interface{}{"max_sockets": "256", "socket_linger": 5, "socket_reuse": false},
+			maxSockets: 256,
+			opts:       dialer.SocketOptions{Linger: true, LingerSec: 5},
+		},
+		{
+			name:       "system linger",
+			params:     map[string]interface{}{"socket_linger": -1},
+			maxSockets: defaultMaxSockets,
+			opts:       dialer.SocketOptions{Linger: false, LingerSec: -1, ReuseAddr: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxSockets, opts := parseSocketParams(tt.params)
+			if maxSockets != tt.maxSockets || opts != tt.opts {
+				t.Fatalf("got (%d, %+v), want (%d, %+v)", maxSockets, opts, tt.maxSockets, tt.opts)
+			}
+		})
+	}
+}
+
+// max_sockets 独立于 rate: 并发令牌充足时，同时进行的探测数也不超过上限
+func TestPortServiceScanner_MaxSockets(t *testing.T) {
+	var inFlight, peak int32
+	s, probed := mockProbeScanner(t, func(ctx context.Context, port int) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	})
+
+	task := &model.Task{
+		ID:        "max-sockets",
+		Target:    "10.0.0.1",
+		PortRange: "1-200",
+		Params:    map[string]interface{}{"rate": 200, "max_sockets": 8},
+	}
+	if _, err := collect(t, s, context.Background(), task); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if got := len(probed()); got != 200 {
+		t.Fatalf("probed %d ports, want 200", got)
+	}
+	if peak > 8 {
+		t.Fatalf("peak concurrent probes %d exceeds max_sockets 8", peak)
+	}
+}
+
+// 默认套接字选项下 (RST 关闭 + SO_REUSEADDR) 真实 TCP 探测结果不变
+func TestPortServiceScanner_IsPortOpenWithSocketOptions(t *testing.T) {
+	port := bannerServer(t, "tcp", "127.0.0.1:0", "")
+	s := NewPortServiceScanner()
+	for i := 0; i < 20; i++ {
+		if !s.isPortOpen(context.Background(), "127.0.0.1", port, time.Second, defaultSocketOptions()) {
+			t.Fatalf("probe %d: expected port %d open", i, port)
+		}
+	}
+}
+
+// 同一扫描器并发执行多个任务时，各任务的并发与套接字参数互不影响 (配合 -race 运行)
+func TestPortServiceScanner_ConcurrentRunsKeepOwnParams(t *testing.T) {
+	s := NewPortServiceScanner()
+	var mu sync.Mutex
+	seen := make(map[int]dialer.SocketOptions)
+	s.probe = func(ctx context.Context, ip string, port int, timeout time.Duration, opts dialer.SocketOptions) bool {
+		mu.Lock()
+		seen[port] = opts
+		mu.Unlock()
+		return false
+	}
+
+	tasks := []*model.Task{
+		{ID: "run-a", Target: "10.0.0.1", PortRange: "1-300", Params: map[string]interface{}{"rate": 20, "socket_linger": 5}},
+		{ID: "run-b", Target: "10.0.0.2", PortRange: "301-600", Params: map[string]interface{}{"rate": 500, "socket_reuse": false}},
+	}
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task *model.Task) {
+			defer wg.Done()
+			if _, err := s.Run(context.Background(), task); err != nil {
+				t.Errorf("task %s failed: %v", task.ID, err)
+			}
+		}(task)
+	}
+	wg.Wait()
+
+	wantA := dialer.SocketOptions{Linger: true, LingerSec: 5, ReuseAddr: true}
+	wantB := dialer.SocketOptions{Linger: true, LingerSec: 0, ReuseAddr: false}
+	if len(seen) != 600 {
+		t.Fatalf("probed %d ports, want 600", len(seen))
+	}
+	for port, opts := range seen {
+		want := wantA
+		if port > 300 {
+			want = wantB
+		}
+		if opts != want {
+			t.Fatalf("port %d probed with %+v, want %+v", port, opts, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package port_service
+
+import (
+	"net"
+	"strings"
+
+	"neoagent/internal/pkg/utils"
+)
+
+const (
+	addressFamilyIPv4 = "ipv4"
+	addressFamilyIPv6 = "ipv6"
+)
+
+// expandTargets 解析任务目标: 单个 IPv4/IPv6 地址(IPv6 可带方括号)、CIDR 网段或域名
+// IPv6 网段过大(如 /64)时返回错误，见 utils.CheckCIDRExpandable
+func expandTargets(target string) ([]string, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "/") {
+		return utils.CIDR2IPs(target)
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "["), "]")
+	if ip := net.ParseIP(target); ip != nil {
+		return []string{ip.String()}, nil
+	}
+	return []string{target}, nil
+}
+
+// addressFamily 返回地址族 (ipv4/ipv6)，域名返回空
+func addressFamily(host string) string {
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return addressFamilyIPv4
+	default:
+		return addressFamilyIPv6
+	}
+}
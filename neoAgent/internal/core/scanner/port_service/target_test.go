@@ -0,0 +1,86 @@
+package port_service
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"neoagent/internal/core/lib/network/dialer"
+	"neoagent/internal/core/model"
+)
+
+func TestPortServiceScanner_IPv6Loopback(t *testing.T) {
+	s := NewPortServiceScanner()
+	if err := s.LoadFingerprintFile(filepath.Join("testdata", "service-probes.sample")); err != nil {
+		t.Fatalf("LoadFingerprintFile failed: %v", err)
+	}
+	port := bannerServer(t, "tcp6", "[::1]:0", "SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1\r\n")
+
+	results, err := s.Run(context.Background(), &model.Task{
+		ID:        "ipv6-single",
+		Target:    "[::1]",
+		PortRange: strconv.Itoa(port),
+		Params:    map[string]interface{}{"service_detect": true},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	got := results[0].Result.(*model.PortServiceResult)
+	if got.IP != "::1" || got.AddressFamily != addressFamilyIPv6 || got.Service != "ssh" {
+		t.Fatalf("unexpected result: ip=%q family=%q service=%q", got.IP, got.AddressFamily, got.Service)
+	}
+}
+
+func TestPortServiceScanner_IPv6Range(t *testing.T) {
+	s := NewPortServiceScanner()
+	var mu sync.Mutex
+	probed := make(map[string]int)
+	s.probe = func(ctx context.Context, ip string, port int, timeout time.Duration, opts dialer.SocketOptions) bool {
+		mu.Lock()
+		probed[ip]++
+		mu.Unlock()
+		return ip == "fd00::10" && port == 80
+	}
+
+	task := &model.Task{ID: "ipv6-range", Type: model.TaskTypePortScan, Target: "fd00::/120", PortRange: "22,80"}
+	if err := s.Validate(task); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	results, err := s.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(probed) != 256 || probed["fd00::"] != 2 || probed["fd00::ff"] != 2 {
+		t.Fatalf("expected 256 hosts x 2 ports, got %d hosts", len(probed))
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	got := results[0].Result.(*model.PortServiceResult)
+	if got.IP != "fd00::10" || got.Port != 80 || got.AddressFamily != addressFamilyIPv6 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestPortServiceScanner_ValidateIPv6Target(t *testing.T) {
+	s := NewPortServiceScanner()
+	for target, valid := range map[string]bool{
+		"::1":          true,
+		"[::1]":        true,
+		"10.0.0.0/24":  true,
+		"fd00::/112":   true,
+		"fd00::/64":    false, // 无法逐个枚举
+		"not-a-cidr/8": false,
+	} {
+		err := s.Validate(&model.Task{Target: target, PortRange: "80"})
+		if (err == nil) != valid {
+			t.Errorf("Validate(%q) error = %v, want valid=%v", target, err, valid)
+		}
+	}
+}
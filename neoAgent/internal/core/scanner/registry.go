@@ -0,0 +1,174 @@
+/**
+ * 扫描器注册表
+ * @author: Sun977
+ * @date: 2026.10.16
+ * @description: 扫描能力按 model.TaskType 注册，任务执行时按任务类型查找并分发(含生命周期钩子)，
+ *               新增扫描器只需注册，不需要修改任务执行流程。同一任务类型重复注册视为装配错误。
+ */
+
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"neoagent/internal/core/model"
+)
+
+// Runner 可被注册表调度的扫描能力 (runner.Runner 与之方法集一致)
+type Runner interface {
+	// Name 返回处理的任务类型
+	Name() model.TaskType
+
+	// Run 执行扫描任务，返回结果列表
+	Run(ctx context.Context, task *model.Task) ([]*model.TaskResult, error)
+}
+
+// Registry 扫描器注册表，并发安全
+type Registry struct {
+	mu       sync.RWMutex
+	scanners map[model.TaskType]Runner
+}
+
+// NewRegistry 创建空的扫描器注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		scanners: make(map[model.TaskType]Runner),
+	}
+}
+
+// Register 按任务类型注册扫描器，类型为空或已被注册时返回错误
+func (r *Registry) Register(s Runner) error {
+	if s == nil {
+		return fmt.Errorf("scanner registry: nil scanner")
+	}
+	taskType := s.Name()
+	if taskType == "" {
+		return fmt.Errorf("scanner registry: %T has empty task type", s)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.scanners[taskType]; ok {
+		return fmt.Errorf("scanner registry: task type %s already registered by %T, cannot register %T", taskType, existing, s)
+	}
+	r.scanners[taskType] = s
+	return nil
+}
+
+// MustRegister 注册扫描器，失败时 panic，用于启动阶段装配
+func (r *Registry) MustRegister(scanners ...Runner) {
+	for _, s := range scanners {
+		if err := r.Register(s); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Get 获取任务类型对应的扫描器
+func (r *Registry) Get(taskType model.TaskType) (Runner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if s, ok := r.scanners[taskType]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("no scanner registered for task type: %s", taskType)
+}
+
+// Dispatch 按任务类型查找扫描器并执行任务
+// 扫描器实现了 Scanner 时先 Validate；实现了 Preparer/Cleaner 时在 Run 前后调用，
+// Cleanup 在 Prepare 失败、Run 出错或 panic 时同样执行(panic 在清理后继续向上抛出)
+func (r *Registry) Dispatch(ctx context.Context, task *model.Task) ([]*model.TaskResult, error) {
+	s, err := r.lookup(task)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := s.(Cleaner); ok {
+		defer c.Cleanup(task)
+	}
+	if err := prepare(ctx, s, task); err != nil {
+		return nil, err
+	}
+	return s.Run(ctx, task)
+}
+
+// DispatchStream 按任务类型查找扫描器并流式执行任务，生命周期与 Dispatch 相同
+// 扫描器实现了 Streamer 时结果边扫描边写入 out，否则 Run 完成后逐条写入；
+// out 在返回前关闭且只关闭一次，ctx 取消后不再写入
+func (r *Registry) DispatchStream(ctx context.Context, task *model.Task, out chan<- *model.TaskResult) error {
+	// 交给 Streamer 后由其负责关闭 out，其余路径(包括 panic)在此关闭
+	delegated := false
+	defer func() {
+		if !delegated {
+			close(out)
+		}
+	}()
+
+	s, err := r.lookup(task)
+	if err != nil {
+		return err
+	}
+	if c, ok := s.(Cleaner); ok {
+		defer c.Cleanup(task)
+	}
+	if err := prepare(ctx, s, task); err != nil {
+		return err
+	}
+	if st, ok := s.(Streamer); ok {
+		delegated = true
+		return st.RunStream(ctx, task, out)
+	}
+
+	results, err := s.Run(ctx, task)
+	for _, result := range results {
+		select {
+		case out <- result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// lookup 查找任务对应的扫描器并执行 Validate，校验失败的任务不会进入 Prepare/Cleanup
+func (r *Registry) lookup(task *model.Task) (Runner, error) {
+	if task == nil {
+		return nil, fmt.Errorf("scanner registry: nil task")
+	}
+	s, err := r.Get(task.Type)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := s.(Scanner); ok {
+		if err := v.Validate(task); err != nil {
+			return nil, fmt.Errorf("invalid %s task: %w", task.Type, err)
+		}
+	}
+	return s, nil
+}
+
+// prepare 执行 Prepare 钩子
+func prepare(ctx context.Context, s Runner, task *model.Task) error {
+	if p, ok := s.(Preparer); ok {
+		if err := p.Prepare(ctx, task); err != nil {
+			return fmt.Errorf("prepare %s scanner: %w", task.Type, err)
+		}
+	}
+	return nil
+}
+
+// Types 返回已注册的任务类型(按名称排序)
+func (r *Registry) Types() []model.TaskType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]model.TaskType, 0, len(r.scanners))
+	for t := range r.scanners {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
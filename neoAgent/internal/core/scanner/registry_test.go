@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"neoagent/internal/core/model"
+)
+
+type fakeRunner struct {
+	taskType model.TaskType
+}
+
+func (f *fakeRunner) Name() model.TaskType { return f.taskType }
+
+func (f *fakeRunner) Run(_ context.Context, task *model.Task) ([]*model.TaskResult, error) {
+	return []*model.TaskResult{{TaskID: task.ID, Status: model.TaskStatusSuccess, Result: string(f.taskType)}}, nil
+}
+
+func TestRegistryDispatch(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(&fakeRunner{taskType: model.TaskTypePortScan}, &fakeRunner{taskType: model.TaskTypeOsScan})
+
+	results, err := r.Dispatch(context.Background(), &model.Task{ID: "t1", Type: model.TaskTypeOsScan})
+	if err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Result != string(model.TaskTypeOsScan) {
+		t.Fatalf("Dispatch() dispatched to wrong scanner: %+v", results)
+	}
+
+	if _, err := r.Dispatch(context.Background(), &model.Task{ID: "t2", Type: model.TaskTypeWebScan}); err == nil {
+		t.Fatal("Dispatch() expected error for unregistered task type")
+	}
+}
+
+func TestRegistryDuplicateRegistration(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(&fakeRunner{taskType: model.TaskTypePortScan}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register(&fakeRunner{taskType: model.TaskTypePortScan}); err == nil {
+		t.Fatal("Register() expected error for duplicate task type")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustRegister() expected panic for duplicate task type")
+		}
+	}()
+	r.MustRegister(&fakeRunner{taskType: model.TaskTypePortScan})
+}
+
+// lifecycleScanner 记录生命周期钩子的调用顺序
+type lifecycleScanner struct {
+	validateErr error
+	runPanic    bool
+	calls       []string
+}
+
+func (l *lifecycleScanner) Name() model.TaskType { return model.TaskTypePortScan }
+
+func (l *lifecycleScanner) Validate(*model.Task) error {
+	l.calls = append(l.calls, "validate")
+	return l.validateErr
+}
+
+func (l *lifecycleScanner) Prepare(context.Context, *model.Task) error {
+	l.calls = append(l.calls, "prepare")
+	return nil
+}
+
+func (l *lifecycleScanner) Run(context.Context, *model.Task) ([]*model.TaskResult, error) {
+	l.calls = append(l.calls, "run")
+	if l.runPanic {
+		panic("boom")
+	}
+	return nil, nil
+}
+
+func (l *lifecycleScanner) Cleanup(*model.Task) {
+	l.calls = append(l.calls, "cleanup")
+}
+
+func TestRegistryDispatchLifecycle(t *testing.T) {
+	task := &model.Task{ID: "t1", Type: model.TaskTypePortScan}
+
+	ok := &lifecycleScanner{}
+	r := NewRegistry()
+	r.MustRegister(ok)
+	if _, err := r.Dispatch(context.Background(), task); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if got := strings.Join(ok.calls, ","); got != "validate,prepare,run,cleanup" {
+		t.Fatalf("lifecycle = %s", got)
+	}
+
+	invalid := &lifecycleScanner{validateErr: errors.New("target is required")}
+	r = NewRegistry()
+	r.MustRegister(invalid)
+	if _, err := r.Dispatch(context.Background(), task); err == nil {
+		t.Fatal("Dispatch() expected validation error")
+	}
+	if got := strings.Join(invalid.calls, ","); got != "validate" {
+		t.Fatalf("invalid task should not run, lifecycle = %s", got)
+	}
+
+	panicking := &lifecycleScanner{runPanic: true}
+	r = NewRegistry()
+	r.MustRegister(panicking)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Dispatch() expected panic to propagate")
+			}
+		}()
+		_, _ = r.Dispatch(context.Background(), task)
+	}()
+	if got := strings.Join(panicking.calls, ","); got != "validate,prepare,run,cleanup" {
+		t.Fatalf("cleanup must run on panic, lifecycle = %s", got)
+	}
+}
+
+func TestRegistryDispatchStreamFallback(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(&fakeRunner{taskType: model.TaskTypeOsScan})
+
+	out := make(chan *model.TaskResult, 1)
+	if err := r.DispatchStream(context.Background(), &model.Task{ID: "t1", Type: model.TaskTypeOsScan}, out); err != nil {
+		t.Fatalf("DispatchStream() error = %v", err)
+	}
+	var got []*model.TaskResult
+	for result := range out {
+		got = append(got, result)
+	}
+	if len(got) != 1 {
+		t.Fatalf("DispatchStream() results = %d, want 1", len(got))
+	}
+
+	// 未注册的类型同样关闭通道
+	out = make(chan *model.TaskResult)
+	if err := r.DispatchStream(context.Background(), &model.Task{Type: model.TaskTypeWebScan}, out); err == nil {
+		t.Fatal("DispatchStream() expected error for unregistered task type")
+	}
+	if _, ok := <-out; ok {
+		t.Fatal("DispatchStream() must close out on error")
+	}
+}
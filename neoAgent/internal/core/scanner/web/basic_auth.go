@@ -0,0 +1,143 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"neoagent/internal/core/model"
+	"neoagent/internal/core/scanner/brute"
+	"neoagent/internal/pkg/logger"
+)
+
+const basicAuthRequestTimeout = 5 * time.Second // 单次认证请求超时
+
+// errNoBasicChallenge 目标无需认证或使用的不是 Basic 认证，不进行凭据探测
+var errNoBasicChallenge = errors.New("no basic auth challenge")
+
+// basicAuthEnabled 任务是否启用 HTTP Basic 认证探测 (Params["basic_auth"] = true)
+func basicAuthEnabled(task *model.Task) bool {
+	enabled, _ := task.Params["basic_auth"].(bool)
+	return enabled
+}
+
+// probeTaskBasicAuth 按任务参数对 targetURL 进行 Basic 认证凭据探测
+// 凭据来自 Params["users"]/Params["passwords"] (与爆破任务一致，未指定时使用内置字典)，受 task.Timeout 约束
+// 目标无 Basic 挑战时返回 nil
+func probeTaskBasicAuth(ctx context.Context, task *model.Task, targetURL string) *model.WebBasicAuth {
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, task.Timeout)
+		defer cancel()
+	}
+
+	creds := brute.NewDictManager().Generate(task.Params, brute.AuthModeUserPass)
+	res, err := probeBasicAuth(ctx, targetURL, creds)
+	if err != nil {
+		if !errors.Is(err, errNoBasicChallenge) {
+			logger.Warnf("[WebScanner] Basic auth probe for %s aborted: %v", targetURL, err)
+		}
+		return res
+	}
+	if res.Success {
+		logger.Infof("[WebScanner] Basic auth credential found for %s: %s", targetURL, res.Username)
+	}
+	return res
+}
+
+// probeBasicAuth 探测 HTTP Basic 认证
+// 1. 先发送不带凭据的请求: 200 等非 401 响应视为无需认证，401 但不是 Basic 挑战 (如 Digest/Bearer) 也不探测，均返回 errNoBasicChallenge
+// 2. 401 + `WWW-Authenticate: Basic` 时依次尝试 creds，2xx 即成功并停止，401 继续下一个
+// 连接错误或 ctx 结束时立即返回错误，同时返回已完成部分的结果
+func probeBasicAuth(ctx context.Context, targetURL string, creds []brute.Auth) (*model.WebBasicAuth, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			Proxy:             http.ProxyFromEnvironment,
+			DisableKeepAlives: true,
+		},
+		Timeout: basicAuthRequestTimeout,
+		// 不跟随重定向，认证结果以首个响应为准
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := basicAuthRequest(ctx, client, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, errNoBasicChallenge
+	}
+	realm, ok := basicAuthRealm(resp.Header.Values("WWW-Authenticate"))
+	if !ok {
+		return nil, errNoBasicChallenge
+	}
+
+	res := &model.WebBasicAuth{Realm: realm}
+	for i := range creds {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		resp, err := basicAuthRequest(ctx, client, targetURL, &creds[i])
+		if err != nil {
+			return res, err
+		}
+		res.Attempts++
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			res.Success = true
+			res.Username = creds[i].Username
+			res.Password = creds[i].Password
+			return res, nil
+		}
+		// 401 为凭据错误；403 等其他状态码同样视为未通过认证
+	}
+	return res, nil
+}
+
+// basicAuthRequest 发送一次 GET 请求，auth 为 nil 时不携带凭据
+// 只关心状态码与响应头，响应体丢弃
+func basicAuthRequest(ctx context.Context, client *http.Client, targetURL string, auth *brute.Auth) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	if auth != nil {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", brute.ErrConnectionFailed, err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return resp, nil
+}
+
+// basicAuthRealm 从 WWW-Authenticate 头中查找 Basic 挑战并提取 realm
+func basicAuthRealm(challenges []string) (string, bool) {
+	for _, c := range challenges {
+		scheme, params, _ := strings.Cut(strings.TrimSpace(c), " ")
+		if !strings.EqualFold(scheme, "Basic") {
+			continue
+		}
+		if _, realm, found := strings.Cut(params, "realm="); found {
+			if strings.HasPrefix(realm, `"`) {
+				realm, _, _ = strings.Cut(realm[1:], `"`)
+			} else {
+				realm, _, _ = strings.Cut(realm, ",")
+			}
+			return realm, true
+		}
+		return "", true
+	}
+	return "", false
+}
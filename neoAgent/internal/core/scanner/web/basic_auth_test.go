@@ -0,0 +1,122 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neoagent/internal/core/model"
+	"neoagent/internal/core/scanner/brute"
+	"neoagent/test/mocklab"
+)
+
+func TestProbeBasicAuth_MockLab(t *testing.T) {
+	lab, err := mocklab.Start()
+	if err != nil {
+		t.Fatalf("start mocklab: %v", err)
+	}
+	defer lab.Close()
+
+	targetURL := "http://" + lab.Addr(lab.HTTP)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 正确凭据位于列表中间: 找到后立即停止
+	creds := []brute.Auth{
+		{Username: "admin", Password: "admin"},
+		{Username: mocklab.HTTPUser, Password: "123456"},
+		{Username: mocklab.HTTPUser, Password: mocklab.Password},
+		{Username: "root", Password: mocklab.Password},
+	}
+	res, err := probeBasicAuth(ctx, targetURL, creds)
+	if err != nil {
+		t.Fatalf("probeBasicAuth failed: %v", err)
+	}
+	if !res.Success || res.Username != mocklab.HTTPUser || res.Password != mocklab.Password {
+		t.Fatalf("expected %s:%s found, got %+v", mocklab.HTTPUser, mocklab.Password, res)
+	}
+	if res.Attempts != 3 {
+		t.Errorf("expected probing to stop after 3 attempts, got %d", res.Attempts)
+	}
+	if res.Realm != "Restricted" {
+		t.Errorf("expected realm Restricted, got %q", res.Realm)
+	}
+
+	// 只有错误凭据: 不报告任何成功
+	res, err = probeBasicAuth(ctx, targetURL, creds[:2])
+	if err != nil {
+		t.Fatalf("probeBasicAuth failed: %v", err)
+	}
+	if res.Success || res.Username != "" || res.Attempts != 2 {
+		t.Fatalf("expected no credential found after 2 attempts, got %+v", res)
+	}
+}
+
+func TestProbeBasicAuth_NoChallenge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bearer" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintln(w, "open")
+	}))
+	defer ts.Close()
+
+	creds := []brute.Auth{{Username: "admin", Password: "admin"}}
+	for _, path := range []string{"/", "/bearer"} {
+		res, err := probeBasicAuth(context.Background(), ts.URL+path, creds)
+		if !errors.Is(err, errNoBasicChallenge) || res != nil {
+			t.Errorf("%s: expected errNoBasicChallenge, got res=%+v err=%v", path, res, err)
+		}
+	}
+}
+
+func TestProbeBasicAuth_ConnectionError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("Failed to listen")
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	_, err = probeBasicAuth(context.Background(), "http://"+addr, []brute.Auth{{Username: "admin"}})
+	if !errors.Is(err, brute.ErrConnectionFailed) {
+		t.Fatalf("expected ErrConnectionFailed, got %v", err)
+	}
+}
+
+func TestFallbackScan_BasicAuth(t *testing.T) {
+	lab, err := mocklab.Start()
+	if err != nil {
+		t.Fatalf("start mocklab: %v", err)
+	}
+	defer lab.Close()
+
+	task := &model.Task{
+		ID:      "basic-auth",
+		Target:  lab.Host,
+		Timeout: 10 * time.Second,
+		Params: map[string]interface{}{
+			"basic_auth": true,
+			"users":      "admin," + mocklab.HTTPUser,
+			"passwords":  "wrong," + mocklab.Password,
+		},
+	}
+	results, err := NewWebScanner().fallbackScan(context.Background(), task, "http://"+lab.Addr(lab.HTTP), time.Now())
+	if err != nil {
+		t.Fatalf("fallbackScan failed: %v", err)
+	}
+	res := results[0].Result.(*model.WebResult)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 before authentication, got %d", res.StatusCode)
+	}
+	if res.BasicAuth == nil || !res.BasicAuth.Success || res.BasicAuth.Username != mocklab.HTTPUser {
+		t.Fatalf("expected credential found, got %+v", res.BasicAuth)
+	}
+}
@@ -343,6 +343,11 @@ func (s *WebScanner) Run(ctx context.Context, task *model.Task) (results []*mode
 		},
 	}
 
+	// 11. HTTP Basic 认证探测 (如果启用)
+	if basicAuthEnabled(task) && finalStatusCode == http.StatusUnauthorized {
+		result.Result.(*model.WebResult).BasicAuth = probeTaskBasicAuth(ctx, task, targetURL)
+	}
+
 	s.limiter.OnSuccess()
 	return []*model.TaskResult{result}, nil
 }
@@ -568,6 +573,11 @@ func (s *WebScanner) fallbackScan(ctx context.Context, task *model.Task, targetU
 		},
 	}
 
+	// 7. HTTP Basic 认证探测 (如果启用)
+	if basicAuthEnabled(task) && resp.StatusCode == http.StatusUnauthorized {
+		result.Result.(*model.WebResult).BasicAuth = probeTaskBasicAuth(ctx, task, targetURL)
+	}
+
 	logger.Infof("[WebScanner] Fallback scan success for %s", targetURL)
 	return []*model.TaskResult{result}, nil
 }
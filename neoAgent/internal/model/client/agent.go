@@ -73,9 +73,12 @@ type NetworkIOMetrics struct {
 
 // AgentRegisterResponseData 注册响应数据
 type AgentRegisterResponseData struct {
-	AgentID   string `json:"agent_id"`
-	AuthToken string `json:"token"`
-	Status    string `json:"status"`
+	AgentID     string    `json:"agent_id"`
+	AuthToken   string    `json:"token"`
+	TokenExpiry time.Time `json:"token_expiry"` // Token过期时间，旧版本 Master 不返回时为零值
+	Status      string    `json:"status"`
+
+	HeartbeatInterval int `json:"heartbeat_interval"` // Master 为本Agent配置的心跳间隔(秒)，0 表示使用默认值
 }
 
 // AgentRegisterResponse 注册响应
@@ -85,6 +88,20 @@ type AgentRegisterResponse struct {
 	Data   AgentRegisterResponseData `json:"data"`
 }
 
+// TokenRefreshResponseData Token 刷新响应数据
+type TokenRefreshResponseData struct {
+	AgentID     string    `json:"agent_id"`
+	AuthToken   string    `json:"token"`
+	TokenExpiry time.Time `json:"token_expiry"`
+}
+
+// TokenRefreshResponse Token 刷新响应
+type TokenRefreshResponse struct {
+	Code   int                      `json:"code"`
+	Status string                   `json:"status"`
+	Data   TokenRefreshResponseData `json:"data"`
+}
+
 // AgentRegisterRequest Agent注册请求
 type AgentRegisterRequest struct {
 	Hostname    string   `json:"hostname"`
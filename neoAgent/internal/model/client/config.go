@@ -7,7 +7,10 @@
  */
 package client
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // ==================== 配置相关 ====================
 
@@ -62,4 +65,50 @@ type ConfigSyncResponse struct {
 	NeedRestart   bool                   `json:"need_restart"`   // 是否需要重启
 	Message       string                 `json:"message"`        // 响应消息
 	Timestamp     time.Time              `json:"timestamp"`      // 响应时间戳
-}
\ No newline at end of file
+}
+
+// RemoteConfig Master 下发的Agent配置 (字段与 Master 端 AgentConfigResponse 对应，时间单位为秒)
+// Version 每次在 Master 端修改时 +1，Agent 心跳上报已应用的版本用于对账
+type RemoteConfig struct {
+	AgentID             string                 `json:"agent_id"`
+	Version             int                    `json:"version"`
+	HeartbeatInterval   int                    `json:"heartbeat_interval"`
+	TaskPollInterval    int                    `json:"task_poll_interval"`
+	MaxConcurrentTasks  int                    `json:"max_concurrent_tasks"`
+	PluginConfig        map[string]interface{} `json:"plugin_config"`
+	LogLevel            string                 `json:"log_level"`
+	Timeout             int                    `json:"timeout"`
+	TokenExpiryDuration int                    `json:"token_expiry_duration"`
+	TokenNeverExpire    bool                   `json:"token_never_expire"`
+	IsActive            bool                   `json:"is_active"`
+}
+
+// Validate 校验下发的配置，任一字段不合法则整份配置都不应用
+func (c *RemoteConfig) Validate() error {
+	switch {
+	case c.Version < 1:
+		return fmt.Errorf("invalid config version: %d", c.Version)
+	case c.HeartbeatInterval < 5 || c.HeartbeatInterval > 300:
+		return fmt.Errorf("invalid heartbeat_interval: %d", c.HeartbeatInterval)
+	case c.TaskPollInterval < 1 || c.TaskPollInterval > 60:
+		return fmt.Errorf("invalid task_poll_interval: %d", c.TaskPollInterval)
+	case c.MaxConcurrentTasks < 1 || c.MaxConcurrentTasks > 50:
+		return fmt.Errorf("invalid max_concurrent_tasks: %d", c.MaxConcurrentTasks)
+	case c.Timeout < 30 || c.Timeout > 3600:
+		return fmt.Errorf("invalid timeout: %d", c.Timeout)
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid log_level: %q", c.LogLevel)
+	}
+	return nil
+}
+
+// ConfigPullResponse 拉取配置响应 (版本一致时 Data 为空)
+type ConfigPullResponse struct {
+	Code    int           `json:"code"`
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Data    *RemoteConfig `json:"data"`
+}
@@ -29,6 +29,8 @@ type HeartbeatRequest struct {
 	AgentID string            `json:"agent_id"`
 	Status  string            `json:"status"`
 	Metrics *HeartbeatMetrics `json:"metrics,omitempty"`
+
+	ConfigVersion *int `json:"config_version,omitempty"` // 当前已应用的 Master 下发配置版本，0 表示尚未应用过
 }
 
 // HeartbeatResponseData 心跳响应数据
@@ -38,6 +40,7 @@ type HeartbeatResponseData struct {
 	Message      string            `json:"message"`
 	Timestamp    time.Time         `json:"timestamp"`
 	RuleVersions map[string]string `json:"rule_versions,omitempty"` // 规则版本信息
+	Config       *RemoteConfig     `json:"config,omitempty"`        // 配置版本落后时 Master 下发的最新配置
 }
 
 // HeartbeatResponse 心跳响应
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ResultBatch 扫描结果批次 (POST /api/v1/agent/results)
+// BatchID 在批次生成时确定，失败重放时保持不变，Master 据此对重复上报去重
+type ResultBatch struct {
+	BatchID   string       `json:"batch_id"`
+	AgentID   string       `json:"agent_id"`
+	Results   []ResultItem `json:"results"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// ResultItem 单条扫描结果，对应核心模型 TaskResult
+type ResultItem struct {
+	TaskID      string          `json:"task_id"`
+	TaskType    string          `json:"task_type"` // 核心任务类型 (port_scan/web_scan/...)，决定 Result 的结构
+	Status      string          `json:"status"`
+	Target      string          `json:"target,omitempty"` // 扫描目标，Master 据此推断目标类型
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	ExecutedAt  time.Time       `json:"executed_at"`
+	CompletedAt time.Time       `json:"completed_at"`
+}
+
+// ResultBatchResponse 结果批次上报响应
+type ResultBatchResponse struct {
+	Code   int                     `json:"code"`
+	Status string                  `json:"status"`
+	Data   ResultBatchResponseData `json:"data"`
+}
+
+// ResultBatchResponseData 结果批次处理情况
+type ResultBatchResponseData struct {
+	BatchID     string            `json:"batch_id"`
+	Accepted    int               `json:"accepted"`    // 成功入库的条数
+	Skipped     int               `json:"skipped"`     // 执行失败、无结果数据的条数
+	Duplicate   bool              `json:"duplicate"`   // 批次已处理过 (重放)
+	Quarantined []QuarantinedItem `json:"quarantined"` // 被隔离的异常条目 (格式错误)
+	Rejected    []QuarantinedItem `json:"rejected"`    // 被拒绝的条目 (任务不存在/不属于本 Agent/已结束)
+}
+
+// QuarantinedItem 被 Master 隔离或拒绝的结果条目
+type QuarantinedItem struct {
+	Index  int    `json:"index"` // 在批次 Results 中的下标
+	TaskID string `json:"task_id"`
+	Reason string `json:"reason"`
+}
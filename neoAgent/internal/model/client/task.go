@@ -11,6 +11,7 @@ type Task struct {
 	TaskID      string `json:"task_id"`
 	ProjectID   int    `json:"project_id"`
 	TaskType    string `json:"task_type"`
+	Status      string `json:"status"` // Master 端任务状态 (assigned/running/canceling)，canceling 表示需要中止执行
 	ToolName    string `json:"tool_name"`
 	ToolParams  string `json:"tool_params"`
 	InputTarget string `json:"input_target"` // JSON string
@@ -8,12 +8,16 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"neoagent/internal/model/client"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -25,6 +29,9 @@ type HTTPClient interface {
 	// RegisterAgent 注册Agent
 	RegisterAgent(ctx context.Context, req *client.AgentRegisterRequest) (*client.AgentRegisterResponse, error)
 
+	// RefreshToken 在 Token 过期前换取新 Token
+	RefreshToken(ctx context.Context) (*client.TokenRefreshResponse, error)
+
 	// SendHeartbeat 发送心跳
 	SendHeartbeat(ctx context.Context, req *client.HeartbeatRequest) (*client.HeartbeatResponse, error)
 
@@ -33,6 +40,32 @@ type HTTPClient interface {
 
 	// ReportTaskStatus 上报任务状态/结果
 	ReportTaskStatus(ctx context.Context, agentID, taskID string, report *client.TaskStatusReport) (*client.TaskStatusResponse, error)
+
+	// SendResultBatch 上报扫描结果批次 (gzip 压缩，单次请求不重试，由调用方负责退避重试)
+	SendResultBatch(ctx context.Context, batch *client.ResultBatch) (*client.ResultBatchResponse, error)
+
+	// FetchConfig 拉取最新配置，currentVersion 为当前已应用版本，一致时响应 Data 为空
+	FetchConfig(ctx context.Context, currentVersion int) (*client.ConfigPullResponse, error)
+}
+
+// StatusError Master 返回的非 2xx 响应
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// ErrCodeTokenExpired Master 返回的 Token 过期错误码，Agent 收到后需重新注册
+const ErrCodeTokenExpired = "AGENT_TOKEN_EXPIRED"
+
+// IsTokenExpired 判断错误是否为 Master 拒绝了已过期的 Token
+func IsTokenExpired(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized &&
+		strings.Contains(statusErr.Body, ErrCodeTokenExpired)
 }
 
 // httpClient HTTP客户端实现
@@ -40,6 +73,7 @@ type httpClient struct {
 	client     *http.Client
 	baseURL    string
 	authToken  string
+	tokenMu    sync.RWMutex // 保护 authToken，Token 刷新与其他请求并发
 	userAgent  string
 	maxRetries int
 	retryDelay time.Duration
@@ -60,7 +94,16 @@ func NewHTTPClient(baseURL string) HTTPClient {
 
 // SetAuthToken 设置认证令牌
 func (c *httpClient) SetAuthToken(token string) {
+	c.tokenMu.Lock()
 	c.authToken = token
+	c.tokenMu.Unlock()
+}
+
+// currentAuthToken 当前认证令牌
+func (c *httpClient) currentAuthToken() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.authToken
 }
 
 // RegisterAgent 注册Agent
@@ -78,6 +121,21 @@ func (c *httpClient) RegisterAgent(ctx context.Context, req *client.AgentRegiste
 	return &result, nil
 }
 
+// RefreshToken 刷新Token
+func (c *httpClient) RefreshToken(ctx context.Context) (*client.TokenRefreshResponse, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/agent/token/refresh", nil)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result client.TokenRefreshResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode refresh token response: %w", err)
+	}
+	return &result, nil
+}
+
 // SendHeartbeat 发送心跳
 func (c *httpClient) SendHeartbeat(ctx context.Context, req *client.HeartbeatRequest) (*client.HeartbeatResponse, error) {
 	resp, err := c.doRequest(ctx, "POST", "/api/v1/agent/heartbeat", req)
@@ -125,6 +183,62 @@ func (c *httpClient) ReportTaskStatus(ctx context.Context, agentID, taskID strin
 	return &result, nil
 }
 
+// FetchConfig 拉取最新配置
+func (c *httpClient) FetchConfig(ctx context.Context, currentVersion int) (*client.ConfigPullResponse, error) {
+	url := fmt.Sprintf("/api/v1/agent/config?version=%d", currentVersion)
+	resp, err := c.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch config request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result client.ConfigPullResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode fetch config response: %w", err)
+	}
+	return &result, nil
+}
+
+// SendResultBatch 上报扫描结果批次
+func (c *httpClient) SendResultBatch(ctx context.Context, batch *client.ResultBatch) (*client.ResultBatchResponse, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(batch); err != nil {
+		return nil, fmt.Errorf("marshal result batch: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress result batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/agent/results", &buf)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("User-Agent", c.userAgent)
+	if token := c.currentAuthToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send result batch request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var result client.ResultBatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode result batch response: %w", err)
+	}
+	return &result, nil
+}
+
 // doRequest 执行HTTP请求
 func (c *httpClient) doRequest(ctx context.Context, method, url string, data interface{}) (*http.Response, error) {
 	fullURL := c.baseURL + url
@@ -145,8 +259,8 @@ func (c *httpClient) doRequest(ctx context.Context, method, url string, data int
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", c.userAgent)
-	if c.authToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	if token := c.currentAuthToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
 	var resp *http.Response
@@ -171,7 +285,7 @@ func (c *httpClient) doRequest(ctx context.Context, method, url string, data int
 		// Try to read body for error message
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("http request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	return resp, nil
@@ -10,6 +10,7 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -73,8 +74,10 @@ func StringToFloat64(str string, defaultValue float64) float64 {
 // StringToBool 字符串转布尔值，支持多种格式
 // 参数: str - 待转换的字符串, defaultValue - 转换失败时的默认值
 // 返回: 转换后的布尔值
-// 支持的true值: "true", "1", "yes", "on", "enabled"
-// 支持的false值: "false", "0", "no", "off", "disabled"
+// 忽略大小写和首尾空白，支持的取值:
+// true值: "true", "t", "1", "yes", "y", "on", "enable", "enabled"
+// false值: "false", "f", "0", "no", "n", "off", "disable", "disabled"
+// 空字符串及其他取值返回默认值
 func StringToBool(str string, defaultValue bool) bool {
 	if str == "" {
 		return defaultValue
@@ -82,9 +85,9 @@ func StringToBool(str string, defaultValue bool) bool {
 
 	str = strings.ToLower(strings.TrimSpace(str))
 	switch str {
-	case "true", "1", "yes", "on", "enabled":
+	case "true", "t", "1", "yes", "y", "on", "enable", "enabled":
 		return true
-	case "false", "0", "no", "off", "disabled":
+	case "false", "f", "0", "no", "n", "off", "disable", "disabled":
 		return false
 	default:
 		return defaultValue
@@ -637,6 +640,20 @@ func SafeStringToFloat64(str string, min, max float64) (float64, error) {
 	return value, nil
 }
 
+// SafeInterfaceToInt 安全的接口转整数（带验证）
+// 参数: value - 接口值, min - 最小值, max - 最大值
+// 返回: 转换后的整数值和错误信息，支持的类型同 InterfaceToInt
+func SafeInterfaceToInt(value interface{}, min, max int) (int, error) {
+	result, err := interfaceToInt(value)
+	if err != nil {
+		return 0, err
+	}
+	if result < min || result > max {
+		return 0, fmt.Errorf("值%d超出范围[%d, %d]", result, min, max)
+	}
+	return result, nil
+}
+
 // ==================== JSON数组转换 ====================
 
 // JSONArrayToStringSlice JSON数组字符串转字符串切片
@@ -777,6 +794,96 @@ func InterfaceToString(value interface{}) string {
 	return fmt.Sprintf("%v", value)
 }
 
+// InterfaceToInt 接口转整数，支持默认值
+// 参数: value - 接口值, defaultValue - 无法转换时的默认值
+// 返回: 转换后的整数值
+// 支持 int/uint 各类整型、float32/float64 (向零截断)、json.Number 和数字字符串 (如 "100"、"1.5")
+// 溢出、NaN/Inf 以及其他类型均返回默认值。JSON 解码得到的 float64 参数可直接使用
+func InterfaceToInt(value interface{}, defaultValue int) int {
+	if result, err := interfaceToInt(value); err == nil {
+		return result
+	}
+	return defaultValue
+}
+
+// interfaceToInt InterfaceToInt 与 SafeInterfaceToInt 的公共转换逻辑
+func interfaceToInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, fmt.Errorf("值不能为空")
+	case int:
+		return v, nil
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		if v < math.MinInt || v > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case uint:
+		if v > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case uint8:
+		return int(v), nil
+	case uint16:
+		return int(v), nil
+	case uint32:
+		if uint64(v) > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case uint64:
+		if v > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case float32:
+		return floatToInt(float64(v))
+	case float64:
+		return floatToInt(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return interfaceToInt(i)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("'%s'不是有效数字: %v", v, err)
+		}
+		return floatToInt(f)
+	case string:
+		str := strings.TrimSpace(v)
+		if i, err := strconv.Atoi(str); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return 0, fmt.Errorf("字符串'%s'不是有效数字", v)
+		}
+		return floatToInt(f)
+	default:
+		return 0, fmt.Errorf("不支持的类型%T", value)
+	}
+}
+
+// floatToInt 浮点数向零截断为整数，NaN/Inf 或超出int范围时返回错误
+func floatToInt(f float64) (int, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("值%v不是有限数", f)
+	}
+	t := math.Trunc(f)
+	// float64(math.MaxInt) 会进位为 2^63，因此上界使用 >=
+	if t < math.MinInt || t >= math.MaxInt {
+		return 0, fmt.Errorf("值%v超出int范围", f)
+	}
+	return int(t), nil
+}
+
 // IsZeroValue 检查值是否为零值
 // 参数: value - 待检查的值
 // 返回: 是否为零值
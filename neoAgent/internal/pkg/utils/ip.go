@@ -126,19 +126,36 @@ func isIPEqual(clientIP net.IP, targetIPStr string) bool {
 	return clientIP.Equal(targetIP)
 }
 
+// MaxIPv6CIDRHostBits IPv6 网段允许展开的最大主机位数 (即最大 /112，65536 个地址)
+// 更大的 IPv6 网段(如 /64)无法逐个枚举，需提供明确的主机列表
+const MaxIPv6CIDRHostBits = 16
+
+// CheckCIDRExpandable 检查网段是否允许展开为主机列表，IPv6 网段主机位超过 MaxIPv6CIDRHostBits 时返回错误
+func CheckCIDRExpandable(ipNet *net.IPNet) error {
+	if ipNet.IP.To4() != nil {
+		return nil
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits-ones > MaxIPv6CIDRHostBits {
+		return fmt.Errorf("IPv6 CIDR %s is too large to enumerate (max /%d), provide an explicit host list", ipNet.String(), bits-MaxIPv6CIDRHostBits)
+	}
+	return nil
+}
+
 // CIDR2IPs 将 CIDR 转换为 IP 列表
 // 示例: "192.168.0.0/30" -> ["192.168.0.0", "192.168.0.1", "192.168.0.2", "192.168.0.3"]
-// 注意: 仅支持 IPv4, 且不建议用于过大的网段
+// 支持 IPv4 与 IPv6 (IPv6 最大 /112，见 CheckCIDRExpandable)，不建议用于过大的网段
 func CIDR2IPs(cidr string) ([]string, error) {
 	ip, ipNet, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid CIDR format: %w", err)
 	}
+	if err := CheckCIDRExpandable(ipNet); err != nil {
+		return nil, err
+	}
 
-	// 仅支持 IPv4
-	ip = ip.To4()
-	if ip == nil {
-		return nil, fmt.Errorf("only IPv4 is supported for CIDR expansion")
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
 	}
 
 	var ips []string
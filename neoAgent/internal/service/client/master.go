@@ -32,19 +32,42 @@ type MasterService interface {
 	// ReportTask 上报任务状态/结果
 	ReportTask(ctx context.Context, taskID string, status string, result string, errorMsg string) error
 
+	// SendResultBatch 上报扫描结果批次
+	SendResultBatch(ctx context.Context, batch *modelComm.ResultBatch) (*modelComm.ResultBatchResponse, error)
+
 	// GetAgentID 获取Agent ID
 	GetAgentID() string
+
+	// GetRemoteConfig 获取当前已应用的 Master 下发配置，尚未应用过时返回 nil (使用本地配置)
+	GetRemoteConfig() *modelComm.RemoteConfig
+
+	// OnConfigChange 设置配置变更处理函数，返回错误时新配置不生效，下次心跳对账时重试
+	OnConfigChange(handler func(cfg *modelComm.RemoteConfig) error)
 }
 
+// defaultHeartbeatInterval Master 未下发心跳间隔时使用的默认值
+const defaultHeartbeatInterval = 30 * time.Second
+
+// tokenRefreshLead Token 剩余有效期不足该值(且不少于两个心跳间隔)时，在心跳前刷新
+const tokenRefreshLead = 10 * time.Minute
+
 // masterService Master通信服务实现
 type masterService struct {
-	client    httpclient.HTTPClient
-	agentID   string
-	token     string
-	status    string
-	mu        sync.RWMutex
-	stopChan  chan struct{}
-	taskStats struct {
+	client   httpclient.HTTPClient
+	agentID  string
+	token    string
+	expiry   time.Time                       // Token 过期时间，Master 未返回时为零值(不主动刷新)
+	register *modelComm.AgentRegisterRequest // 注册请求，Token 过期时用于重新注册
+	status   string
+	interval time.Duration // 心跳间隔，注册时由 Master 下发
+	mu       sync.RWMutex
+	stopChan chan struct{}
+
+	config        *modelComm.RemoteConfig                 // 当前已应用的 Master 下发配置
+	configMu      sync.Mutex                              // 串行化配置应用，保证校验、处理、替换整体完成
+	configHandler func(cfg *modelComm.RemoteConfig) error // 配置变更处理函数
+	intervalCh    chan time.Duration                      // 心跳间隔变更通知
+	taskStats     struct {
 		Running   int
 		Completed int
 		Failed    int
@@ -54,9 +77,11 @@ type masterService struct {
 // NewMasterService 创建Master通信服务实例
 func NewMasterService(baseURL string) MasterService {
 	return &masterService{
-		client:   httpclient.NewHTTPClient(baseURL),
-		status:   "offline",
-		stopChan: make(chan struct{}),
+		client:     httpclient.NewHTTPClient(baseURL),
+		status:     "offline",
+		interval:   defaultHeartbeatInterval,
+		stopChan:   make(chan struct{}),
+		intervalCh: make(chan time.Duration, 1),
 	}
 }
 
@@ -77,18 +102,30 @@ func (s *masterService) Register(ctx context.Context, req *modelComm.AgentRegist
 	s.mu.Lock()
 	s.agentID = resp.Data.AgentID
 	s.token = resp.Data.AuthToken
+	s.expiry = resp.Data.TokenExpiry
+	s.register = req
 	s.status = "online"
+	if resp.Data.HeartbeatInterval > 0 {
+		s.interval = time.Duration(resp.Data.HeartbeatInterval) * time.Second
+	}
 	s.client.SetAuthToken(s.token)
 	s.mu.Unlock()
 
 	logger.LogSystemEvent("MasterService", "Register", fmt.Sprintf("Registered successfully. AgentID: %s", s.agentID), logger.InfoLevel, nil)
+
+	// 注册后立即拉取一次配置，不必等到第一次心跳；失败不影响注册，心跳对账时会再次下发
+	s.syncConfig(ctx)
 	return nil
 }
 
-// StartHeartbeat 开启心跳上报
+// StartHeartbeat 开启心跳上报，按注册时 Master 下发的间隔上报
 func (s *masterService) StartHeartbeat(ctx context.Context) {
+	s.mu.RLock()
+	interval := s.interval
+	s.mu.RUnlock()
+
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
@@ -97,6 +134,8 @@ func (s *masterService) StartHeartbeat(ctx context.Context) {
 				return
 			case <-s.stopChan:
 				return
+			case d := <-s.intervalCh:
+				ticker.Reset(d)
 			case <-ticker.C:
 				s.sendHeartbeat(ctx)
 			}
@@ -110,12 +149,18 @@ func (s *masterService) sendHeartbeat(ctx context.Context) {
 	agentID := s.agentID
 	status := s.status
 	stats := s.taskStats
+	configVersion := s.configVersionLocked()
 	s.mu.RUnlock()
 
 	if agentID == "" {
 		return
 	}
 
+	// Token 临近过期时先续期，过期则重新注册
+	if !s.ensureToken(ctx) {
+		return
+	}
+
 	// Collect real system metrics
 	sysMetrics, err := monitor.GetSystemMetrics()
 	if err != nil {
@@ -144,13 +189,18 @@ func (s *masterService) sendHeartbeat(ctx context.Context) {
 	}
 
 	req := &modelComm.HeartbeatRequest{
-		AgentID: agentID,
-		Status:  status,
-		Metrics: metrics,
+		AgentID:       agentID,
+		Status:        status,
+		Metrics:       metrics,
+		ConfigVersion: &configVersion,
 	}
 
 	resp, err := s.client.SendHeartbeat(ctx, req)
 	if err != nil {
+		if httpclient.IsTokenExpired(err) {
+			s.reregister(ctx)
+			return
+		}
 		logger.LogSystemEvent("MasterService", "Heartbeat", fmt.Sprintf("Failed to send heartbeat: %v", err), logger.ErrorLevel, nil)
 		return
 	}
@@ -158,6 +208,149 @@ func (s *masterService) sendHeartbeat(ctx context.Context) {
 	if len(resp.Data.RuleVersions) > 0 {
 		logger.LogSystemEvent("MasterService", "Heartbeat", fmt.Sprintf("Received rule versions: %v", resp.Data.RuleVersions), logger.InfoLevel, nil)
 	}
+
+	// 配置版本落后时 Master 随心跳下发最新配置
+	if resp.Data.Config != nil {
+		if err := s.applyRemoteConfig(resp.Data.Config); err != nil {
+			logger.LogSystemEvent("MasterService", "Config", fmt.Sprintf("Rejected config version %d: %v", resp.Data.Config.Version, err), logger.ErrorLevel, nil)
+		}
+	}
+}
+
+// ensureToken Token 剩余有效期不足时刷新，返回 false 表示 Token 已不可用且重新注册失败
+// 刷新失败但 Token 尚未过期时继续使用原 Token，下次心跳再试
+func (s *masterService) ensureToken(ctx context.Context) bool {
+	s.mu.RLock()
+	expiry := s.expiry
+	lead := 2 * s.interval
+	s.mu.RUnlock()
+
+	if lead < tokenRefreshLead {
+		lead = tokenRefreshLead
+	}
+	if expiry.IsZero() || time.Until(expiry) > lead {
+		return true
+	}
+
+	resp, err := s.client.RefreshToken(ctx)
+	if err == nil && resp.Code != 200 {
+		err = fmt.Errorf("refresh token failed with code %d: %s", resp.Code, resp.Status)
+	}
+	if err != nil {
+		if httpclient.IsTokenExpired(err) {
+			return s.reregister(ctx)
+		}
+		logger.LogSystemEvent("MasterService", "Token", fmt.Sprintf("Failed to refresh token: %v", err), logger.WarnLevel, nil)
+		return true
+	}
+
+	s.mu.Lock()
+	s.token = resp.Data.AuthToken
+	s.expiry = resp.Data.TokenExpiry
+	s.client.SetAuthToken(s.token)
+	s.mu.Unlock()
+
+	logger.LogSystemEvent("MasterService", "Token", fmt.Sprintf("Token refreshed, expires at %s", resp.Data.TokenExpiry.Format(time.RFC3339)), logger.InfoLevel, nil)
+	return true
+}
+
+// reregister Token 过期后使用注册密钥重新注册，换取新 Token
+func (s *masterService) reregister(ctx context.Context) bool {
+	s.mu.RLock()
+	req := s.register
+	s.mu.RUnlock()
+
+	if req == nil {
+		return false
+	}
+	logger.LogSystemEvent("MasterService", "Token", "Token expired, re-registering", logger.WarnLevel, nil)
+	return s.Register(ctx, req) == nil
+}
+
+// configVersionLocked 当前已应用的配置版本，调用方需持有 s.mu
+func (s *masterService) configVersionLocked() int {
+	if s.config == nil {
+		return 0
+	}
+	return s.config.Version
+}
+
+// syncConfig 主动拉取并应用最新配置
+func (s *masterService) syncConfig(ctx context.Context) {
+	s.mu.RLock()
+	version := s.configVersionLocked()
+	s.mu.RUnlock()
+
+	resp, err := s.client.FetchConfig(ctx, version)
+	if err != nil {
+		logger.LogSystemEvent("MasterService", "Config", fmt.Sprintf("Failed to fetch config: %v", err), logger.WarnLevel, nil)
+		return
+	}
+	if resp.Code != 200 || resp.Data == nil {
+		return
+	}
+	if err := s.applyRemoteConfig(resp.Data); err != nil {
+		logger.LogSystemEvent("MasterService", "Config", fmt.Sprintf("Rejected config version %d: %v", resp.Data.Version, err), logger.ErrorLevel, nil)
+	}
+}
+
+// applyRemoteConfig 应用 Master 下发的配置
+// 整份配置校验通过且处理函数成功后才一次性替换，任何一步失败都保留原配置和原版本号，
+// 下次心跳仍上报旧版本，Master 会再次下发
+func (s *masterService) applyRemoteConfig(cfg *modelComm.RemoteConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	s.mu.RLock()
+	applied := s.configVersionLocked()
+	handler := s.configHandler
+	s.mu.RUnlock()
+	if applied == cfg.Version {
+		return nil
+	}
+
+	if handler != nil {
+		if err := handler(cfg); err != nil {
+			return fmt.Errorf("apply config: %w", err)
+		}
+	}
+
+	interval := time.Duration(cfg.HeartbeatInterval) * time.Second
+	s.mu.Lock()
+	s.config = cfg
+	changed := s.interval != interval
+	s.interval = interval
+	s.mu.Unlock()
+
+	if changed {
+		// 只保留最新的间隔通知
+		select {
+		case <-s.intervalCh:
+		default:
+		}
+		s.intervalCh <- interval
+	}
+
+	logger.LogSystemEvent("MasterService", "Config", fmt.Sprintf("Applied config version %d", cfg.Version), logger.InfoLevel, nil)
+	return nil
+}
+
+// GetRemoteConfig 获取当前已应用的 Master 下发配置
+func (s *masterService) GetRemoteConfig() *modelComm.RemoteConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// OnConfigChange 设置配置变更处理函数
+func (s *masterService) OnConfigChange(handler func(cfg *modelComm.RemoteConfig) error) {
+	s.mu.Lock()
+	s.configHandler = handler
+	s.mu.Unlock()
 }
 
 // StartTaskPoller 开启任务轮询
@@ -232,6 +425,10 @@ func (s *masterService) ReportTask(ctx context.Context, taskID string, status st
 			s.taskStats.Running--
 		}
 		s.taskStats.Failed++
+	case "canceled":
+		if s.taskStats.Running > 0 {
+			s.taskStats.Running--
+		}
 	}
 	s.mu.Unlock()
 
@@ -257,6 +454,17 @@ func (s *masterService) ReportTask(ctx context.Context, taskID string, status st
 	return nil
 }
 
+// SendResultBatch 上报扫描结果批次，批次的 AgentID 填写为当前注册的 Agent ID
+// 未注册时返回 ErrNotConnected，调用方应稍后重试
+func (s *masterService) SendResultBatch(ctx context.Context, batch *modelComm.ResultBatch) (*modelComm.ResultBatchResponse, error) {
+	agentID := s.GetAgentID()
+	if agentID == "" {
+		return nil, modelComm.ErrNotConnected
+	}
+	batch.AgentID = agentID
+	return s.client.SendResultBatch(ctx, batch)
+}
+
 // determineWorkStatus 根据运行任务数确定工作状态
 func (s *masterService) determineWorkStatus(runningTasks int) string {
 	if runningTasks > 0 {
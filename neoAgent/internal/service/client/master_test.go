@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	modelComm "neoagent/internal/model/client"
+)
+
+func testRemoteConfig(version int) *modelComm.RemoteConfig {
+	return &modelComm.RemoteConfig{
+		AgentID:            "agent-1",
+		Version:            version,
+		HeartbeatInterval:  60,
+		TaskPollInterval:   5,
+		MaxConcurrentTasks: 8,
+		LogLevel:           "debug",
+		Timeout:            600,
+		IsActive:           true,
+	}
+}
+
+func TestMasterService_ApplyRemoteConfig(t *testing.T) {
+	s := NewMasterService("http://127.0.0.1:0").(*masterService)
+
+	// 校验失败：整份配置不生效
+	bad := testRemoteConfig(1)
+	bad.LogLevel = "verbose"
+	if err := s.applyRemoteConfig(bad); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if s.GetRemoteConfig() != nil || s.interval != defaultHeartbeatInterval {
+		t.Fatalf("invalid config partially applied: config=%v interval=%v", s.GetRemoteConfig(), s.interval)
+	}
+
+	// 处理函数失败：保留原版本，下次对账重试
+	s.OnConfigChange(func(cfg *modelComm.RemoteConfig) error { return errors.New("reload failed") })
+	if err := s.applyRemoteConfig(testRemoteConfig(1)); err == nil {
+		t.Fatal("expected handler error")
+	}
+	if s.GetRemoteConfig() != nil {
+		t.Fatal("config applied although handler failed")
+	}
+
+	var handled []int
+	s.OnConfigChange(func(cfg *modelComm.RemoteConfig) error {
+		handled = append(handled, cfg.Version)
+		return nil
+	})
+	if err := s.applyRemoteConfig(testRemoteConfig(1)); err != nil {
+		t.Fatalf("applyRemoteConfig() error = %v", err)
+	}
+	if got := s.GetRemoteConfig(); got == nil || got.Version != 1 || got.MaxConcurrentTasks != 8 {
+		t.Fatalf("applied config = %+v", got)
+	}
+	select {
+	case d := <-s.intervalCh:
+		if d != time.Minute {
+			t.Errorf("heartbeat interval notification = %v, want 1m", d)
+		}
+	default:
+		t.Error("heartbeat interval change not notified")
+	}
+
+	// 同一版本重复下发不重复处理
+	if err := s.applyRemoteConfig(testRemoteConfig(1)); err != nil {
+		t.Fatalf("reapply error = %v", err)
+	}
+	if len(handled) != 1 {
+		t.Errorf("handler called %d times, want 1", len(handled))
+	}
+}
+
+// fakeMaster 模拟 Master 的注册、Token 刷新与心跳接口，只接受最近签发的 Token
+type fakeMaster struct {
+	mu         sync.Mutex
+	issued     int
+	current    string
+	expiry     time.Duration // 签发 Token 的有效期
+	registered int
+	refreshed  int
+	beats      []string // 心跳携带的 Token
+}
+
+func (m *fakeMaster) issueLocked() (string, time.Time) {
+	m.issued++
+	m.current = fmt.Sprintf("token-%d", m.issued)
+	return m.current, time.Now().Add(m.expiry)
+}
+
+func (m *fakeMaster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token := r.Header.Get("Authorization")
+	switch r.URL.Path {
+	case "/api/v1/agent/register":
+		m.registered++
+		t, exp := m.issueLocked()
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 200, "status": "success", "data": map[string]interface{}{"agent_id": "agent-1", "token": t, "token_expiry": exp}})
+		return
+	case "/api/v1/agent/config":
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 200, "status": "success"})
+		return
+	}
+	if token != "Bearer "+m.current {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 401, "status": "failed", "error": "AGENT_TOKEN_EXPIRED"})
+		return
+	}
+	switch r.URL.Path {
+	case "/api/v1/agent/token/refresh":
+		m.refreshed++
+		t, exp := m.issueLocked()
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 200, "status": "success", "data": map[string]interface{}{"agent_id": "agent-1", "token": t, "token_expiry": exp}})
+	case "/api/v1/agent/heartbeat":
+		m.beats = append(m.beats, token)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 200, "status": "success"})
+	}
+}
+
+func TestMasterService_HeartbeatRefreshesToken(t *testing.T) {
+	master := &fakeMaster{expiry: time.Minute}
+	srv := httptest.NewServer(master)
+	defer srv.Close()
+	s := NewMasterService(srv.URL).(*masterService)
+	ctx := context.Background()
+
+	if err := s.Register(ctx, &modelComm.AgentRegisterRequest{Hostname: "scanner-01"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// 剩余有效期不足，心跳前先刷新，心跳携带新 Token
+	s.sendHeartbeat(ctx)
+	if master.refreshed != 1 || len(master.beats) != 1 || master.beats[0] != "Bearer token-2" {
+		t.Fatalf("refreshed=%d beats=%v, want refresh before heartbeat with token-2", master.refreshed, master.beats)
+	}
+
+	// 有效期充足时不刷新
+	master.expiry = time.Hour
+	s.sendHeartbeat(ctx) // 刷新到长有效期 Token
+	s.sendHeartbeat(ctx)
+	if master.refreshed != 2 || master.beats[2] != "Bearer token-3" {
+		t.Errorf("refreshed=%d beats=%v, want no refresh with long-lived token", master.refreshed, master.beats)
+	}
+}
+
+func TestMasterService_ReregistersOnExpiredToken(t *testing.T) {
+	master := &fakeMaster{expiry: time.Hour}
+	srv := httptest.NewServer(master)
+	defer srv.Close()
+	s := NewMasterService(srv.URL).(*masterService)
+	ctx := context.Background()
+
+	if err := s.Register(ctx, &modelComm.AgentRegisterRequest{Hostname: "scanner-01"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// Master 侧 Token 已失效：心跳返回 AGENT_TOKEN_EXPIRED，Agent 重新注册
+	master.mu.Lock()
+	master.current = "revoked"
+	master.mu.Unlock()
+	s.sendHeartbeat(ctx)
+	if master.registered != 2 {
+		t.Fatalf("registered %d times, want re-registration", master.registered)
+	}
+
+	s.sendHeartbeat(ctx)
+	if len(master.beats) != 1 || master.beats[0] != "Bearer token-2" {
+		t.Errorf("beats = %v, want heartbeat with re-registered token-2", master.beats)
+	}
+}
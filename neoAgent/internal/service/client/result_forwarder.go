@@ -0,0 +1,423 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"neoagent/internal/core/model"
+	modelComm "neoagent/internal/model/client"
+	httpclient "neoagent/internal/pkg/client"
+	"neoagent/internal/pkg/logger"
+	"neoagent/internal/pkg/utils"
+)
+
+const (
+	defaultResultBatchSize     = 100
+	defaultResultFlushInterval = 5 * time.Second
+	defaultResultMaxRetries    = 3
+	defaultResultRetryBackoff  = 1 * time.Second
+	maxResultRetryBackoff      = 30 * time.Second
+	defaultResultSpoolDir      = "./data/results"
+)
+
+// ErrResultsPending 任务仍有结果在内存或磁盘缓冲中等待送达
+var ErrResultsPending = errors.New("task results pending delivery")
+
+// ResultSender 结果批次发送方 (MasterService 实现该接口)
+type ResultSender interface {
+	SendResultBatch(ctx context.Context, batch *modelComm.ResultBatch) (*modelComm.ResultBatchResponse, error)
+}
+
+// ResultForwarderConfig 结果上报配置，零值字段使用默认值
+type ResultForwarderConfig struct {
+	BatchSize     int           // 单批最多结果数，达到后立即发送
+	FlushInterval time.Duration // 未满批时的最长等待时间，同时也是磁盘缓冲的重放间隔
+	MaxRetries    int           // 单批发送失败后的重试次数，仍失败则写入磁盘缓冲
+	RetryBackoff  time.Duration // 首次重试等待时间，之后每次翻倍 (上限 30s)
+	SpoolDir      string        // 磁盘缓冲目录
+}
+
+func (c *ResultForwarderConfig) applyDefaults() {
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultResultBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultResultFlushInterval
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	} else if c.MaxRetries == 0 {
+		c.MaxRetries = defaultResultMaxRetries
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = defaultResultRetryBackoff
+	}
+	if c.SpoolDir == "" {
+		c.SpoolDir = defaultResultSpoolDir
+	}
+}
+
+// ResultForwarder 扫描结果上报器，将扫描结果分批上报到 Master (POST /api/v1/agent/results)
+// Submit 只做内存缓冲，发送在后台协程中进行:
+// 满批或到达 FlushInterval 时发送，失败按指数退避重试，仍失败(或磁盘中已有待重放批次，为保持顺序)时写入磁盘缓冲；
+// 每次发送前先按顺序重放磁盘缓冲，批次 ID 不变，Master 据此去重
+type ResultForwarder struct {
+	sender ResultSender
+	cfg    ResultForwarderConfig
+	spool  *resultSpool
+
+	mu          sync.Mutex
+	pending     []modelComm.ResultItem
+	unconfirmed map[string]int   // 任务已提交但 Master 尚未确认接收的结果数
+	failed      map[string]error // 结果被 Master 拒绝或丢失的任务
+
+	kick      chan struct{}   // 满批通知
+	flushReqs chan chan error // Flush 请求
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewResultForwarder 创建结果上报器，磁盘缓冲目录不可用时返回错误
+func NewResultForwarder(sender ResultSender, cfg ResultForwarderConfig) (*ResultForwarder, error) {
+	cfg.applyDefaults()
+	spool, err := newResultSpool(cfg.SpoolDir)
+	if err != nil {
+		return nil, err
+	}
+	if n := spool.Len(); n > 0 {
+		logger.Infof("[ResultForwarder] %d spooled result batches pending replay", n)
+	}
+	return &ResultForwarder{
+		sender:      sender,
+		cfg:         cfg,
+		spool:       spool,
+		unconfirmed: make(map[string]int),
+		failed:      make(map[string]error),
+		kick:        make(chan struct{}, 1),
+		flushReqs:   make(chan chan error),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Start 启动后台发送协程，重复调用无效
+func (f *ResultForwarder) Start(ctx context.Context) {
+	f.startOnce.Do(func() {
+		go f.run(ctx)
+	})
+}
+
+// Submit 提交一个任务的扫描结果
+func (f *ResultForwarder) Submit(task *model.Task, results []*model.TaskResult) {
+	if len(results) == 0 {
+		return
+	}
+	items := make([]modelComm.ResultItem, 0, len(results))
+	for _, r := range results {
+		if r != nil {
+			items = append(items, toResultItem(task, r))
+		}
+	}
+
+	f.mu.Lock()
+	f.pending = append(f.pending, items...)
+	for _, item := range items {
+		f.unconfirmed[item.TaskID]++
+	}
+	full := len(f.pending) >= f.cfg.BatchSize
+	f.mu.Unlock()
+
+	if full {
+		select {
+		case f.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush 立即发送内存中的全部结果并等待完成 (需已 Start)
+// 返回 nil 表示结果已送达或已写入磁盘缓冲(稍后重放)；只有写入磁盘也失败时才返回错误
+func (f *ResultForwarder) Flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+	select {
+	case f.flushReqs <- reply:
+	case <-f.done:
+		return errors.New("result forwarder stopped")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FlushTask 立即发送并确认任务的全部结果 (需已 Start)
+// 返回 nil 表示该任务提交的结果均已被 Master 接收；仍有结果在磁盘缓冲中等待重放时返回 ErrResultsPending，
+// 调用方应稍后重试；结果被 Master 拒绝或写入磁盘失败(已丢失)时返回对应错误
+func (f *ResultForwarder) FlushTask(ctx context.Context, taskID string) error {
+	if err := f.Flush(ctx); err != nil && ctx.Err() != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err, ok := f.failed[taskID]; ok {
+		delete(f.failed, taskID)
+		delete(f.unconfirmed, taskID)
+		return err
+	}
+	if f.unconfirmed[taskID] > 0 {
+		return ErrResultsPending
+	}
+	return nil
+}
+
+// Release 停止跟踪任务的结果送达情况，任务结束后调用
+// 尚在缓冲中的结果仍会照常发送，只是不再记录结论
+func (f *ResultForwarder) Release(taskID string) {
+	f.mu.Lock()
+	delete(f.unconfirmed, taskID)
+	delete(f.failed, taskID)
+	f.mu.Unlock()
+}
+
+// Close 停止后台协程，内存中未发送的结果尝试发送一次，失败则写入磁盘缓冲
+func (f *ResultForwarder) Close(ctx context.Context) error {
+	f.stopOnce.Do(func() { close(f.stop) })
+	f.startOnce.Do(func() { close(f.done) }) // 未启动时直接视为已退出
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return f.flush(ctx)
+}
+
+// Pending 内存中待发送的结果数与磁盘中待重放的批次数
+func (f *ResultForwarder) Pending() (items int, spooledBatches int) {
+	f.mu.Lock()
+	items = len(f.pending)
+	f.mu.Unlock()
+	return items, f.spool.Len()
+}
+
+func (f *ResultForwarder) run(ctx context.Context) {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.flush(ctx)
+		case <-f.kick:
+			f.flush(ctx)
+		case reply := <-f.flushReqs:
+			reply <- f.flush(ctx)
+		}
+	}
+}
+
+// flush 先重放磁盘缓冲，再将内存中的结果按 BatchSize 切分为批次发送
+func (f *ResultForwarder) flush(ctx context.Context) error {
+	f.replay(ctx)
+
+	f.mu.Lock()
+	items := f.pending
+	f.pending = nil
+	f.mu.Unlock()
+
+	var errs []error
+	for len(items) > 0 {
+		n := min(len(items), f.cfg.BatchSize)
+		batch, err := newResultBatch(items[:n])
+		items = items[n:]
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// 磁盘中还有待重放的批次时直接排队，保持上报顺序
+		if f.spool.Len() == 0 {
+			err = f.deliver(ctx, batch, f.cfg.MaxRetries)
+			if err == nil {
+				continue
+			}
+			if !isRetryableResultError(err) {
+				logger.Errorf("[ResultForwarder] batch %s (%d results) rejected by master, dropped: %v", batch.BatchID, len(batch.Results), err)
+				f.settle(batch, err)
+				continue
+			}
+			logger.Warnf("[ResultForwarder] batch %s delivery failed, spooling to disk: %v", batch.BatchID, err)
+		}
+		if err := f.spool.Put(batch); err != nil {
+			logger.Errorf("[ResultForwarder] batch %s (%d results) lost: %v", batch.BatchID, len(batch.Results), err)
+			f.settle(batch, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// replay 按顺序重放磁盘缓冲，遇到可重试的失败即停止，等待下一次重放
+func (f *ResultForwarder) replay(ctx context.Context) {
+	names, err := f.spool.List()
+	if err != nil {
+		logger.Warnf("[ResultForwarder] list result spool failed: %v", err)
+		return
+	}
+	for _, name := range names {
+		batch, err := f.spool.Load(name)
+		if err != nil {
+			logger.Errorf("[ResultForwarder] skip spooled batch: %v", err)
+			continue
+		}
+		if err := f.deliver(ctx, batch, 0); err != nil {
+			if isRetryableResultError(err) {
+				return
+			}
+			logger.Errorf("[ResultForwarder] spooled batch %s rejected by master, dropped: %v", batch.BatchID, err)
+			f.settle(batch, err)
+		} else {
+			logger.Infof("[ResultForwarder] spooled batch %s replayed", batch.BatchID)
+		}
+		f.spool.Remove(name)
+	}
+}
+
+// settle 批次已有结论: err 为 nil 表示被 Master 接收，否则批次中各任务记为失败
+// 磁盘缓冲中的批次可能来自重启前的任务，未在本进程提交过的任务不做记录
+func (f *ResultForwarder) settle(batch *modelComm.ResultBatch, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, item := range batch.Results {
+		n, ok := f.unconfirmed[item.TaskID]
+		if !ok {
+			continue
+		}
+		if n <= 1 {
+			delete(f.unconfirmed, item.TaskID)
+		} else {
+			f.unconfirmed[item.TaskID] = n - 1
+		}
+		if err != nil {
+			f.failed[item.TaskID] = err
+		}
+	}
+}
+
+// deliver 发送一个批次，可重试的错误按指数退避最多重试 retries 次
+func (f *ResultForwarder) deliver(ctx context.Context, batch *modelComm.ResultBatch, retries int) error {
+	backoff := f.cfg.RetryBackoff
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		resp, err := f.sender.SendResultBatch(ctx, batch)
+		if err == nil {
+			defer f.settle(batch, nil)
+			if resp == nil {
+				return nil
+			}
+			if n := len(resp.Data.Quarantined); n > 0 {
+				logger.Warnf("[ResultForwarder] batch %s: %d results quarantined by master", batch.BatchID, n)
+				for _, q := range resp.Data.Quarantined {
+					logger.Warnf("[ResultForwarder] quarantined result #%d (task %s): %s", q.Index, q.TaskID, q.Reason)
+				}
+			}
+			for _, r := range resp.Data.Rejected {
+				logger.Warnf("[ResultForwarder] batch %s: result #%d rejected (task %s): %s", batch.BatchID, r.Index, r.TaskID, r.Reason)
+				f.markFailed(r.TaskID, fmt.Errorf("result rejected by master: %s", r.Reason))
+			}
+			return nil
+		}
+		lastErr = err
+		if !isRetryableResultError(err) || attempt >= retries {
+			return lastErr
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return lastErr
+		}
+		backoff = min(backoff*2, maxResultRetryBackoff)
+	}
+}
+
+// markFailed 记录任务的结果被 Master 拒绝，须在 settle 该批次之前调用 (仅限本进程提交过且未确认的任务)
+func (f *ResultForwarder) markFailed(taskID string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.unconfirmed[taskID]; ok {
+		f.failed[taskID] = err
+	}
+}
+
+// isRetryableResultError 网络错误、未注册、5xx/408/429 以及认证失败(令牌过期，重新注册后恢复)可重试；
+// 其余 4xx 表示批次本身被拒绝 (如任务已结束)，重试没有意义
+func isRetryableResultError(err error) bool {
+	var se *httpclient.StatusError
+	if !errors.As(err, &se) {
+		return true
+	}
+	switch se.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusUnauthorized:
+		return true
+	}
+	return se.StatusCode >= 500
+}
+
+// newResultBatch 创建新批次，批次 ID 在此生成并在重放时保持不变
+func newResultBatch(items []modelComm.ResultItem) (*modelComm.ResultBatch, error) {
+	id, err := utils.GenerateUUID()
+	if err != nil {
+		return nil, fmt.Errorf("generate batch id: %w", err)
+	}
+	return &modelComm.ResultBatch{
+		BatchID:   id,
+		Results:   append([]modelComm.ResultItem(nil), items...),
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// toResultItem 核心结果转换为上报条目，结果体无法序列化时记录为错误
+func toResultItem(task *model.Task, r *model.TaskResult) modelComm.ResultItem {
+	item := modelComm.ResultItem{
+		TaskID:      r.TaskID,
+		Status:      string(r.Status),
+		Error:       r.Error,
+		ExecutedAt:  r.ExecutedAt,
+		CompletedAt: r.CompletedAt,
+	}
+	if task != nil {
+		item.TaskType = string(task.Type)
+		item.Target = task.Target
+		if item.TaskID == "" {
+			item.TaskID = task.ID
+		}
+	}
+	if r.Result != nil {
+		data, err := json.Marshal(r.Result)
+		if err != nil {
+			item.Status = string(model.TaskStatusFailed)
+			item.Error = fmt.Sprintf("marshal result: %v", err)
+		} else {
+			item.Result = data
+		}
+	}
+	return item
+}
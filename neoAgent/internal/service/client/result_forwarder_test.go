@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"neoagent/internal/core/model"
+	modelComm "neoagent/internal/model/client"
+	httpclient "neoagent/internal/pkg/client"
+)
+
+// fakeSender 记录收到的批次，fail 返回非 nil 时本次发送失败
+type fakeSender struct {
+	mu      sync.Mutex
+	batches []*modelComm.ResultBatch
+	calls   int
+	fail    func(call int) error
+}
+
+func (s *fakeSender) SendResultBatch(ctx context.Context, batch *modelComm.ResultBatch) (*modelComm.ResultBatchResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.fail != nil {
+		if err := s.fail(s.calls); err != nil {
+			return nil, err
+		}
+	}
+	s.batches = append(s.batches, batch)
+	return &modelComm.ResultBatchResponse{Data: modelComm.ResultBatchResponseData{BatchID: batch.BatchID, Accepted: len(batch.Results)}}, nil
+}
+
+func (s *fakeSender) delivered() []*modelComm.ResultBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*modelComm.ResultBatch(nil), s.batches...)
+}
+
+func testResults(taskID string, n int) (*model.Task, []*model.TaskResult) {
+	task := &model.Task{ID: taskID, Type: model.TaskTypePortScan}
+	results := make([]*model.TaskResult, n)
+	for i := range results {
+		results[i] = &model.TaskResult{
+			TaskID: taskID,
+			Status: model.TaskStatusSuccess,
+			Result: map[string]interface{}{"port": 80 + i},
+		}
+	}
+	return task, results
+}
+
+func newTestForwarder(t *testing.T, sender ResultSender, cfg ResultForwarderConfig) *ResultForwarder {
+	t.Helper()
+	if cfg.SpoolDir == "" {
+		cfg.SpoolDir = t.TempDir()
+	}
+	if cfg.RetryBackoff == 0 {
+		cfg.RetryBackoff = time.Millisecond
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = time.Hour
+	}
+	f, err := NewResultForwarder(sender, cfg)
+	if err != nil {
+		t.Fatalf("NewResultForwarder failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	f.Start(ctx)
+	return f
+}
+
+func TestResultForwarder_Batching(t *testing.T) {
+	sender := &fakeSender{}
+	f := newTestForwarder(t, sender, ResultForwarderConfig{BatchSize: 2})
+
+	task, results := testResults("task-1", 5)
+	f.Submit(task, results)
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	batches := sender.delivered()
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(batches))
+	}
+	total := 0
+	for _, b := range batches {
+		total += len(b.Results)
+		if b.BatchID == "" {
+			t.Error("batch id should be set")
+		}
+		for _, item := range b.Results {
+			if item.TaskID != "task-1" || item.TaskType != string(model.TaskTypePortScan) || len(item.Result) == 0 {
+				t.Errorf("unexpected item: %+v", item)
+			}
+		}
+	}
+	if total != 5 {
+		t.Errorf("expected 5 results, got %d", total)
+	}
+}
+
+func TestResultForwarder_RetryThenSuccess(t *testing.T) {
+	sender := &fakeSender{fail: func(call int) error {
+		if call <= 2 {
+			return &httpclient.StatusError{StatusCode: http.StatusServiceUnavailable}
+		}
+		return nil
+	}}
+	f := newTestForwarder(t, sender, ResultForwarderConfig{MaxRetries: 3})
+
+	f.Submit(testResults("task-1", 1))
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if n := len(sender.delivered()); n != 1 {
+		t.Fatalf("expected 1 delivered batch, got %d", n)
+	}
+	if _, spooled := f.Pending(); spooled != 0 {
+		t.Errorf("expected empty spool, got %d", spooled)
+	}
+}
+
+func TestResultForwarder_SpoolAndReplay(t *testing.T) {
+	var down sync.Mutex
+	unreachable := true
+	sender := &fakeSender{fail: func(int) error {
+		down.Lock()
+		defer down.Unlock()
+		if unreachable {
+			return errors.New("connection refused")
+		}
+		return nil
+	}}
+	dir := t.TempDir()
+	f := newTestForwarder(t, sender, ResultForwarderConfig{MaxRetries: 1, SpoolDir: dir})
+
+	// Master 不可达: 两个批次依次写入磁盘
+	f.Submit(testResults("task-1", 1))
+	f.Flush(context.Background())
+	f.Submit(testResults("task-2", 1))
+	f.Flush(context.Background())
+	if _, spooled := f.Pending(); spooled != 2 {
+		t.Fatalf("expected 2 spooled batches, got %d", spooled)
+	}
+	spool, _ := newResultSpool(dir)
+	names, _ := spool.List()
+	first, _ := spool.Load(names[0])
+
+	// 进程重启后恢复连接: 按写入顺序重放，批次 ID 不变，新结果排在其后
+	f.Close(context.Background())
+	down.Lock()
+	unreachable = false
+	down.Unlock()
+	f = newTestForwarder(t, sender, ResultForwarderConfig{SpoolDir: dir})
+	f.Submit(testResults("task-3", 1))
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	batches := sender.delivered()
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 delivered batches, got %d", len(batches))
+	}
+	if batches[0].BatchID != first.BatchID {
+		t.Errorf("replayed batch id changed: %s != %s", batches[0].BatchID, first.BatchID)
+	}
+	for i, want := range []string{"task-1", "task-2", "task-3"} {
+		if got := batches[i].Results[0].TaskID; got != want {
+			t.Errorf("batch %d: expected %s, got %s", i, want, got)
+		}
+	}
+	if _, spooled := f.Pending(); spooled != 0 {
+		t.Errorf("expected empty spool after replay, got %d", spooled)
+	}
+}
+
+func TestResultForwarder_RejectedBatchDropped(t *testing.T) {
+	sender := &fakeSender{fail: func(int) error {
+		return &httpclient.StatusError{StatusCode: http.StatusConflict, Body: "task not running"}
+	}}
+	f := newTestForwarder(t, sender, ResultForwarderConfig{MaxRetries: 3})
+
+	f.Submit(testResults("task-1", 1))
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if sender.calls != 1 {
+		t.Errorf("expected no retry for rejected batch, got %d calls", sender.calls)
+	}
+	if _, spooled := f.Pending(); spooled != 0 {
+		t.Errorf("rejected batch should not be spooled, got %d", spooled)
+	}
+}
+
+func TestResultForwarder_FlushTaskConfirmsDelivery(t *testing.T) {
+	var down sync.Mutex
+	unreachable := true
+	sender := &fakeSender{fail: func(int) error {
+		down.Lock()
+		defer down.Unlock()
+		if unreachable {
+			return errors.New("connection refused")
+		}
+		return nil
+	}}
+	f := newTestForwarder(t, sender, ResultForwarderConfig{MaxRetries: 1})
+	ctx := context.Background()
+
+	// Master 不可达：结果写入磁盘缓冲，尚未确认送达
+	f.Submit(testResults("task-1", 2))
+	if err := f.FlushTask(ctx, "task-1"); !errors.Is(err, ErrResultsPending) {
+		t.Fatalf("FlushTask() error = %v, want ErrResultsPending", err)
+	}
+
+	// 恢复连接后重放成功，确认送达
+	down.Lock()
+	unreachable = false
+	down.Unlock()
+	if err := f.FlushTask(ctx, "task-1"); err != nil {
+		t.Fatalf("FlushTask() after recovery error = %v", err)
+	}
+	// 未提交过结果的任务无需等待
+	if err := f.FlushTask(ctx, "task-2"); err != nil {
+		t.Errorf("FlushTask() for task without results error = %v", err)
+	}
+}
+
+func TestResultForwarder_FlushTaskReportsRejection(t *testing.T) {
+	sender := &fakeSender{fail: func(int) error {
+		return &httpclient.StatusError{StatusCode: http.StatusConflict, Body: "task not running"}
+	}}
+	f := newTestForwarder(t, sender, ResultForwarderConfig{})
+
+	f.Submit(testResults("task-1", 1))
+	err := f.FlushTask(context.Background(), "task-1")
+	if err == nil || errors.Is(err, ErrResultsPending) {
+		t.Fatalf("FlushTask() error = %v, want rejection error", err)
+	}
+	// 失败只报告一次
+	if err := f.FlushTask(context.Background(), "task-1"); err != nil {
+		t.Errorf("second FlushTask() error = %v", err)
+	}
+}
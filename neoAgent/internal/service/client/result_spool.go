@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	modelComm "neoagent/internal/model/client"
+)
+
+const spoolFileExt = ".json"
+
+// resultSpool 结果批次的磁盘缓冲区
+// Master 不可达时批次写入磁盘，恢复连接后按写入顺序重放；每个批次一个文件，
+// 文件名以批次创建时间开头以保证顺序，先写临时文件再重命名，进程中途退出不会留下半个批次
+type resultSpool struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// newResultSpool 打开(必要时创建)缓冲目录，清理上次异常退出残留的临时文件
+func newResultSpool(dir string) (*resultSpool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create result spool dir %s: %w", dir, err)
+	}
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	for _, tmp := range tmps {
+		os.Remove(tmp)
+	}
+	return &resultSpool{dir: dir}, nil
+}
+
+// Put 写入一个批次
+func (s *resultSpool) Put(batch *modelComm.ResultBatch) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("marshal result batch: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := fmt.Sprintf("%020d-%s%s", batch.CreatedAt.UnixNano(), batch.BatchID, spoolFileExt)
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("write result spool: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("write result spool: %w", err)
+	}
+	return nil
+}
+
+// List 按写入顺序返回缓冲中的批次文件名
+func (s *resultSpool) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), spoolFileExt) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Len 缓冲中的批次数
+func (s *resultSpool) Len() int {
+	names, _ := s.List()
+	return len(names)
+}
+
+// Load 读取一个批次；文件损坏时重命名为 .bad 留待人工排查，并返回错误
+func (s *resultSpool) Load(name string) (*modelComm.ResultBatch, error) {
+	path := filepath.Join(s.dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var batch modelComm.ResultBatch
+	if err := json.Unmarshal(data, &batch); err != nil || batch.BatchID == "" {
+		os.Rename(path, path+".bad")
+		return nil, fmt.Errorf("corrupt result spool file %s: %v", name, err)
+	}
+	return &batch, nil
+}
+
+// Remove 删除已送达(或被 Master 明确拒绝)的批次
+func (s *resultSpool) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.Remove(filepath.Join(s.dir, name))
+}
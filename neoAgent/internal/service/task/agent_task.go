@@ -4,7 +4,7 @@
  * @date: 2025.10.21
  * @description: 处理Agent与Master端的任务交互（Outbound）和本地任务管理（Inbound）
  * @func:
- *  1. Outbound: 轮询Master任务 -> 转换 -> 执行 -> 上报结果
+ *  1. Outbound: 轮询Master任务 -> 转换 -> 执行 -> 上报结果 (Master 下达取消时中止执行并确认)
  *  2. Inbound: 响应API请求 -> 控制任务状态
  */
 package task
@@ -12,11 +12,13 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"neoagent/internal/config"
+	coreModel "neoagent/internal/core/model"
 	"neoagent/internal/core/runner"
 	modelComm "neoagent/internal/model/client"
 	"neoagent/internal/pkg/logger"
@@ -24,6 +26,16 @@ import (
 	"neoagent/internal/service/client"
 )
 
+// errCanceledByMaster Master 下达取消 (任务状态为 canceling) 时作为任务上下文的取消原因
+// 用于区分本地 StopTask 与 Master 取消：只有后者需要向 Master 确认 canceled
+var errCanceledByMaster = errors.New("task canceled by master")
+
+// resultRetryInterval 任务结果未送达时重新确认的间隔
+const resultRetryInterval = 5 * time.Second
+
+// resultStreamBuffer 流式执行时结果通道的缓冲
+const resultStreamBuffer = 64
+
 // AgentTaskService Agent任务管理服务接口
 type AgentTaskService interface {
 	// ==================== Lifecycle Methods (Outbound 能力) ====================
@@ -52,30 +64,33 @@ type AgentTaskService interface {
 // agentTaskService Agent任务管理服务实现
 type agentTaskService struct {
 	masterService client.MasterService
+	forwarder     *client.ResultForwarder // 扫描结果上报器，可为 nil
 	runnerManager *runner.RunnerManager
 	translator    *adapter.TaskTranslator
 	config        *config.Config
 
 	// runningTasks 维护正在运行的任务的取消函数
-	// Key: TaskID, Value: CancelFunc
-	runningTasks map[string]context.CancelFunc
+	// Key: TaskID, Value: CancelCauseFunc
+	runningTasks map[string]context.CancelCauseFunc
 	mu           sync.RWMutex
 }
 
 // NewAgentTaskService 创建Agent任务管理服务实例
-// 注入必要的依赖：Master通信服务、Runner管理器、任务转换器、配置
+// 注入必要的依赖：Master通信服务、结果上报器、Runner管理器、任务转换器、配置
 func NewAgentTaskService(
 	masterService client.MasterService, // Master通信服务
+	forwarder *client.ResultForwarder, // 结果上报器 (可为 nil)
 	runnerManager *runner.RunnerManager, // Runner管理器
 	translator *adapter.TaskTranslator, // 任务转换器
 	cfg *config.Config,
 ) AgentTaskService {
 	return &agentTaskService{
 		masterService: masterService,
+		forwarder:     forwarder,
 		runnerManager: runnerManager,
 		translator:    translator,
 		config:        cfg,
-		runningTasks:  make(map[string]context.CancelFunc),
+		runningTasks:  make(map[string]context.CancelCauseFunc),
 	}
 }
 
@@ -101,6 +116,10 @@ func (s *agentTaskService) StartWorker(ctx context.Context, interval time.Durati
 			}
 			// 处理一批任务
 			for _, task := range tasks {
+				if task.Status == "canceling" {
+					s.cancelTask(ctx, task.TaskID)
+					continue
+				}
 				// 并发处理任务
 				go s.processTask(ctx, task)
 			}
@@ -111,17 +130,16 @@ func (s *agentTaskService) StartWorker(ctx context.Context, interval time.Durati
 // processTask 处理单个任务（Outbound 核心逻辑）
 func (s *agentTaskService) processTask(parentCtx context.Context, task modelComm.Task) {
 	taskID := task.TaskID
-	logger.LogSystemEvent("TaskService", "ProcessTask", fmt.Sprintf("Processing task: %s (%s)", taskID, task.TaskType), logger.InfoLevel, nil)
 
-	// 1. 上报状态：Running
-	if err := s.masterService.ReportTask(parentCtx, taskID, "running", "", ""); err != nil {
-		logger.LogSystemEvent("TaskService", "ReportTask", fmt.Sprintf("Failed to report running status for task %s: %v", taskID, err), logger.ErrorLevel, nil)
-		// 即使上报失败，也尝试继续执行，或者选择终止
-	}
-
-	// 2. 创建任务上下文（用于支持取消）
-	ctx, cancel := context.WithCancel(parentCtx)
+	// 1. 创建任务上下文（用于支持取消），同一任务已在执行时跳过
+	// Master 每次拉取都会返回未结束的任务，这里保证同一任务只执行一次
+	ctx, cancel := context.WithCancelCause(parentCtx)
 	s.mu.Lock()
+	if _, running := s.runningTasks[taskID]; running {
+		s.mu.Unlock()
+		cancel(nil)
+		return
+	}
 	s.runningTasks[taskID] = cancel
 	s.mu.Unlock()
 
@@ -130,9 +148,20 @@ func (s *agentTaskService) processTask(parentCtx context.Context, task modelComm
 		s.mu.Lock()
 		delete(s.runningTasks, taskID)
 		s.mu.Unlock()
-		cancel()
+		cancel(nil)
+		if s.forwarder != nil {
+			s.forwarder.Release(taskID)
+		}
 	}()
 
+	logger.LogSystemEvent("TaskService", "ProcessTask", fmt.Sprintf("Processing task: %s (%s)", taskID, task.TaskType), logger.InfoLevel, nil)
+
+	// 2. 上报状态：Running
+	if err := s.masterService.ReportTask(parentCtx, taskID, "running", "", ""); err != nil {
+		logger.LogSystemEvent("TaskService", "ReportTask", fmt.Sprintf("Failed to report running status for task %s: %v", taskID, err), logger.ErrorLevel, nil)
+		// 即使上报失败，也尝试继续执行，或者选择终止
+	}
+
 	// 3. 转换任务模型 (Master Model -> Core Model)
 	coreTask, err := s.translator.ToCoreTask(&task)
 	if err != nil {
@@ -142,22 +171,65 @@ func (s *agentTaskService) processTask(parentCtx context.Context, task modelComm
 		return
 	}
 
-	// 4. 执行任务
-	results, err := s.runnerManager.Execute(ctx, coreTask)
+	// 4. 流式执行任务：结果边扫描边交给结果通道上报，不在内存中累积整个任务的结果
+	// 没有结果上报器 (磁盘缓冲初始化失败) 时退回到在内存中收集结果，随完成状态一并上报
+	out := make(chan *coreModel.TaskResult, resultStreamBuffer)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.runnerManager.ExecuteStream(ctx, coreTask, out)
+	}()
+	var collected []*coreModel.TaskResult
+	for result := range out {
+		if s.forwarder != nil {
+			s.forwarder.Submit(coreTask, []*coreModel.TaskResult{result})
+		} else {
+			collected = append(collected, result)
+		}
+	}
+	err = <-errCh
 
 	// 5. 处理结果并上报
-	if err != nil {
+	if errors.Is(context.Cause(ctx), errCanceledByMaster) {
+		// Master 已取消任务，结果不再上报 (Master 只接收运行中任务的结果)，仅确认取消
+		if err := s.masterService.ReportTask(parentCtx, taskID, "canceled", "", ""); err != nil {
+			logger.LogSystemEvent("TaskService", "ReportCancel", fmt.Sprintf("Failed to acknowledge cancellation for task %s: %v", taskID, err), logger.ErrorLevel, nil)
+		} else {
+			logger.LogSystemEvent("TaskService", "TaskCanceled", fmt.Sprintf("Task %s canceled", taskID), logger.InfoLevel, nil)
+		}
+	} else if err != nil {
 		// 任务执行失败
 		errMsg := fmt.Sprintf("Task execution failed: %v", err)
 		logger.LogSystemEvent("TaskService", "ExecuteTask", fmt.Sprintf("%s: %v", errMsg, err), logger.ErrorLevel, nil)
 		s.masterService.ReportTask(parentCtx, taskID, "failed", "", errMsg)
 	} else {
 		// 任务执行成功
-		// 序列化结果
-		resultJSON, _ := json.Marshal(results)
-		// 注意：ReportTask 的 result 字段可能需要根据 Master 的期望格式进行调整
-		// 这里简单将 coreModel.TaskResult 数组序列化后上报
-		if err := s.masterService.ReportTask(parentCtx, taskID, "completed", string(resultJSON), ""); err != nil {
+		// 扫描结果通过结果通道上报，确认全部送达后再上报完成状态
+		// Master 只接收运行中任务的结果，顺序不能颠倒；结果尚未送达时不上报完成，避免缓冲中的结果被拒收
+		resultJSON := ""
+		if s.forwarder == nil {
+			data, err := json.Marshal(collected)
+			if err != nil {
+				errMsg := fmt.Sprintf("Failed to encode results: %v", err)
+				logger.LogSystemEvent("TaskService", "ReportResult", fmt.Sprintf("%s (task %s)", errMsg, taskID), logger.ErrorLevel, nil)
+				s.masterService.ReportTask(parentCtx, taskID, "failed", "", errMsg)
+				return
+			}
+			resultJSON = string(data)
+		} else {
+			if err := s.awaitResults(parentCtx, taskID); err != nil {
+				if errors.Is(err, client.ErrResultsPending) {
+					// Agent 退出时结果仍在磁盘缓冲中，任务保持运行状态，重启后重放结果，由 Master 超时机制兜底
+					logger.LogSystemEvent("TaskService", "ForwardResult", fmt.Sprintf("Results for task %s not yet delivered, completion not reported", taskID), logger.WarnLevel, nil)
+					return
+				}
+				errMsg := fmt.Sprintf("Failed to deliver results: %v", err)
+				logger.LogSystemEvent("TaskService", "ForwardResult", fmt.Sprintf("%s (task %s)", errMsg, taskID), logger.ErrorLevel, nil)
+				s.masterService.ReportTask(parentCtx, taskID, "failed", "", errMsg)
+				return
+			}
+		}
+
+		if err := s.masterService.ReportTask(parentCtx, taskID, "completed", resultJSON, ""); err != nil {
 			logger.LogSystemEvent("TaskService", "ReportResult", fmt.Sprintf("Failed to report completion for task %s: %v", taskID, err), logger.ErrorLevel, nil)
 		} else {
 			logger.LogSystemEvent("TaskService", "TaskCompleted", fmt.Sprintf("Task %s completed successfully", taskID), logger.InfoLevel, nil)
@@ -165,6 +237,42 @@ func (s *agentTaskService) processTask(parentCtx context.Context, task modelComm
 	}
 }
 
+// awaitResults 等待任务结果全部被 Master 接收
+// 结果暂存在磁盘缓冲 (Master 不可达) 时按 resultRetryInterval 重试，直到送达或 ctx 结束
+func (s *agentTaskService) awaitResults(ctx context.Context, taskID string) error {
+	for {
+		err := s.forwarder.FlushTask(ctx, taskID)
+		if !errors.Is(err, client.ErrResultsPending) {
+			return err
+		}
+		select {
+		case <-time.After(resultRetryInterval):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// cancelTask 处理 Master 下达的取消 (任务状态为 canceling)
+// 任务正在执行时中止其上下文，由 processTask 在执行返回后确认 canceled；
+// 本地没有该任务 (已结束并上报，或 Agent 重启后丢失) 时直接确认，
+// 若任务实际已结束，Master 会以已上报的终态为准拒绝该确认
+func (s *agentTaskService) cancelTask(ctx context.Context, taskID string) {
+	s.mu.RLock()
+	cancel, running := s.runningTasks[taskID]
+	s.mu.RUnlock()
+
+	if running {
+		cancel(errCanceledByMaster)
+		logger.LogSystemEvent("TaskService", "CancelTask", fmt.Sprintf("Cancel signal from master sent to task: %s", taskID), logger.InfoLevel, nil)
+		return
+	}
+
+	if err := s.masterService.ReportTask(ctx, taskID, "canceled", "", ""); err != nil {
+		logger.LogSystemEvent("TaskService", "ReportCancel", fmt.Sprintf("Failed to acknowledge cancellation for task %s: %v", taskID, err), logger.WarnLevel, nil)
+	}
+}
+
 // ==================== Agent任务管理实现 (Inbound 能力) ====================
 
 // GetTaskList 获取Agent任务列表
@@ -228,8 +336,8 @@ func (s *agentTaskService) StopTask(ctx context.Context, taskID string) error {
 		return fmt.Errorf("task not running: %s", taskID)
 	}
 
-	// 调用 cancel 函数，这将导致 processTask 中的 runnerManager.Execute 接收到 context done 信号
-	cancel()
+	// 调用 cancel 函数，这将导致 processTask 中的 runnerManager.ExecuteStream 接收到 context done 信号
+	cancel(nil)
 	logger.LogSystemEvent("TaskService", "StopTask", fmt.Sprintf("Stop signal sent to task: %s", taskID), logger.InfoLevel, nil)
 	return nil
 }
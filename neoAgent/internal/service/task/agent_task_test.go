@@ -0,0 +1,86 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	coreModel "neoagent/internal/core/model"
+	"neoagent/internal/core/runner"
+	modelComm "neoagent/internal/model/client"
+	"neoagent/internal/service/adapter"
+	"neoagent/internal/service/client"
+)
+
+// reportCall 一次任务状态上报
+type reportCall struct {
+	status string
+	result string
+	errMsg string
+}
+
+// fakeMasterService 记录任务状态上报的 MasterService，其余方法不应被调用
+type fakeMasterService struct {
+	client.MasterService
+
+	mu      sync.Mutex
+	reports []reportCall
+}
+
+func (f *fakeMasterService) ReportTask(ctx context.Context, taskID string, status string, result string, errorMsg string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reports = append(f.reports, reportCall{status: status, result: result, errMsg: errorMsg})
+	return nil
+}
+
+// fakeRunner 返回固定结果的 Runner
+type fakeRunner struct {
+	results []*coreModel.TaskResult
+}
+
+func (r *fakeRunner) Name() coreModel.TaskType {
+	return coreModel.TaskTypeRawCmd
+}
+
+func (r *fakeRunner) Run(ctx context.Context, task *coreModel.Task) ([]*coreModel.TaskResult, error) {
+	return r.results, nil
+}
+
+func TestProcessTask_NoForwarderReportsResultsWithCompletion(t *testing.T) {
+	manager := runner.NewRunnerManager()
+	if err := manager.Register(&fakeRunner{results: []*coreModel.TaskResult{
+		{TaskID: "t1", Status: coreModel.TaskStatusSuccess, Result: map[string]interface{}{"port": float64(22)}},
+		{TaskID: "t1", Status: coreModel.TaskStatusSuccess, Result: map[string]interface{}{"port": float64(80)}},
+	}}); err != nil {
+		t.Fatalf("register runner: %v", err)
+	}
+
+	master := &fakeMasterService{}
+	svc := NewAgentTaskService(master, nil, manager, adapter.NewTaskTranslator(), nil).(*agentTaskService)
+
+	svc.processTask(context.Background(), modelComm.Task{TaskID: "t1", TaskType: string(coreModel.TaskTypeRawCmd)})
+
+	if len(master.reports) != 2 {
+		t.Fatalf("expected running and completed reports, got %+v", master.reports)
+	}
+	final := master.reports[1]
+	if final.status != "completed" {
+		t.Fatalf("expected completed, got %+v", final)
+	}
+
+	var results []*coreModel.TaskResult
+	if err := json.Unmarshal([]byte(final.result), &results); err != nil {
+		t.Fatalf("decode reported result %q: %v", final.result, err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results reported with completion, got %d", len(results))
+	}
+	for i, port := range []float64{22, 80} {
+		m, ok := results[i].Result.(map[string]interface{})
+		if !ok || m["port"] != port {
+			t.Errorf("result %d: expected port %v, got %+v", i, port, results[i].Result)
+		}
+	}
+}
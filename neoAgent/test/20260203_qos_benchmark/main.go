@@ -143,6 +143,8 @@ func runBenchmark(target, ports string, rate int) {
 	fmt.Printf("Duration: %v\n", duration)
 	fmt.Printf("Total Open Ports: %d\n", totalOpenPorts)
 	fmt.Printf("Average Speed: %.2f hosts/s\n", float64(len(ips))/duration.Seconds())
+	stats := scanner.ProbeTimeoutStats()
+	fmt.Printf("Final Probe Timeout: %v (median RTT %v, %d samples)\n", stats.Timeout, stats.MedianRTT, stats.Samples)
 
 	// 结果记录 (2026-02-03):
 	// [Test 1] Baseline (Rate=100): 3.90s, 65.15 hosts/s
@@ -1,214 +1,41 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"net"
-	"net/http"
-	"strings"
-	"sync"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"neoagent/test/mocklab"
 )
 
 // Mock Server 用于在无 Docker 环境下测试 Agent 的基础连接和认证逻辑
 // 注意：这不能替代真实环境测试，只能验证 Agent 的网络层和基础协议解析逻辑
+// Mock 服务实现位于 test/mocklab，扫描器测试中可直接通过 mocklab.Start() 在随机端口上启动
 
 func main() {
-	var wg sync.WaitGroup
-
-	// 1. Mock SSH (Port 2222)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		startTCPServer("SSH", 2222, handleSSH)
-	}()
-
-	// 2. Mock Redis (Port 63790)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		startTCPServer("Redis", 63790, handleRedis)
-	}()
-
-	// 3. Mock HTTP/Elasticsearch (Port 9200)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		startHTTPServer("HTTP", 9200)
-	}()
-
-	// 4. Mock Generic TCP Sink (Port 33061 - MySQL)
-	// 仅接受连接，测试 Agent 的连接超时或握手超时
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		startTCPServer("MySQL-Sink", 33061, handleSink)
-	}()
-
-	// 5. Mock RDP (Port 33890)
-	// 发送垃圾数据，测试 Agent 是否会 Panic
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		startTCPServer("RDP", 33890, handleRDP)
-	}()
-
-	fmt.Println("Mock Lab started. Press Ctrl+C to exit.")
-	wg.Wait()
-}
-
-func handleRDP(conn net.Conn) {
-	defer conn.Close()
-	// 发送 5 字节数据，测试 tpkt.go 是否会因为长度检查不足而 panic (slice bounds out of range)
-	conn.Write([]byte("Hello"))
-}
-
-func startTCPServer(name string, port int, handler func(net.Conn)) {
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-	if err != nil {
-		fmt.Printf("[%s] Failed to start: %v\n", name, err)
-		return
-	}
-	fmt.Printf("[%s] Listening on :%d\n", name, port)
-
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			continue
-		}
-		go handler(conn)
-	}
-}
-
-func handleSink(conn net.Conn) {
-	defer conn.Close()
-	// 什么都不做，或者发送一些垃圾数据
-	// 让 Agent 认为端口是通的，但是协议握手会失败
-	time.Sleep(100 * time.Millisecond)
-	conn.Write([]byte("Not MySQL Protocol\n"))
-}
-
-func handleSSH(conn net.Conn) {
-	defer conn.Close()
-	// 1. 发送 Banner
-	conn.Write([]byte("SSH-2.0-OpenSSH_8.2p1 Ubuntu-4ubuntu0.5\r\n"))
-
-	// 2. 模拟 SSH 握手 (极简)
-	// 读取 Client Banner
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
+	// 固定端口，与 verify_mock.ps1 保持一致
+	lab, err := mocklab.StartWithPorts(mocklab.Ports{
+		SSH:   2222,
+		Redis: 63790,
+		HTTP:  9200,
+		MySQL: 33061,
+		RDP:   33890,
+	})
 	if err != nil {
-		return
+		fmt.Printf("Failed to start Mock Lab: %v\n", err)
+		os.Exit(1)
 	}
-	fmt.Printf("[SSH] Client Banner: %s", string(buf[:n]))
-
-	// 模拟密钥交换的初始化包，让客户端以为可以继续
-	// 这只是为了骗过极其简单的扫描器，对于 Go 的 crypto/ssh 可能不够
-	// 但我们的目标是 PortServiceScanner 能识别出是 SSH
-	// PortServiceScanner 只要读到 "SSH-" 开头的 Banner 就会认为是 SSH
-
-	// 对于 BruteScanner，它会尝试建立 SSH 连接
-	// 如果握手失败，它会报错。
-	// 为了演示 Pipeline 逻辑，我们可以接受 "Brute Scan Failed" 的结果
-	// 只要看到它尝试爆破了就行。
-}
-
-func handleRedis(conn net.Conn) {
-	defer conn.Close()
-	reader := bufio.NewReader(conn)
-
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			return
-		}
-		line = strings.TrimSpace(line)
-		fmt.Printf("[Redis] Received: %s\n", line)
+	defer lab.Close()
 
-		if strings.HasPrefix(line, "*") {
-			// Handle RESP Array
-			var count int
-			fmt.Sscanf(line, "*%d", &count)
-			var args []string
-			for i := 0; i < count; i++ {
-				// Read length line ($N)
-				_, err := reader.ReadString('\n')
-				if err != nil {
-					return
-				}
-				// Read value line
-				val, err := reader.ReadString('\n')
-				if err != nil {
-					return
-				}
-				args = append(args, strings.TrimSpace(val))
-			}
-
-			if len(args) == 0 {
-				continue
-			}
-
-			cmd := strings.ToUpper(args[0])
-			if cmd == "HELLO" {
-				// HELLO 3 AUTH default password123
-				if len(args) >= 5 && strings.ToUpper(args[2]) == "AUTH" && args[4] == "password123" {
-					// Return Map: {server: redis}
-					conn.Write([]byte("%1\r\n$6\r\nserver\r\n$5\r\nredis\r\n"))
-				} else {
-					conn.Write([]byte("-ERR invalid auth\r\n"))
-				}
-			} else if cmd == "AUTH" {
-				pass := ""
-				if len(args) == 2 {
-					pass = args[1]
-				} else if len(args) == 3 {
-					pass = args[2] // user pass
-				}
-				if pass == "password123" {
-					conn.Write([]byte("+OK\r\n"))
-				} else {
-					conn.Write([]byte("-ERR invalid password\r\n"))
-				}
-			} else if cmd == "PING" {
-				conn.Write([]byte("+PONG\r\n"))
-			} else {
-				conn.Write([]byte("-ERR unknown command\r\n"))
-			}
-			continue
-		}
-
-		// 简单模拟 RESP 协议 (Inline Commands)
-		if strings.HasPrefix(strings.ToUpper(line), "PING") {
-			conn.Write([]byte("+PONG\r\n"))
-		} else if strings.HasPrefix(strings.ToUpper(line), "AUTH") {
-			parts := strings.Fields(line)
-			if len(parts) > 1 && parts[1] == "password123" {
-				conn.Write([]byte("+OK\r\n"))
-			} else {
-				conn.Write([]byte("-ERR invalid password\r\n"))
-			}
-		} else if strings.HasPrefix(strings.ToUpper(line), "QUIT") {
-			conn.Write([]byte("+OK\r\n"))
-			return
-		} else {
-			conn.Write([]byte("-ERR unknown command\r\n"))
-		}
-	}
-}
-
-func startHTTPServer(name string, port int) {
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		user, pass, ok := r.BasicAuth()
-		if !ok || user != "elastic" || pass != "password123" {
-			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("Unauthorized"))
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "green"}`))
-	})
+	fmt.Printf("[SSH] Listening on %s\n", lab.Addr(lab.SSH))
+	fmt.Printf("[Redis] Listening on %s\n", lab.Addr(lab.Redis))
+	fmt.Printf("[HTTP] Listening on %s\n", lab.Addr(lab.HTTP))
+	fmt.Printf("[MySQL-Sink] Listening on %s\n", lab.Addr(lab.MySQL))
+	fmt.Printf("[RDP] Listening on %s\n", lab.Addr(lab.RDP))
+	fmt.Println("Mock Lab started. Press Ctrl+C to exit.")
 
-	fmt.Printf("[%s] Listening on :%d\n", name, port)
-	http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
 }
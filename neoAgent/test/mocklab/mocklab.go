@@ -0,0 +1,319 @@
+// Package mocklab 协议 Mock 服务集合 (SSH/Redis/HTTP/MySQL/RDP)
+// 用于在无 Docker 环境下测试 Agent 的网络层、基础协议解析与认证逻辑，
+// 不能替代 brute_lab 的真实环境测试。
+//
+// 扫描器测试中使用:
+//
+//	lab, err := mocklab.Start()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer lab.Close()
+//	// lab.Host, lab.Redis ...
+//
+// 默认绑定 127.0.0.1 的随机端口，多个测试可并行启动互不冲突。
+package mocklab
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Host Mock 服务监听地址
+	Host = "127.0.0.1"
+	// Password 可通过认证的密码 (Redis AUTH / HTTP Basic Auth)
+	Password = "password123"
+	// HTTPUser HTTP Basic Auth 用户名 (模拟 Elasticsearch)
+	HTTPUser = "elastic"
+)
+
+// Ports 各 Mock 服务的端口，为 0 时绑定随机端口
+type Ports struct {
+	SSH   int
+	Redis int
+	HTTP  int
+	MySQL int
+	RDP   int
+}
+
+// MockLab 一组运行中的协议 Mock 服务
+type MockLab struct {
+	Host string
+	Ports
+
+	listeners  []net.Listener
+	httpServer *http.Server
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Start 在随机端口上启动全部 Mock 服务
+func Start() (*MockLab, error) {
+	return StartWithPorts(Ports{})
+}
+
+// StartWithPorts 在指定端口上启动全部 Mock 服务，端口为 0 时绑定随机端口
+// 任一服务启动失败时关闭已启动的服务并返回错误
+func StartWithPorts(ports Ports) (*MockLab, error) {
+	lab := &MockLab{
+		Host:  Host,
+		conns: make(map[net.Conn]struct{}),
+	}
+
+	var err error
+	if lab.SSH, err = lab.serveTCP(ports.SSH, handleSSH); err != nil {
+		return nil, lab.fail("SSH", err)
+	}
+	if lab.Redis, err = lab.serveTCP(ports.Redis, handleRedis); err != nil {
+		return nil, lab.fail("Redis", err)
+	}
+	// MySQL Sink: 接受连接后返回垃圾数据，Agent 应判定为协议错误而不是连接超时
+	if lab.MySQL, err = lab.serveTCP(ports.MySQL, handleSink); err != nil {
+		return nil, lab.fail("MySQL", err)
+	}
+	// RDP: 仅发送 5 字节数据，Agent 不应 panic
+	if lab.RDP, err = lab.serveTCP(ports.RDP, handleRDP); err != nil {
+		return nil, lab.fail("RDP", err)
+	}
+	if lab.HTTP, err = lab.serveHTTP(ports.HTTP); err != nil {
+		return nil, lab.fail("HTTP", err)
+	}
+	return lab, nil
+}
+
+// Addr 返回 Mock 服务地址 (host:port)
+func (l *MockLab) Addr(port int) string {
+	return net.JoinHostPort(l.Host, strconv.Itoa(port))
+}
+
+// Close 关闭全部 Mock 服务及其上的连接，等待处理协程退出，可重复调用
+func (l *MockLab) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.mu.Unlock()
+
+	var errs []error
+	for _, ln := range l.listeners {
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	if l.httpServer != nil {
+		if err := l.httpServer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.wg.Wait()
+	return errors.Join(errs...)
+}
+
+// fail 启动失败时清理已启动的服务
+func (l *MockLab) fail(name string, err error) error {
+	l.Close()
+	return fmt.Errorf("mocklab: start %s: %w", name, err)
+}
+
+func (l *MockLab) listen(port int) (net.Listener, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort(Host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	l.listeners = append(l.listeners, ln)
+	return ln, nil
+}
+
+func (l *MockLab) serveTCP(port int, handler func(net.Conn)) (int, error) {
+	ln, err := l.listen(port)
+	if err != nil {
+		return 0, err
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return // listener 已关闭
+			}
+			if !l.track(conn) {
+				conn.Close()
+				return
+			}
+			l.wg.Add(1)
+			go func() {
+				defer l.wg.Done()
+				defer l.untrack(conn)
+				handler(conn)
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (l *MockLab) serveHTTP(port int) (int, error) {
+	ln, err := l.listen(port)
+	if err != nil {
+		return 0, err
+	}
+
+	// 使用独立的 ServeMux，避免多个 MockLab 共享 http.DefaultServeMux
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleHTTP)
+	l.httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.httpServer.Serve(ln)
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (l *MockLab) track(conn net.Conn) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return false
+	}
+	l.conns[conn] = struct{}{}
+	return true
+}
+
+func (l *MockLab) untrack(conn net.Conn) {
+	conn.Close()
+	l.mu.Lock()
+	delete(l.conns, conn)
+	l.mu.Unlock()
+}
+
+func handleRDP(conn net.Conn) {
+	// 发送 5 字节数据，测试 tpkt.go 是否会因为长度检查不足而 panic (slice bounds out of range)
+	conn.Write([]byte("Hello"))
+}
+
+func handleSink(conn net.Conn) {
+	// 让 Agent 认为端口是通的，但是协议握手会失败
+	time.Sleep(100 * time.Millisecond)
+	conn.Write([]byte("Not MySQL Protocol\n"))
+}
+
+func handleSSH(conn net.Conn) {
+	// 1. 发送 Banner，PortServiceScanner 读到 "SSH-" 开头的 Banner 即识别为 SSH
+	conn.Write([]byte("SSH-2.0-OpenSSH_8.2p1 Ubuntu-4ubuntu0.5\r\n"))
+
+	// 2. 读取 Client Banner 后不再继续握手，BruteScanner 的 SSH 连接会以握手失败结束
+	buf := make([]byte, 1024)
+	conn.Read(buf)
+}
+
+func handleRedis(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "*") {
+			// Handle RESP Array
+			var count int
+			fmt.Sscanf(line, "*%d", &count)
+			var args []string
+			for i := 0; i < count; i++ {
+				// Read length line ($N)
+				if _, err := reader.ReadString('\n'); err != nil {
+					return
+				}
+				// Read value line
+				val, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				args = append(args, strings.TrimSpace(val))
+			}
+
+			if len(args) == 0 {
+				continue
+			}
+
+			cmd := strings.ToUpper(args[0])
+			if cmd == "HELLO" {
+				// HELLO 3 AUTH default password123
+				if len(args) >= 5 && strings.ToUpper(args[2]) == "AUTH" && args[4] == Password {
+					// Return Map: {server: redis}
+					conn.Write([]byte("%1\r\n$6\r\nserver\r\n$5\r\nredis\r\n"))
+				} else {
+					// 与 Redis 6.0+ 认证失败时的回复一致
+					conn.Write([]byte("-WRONGPASS invalid username-password pair or user is disabled.\r\n"))
+				}
+			} else if cmd == "AUTH" {
+				pass := ""
+				if len(args) == 2 {
+					pass = args[1]
+				} else if len(args) == 3 {
+					pass = args[2] // user pass
+				}
+				if pass == Password {
+					conn.Write([]byte("+OK\r\n"))
+				} else {
+					conn.Write([]byte("-ERR invalid password\r\n"))
+				}
+			} else if cmd == "PING" {
+				conn.Write([]byte("+PONG\r\n"))
+			} else {
+				conn.Write([]byte("-ERR unknown command\r\n"))
+			}
+			continue
+		}
+
+		// 简单模拟 RESP 协议 (Inline Commands)
+		if strings.HasPrefix(strings.ToUpper(line), "PING") {
+			conn.Write([]byte("+PONG\r\n"))
+		} else if strings.HasPrefix(strings.ToUpper(line), "AUTH") {
+			parts := strings.Fields(line)
+			if len(parts) > 1 && parts[1] == Password {
+				conn.Write([]byte("+OK\r\n"))
+			} else {
+				conn.Write([]byte("-ERR invalid password\r\n"))
+			}
+		} else if strings.HasPrefix(strings.ToUpper(line), "QUIT") {
+			conn.Write([]byte("+OK\r\n"))
+			return
+		} else {
+			conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+	}
+}
+
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != HTTPUser || pass != Password {
+		w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Unauthorized"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status": "green"}`))
+}
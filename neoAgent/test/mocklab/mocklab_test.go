@@ -0,0 +1,132 @@
+package mocklab
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startLab(t *testing.T) *MockLab {
+	t.Helper()
+	lab, err := Start()
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { lab.Close() })
+	return lab
+}
+
+// redisCommand 发送 RESP 命令并读取一行响应
+func redisCommand(t *testing.T, conn net.Conn, reader *bufio.Reader, args ...string) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return strings.TrimSpace(line)
+}
+
+func TestMockLab_Redis(t *testing.T) {
+	t.Parallel()
+	lab := startLab(t)
+
+	conn, err := net.DialTimeout("tcp", lab.Addr(lab.Redis), time.Second)
+	if err != nil {
+		t.Fatalf("dial redis: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	if got := redisCommand(t, conn, reader, "AUTH", "wrong"); !strings.HasPrefix(got, "-ERR") {
+		t.Fatalf("AUTH with wrong password = %q, want error", got)
+	}
+	if got := redisCommand(t, conn, reader, "AUTH", Password); got != "+OK" {
+		t.Fatalf("AUTH = %q, want +OK", got)
+	}
+	if got := redisCommand(t, conn, reader, "PING"); got != "+PONG" {
+		t.Fatalf("PING = %q, want +PONG", got)
+	}
+}
+
+func TestMockLab_HTTPBasicAuth(t *testing.T) {
+	t.Parallel()
+	lab := startLab(t)
+
+	url := "http://" + lab.Addr(lab.HTTP) + "/"
+	for _, tt := range []struct {
+		user, pass string
+		want       int
+	}{
+		{HTTPUser, Password, http.StatusOK},
+		{HTTPUser, "wrong", http.StatusUnauthorized},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.SetBasicAuth(tt.user, tt.pass)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Fatalf("%s/%s status = %d, want %d", tt.user, tt.pass, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestMockLab_BannersAndClose(t *testing.T) {
+	t.Parallel()
+	lab := startLab(t)
+
+	for name, tt := range map[string]struct {
+		port int
+		want string
+	}{
+		"ssh": {lab.SSH, "SSH-2.0-"},
+		"rdp": {lab.RDP, "Hello"},
+	} {
+		conn, err := net.DialTimeout("tcp", lab.Addr(tt.port), time.Second)
+		if err != nil {
+			t.Fatalf("dial %s: %v", name, err)
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		conn.Close()
+		if !strings.HasPrefix(string(buf[:n]), tt.want) {
+			t.Fatalf("%s banner = %q, want prefix %q", name, buf[:n], tt.want)
+		}
+	}
+
+	// 关闭后端口不再可连接，且 Close 可重复调用
+	addr := lab.Addr(lab.SSH)
+	if err := lab.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := lab.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Fatal("expected connection refused after Close()")
+	}
+}
+
+func TestMockLab_ParallelStartUsesDistinctPorts(t *testing.T) {
+	t.Parallel()
+	a, b := startLab(t), startLab(t)
+	if a.Redis == b.Redis || a.SSH == b.SSH || a.HTTP == b.HTTP {
+		t.Fatalf("expected distinct ephemeral ports, got %+v and %+v", a.Ports, b.Ports)
+	}
+}
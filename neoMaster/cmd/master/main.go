@@ -9,6 +9,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -18,6 +19,7 @@ import (
 	"time"
 
 	"neomaster/internal/app/master"
+	"neomaster/internal/pkg/logger"
 )
 
 func main() {
@@ -41,6 +43,8 @@ func main() {
 		IdleTimeout:    config.Server.IdleTimeout,
 		MaxHeaderBytes: config.Server.MaxHeaderBytes,
 	}
+	// Shutdown 不会取消进行中请求的 ctx，关闭时通知 SSE 长连接退出
+	server.RegisterOnShutdown(app.GetRouter().CloseEventStreams)
 
 	// 启动服务器的goroutine
 	go func() {
@@ -64,13 +68,31 @@ func main() {
 	cancelScheduler()
 	app.StopScheduler()
 
-	// 给服务器5秒钟的时间来完成现有请求
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// 给服务器一定时间来完成现有请求 (server.shutdown_timeout，未配置时默认5秒)
+	shutdownTimeout := config.Server.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
+	router := app.GetRouter()
+	inFlightBefore := router.InFlightRequests()
+	shutdownStart := time.Now()
+	err = server.Shutdown(ctx)
+	inFlightAfter := router.InFlightRequests()
+	deadlineHit := errors.Is(err, context.DeadlineExceeded)
+	log.Printf("Shutdown drain: in-flight before=%d, still active=%d, elapsed=%s, timeout=%s, deadline_hit=%t",
+		inFlightBefore, inFlightAfter, time.Since(shutdownStart).Round(time.Millisecond), shutdownTimeout, deadlineHit)
+
+	// 请求排空后写完缓冲区中的审计日志
+	app.StopAuditLog()
+	if err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
 
+	// 输出采样日志中尚未汇总的抑制条数
+	logger.FlushSampledLogs()
+
 	fmt.Println("Server exiting")
 }
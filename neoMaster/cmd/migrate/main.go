@@ -13,10 +13,17 @@
     是否填充测试数据 (default true)
     -verbose
     是否显示详细日志
+    -seed-update
+    种子数据已存在时按唯一键更新非键字段 (默认只插入不更新)
+    -seed-update-passwords
+    配合 -seed-update 使用，同时重置内置用户的密码哈希
+    -only string
+    只迁移指定的模型分组，逗号分隔 (system, agent, tag, orchestrator, asset)，默认全部
 
 示例:
 main.exe -env=test -seed=true    # 测试环境迁移并填充数据
 main.exe -env=prod -seed=false   # 生产环境仅迁移表结构
+main.exe -only=orchestrator      # 仅迁移编排器相关表
 */
 package main
 
@@ -24,36 +31,48 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	assetmodel "neomaster/internal/model/asset"
 	"neomaster/internal/model/orchestrator"
 	"os"
+	"strings"
 	"time"
 
 	"neomaster/internal/config"
 	"neomaster/internal/model/agent"
+	assetmodel "neomaster/internal/model/asset"
 	"neomaster/internal/model/system"
-	"neomaster/internal/model/tag_system"
 	"neomaster/internal/pkg/database"
 	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // MigrateOptions 迁移选项配置
 type MigrateOptions struct {
-	Environment string // 环境标识: test, dev, prod
-	SeedData    bool   // 是否填充测试数据
-	DropFirst   bool   // 是否先删除表（危险操作）
-	Verbose     bool   // 是否显示详细日志
+	Environment         string // 环境标识: test, dev, prod
+	SeedData            bool   // 是否填充测试数据
+	SeedUpdate          bool   // 种子数据已存在时是否更新（默认只插入，生产环境保持不变）
+	SeedUpdatePasswords bool   // 更新种子数据时是否同时重置内置用户密码（需显式开启）
+	DropFirst           bool   // 是否先删除表（危险操作）
+	Verbose             bool   // 是否显示详细日志
+	Only                string // 只迁移指定的模型分组（逗号分隔），为空表示全部
+
+	groups groupSelection // 由 Only 解析得到的分组
 }
 
 // DataSeeder 测试数据填充器
 // 遵循"好品味"原则：简洁的数据结构，无特殊情况
 type DataSeeder struct {
-	db  *gorm.DB
-	env string
-	log *logger.LoggerManager
+	db     *gorm.DB
+	env    string
+	groups groupSelection // 只填充选中分组的数据，避免产生孤立的种子数据
+	// update 已存在的种子记录是否按唯一键更新非键字段；为 false 时保持 FirstOrCreate 只插入的行为
+	update bool
+	// updatePasswords 更新内置用户时是否覆盖密码哈希
+	updatePasswords bool
+	log             *logger.LoggerManager
 }
 
 // Fields 定义日志字段类型，避免直接依赖logrus
@@ -62,6 +81,12 @@ type Fields map[string]interface{}
 func main() {
 	// 解析命令行参数
 	opts := parseFlags()
+	groups, err := parseModelGroups(opts.Only)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "参数错误: %v\n", err)
+		os.Exit(2)
+	}
+	opts.groups = groups
 
 	// 加载配置
 	cfg, err := config.LoadConfig("", opts.Environment)
@@ -83,6 +108,7 @@ func main() {
 		"environment": opts.Environment,
 		"seed_data":   opts.SeedData,
 		"drop_first":  opts.DropFirst,
+		"groups":      opts.groups.Names(),
 	}).Info("开始数据库迁移")
 
 	// 初始化数据库连接
@@ -123,8 +149,11 @@ func parseFlags() *MigrateOptions {
 
 	flag.StringVar(&opts.Environment, "env", "test", "环境标识 (test, dev, prod)")
 	flag.BoolVar(&opts.SeedData, "seed", true, "是否填充测试数据")
+	flag.BoolVar(&opts.SeedUpdate, "seed-update", false, "种子数据已存在时按唯一键更新非键字段（默认只插入不更新）")
+	flag.BoolVar(&opts.SeedUpdatePasswords, "seed-update-passwords", false, "配合 -seed-update 使用，同时重置内置用户的密码哈希")
 	flag.BoolVar(&opts.DropFirst, "drop", false, "是否先删除表（危险操作）")
 	flag.BoolVar(&opts.Verbose, "verbose", false, "是否显示详细日志")
+	flag.StringVar(&opts.Only, "only", "", fmt.Sprintf("只迁移指定的模型分组，逗号分隔 (%s)，默认全部", strings.Join(modelGroupNames(), ", ")))
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "NeoScan 数据库迁移工具\n\n")
@@ -134,6 +163,7 @@ func parseFlags() *MigrateOptions {
 		fmt.Fprintf(os.Stderr, "\n示例:\n")
 		fmt.Fprintf(os.Stderr, "  %s -env=test -seed=true    # 测试环境迁移并填充数据\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -env=prod -seed=false   # 生产环境仅迁移表结构\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -only=orchestrator      # 仅迁移编排器相关表\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -145,23 +175,28 @@ func parseFlags() *MigrateOptions {
 func performMigration(db *gorm.DB, opts *MigrateOptions, logManager *logger.LoggerManager) error {
 	// 1. 删除表（如果指定）
 	if opts.DropFirst {
-		if err := dropTables(db, logManager); err != nil {
+		if err := dropTables(db, opts.groups, logManager); err != nil {
 			return fmt.Errorf("删除表失败: %w", err)
 		}
 	}
 
-	if err := prepareAssetVulnsForConstraints(db, logManager); err != nil {
-		return fmt.Errorf("准备 asset_vulns 数据失败: %w", err)
+	// 迁移版本记录表，手工迁移步骤执行后记录版本，已执行的步骤不再重复执行
+	if err := ensureSchemaMigrationsTable(db); err != nil {
+		return fmt.Errorf("创建迁移版本记录表失败: %w", err)
+	}
+
+	if err := runMigrationSteps(db, preMigrationSteps, opts.groups, logManager); err != nil {
+		return fmt.Errorf("执行前置迁移步骤失败: %w", err)
 	}
 
 	// 2. 执行模型迁移
-	if err := migrateModels(db, logManager); err != nil {
+	if err := migrateModels(db, opts.groups, logManager); err != nil {
 		return fmt.Errorf("模型迁移失败: %w", err)
 	}
 
 	// 3. 填充测试数据（如果指定）
 	if opts.SeedData {
-		seeder := NewDataSeeder(db, opts.Environment, logManager)
+		seeder := NewDataSeeder(db, opts, logManager)
 		if err := seeder.SeedAll(); err != nil {
 			return fmt.Errorf("数据填充失败: %w", err)
 		}
@@ -170,121 +205,88 @@ func performMigration(db *gorm.DB, opts *MigrateOptions, logManager *logger.Logg
 	return nil
 }
 
-// dropTables 删除所有表
+// dropTables 删除选中分组的表
 // 危险操作，仅用于开发环境重置
-func dropTables(db *gorm.DB, logManager *logger.LoggerManager) error {
+func dropTables(db *gorm.DB, groups groupSelection, logManager *logger.LoggerManager) error {
 	logManager.GetLogger().WithFields(logrus.Fields{
 		"path":      "cmd/migrate/main.go",
 		"operation": "drop_tables",
 		"option":    "dropTables",
 		"func_name": "dropTables",
+		"groups":    groups.Names(),
 	}).Warn("开始删除数据库表")
 
-	// 定义所有模型（按依赖关系逆序）- 只包含实际存在的模型
-	models := []interface{}{
-		// 关联表先删除
-		&system.UserRole{},
-		&system.RolePermission{},
-		// &agent.AgentGroupMember{}, // 暂时注释：模型未定义
-
-		// 标签系统
-		&tag_system.SysEntityTag{},
-		&tag_system.SysMatchRule{},
-		&tag_system.SysTag{},
-
-		// 主表后删除
-		&system.User{},
-		&system.Role{},
-		&system.Permission{},
-		&agent.Agent{},
-		&agent.AgentVersion{},
-		&agent.AgentConfig{},
-		&agent.AgentMetrics{},
-		// &agent.AgentGroup{}, // 暂时注释：模型未定义
-		&agent.ScanType{},
-
-		// Orchestrator模块 (New)
-		&orchestrator.Project{},
-		&orchestrator.Workflow{},
-		&orchestrator.ProjectWorkflow{},
-		&orchestrator.ScanStage{},
-		&orchestrator.AgentTask{},
-		&orchestrator.StageResult{},
-		&orchestrator.ScanToolTemplate{},
-	}
-
-	for _, model := range models {
-		if err := db.Migrator().DropTable(model); err != nil {
-			logManager.GetLogger().WithFields(logrus.Fields{
-				"path":      "cmd/migrate/main.go",
-				"operation": "drop_table",
-				"option":    "db.Migrator().DropTable",
-				"func_name": "dropTables",
-				"model":     fmt.Sprintf("%T", model),
-				"error":     err.Error(),
-			}).Error("删除表失败")
+	// 按迁移顺序的逆序删除分组，分组内按 DropModels 顺序（关联表在前）
+	selected := groups.Groups()
+	for i := len(selected) - 1; i >= 0; i-- {
+		for _, model := range selected[i].DropModels {
+			if err := db.Migrator().DropTable(model); err != nil {
+				logManager.GetLogger().WithFields(logrus.Fields{
+					"path":      "cmd/migrate/main.go",
+					"operation": "drop_table",
+					"option":    "db.Migrator().DropTable",
+					"func_name": "dropTables",
+					"model":     fmt.Sprintf("%T", model),
+					"error":     err.Error(),
+				}).Error("删除表失败")
+			}
 		}
 	}
 
+	// 迁移版本记录，表删除后对应分组的手工迁移步骤需要重新执行
+	if err := clearMigrationRecords(db, groups); err != nil {
+		logManager.GetLogger().WithFields(logrus.Fields{
+			"path":      "cmd/migrate/main.go",
+			"operation": "drop_table",
+			"option":    "clearMigrationRecords",
+			"func_name": "dropTables",
+			"error":     err.Error(),
+		}).Error("清理迁移版本记录失败")
+	}
+
 	return nil
 }
 
-// migrateModels 执行模型迁移
-func migrateModels(db *gorm.DB, loggerMgr *logger.LoggerManager) error {
+// migrateModels 执行选中分组的模型迁移
+func migrateModels(db *gorm.DB, groups groupSelection, loggerMgr *logger.LoggerManager) error {
 	loggerMgr.GetLogger().Info("开始执行模型迁移...")
 
-	// 定义所有需要迁移的模型
-	models := []interface{}{
-		// 系统模块
-		&system.User{},
-		&system.Role{},
-		&system.Permission{},
-		&system.LoginRequest{},
-
-		// Agent模块
-		&agent.Agent{},
-		&agent.AgentVersion{},
-		&agent.AgentConfig{},
-		&agent.AgentMetrics{},
-		// &agent.AgentGroup{},       // 暂时注释：模型未定义
-		// &agent.AgentGroupMember{}, // 暂时注释：模型未定义
-		&agent.ScanType{},
-
-		// 标签系统
-		&tag_system.SysTag{},
-		&tag_system.SysMatchRule{},
-		&tag_system.SysEntityTag{},
-
-		// Orchestrator模块 (New)
-		&orchestrator.Project{},
-		&orchestrator.Workflow{},
-		&orchestrator.ProjectWorkflow{},
-		&orchestrator.ScanStage{},
-		&orchestrator.AgentTask{},
-		&orchestrator.StageResult{},
-		&orchestrator.ScanToolTemplate{},
-
-		&assetmodel.AssetVuln{},
-		&assetmodel.AssetVulnPoc{},
-	}
-
 	// 执行自动迁移
-	for _, model := range models {
-		if err := db.AutoMigrate(model); err != nil {
-			return fmt.Errorf("迁移模型 %T 失败: %w", model, err)
+	for _, group := range groups.Groups() {
+		for _, model := range group.Models {
+			if err := db.AutoMigrate(model); err != nil {
+				return fmt.Errorf("迁移模型 %T 失败: %w", model, err)
+			}
+			loggerMgr.GetLogger().WithFields(logrus.Fields{
+				"group": group.Name,
+				"model": fmt.Sprintf("%T", model),
+			}).Info("模型迁移成功")
 		}
-		loggerMgr.GetLogger().WithField("model", fmt.Sprintf("%T", model)).Info("模型迁移成功")
 	}
 
-	// 手动处理关联表的特殊字段
-	if err := fixAssociationTables(db, loggerMgr); err != nil {
+	// 手动处理关联表的特殊字段（按版本记录，只执行一次）
+	if err := runMigrationSteps(db, postMigrationSteps, groups, loggerMgr); err != nil {
 		return fmt.Errorf("修复关联表失败: %w", err)
 	}
 
+	// 关联表结构同步（声明式，每次执行）
+	if groups[GroupSystem] {
+		associationModels := []interface{}{
+			&system.RolePermission{},
+		}
+		for _, model := range associationModels {
+			if err := db.AutoMigrate(model); err != nil {
+				return fmt.Errorf("迁移关联表 %T 失败: %w", model, err)
+			}
+			loggerMgr.GetLogger().WithField("model", fmt.Sprintf("%T", model)).Info("关联表迁移成功")
+		}
+	}
+
 	loggerMgr.GetLogger().Info("所有模型迁移完成")
 	return nil
 }
 
+// prepareAssetVulnsForConstraints 为 asset_vulns 唯一约束清洗历史数据
 func prepareAssetVulnsForConstraints(db *gorm.DB, loggerMgr *logger.LoggerManager) error {
 	if !db.Migrator().HasTable("asset_vulns") {
 		return nil
@@ -376,6 +378,106 @@ func prepareAssetVulnsForConstraints(db *gorm.DB, loggerMgr *logger.LoggerManage
 	return nil
 }
 
+// dedupAgentSeedKeys 为种子数据自然键的唯一索引清洗历史数据
+// agent_versions.version、agent_scan_types.name 在添加唯一索引前可能已有重复行，AutoMigrate 建索引会直接失败；
+// 每组重复只保留 id 最小的一行 (原 FirstOrCreate 按 id 顺序读到的就是这一行)
+func dedupAgentSeedKeys(db *gorm.DB, loggerMgr *logger.LoggerManager) error {
+	targets := []struct {
+		table  string
+		column string
+	}{
+		{table: (&agent.AgentVersion{}).TableName(), column: "version"},
+		{table: (&agent.ScanType{}).TableName(), column: "name"},
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, target := range targets {
+			if !tx.Migrator().HasTable(target.table) {
+				continue
+			}
+			// 派生表包一层，MySQL 不允许 DELETE 的子查询直接引用目标表
+			result := tx.Exec(fmt.Sprintf(`
+				DELETE FROM %[1]s
+				WHERE id NOT IN (
+					SELECT keep_id FROM (
+						SELECT MIN(id) AS keep_id FROM %[1]s GROUP BY %[2]s
+					) AS keep_rows
+				)`, target.table, target.column))
+			if result.Error != nil {
+				return fmt.Errorf("%s 去重失败: %w", target.table, result.Error)
+			}
+
+			loggerMgr.GetLogger().WithFields(logrus.Fields{
+				"path":      "cmd/migrate/main.go",
+				"operation": "dedup_agent_seed_keys",
+				"option":    target.table,
+				"func_name": "dedupAgentSeedKeys",
+				"deleted":   result.RowsAffected,
+			}).Info("唯一索引前去重完成")
+		}
+		return nil
+	})
+}
+
+// addAgentSearchIndex 为 agents 表添加全文索引
+// 仅 MySQL 支持；索引不存在时仓储层退化为 LIKE 匹配 + 精确/前缀分档排序，不影响搜索结果
+func addAgentSearchIndex(db *gorm.DB, loggerMgr *logger.LoggerManager) error {
+	if db.Dialector.Name() != "mysql" || !db.Migrator().HasTable(&agent.Agent{}) {
+		return nil
+	}
+	if db.Migrator().HasIndex(&agent.Agent{}, agent.AgentSearchIndex) {
+		return nil
+	}
+	if err := db.Exec(fmt.Sprintf("ALTER TABLE agents ADD FULLTEXT INDEX %s (%s)", agent.AgentSearchIndex, agent.AgentSearchIndexColumns)).Error; err != nil {
+		return fmt.Errorf("创建 agents 全文索引失败: %w", err)
+	}
+
+	loggerMgr.GetLogger().WithFields(logrus.Fields{
+		"path":      "cmd/migrate/main.go",
+		"operation": "add_agent_search_index",
+		"option":    "addAgentSearchIndex",
+		"func_name": "addAgentSearchIndex",
+	}).Info("agents 全文索引创建完成")
+	return nil
+}
+
+// backfillAssetHostIPHex 为历史主机补写 ip_hex，网段范围查询依赖该列
+func backfillAssetHostIPHex(db *gorm.DB, loggerMgr *logger.LoggerManager) error {
+	if !db.Migrator().HasTable(&assetmodel.AssetHost{}) {
+		return nil
+	}
+
+	var updated int64
+	var hosts []*assetmodel.AssetHost
+	err := db.Model(&assetmodel.AssetHost{}).Select("id, ip").
+		Where("ip_hex IS NULL OR ip_hex = ''").
+		FindInBatches(&hosts, 500, func(tx *gorm.DB, batch int) error {
+			for _, h := range hosts {
+				key := utils.IPSortKey(h.IP)
+				if key == "" {
+					continue
+				}
+				if err := db.Model(&assetmodel.AssetHost{}).Where("id = ?", h.ID).UpdateColumn("ip_hex", key).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return fmt.Errorf("补写 asset_hosts.ip_hex 失败: %w", err)
+	}
+
+	loggerMgr.GetLogger().WithFields(logrus.Fields{
+		"path":      "cmd/migrate/main.go",
+		"operation": "backfill_asset_host_ip_hex",
+		"option":    "backfillAssetHostIPHex",
+		"func_name": "backfillAssetHostIPHex",
+		"updated":   updated,
+	}).Info("asset_hosts.ip_hex 补写完成")
+	return nil
+}
+
 // fixAssociationTables 修复关联表的特殊字段
 func fixAssociationTables(db *gorm.DB, loggerMgr *logger.LoggerManager) error {
 	loggerMgr.GetLogger().Info("开始修复关联表字段...")
@@ -424,29 +526,48 @@ func fixAssociationTables(db *gorm.DB, loggerMgr *logger.LoggerManager) error {
 		}
 	}
 
-	// 3. 强制重新迁移关联表
-	associationModels := []interface{}{
-		&system.RolePermission{},
-	}
-
-	for _, model := range associationModels {
-		if err := db.AutoMigrate(model); err != nil {
-			return fmt.Errorf("重新迁移关联表 %T 失败: %w", model, err)
-		}
-		loggerMgr.GetLogger().WithField("model", fmt.Sprintf("%T", model)).Info("关联表重新迁移成功")
-	}
-
 	loggerMgr.GetLogger().Info("关联表字段修复完成")
 	return nil
 }
 
 // NewDataSeeder 创建数据填充器
-func NewDataSeeder(db *gorm.DB, env string, logManager *logger.LoggerManager) *DataSeeder {
+func NewDataSeeder(db *gorm.DB, opts *MigrateOptions, logManager *logger.LoggerManager) *DataSeeder {
 	return &DataSeeder{
-		db:  db,
-		env: env,
-		log: logManager,
+		db:              db,
+		env:             opts.Environment,
+		groups:          opts.groups,
+		update:          opts.SeedUpdate,
+		updatePasswords: opts.SeedUpdate && opts.SeedUpdatePasswords,
+		log:             logManager,
+	}
+}
+
+// upsertByKey 按唯一键写入种子记录
+// 只插入模式下与 FirstOrCreate 一致；更新模式下使用 ON CONFLICT 更新 updateColumns，
+// 写入后按唯一键重新加载，保证 record 的 ID 等字段与数据库一致
+// 注意: keyColumn 必须有唯一索引，否则更新模式会插入重复记录
+func (s *DataSeeder) upsertByKey(record interface{}, keyColumn string, keyValue interface{}, updateColumns []string) error {
+	if !s.update {
+		return s.db.Where(keyColumn+" = ?", keyValue).FirstOrCreate(record).Error
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: keyColumn}},
+		DoUpdates: clause.AssignmentColumns(updateColumns),
+	}).Create(record).Error
+	if err != nil {
+		return err
 	}
+	return s.db.Where(keyColumn+" = ?", keyValue).First(record).Error
+}
+
+// firstOrAssign 按查询条件写入没有唯一索引的种子记录
+// 只插入模式下与 FirstOrCreate 一致；更新模式下已存在的记录会被 assign 中的字段覆盖
+func (s *DataSeeder) firstOrAssign(record interface{}, assign map[string]interface{}, query string, args ...interface{}) error {
+	tx := s.db.Where(query, args...)
+	if s.update {
+		tx = tx.Assign(assign)
+	}
+	return tx.FirstOrCreate(record).Error
 }
 
 // SeedAll 填充所有测试数据
@@ -462,15 +583,19 @@ func (s *DataSeeder) SeedAll() error {
 
 	// 按依赖关系顺序填充数据
 	seedFunctions := []struct {
-		name string
-		fn   func() error
+		name  string
+		group string
+		fn    func() error
 	}{
-		{"系统基础数据", s.seedSystemData},
-		{"Agent测试数据", s.seedAgentData},
-		{"扫描配置数据", s.seedOrchestratorData},
+		{"系统基础数据", GroupSystem, s.seedSystemData},
+		{"Agent测试数据", GroupAgent, s.seedAgentData},
+		{"扫描配置数据", GroupOrchestrator, s.seedOrchestratorData},
 	}
 
 	for _, seed := range seedFunctions {
+		if !s.groups[seed.group] {
+			continue
+		}
 		s.log.GetLogger().WithFields(logrus.Fields{
 			"path":      "cmd/migrate/main.go",
 			"operation": "seed_module",
@@ -503,7 +628,7 @@ func (s *DataSeeder) seedSystemData() error {
 	}
 
 	for _, role := range roles {
-		if err := s.db.Where("name = ?", role.Name).FirstOrCreate(&role).Error; err != nil {
+		if err := s.upsertByKey(&role, "name", role.Name, []string{"display_name", "description", "status"}); err != nil {
 			return fmt.Errorf("创建角色失败: %w", err)
 		}
 	}
@@ -526,7 +651,7 @@ func (s *DataSeeder) seedSystemData() error {
 	}
 
 	for _, perm := range permissions {
-		if err := s.db.Where("name = ?", perm.Name).FirstOrCreate(&perm).Error; err != nil {
+		if err := s.upsertByKey(&perm, "name", perm.Name, []string{"display_name", "description", "resource", "action", "status"}); err != nil {
 			return fmt.Errorf("创建权限失败: %w", err)
 		}
 	}
@@ -541,7 +666,7 @@ func (s *DataSeeder) seedSystemData() error {
 		Status:   1,
 	}
 
-	if err := s.db.Where("username = ?", adminUser.Username).FirstOrCreate(&adminUser).Error; err != nil {
+	if err := s.upsertByKey(&adminUser, "username", adminUser.Username, s.userUpdateColumns()); err != nil {
 		return fmt.Errorf("创建管理员用户失败: %w", err)
 	}
 
@@ -553,7 +678,7 @@ func (s *DataSeeder) seedSystemData() error {
 		Nickname: "系统用户-仅系统使用",
 		Status:   1,
 	}
-	if err := s.db.Where("username = ?", sysUser.Username).FirstOrCreate(&sysUser).Error; err != nil {
+	if err := s.upsertByKey(&sysUser, "username", sysUser.Username, s.userUpdateColumns()); err != nil {
 		return fmt.Errorf("创建系统用户失败: %w", err)
 	}
 
@@ -594,6 +719,16 @@ func (s *DataSeeder) seedSystemData() error {
 	return nil
 }
 
+// userUpdateColumns 内置用户更新时覆盖的字段
+// 密码哈希默认不覆盖，避免重新执行种子后线上已修改的管理员密码被重置
+func (s *DataSeeder) userUpdateColumns() []string {
+	columns := []string{"email", "nickname", "status"}
+	if s.updatePasswords {
+		columns = append(columns, "password")
+	}
+	return columns
+}
+
 // seedAgentData 填充Agent测试数据
 func (s *DataSeeder) seedAgentData() error {
 	// 1. 创建Agent版本
@@ -617,7 +752,8 @@ func (s *DataSeeder) seedAgentData() error {
 	}
 
 	for _, version := range versions {
-		if err := s.db.Where("version = ?", version.Version).FirstOrCreate(&version).Error; err != nil {
+		// is_latest 不随种子更新，避免覆盖线上手工指定的最新版本
+		if err := s.upsertByKey(&version, "version", version.Version, []string{"release_date", "changelog", "download_url", "is_active"}); err != nil {
 			return fmt.Errorf("创建Agent版本失败: %w", err)
 		}
 	}
@@ -826,7 +962,7 @@ func (s *DataSeeder) seedAgentData() error {
 	}
 
 	for _, scanType := range scanTypes {
-		if err := s.db.Where("name = ?", scanType.Name).FirstOrCreate(&scanType).Error; err != nil {
+		if err := s.upsertByKey(&scanType, "name", scanType.Name, []string{"display_name", "description", "category", "is_active", "is_system", "config_template"}); err != nil {
 			return fmt.Errorf("创建扫描类型失败: %w", err)
 		}
 	}
@@ -873,7 +1009,8 @@ func (s *DataSeeder) seedAgentData() error {
 		}
 
 		for _, ag := range agents {
-			if err := s.db.Where("agent_id = ?", ag.AgentID).FirstOrCreate(&ag).Error; err != nil {
+			// 运行时状态(status/last_heartbeat/token)不随种子更新
+			if err := s.upsertByKey(&ag, "agent_id", ag.AgentID, []string{"hostname", "ip_address", "port", "version", "os", "arch", "cpu_cores", "memory_total", "disk_total", "remark"}); err != nil {
 				return fmt.Errorf("创建测试Agent失败: %w", err)
 			}
 		}
@@ -909,7 +1046,15 @@ func (s *DataSeeder) seedOrchestratorData() error {
 	}
 
 	for _, tmpl := range scanToolTemplates {
-		if err := s.db.Where("name = ?", tmpl.Name).FirstOrCreate(&tmpl).Error; err != nil {
+		// 模板名称没有唯一索引（用户可创建同名模板），按 名称+创建者 定位系统模板
+		assign := map[string]interface{}{
+			"tool_name":   tmpl.ToolName,
+			"tool_params": tmpl.ToolParams,
+			"description": tmpl.Description,
+			"category":    tmpl.Category,
+			"is_public":   tmpl.IsPublic,
+		}
+		if err := s.firstOrAssign(&tmpl, assign, "name = ? AND created_by = ?", tmpl.Name, tmpl.CreatedBy); err != nil {
 			return fmt.Errorf("创建扫描工具模板失败: %w", err)
 		}
 		s.log.GetLogger().WithField("template", tmpl.Name).Info("扫描工具模板创建成功")
@@ -928,7 +1073,8 @@ func (s *DataSeeder) seedOrchestratorData() error {
 		CreatedBy:    1,
 	}
 
-	if err := s.db.Where("name = ?", project.Name).FirstOrCreate(&project).Error; err != nil {
+	// 运行状态(status)不随种子更新
+	if err := s.upsertByKey(&project, "name", project.Name, []string{"display_name", "description", "target_scope", "schedule_type", "exec_mode"}); err != nil {
 		return fmt.Errorf("创建项目失败: %w", err)
 	}
 	s.log.GetLogger().WithField("project", project.Name).Info("项目创建成功")
@@ -944,7 +1090,7 @@ func (s *DataSeeder) seedOrchestratorData() error {
 		CreatedBy:   1,
 	}
 
-	if err := s.db.Where("name = ?", workflow.Name).FirstOrCreate(&workflow).Error; err != nil {
+	if err := s.upsertByKey(&workflow, "name", workflow.Name, []string{"display_name", "version", "description", "exec_mode", "enabled"}); err != nil {
 		return fmt.Errorf("创建工作流失败: %w", err)
 	}
 	s.log.GetLogger().WithField("workflow", workflow.Name).Info("工作流创建成功")
@@ -970,7 +1116,13 @@ func (s *DataSeeder) seedOrchestratorData() error {
 	}
 
 	for _, stage := range stages {
-		if err := s.db.Where("workflow_id = ? AND stage_name = ?", stage.WorkflowID, stage.StageName).FirstOrCreate(&stage).Error; err != nil {
+		assign := map[string]interface{}{
+			"stage_type":  stage.StageType,
+			"tool_name":   stage.ToolName,
+			"tool_params": stage.ToolParams,
+			"enabled":     stage.Enabled,
+		}
+		if err := s.firstOrAssign(&stage, assign, "workflow_id = ? AND stage_name = ?", stage.WorkflowID, stage.StageName); err != nil {
 			return fmt.Errorf("创建扫描阶段失败: %w", err)
 		}
 	}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"neomaster/internal/model/agent"
+	assetmodel "neomaster/internal/model/asset"
+	"neomaster/internal/model/orchestrator"
+	"neomaster/internal/model/system"
+	"neomaster/internal/model/tag_system"
+)
+
+// 模型分组名称 (-only 参数取值)
+const (
+	GroupSystem       = "system"
+	GroupAgent        = "agent"
+	GroupTag          = "tag"
+	GroupOrchestrator = "orchestrator"
+	GroupAsset        = "asset"
+)
+
+// modelGroup 一组可独立迁移的模型
+type modelGroup struct {
+	Name   string
+	Models []interface{} // 迁移顺序
+	// DropModels 删除顺序 (关联表在前，主表在后)；为空表示 -drop 时不删除该组的表
+	DropModels []interface{}
+}
+
+// modelGroups 所有模型分组，顺序即迁移顺序
+var modelGroups = []*modelGroup{
+	{
+		Name: GroupSystem,
+		Models: []interface{}{
+			&system.User{},
+			&system.Role{},
+			&system.Permission{},
+			&system.LoginRequest{},
+			&system.AuditLog{},
+			&system.APIKey{},
+		},
+		DropModels: []interface{}{
+			// 关联表先删除
+			&system.UserRole{},
+			&system.RolePermission{},
+			&system.APIKey{},
+			&system.User{},
+			&system.Role{},
+			&system.Permission{},
+			&system.AuditLog{},
+		},
+	},
+	{
+		Name: GroupAgent,
+		Models: []interface{}{
+			&agent.Agent{},
+			&agent.AgentVersion{},
+			&agent.AgentConfig{},
+			&agent.AgentGroupConfig{},
+			&agent.AgentMetrics{},
+			&agent.AgentEnrollmentToken{},
+			// &agent.AgentGroup{},       // 暂时注释：模型未定义
+			// &agent.AgentGroupMember{}, // 暂时注释：模型未定义
+			&agent.ScanType{},
+		},
+		DropModels: []interface{}{
+			// &agent.AgentGroupMember{}, // 暂时注释：模型未定义
+			&agent.Agent{},
+			&agent.AgentVersion{},
+			&agent.AgentConfig{},
+			&agent.AgentGroupConfig{},
+			&agent.AgentMetrics{},
+			&agent.AgentEnrollmentToken{},
+			// &agent.AgentGroup{}, // 暂时注释：模型未定义
+			&agent.ScanType{},
+		},
+	},
+	{
+		Name: GroupTag,
+		Models: []interface{}{
+			&tag_system.SysTag{},
+			&tag_system.SysMatchRule{},
+			&tag_system.SysEntityTag{},
+			&tag_system.SysRetagJob{},
+		},
+		DropModels: []interface{}{
+			&tag_system.SysRetagJob{},
+			&tag_system.SysEntityTag{},
+			&tag_system.SysMatchRule{},
+			&tag_system.SysTag{},
+		},
+	},
+	{
+		Name: GroupOrchestrator,
+		Models: []interface{}{
+			&orchestrator.Project{},
+			&orchestrator.Workflow{},
+			&orchestrator.ProjectWorkflow{},
+			&orchestrator.ScanStage{},
+			&orchestrator.AgentTask{},
+			&orchestrator.StageResult{},
+			&orchestrator.ScanToolTemplate{},
+			&orchestrator.DispatchLock{},
+			&orchestrator.WebhookSubscription{},
+			&orchestrator.WebhookDelivery{},
+			&orchestrator.ResultBatch{},
+		},
+		DropModels: []interface{}{
+			&orchestrator.Project{},
+			&orchestrator.Workflow{},
+			&orchestrator.ProjectWorkflow{},
+			&orchestrator.ScanStage{},
+			&orchestrator.AgentTask{},
+			&orchestrator.StageResult{},
+			&orchestrator.ScanToolTemplate{},
+			&orchestrator.DispatchLock{},
+			&orchestrator.WebhookSubscription{},
+			&orchestrator.WebhookDelivery{},
+			&orchestrator.ResultBatch{},
+		},
+	},
+	{
+		// 资产表保存扫描沉淀数据，-drop 时不删除
+		Name: GroupAsset,
+		Models: []interface{}{
+			&assetmodel.AssetHost{},
+			&assetmodel.AssetService{},
+			&assetmodel.AssetVuln{},
+			&assetmodel.AssetVulnPoc{},
+		},
+	},
+}
+
+// modelGroupNames 所有分组名称
+func modelGroupNames() []string {
+	names := make([]string, 0, len(modelGroups))
+	for _, g := range modelGroups {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// groupSelection 选中的模型分组
+type groupSelection map[string]bool
+
+// parseModelGroups 解析 -only 参数 (逗号分隔)，为空表示全部分组
+func parseModelGroups(only string) (groupSelection, error) {
+	selected := make(groupSelection)
+	only = strings.TrimSpace(only)
+	if only == "" {
+		for _, g := range modelGroups {
+			selected[g.Name] = true
+		}
+		return selected, nil
+	}
+
+	valid := make(map[string]bool, len(modelGroups))
+	for _, g := range modelGroups {
+		valid[g.Name] = true
+	}
+	var unknown []string
+	for _, name := range strings.Split(only, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !valid[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		selected[name] = true
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("未知的模型分组: %s (可选: %s)", strings.Join(unknown, ", "), strings.Join(modelGroupNames(), ", "))
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("-only 未指定有效的模型分组 (可选: %s)", strings.Join(modelGroupNames(), ", "))
+	}
+	return selected, nil
+}
+
+// All 是否选中了全部分组
+func (s groupSelection) All() bool {
+	for _, g := range modelGroups {
+		if !s[g.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+// Groups 按迁移顺序返回选中的分组
+func (s groupSelection) Groups() []*modelGroup {
+	groups := make([]*modelGroup, 0, len(s))
+	for _, g := range modelGroups {
+		if s[g.Name] {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// Names 按迁移顺序返回选中的分组名称
+func (s groupSelection) Names() []string {
+	names := make([]string, 0, len(s))
+	for _, g := range s.Groups() {
+		names = append(names, g.Name)
+	}
+	return names
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"neomaster/internal/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SchemaMigration 迁移版本记录
+// 每个手工迁移步骤执行成功后写入一条记录，再次运行时已记录的步骤直接跳过
+type SchemaMigration struct {
+	Version     string    `gorm:"primaryKey;type:varchar(100)"` // 步骤版本号 (日期_描述)
+	Description string    `gorm:"type:varchar(255)"`            // 步骤说明
+	AppliedAt   time.Time `gorm:"not null"`                     // 执行时间
+	DurationMs  int64     `gorm:"not null;default:0"`           // 执行耗时(毫秒)
+}
+
+// TableName 表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrationStep 带版本号的手工迁移步骤
+// 版本号一旦发布不得修改，新步骤只能追加
+type migrationStep struct {
+	Version     string
+	Group       string // 所属模型分组，-only 未选中该分组时不执行
+	Description string
+	Run         func(db *gorm.DB, loggerMgr *logger.LoggerManager) error
+}
+
+// preMigrationSteps 在 AutoMigrate 之前执行的步骤 (如添加唯一约束前的数据清洗)
+var preMigrationSteps = []migrationStep{
+	{
+		Version:     "20251220_prepare_asset_vulns_constraints",
+		Group:       GroupAsset,
+		Description: "asset_vulns 补齐 id_alias 并去重，为唯一约束做准备",
+		Run:         prepareAssetVulnsForConstraints,
+	},
+	{
+		Version:     "20261016_dedup_agent_seed_keys",
+		Group:       GroupAgent,
+		Description: "agent_versions.version / agent_scan_types.name 去重，为唯一索引做准备",
+		Run:         dedupAgentSeedKeys,
+	},
+}
+
+// postMigrationSteps 在 AutoMigrate 之后执行的步骤 (关联表结构修复等)
+var postMigrationSteps = []migrationStep{
+	{
+		Version:     "20251015_fix_association_tables",
+		Group:       GroupSystem,
+		Description: "role_permissions 补 created_at 字段，user_roles 缺少 id 时重建",
+		Run:         fixAssociationTables,
+	},
+	{
+		Version:     "20261016_add_agents_fulltext_index",
+		Group:       GroupAgent,
+		Description: "agents 添加全文索引，用于关键词搜索的相关度排序",
+		Run:         addAgentSearchIndex,
+	},
+	{
+		Version:     "20261016_backfill_asset_hosts_ip_hex",
+		Group:       GroupAsset,
+		Description: "asset_hosts 补写 ip_hex，支持按网段的索引范围查询",
+		Run:         backfillAssetHostIPHex,
+	},
+}
+
+// ensureSchemaMigrationsTable 创建迁移版本记录表
+func ensureSchemaMigrationsTable(db *gorm.DB) error {
+	return db.AutoMigrate(&SchemaMigration{})
+}
+
+// clearMigrationRecords 删除选中分组的迁移版本记录 (-drop 时调用)
+// 选中全部分组时直接删除记录表，否则只删除对应分组步骤的记录
+func clearMigrationRecords(db *gorm.DB, selection groupSelection) error {
+	if selection.All() {
+		return db.Migrator().DropTable(&SchemaMigration{})
+	}
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil
+	}
+	var versions []string
+	for _, steps := range [][]migrationStep{preMigrationSteps, postMigrationSteps} {
+		for _, step := range steps {
+			if selection[step.Group] {
+				versions = append(versions, step.Version)
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return nil
+	}
+	return db.Where("version IN ?", versions).Delete(&SchemaMigration{}).Error
+}
+
+// runMigrationSteps 依次执行选中分组中未记录的迁移步骤，并在成功后记录版本
+func runMigrationSteps(db *gorm.DB, steps []migrationStep, selection groupSelection, loggerMgr *logger.LoggerManager) error {
+	var applied []string
+	if err := db.Model(&SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return fmt.Errorf("读取迁移版本记录失败: %w", err)
+	}
+	appliedSet := make(map[string]struct{}, len(applied))
+	for _, v := range applied {
+		appliedSet[v] = struct{}{}
+	}
+
+	for _, step := range steps {
+		if !selection[step.Group] {
+			continue
+		}
+		fields := logrus.Fields{
+			"path":      "cmd/migrate/schema_migrations.go",
+			"operation": "migration_step",
+			"option":    step.Version,
+			"func_name": "runMigrationSteps",
+		}
+		if _, ok := appliedSet[step.Version]; ok {
+			loggerMgr.GetLogger().WithFields(fields).Info("迁移步骤已执行，跳过")
+			continue
+		}
+
+		start := time.Now()
+		if err := step.Run(db, loggerMgr); err != nil {
+			return fmt.Errorf("迁移步骤 %s 执行失败: %w", step.Version, err)
+		}
+		record := &SchemaMigration{
+			Version:     step.Version,
+			Description: step.Description,
+			AppliedAt:   time.Now(),
+			DurationMs:  time.Since(start).Milliseconds(),
+		}
+		if err := db.Create(record).Error; err != nil {
+			return fmt.Errorf("记录迁移版本 %s 失败: %w", step.Version, err)
+		}
+		fields["duration_ms"] = record.DurationMs
+		loggerMgr.GetLogger().WithFields(fields).Info("迁移步骤执行完成")
+	}
+	return nil
+}
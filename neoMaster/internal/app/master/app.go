@@ -18,13 +18,17 @@ import (
 	"fmt"
 	"log"
 	"neomaster/internal/service/asset/etl"
+	authService "neomaster/internal/service/auth"
+	"neomaster/internal/service/notify/webhook"
 	"neomaster/internal/service/orchestrator/core/scheduler"
 	"neomaster/internal/service/orchestrator/local_agent"
+	"time"
 
 	"neomaster/internal/app/master/router"
 	"neomaster/internal/config"
 	"neomaster/internal/pkg/database"
 	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/robfig/cron/v3"
@@ -41,6 +45,8 @@ type App struct {
 	localAgent *local_agent.LocalAgent
 	etl        etl.ResultProcessor
 	cron       *cron.Cron // 系统级 Cron，用于后台维护任务
+	audit      *authService.AuditService
+	webhook    *webhook.Dispatcher
 }
 
 // NewApp 创建新的应用程序实例
@@ -58,6 +64,15 @@ func NewApp() (*App, error) {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
+	// 设置时间格式化输出的默认时区 (app.timezone)，未配置时统一输出 UTC
+	timezone := cfg.App.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if err := utils.SetDefaultTimeZone(timezone); err != nil {
+		log.Printf("Warning: Failed to set default timezone: %v", err)
+	}
+
 	// 记录应用启动日志
 	logger.LogBusinessOperation("app_start", 0, "", "", "", "success", "NeoMaster application starting", map[string]interface{}{
 		"version":   "1.0.0",
@@ -162,6 +177,12 @@ func NewApp() (*App, error) {
 	localAgent := router.GetLocalAgent()
 	etlProcessor := router.GetETLProcessor()
 
+	// 系统级Cron: 注册后台维护任务
+	systemCron, err := newSystemCron(router)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init system cron: %w", err)
+	}
+
 	return &App{
 		db:         db,
 		router:     router,
@@ -170,9 +191,32 @@ func NewApp() (*App, error) {
 		scheduler:  schedulerService,
 		localAgent: localAgent,
 		etl:        etlProcessor,
+		cron:       systemCron,
+		audit:      router.GetAuditService(),
+		webhook:    router.GetWebhookDispatcher(),
 	}, nil
 }
 
+// agentOfflineSweepSpec Agent离线巡检周期
+// 每个Agent按自身离线阈值判定，巡检周期只影响判定的及时性
+const agentOfflineSweepSpec = "@every 30s"
+
+// newSystemCron 创建系统级Cron并注册后台维护任务
+func newSystemCron(r *router.Router) (*cron.Cron, error) {
+	c := cron.New()
+	monitorService := r.GetAgentMonitorService()
+	if monitorService != nil {
+		_, err := c.AddFunc(agentOfflineSweepSpec, func() {
+			// 错误已在服务层记录，下一周期重试
+			_, _ = monitorService.MarkStaleAgentsOffline(time.Now())
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
 // GetRouter 获取路由器实例
 func (a *App) GetRouter() *router.Router { // 返回类型使用router包中的Router类型
 	return a.router
@@ -197,6 +241,10 @@ func (a *App) StartScheduler(ctx context.Context) {
 	if a.etl != nil {
 		a.etl.Start(ctx)
 	}
+	// Webhook 事件投递启动
+	if a.webhook != nil {
+		a.webhook.Start()
+	}
 	// 系统级Cron服务启动
 	if a.cron != nil {
 		a.cron.Start()
@@ -218,6 +266,19 @@ func (a *App) StopScheduler() {
 	if a.etl != nil {
 		a.etl.Stop()
 	}
+	// 最后停止 Webhook 投递，调度器与 ETL 停止前产生的事件仍可入队
+	if a.webhook != nil {
+		a.webhook.Stop()
+	}
+}
+
+// StopAuditLog 停止审计日志落库，写完缓冲区中剩余的审计条目
+// 应在 HTTP 请求排空之后调用，保证最后一批请求产生的审计日志也能落库
+func (a *App) StopAuditLog() {
+	if a.audit != nil {
+		logger.SetAuditSink(nil)
+		a.audit.Stop()
+	}
 }
 
 // Start 启动应用程序（可选方法，用于未来扩展）
@@ -4,8 +4,10 @@
 package middleware
 
 import (
+	"errors"
 	"neomaster/internal/model/system"
 	"neomaster/internal/pkg/logger"
+	agentService "neomaster/internal/service/agent"
 	"net/http"
 	"strings"
 
@@ -46,31 +48,38 @@ func (m *MiddlewareManager) GinAgentAuthMiddleware() gin.HandlerFunc {
 		logger.LogInfo("Agent Auth Middleware Checking Token", "", 0, "", c.Request.URL.Path, c.Request.Method, map[string]interface{}{
 			"func_name": "GinAgentAuthMiddleware",
 			"token_len": len(token),
-			"token_pre": token[:10],
 		})
 
-		// 2. 验证 Token
-		// Linus: 使用 AgentService 查询 Token，保持层级清晰
-		agent, err := m.agentService.GetAgentByToken(token)
+		// 2. 验证 Token (存在性 + 有效期)
+		// Linus: 使用 AgentService 校验 Token，保持层级清晰
+		agent, err := m.agentService.AuthenticateAgentToken(token)
 		if err != nil {
-			logger.LogError(err, "", 0, "", "GinAgentAuthMiddleware", "GetAgentByToken", map[string]interface{}{
-				"token": token,
-			})
-			c.JSON(http.StatusInternalServerError, system.APIResponse{
-				Code:    http.StatusInternalServerError,
-				Status:  "failed",
-				Message: "internal server error",
-			})
-			c.Abort()
-			return
-		}
-
-		if agent == nil {
-			c.JSON(http.StatusUnauthorized, system.APIResponse{
-				Code:    http.StatusUnauthorized,
-				Status:  "failed",
-				Message: "invalid token",
-			})
+			switch {
+			case errors.Is(err, agentService.ErrAgentTokenExpired):
+				// 过期单独返回错误码，Agent 据此重新接入，而不是当作普通鉴权失败重试
+				c.JSON(http.StatusUnauthorized, system.APIResponse{
+					Code:    http.StatusUnauthorized,
+					Status:  "failed",
+					Message: "agent token expired",
+					Error:   "AGENT_TOKEN_EXPIRED",
+				})
+			case errors.Is(err, agentService.ErrInvalidAgentToken):
+				c.JSON(http.StatusUnauthorized, system.APIResponse{
+					Code:    http.StatusUnauthorized,
+					Status:  "failed",
+					Message: "invalid token",
+					Error:   "AGENT_TOKEN_INVALID",
+				})
+			default:
+				logger.LogError(err, "", 0, "", "GinAgentAuthMiddleware", "AuthenticateAgentToken", map[string]interface{}{
+					"token_len": len(token),
+				})
+				c.JSON(http.StatusInternalServerError, system.APIResponse{
+					Code:    http.StatusInternalServerError,
+					Status:  "failed",
+					Message: "internal server error",
+				})
+			}
 			c.Abort()
 			return
 		}
@@ -0,0 +1,199 @@
+/**
+ * 中间件:API 密钥认证
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 服务间调用(CI 等)使用 Authorization: ApiKey <key> 认证，由 JWT 认证中间件统一分派；
+ *               认证通过后与 JWT 一样写入 user_id/username，后续的用户状态、角色、权限中间件照常工作，
+ *               权限中间件另外要求密钥的授权范围覆盖所需权限；
+ *               默认拒绝 API 密钥，只有通过 GinAPIKeyScopeMiddleware 声明了资源的路由组接受密钥，
+ *               且密钥须覆盖 resource:read(GET/HEAD/OPTIONS) 或 resource:write(其余方法)
+ * @func:
+ *   - SetAPIKeyAuthenticator 注入 API 密钥认证服务
+ *   - GinAPIKeyScopeMiddleware 声明路由组接受的 API 密钥资源范围
+ *   - GinDenyAPIKeyMiddleware 拒绝 API 密钥访问(用于账号凭据管理接口)
+ */
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// APIKeyAuthScheme API 密钥认证方案，请求头格式 Authorization: ApiKey <key>
+	APIKeyAuthScheme = "ApiKey"
+	// APIKeyContextKey Gin 上下文中保存当前请求 API 密钥(*system.APIKey)的键，JWT 认证的请求没有该键
+	APIKeyContextKey = "api_key"
+	// apiKeyScopeContextKey 路由组声明的 API 密钥资源范围
+	apiKeyScopeContextKey = "api_key_scope"
+)
+
+// APIKeyAuthenticator API 密钥认证 (auth.APIKeyService 已实现)
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey, clientIP string) (*system.APIKey, *system.User, error)
+}
+
+// SetAPIKeyAuthenticator 注入 API 密钥认证服务，未注入时拒绝所有 API 密钥请求
+func (m *MiddlewareManager) SetAPIKeyAuthenticator(authenticator APIKeyAuthenticator) {
+	m.apiKeyAuthenticator = authenticator
+}
+
+// GinAPIKeyScopeMiddleware 声明路由组接受 API 密钥访问的资源范围，必须挂在 JWT 认证中间件之前
+// 未声明的路由一律拒绝 API 密钥；JWT 认证的请求不受影响
+// 使用方式: group.Use(middlewareManager.GinAPIKeyScopeMiddleware("asset"), middlewareManager.GinJWTAuthMiddleware())
+func (m *MiddlewareManager) GinAPIKeyScopeMiddleware(resource string) gin.HandlerFunc {
+	if resource == "" || strings.Contains(resource, ":") {
+		panic("middleware: GinAPIKeyScopeMiddleware requires a resource name")
+	}
+	return func(c *gin.Context) {
+		c.Set(apiKeyScopeContextKey, resource)
+		c.Next()
+	}
+}
+
+// apiKeyScopeAction 请求方法对应的密钥操作范围: 只读方法为 read，其余为 write
+func apiKeyScopeAction(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read"
+	default:
+		return "write"
+	}
+}
+
+// extractAPIKeyFromGinHeader 请求头为 ApiKey 认证方案时返回密钥
+func extractAPIKeyFromGinHeader(c *gin.Context) (string, bool) {
+	scheme, rawKey, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || scheme != APIKeyAuthScheme {
+		return "", false
+	}
+	return strings.TrimSpace(rawKey), true
+}
+
+// authenticateAPIKey 认证 API 密钥并写入用户上下文，失败时直接写出响应并中止
+func (m *MiddlewareManager) authenticateAPIKey(c *gin.Context, rawKey string) {
+	clientIP := utils.GetClientIP(c)
+	if m.apiKeyAuthenticator == nil {
+		c.JSON(http.StatusUnauthorized, system.APIResponse{
+			Code:      http.StatusUnauthorized,
+			Status:    "failed",
+			Message:   "api key authentication is not enabled",
+			ErrorCode: system.ErrCodeUnauthorized,
+		})
+		c.Abort()
+		return
+	}
+
+	key, user, err := m.apiKeyAuthenticator.Authenticate(c.Request.Context(), rawKey, clientIP)
+	if err != nil {
+		statusCode := http.StatusUnauthorized
+		if errors.Is(err, system.ErrUserDisabled) {
+			statusCode = http.StatusForbidden
+		}
+		logger.LogBusinessError(err, c.GetHeader("X-Request-ID"), 0, clientIP, c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+			"operation":   "api_key_auth",
+			"func_name":   "middleware.api_key.authenticateAPIKey",
+			"status_code": statusCode,
+			"timestamp":   logger.NowFormatted(),
+		})
+		errorCode := system.ErrorCodeOf(err)
+		if errorCode == "" {
+			errorCode = system.ErrCodeAPIKeyInvalid
+		}
+		c.JSON(statusCode, system.APIResponse{
+			Code:      statusCode,
+			Status:    "failed",
+			Message:   "invalid or expired api key",
+			Error:     err.Error(),
+			ErrorCode: errorCode,
+		})
+		c.Abort()
+		return
+	}
+
+	// 默认拒绝: 路由组须声明资源范围，且密钥覆盖本次请求的读/写操作
+	resource := c.GetString(apiKeyScopeContextKey)
+	if resource == "" {
+		c.JSON(http.StatusForbidden, system.APIResponse{
+			Code:      http.StatusForbidden,
+			Status:    "failed",
+			Message:   "api key is not allowed for this endpoint",
+			Error:     system.ErrAPIKeyNotAllowed.Error(),
+			ErrorCode: system.ErrCodePermissionDenied,
+		})
+		c.Abort()
+		return
+	}
+	if action := apiKeyScopeAction(c.Request.Method); !key.AllowsScope(resource, action) {
+		abortAPIKeyScope(c, resource+":"+action)
+		return
+	}
+
+	// 与 JWT 认证写入相同的用户上下文，后续中间件与 Handler 无需区分认证方式
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("roles", []string{})
+	c.Set("permissions", []string{})
+	c.Set(APIKeyContextKey, key)
+	c.Request = c.Request.WithContext(logger.ContextWithUserID(c.Request.Context(), user.ID))
+	c.Next()
+}
+
+// apiKeyFromContext 获取当前请求使用的 API 密钥，JWT 认证的请求返回 nil
+func apiKeyFromContext(c *gin.Context) *system.APIKey {
+	value, exists := c.Get(APIKeyContextKey)
+	if !exists {
+		return nil
+	}
+	key, _ := value.(*system.APIKey)
+	return key
+}
+
+// apiKeyAllowsAny API 密钥授权范围是否覆盖任意一个所需权限
+func apiKeyAllowsAny(key *system.APIKey, required []requiredPermission) bool {
+	for _, r := range required {
+		if key.AllowsScope(r.resource, r.action) {
+			return true
+		}
+	}
+	return false
+}
+
+// abortAPIKeyScope 写出密钥授权范围不足的响应
+func abortAPIKeyScope(c *gin.Context, requiredText string) {
+	c.JSON(http.StatusForbidden, system.APIResponse{
+		Code:      http.StatusForbidden,
+		Status:    "failed",
+		Message:   "api key scope " + requiredText + " required",
+		ErrorCode: system.ErrCodePermissionDenied,
+	})
+	c.Abort()
+}
+
+// GinDenyAPIKeyMiddleware 拒绝使用 API 密钥访问，要求交互式登录(JWT)
+// 用于修改密码、两步验证、API 密钥管理等账号凭据接口，防止泄露的密钥被用来扩大或延续权限
+// 使用方式: group.POST("/api-keys", middlewareManager.GinDenyAPIKeyMiddleware(), handler)
+func (m *MiddlewareManager) GinDenyAPIKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKeyFromContext(c) != nil {
+			c.JSON(http.StatusForbidden, system.APIResponse{
+				Code:      http.StatusForbidden,
+				Status:    "failed",
+				Message:   "api key is not allowed for this endpoint",
+				Error:     system.ErrAPIKeyNotAllowed.Error(),
+				ErrorCode: system.ErrCodePermissionDenied,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
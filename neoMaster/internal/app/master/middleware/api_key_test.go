@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	"neomaster/internal/model/system"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubAPIKeyAuthenticator 按密钥明文返回固定的密钥
+type stubAPIKeyAuthenticator struct {
+	keys map[string]*system.APIKey
+	now  time.Time
+}
+
+func (s *stubAPIKeyAuthenticator) Authenticate(_ context.Context, rawKey, _ string) (*system.APIKey, *system.User, error) {
+	key, ok := s.keys[rawKey]
+	if !ok {
+		return nil, nil, system.ErrAPIKeyInvalid
+	}
+	if key.IsExpired(s.now) {
+		return nil, nil, system.ErrAPIKeyExpired
+	}
+	return key, &system.User{ID: key.UserID, Username: "ci"}, nil
+}
+
+func newAPIKeyEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	m := &MiddlewareManager{securityConfig: &config.SecurityConfig{}}
+	m.SetPermissionProvider(&stubPermissionProvider{permissions: map[uint][]*system.Permission{
+		1: {{Resource: "*", Action: "*"}},
+	}})
+	m.SetAPIKeyAuthenticator(&stubAPIKeyAuthenticator{now: now, keys: map[string]*system.APIKey{
+		"nsk_scan":       {ID: 1, UserID: 1, Scopes: []string{"scan:*"}},
+		"nsk_expired":    {ID: 2, UserID: 1, Scopes: []string{"*:*"}, ExpiresAt: &expired},
+		"nsk_asset_read": {ID: 3, UserID: 1, Scopes: []string{"asset:read"}},
+		"nsk_all":        {ID: 4, UserID: 1, Scopes: []string{"*:*"}},
+	}})
+
+	engine := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	group := engine.Group("", m.GinAPIKeyScopeMiddleware("scan"), m.GinJWTAuthMiddleware())
+	group.POST("/scans", m.RequirePermission("scan:submit"), ok)
+	group.POST("/rules/import", m.RequirePermission("system:admin"), ok)
+	group.POST("/api-keys", m.GinDenyAPIKeyMiddleware(), ok)
+
+	assets := engine.Group("/asset", m.GinAPIKeyScopeMiddleware("asset"), m.GinJWTAuthMiddleware())
+	assets.GET("/hosts", ok)
+	assets.POST("/hosts", ok)
+	tags := engine.Group("/tags", m.GinAPIKeyScopeMiddleware("tag"), m.GinJWTAuthMiddleware())
+	tags.POST("", ok)
+	// 未声明资源范围的路由组
+	engine.GET("/user/profile", m.GinJWTAuthMiddleware(), ok)
+	return engine
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	engine := newAPIKeyEngine()
+
+	tests := []struct {
+		name   string
+		method string // 默认 POST
+		path   string
+		header string
+		want   int
+	}{
+		{name: "scope_allowed", path: "/scans", header: "ApiKey nsk_scan", want: http.StatusOK},
+		{name: "scope_missing_denied", path: "/rules/import", header: "ApiKey nsk_scan", want: http.StatusForbidden},
+		{name: "expired_key_rejected", path: "/scans", header: "ApiKey nsk_expired", want: http.StatusUnauthorized},
+		{name: "unknown_key_rejected", path: "/scans", header: "ApiKey nsk_unknown", want: http.StatusUnauthorized},
+		{name: "credential_endpoint_denied", path: "/api-keys", header: "ApiKey nsk_scan", want: http.StatusForbidden},
+		{name: "narrow_key_reads_asset", method: http.MethodGet, path: "/asset/hosts", header: "ApiKey nsk_asset_read", want: http.StatusOK},
+		{name: "narrow_key_asset_write_denied", path: "/asset/hosts", header: "ApiKey nsk_asset_read", want: http.StatusForbidden},
+		{name: "narrow_key_tag_denied", path: "/tags", header: "ApiKey nsk_asset_read", want: http.StatusForbidden},
+		{name: "other_resource_key_denied", method: http.MethodGet, path: "/asset/hosts", header: "ApiKey nsk_scan", want: http.StatusForbidden},
+		{name: "undeclared_route_denied", method: http.MethodGet, path: "/user/profile", header: "ApiKey nsk_all", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(method, tt.path, nil)
+			req.Header.Set("Authorization", tt.header)
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -4,10 +4,11 @@
  * @date: 2025.10.10
  * @description: 定义认证相关中间件
  * @func:
- *   - GinJWTAuthMiddleware: Gin JWT认证中间件
+ *   - GinJWTAuthMiddleware: Gin JWT认证中间件(ApiKey 认证方案转交 API 密钥认证)
  *   - GinUserActiveMiddleware: 检查用户是否活跃中间件
  *   - GinAdminRoleMiddleware: 检查用户是否具有管理员角色中间件
  *   - GinRequireAnyRole: 检查用户是否具有任意角色中间件[未使用]
+ *   - GinRequirePermission: 检查用户是否具有指定权限中间件
  *   - extractTokenFromGinHeader: 从Gin请求头中提取JWT令牌
  */
 package middleware
@@ -83,6 +84,12 @@ func (m *MiddlewareManager) GinJWTAuthMiddleware() gin.HandlerFunc {
 			})
 		}
 
+		// Authorization: ApiKey <key> 走 API 密钥认证
+		if rawKey, ok := extractAPIKeyFromGinHeader(c); ok {
+			m.authenticateAPIKey(c, rawKey)
+			return
+		}
+
 		// 从请求头中提取访问令牌
 		accessToken, err := m.extractTokenFromGinHeader(c)
 		if err != nil {
@@ -200,6 +207,8 @@ func (m *MiddlewareManager) GinJWTAuthMiddleware() gin.HandlerFunc {
 		c.Set("roles", []string{})       // User模型中没有直接的Roles字段
 		c.Set("permissions", []string{}) // User模型中没有直接的Permissions字段
 		c.Set("claims", claims)
+		// 同时写入标准上下文，Service 层可通过 logger.WithContext(ctx) 自动带上用户ID
+		c.Request = c.Request.WithContext(logger.ContextWithUserID(c.Request.Context(), claims.ID))
 
 		// 继续处理请求
 		c.Next()
@@ -321,6 +330,12 @@ func (m *MiddlewareManager) GinAdminRoleMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// API 密钥还需授权 system:admin 范围
+		if key := apiKeyFromContext(c); key != nil && !key.AllowsScope("system", "admin") {
+			abortAPIKeyScope(c, "system:admin")
+			return
+		}
+
 		// 继续处理请求
 		c.Next()
 	}
@@ -383,6 +398,13 @@ func (m *MiddlewareManager) GinRequireAnyRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
+// GinRequirePermission Gin权限验证中间件
+// 验证用户是否拥有 resource:action 权限(支持权限表中的 * 通配)，等价于 RequirePermission(resource + ":" + action)
+// 使用方式: router.Use(middlewareManager.GinRequirePermission("system", "admin"))
+func (m *MiddlewareManager) GinRequirePermission(resource, action string) gin.HandlerFunc {
+	return m.RequirePermission(resource + ":" + action)
+}
+
 // =============================================================================
 // 辅助方法
 // =============================================================================
@@ -393,6 +415,12 @@ func (m *MiddlewareManager) GinRequireAnyRole(roles ...string) gin.HandlerFunc {
 func (m *MiddlewareManager) extractTokenFromGinHeader(c *gin.Context) (string, error) {
 	authorization := c.GetHeader("Authorization")
 	if authorization == "" {
+		// 浏览器 WebSocket / EventSource API 无法设置请求头，这两类请求允许通过 access_token 查询参数携带令牌
+		if isWebSocketUpgrade(c) || isEventStream(c) {
+			if token := c.Query("access_token"); token != "" {
+				return token, nil
+			}
+		}
 		return "", &system.ValidationError{Field: "authorization", Message: "authorization header is required"}
 	}
 
@@ -410,6 +438,16 @@ func (m *MiddlewareManager) extractTokenFromGinHeader(c *gin.Context) (string, e
 	return token, nil
 }
 
+// isWebSocketUpgrade 是否为 WebSocket 握手请求
+func isWebSocketUpgrade(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
+
+// isEventStream 是否为 Server-Sent Events 请求
+func isEventStream(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
 // isPathSkipped 检查路径是否在跳过列表中
 func (m *MiddlewareManager) isPathSkipped(requestPath string, skipPaths []string) bool {
 	for _, skipPath := range skipPaths {
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neomaster/internal/config"
+	"neomaster/internal/model/system"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newBodyLimitEngine 默认上限 16 字节，/import 放宽到 64 字节；Handler 与业务代码一样绑定 JSON，失败返回 400
+func newBodyLimitEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use((&MiddlewareManager{}).GinBodyLimitMiddleware(&config.BodyLimitConfig{
+		MaxBytes: 16,
+		Routes:   []config.RouteBodyLimit{{Path: "/import", MaxBytes: 64}},
+	}))
+	bind := func(c *gin.Context) {
+		var req map[string]interface{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, system.APIResponse{Code: http.StatusBadRequest, Status: "failed", Message: "invalid request", Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, system.APIResponse{Code: http.StatusOK, Status: "success", Message: "ok"})
+	}
+	engine.POST("/login", bind)
+	engine.POST("/import", bind)
+	return engine
+}
+
+func assertPayloadTooLarge(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413; body = %s", w.Code, w.Body.String())
+	}
+	var resp system.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body is not a single APIResponse: %v; body = %s", err, w.Body.String())
+	}
+	if resp.Code != http.StatusRequestEntityTooLarge || resp.Status != "failed" || resp.ErrorCode != system.ErrCodePayloadTooLarge {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestGinBodyLimitMiddleware_RejectsDeclaredOversizeBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"admin","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newBodyLimitEngine().ServeHTTP(w, req)
+
+	assertPayloadTooLarge(t, w)
+}
+
+func TestGinBodyLimitMiddleware_RejectsChunkedOversizeBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	// 未声明长度，只有读取时才会发现超限；Handler 写出的 400 必须被丢弃
+	req := httptest.NewRequest(http.MethodPost, "/login", io.NopCloser(strings.NewReader(`{"username":"admin","password":"secret"}`)))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	newBodyLimitEngine().ServeHTTP(w, req)
+
+	assertPayloadTooLarge(t, w)
+}
+
+func TestGinBodyLimitMiddleware_RouteOverride(t *testing.T) {
+	engine := newBodyLimitEngine()
+	body := `{"rules":["a","b","c","d"]}` // 27 字节: 超过默认上限，未超过 /import 的上限
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Errorf("/import status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body)))
+	assertPayloadTooLarge(t, w)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(strings.Repeat("x", 65))))
+	assertPayloadTooLarge(t, w)
+}
@@ -0,0 +1,215 @@
+/**
+ * 中间件:响应压缩
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 根据客户端 Accept-Encoding 对响应进行 gzip 压缩
+ * @func:
+ *   - GinCompressionMiddleware 响应压缩中间件
+ */
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"neomaster/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultCompressionMinLength = 1024 // 默认最小压缩字节数
+
+// GinCompressionMiddleware 响应压缩中间件
+// 1. 客户端 Accept-Encoding 不含 gzip、HEAD 请求、WebSocket 升级请求、排除路径不压缩
+// 2. 响应体先缓冲，达到 MinLength 才开始压缩，小响应原样输出
+// 3. 响应已设置 Content-Encoding 或内容类型在排除列表中(已压缩格式)时不压缩
+// 4. 只改写 Content-Encoding/Content-Length/Vary，Content-Disposition 等其他头保持不变
+func (m *MiddlewareManager) GinCompressionMiddleware(cfg *config.CompressionConfig) gin.HandlerFunc {
+	if cfg == nil || !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	level := cfg.Level
+	if level < gzip.BestSpeed || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	minLength := cfg.MinLength
+	if minLength <= 0 {
+		minLength = defaultCompressionMinLength
+	}
+	excludedPaths := cfg.ExcludedPaths
+	excludedTypes := make([]string, 0, len(cfg.ExcludedContentTypes))
+	for _, t := range cfg.ExcludedContentTypes {
+		excludedTypes = append(excludedTypes, strings.ToLower(strings.TrimSpace(t)))
+	}
+
+	pool := &sync.Pool{
+		New: func() interface{} {
+			gz, _ := gzip.NewWriterLevel(nil, level)
+			return gz
+		},
+	}
+
+	return func(c *gin.Context) {
+		if !shouldCompressRequest(c.Request, excludedPaths) {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{
+			ResponseWriter: c.Writer,
+			pool:           pool,
+			minLength:      minLength,
+			excludedTypes:  excludedTypes,
+		}
+		c.Writer = writer
+		defer writer.finish()
+
+		c.Next()
+	}
+}
+
+// shouldCompressRequest 根据请求判断是否可能压缩
+func shouldCompressRequest(req *http.Request, excludedPaths []string) bool {
+	if req.Method == http.MethodHead {
+		return false
+	}
+	if !strings.Contains(strings.ToLower(req.Header.Get("Accept-Encoding")), "gzip") {
+		return false
+	}
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	for _, prefix := range excludedPaths {
+		if prefix != "" && strings.HasPrefix(req.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// gzipResponseWriter 延迟决定是否压缩的 ResponseWriter
+// 在响应体达到阈值(或 Flush)之前，写入内容暂存在 buf 中
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	pool          *sync.Pool
+	minLength     int
+	excludedTypes []string
+
+	buf      bytes.Buffer
+	gz       *gzip.Writer
+	decided  bool
+	compress bool
+}
+
+// Write 写入响应体
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.compress {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minLength {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString 写入字符串响应体
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 流式响应需要立即输出，此时未决定的响应不再压缩
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.compress {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Size 返回已写入(压缩前)的字节数，未决定前返回缓冲区长度
+func (w *gzipResponseWriter) Size() int {
+	if !w.decided {
+		return w.buf.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Written 缓冲中的响应视为已写入，避免 Gin 重复写默认响应
+func (w *gzipResponseWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// decide 决定是否压缩，并将缓冲内容写出
+// wantCompress 为 false 时强制不压缩 (如响应体未达阈值或需要流式输出)
+func (w *gzipResponseWriter) decide(wantCompress bool) error {
+	w.decided = true
+	w.compress = wantCompress && w.compressible()
+
+	if w.compress {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+
+		w.gz = w.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	data := w.buf.Bytes()
+	w.buf = bytes.Buffer{}
+	var err error
+	if w.compress {
+		_, err = w.gz.Write(data)
+	} else {
+		_, err = w.ResponseWriter.Write(data)
+	}
+	return err
+}
+
+// compressible 根据响应头判断是否适合压缩
+func (w *gzipResponseWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	switch w.Status() {
+	case http.StatusNoContent, http.StatusNotModified:
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, excluded := range w.excludedTypes {
+		if excluded != "" && strings.HasPrefix(contentType, excluded) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish 请求结束: 输出未达阈值的缓冲内容，或关闭 gzip 流
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.compress {
+		_ = w.gz.Close()
+		w.gz.Reset(nil)
+		w.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neomaster/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCompressionEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use((&MiddlewareManager{}).GinCompressionMiddleware(&config.CompressionConfig{
+		Enabled:              true,
+		Level:                5,
+		MinLength:            256,
+		ExcludedContentTypes: []string{"application/zip"},
+	}))
+	large := strings.Repeat("neoscan ", 200)
+	engine.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": large})
+	})
+	engine.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "ok"})
+	})
+	engine.GET("/export", func(c *gin.Context) {
+		c.Header("Content-Disposition", `attachment; filename="rules.json"`)
+		c.Data(http.StatusOK, "application/json", []byte(large))
+	})
+	engine.GET("/archive", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/zip", []byte(large))
+	})
+	return engine
+}
+
+func doCompressionRequest(engine *gin.Engine, path string, acceptGzip bool) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptGzip {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestGinCompressionMiddleware_CompressesLargeResponse(t *testing.T) {
+	w := doCompressionRequest(newCompressionEngine(), "/large", true)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip Content-Encoding, got %q", w.Header().Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("invalid gzip body: %v", err)
+	}
+	body, _ := io.ReadAll(gz)
+	if !strings.Contains(string(body), "neoscan neoscan") {
+		t.Errorf("unexpected decompressed body: %.60s", body)
+	}
+}
+
+func TestGinCompressionMiddleware_SkipsSmallAndUnsupported(t *testing.T) {
+	engine := newCompressionEngine()
+
+	if w := doCompressionRequest(engine, "/small", true); w.Header().Get("Content-Encoding") != "" || w.Body.String() != `{"data":"ok"}` {
+		t.Errorf("small response should not be compressed: encoding=%q body=%s", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+	if w := doCompressionRequest(engine, "/large", false); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("response without Accept-Encoding should not be compressed")
+	}
+	if w := doCompressionRequest(engine, "/archive", true); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("excluded content type should not be compressed")
+	}
+}
+
+func TestGinCompressionMiddleware_KeepsContentDisposition(t *testing.T) {
+	w := doCompressionRequest(newCompressionEngine(), "/export", true)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("expected gzip Content-Encoding, got %q", w.Header().Get("Content-Encoding"))
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="rules.json"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
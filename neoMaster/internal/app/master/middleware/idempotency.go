@@ -0,0 +1,442 @@
+/**
+ * 中间件:Idempotency-Key 幂等
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 创建类 POST 接口按 用户 + 接口 + Idempotency-Key 记录首次响应，
+ *               网络重试带上同一个键时直接返回首次响应，不再重复创建资源；
+ *               记录默认存放在 Redis(多实例共享)，未注入时退化为进程内存储
+ * @func:
+ *   - GinIdempotencyMiddleware 幂等中间件[挂在具体的创建接口上]
+ *   - SetIdempotencyStore 注入幂等记录存储
+ */
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader 客户端生成的幂等键请求头
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 响应为首次响应的重放时返回 true
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyLockTimeout = 30 * time.Second
+	idempotencyPollInterval       = 100 * time.Millisecond
+	maxIdempotencyKeyLength       = 255
+)
+
+// IdempotencyStore 幂等记录存储 (redis.IdempotencyRepository 已实现)
+type IdempotencyStore interface {
+	// Reserve 键不存在时写入记录并返回 true
+	Reserve(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error)
+	// Get 获取记录，键不存在时返回 nil
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Replace 仅当记录仍为 expected 时覆盖写入并重置有效期，返回是否写入
+	Replace(ctx context.Context, key string, expected []byte, data []byte, ttl time.Duration) (bool, error)
+	// Release 仅当记录仍为 expected 时删除，返回是否删除
+	Release(ctx context.Context, key string, expected []byte) (bool, error)
+}
+
+// SetIdempotencyStore 注入幂等记录存储，需在注册路由前调用
+func (m *MiddlewareManager) SetIdempotencyStore(store IdempotencyStore) {
+	m.idempotencyStore = store
+}
+
+// idempotencyRecord 幂等记录: 处理中时只有请求指纹和占位者，完成后保存首次响应
+// Owner 为占位请求的随机令牌，续期/保存/释放都以"记录仍为本请求的占位"为前提，
+// 占位过期后被其他请求抢占时，原请求不会覆盖或删除新占位
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Owner       string `json:"owner,omitempty"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// GinIdempotencyMiddleware 幂等中间件
+// 1. 未携带 Idempotency-Key 的请求不受影响
+// 2. 首个请求占位后执行 Handler，响应状态码非 5xx/408/429 时保存响应，有效期 security.idempotency.ttl
+// 3. 同一个键的并发请求轮询等待首个请求完成后返回其响应，等待超过 lock_timeout 返回 409
+// 4. 同一个键用于不同的请求(路径或请求体不同)返回 422
+// 需挂在 JWT 认证中间件之后才能按用户区分；存储异常时放行请求，避免 Redis 故障导致接口不可用
+func (m *MiddlewareManager) GinIdempotencyMiddleware() gin.HandlerFunc {
+	ttl, lockTimeout := defaultIdempotencyTTL, defaultIdempotencyLockTimeout
+	if m.securityConfig != nil {
+		if m.securityConfig.Idempotency.TTL > 0 {
+			ttl = m.securityConfig.Idempotency.TTL
+		}
+		if m.securityConfig.Idempotency.LockTimeout > 0 {
+			lockTimeout = m.securityConfig.Idempotency.LockTimeout
+		}
+	}
+	store := m.idempotencyStore
+	if store == nil {
+		store = newMemoryIdempotencyStore()
+	}
+
+	return func(c *gin.Context) {
+		if m.securityConfig == nil || !m.securityConfig.Idempotency.Enabled {
+			c.Next()
+			return
+		}
+		idempotencyKey := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+
+		clientIP := utils.GetClientIP(c)
+		if !validIdempotencyKey(idempotencyKey) {
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:      http.StatusBadRequest,
+				Status:    "failed",
+				Message:   "invalid Idempotency-Key header",
+				Error:     "Idempotency-Key must be 1-255 printable ASCII characters",
+				ErrorCode: system.ErrCodeInvalidParam,
+			})
+			c.Abort()
+			return
+		}
+
+		fingerprint, ok := requestFingerprint(c)
+		if !ok {
+			return
+		}
+		keyHash := sha256.Sum256([]byte(idempotencyKey))
+		key := requestSubject(c, clientIP) + ":" + requestEndpoint(c) + ":" + hex.EncodeToString(keyHash[:])
+		logFields := map[string]interface{}{
+			"operation": "idempotency_check",
+			"func_name": "middleware.idempotency.GinIdempotencyMiddleware",
+			"key":       key,
+		}
+		storeUnavailable := func(err error) {
+			logFields["option"] = "store_error"
+			logFields["error"] = err.Error()
+			logger.LogWarn("Idempotency store unavailable, request allowed", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, logFields)
+			c.Next()
+		}
+
+		ctx := c.Request.Context()
+		pending, _ := json.Marshal(&idempotencyRecord{Fingerprint: fingerprint, Owner: rand.Text()})
+		deadline := m.currentTime().Add(lockTimeout)
+		for {
+			// 占位有效期为 lock_timeout，处理期间持续续期；处理中的实例异常退出后占位到期自动释放
+			reserved, err := store.Reserve(ctx, key, pending, lockTimeout)
+			if err != nil {
+				storeUnavailable(err)
+				return
+			}
+			if reserved {
+				m.executeIdempotent(c, store, key, fingerprint, pending, lockTimeout, ttl)
+				return
+			}
+
+			data, err := store.Get(ctx, key)
+			if err != nil {
+				storeUnavailable(err)
+				return
+			}
+			if data == nil {
+				continue // 占位刚被释放(首个请求失败或到期)，重新抢占
+			}
+			var record idempotencyRecord
+			if err = json.Unmarshal(data, &record); err != nil {
+				storeUnavailable(err)
+				return
+			}
+
+			if record.Fingerprint != fingerprint {
+				logFields["option"] = "key_reused"
+				logger.LogWarn("Idempotency key reused with a different request", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, logFields)
+				c.JSON(http.StatusUnprocessableEntity, system.APIResponse{
+					Code:      http.StatusUnprocessableEntity,
+					Status:    "failed",
+					Message:   "Idempotency-Key has already been used for a different request",
+					Error:     "idempotency key reused with a different request body",
+					ErrorCode: system.ErrCodeIdempotencyKeyReused,
+				})
+				c.Abort()
+				return
+			}
+			if record.Completed {
+				logFields["option"] = "replay"
+				logFields["status"] = record.Status
+				logger.LogInfo("Idempotent request replayed", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, logFields)
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(record.Status, record.ContentType, record.Body)
+				c.Abort()
+				return
+			}
+			if !m.currentTime().Before(deadline) {
+				c.JSON(http.StatusConflict, system.APIResponse{
+					Code:      http.StatusConflict,
+					Status:    "failed",
+					Message:   "a request with the same Idempotency-Key is still being processed",
+					Error:     "idempotency key in progress",
+					ErrorCode: system.ErrCodeIdempotencyInProgress,
+				})
+				c.Abort()
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				c.Abort()
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+	}
+}
+
+// executeIdempotent 执行 Handler 并保存响应；响应不可缓存或 Handler panic 时释放占位，允许客户端重试
+// pending 为本请求写入的占位记录，保存响应与释放占位都只在记录仍为 pending 时生效
+func (m *MiddlewareManager) executeIdempotent(c *gin.Context, store IdempotencyStore, key string, fingerprint string, pending []byte, lockTimeout time.Duration, ttl time.Duration) {
+	// 客户端断开不应影响记录的写入，否则重试会再次创建资源
+	ctx := context.WithoutCancel(c.Request.Context())
+	recorder := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	stopRenew := renewIdempotencyReservation(ctx, store, key, pending, lockTimeout)
+	saved := false
+	defer func() {
+		stopRenew()
+		if saved {
+			return
+		}
+		if _, err := store.Release(ctx, key, pending); err != nil {
+			logger.LogWarn("Failed to release idempotency key", "", 0, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+				"operation": "idempotency_release",
+				"option":    "store_error",
+				"func_name": "middleware.idempotency.executeIdempotent",
+				"key":       key,
+				"error":     err.Error(),
+			})
+		}
+	}()
+
+	c.Next()
+	// 先停止续期，避免续期写入覆盖下面保存的响应
+	stopRenew()
+
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests {
+		return
+	}
+	data, err := json.Marshal(&idempotencyRecord{
+		Fingerprint: fingerprint,
+		Completed:   true,
+		Status:      status,
+		ContentType: recorder.Header().Get("Content-Type"),
+		Body:        recorder.body.Bytes(),
+	})
+	var replaced bool
+	if err == nil {
+		replaced, err = store.Replace(ctx, key, pending, data, ttl)
+	}
+	if err == nil && !replaced {
+		// 处理时间超过占位有效期且续期失败，占位已被其他请求抢占，不覆盖对方的记录
+		logger.LogWarn("Idempotency reservation lost, response not saved", "", 0, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+			"operation": "idempotency_save",
+			"option":    "reservation_lost",
+			"func_name": "middleware.idempotency.executeIdempotent",
+			"key":       key,
+		})
+		saved = true // 占位已不属于本请求，无需释放
+		return
+	}
+	if err != nil {
+		logger.LogWarn("Failed to save idempotent response", "", 0, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+			"operation": "idempotency_save",
+			"option":    "store_error",
+			"func_name": "middleware.idempotency.executeIdempotent",
+			"key":       key,
+			"error":     err.Error(),
+		})
+		return
+	}
+	saved = true
+}
+
+// renewIdempotencyReservation 每隔半个 lock_timeout 续期占位，处理时间超过 lock_timeout 时重复请求仍然等待而不是重新创建
+// 仅当记录仍为本请求的占位时续期，占位已被其他请求抢占时停止续期
+// 返回的停止函数可重复调用，返回时续期已结束
+func renewIdempotencyReservation(ctx context.Context, store IdempotencyStore, key string, pending []byte, lockTimeout time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if renewed, err := store.Replace(ctx, key, pending, pending, lockTimeout); err == nil && !renewed {
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// requestFingerprint 请求指纹: 方法 + 实际路径(含查询参数) + 请求体的 SHA-256
+// 读取请求体失败时已写出响应，返回 false
+func requestFingerprint(c *gin.Context) (string, bool) {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			// 超过请求体上限时 GinBodyLimitMiddleware 已返回 413
+			if !c.IsAborted() {
+				c.JSON(http.StatusBadRequest, system.APIResponse{
+					Code:      http.StatusBadRequest,
+					Status:    "failed",
+					Message:   "failed to read request body",
+					Error:     err.Error(),
+					ErrorCode: system.ErrCodeInvalidParam,
+				})
+				c.Abort()
+			}
+			return "", false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// validIdempotencyKey 键长度 1-255，仅允许可见 ASCII 字符
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyResponseWriter 转发响应的同时保留一份响应体
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 写入响应体
+func (w *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入响应体
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// memoryIdempotencyStore 进程内幂等记录(单实例部署或未配置 Redis 时使用)
+type memoryIdempotencyStore struct {
+	mutex     sync.Mutex
+	records   map[string]memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]memoryIdempotencyEntry)}
+}
+
+// Reserve 实现 IdempotencyStore
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if entry, ok := s.records[key]; ok && now.Before(entry.expiresAt) {
+		return false, nil
+	}
+	s.records[key] = memoryIdempotencyEntry{data: data, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Get 实现 IdempotencyStore
+func (s *memoryIdempotencyStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.records[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, nil
+	}
+	return entry.data, nil
+}
+
+// Replace 实现 IdempotencyStore
+func (s *memoryIdempotencyStore) Replace(_ context.Context, key string, expected []byte, data []byte, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	entry, ok := s.records[key]
+	if !ok || !now.Before(entry.expiresAt) || !bytes.Equal(entry.data, expected) {
+		return false, nil
+	}
+	s.records[key] = memoryIdempotencyEntry{data: data, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Release 实现 IdempotencyStore
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string, expected []byte) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.records[key]
+	if !ok || !bytes.Equal(entry.data, expected) {
+		return false, nil
+	}
+	delete(s.records, key)
+	return true, nil
+}
+
+// sweep 每分钟清理一次过期记录
+func (s *memoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.records {
+		if !now.Before(entry.expiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	"neomaster/internal/model/system"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotencyEngine 挂载幂等中间件的创建接口，每次真正执行时 created 加一并返回新的资源ID
+// 请求头 X-User 模拟 JWT 中间件写入的 user_id，X-Fail 让 Handler 返回 500
+func newIdempotencyEngine(created *int64, delay time.Duration, lockTimeout time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := &MiddlewareManager{securityConfig: &config.SecurityConfig{
+		Idempotency: config.IdempotencyConfig{Enabled: true, TTL: time.Hour, LockTimeout: lockTimeout},
+	}}
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set("user_id", user)
+		}
+	})
+	engine.POST("/projects", m.GinIdempotencyMiddleware(), func(c *gin.Context) {
+		time.Sleep(delay)
+		if c.GetHeader("X-Fail") != "" {
+			c.JSON(http.StatusInternalServerError, system.APIResponse{Code: http.StatusInternalServerError, Status: "failed"})
+			return
+		}
+		id := atomic.AddInt64(created, 1)
+		c.JSON(http.StatusCreated, system.APIResponse{Code: http.StatusCreated, Status: "success", Data: id})
+	})
+	return engine
+}
+
+func postProject(engine *gin.Engine, user, key, body string, header ...string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/projects", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestGinIdempotencyMiddleware_ReplaysFirstResponse(t *testing.T) {
+	var created int64
+	engine := newIdempotencyEngine(&created, 0, time.Second)
+
+	first := postProject(engine, "1", "create-1", `{"name":"p1"}`)
+	second := postProject(engine, "1", "create-1", `{"name":"p1"}`)
+
+	if created != 1 {
+		t.Fatalf("handler executed %d times, want 1", created)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("replayed header: first=%q second=%q", first.Header().Get(IdempotentReplayedHeader), second.Header().Get(IdempotentReplayedHeader))
+	}
+	if !strings.HasPrefix(second.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("replay content-type = %q", second.Header().Get("Content-Type"))
+	}
+
+	// 不同用户、不同键或未携带键的请求各自执行
+	postProject(engine, "2", "create-1", `{"name":"p1"}`)
+	postProject(engine, "1", "create-2", `{"name":"p1"}`)
+	postProject(engine, "1", "", `{"name":"p1"}`)
+	if created != 4 {
+		t.Errorf("handler executed %d times, want 4", created)
+	}
+}
+
+func TestGinIdempotencyMiddleware_SerializesConcurrentDuplicates(t *testing.T) {
+	var created int64
+	engine := newIdempotencyEngine(&created, 200*time.Millisecond, 5*time.Second)
+
+	const n = 8
+	responses := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = postProject(engine, "1", "concurrent", `{"name":"p1"}`)
+		}(i)
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Fatalf("handler executed %d times, want 1", created)
+	}
+	for i, w := range responses {
+		if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"data":1`) {
+			t.Errorf("response %d = %d %s", i, w.Code, w.Body)
+		}
+	}
+}
+
+func TestGinIdempotencyMiddleware_InProgressTimeout(t *testing.T) {
+	var created int64
+	engine := newIdempotencyEngine(&created, 500*time.Millisecond, 150*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		postProject(engine, "1", "slow", `{}`)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	w := postProject(engine, "1", "slow", `{}`)
+	<-done
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), system.ErrCodeIdempotencyInProgress) {
+		t.Errorf("duplicate during processing = %d %s, want 409", w.Code, w.Body)
+	}
+}
+
+func TestGinIdempotencyMiddleware_KeyReusedWithDifferentBody(t *testing.T) {
+	var created int64
+	engine := newIdempotencyEngine(&created, 0, time.Second)
+
+	postProject(engine, "1", "reuse", `{"name":"p1"}`)
+	w := postProject(engine, "1", "reuse", `{"name":"p2"}`)
+
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), system.ErrCodeIdempotencyKeyReused) {
+		t.Errorf("reused key = %d %s, want 422", w.Code, w.Body)
+	}
+	if created != 1 {
+		t.Errorf("handler executed %d times, want 1", created)
+	}
+}
+
+func TestGinIdempotencyMiddleware_ServerErrorNotCached(t *testing.T) {
+	var created int64
+	engine := newIdempotencyEngine(&created, 0, time.Second)
+
+	if w := postProject(engine, "1", "retry", `{}`, "X-Fail", "1"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first attempt = %d, want 500", w.Code)
+	}
+	w := postProject(engine, "1", "retry", `{}`)
+	if w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Errorf("retry after 500 = %d replayed=%q, want fresh 201", w.Code, w.Header().Get(IdempotentReplayedHeader))
+	}
+}
+
+func TestGinIdempotencyMiddleware_InvalidKey(t *testing.T) {
+	var created int64
+	engine := newIdempotencyEngine(&created, 0, time.Second)
+
+	for _, key := range []string{"has space", strings.Repeat("k", 256), "中文"} {
+		w := postProject(engine, "1", key, `{}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("key %s = %d, want 400", strconv.Quote(key), w.Code)
+		}
+	}
+	if created != 0 {
+		t.Errorf("handler executed %d times, want 0", created)
+	}
+}
+
+func TestGinIdempotencyMiddleware_LostReservationKeepsNewOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newMemoryIdempotencyStore()
+	m := &MiddlewareManager{
+		securityConfig: &config.SecurityConfig{
+			Idempotency: config.IdempotencyConfig{Enabled: true, TTL: time.Hour, LockTimeout: time.Second},
+		},
+		idempotencyStore: store,
+	}
+	other := []byte(`{"fingerprint":"other","owner":"other"}`)
+	engine := gin.New()
+	engine.POST("/projects", m.GinIdempotencyMiddleware(), func(c *gin.Context) {
+		// 模拟处理超时: 占位已过期并被另一个请求抢占
+		store.mutex.Lock()
+		for key := range store.records {
+			store.records[key] = memoryIdempotencyEntry{data: other, expiresAt: time.Now().Add(time.Hour)}
+		}
+		store.mutex.Unlock()
+		status := http.StatusCreated
+		if c.GetHeader("X-Fail") != "" {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, system.APIResponse{Code: status})
+	})
+
+	for _, fail := range []string{"", "1"} {
+		store.records = make(map[string]memoryIdempotencyEntry)
+		postProject(engine, "1", "lost-"+fail, `{}`, "X-Fail", fail)
+
+		if len(store.records) != 1 {
+			t.Fatalf("fail=%q: %d records, want 1", fail, len(store.records))
+		}
+		for _, entry := range store.records {
+			if string(entry.data) != string(other) {
+				t.Errorf("fail=%q: new owner's reservation overwritten: %s", fail, entry.data)
+			}
+		}
+	}
+}
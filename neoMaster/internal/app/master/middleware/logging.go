@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -228,3 +229,19 @@ func getErrorMessage(statusCode int, errors []*gin.Error) string {
 		return http.StatusText(statusCode)
 	}
 }
+
+// GinInFlightMiddleware 进行中请求计数中间件
+// 请求进入时计数+1，处理完成(包括 panic 被上层恢复)后计数-1
+// 优雅关闭时通过 InFlightRequests 判断请求是否已全部排空
+func (m *MiddlewareManager) GinInFlightMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		atomic.AddInt64(&m.inFlight, 1)
+		defer atomic.AddInt64(&m.inFlight, -1)
+		c.Next()
+	}
+}
+
+// InFlightRequests 获取当前进行中的请求数
+func (m *MiddlewareManager) InFlightRequests() int64 {
+	return atomic.LoadInt64(&m.inFlight)
+}
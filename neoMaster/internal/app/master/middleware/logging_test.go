@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGinInFlightMiddleware_CountsActiveRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := &MiddlewareManager{}
+	engine := gin.New()
+	engine.Use(gin.Recovery(), m.GinInFlightMiddleware())
+
+	var during int64
+	engine.GET("/ok", func(c *gin.Context) {
+		during = m.InFlightRequests()
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	for _, path := range []string{"/ok", "/panic"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if during != 1 {
+		t.Errorf("in-flight during request = %d, want 1", during)
+	}
+	if got := m.InFlightRequests(); got != 0 {
+		t.Errorf("in-flight after requests = %d, want 0", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"neomaster/internal/service/agent"
 	"neomaster/internal/service/auth"
 	"sync"
+	"time"
 )
 
 // MiddlewareManager 中间件管理器
@@ -18,6 +19,13 @@ type MiddlewareManager struct {
 	agentService    agent.AgentManagerService
 	rateLimiter     RateLimiter
 	rateLimiterOnce sync.Once
+	inFlight        int64            // 进行中的请求数 (GinInFlightMiddleware 维护，原子操作)
+	rateLimitStore  RateLimitStore   // 按用户/接口限流的计数存储，未设置时使用进程内存储
+	now             func() time.Time // 时钟，测试时可替换
+
+	permissionProvider  PermissionProvider  // 权限集来源，默认为 rbacService
+	idempotencyStore    IdempotencyStore    // 幂等记录存储，未设置时使用进程内存储
+	apiKeyAuthenticator APIKeyAuthenticator // API 密钥认证，未设置时拒绝 ApiKey 认证
 }
 
 // NewMiddlewareManager 创建中间件管理器
@@ -29,11 +37,15 @@ type MiddlewareManager struct {
 //
 // 返回: 中间件管理器实例
 func NewMiddlewareManager(sessionService *auth.SessionService, rbacService *auth.RBACService, jwtService *auth.JWTService, securityConfig *config.SecurityConfig, agentService agent.AgentManagerService) *MiddlewareManager {
-	return &MiddlewareManager{
+	m := &MiddlewareManager{
 		sessionService: sessionService,
 		rbacService:    rbacService,
 		jwtService:     jwtService,
 		securityConfig: securityConfig,
 		agentService:   agentService,
 	}
+	if rbacService != nil {
+		m.permissionProvider = rbacService
+	}
+	return m
 }
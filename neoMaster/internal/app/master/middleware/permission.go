@@ -0,0 +1,139 @@
+/**
+ * 中间件:声明式权限校验
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 按 resource:action 权限串保护路由，权限集经用户服务读取(带 Redis 缓存)；
+ *               必须挂在 JWT 认证中间件之后，上下文中没有用户ID时一律拒绝；
+ *               API 密钥认证的请求还需密钥授权范围覆盖所需权限
+ * @func:
+ *   - RequirePermission 要求拥有指定权限，如 RequirePermission("system:admin")
+ *   - RequireAnyPermission 拥有任意一个权限即可
+ *   - SetPermissionProvider 替换权限集来源
+ */
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionProvider 用户权限集来源 (auth.RBACService 已实现)
+type PermissionProvider interface {
+	GetUserPermissions(ctx context.Context, userID uint) ([]*system.Permission, error)
+}
+
+// SetPermissionProvider 替换权限集来源，需在注册路由前调用
+func (m *MiddlewareManager) SetPermissionProvider(provider PermissionProvider) {
+	m.permissionProvider = provider
+}
+
+// requiredPermission 解析后的 resource:action
+type requiredPermission struct {
+	resource string
+	action   string
+}
+
+// RequirePermission 权限校验中间件，用户需拥有 perm 权限(格式 resource:action)
+// 使用方式: group.POST("/import", middlewareManager.RequirePermission("system:admin"), handler)
+func (m *MiddlewareManager) RequirePermission(perm string) gin.HandlerFunc {
+	return m.RequireAnyPermission(perm)
+}
+
+// RequireAnyPermission 权限校验中间件，用户拥有 perms 中任意一个权限即可通过
+// 权限串格式错误属于编码错误，注册路由时直接 panic
+func (m *MiddlewareManager) RequireAnyPermission(perms ...string) gin.HandlerFunc {
+	if len(perms) == 0 {
+		panic("middleware: RequireAnyPermission requires at least one permission")
+	}
+	required := make([]requiredPermission, 0, len(perms))
+	for _, perm := range perms {
+		resource, action, ok := strings.Cut(perm, ":")
+		if !ok || resource == "" || action == "" {
+			panic(fmt.Sprintf("middleware: invalid permission %q, expected resource:action", perm))
+		}
+		required = append(required, requiredPermission{resource: resource, action: action})
+	}
+	requiredText := strings.Join(perms, " or ")
+
+	return func(c *gin.Context) {
+		// 未经过 JWT 中间件或用户ID类型不符时按未认证处理
+		userID := utils.GetCurrentUserIDFromGinContext(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, system.APIResponse{
+				Code:    http.StatusUnauthorized,
+				Status:  "failed",
+				Message: "user not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		if m.permissionProvider == nil {
+			c.JSON(http.StatusInternalServerError, system.APIResponse{
+				Code:    http.StatusInternalServerError,
+				Status:  "failed",
+				Message: "permission check unavailable",
+			})
+			c.Abort()
+			return
+		}
+
+		permissions, err := m.permissionProvider.GetUserPermissions(c.Request.Context(), userID)
+		if err != nil {
+			logger.LogBusinessError(err, c.GetHeader("X-Request-ID"), userID, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+				"operation": "permission_check",
+				"option":    "permissionProvider.GetUserPermissions",
+				"func_name": "middleware.permission.RequireAnyPermission",
+				"required":  requiredText,
+			})
+			c.JSON(http.StatusInternalServerError, system.APIResponse{
+				Code:    http.StatusInternalServerError,
+				Status:  "failed",
+				Message: "failed to check permission",
+			})
+			c.Abort()
+			return
+		}
+
+		if !hasAnyPermission(permissions, required) {
+			c.JSON(http.StatusForbidden, system.APIResponse{
+				Code:    http.StatusForbidden,
+				Status:  "failed",
+				Message: "permission " + requiredText + " required",
+			})
+			c.Abort()
+			return
+		}
+
+		// API 密钥以所属用户身份访问，授权范围只能进一步收窄用户权限
+		if key := apiKeyFromContext(c); key != nil && !apiKeyAllowsAny(key, required) {
+			abortAPIKeyScope(c, requiredText)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// hasAnyPermission 用户权限集是否覆盖任意一个所需权限(支持 * 通配)
+func hasAnyPermission(permissions []*system.Permission, required []requiredPermission) bool {
+	for _, perm := range permissions {
+		if perm == nil {
+			continue
+		}
+		for _, r := range required {
+			if perm.Matches(r.resource, r.action) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"neomaster/internal/model/system"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubPermissionProvider 按用户ID返回固定权限集
+type stubPermissionProvider struct {
+	permissions map[uint][]*system.Permission
+	err         error
+}
+
+func (s *stubPermissionProvider) GetUserPermissions(ctx context.Context, userID uint) ([]*system.Permission, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.permissions[userID], nil
+}
+
+// newPermissionEngine 请求头 X-User 模拟 JWT 中间件写入的 user_id(uint)
+func newPermissionEngine(provider PermissionProvider) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := &MiddlewareManager{}
+	m.SetPermissionProvider(provider)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if v := c.GetHeader("X-User"); v != "" {
+			id, _ := strconv.ParseUint(v, 10, 32)
+			c.Set("user_id", uint(id))
+		}
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.POST("/rules/import", m.RequirePermission("system:admin"), ok)
+	engine.GET("/rules", m.RequireAnyPermission("rule:read", "system:admin"), ok)
+	return engine
+}
+
+func TestRequirePermission(t *testing.T) {
+	provider := &stubPermissionProvider{permissions: map[uint][]*system.Permission{
+		1: {{Resource: "system", Action: "admin"}},
+		2: {{Resource: "rule", Action: "read"}},
+		3: {{Resource: "*", Action: "*"}},
+		4: {{Resource: "system", Action: "*"}},
+	}}
+	engine := newPermissionEngine(provider)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		want   int
+	}{
+		{name: "admin_allowed", method: http.MethodPost, path: "/rules/import", user: "1", want: http.StatusOK},
+		{name: "missing_permission_denied", method: http.MethodPost, path: "/rules/import", user: "2", want: http.StatusForbidden},
+		{name: "wildcard_allowed", method: http.MethodPost, path: "/rules/import", user: "3", want: http.StatusOK},
+		{name: "action_wildcard_allowed", method: http.MethodPost, path: "/rules/import", user: "4", want: http.StatusOK},
+		{name: "no_permissions_denied", method: http.MethodPost, path: "/rules/import", user: "5", want: http.StatusForbidden},
+		{name: "no_user_fails_closed", method: http.MethodPost, path: "/rules/import", want: http.StatusUnauthorized},
+		{name: "any_first_matches", method: http.MethodGet, path: "/rules", user: "2", want: http.StatusOK},
+		{name: "any_second_matches", method: http.MethodGet, path: "/rules", user: "1", want: http.StatusOK},
+		{name: "any_none_matches", method: http.MethodGet, path: "/rules", user: "5", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+			}
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirePermission_FailsClosed(t *testing.T) {
+	// 权限集读取失败与未配置权限来源时都不放行
+	for name, provider := range map[string]PermissionProvider{
+		"provider_error": &stubPermissionProvider{err: errors.New("db down")},
+		"no_provider":    nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			engine := newPermissionEngine(provider)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/rules/import", nil)
+			req.Header.Set("X-User", "1")
+			engine.ServeHTTP(w, req)
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want 500", w.Code)
+			}
+		})
+	}
+
+	// 用户ID类型不符(非 JWT 中间件写入)按未认证处理
+	gin.SetMode(gin.TestMode)
+	m := &MiddlewareManager{}
+	m.SetPermissionProvider(&stubPermissionProvider{})
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { c.Set("user_id", "1") })
+	engine.GET("/", m.RequirePermission("system:admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestRequireAnyPermission_InvalidSpecPanics(t *testing.T) {
+	for _, perms := range [][]string{nil, {"system"}, {":admin"}, {"system:"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RequireAnyPermission(%q) did not panic", perms)
+				}
+			}()
+			(&MiddlewareManager{}).RequireAnyPermission(perms...)
+		}()
+	}
+}
@@ -0,0 +1,223 @@
+/**
+ * 中间件:按用户+接口的滑动窗口限流
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 已认证请求按用户ID计数，匿名请求按客户端IP计数，每个接口独立计数；
+ *               计数存储默认使用 Redis(多实例共享)，未注入时退化为进程内存储
+ * @func:
+ *   - GinKeyedRateLimitMiddleware 路由分组限流中间件[规则可由 security.rate_limit.routes 按分组覆盖]
+ *   - SetRateLimitStore 注入计数存储
+ */
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitRule 滑动窗口限流规则: Window 内最多 Limit 次请求
+type RateLimitRule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitStore 滑动窗口计数存储 (redis.RateLimitRepository 已实现)
+// 放行时记录本次请求；拒绝时返回需要等待的时间
+type RateLimitStore interface {
+	SlidingWindowAllow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, time.Duration, error)
+}
+
+// SetRateLimitStore 注入限流计数存储，需在注册路由前调用
+func (m *MiddlewareManager) SetRateLimitStore(store RateLimitStore) {
+	m.rateLimitStore = store
+}
+
+// GinKeyedRateLimitMiddleware 路由分组限流中间件
+// 参数:
+//   - scope: 分组名，同时作为 security.rate_limit.routes 的配置键
+//   - defaultRule: 配置中没有该分组(或配置无效)时使用的规则
+//
+// 需挂在 JWT 认证中间件之后才能按用户计数；计数存储异常时放行请求，避免 Redis 故障导致接口不可用
+func (m *MiddlewareManager) GinKeyedRateLimitMiddleware(scope string, defaultRule RateLimitRule) gin.HandlerFunc {
+	rule := m.resolveRateLimitRule(scope, defaultRule)
+	store := m.rateLimitStore
+	if store == nil {
+		store = newMemoryRateLimitStore()
+	}
+
+	return func(c *gin.Context) {
+		if m.securityConfig == nil || !m.securityConfig.RateLimit.Enabled || rule.Limit <= 0 || rule.Window <= 0 {
+			c.Next()
+			return
+		}
+		if m.shouldSkipRateLimit(c) {
+			c.Next()
+			return
+		}
+
+		clientIP := utils.GetClientIP(c)
+		key := rateLimitKey(c, scope, clientIP)
+
+		allowed, retryAfter, err := store.SlidingWindowAllow(c.Request.Context(), key, rule.Limit, rule.Window, m.currentTime())
+		if err != nil {
+			logger.LogWarn("Rate limit store unavailable, request allowed", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+				"operation": "rate_limit_check",
+				"option":    "store_error",
+				"func_name": "middleware.ratelimit_keyed.GinKeyedRateLimitMiddleware",
+				"scope":     scope,
+				"error":     err.Error(),
+			})
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			logger.LogWarn("Rate limit exceeded for key", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+				"operation":   "rate_limit_exceeded",
+				"option":      "block_request",
+				"func_name":   "middleware.ratelimit_keyed.GinKeyedRateLimitMiddleware",
+				"scope":       scope,
+				"key":         key,
+				"retry_after": retryAfter.String(),
+			})
+
+			message := m.securityConfig.RateLimit.Message
+			if message == "" {
+				message = "Too many requests, please try again later"
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "Rate limit exceeded",
+				"message": message,
+				"code":    "RATE_LIMIT_EXCEEDED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// resolveRateLimitRule 配置中的分组规则优先于默认规则
+func (m *MiddlewareManager) resolveRateLimitRule(scope string, defaultRule RateLimitRule) RateLimitRule {
+	if m.securityConfig == nil {
+		return defaultRule
+	}
+	routeConfig, ok := m.securityConfig.RateLimit.Routes[scope]
+	if !ok {
+		return defaultRule
+	}
+	window, err := time.ParseDuration(routeConfig.Window)
+	if err != nil || routeConfig.Limit <= 0 || window <= 0 {
+		logger.LogWarn("Invalid route rate limit config, using default rule", "", 0, "", "middleware.ratelimit_keyed.resolveRateLimitRule", "", map[string]interface{}{
+			"operation": "rate_limit_config",
+			"option":    "fallback_default",
+			"func_name": "middleware.ratelimit_keyed.resolveRateLimitRule",
+			"scope":     scope,
+			"limit":     routeConfig.Limit,
+			"window":    routeConfig.Window,
+		})
+		return defaultRule
+	}
+	return RateLimitRule{Limit: routeConfig.Limit, Window: window}
+}
+
+// currentTime 当前时间(支持测试替换时钟)
+func (m *MiddlewareManager) currentTime() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// rateLimitKey 生成限流键: {分组}:{user:ID|ip:IP}:{方法} {路由模板}
+// 使用路由模板而非实际路径，避免 /:id 这类参数把同一接口拆成多个计数
+func rateLimitKey(c *gin.Context, scope string, clientIP string) string {
+	return fmt.Sprintf("%s:%s:%s", scope, requestSubject(c, clientIP), requestEndpoint(c))
+}
+
+// requestSubject 请求主体: 已认证为 user:{ID}，匿名为 ip:{IP}
+func requestSubject(c *gin.Context, clientIP string) string {
+	if userID, exists := c.Get("user_id"); exists && userID != nil {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return "ip:" + clientIP
+}
+
+// requestEndpoint 接口标识: {方法} {路由模板}
+func requestEndpoint(c *gin.Context) string {
+	endpoint := c.FullPath()
+	if endpoint == "" {
+		endpoint = c.Request.URL.Path
+	}
+	return c.Request.Method + " " + endpoint
+}
+
+// retryAfterSeconds Retry-After 头取整秒并向上取整，至少为 1
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// memoryRateLimitStore 进程内滑动窗口计数(单实例部署或未配置 Redis 时使用)
+type memoryRateLimitStore struct {
+	mutex     sync.Mutex
+	requests  map[string][]time.Time // 每个键窗口内的请求时间，按时间升序
+	lastSweep time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{requests: make(map[string][]time.Time)}
+}
+
+// SlidingWindowAllow 实现 RateLimitStore
+func (s *memoryRateLimitStore) SlidingWindowAllow(_ context.Context, key string, limit int, window time.Duration, now time.Time) (bool, time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sweep(now, window)
+
+	recent := pruneBefore(s.requests[key], now.Add(-window))
+	if len(recent) < limit {
+		s.requests[key] = append(recent, now)
+		return true, 0, nil
+	}
+	s.requests[key] = recent
+	return false, recent[0].Add(window).Sub(now), nil
+}
+
+// sweep 每隔一个窗口清理一次过期键，防止匿名IP键无限增长
+func (s *memoryRateLimitStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+	s.lastSweep = now
+	for key, times := range s.requests {
+		if len(times) == 0 || !times[len(times)-1].After(now.Add(-window)) {
+			delete(s.requests, key)
+		}
+	}
+}
+
+// pruneBefore 移除不晚于 cutoff 的请求时间
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
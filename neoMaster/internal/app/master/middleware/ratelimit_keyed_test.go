@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newKeyedRateLimitEngine 构造挂载分组限流的引擎，时钟由 clock 控制
+// 请求头 X-User 模拟 JWT 中间件写入的 user_id
+func newKeyedRateLimitEngine(clock *time.Time, routes map[string]config.RouteRateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := &MiddlewareManager{
+		securityConfig: &config.SecurityConfig{RateLimit: config.RateLimitConfig{Enabled: true, Routes: routes}},
+		now:            func() time.Time { return *clock },
+	}
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set("user_id", user)
+		}
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.POST("/login", m.GinKeyedRateLimitMiddleware("login", RateLimitRule{Limit: 10, Window: time.Minute}), ok)
+	engine.GET("/rules", m.GinKeyedRateLimitMiddleware("api", RateLimitRule{Limit: 3, Window: time.Minute}), ok)
+	engine.GET("/rules/:id", m.GinKeyedRateLimitMiddleware("api", RateLimitRule{Limit: 3, Window: time.Minute}), ok)
+	return engine
+}
+
+func doRequest(engine *gin.Engine, method, path, user string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestGinKeyedRateLimitMiddleware_SlidingWindow(t *testing.T) {
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	engine := newKeyedRateLimitEngine(&clock, nil)
+
+	for i := 0; i < 3; i++ {
+		if w := doRequest(engine, http.MethodGet, "/rules", "1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+		}
+		clock = clock.Add(10 * time.Second)
+	}
+
+	// 第4次请求在窗口内被拒绝，最早一次请求 60s 后移出窗口，此时已过去 30s
+	w := doRequest(engine, http.MethodGet, "/rules", "1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+
+	// 其他用户、同一用户的其他接口、匿名IP分别计数
+	if w := doRequest(engine, http.MethodGet, "/rules", "2"); w.Code != http.StatusOK {
+		t.Errorf("other user: status = %d, want 200", w.Code)
+	}
+	if w := doRequest(engine, http.MethodGet, "/rules/7", "1"); w.Code != http.StatusOK {
+		t.Errorf("other endpoint: status = %d, want 200", w.Code)
+	}
+	if w := doRequest(engine, http.MethodGet, "/rules", ""); w.Code != http.StatusOK {
+		t.Errorf("anonymous: status = %d, want 200", w.Code)
+	}
+
+	// 最早一次请求移出窗口后再次放行
+	clock = clock.Add(30 * time.Second)
+	if w := doRequest(engine, http.MethodGet, "/rules", "1"); w.Code != http.StatusOK {
+		t.Errorf("after window slides: status = %d, want 200", w.Code)
+	}
+	if w := doRequest(engine, http.MethodGet, "/rules", "1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("window full again: status = %d, want 429", w.Code)
+	}
+}
+
+func TestGinKeyedRateLimitMiddleware_RouteOverride(t *testing.T) {
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	engine := newKeyedRateLimitEngine(&clock, map[string]config.RouteRateLimitConfig{
+		"login": {Limit: 2, Window: "10m"},
+		"api":   {Limit: 0, Window: "bad"}, // 无效配置回退到默认规则
+	})
+
+	for i := 0; i < 2; i++ {
+		if w := doRequest(engine, http.MethodPost, "/login", ""); w.Code != http.StatusOK {
+			t.Fatalf("login %d: status = %d, want 200", i+1, w.Code)
+		}
+	}
+	w := doRequest(engine, http.MethodPost, "/login", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("login over limit: status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "600" {
+		t.Errorf("Retry-After = %q, want 600", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := doRequest(engine, http.MethodGet, "/rules", "1"); w.Code != http.StatusOK {
+			t.Fatalf("api %d: status = %d, want 200", i+1, w.Code)
+		}
+	}
+	if w := doRequest(engine, http.MethodGet, "/rules", "1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("api over default limit: status = %d, want 429", w.Code)
+	}
+}
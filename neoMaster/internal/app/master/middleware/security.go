@@ -22,7 +22,8 @@ import (
 )
 
 // GinCORSMiddleware CORS跨域资源共享中间件
-// 处理跨域请求，设置必要的CORS头部信息
+// 只在请求来源位于允许列表时返回 CORS 头部，启用凭证时回显具体来源而不是 "*"
+// 预检请求(OPTIONS + Access-Control-Request-Method)在此直接应答，不进入后续处理链
 func (m *MiddlewareManager) GinCORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 检查是否启用CORS
@@ -31,30 +32,43 @@ func (m *MiddlewareManager) GinCORSMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 获取客户端IP和请求来源
-		clientIP := utils.GetClientIP(c)
 		origin := c.Request.Header.Get("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != ""
 
-		// 记录日志
-		logger.LogInfo("Processing CORS request", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, map[string]interface{}{
-			"operation": "cors_middleware",
-			"option":    "handle_cors_request",
-			"func_name": "middleware.security.GinCORSMiddleware",
-			"origin":    origin,
-		})
-
-		// 设置CORS头部
-		m.setCORSHeaders(c, origin)
+		// 非跨域请求(同源或非浏览器客户端)不需要CORS头部
+		if origin == "" {
+			c.Next()
+			return
+		}
+		// 响应内容随 Origin 变化，告知缓存按 Origin 区分
+		c.Writer.Header().Add("Vary", "Origin")
 
-		// 处理预检请求（OPTIONS方法）
-		if c.Request.Method == "OPTIONS" {
-			logger.LogInfo("Handling CORS preflight request", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, map[string]interface{}{
-				"operation": "cors_preflight",
-				"option":    "handle_options_request",
+		if !m.isOriginAllowed(origin) {
+			logger.LogWarn("CORS origin not allowed", "", 0, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+				"operation": "cors_middleware",
+				"option":    "origin_not_allowed",
 				"func_name": "middleware.security.GinCORSMiddleware",
 				"origin":    origin,
+				"preflight": preflight,
 			})
+			// 预检直接拒绝；普通请求照常处理但不返回CORS头部，由浏览器拦截响应
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		m.setCORSHeaders(c, origin)
 
+		// 处理预检请求（OPTIONS方法）
+		if preflight {
+			if !m.isMethodAllowed(c.Request.Header.Get("Access-Control-Request-Method")) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			m.setPreflightHeaders(c)
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -141,58 +155,55 @@ func (m *MiddlewareManager) GinNoIndexMiddleware() gin.HandlerFunc {
 
 // GinRequestIDMiddleware 请求ID中间件
 // 为每个请求生成唯一ID，便于日志追踪和问题排查
+// 1. 客户端(或负载均衡/代理)已携带 X-Request-ID 时直接沿用
+// 2. 未携带时生成 UUID，并回写到请求头，后续中间件和 Handler 读取 X-Request-ID 即可拿到同一个ID
+// 3. 请求ID同时写入 Gin 上下文(request_id)和标准 context (logger.RequestIDFromContext)
+// 4. 通过响应头 X-Request-ID 返回给客户端
 func (m *MiddlewareManager) GinRequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取客户端IP
-		clientIP := utils.GetClientIP(c)
-
 		// 检查是否已有请求ID（可能来自负载均衡器或代理）
 		requestID := c.GetHeader("X-Request-ID")
 		if requestID == "" {
-			// 生成新的请求ID（使用简单的时间戳+随机数方案） 550e8400-e29b-41d4-a716-446655440000
-			requestID, _ = utils.GenerateUUID()
+			// 生成新的请求ID 550e8400-e29b-41d4-a716-446655440000
+			generated, err := utils.GenerateUUID()
+			if err != nil {
+				logger.LogError(err, "", 0, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+					"operation": "request_id",
+					"option":    "generate_request_id",
+					"func_name": "middleware.security.GinRequestIDMiddleware",
+				})
+			}
+			requestID = generated
+			c.Request.Header.Set("X-Request-ID", requestID)
 		}
 
 		// 设置请求ID到上下文中
 		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
 
 		// 设置响应头
 		c.Header("X-Request-ID", requestID)
 
-		// 记录日志
-		logger.LogInfo("Generated request ID", "", 0, clientIP, c.Request.URL.Path, c.Request.Method, map[string]interface{}{
-			"operation":  "request_id",
-			"option":     "generate_request_id",
-			"func_name":  "middleware.security.GinRequestIDMiddleware",
-			"request_id": requestID,
-		})
-
 		// 继续处理请求
 		c.Next()
 	}
 }
 
-// setCORSHeaders 设置CORS头部
+// setCORSHeaders 设置实际请求与预检请求共用的CORS头部，调用前需确认来源已被允许
 func (m *MiddlewareManager) setCORSHeaders(c *gin.Context, origin string) {
 	corsConfig := &m.securityConfig.CORS
 
-	// 设置允许的源
-	if corsConfig.AllowAllOrigins {
+	// 设置允许的源: 未启用凭证且允许所有源时返回 "*"，否则回显请求来源
+	// (配置校验保证通配源与凭证不会同时开启)
+	if corsConfig.AllowAllOrigins && !corsConfig.AllowCredentials {
 		c.Header("Access-Control-Allow-Origin", "*")
-	} else if origin != "" && m.isOriginAllowed(origin) {
+	} else {
 		c.Header("Access-Control-Allow-Origin", origin)
 	}
 
-	// 设置允许的方法
-	if len(corsConfig.AllowMethods) > 0 {
-		methods := strings.Join(corsConfig.AllowMethods, ", ")
-		c.Header("Access-Control-Allow-Methods", methods)
-	}
-
-	// 设置允许的头部
-	if len(corsConfig.AllowHeaders) > 0 {
-		headers := strings.Join(corsConfig.AllowHeaders, ", ")
-		c.Header("Access-Control-Allow-Headers", headers)
+	// 设置是否允许凭证
+	if corsConfig.AllowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
 	}
 
 	// 设置暴露的头部
@@ -200,10 +211,29 @@ func (m *MiddlewareManager) setCORSHeaders(c *gin.Context, origin string) {
 		exposeHeaders := strings.Join(corsConfig.ExposeHeaders, ", ")
 		c.Header("Access-Control-Expose-Headers", exposeHeaders)
 	}
+}
 
-	// 设置是否允许凭证
-	if corsConfig.AllowCredentials {
-		c.Header("Access-Control-Allow-Credentials", "true")
+// setPreflightHeaders 设置预检响应特有的CORS头部
+func (m *MiddlewareManager) setPreflightHeaders(c *gin.Context) {
+	corsConfig := &m.securityConfig.CORS
+	c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+	c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	// 设置允许的方法
+	if len(corsConfig.AllowMethods) > 0 {
+		methods := strings.Join(corsConfig.AllowMethods, ", ")
+		c.Header("Access-Control-Allow-Methods", methods)
+	}
+
+	// 设置允许的头部: 配置 "*" 时回显预检声明的请求头(携带凭证时浏览器不认可 "*")
+	if len(corsConfig.AllowHeaders) > 0 {
+		headers := strings.Join(corsConfig.AllowHeaders, ", ")
+		if containsFold(corsConfig.AllowHeaders, "*") {
+			headers = c.Request.Header.Get("Access-Control-Request-Headers")
+		}
+		if headers != "" {
+			c.Header("Access-Control-Allow-Headers", headers)
+		}
 	}
 
 	// 设置缓存时间
@@ -214,22 +244,40 @@ func (m *MiddlewareManager) setCORSHeaders(c *gin.Context, origin string) {
 }
 
 // isOriginAllowed 检查源是否被允许
+// 来源按 scheme://host[:port] 比较，忽略大小写与结尾的 "/"
 func (m *MiddlewareManager) isOriginAllowed(origin string) bool {
 	corsConfig := &m.securityConfig.CORS
 
-	if corsConfig.AllowAllOrigins {
-		return true
-	}
-
 	if origin == "" {
 		return false
 	}
 
+	if corsConfig.AllowAllOrigins {
+		return true
+	}
+
+	origin = strings.TrimSuffix(origin, "/")
 	for _, allowedOrigin := range corsConfig.AllowOrigins {
-		if allowedOrigin == "*" || allowedOrigin == origin {
+		if allowedOrigin == "*" || strings.EqualFold(strings.TrimSuffix(allowedOrigin, "/"), origin) {
 			return true
 		}
 	}
 
 	return false
 }
+
+// isMethodAllowed 检查预检请求声明的方法是否被允许，未配置方法时不限制
+func (m *MiddlewareManager) isMethodAllowed(method string) bool {
+	allowMethods := m.securityConfig.CORS.AllowMethods
+	return len(allowMethods) == 0 || containsFold(allowMethods, method)
+}
+
+// containsFold 忽略大小写检查切片是否包含指定元素
+func containsFold(slice []string, item string) bool {
+	for _, s := range slice {
+		if strings.EqualFold(s, item) {
+			return true
+		}
+	}
+	return false
+}
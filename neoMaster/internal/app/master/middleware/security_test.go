@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	"neomaster/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRequestIDEngine 构造只挂载请求ID中间件的引擎，Handler 回传各处读取到的请求ID
+func newRequestIDEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use((&MiddlewareManager{}).GinRequestIDMiddleware())
+	engine.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"header":  c.GetHeader("X-Request-ID"),
+			"gin":     c.GetString("request_id"),
+			"context": logger.RequestIDFromContext(c.Request.Context()),
+		})
+	})
+	return engine
+}
+
+func TestGinRequestIDMiddleware_GeneratesWhenAbsent(t *testing.T) {
+	engine := newRequestIDEngine()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	engine.ServeHTTP(w, req)
+
+	requestID := w.Header().Get("X-Request-ID")
+	if len(requestID) != 36 {
+		t.Fatalf("expected generated UUID in response header, got %q", requestID)
+	}
+	want := `{"context":"` + requestID + `","gin":"` + requestID + `","header":"` + requestID + `"}`
+	if w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+}
+
+func TestGinRequestIDMiddleware_PassesThroughWhenPresent(t *testing.T) {
+	engine := newRequestIDEngine()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("X-Request-ID", "client-supplied-id")
+	engine.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Request-ID"); got != "client-supplied-id" {
+		t.Errorf("response X-Request-ID = %q, want client-supplied-id", got)
+	}
+	want := `{"context":"client-supplied-id","gin":"client-supplied-id","header":"client-supplied-id"}`
+	if w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+}
+
+// newCORSEngine 构造只挂载CORS中间件的引擎
+func newCORSEngine(cors config.CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cors.Enabled = true
+	engine := gin.New()
+	engine.Use((&MiddlewareManager{securityConfig: &config.SecurityConfig{CORS: cors}}).GinCORSMiddleware())
+	engine.GET("/api/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	return engine
+}
+
+func preflight(engine *gin.Engine, origin, method string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/api/ping", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization, Content-Type")
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+var credentialedCORS = config.CORSConfig{
+	AllowOrigins:     []string{"https://console.example.com"},
+	AllowMethods:     []string{"GET", "POST"},
+	AllowHeaders:     []string{"Authorization", "Content-Type"},
+	ExposeHeaders:    []string{"X-Request-ID"},
+	AllowCredentials: true,
+	MaxAge:           time.Hour,
+}
+
+func TestGinCORSMiddleware_PreflightAllowedOrigin(t *testing.T) {
+	w := preflight(newCORSEngine(credentialedCORS), "https://console.example.com", http.MethodPost)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://console.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+		"Access-Control-Max-Age":           "3600",
+	}
+	for header, value := range want {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+	if w.Header().Values("Vary")[0] != "Origin" {
+		t.Errorf("Vary = %v, want Origin first", w.Header().Values("Vary"))
+	}
+}
+
+func TestGinCORSMiddleware_PreflightDisallowed(t *testing.T) {
+	engine := newCORSEngine(credentialedCORS)
+	cases := map[string]*httptest.ResponseRecorder{
+		"unknown origin":    preflight(engine, "https://evil.example.com", http.MethodGet),
+		"suffix origin":     preflight(engine, "https://console.example.com.evil.com", http.MethodGet),
+		"disallowed method": preflight(engine, "https://console.example.com", http.MethodDelete),
+	}
+	for name, w := range cases {
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", name, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Methods = %q, want none", name, got)
+		}
+	}
+	if got := cases["unknown origin"].Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin reflected: %q", got)
+	}
+}
+
+func TestGinCORSMiddleware_SimpleRequest(t *testing.T) {
+	engine := newCORSEngine(credentialedCORS)
+
+	// 允许的来源: 回显来源并暴露配置的响应头
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.Header.Set("Origin", "https://console.example.com")
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://console.example.com" ||
+		w.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Errorf("allowed origin: status=%d headers=%v", w.Code, w.Header())
+	}
+
+	// 不允许的来源: 请求照常处理但不返回任何CORS头部
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("disallowed origin: status = %d, want 200", w.Code)
+	}
+	for _, header := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials"} {
+		if got := w.Header().Get(header); got != "" {
+			t.Errorf("disallowed origin: %s = %q, want none", header, got)
+		}
+	}
+}
+
+func TestGinCORSMiddleware_AllowAllWithoutCredentials(t *testing.T) {
+	engine := newCORSEngine(config.CORSConfig{AllowAllOrigins: true, AllowHeaders: []string{"*"}})
+	w := preflight(engine, "https://any.example.com", http.MethodGet)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want none", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+		t.Errorf("Access-Control-Allow-Headers = %q, want requested headers", got)
+	}
+}
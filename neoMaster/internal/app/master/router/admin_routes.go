@@ -27,6 +27,7 @@ func (r *Router) setupAdminRoutes(v1 *gin.RouterGroup) {
 			users.GET("/:id", r.userHandler.GetUserByID)                // 获取用户详情(users表)
 			users.GET("/:id/info", r.userHandler.GetUserInfoByID)       // 获取用户全量信息(包含权限和角色信息)
 			users.POST("/:id", r.userHandler.UpdateUserByID)            // 包含用户角色更新
+			users.PATCH("/:id", r.userHandler.PatchUserByID)            // 局部更新(未携带字段不修改，空字符串清空可选字段)
 			users.DELETE("/:id", r.userHandler.DeleteUser)              // 删除用户(同时删除用户角色关系)
 			users.POST("/:id/activate", r.userHandler.ActivateUser)     // 激活用户
 			users.POST("/:id/deactivate", r.userHandler.DeactivateUser) // 禁用用户
@@ -66,5 +67,15 @@ func (r *Router) setupAdminRoutes(v1 *gin.RouterGroup) {
 			sessionMgmt.POST("/user/:userId/revoke-all", r.sessionHandler.RevokeAllUserSessions) // 撤销用户所有会话
 		}
 
+		// 审计日志（app.features.audit_log 启用时注册，额外要求 system:admin 权限）
+		if r.auditLogHandler != nil {
+			auditLogs := admin.Group("/audit-logs")
+			auditLogs.Use(r.middlewareManager.RequirePermission("system:admin"))
+			{
+				auditLogs.GET("/list", r.auditLogHandler.ListAuditLogs)     // 分页查询审计日志(默认最近7天)
+				auditLogs.GET("/export", r.auditLogHandler.ExportAuditLogs) // 导出审计日志CSV(流式输出)
+			}
+		}
+
 	}
 }
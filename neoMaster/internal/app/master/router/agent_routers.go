@@ -8,8 +8,10 @@
 package router
 
 import (
+	"neomaster/internal/app/master/middleware"
 	"neomaster/internal/pkg/logger"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +22,7 @@ func (r *Router) setupAgentRoutes(v1 *gin.RouterGroup) {
 	{
 		// ==================== Agent公开接口（不需要认证） ====================
 		agentPublicGroup.POST("/register", r.agentHandler.RegisterAgent) // 注册新Agent/更新Agent信息 - 公开接口
+		agentPublicGroup.POST("/enroll", r.agentHandler.EnrollAgent)     // Agent接入并签发Token(重复接入轮换Token) - 公开接口
 		// Agent 从心跳接口可以获得规则版本信息(包含HASH),Agent需要把HASH和本地HASH进行对比，如果HASH不一致则调用Master接口下载新的指纹库快照
 	}
 
@@ -30,7 +33,10 @@ func (r *Router) setupAgentRoutes(v1 *gin.RouterGroup) {
 	agentPullGroup := v1.Group("/agent")
 	agentPullGroup.Use(r.middlewareManager.GinAgentAuthMiddleware())
 	{
-		agentPullGroup.POST("/heartbeat", r.agentHandler.ProcessHeartbeat) // 处理Agent心跳 - 需Agent认证
+		agentPullGroup.POST("/heartbeat", r.agentHandler.ProcessHeartbeat)      // 处理Agent心跳 - 需Agent认证
+		agentPullGroup.POST("/token/refresh", r.agentHandler.RefreshAgentToken) // Token过期前续期 - 需Agent认证
+		agentPullGroup.POST("/results", r.agentResultHandler.SubmitResults)     // 扫描结果批次上报(按batch_id去重) - 需Agent认证
+		agentPullGroup.GET("/config", r.agentHandler.PullAgentConfig)           // 拉取最新配置(?version=当前版本，一致时返回空) - 需Agent认证
 
 		// 指纹规则下载接口
 		fingerprintGroup := agentPullGroup.Group("/rules")
@@ -49,15 +55,20 @@ func (r *Router) setupAgentRoutes(v1 *gin.RouterGroup) {
 
 	// Agent管理路由组（需要认证）
 	agentManageGroup := v1.Group("/agent")
+	agentManageGroup.Use(r.middlewareManager.GinAPIKeyScopeMiddleware("agent")) // API 密钥需授权 agent:read / agent:write
 	agentManageGroup.Use(r.middlewareManager.GinJWTAuthMiddleware())
 	agentManageGroup.Use(r.middlewareManager.GinUserActiveMiddleware())
+	agentManageGroup.Use(r.middlewareManager.GinKeyedRateLimitMiddleware("api", middleware.RateLimitRule{Limit: 300, Window: time.Minute}))
 	// agentManageGroup.Use(r.middlewareManager.GinRequireAnyRole("user")) // 用户权限检查,用户是否具有user角色
 	{
 		// ==================== Agent基础管理接口(Master端完全独立实现) ====================
-		agentManageGroup.GET("", r.agentHandler.GetAgentList)                   // 获取Agent列表 - 支持分页、status 状态过滤、keyword 关键字模糊查询、tags 标签过滤、capabilities 功能模块过滤 [Master端数据库查询]
-		agentManageGroup.GET("/:id", r.agentHandler.GetAgentInfo)               // 根据ID获取Agent信息 [Master端数据库查询]
-		agentManageGroup.PATCH("/:id/status", r.agentHandler.UpdateAgentStatus) // 更新Agent状态 - PATCH 对现有资源进行部分修改 [Master端数据库操作]
-		agentManageGroup.DELETE("/:id", r.agentHandler.DeleteAgent)             // 删除Agent [Master端数据库操作]
+		agentManageGroup.GET("/events/ws", r.agentHandler.StreamAgentEvents)                                                                     // WebSocket 推送Agent状态/性能指标变化 - 支持 agent_ids、tag_ids 过滤 [替代轮询列表接口]
+		agentManageGroup.GET("", r.agentHandler.GetAgentList)                                                                                    // 获取Agent列表 - 支持分页、status 状态过滤、keyword 关键字模糊查询、tags 标签过滤、capabilities 功能模块过滤、sort_by/sort_order 排序 [Master端数据库查询]
+		agentManageGroup.GET("/:id", r.agentHandler.GetAgentInfo)                                                                                // 根据ID获取Agent信息 [Master端数据库查询]
+		agentManageGroup.PATCH("/:id/status", r.agentHandler.UpdateAgentStatus)                                                                  // 更新Agent状态 - PATCH 对现有资源进行部分修改 [Master端数据库操作]
+		agentManageGroup.PATCH("/:id/heartbeat", r.agentHandler.UpdateAgentHeartbeatSettings)                                                    // 更新Agent心跳间隔与离线阈值 - 0 恢复默认值，注册/接入时下发给Agent [Master端数据库操作]
+		agentManageGroup.DELETE("/:id", r.agentHandler.DeleteAgent)                                                                              // 删除Agent [Master端数据库操作]
+		agentManageGroup.POST("/enrollment-tokens", r.middlewareManager.RequirePermission("system:admin"), r.agentHandler.CreateEnrollmentToken) // 签发一次性接入令牌(明文只返回一次) - 需 system:admin 权限
 
 		// ==================== Agent进程控制路由（🔴 需要Agent端配合实现 - 控制Agent进程生命周期） ====================
 		agentManageGroup.POST("/:id/start", r.agentStartPlaceholder)     // 🔴 启动Agent进程 [需要Master->Agent通信协议，发送启动命令]
@@ -66,8 +77,11 @@ func (r *Router) setupAgentRoutes(v1 *gin.RouterGroup) {
 		agentManageGroup.GET("/:id/status", r.agentStatusPlaceholder)    // 🔴 获取Agent实时状态 [需要Agent端实时响应状态信息]
 
 		// ==================== Agent配置管理路由（🟡 混合实现 - Master端存储+Agent端应用） ====================
-		agentManageGroup.GET("/:id/config", r.agentGetConfigPlaceholder)    // ✅ 获取Agent配置 [Master端从数据库读取配置]
-		agentManageGroup.PUT("/:id/config", r.agentUpdateConfigPlaceholder) // 🟡 更新Agent配置 [Master端存储配置 + 🔴 推送到Agent端应用]
+		agentManageGroup.GET("/:id/config", r.agentHandler.GetAgentConfig)                        // ✅ 获取Agent配置 [Master端从数据库读取配置，未下发过时返回默认配置]
+		agentManageGroup.PUT("/:id/config", r.agentHandler.UpdateAgentConfig)                     // ✅ 更新Agent配置 [Master端校验存储并递增版本号，Agent心跳对账时获取]
+		agentManageGroup.GET("/:id/effective-config", r.agentHandler.GetAgentEffectiveConfig)     // ✅ 导出Agent生效配置 [与Agent拉取的配置一致，标注每个配置项来源 default/group/agent]
+		agentManageGroup.GET("/groups/:group_id/config", r.agentHandler.GetAgentGroupConfig)      // ✅ 获取分组配置补丁 [group_id 为 agent_group 分组标签ID]
+		agentManageGroup.PATCH("/groups/:group_id/config", r.agentHandler.UpdateAgentGroupConfig) // ✅ 批量更新分组配置 [局部补丁，事务内应用到全部成员并各自递增版本号，之后加入分组的Agent继承]
 
 		// ==================== Agent任务管理路由 ====================
 		// ============== Agent任务管理路由（🔴 需要Agent端配合实现 - Agent端执行任务） ====================
@@ -103,16 +117,18 @@ func (r *Router) setupAgentRoutes(v1 *gin.RouterGroup) {
 		// ----- 分组管理 -----
 		// (已移除 AgentGroup 相关路由，“组”功能由标签系统替代，不再保留“组”概念，统一使用标签来实现分组功能)
 		// ----- 标签管理 -----
-		agentManageGroup.GET("/:id/tags", r.agentHandler.GetAgentTags)      // 获取Agent标签 [Master端查询Agent标签]
-		agentManageGroup.POST("/:id/tags", r.agentHandler.AddAgentTag)      // 添加Agent标签 [Master端更新单个标签]
-		agentManageGroup.PUT("/:id/tags", r.agentHandler.UpdateAgentTags)   // 更新Agent标签列表（覆盖更新为指定列表）
-		agentManageGroup.DELETE("/:id/tags", r.agentHandler.RemoveAgentTag) // 移除Agent标签 [Master端删除指定标签]
+		agentManageGroup.GET("/:id/tags", r.agentHandler.GetAgentTags)       // 获取Agent标签 [Master端查询Agent标签]
+		agentManageGroup.POST("/:id/tags", r.agentHandler.AddAgentTag)       // 添加Agent标签 [Master端更新单个标签]
+		agentManageGroup.PUT("/:id/tags", r.agentHandler.UpdateAgentTags)    // 更新Agent标签列表（覆盖更新为指定列表）
+		agentManageGroup.DELETE("/:id/tags", r.agentHandler.RemoveAgentTag)  // 移除Agent标签 [Master端删除指定标签]
+		agentManageGroup.POST("/:id/tags/auto", r.agentHandler.AutoTagAgent) // 按匹配规则重新评估Agent自动标签 [entity_type=agent 的规则，只增删 auto 来源标签]
 
 		// ==================== Agent通信和控制路由（🔴 需要Agent端配合实现 - 跨网络通信） ====================
 		agentManageGroup.POST("/:id/command", r.agentSendCommandPlaceholder)             // 🔴 发送控制命令到Agent [需要Master->Agent通信协议，发送自定义命令]
 		agentManageGroup.GET("/:id/command/:cmd_id", r.agentGetCommandStatusPlaceholder) // 🔴 获取命令执行状态 [需要Agent端返回命令执行结果]
 		agentManageGroup.POST("/:id/sync", r.agentSyncConfigPlaceholder)                 // 🔴 同步配置到Agent [需要Master->Agent推送配置并确认应用]
 		agentManageGroup.POST("/:id/upgrade", r.agentUpgradePlaceholder)                 // 🔴 升级Agent版本 [需要Agent端支持版本升级机制]
+		agentManageGroup.GET("/updates/pending", r.agentHandler.GetAgentsNeedingUpdate)  // 获取需要升级的在线Agent [Master端对比最新版本]
 		agentManageGroup.POST("/:id/reset", r.agentResetPlaceholder)                     // 🔴 重置Agent配置 [需要Agent端重置到默认配置]
 
 		// ==================== Agent监控和告警路由（🔴 需要Agent端配合实现 - 实时监控） ====================
@@ -179,26 +195,6 @@ func (r *Router) agentStatusPlaceholder(c *gin.Context) {
 
 // ==================== Agent配置管理占位符 ====================
 
-// agentGetConfigPlaceholder 获取Agent配置占位符
-func (r *Router) agentGetConfigPlaceholder(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "获取Agent配置功能待实现",
-		"status":    "placeholder",
-		"agent_id":  c.Param("id"),
-		"timestamp": logger.NowFormatted(),
-	})
-}
-
-// agentUpdateConfigPlaceholder 更新Agent配置占位符
-func (r *Router) agentUpdateConfigPlaceholder(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "更新Agent配置功能待实现",
-		"status":    "placeholder",
-		"agent_id":  c.Param("id"),
-		"timestamp": logger.NowFormatted(),
-	})
-}
-
 // ==================== Agent任务管理占位符 ====================
 
 // ==================== Agent日志管理占位符 ====================
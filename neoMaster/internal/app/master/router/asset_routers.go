@@ -16,8 +16,9 @@ import (
 func (r *Router) setupAssetRoutes(v1 *gin.RouterGroup) {
 	assetGroup := v1.Group("/asset")
 
-	// 使用 JWT 中间件保护
+	// 使用 JWT 中间件保护 (API 密钥需授权 asset:read / asset:write)
 	if r.middlewareManager != nil {
+		assetGroup.Use(r.middlewareManager.GinAPIKeyScopeMiddleware("asset"))
 		assetGroup.Use(r.middlewareManager.GinJWTAuthMiddleware())
 		assetGroup.Use(r.middlewareManager.GinUserActiveMiddleware())
 	}
@@ -71,11 +72,13 @@ func (r *Router) setupAssetRoutes(v1 *gin.RouterGroup) {
 		// 主机资产管理
 		hosts := assetGroup.Group("/hosts")
 		{
-			hosts.POST("", r.assetHostHandler.CreateHost)       // 创建主机
-			hosts.GET("/:id", r.assetHostHandler.GetHost)       // 获取主机详情
-			hosts.PUT("/:id", r.assetHostHandler.UpdateHost)    // 更新主机
-			hosts.DELETE("/:id", r.assetHostHandler.DeleteHost) // 删除主机
-			hosts.GET("", r.assetHostHandler.ListHosts)         // 获取主机列表
+			hosts.POST("", r.assetHostHandler.CreateHost)        // 创建主机
+			hosts.GET("/:id", r.assetHostHandler.GetHost)        // 获取主机详情
+			hosts.PUT("/:id", r.assetHostHandler.UpdateHost)     // 更新主机
+			hosts.DELETE("/:id", r.assetHostHandler.DeleteHost)  // 删除主机
+			hosts.GET("", r.assetHostHandler.ListHosts)          // 获取主机列表 (支持 cidr / open_port 筛选)
+			hosts.POST("/ingest", r.assetHostHandler.IngestHost) // 扫描结果主机入库(合并端口)
+			hosts.GET("/search", r.assetHostHandler.SearchHosts) // 按网段搜索主机 (cidr + open_port)
 
 			// 主机服务列表
 			hosts.GET("/:id/services", r.assetHostHandler.ListServicesByHost)
@@ -215,8 +218,9 @@ func (r *Router) setupAssetRoutes(v1 *gin.RouterGroup) {
 
 			// 核心操作
 			fingerprintRules.GET("/export", r.assetFingerprintRuleHandler.ExportRules)      // 导出规则库
-			fingerprintRules.POST("/import", r.assetFingerprintRuleHandler.ImportRules)     // 导入规则库
 			fingerprintRules.POST("/rollback", r.assetFingerprintRuleHandler.RollbackRules) // 回滚规则库
+			// 导入规则库(批量覆盖现有规则，需 system:admin 权限)
+			fingerprintRules.POST("/import", r.middlewareManager.RequirePermission("system:admin"), r.assetFingerprintRuleHandler.ImportRules)
 
 			// 发布 (Admin) - 将 DB 中的规则同步到 Agent 下载目录
 			// 指纹资产发生变更后，需要触发发布操作，系统才会将最新规则同步到 Agent 下载目录，Agent 才会生效
@@ -242,7 +246,8 @@ func (r *Router) setupAssetRoutes(v1 *gin.RouterGroup) {
 		// 资产扫描记录管理
 		scans := assetGroup.Group("/scans")
 		{
-			scans.POST("", r.assetScanHandler.CreateScan)                                 // 创建扫描记录
+			// 创建扫描记录(提交扫描任务)，支持 Idempotency-Key，网络重试不会重复提交
+			scans.POST("", r.middlewareManager.GinIdempotencyMiddleware(), r.assetScanHandler.CreateScan)
 			scans.GET("/:id", r.assetScanHandler.GetScan)                                 // 获取扫描记录详情
 			scans.PUT("/:id", r.assetScanHandler.UpdateScan)                              // 更新扫描记录
 			scans.DELETE("/:id", r.assetScanHandler.DeleteScan)                           // 删除扫描记录
@@ -4,6 +4,9 @@
  * @date: 2025.10.10
  * @description: 包含健康检查路由
  * @func:
+ *   - setupHealthRoutes /api 下的健康/就绪/存活检查
+ *   - setupProbeRoutes 根路径下的 /healthz、/readyz 探针(探测 MySQL/Redis)
+ *   - setupMetricsRoutes 根路径下的 Prometheus 指标接口(monitor.metrics)
  */
 
 package router
@@ -20,12 +23,32 @@ import (
 func (r *Router) setupHealthRoutes(api *gin.RouterGroup) {
 	// 健康检查
 	api.GET("/health", r.healthCheck)
-	// 就绪检查
-	api.GET("/ready", r.readinessCheck)
+	// 就绪检查（与 /readyz 一致，检查依赖与迁移状态）
+	api.GET("/ready", r.healthHandler.Readyz)
 	// 存活检查
 	api.GET("/live", r.livenessCheck)
 }
 
+// setupProbeRoutes 设置探针路由
+// 供 k8s/负载均衡探测使用：依赖全部可用返回 200，否则返回 503
+func (r *Router) setupProbeRoutes(engine *gin.Engine) {
+	engine.GET("/healthz", r.healthHandler.Healthz)
+	engine.GET("/readyz", r.healthHandler.Readyz)
+}
+
+// setupMetricsRoutes 设置 Prometheus 指标路由
+// 与探针一样不经过用户认证，按 Prometheus 抓取惯例由网络层限制访问来源；路径默认 /metrics
+func (r *Router) setupMetricsRoutes(engine *gin.Engine) {
+	if r.metricsHandler == nil || r.config == nil || !r.config.Monitor.Metrics.Enabled {
+		return
+	}
+	path := r.config.Monitor.Metrics.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	engine.GET(path, r.metricsHandler.Metrics)
+}
+
 // 健康检查处理器
 func (r *Router) healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -34,15 +57,6 @@ func (r *Router) healthCheck(c *gin.Context) {
 	})
 }
 
-// readinessCheck 就绪检查处理器
-func (r *Router) readinessCheck(c *gin.Context) {
-	// TODO: 检查依赖服务（数据库、Redis等）是否就绪
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "ready",
-		"timestamp": logger.NowFormatted(),
-	})
-}
-
 // livenessCheck 存活检查处理器
 func (r *Router) livenessCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
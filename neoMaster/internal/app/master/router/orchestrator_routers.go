@@ -7,24 +7,36 @@
  */
 package router
 
-import "github.com/gin-gonic/gin"
+import (
+	"time"
+
+	"neomaster/internal/app/master/middleware"
+
+	"github.com/gin-gonic/gin"
+)
 
 func (r *Router) setupOrchestratorRoutes(v1 *gin.RouterGroup) {
 	orchestratorGroup := v1.Group("/orchestrator")
-	// 使用 JWT 中间件进行认证
+	// 使用 JWT 中间件进行认证 (API 密钥需授权 orchestrator:read / orchestrator:write，Webhook 管理另需 system:admin)
 	if r.middlewareManager != nil {
+		orchestratorGroup.Use(r.middlewareManager.GinAPIKeyScopeMiddleware("orchestrator"))
 		orchestratorGroup.Use(r.middlewareManager.GinJWTAuthMiddleware())
 		orchestratorGroup.Use(r.middlewareManager.GinUserActiveMiddleware())
+		orchestratorGroup.Use(r.middlewareManager.GinKeyedRateLimitMiddleware("api", middleware.RateLimitRule{Limit: 300, Window: time.Minute}))
 	}
 
 	// 1. 项目管理 (Project Management)
 	projects := orchestratorGroup.Group("/projects")
 	{
-		projects.POST("", r.projectHandler.CreateProject)
+		projects.POST("", r.middlewareManager.GinIdempotencyMiddleware(), r.projectHandler.CreateProject) // 支持 Idempotency-Key，重试不会重复创建
 		projects.GET("", r.projectHandler.ListProjects)
 		projects.GET("/:id", r.projectHandler.GetProject)
 		projects.PUT("/:id", r.projectHandler.UpdateProject)
 		projects.DELETE("/:id", r.projectHandler.DeleteProject)
+		projects.POST("/:id/cancel", r.projectHandler.CancelProject) // 取消项目 (运行中的 Agent 任务进入 canceling，等待 Agent 确认)
+
+		// 项目定时调度 (cron 项目接下来的执行时间)
+		projects.GET("/:id/upcoming-runs", r.projectHandler.GetUpcomingRuns)
 
 		// 项目关联工作流
 		projects.POST("/:id/workflows", r.projectHandler.AddWorkflow)
@@ -35,6 +47,15 @@ func (r *Router) setupOrchestratorRoutes(v1 *gin.RouterGroup) {
 		projects.POST("/:id/tags", r.projectHandler.AddProjectTag)
 		projects.DELETE("/:id/tags/:tag_id", r.projectHandler.RemoveProjectTag)
 		projects.GET("/:id/tags", r.projectHandler.GetProjectTags)
+
+		// 项目结果汇总
+		projects.GET("/:id/summary", r.stageResultHandler.GetProjectSummary)
+
+		// 项目两轮执行结果比对 (?run_a=&run_b=)
+		projects.GET("/:id/diff", r.stageResultHandler.DiffProjectRuns)
+
+		// 项目执行进度事件流 (SSE: 阶段开始/完成、进度百分比、结束汇总)
+		projects.GET("/:id/events", r.stageResultHandler.StreamProjectEvents)
 	}
 
 	// 2. 工作流管理 (Workflow Management)
@@ -77,7 +98,28 @@ func (r *Router) setupOrchestratorRoutes(v1 *gin.RouterGroup) {
 		templates.DELETE("/:id", r.scanToolTemplateHandler.DeleteTemplate)
 	}
 
-	// 5. Agent 任务管理 (Agent Task Management)
+	// 5. Webhook 订阅管理 (项目完成 / 严重漏洞事件推送)
+	// 订阅决定 Master 向外发起请求的地址并持有签名密钥，额外要求 system:admin 权限
+	webhooks := orchestratorGroup.Group("/webhooks")
+	if r.middlewareManager != nil {
+		webhooks.Use(r.middlewareManager.RequirePermission("system:admin"))
+	}
+	{
+		webhooks.POST("", r.webhookHandler.CreateWebhook)
+		webhooks.GET("", r.webhookHandler.ListWebhooks)
+		webhooks.GET("/:id", r.webhookHandler.GetWebhook)
+		webhooks.PUT("/:id", r.webhookHandler.UpdateWebhook)
+		webhooks.DELETE("/:id", r.webhookHandler.DeleteWebhook)
+		// 投递记录 (每次尝试一条)
+		webhooks.GET("/:id/deliveries", r.webhookHandler.ListWebhookDeliveries)
+	}
+
+	// 任务并发占用情况 (全局/项目运行中与排队任务数)
+	orchestratorGroup.GET("/tasks/concurrency", r.agentTaskHandler.GetConcurrencyStatus)
+	// 取消单个任务
+	orchestratorGroup.POST("/tasks/:task_id/cancel", r.agentTaskHandler.CancelTask)
+
+	// 6. Agent 任务管理 (Agent Task Management)
 	// 迁移至 Orchestrator 路径下: /orchestrator/agent/...
 	// 注意：Agent 任务接口供 Agent 调用，使用 Agent 鉴权 (Token)，而非用户 JWT
 	agentTaskGroup := v1.Group("/orchestrator/agent")
@@ -8,6 +8,10 @@
 package router
 
 import (
+	"time"
+
+	"neomaster/internal/app/master/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,7 +27,7 @@ func (r *Router) setupPublicRoutes(v1 *gin.RouterGroup) {
 		}
 		// auth.POST("/register", r.registerHandler.Register) // handler\auth\register.go 没有权限校验的接口，默认角色为普通用户 role_id = 2
 		// 用户登录
-		auth.POST("/login", r.loginHandler.Login) // handler\auth\login.go
+		auth.POST("/login", r.middlewareManager.GinKeyedRateLimitMiddleware("login", middleware.RateLimitRule{Limit: 5, Window: time.Minute}), r.loginHandler.Login) // handler\auth\login.go 登录接口单独严格限流
 		// 获取登录表单页面（可选）
 		// auth.GET("/login", r.loginHandler.GetLoginForm)
 		// 刷新令牌(从body中传递传递refresh_token)
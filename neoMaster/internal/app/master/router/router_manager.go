@@ -11,6 +11,7 @@ import (
 	setup "neomaster/internal/app/master/setup"
 	"neomaster/internal/service/asset/enrichment"
 	"neomaster/internal/service/asset/etl"
+	"neomaster/internal/service/notify/webhook"
 	"neomaster/internal/service/orchestrator/core/scheduler"
 	"neomaster/internal/service/orchestrator/local_agent"
 
@@ -19,12 +20,17 @@ import (
 	agentHandler "neomaster/internal/handler/agent"
 	assetHandler "neomaster/internal/handler/asset"
 	authHandler "neomaster/internal/handler/auth"
+	monitorHandler "neomaster/internal/handler/monitor"
 	orchestratorHandler "neomaster/internal/handler/orchestrator"
 	systemHandler "neomaster/internal/handler/system"
 	tagHandler "neomaster/internal/handler/tag_system"
 
+	agentService "neomaster/internal/service/agent"
+	authService "neomaster/internal/service/auth"
+
 	// 统一使用项目封装的日志模块，便于采集规范字段与统一输出
 	"neomaster/internal/pkg/logger"
+	redisRepo "neomaster/internal/repo/redis"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -40,10 +46,13 @@ type Router struct {
 	logoutHandler     *authHandler.LogoutHandler
 	refreshHandler    *authHandler.RefreshHandler
 	registerHandler   *authHandler.RegisterHandler
+	totpHandler       *authHandler.TOTPHandler
+	apiKeyHandler     *authHandler.APIKeyHandler
 	userHandler       *systemHandler.UserHandler
 	roleHandler       *systemHandler.RoleHandler
 	permissionHandler *systemHandler.PermissionHandler
 	sessionHandler    *systemHandler.SessionHandler
+	auditLogHandler   *systemHandler.AuditLogHandler // 审计日志查询/导出(未启用审计日志功能时为 nil)
 	// Agent管理相关Handler
 	agentHandler *agentHandler.AgentHandler
 	// 资产管理相关Handler
@@ -66,10 +75,18 @@ type Router struct {
 	scanStageHandler        *orchestratorHandler.ScanStageHandler
 	scanToolTemplateHandler *orchestratorHandler.ScanToolTemplateHandler
 	agentTaskHandler        *orchestratorHandler.AgentTaskHandler
+	stageResultHandler      *orchestratorHandler.StageResultHandler
+	webhookHandler          *orchestratorHandler.WebhookHandler
+	agentResultHandler      *orchestratorHandler.AgentResultHandler
 
 	// 标签系统相关Handler
 	tagHandler *tagHandler.TagHandler
 
+	// 健康检查Handler
+	healthHandler *monitorHandler.HealthHandler
+	// Prometheus 指标Handler
+	metricsHandler *monitorHandler.MetricsHandler
+
 	// 调度服务
 	schedulerService scheduler.SchedulerService
 	// 本地Agent (原系统任务执行器)
@@ -78,6 +95,12 @@ type Router struct {
 	etlProcessor etl.ResultProcessor
 	// 指纹治理服务(资产富化 - Master端二次指纹治理服务)
 	fingerprintGovernance *enrichment.FingerprintMatcher
+	// 审计日志落库服务(未启用时为 nil)
+	auditService *authService.AuditService
+	// Webhook 事件分发器(未启用时为 nil)
+	webhookDispatcher *webhook.Dispatcher
+	// Agent 监控服务(后台离线巡检)
+	agentMonitorService agentService.AgentMonitorService
 }
 
 // NewRouter 创建路由管理器实例
@@ -104,6 +127,9 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 
 	// 通过 setup.BuildSystemRBACModule 初始化系统RBAC模块（角色与权限管理）
 	rbacModule := setup.BuildSystemRBACModule(db)
+	// 角色/权限变更后使已缓存的用户权限集失效
+	rbacModule.RoleService.SetPermissionCache(authModule.PermissionCache)
+	rbacModule.PermissionService.SetPermissionCache(authModule.PermissionCache)
 
 	// 通过 setup.BuildTagSystemModule 初始化标签系统模块
 	tagModule := setup.BuildTagSystemModule(db)
@@ -115,6 +141,13 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 	// 初始化中间件管理器（传入jwtService用于密码版本验证，传入agentManagerService用于Agent鉴权）
 	// Linus: 修正中间件依赖，注入 Service 而非 Repo
 	middlewareManager := middleware.NewMiddlewareManager(authModule.SessionService, authModule.RBACService, authModule.JWTService, securityConfig, agentModule.ManagerService)
+	// 按用户/接口限流的计数与幂等记录放在 Redis 中，多实例部署时共享
+	if redisClient != nil {
+		middlewareManager.SetRateLimitStore(redisRepo.NewRateLimitRepository(redisClient))
+		middlewareManager.SetIdempotencyStore(redisRepo.NewIdempotencyRepository(redisClient))
+	}
+	// 启用 Authorization: ApiKey <key> 认证
+	middlewareManager.SetAPIKeyAuthenticator(authModule.APIKeyService)
 
 	// 初始化处理器(控制器是服务集合,先初始化服务,然后服务装填成控制器)
 	loginHandler := authModule.LoginHandler
@@ -125,13 +158,24 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 	roleHandler := rbacModule.RoleHandler
 	permissionHandler := rbacModule.PermissionHandler
 	sessionHandler := systemHandler.NewSessionHandler(authModule.SessionService)
+	var auditLogHandler *systemHandler.AuditLogHandler
+	if authModule.AuditService != nil {
+		auditLogHandler = systemHandler.NewAuditLogHandler(authModule.AuditService)
+	}
 
 	// 通过 setup.BuildOrchestratorModule 初始化扫描编排器模块
 	orchestratorModule := setup.BuildOrchestratorModule(db, config, tagModule.TagService)
+	// 多个 Master 实例共享定时触发锁，同一次定时任务只触发一次
+	if redisClient != nil {
+		orchestratorModule.SchedulerService.SetScheduleLocker(redisRepo.NewScheduleLockRepository(redisClient))
+	}
 
 	// 通过 setup.BuildAssetModule 初始化资产管理模块
-	// 注意：BuildAssetModule 依赖 OrchestratorModule.ETLProcessor，所以必须在 OrchestratorModule 之后初始化
-	assetModule := setup.BuildAssetModule(db, config, tagModule.TagService, orchestratorModule.ETLProcessor)
+	// 注意：BuildAssetModule 依赖 OrchestratorModule.ETLProcessor 与 AssetMerger，所以必须在 OrchestratorModule 之后初始化
+	assetModule := setup.BuildAssetModule(db, config, tagModule.TagService, orchestratorModule.ETLProcessor, orchestratorModule.AssetMerger)
+
+	// 各模块已注册重打标数据来源，恢复重启前未执行完的重打标任务
+	tagModule.TagService.ResumeRetags()
 
 	// 从 OrchestratorModule 中获取聚合后的处理器
 	projectHandler := orchestratorModule.ProjectHandler
@@ -139,6 +183,8 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 	scanStageHandler := orchestratorModule.ScanStageHandler
 	scanToolTemplateHandler := orchestratorModule.ScanToolTemplateHandler
 	agentTaskHandler := orchestratorModule.AgentTaskHandler
+	stageResultHandler := orchestratorModule.StageResultHandler
+	webhookHandler := orchestratorModule.WebhookHandler
 
 	// 从 AgentModule 中获取聚合后的 Handler（分组功能已合并到 ManagerService 内部）
 	assetRawHandler := assetModule.AssetRawHandler
@@ -158,6 +204,11 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 	// 从 TagModule 中获取处理器
 	tagHandler := tagModule.TagHandler
 
+	// 健康检查处理器（直接探测 MySQL/Redis 连接）
+	healthHandler := monitorHandler.NewHealthHandler(db, redisClient, &config.App)
+	// Prometheus 指标处理器（导出在线Agent的最新性能快照）
+	metricsHandler := monitorHandler.NewMetricsHandler(agentModule.MetricsExporter)
+
 	// 创建Gin引擎
 	gin.SetMode(gin.ReleaseMode) // 设置为生产模式
 	engine := gin.New()
@@ -170,10 +221,13 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 		logoutHandler:     logoutHandler,
 		refreshHandler:    refreshHandler,
 		registerHandler:   registerHandler,
+		totpHandler:       authModule.TOTPHandler,
+		apiKeyHandler:     authModule.APIKeyHandler,
 		userHandler:       userHandler,
 		roleHandler:       roleHandler,
 		permissionHandler: permissionHandler,
 		sessionHandler:    sessionHandler,
+		auditLogHandler:   auditLogHandler,
 		// Agent管理相关Handler
 		agentHandler: agentMgmtHandler,
 		// 资产管理相关Handler
@@ -196,10 +250,18 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 		scanStageHandler:        scanStageHandler,
 		scanToolTemplateHandler: scanToolTemplateHandler,
 		agentTaskHandler:        agentTaskHandler,
+		stageResultHandler:      stageResultHandler,
+		webhookHandler:          webhookHandler,
+		agentResultHandler:      orchestratorModule.AgentResultHandler,
 
 		// 标签系统Handler
 		tagHandler: tagHandler,
 
+		// 健康检查Handler
+		healthHandler: healthHandler,
+		// Prometheus 指标Handler
+		metricsHandler: metricsHandler,
+
 		// 扫描任务调度服务
 		schedulerService: orchestratorModule.SchedulerService,
 		// 本地Agent
@@ -208,6 +270,12 @@ func NewRouter(db *gorm.DB, redisClient *redis.Client, config *config.Config) *R
 		etlProcessor: orchestratorModule.ETLProcessor,
 		// 指纹治理服务
 		fingerprintGovernance: assetModule.FingerprintGovernance,
+		// 审计日志落库服务
+		auditService: authModule.AuditService,
+		// Webhook 事件分发器
+		webhookDispatcher: orchestratorModule.WebhookDispatcher,
+		// Agent 监控服务
+		agentMonitorService: agentModule.MonitorService,
 	}
 }
 
@@ -238,6 +306,36 @@ func (r *Router) GetLocalAgent() *local_agent.LocalAgent {
 	return r.localAgent
 }
 
+// InFlightRequests 获取当前进行中的请求数 (用于优雅关闭时观测请求排空情况)
+func (r *Router) InFlightRequests() int64 {
+	if r.middlewareManager == nil {
+		return 0
+	}
+	return r.middlewareManager.InFlightRequests()
+}
+
+// CloseEventStreams 结束进行中的 SSE 事件流 (注册到 http.Server.RegisterOnShutdown)
+func (r *Router) CloseEventStreams() {
+	if r.stageResultHandler != nil {
+		r.stageResultHandler.CloseStreams()
+	}
+}
+
+// GetAuditService 获取审计日志落库服务实例 (未启用审计日志功能时为 nil)
+func (r *Router) GetAuditService() *authService.AuditService {
+	return r.auditService
+}
+
+// GetWebhookDispatcher 获取 Webhook 事件分发器实例 (未启用 Webhook 时为 nil)
+func (r *Router) GetWebhookDispatcher() *webhook.Dispatcher {
+	return r.webhookDispatcher
+}
+
+// GetAgentMonitorService 获取Agent监控服务实例
+func (r *Router) GetAgentMonitorService() agentService.AgentMonitorService {
+	return r.agentMonitorService
+}
+
 // GetETLProcessor 获取ETL处理器实例
 func (r *Router) GetETLProcessor() etl.ResultProcessor {
 	return r.etlProcessor
@@ -259,10 +357,24 @@ func (r *Router) registerGlobalMiddleware() {
 	r.engine.Use(gin.Recovery())
 
 	if r.middlewareManager != nil {
+		// 进行中请求计数中间件，优雅关闭时据此统计未排空的请求
+		r.engine.Use(r.middlewareManager.GinInFlightMiddleware())
+		// 请求ID中间件，需最先注册，保证后续中间件与 Handler 日志都带上同一个请求ID
+		r.engine.Use(r.middlewareManager.GinRequestIDMiddleware())
 		// CORS 中间件
 		r.engine.Use(r.middlewareManager.GinCORSMiddleware())
 		// 安全响应头中间件
 		r.engine.Use(r.middlewareManager.GinSecurityHeadersMiddleware())
+		// 响应压缩中间件 (gzip，按配置启用)
+		if r.config != nil {
+			r.engine.Use(r.middlewareManager.GinCompressionMiddleware(&r.config.Server.Compression))
+		}
+		// 请求体大小限制中间件，需在日志中间件读取请求体之前
+		var bodyLimit *config.BodyLimitConfig
+		if r.config != nil {
+			bodyLimit = &r.config.Server.BodyLimit
+		}
+		r.engine.Use(r.middlewareManager.GinBodyLimitMiddleware(bodyLimit))
 		// 统一日志中间件
 		r.engine.Use(r.middlewareManager.GinLoggingMiddleware())
 		// 限流中间件
@@ -313,6 +425,10 @@ func (r *Router) registerRoutes() {
 	r.setupTagSystemRoutes(v1)
 	// 健康检查路由
 	r.setupHealthRoutes(api)
+	// 探针路由（/healthz、/readyz，挂载在根路径，不需要认证）
+	r.setupProbeRoutes(r.engine)
+	// Prometheus 指标路由（monitor.metrics 启用时挂载在根路径）
+	r.setupMetricsRoutes(r.engine)
 
 	logger.WithFields(map[string]interface{}{
 		"path":      "router_manager.registerRoutes",
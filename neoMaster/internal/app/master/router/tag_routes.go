@@ -8,6 +8,7 @@ import (
 func (r *Router) setupTagSystemRoutes(rg *gin.RouterGroup) {
 	// 使用 JWT 中间件保护
 	tags := rg.Group("/tags")
+	tags.Use(r.middlewareManager.GinAPIKeyScopeMiddleware("tag")) // API 密钥需授权 tag:read / tag:write
 	tags.Use(r.middlewareManager.GinJWTAuthMiddleware())
 	tags.Use(r.middlewareManager.GinUserActiveMiddleware())
 	{
@@ -22,9 +23,16 @@ func (r *Router) setupTagSystemRoutes(rg *gin.RouterGroup) {
 		// 规则 CRUD
 		rules := tags.Group("/rules")
 		rules.POST("", r.tagHandler.CreateRule)
-		rules.GET("", r.tagHandler.ListRules) // 支持 ?entity_type=xxx
+		rules.GET("", r.tagHandler.ListRules)      // 支持 ?entity_type=xxx
+		rules.POST("/test", r.tagHandler.TestRule) // 用样例数据测试规则 (rule_id 或 rule_json)，不写入标签
 		rules.PUT("/:id", r.tagHandler.UpdateRule)
 		rules.DELETE("/:id", r.tagHandler.DeleteRule)
 		rules.POST("/:id/apply", r.tagHandler.ApplyRule) // 手动触发规则执行 ?action=add|remove
+
+		// 批量重打标 (规则变更后对存量实体重新执行自动打标)
+		retag := tags.Group("/retag")
+		retag.POST("/:entity_type", r.tagHandler.StartRetag)
+		retag.GET("/:entity_type", r.tagHandler.GetRetagProgress)
+		retag.DELETE("/:entity_type", r.tagHandler.CancelRetag)
 	}
 }
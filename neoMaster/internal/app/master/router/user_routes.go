@@ -20,7 +20,7 @@ func (r *Router) setupUserRoutes(v1 *gin.RouterGroup) {
 	{
 		// 登出只能一次
 		// 用户全部登出(更新密码版本,所有类型token失效,不再使用redis撤销黑名单的方式)
-		auth.POST("/logout-all", r.logoutHandler.LogoutAll)
+		auth.POST("/logout-all", r.middlewareManager.GinDenyAPIKeyMiddleware(), r.logoutHandler.LogoutAll)
 	}
 
 	// 用户相关路由（需要JWT认证和用户激活状态检查）
@@ -31,12 +31,24 @@ func (r *Router) setupUserRoutes(v1 *gin.RouterGroup) {
 		// 获取当前用户全量信息(包含权限和角色信息)
 		user.GET("/profile", r.userHandler.GetUserInfoByIDforUser) // 获取当前用户全量信息
 		// 修改用户密码
-		user.POST("/change-password", r.userHandler.ChangePassword) // 修改用户密码
+		user.POST("/change-password", r.middlewareManager.GinDenyAPIKeyMiddleware(), r.userHandler.ChangePassword) // 修改用户密码
 		// 更新用户信息（需要补充）
 		user.POST("/update", r.userHandler.UserUpdateInfoByID) // 允许用户自己修改自己的信息（仅user表，不能修改角色和权限等）
 		// 获取用户权限
 		user.GET("/permissions", r.userHandler.GetUserPermission) // 获取用户权限(permissions表)
 		// 获取用户角色
 		user.GET("/roles", r.userHandler.GetUserRoles) // 获取用户角色(roles表)
+		// 两步验证(TOTP)：先绑定获取二维码，再提交动态码启用；关闭同样需要动态码
+		user.POST("/totp/enroll", r.middlewareManager.GinDenyAPIKeyMiddleware(), r.totpHandler.Enroll)     // 生成密钥，返回 otpauth:// 配置URI
+		user.POST("/totp/activate", r.middlewareManager.GinDenyAPIKeyMiddleware(), r.totpHandler.Activate) // 校验动态码并启用
+		user.POST("/totp/disable", r.middlewareManager.GinDenyAPIKeyMiddleware(), r.totpHandler.Disable)   // 校验动态码并关闭
+		// API 密钥(服务间调用，请求头 Authorization: ApiKey <key>)：明文只在创建时返回一次；密钥本身不能管理密钥
+		apiKeys := user.Group("/api-keys")
+		apiKeys.Use(r.middlewareManager.GinDenyAPIKeyMiddleware())
+		{
+			apiKeys.POST("", r.apiKeyHandler.Create)       // 创建密钥
+			apiKeys.GET("", r.apiKeyHandler.List)          // 密钥列表(不含明文)
+			apiKeys.DELETE("/:id", r.apiKeyHandler.Revoke) // 吊销密钥
+		}
 	}
 }
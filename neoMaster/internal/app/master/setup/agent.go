@@ -38,10 +38,16 @@ func BuildAgentModule(db *gorm.DB, cfg *config.Config, tagService tag_system.Tag
 	// TaskRepository 现由 Orchestrator 模块管理，Agent 模块仅做 Agent 本身管理
 
 	// 2) 初始化服务（遵循 Handler → Service → Repository 层级调用约束）
-	managerService := agentService.NewAgentManagerService(cfg, agentRepository, tagService)
+	// 事件中心由 Manager/Monitor 共享：状态更新、心跳、指标更新都从这里推送给看板
+	eventHub := agentService.NewAgentEventHub(0)
+	managerService := agentService.NewAgentManagerService(cfg, agentRepository, tagService, eventHub)
 	updateService := agentService.NewAgentUpdateService(cfg)
-	monitorService := agentService.NewAgentMonitorService(agentRepository, tagService, updateService) // 注入 updateService
-	configService := agentService.NewAgentConfigService(agentRepository)
+	configService := agentService.NewAgentConfigService(agentRepository, tagService)
+	monitorService := agentService.NewAgentMonitorService(agentRepository, tagService, updateService, configService, eventHub) // 注入 updateService/configService
+	// 加入分组时继承分组配置
+	managerService.SetConfigService(configService)
+	// 匹配规则变更后可对存量Agent批量重打标
+	agentService.RegisterAgentRetagSource(tagService, db)
 	// AgentTaskService 已移至 Orchestrator 模块
 
 	// 执行系统标签初始化与同步 (Bootstrap & Sync)
@@ -65,6 +71,8 @@ func BuildAgentModule(db *gorm.DB, cfg *config.Config, tagService tag_system.Tag
 		updateService,
 		// taskService, // 已移除
 	)
+	// WebSocket 事件订阅的来源检查
+	agentHandler.SetWebSocketOriginPolicy(cfg.WebSocket.CheckOrigin, cfg.Security.CORS)
 
 	// 4) 聚合输出模块，便于路由层与其他模块按需使用
 	module := &AgentModule{
@@ -73,6 +81,8 @@ func BuildAgentModule(db *gorm.DB, cfg *config.Config, tagService tag_system.Tag
 		MonitorService:  monitorService,
 		ConfigService:   configService,
 		UpdateService:   updateService,
+		EventHub:        eventHub,
+		MetricsExporter: agentService.NewAgentMetricsExporter(agentRepository, cfg),
 		AgentRepository: agentRepository,
 	}
 
@@ -24,7 +24,7 @@ import (
 )
 
 // BuildAssetModule 构建资产管理模块
-func BuildAssetModule(db *gorm.DB, config *config.Config, tagSystem tagService.TagService, etlProcessor etl.ResultProcessor) *AssetModule {
+func BuildAssetModule(db *gorm.DB, config *config.Config, tagSystem tagService.TagService, etlProcessor etl.ResultProcessor, assetMerger etl.AssetMerger) *AssetModule {
 	logger.WithFields(map[string]interface{}{
 		"path":      "setup.asset",
 		"operation": "build_module",
@@ -48,6 +48,7 @@ func BuildAssetModule(db *gorm.DB, config *config.Config, tagSystem tagService.T
 	// 2. Service 初始化
 	rawService := assetService.NewRawAssetService(rawRepo, tagSystem)                     // 原始资产管理服务
 	hostService := assetService.NewAssetHostService(hostRepo, tagSystem)                  // 主机资产服务
+	hostService.SetAssetMerger(assetMerger)                                               // 主机入库与 ETL 共用资产合并器
 	networkService := assetService.NewAssetNetworkService(networkRepo, tagSystem)         // 网络资产服务
 	policyService := assetService.NewAssetPolicyService(policyRepo, tagSystem)            // 策略执行服务
 	fingerCmsService := assetService.NewAssetFingerService(fingerCmsRepo, tagSystem)      // CMS指纹服务
@@ -47,13 +47,14 @@ func BuildAuthModule(
 	jwtCfg := cfg.Security.JWT
 	jwtManager := authPkg.NewJWTManager(jwtCfg.Secret, jwtCfg.AccessTokenExpire, jwtCfg.RefreshTokenExpire)
 
-	// PasswordManager 的参数目前未配置化，沿用项目内既有初始化常量（与原 router_manager.go 一致）
+	// PasswordManager 的 Argon2id 参数从配置读取，未配置的项使用默认值
+	pwdCfg := cfg.Security.Password
 	passwordConfig := &authPkg.PasswordConfig{
-		Memory:      64 * 1024, // 64MB
-		Iterations:  3,
-		Parallelism: 2,
-		SaltLength:  32,
-		KeyLength:   32,
+		Memory:      pwdCfg.Memory,
+		Iterations:  pwdCfg.Iterations,
+		Parallelism: pwdCfg.Parallelism,
+		SaltLength:  pwdCfg.SaltLength,
+		KeyLength:   pwdCfg.KeyLength,
 	}
 	passwordManager := authPkg.NewPasswordManager(passwordConfig)
 
@@ -88,6 +89,9 @@ func BuildAuthModule(
 	// 3) 初始化系统用户仓库与服务
 	userRepo := systemRepo.NewUserRepository(db)
 	userService := authService.NewUserService(userRepo, sessionRepo, passwordManager, jwtManager)
+	// 用户权限集缓存(每个认证请求都要解析权限，缓存后避免重复查询角色与权限表)
+	permissionCache := redisRepo.NewPermissionCacheRepository(redisCli)
+	userService.SetPermissionCache(permissionCache, authService.ResolvePermissionCacheTTL(cfg.Security.Auth.PermissionCacheTTL))
 
 	// 4) 初始化RBAC服务 (运行时鉴权使用,并非系统RBAC管理使用)
 	rbacService := authService.NewRBACService(userService)
@@ -96,27 +100,48 @@ func BuildAuthModule(
 	sessionService := authService.NewSessionService(userService, passwordManager, rbacService, sessionRepo)
 	jwtService := authService.NewJWTService(jwtManager, userService, sessionRepo)
 	sessionService.SetTokenGenerator(jwtService)
+	// 两步验证：密钥使用 security.totp.encryption_key 加密落库，已用动态码记录在 Redis 中防重放
+	totpService := authService.NewTOTPService(userRepo, redisRepo.NewTOTPRepository(redisCli), cfg.Security.TOTP.Issuer, cfg.Security.TOTP.EncryptionKey)
+	sessionService.SetTOTPService(totpService)
+	// API 密钥：服务间调用以密钥所属用户身份访问，库中只保存密钥哈希
+	apiKeyService := authService.NewAPIKeyService(systemRepo.NewAPIKeyRepository(db), userRepo)
 
 	// 6) 初始化密码服务
 	passwordService := authService.NewPasswordService(userService, sessionService, passwordManager, time.Hour*24)
 
-	// 7) 初始化处理器（认证相关）
+	// 7) 审计日志落库（app.features.audit_log 启用时 LogAuditOperation 同时写日志流与 audit_logs 表）
+	var auditService *authService.AuditService
+	if cfg.App.Features.AuditLog && db != nil {
+		auditService = authService.NewAuditService(systemRepo.NewAuditLogRepository(db), 0)
+		auditService.Start()
+		logger.SetAuditSink(auditService)
+	}
+
+	// 8) 初始化处理器（认证相关）
 	loginHandler := authHandler.NewLoginHandler(sessionService)
 	logoutHandler := authHandler.NewLogoutHandler(sessionService)
 	refreshHandler := authHandler.NewRefreshHandler(sessionService)
 	registerHandler := authHandler.NewRegisterHandler(userService)
+	totpHandler := authHandler.NewTOTPHandler(totpService)
+	apiKeyHandler := authHandler.NewAPIKeyHandler(apiKeyService)
 
-	// 8) 聚合输出
+	// 9) 聚合输出
 	module := &AuthModule{
 		LoginHandler:    loginHandler,
 		LogoutHandler:   logoutHandler,
 		RefreshHandler:  refreshHandler,
 		RegisterHandler: registerHandler,
+		TOTPHandler:     totpHandler,
+		APIKeyHandler:   apiKeyHandler,
 		SessionService:  sessionService,
 		JWTService:      jwtService,
 		PasswordService: passwordService,
 		UserService:     userService,
 		RBACService:     rbacService,
+		TOTPService:     totpService,
+		APIKeyService:   apiKeyService,
+		AuditService:    auditService,
+		PermissionCache: permissionCache,
 	}
 
 	logger.WithFields(map[string]interface{}{
@@ -17,6 +17,8 @@ import (
 	"neomaster/internal/service/fingerprint"
 	"neomaster/internal/service/fingerprint/engines/http"
 	"neomaster/internal/service/fingerprint/engines/service"
+	"neomaster/internal/service/notify/mail"
+	"neomaster/internal/service/notify/webhook"
 	"neomaster/internal/service/orchestrator/core/scheduler"
 	"neomaster/internal/service/orchestrator/core/task_dispatcher"
 	"neomaster/internal/service/orchestrator/ingestor"    // 引入ingestor
@@ -24,6 +26,7 @@ import (
 
 	// 引入ETL
 	orchestratorHandler "neomaster/internal/handler/orchestrator"
+	orchestratorModel "neomaster/internal/model/orchestrator"
 	orchestratorRepo "neomaster/internal/repo/mysql/orchestrator"
 	orchestratorService "neomaster/internal/service/orchestrator"
 	"neomaster/internal/service/orchestrator/allocator"
@@ -46,6 +49,8 @@ func BuildOrchestratorModule(db *gorm.DB, cfg *config.Config, tagService tag_sys
 	workflowRepo := orchestratorRepo.NewWorkflowRepository(db)
 	scanStageRepo := orchestratorRepo.NewScanStageRepository(db)
 	scanToolTemplateRepo := orchestratorRepo.NewScanToolTemplateRepository(db)
+	stageResultRepo := orchestratorRepo.NewStageResultRepository(db)
+	resultBatchRepo := orchestratorRepo.NewResultBatchRepository(db)
 	// TaskDispatcher 需要 TaskRepository (虽属 Agent 域，但被编排器核心组件使用)
 	taskRepo := orchestratorRepo.NewTaskRepository(db)
 	// AgentTaskService 需要 AgentRepository
@@ -56,7 +61,8 @@ func BuildOrchestratorModule(db *gorm.DB, cfg *config.Config, tagService tag_sys
 	// 2. Core Components 初始化 (Policy Enforcer, Resource Allocator, Task Dispatcher, Scheduler)
 	policyEnforcer := policy.NewPolicyEnforcer(assetPolicyRepo)
 	resourceAllocator := allocator.NewResourceAllocator(tagService)
-	dispatcher := task_dispatcher.NewTaskDispatcher(cfg, taskRepo, policyEnforcer, resourceAllocator)
+	agentSelector := allocator.NewAgentSelector(agentRepository, cfg)
+	dispatcher := task_dispatcher.NewTaskDispatcher(cfg, taskRepo, policyEnforcer, resourceAllocator, agentSelector)
 	schedulerService := scheduler.NewSchedulerService(
 		db,
 		cfg,
@@ -69,6 +75,18 @@ func BuildOrchestratorModule(db *gorm.DB, cfg *config.Config, tagService tag_sys
 	)
 	localAgent := local_agent.NewLocalAgent(db, taskRepo)
 
+	// Webhook 事件分发器 (项目完成 / 严重漏洞)，未启用时各发布点不发送事件
+	webhookRepo := orchestratorRepo.NewWebhookRepository(db)
+	// 启用邮件配置时注册 email 订阅类型(SMTP 发送，HTML 模板)
+	if cfg.Mail.Enabled {
+		webhook.RegisterAdapter(orchestratorModel.WebhookTypeEmail, mail.NewAdapter(mail.NewSender(cfg.Mail)))
+	}
+	var webhookDispatcher *webhook.Dispatcher
+	if cfg.App.Master.Webhook.Enabled {
+		webhookDispatcher = webhook.NewDispatcher(webhookRepo, webhook.OptionsFromConfig(cfg.App.Master.Webhook))
+		schedulerService.SetWebhookNotifier(webhookDispatcher)
+	}
+
 	// Ingestor Components 初始化
 	// 读取队列配置
 	queueCapacity := cfg.App.Master.Queue.Capacity
@@ -96,7 +114,20 @@ func BuildOrchestratorModule(db *gorm.DB, cfg *config.Config, tagService tag_sys
 	vulnRepo := assetRepo.NewAssetVulnRepository(db)
 	unifiedRepo := assetRepo.NewAssetUnifiedRepository(db)
 	etlErrorRepo := assetRepo.NewETLErrorRepository(db)
-	assetMerger := etl.NewAssetMerger(hostRepo, webRepo, vulnRepo, unifiedRepo)
+	mergePolicy, err := etl.ParseMergePolicy(cfg.App.Master.ETL.MergePolicy)
+	if err != nil {
+		// 配置错误不阻断启动，回退到默认策略
+		logger.LogError(err, "", 0, "", "setup.BuildOrchestratorModule", "", map[string]interface{}{
+			"msg":          "Invalid etl merge policy, fallback to keep_latest",
+			"merge_policy": cfg.App.Master.ETL.MergePolicy,
+		})
+		mergePolicy = etl.MergeKeepLatest
+	}
+	mergerOpts := []etl.MergerOption{etl.WithMergePolicy(mergePolicy)}
+	if webhookDispatcher != nil {
+		mergerOpts = append(mergerOpts, etl.WithWebhookNotifier(webhookDispatcher))
+	}
+	assetMerger := etl.NewAssetMerger(hostRepo, webRepo, vulnRepo, unifiedRepo, mergerOpts...)
 
 	// 初始化 FingerprintService
 	httpEngine := http.NewHTTPEngine(assetRepo.NewAssetFingerRepository(db))
@@ -122,11 +153,20 @@ func BuildOrchestratorModule(db *gorm.DB, cfg *config.Config, tagService tag_sys
 
 	// 3. Service 初始化
 	projectService := orchestratorService.NewProjectService(projectRepo, tagService)
+	projectService.SetTaskRepository(taskRepo)
+	if webhookDispatcher != nil {
+		projectService.SetWebhookNotifier(webhookDispatcher)
+	}
 	workflowService := orchestratorService.NewWorkflowService(workflowRepo, tagService)
 	scanStageService := orchestratorService.NewScanStageService(scanStageRepo, tagService)
 	scanToolTemplateService := orchestratorService.NewScanToolTemplateService(scanToolTemplateRepo)
 	// agentTaskService := orchestratorService.NewAgentTaskService(agentRepository, taskRepo, dispatcher)
 	agentTaskService := task_dispatcher.NewAgentTaskService(agentRepository, taskRepo, dispatcher)
+	stageResultService := orchestratorService.NewStageResultService(stageResultRepo, scanStageRepo, taskRepo, projectRepo)
+	webhookService := orchestratorService.NewWebhookService(webhookRepo)
+	webhookService.SetAllowPrivateNetworks(cfg.App.Master.Webhook.AllowPrivateNetworks)
+	// Agent 结果批次入库后推入 ETL 队列合并到资产表
+	resultBatchService := orchestratorService.NewResultBatchService(resultBatchRepo, taskRepo, scanStageRepo, resultQueue)
 
 	// 4. Handler 初始化
 	projectHandler := orchestratorHandler.NewProjectHandler(projectService)
@@ -134,6 +174,9 @@ func BuildOrchestratorModule(db *gorm.DB, cfg *config.Config, tagService tag_sys
 	scanStageHandler := orchestratorHandler.NewScanStageHandler(scanStageService)
 	scanToolTemplateHandler := orchestratorHandler.NewScanToolTemplateHandler(scanToolTemplateService)
 	agentTaskHandler := orchestratorHandler.NewAgentTaskHandler(agentTaskService)
+	stageResultHandler := orchestratorHandler.NewStageResultHandler(stageResultService)
+	webhookHandler := orchestratorHandler.NewWebhookHandler(webhookService)
+	agentResultHandler := orchestratorHandler.NewAgentResultHandler(resultBatchService)
 
 	logger.WithFields(map[string]interface{}{
 		"path":      "setup.orchestrator",
@@ -147,18 +190,27 @@ func BuildOrchestratorModule(db *gorm.DB, cfg *config.Config, tagService tag_sys
 		ScanStageHandler:        scanStageHandler,
 		ScanToolTemplateHandler: scanToolTemplateHandler,
 		AgentTaskHandler:        agentTaskHandler,
+		StageResultHandler:      stageResultHandler,
+		WebhookHandler:          webhookHandler,
+		AgentResultHandler:      agentResultHandler,
 
 		ProjectService:          projectService,
 		WorkflowService:         workflowService,
 		ScanStageService:        scanStageService,
 		ScanToolTemplateService: scanToolTemplateService,
 		AgentTaskService:        agentTaskService,
+		StageResultService:      stageResultService,
+		WebhookService:          webhookService,
+		ResultBatchService:      resultBatchService,
 
 		// Core Components
-		TaskDispatcher:   dispatcher,
-		SchedulerService: schedulerService,
-		LocalAgent:       localAgent,
-		ResultIngestor:   resultIngestor,
-		ETLProcessor:     etlProcessor,
+		TaskDispatcher:    dispatcher,
+		AgentSelector:     agentSelector,
+		SchedulerService:  schedulerService,
+		LocalAgent:        localAgent,
+		ResultIngestor:    resultIngestor,
+		ETLProcessor:      etlProcessor,
+		AssetMerger:       assetMerger,
+		WebhookDispatcher: webhookDispatcher,
 	}
 }
@@ -21,6 +21,7 @@ import (
 	"neomaster/internal/service/asset/etl"        // 引入ETL
 	authService "neomaster/internal/service/auth"
 	"neomaster/internal/service/fingerprint" // 引入 fingerprint
+	"neomaster/internal/service/notify/webhook"
 	orchestratorService "neomaster/internal/service/orchestrator"
 	"neomaster/internal/service/orchestrator/core/scheduler"
 	"neomaster/internal/service/orchestrator/ingestor" // 引入ingestor
@@ -52,6 +53,8 @@ type AuthModule struct {
 	LogoutHandler   *authHandler.LogoutHandler
 	RefreshHandler  *authHandler.RefreshHandler
 	RegisterHandler *authHandler.RegisterHandler
+	TOTPHandler     *authHandler.TOTPHandler
+	APIKeyHandler   *authHandler.APIKeyHandler
 
 	// Services（对外暴露以供 router_manager 及其他模块使用）
 	SessionService  *authService.SessionService
@@ -59,6 +62,12 @@ type AuthModule struct {
 	PasswordService *authService.PasswordService
 	UserService     *authService.UserService
 	RBACService     *authService.RBACService
+	TOTPService     *authService.TOTPService
+	APIKeyService   *authService.APIKeyService
+	// AuditService 审计日志落库服务，未启用审计日志功能时为 nil
+	AuditService *authService.AuditService
+	// PermissionCache 用户权限集缓存，角色/权限管理服务变更数据后通过它使缓存失效
+	PermissionCache authService.PermissionCacheStore
 }
 
 // SystemRBACModule 是系统层面的 RBAC 管理模块聚合输出
@@ -99,6 +108,11 @@ type AgentModule struct {
 	ConfigService  agentService.AgentConfigService
 	UpdateService  agentService.AgentUpdateService
 
+	// EventHub Agent实时事件中心(看板 WebSocket 推送)
+	EventHub *agentService.AgentEventHub
+	// MetricsExporter Agent性能指标 Prometheus 导出器
+	MetricsExporter *agentService.AgentMetricsExporter
+
 	// Repository (供 Middleware 使用)
 	AgentRepository agentRepo.AgentRepository
 
@@ -121,6 +135,9 @@ type OrchestratorModule struct {
 	ScanStageHandler        *orchestratorHandler.ScanStageHandler
 	ScanToolTemplateHandler *orchestratorHandler.ScanToolTemplateHandler
 	AgentTaskHandler        *orchestratorHandler.AgentTaskHandler // 新增
+	StageResultHandler      *orchestratorHandler.StageResultHandler
+	WebhookHandler          *orchestratorHandler.WebhookHandler
+	AgentResultHandler      *orchestratorHandler.AgentResultHandler // Agent 结果批次上报
 
 	// Services（对外暴露以供 router_manager 或其他模块使用）
 	ProjectService          *orchestratorService.ProjectService
@@ -128,13 +145,19 @@ type OrchestratorModule struct {
 	ScanStageService        *orchestratorService.ScanStageService
 	ScanToolTemplateService *orchestratorService.ScanToolTemplateService
 	AgentTaskService        orchestratorService.AgentTaskService // 新增 (interface type)
+	StageResultService      *orchestratorService.StageResultService
+	WebhookService          *orchestratorService.WebhookService
+	ResultBatchService      *orchestratorService.ResultBatchService
 
 	// Core Components (核心组件)
-	TaskDispatcher   orchestratorService.TaskDispatcher
-	SchedulerService scheduler.SchedulerService
-	LocalAgent       *local_agent.LocalAgent // 本地Agent (原系统任务执行器)
-	ResultIngestor   ingestor.ResultIngestor // 结果摄入服务
-	ETLProcessor     etl.ResultProcessor     // ETL 结果处理器
+	TaskDispatcher    orchestratorService.TaskDispatcher
+	AgentSelector     orchestratorService.AgentSelector // 按负载选择Agent
+	SchedulerService  scheduler.SchedulerService
+	LocalAgent        *local_agent.LocalAgent // 本地Agent (原系统任务执行器)
+	ResultIngestor    ingestor.ResultIngestor // 结果摄入服务
+	ETLProcessor      etl.ResultProcessor     // ETL 结果处理器
+	AssetMerger       etl.AssetMerger         // ETL 资产合并器 (主机入库接口共用)
+	WebhookDispatcher *webhook.Dispatcher     // Webhook 事件分发器 (未启用时为 nil)
 }
 
 // AssetModule 是资产管理模块的聚合输出
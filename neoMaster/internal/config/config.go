@@ -37,13 +37,37 @@ type RuleDirConfig struct {
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Host           string        `yaml:"host" mapstructure:"host"`                         // 服务器主机地址
-	Port           int           `yaml:"port" mapstructure:"port"`                         // 服务器端口
-	Mode           string        `yaml:"mode" mapstructure:"mode"`                         // 运行模式: debug, release, test
-	ReadTimeout    time.Duration `yaml:"read_timeout" mapstructure:"read_timeout"`         // 读取超时时间
-	WriteTimeout   time.Duration `yaml:"write_timeout" mapstructure:"write_timeout"`       // 写入超时时间
-	IdleTimeout    time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`         // 空闲超时时间
-	MaxHeaderBytes int           `yaml:"max_header_bytes" mapstructure:"max_header_bytes"` // 最大请求头字节数
+	Host            string            `yaml:"host" mapstructure:"host"`                         // 服务器主机地址
+	Port            int               `yaml:"port" mapstructure:"port"`                         // 服务器端口
+	Mode            string            `yaml:"mode" mapstructure:"mode"`                         // 运行模式: debug, release, test
+	ReadTimeout     time.Duration     `yaml:"read_timeout" mapstructure:"read_timeout"`         // 读取超时时间
+	WriteTimeout    time.Duration     `yaml:"write_timeout" mapstructure:"write_timeout"`       // 写入超时时间
+	IdleTimeout     time.Duration     `yaml:"idle_timeout" mapstructure:"idle_timeout"`         // 空闲超时时间
+	MaxHeaderBytes  int               `yaml:"max_header_bytes" mapstructure:"max_header_bytes"` // 最大请求头字节数
+	ShutdownTimeout time.Duration     `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout"` // 优雅关闭等待进行中请求完成的超时时间
+	Compression     CompressionConfig `yaml:"compression" mapstructure:"compression"`           // 响应压缩配置
+	BodyLimit       BodyLimitConfig   `yaml:"body_limit" mapstructure:"body_limit"`             // 请求体大小限制
+}
+
+// BodyLimitConfig 请求体大小限制配置
+type BodyLimitConfig struct {
+	MaxBytes int64            `yaml:"max_bytes" mapstructure:"max_bytes"` // 默认请求体上限(字节)，<=0 使用 4MB
+	Routes   []RouteBodyLimit `yaml:"routes" mapstructure:"routes"`       // 按路由覆盖默认上限(如规则导入、批量入库)
+}
+
+// RouteBodyLimit 单个路由的请求体上限
+type RouteBodyLimit struct {
+	Path     string `yaml:"path" mapstructure:"path"`           // Gin 路由路径，如 /api/v1/asset/hosts/ingest (含 :id 等参数占位)
+	MaxBytes int64  `yaml:"max_bytes" mapstructure:"max_bytes"` // 该路由的请求体上限(字节)
+}
+
+// CompressionConfig 响应压缩(gzip)配置
+type CompressionConfig struct {
+	Enabled              bool     `yaml:"enabled" mapstructure:"enabled"`                               // 是否启用gzip压缩
+	Level                int      `yaml:"level" mapstructure:"level"`                                   // 压缩级别 1-9，0 使用默认级别
+	MinLength            int      `yaml:"min_length" mapstructure:"min_length"`                         // 最小压缩字节数，小于该值的响应不压缩
+	ExcludedPaths        []string `yaml:"excluded_paths" mapstructure:"excluded_paths"`                 // 不压缩的路径前缀
+	ExcludedContentTypes []string `yaml:"excluded_content_types" mapstructure:"excluded_content_types"` // 不压缩的内容类型(已压缩格式)
 }
 
 // DatabaseConfig 数据库配置
@@ -106,12 +130,23 @@ type SecurityConfig struct {
 	Logging   LoggingConfig   `yaml:"logging" mapstructure:"logging"`       // 日志中间件配置
 	CORS      CORSConfig      `yaml:"cors" mapstructure:"cors"`             // CORS配置
 	RateLimit RateLimitConfig `yaml:"rate_limit" mapstructure:"rate_limit"` // 限流配置
+	// Idempotency 创建类接口的 Idempotency-Key 幂等配置
+	Idempotency IdempotencyConfig `yaml:"idempotency" mapstructure:"idempotency"`
+	// Password 用户密码 Argon2id 哈希参数
+	Password PasswordConfig `yaml:"password" mapstructure:"password"`
+	// TOTP 两步验证配置
+	TOTP TOTPConfig `yaml:"totp" mapstructure:"totp"`
 }
 
 // AgentConfig Agent安全配置
 type AgentConfig struct {
-	TokenSecret       string `yaml:"token_secret" mapstructure:"token_secret"`               // 身份鉴权密钥
-	RuleEncryptionKey string `yaml:"rule_encryption_key" mapstructure:"rule_encryption_key"` // 规则加密密钥
+	TokenSecret       string        `yaml:"token_secret" mapstructure:"token_secret"`               // 身份鉴权密钥
+	RuleEncryptionKey string        `yaml:"rule_encryption_key" mapstructure:"rule_encryption_key"` // 规则加密密钥
+	TokenSigningKey   string        `yaml:"token_signing_key" mapstructure:"token_signing_key"`     // Agent Token 签名密钥
+	TokenTTL          time.Duration `yaml:"token_ttl" mapstructure:"token_ttl"`                     // Agent Token 有效期
+	// EnforceTokenExpiry 是否拒绝过期 Token；关闭时过期及未设置有效期的旧 Token 仍可使用(仅记录告警)，
+	// 待所有 Agent 升级为自动续期版本后再开启
+	EnforceTokenExpiry bool `yaml:"enforce_token_expiry" mapstructure:"enforce_token_expiry"`
 }
 
 // JWTConfig JWT配置
@@ -125,12 +160,13 @@ type JWTConfig struct {
 
 // AuthConfig 认证中间件配置
 type AuthConfig struct {
-	AuthMethod        string   `yaml:"auth_method" mapstructure:"auth_method"`                 // 认证方式
-	APIKey            string   `yaml:"api_key" mapstructure:"api_key"`                         // API密钥
-	APIKeyHeader      string   `yaml:"api_key_header" mapstructure:"api_key_header"`           // API密钥请求头
-	WhitelistIPs      []string `yaml:"whitelist_ips" mapstructure:"whitelist_ips"`             // IP白名单
-	EnableIPWhitelist bool     `yaml:"enable_ip_whitelist" mapstructure:"enable_ip_whitelist"` // 是否启用IP白名单
-	SkipPaths         []string `yaml:"skip_paths" mapstructure:"skip_paths"`                   // 跳过认证的路径
+	AuthMethod         string        `yaml:"auth_method" mapstructure:"auth_method"`                   // 认证方式
+	APIKey             string        `yaml:"api_key" mapstructure:"api_key"`                           // API密钥
+	APIKeyHeader       string        `yaml:"api_key_header" mapstructure:"api_key_header"`             // API密钥请求头
+	WhitelistIPs       []string      `yaml:"whitelist_ips" mapstructure:"whitelist_ips"`               // IP白名单
+	EnableIPWhitelist  bool          `yaml:"enable_ip_whitelist" mapstructure:"enable_ip_whitelist"`   // 是否启用IP白名单
+	SkipPaths          []string      `yaml:"skip_paths" mapstructure:"skip_paths"`                     // 跳过认证的路径
+	PermissionCacheTTL time.Duration `yaml:"permission_cache_ttl" mapstructure:"permission_cache_ttl"` // 用户权限集缓存有效期(0 使用默认值 5m，负值关闭缓存)
 }
 
 // LoggingConfig 日志中间件配置
@@ -168,6 +204,37 @@ type RateLimitConfig struct {
 	Message           string   `yaml:"message" mapstructure:"message"`                         // 限流时返回的消息
 	SkipPaths         []string `yaml:"skip_paths" mapstructure:"skip_paths"`                   // 跳过限流的路径
 	SkipIPs           []string `yaml:"skip_ips" mapstructure:"skip_ips"`                       // 跳过限流的IP
+	// Routes 按路由分组覆盖的限流规则(键为分组名，如 login、api)，未配置的分组使用代码中的默认规则
+	Routes map[string]RouteRateLimitConfig `yaml:"routes" mapstructure:"routes"`
+}
+
+// RouteRateLimitConfig 路由分组限流规则(按用户/IP + 接口计数)
+type RouteRateLimitConfig struct {
+	Limit  int    `yaml:"limit" mapstructure:"limit"`   // 窗口内允许的请求数
+	Window string `yaml:"window" mapstructure:"window"` // 窗口大小，如 "1m"
+}
+
+// IdempotencyConfig Idempotency-Key 幂等配置
+type IdempotencyConfig struct {
+	Enabled     bool          `yaml:"enabled" mapstructure:"enabled"`           // 是否启用
+	TTL         time.Duration `yaml:"ttl" mapstructure:"ttl"`                   // 首次响应的保留时间，期间同一个键重放该响应(默认 24h)
+	LockTimeout time.Duration `yaml:"lock_timeout" mapstructure:"lock_timeout"` // 首个请求处理中时，重复请求的最长等待时间(默认 30s)
+}
+
+// PasswordConfig 用户密码 Argon2id 哈希参数，未配置(0)的项使用默认值
+// 调高参数后旧哈希仍可验证，用户下次登录时按新参数重新哈希
+type PasswordConfig struct {
+	Memory      uint32 `yaml:"memory" mapstructure:"memory"`           // 内存使用量 KB(默认 65536)
+	Iterations  uint32 `yaml:"iterations" mapstructure:"iterations"`   // 迭代次数(默认 3)
+	Parallelism uint8  `yaml:"parallelism" mapstructure:"parallelism"` // 并行度(默认 2)
+	SaltLength  uint32 `yaml:"salt_length" mapstructure:"salt_length"` // 盐长度字节(默认 32)
+	KeyLength   uint32 `yaml:"key_length" mapstructure:"key_length"`   // 哈希长度字节(默认 32)
+}
+
+// TOTPConfig 两步验证(TOTP)配置
+type TOTPConfig struct {
+	Issuer        string `yaml:"issuer" mapstructure:"issuer"`                 // 认证器中显示的签发方名称(默认 NeoScan)
+	EncryptionKey string `yaml:"encryption_key" mapstructure:"encryption_key"` // TOTP 密钥落库加密密钥，为空时不能绑定两步验证
 }
 
 // SessionConfig 会话配置
@@ -208,6 +275,9 @@ type MailConfig struct {
 	Password  string `yaml:"password" mapstructure:"password"`     // SMTP密码
 	FromEmail string `yaml:"from_email" mapstructure:"from_email"` // 发件人邮箱
 	FromName  string `yaml:"from_name" mapstructure:"from_name"`   // 发件人名称
+
+	Encryption string `yaml:"encryption" mapstructure:"encryption"` // 连接加密方式: auto(默认，465端口用SSL，其余服务器支持时STARTTLS)/ssl/starttls/none
+	Timeout    int    `yaml:"timeout" mapstructure:"timeout"`       // 连接与发送超时(秒)，默认10
 }
 
 // MonitorConfig 监控配置
@@ -265,10 +335,12 @@ func (c *Config) GetFingerprintRulePath() string {
 // MasterConfig Master节点配置
 type MasterConfig struct {
 	Task       TaskConfig       `yaml:"task" mapstructure:"task"`               // 任务配置
+	Dispatch   DispatchConfig   `yaml:"dispatch" mapstructure:"dispatch"`       // 任务分发(Agent选择)配置
 	Queue      QueueConfig      `yaml:"queue" mapstructure:"queue"`             // 队列配置
 	ETL        ETLConfig        `yaml:"etl" mapstructure:"etl"`                 // ETL配置
 	Archive    ArchiveConfig    `yaml:"archive" mapstructure:"archive"`         // 归档配置
 	WebCrawler WebCrawlerConfig `yaml:"web_crawler" mapstructure:"web_crawler"` // 爬虫配置
+	Webhook    WebhookConfig    `yaml:"webhook" mapstructure:"webhook"`         // Webhook 通知配置
 }
 
 // QueueConfig 队列配置
@@ -278,7 +350,27 @@ type QueueConfig struct {
 
 // ETLConfig ETL配置
 type ETLConfig struct {
-	WorkerNum int `yaml:"worker_num" mapstructure:"worker_num"` // ETL处理协程数
+	WorkerNum   int    `yaml:"worker_num" mapstructure:"worker_num"`     // ETL处理协程数
+	MergePolicy string `yaml:"merge_policy" mapstructure:"merge_policy"` // 重复发现的冲突字段合并策略(keep_latest/keep_first)，默认 keep_latest
+}
+
+// WebhookConfig Webhook 通知投递配置 (订阅本身存储在 webhook_subscriptions 表)
+type WebhookConfig struct {
+	Enabled          bool `yaml:"enabled" mapstructure:"enabled"`                       // 是否启用 Webhook 投递
+	Timeout          int  `yaml:"timeout" mapstructure:"timeout"`                       // 单次请求超时(秒)
+	MaxAttempts      int  `yaml:"max_attempts" mapstructure:"max_attempts"`             // 最大尝试次数(含首次)
+	RetryInterval    int  `yaml:"retry_interval" mapstructure:"retry_interval"`         // 重试间隔(秒)，指数退避的基数
+	RetryMaxInterval int  `yaml:"retry_max_interval" mapstructure:"retry_max_interval"` // 重试最大间隔(秒)，指数退避的上限
+	QueueSize        int  `yaml:"queue_size" mapstructure:"queue_size"`                 // 待投递事件队列容量，队列满时丢弃新事件
+	WorkerNum        int  `yaml:"worker_num" mapstructure:"worker_num"`                 // 投递协程数
+
+	// AllowPrivateNetworks 是否允许订阅地址指向内网/回环地址，默认 false (防止 SSRF 探测内网与云元数据服务)
+	AllowPrivateNetworks bool `yaml:"allow_private_networks" mapstructure:"allow_private_networks"`
+
+	// 以下仅作用于 slack/dingtalk 等聊天平台订阅
+	DigestInterval int    `yaml:"digest_interval" mapstructure:"digest_interval"`   // 同一订阅两条消息的最小间隔(秒)，间隔内的事件合并为一条摘要，<=0 不限流
+	DigestMaxItems int    `yaml:"digest_max_items" mapstructure:"digest_max_items"` // 单条消息最多列出的事件数，其余汇总为数量并附 UI 链接
+	UIBaseURL      string `yaml:"ui_base_url" mapstructure:"ui_base_url"`           // Web 控制台地址，用于在消息中生成跳转链接，为空时不附链接
 }
 
 // ArchiveConfig 归档配置
@@ -294,11 +386,24 @@ type WebCrawlerConfig struct {
 
 // TaskConfig 任务配置
 type TaskConfig struct {
-	ChunkSize      int `yaml:"chunk_size" mapstructure:"chunk_size"`           // 每个任务分块大小
-	Timeout        int `yaml:"timeout" mapstructure:"timeout"`                 // 任务超时时间(秒)
-	MaxRetries     int `yaml:"max_retries" mapstructure:"max_retries"`         // 任务最大重试次数
-	RetryInterval  int `yaml:"retry_interval" mapstructure:"retry_interval"`   // 任务重试间隔(秒)
-	MaxConcurrency int `yaml:"max_concurrency" mapstructure:"max_concurrency"` // 单个Agent最大并发任务数
+	ChunkSize         int `yaml:"chunk_size" mapstructure:"chunk_size"`                   // 每个任务分块大小
+	Timeout           int `yaml:"timeout" mapstructure:"timeout"`                         // 任务超时时间(秒)
+	MaxRetries        int `yaml:"max_retries" mapstructure:"max_retries"`                 // 任务最大重试次数
+	RetryInterval     int `yaml:"retry_interval" mapstructure:"retry_interval"`           // 任务重试间隔(秒)，指数退避的基数
+	RetryMaxInterval  int `yaml:"retry_max_interval" mapstructure:"retry_max_interval"`   // 任务重试最大间隔(秒)，指数退避的上限
+	MaxConcurrency    int `yaml:"max_concurrency" mapstructure:"max_concurrency"`         // 单个Agent最大并发任务数
+	GlobalMaxRunning  int `yaml:"global_max_running" mapstructure:"global_max_running"`   // 全局最大运行中任务数(所有项目合计)，<=0 不限制
+	ProjectMaxRunning int `yaml:"project_max_running" mapstructure:"project_max_running"` // 单个项目最大运行中任务数，<=0 不限制
+}
+
+// DispatchConfig 任务分发配置
+// 选择Agent时按 CPU使用率、运行任务数、内存使用率 加权打分，分数越低负载越轻
+type DispatchConfig struct {
+	CPUWeight     float64 `yaml:"cpu_weight" mapstructure:"cpu_weight"`           // CPU使用率权重
+	TaskWeight    float64 `yaml:"task_weight" mapstructure:"task_weight"`         // 运行任务数权重(按单Agent最大并发数归一化)
+	MemoryWeight  float64 `yaml:"memory_weight" mapstructure:"memory_weight"`     // 内存使用率权重
+	MetricsMaxAge int     `yaml:"metrics_max_age" mapstructure:"metrics_max_age"` // 性能快照有效期(秒)，超过视为负载未知
+	PreferWindow  int     `yaml:"prefer_window" mapstructure:"prefer_window"`     // 新任务等待负载最低Agent领取的时长(秒)，超过后任何可执行的Agent都可领取，默认30
 }
 
 // FeaturesConfig 功能开关配置
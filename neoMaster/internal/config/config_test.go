@@ -431,6 +431,45 @@ func TestConfigValidation(t *testing.T) {
 			expectError: true,
 			errorMsg:    "jwt secret must be at least 32 characters long",
 		},
+		{
+			name: "cors wildcard origin with credentials",
+			buildConfig: func() *Config {
+				cfg := baseValidConfig()
+				cfg.Security.CORS = CORSConfig{Enabled: true, AllowAllOrigins: true, AllowCredentials: true}
+				return cfg
+			},
+			expectError: true,
+			errorMsg:    "cors allow_credentials cannot be used with a wildcard origin",
+		},
+		{
+			name: "cors wildcard entry with credentials",
+			buildConfig: func() *Config {
+				cfg := baseValidConfig()
+				cfg.Security.CORS = CORSConfig{Enabled: true, AllowOrigins: []string{"https://console.example.com", "*"}, AllowCredentials: true}
+				return cfg
+			},
+			expectError: true,
+			errorMsg:    "cors allow_credentials cannot be used with a wildcard origin",
+		},
+		{
+			name: "cors invalid origin",
+			buildConfig: func() *Config {
+				cfg := baseValidConfig()
+				cfg.Security.CORS = CORSConfig{Enabled: true, AllowOrigins: []string{"console.example.com/app"}}
+				return cfg
+			},
+			expectError: true,
+			errorMsg:    "invalid cors allow_origins entry",
+		},
+		{
+			name: "cors explicit origins with credentials",
+			buildConfig: func() *Config {
+				cfg := baseValidConfig()
+				cfg.Security.CORS = CORSConfig{Enabled: true, AllowOrigins: []string{"https://console.example.com"}, AllowCredentials: true}
+				return cfg
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -141,6 +142,7 @@ func bindEnvironmentVariables(v *viper.Viper) {
 	// 安全配置
 	v.BindEnv("security.cors.allow_origins", "NEOSCAN_CORS_ALLOW_ORIGINS")
 	v.BindEnv("security.csrf.secret", "NEOSCAN_CSRF_SECRET")
+	v.BindEnv("security.totp.encryption_key", "NEOSCAN_TOTP_ENCRYPTION_KEY")
 
 	// 邮件配置
 	v.BindEnv("mail.smtp_host", "NEOSCAN_MAIL_SMTP_HOST")
@@ -195,6 +197,11 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("jwt secret must be at least 32 characters long")
 	}
 
+	// 验证CORS配置
+	if err := validateCORSConfig(&config.Security.CORS); err != nil {
+		return err
+	}
+
 	// 验证日志配置
 	validLogLevels := []string{"debug", "info", "warn", "error", "fatal", "panic"}
 	if !contains(validLogLevels, config.Log.Level) {
@@ -240,6 +247,32 @@ func validateConfig(config *Config) error {
 	return nil
 }
 
+// validateCORSConfig 验证CORS配置
+// 通配源与凭证不能同时开启: 浏览器会拒绝 "*" + credentials，而反射任意源又等于允许任意站点携带用户凭证访问接口
+func validateCORSConfig(cors *CORSConfig) error {
+	if !cors.Enabled {
+		return nil
+	}
+	wildcard := cors.AllowAllOrigins
+	for _, origin := range cors.AllowOrigins {
+		if origin == "*" {
+			wildcard = true
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return fmt.Errorf("invalid cors allow_origins entry: %q (expected scheme://host[:port])", origin)
+		}
+	}
+	if wildcard && cors.AllowCredentials {
+		return fmt.Errorf("cors allow_credentials cannot be used with a wildcard origin, list the allowed origins explicitly")
+	}
+	if cors.MaxAge < 0 {
+		return fmt.Errorf("invalid cors max_age: %s", cors.MaxAge)
+	}
+	return nil
+}
+
 func applyDefaultRulesConfig(config *Config) {
 	if config == nil {
 		return
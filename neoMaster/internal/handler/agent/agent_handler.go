@@ -11,12 +11,18 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"strings"
 
+	"github.com/gin-gonic/gin"
+
+	"neomaster/internal/config"
 	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/utils"
 	agentService "neomaster/internal/service/agent"
 )
 
@@ -27,6 +33,10 @@ type AgentHandler struct {
 	agentMonitorService agentService.AgentMonitorService // Agent监控服务
 	agentConfigService  agentService.AgentConfigService  // Agent配置服务
 	agentUpdateService  agentService.AgentUpdateService  // Agent规则更新服务(Agent自己pull)
+
+	// WebSocket 来源检查 (websocket.check_origin)，允许的来源复用 CORS 配置
+	wsCheckOrigin  bool
+	wsAllowOrigins []string
 }
 
 // NewAgentHandler 创建Agent处理器实例
@@ -50,6 +60,16 @@ func NewAgentHandler(
 	}
 }
 
+// SetWebSocketOriginPolicy 设置 WebSocket 来源检查策略
+// checkOrigin 开启时只接受与服务同源或在 CORS 允许列表中的来源，allow_all_origins 视为允许任意来源
+func (h *AgentHandler) SetWebSocketOriginPolicy(checkOrigin bool, cors config.CORSConfig) {
+	h.wsCheckOrigin = checkOrigin
+	h.wsAllowOrigins = append([]string{}, cors.AllowOrigins...)
+	if cors.AllowAllOrigins {
+		h.wsAllowOrigins = append(h.wsAllowOrigins, "*")
+	}
+}
+
 // validateRegisterRequest 验证Agent注册请求参数
 // 说明: 保持原有业务校验逻辑不变，提供通用的入参校验能力。
 func (h *AgentHandler) validateRegisterRequest(req *agentModel.RegisterAgentRequest) error {
@@ -162,9 +182,25 @@ func (h *AgentHandler) validateHeartbeatRequest(req *agentModel.HeartbeatRequest
 			return fmt.Errorf("invalid failed tasks")
 		}
 	}
+
+	// 验证运行中任务进度（如果提供）
+	if len(req.RunningTasks) > maxHeartbeatRunningTasks {
+		return fmt.Errorf("too many running tasks (max %d)", maxHeartbeatRunningTasks)
+	}
+	for _, task := range req.RunningTasks {
+		if task.TaskID == "" {
+			return fmt.Errorf("running task ID is required")
+		}
+		if task.Percent < 0 || task.Percent > 100 {
+			return fmt.Errorf("invalid progress percent for task %s", task.TaskID)
+		}
+	}
 	return nil
 }
 
+// maxHeartbeatRunningTasks 单次心跳可携带的运行中任务进度条数上限，防止 running_tasks 字段过大
+const maxHeartbeatRunningTasks = 200
+
 // getErrorStatusCode 根据错误类型返回HTTP状态码
 // 说明: 统一的错误到HTTP状态码映射策略，供各分类文件内复用，避免重复实现。
 func (h *AgentHandler) getErrorStatusCode(err error) int {
@@ -173,6 +209,11 @@ func (h *AgentHandler) getErrorStatusCode(err error) int {
 		return http.StatusOK
 	}
 
+	// 乐观锁冲突：Agent 记录在读取后已被其他请求修改
+	if errors.Is(err, system.ErrConcurrentModification) {
+		return http.StatusConflict
+	}
+
 	// 可以根据具体的错误类型进行更精确的状态码映射
 	errMsg := err.Error()
 	// 统一处理“未找到”类错误
@@ -189,3 +230,25 @@ func (h *AgentHandler) getErrorStatusCode(err error) int {
 	// 默认返回内部服务器错误
 	return http.StatusInternalServerError
 }
+
+// 列表接口 fields 参数白名单：取响应结构的 JSON 字段名，响应结构新增字段后自动可选
+var (
+	agentListFields    = utils.NewFieldAllowlist(utils.JSONFieldNames(agentModel.AgentInfo{})...)
+	agentMetricsFields = utils.NewFieldAllowlist(utils.JSONFieldNames(agentModel.AgentMetricsResponse{})...)
+)
+
+// parseFieldsQuery 解析列表接口的 fields 参数 (逗号分隔，只返回指定字段)
+// 包含白名单外的字段时直接响应 400 并返回 false
+func (h *AgentHandler) parseFieldsQuery(c *gin.Context, allowlist utils.FieldAllowlist) ([]string, bool) {
+	fields, err := allowlist.ParseFields(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid fields parameter",
+			Error:   fmt.Sprintf("%v (allowed: %s)", err, strings.Join(allowlist.Names(), ",")),
+		})
+		return nil, false
+	}
+	return fields, true
+}
@@ -1,93 +1,361 @@
 /**
- * Agent配置管理控制器（占位）
+ * Agent配置管理控制器
  * 作者: Sun977
  * 日期: 2025-11-07
- * 说明: 与Agent配置管理相关的 Handler 方法占位，未来将承载配置查询与更新逻辑。
- * - GetAgentConfig
- * - UpdateAgentConfig
+ * 说明: 与Agent配置管理相关的 Handler 方法，配置每次修改版本号 +1，Agent 通过心跳对账或主动拉取获取最新配置。
+ * - GetAgentConfig: 管理端查询Agent配置
+ * - UpdateAgentConfig: 管理端更新Agent配置
+ * - GetAgentEffectiveConfig: 管理端导出Agent生效配置及配置项来源
+ * - PullAgentConfig: Agent端拉取自身最新配置
+ * - GetAgentGroupConfig: 管理端查询分组配置补丁
+ * - UpdateAgentGroupConfig: 管理端将配置补丁批量应用到分组全部成员
  */
 package agent
 
 import (
-    "net/http"
+	"fmt"
+	"net/http"
 
-    "github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin"
 
-    "neomaster/internal/model/system"
-    "neomaster/internal/pkg/logger"
-    "neomaster/internal/pkg/utils"
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
 )
 
-// GetAgentConfig 获取Agent配置（占位实现）
+// GetAgentConfig 获取Agent配置
+// 从未下发过配置时返回默认配置 (version 为 0)
 func (h *AgentHandler) GetAgentConfig(c *gin.Context) {
-    clientIP := utils.GetClientIP(c)
-    userAgent := c.GetHeader("User-Agent")
-    XRequestID := c.GetHeader("X-Request-ID")
-    pathUrl := c.Request.URL.String()
-    agentID := c.Param("id")
-
-    logger.LogBusinessOperation(
-        "get_agent_config",
-        0,
-        "",
-        clientIP,
-        XRequestID,
-        "success",
-        "获取Agent配置占位返回",
-        map[string]interface{}{
-            "func_name":  "handler.agent.GetAgentConfig",
-            "option":     "placeholder",
-            "path":       pathUrl,
-            "method":     "GET",
-            "user_agent": userAgent,
-            "agent_id":   agentID,
-        },
-    )
-
-    c.JSON(http.StatusOK, system.APIResponse{
-        Code:    http.StatusOK,
-        Status:  "success",
-        Message: "Get agent config - placeholder",
-        Data: map[string]interface{}{
-            "agent_id": agentID,
-            "config":   map[string]interface{}{},
-        },
-    })
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	agentID := c.Param("id")
+	if agentID == "" {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Agent ID is required",
+			Error:   "missing agent ID parameter",
+		})
+		return
+	}
+
+	cfg, err := h.agentConfigService.GetAgentConfig(agentID)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation":   "get_agent_config",
+			"option":      "agentConfigService.GetAgentConfig",
+			"func_name":   "handler.agent.GetAgentConfig",
+			"user_agent":  userAgent,
+			"agent_id":    agentID,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to get agent config",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent config retrieved successfully",
+		Data:    cfg,
+	})
 }
 
-// UpdateAgentConfig 更新Agent配置（占位实现）
+// UpdateAgentConfig 更新Agent配置
+// 配置整体校验通过后保存并递增版本号，Agent 下次心跳时获取新配置
 func (h *AgentHandler) UpdateAgentConfig(c *gin.Context) {
-    clientIP := utils.GetClientIP(c)
-    userAgent := c.GetHeader("User-Agent")
-    XRequestID := c.GetHeader("X-Request-ID")
-    pathUrl := c.Request.URL.String()
-    agentID := c.Param("id")
-
-    logger.LogBusinessOperation(
-        "update_agent_config",
-        0,
-        "",
-        clientIP,
-        XRequestID,
-        "success",
-        "更新Agent配置占位返回",
-        map[string]interface{}{
-            "func_name":  "handler.agent.UpdateAgentConfig",
-            "option":     "placeholder",
-            "path":       pathUrl,
-            "method":     "PUT",
-            "user_agent": userAgent,
-            "agent_id":   agentID,
-        },
-    )
-
-    c.JSON(http.StatusOK, system.APIResponse{
-        Code:    http.StatusOK,
-        Status:  "success",
-        Message: "Update agent config - placeholder",
-        Data: map[string]interface{}{
-            "agent_id": agentID,
-            "updated":  true,
-        },
-    })
-}
\ No newline at end of file
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	agentID := c.Param("id")
+	if agentID == "" {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Agent ID is required",
+			Error:   "missing agent ID parameter",
+		})
+		return
+	}
+
+	var req agentModel.AgentConfigUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "PUT", map[string]interface{}{
+			"operation":  "update_agent_config",
+			"option":     "ShouldBindJSON",
+			"func_name":  "handler.agent.UpdateAgentConfig",
+			"user_agent": userAgent,
+			"agent_id":   agentID,
+		})
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	cfg, err := h.agentConfigService.UpdateAgentConfig(agentID, &req)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "PUT", map[string]interface{}{
+			"operation":   "update_agent_config",
+			"option":      "agentConfigService.UpdateAgentConfig",
+			"func_name":   "handler.agent.UpdateAgentConfig",
+			"user_agent":  userAgent,
+			"agent_id":    agentID,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to update agent config",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.LogBusinessOperation(
+		"update_agent_config",
+		0,
+		"",
+		clientIP,
+		XRequestID,
+		"success",
+		"更新Agent配置成功",
+		map[string]interface{}{
+			"func_name":  "handler.agent.UpdateAgentConfig",
+			"option":     "success",
+			"path":       pathUrl,
+			"method":     "PUT",
+			"user_agent": userAgent,
+			"agent_id":   agentID,
+			"version":    cfg.Version,
+		},
+	)
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent config updated successfully",
+		Data:    cfg,
+	})
+}
+
+// GetAgentEffectiveConfig 导出Agent生效配置
+// 返回Agent拉取时获得的配置，并标注每个配置项来自默认值、分组配置还是Agent单独配置
+func (h *AgentHandler) GetAgentEffectiveConfig(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	agentID := c.Param("id")
+	if agentID == "" {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Agent ID is required",
+			Error:   "missing agent ID parameter",
+		})
+		return
+	}
+
+	effective, err := h.agentConfigService.GetEffectiveConfig(agentID)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation":   "get_agent_effective_config",
+			"option":      "agentConfigService.GetEffectiveConfig",
+			"func_name":   "handler.agent.GetAgentEffectiveConfig",
+			"user_agent":  userAgent,
+			"agent_id":    agentID,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to get agent effective config",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent effective config retrieved successfully",
+		Data:    effective,
+	})
+}
+
+// PullAgentConfig Agent拉取自身最新配置
+// 查询参数 version 为Agent当前已应用的配置版本；版本一致时 data 为空，不一致时返回最新配置
+func (h *AgentHandler) PullAgentConfig(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+	agentID := c.GetString("agent_id") // 由 Agent 认证中间件注入
+
+	version := 0
+	if v := c.Query("version"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &version); err != nil || version < 0 {
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "failed",
+				Message: "Invalid version parameter",
+				Error:   "version must be a non-negative integer",
+			})
+			return
+		}
+	}
+
+	cfg, err := h.agentConfigService.ReconcileConfig(agentID, version)
+	if err != nil {
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation": "pull_agent_config",
+			"option":    "agentConfigService.ReconcileConfig",
+			"func_name": "handler.agent.PullAgentConfig",
+			"agent_id":  agentID,
+			"version":   version,
+		})
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to pull agent config",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent config reconciled",
+		Data:    cfg,
+	})
+}
+
+// GetAgentGroupConfig 获取分组配置补丁
+// 分组从未下发过配置时 data 为空
+func (h *AgentHandler) GetAgentGroupConfig(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	groupID := c.Param("group_id")
+	groupCfg, err := h.agentConfigService.GetGroupConfig(groupID)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation":   "get_agent_group_config",
+			"option":      "agentConfigService.GetGroupConfig",
+			"func_name":   "handler.agent.GetAgentGroupConfig",
+			"user_agent":  userAgent,
+			"group_id":    groupID,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to get agent group config",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent group config retrieved successfully",
+		Data:    groupCfg,
+	})
+}
+
+// UpdateAgentGroupConfig 批量更新分组配置
+// 请求体为局部补丁，只应用其中设置的字段；成员Agent配置版本号各自 +1，下次心跳对账时生效
+func (h *AgentHandler) UpdateAgentGroupConfig(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	groupID := c.Param("group_id")
+	var patch agentModel.AgentConfigPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "PATCH", map[string]interface{}{
+			"operation":  "update_agent_group_config",
+			"option":     "ShouldBindJSON",
+			"func_name":  "handler.agent.UpdateAgentGroupConfig",
+			"user_agent": userAgent,
+			"group_id":   groupID,
+		})
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	updated, err := h.agentConfigService.UpdateGroupConfig(groupID, patch)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "PATCH", map[string]interface{}{
+			"operation":   "update_agent_group_config",
+			"option":      "agentConfigService.UpdateGroupConfig",
+			"func_name":   "handler.agent.UpdateAgentGroupConfig",
+			"user_agent":  userAgent,
+			"group_id":    groupID,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to update agent group config",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.LogBusinessOperation(
+		"update_agent_group_config",
+		0,
+		"",
+		clientIP,
+		XRequestID,
+		"success",
+		"批量更新分组配置成功",
+		map[string]interface{}{
+			"func_name":  "handler.agent.UpdateAgentGroupConfig",
+			"option":     "success",
+			"path":       pathUrl,
+			"method":     "PATCH",
+			"user_agent": userAgent,
+			"group_id":   groupID,
+			"updated":    updated,
+		},
+	)
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent group config updated successfully",
+		Data:    gin.H{"group_id": groupID, "updated": updated},
+	})
+}
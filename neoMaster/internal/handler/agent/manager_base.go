@@ -13,6 +13,7 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -24,6 +25,7 @@ import (
 	"neomaster/internal/model/system"
 	"neomaster/internal/pkg/logger"
 	"neomaster/internal/pkg/utils"
+	agentService "neomaster/internal/service/agent"
 )
 
 // RegisterAgent Agent注册处理器
@@ -177,6 +179,177 @@ func (h *AgentHandler) RegisterAgent(c *gin.Context) {
 	})
 }
 
+// EnrollAgent Agent接入处理器
+// 说明: 公开接口，Agent 出示接入密钥换取签名 Token；已接入的 Agent 再次调用会轮换 Token。
+func (h *AgentHandler) EnrollAgent(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	var req agentModel.EnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":  "enroll_agent",
+			"option":     "ShouldBindJSON",
+			"func_name":  "handler.agent.EnrollAgent",
+			"user_agent": userAgent,
+		})
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid JSON format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.agentManagerService.EnrollAgent(c.Request.Context(), req)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		switch {
+		case errors.Is(err, agentService.ErrInvalidEnrollmentSecret):
+			statusCode = http.StatusUnauthorized
+		case errors.Is(err, agentService.ErrEnrollmentNotConfigured):
+			statusCode = http.StatusServiceUnavailable
+		}
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":   "enroll_agent",
+			"option":      "agentService.EnrollAgent",
+			"func_name":   "handler.agent.EnrollAgent",
+			"user_agent":  userAgent,
+			"hostname":    req.Hostname,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Agent enrollment failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.LogBusinessOperation("enroll_agent", 0, "", clientIP, XRequestID, "success", "Agent接入成功", map[string]interface{}{
+		"func_name":  "handler.agent.EnrollAgent",
+		"option":     "success",
+		"path":       pathUrl,
+		"method":     "POST",
+		"user_agent": userAgent,
+		"agent_id":   response.AgentID,
+		"hostname":   req.Hostname,
+		"reenrolled": response.Reenrolled,
+	})
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent enrolled successfully",
+		Data:    response,
+	})
+}
+
+// CreateEnrollmentToken 签发Agent接入令牌处理器
+// 说明: 需 system:admin 权限，令牌明文只在响应中返回一次，过期或使用一次后失效。
+func (h *AgentHandler) CreateEnrollmentToken(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+	userID := utils.GetCurrentUserIDFromGinContext(c)
+
+	var req agentModel.CreateEnrollmentTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogBusinessError(err, XRequestID, userID, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":  "create_enrollment_token",
+			"option":     "ShouldBindJSON",
+			"func_name":  "handler.agent.CreateEnrollmentToken",
+			"user_agent": userAgent,
+		})
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid JSON format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.agentManagerService.CreateEnrollmentToken(c.Request.Context(), req, userID)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, userID, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":   "create_enrollment_token",
+			"option":      "agentService.CreateEnrollmentToken",
+			"func_name":   "handler.agent.CreateEnrollmentToken",
+			"user_agent":  userAgent,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to create enrollment token",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, system.APIResponse{
+		Code:    http.StatusCreated,
+		Status:  "success",
+		Message: "Enrollment token created, it is shown only once",
+		Data:    response,
+	})
+}
+
+// RefreshAgentToken Agent Token刷新处理器
+// 说明: 需Agent认证，Agent 在 Token 过期前调用以换取新 Token；Token 已过期时需重新接入。
+func (h *AgentHandler) RefreshAgentToken(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+	agentID := c.GetString("agent_id")
+
+	token, expiry, err := h.agentManagerService.RefreshAgentToken(agentID)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		errCode := err.Error()
+		switch {
+		case errors.Is(err, agentService.ErrAgentTokenExpired):
+			statusCode, errCode = http.StatusUnauthorized, "AGENT_TOKEN_EXPIRED"
+		case errors.Is(err, agentService.ErrAgentNotOnline):
+			statusCode = http.StatusConflict
+		case errors.Is(err, agentService.ErrEnrollmentNotConfigured):
+			statusCode = http.StatusServiceUnavailable
+		}
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":   "refresh_agent_token",
+			"option":      "agentService.RefreshAgentToken",
+			"func_name":   "handler.agent.RefreshAgentToken",
+			"agent_id":    agentID,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Agent token refresh failed",
+			Error:   errCode,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent token refreshed successfully",
+		Data: agentModel.EnrollResponse{
+			AgentID:     agentID,
+			Token:       token,
+			TokenExpiry: expiry,
+		},
+	})
+}
+
 // GetAgentInfo 根据ID获取Agent信息
 // 说明: 校验路径参数，调用服务层获取信息，统一错误处理与日志记录。
 func (h *AgentHandler) GetAgentInfo(c *gin.Context) {
@@ -295,40 +468,22 @@ func (h *AgentHandler) GetAgentList(c *gin.Context) {
 	// 过滤参数 status: offline / online
 	req.Status = agentModel.AgentStatus(c.Query("status"))
 
-	// 关键字过滤参数 - 支持对agent_id、hostname、ip_address的模糊查询
+	// 关键字过滤参数 - 支持对agent_id、hostname、ip_address、remark、os、arch、version的模糊查询，结果按相关度排序
 	req.Keyword = c.Query("keyword")
 
-	// 标签过滤参数处理 - 支持逗号分隔的标签值
-	// 例如: tags=2,7 或 tags=2&tags=7 两种格式都支持
-	tagsArray := c.QueryArray("tags")
-	if len(tagsArray) > 1 {
-		// 处理多个tags参数: tags=2&tags=7
-		req.Tags = tagsArray
-	} else if len(tagsArray) == 1 && strings.Contains(tagsArray[0], ",") {
-		// 处理逗号分隔的标签值: tags=2,7
-		req.Tags = strings.Split(tagsArray[0], ",")
-		// 去除空白字符
-		for i, tag := range req.Tags {
-			req.Tags[i] = strings.TrimSpace(tag)
-		}
-	} else if len(tagsArray) == 1 {
-		// 单个标签: tags=2
-		req.Tags = tagsArray
-	}
+	// 标签与任务支持过滤参数 - 支持 tags=2,7、tags=2&tags=7 及混合写法
+	query := c.Request.URL.Query()
+	req.Tags = utils.ParseQueryStringSlice(query, "tags")
+	req.TaskSupport = utils.ParseQueryStringSlice(query, "task_support")
 
-	// 任务支持过滤参数处理 - 支持逗号分隔的值
-	taskSupportArray := c.QueryArray("task_support")
-	if len(taskSupportArray) > 0 {
-		if len(taskSupportArray) > 1 {
-			req.TaskSupport = taskSupportArray
-		} else if len(taskSupportArray) == 1 && strings.Contains(taskSupportArray[0], ",") {
-			req.TaskSupport = strings.Split(taskSupportArray[0], ",")
-			for i, v := range req.TaskSupport {
-				req.TaskSupport[i] = strings.TrimSpace(v)
-			}
-		} else {
-			req.TaskSupport = taskSupportArray
-		}
+	// 排序参数 - sort_by 不在白名单内时使用默认排序(updated_at DESC)
+	req.SortBy = c.Query("sort_by")
+	req.SortOrder = c.Query("sort_order")
+
+	// 字段裁剪参数 - fields=agent_id,hostname,status，未携带时返回完整对象
+	fields, ok := h.parseFieldsQuery(c, agentListFields)
+	if !ok {
+		return
 	}
 
 	// 调用服务层获取Agent列表
@@ -378,11 +533,22 @@ func (h *AgentHandler) GetAgentList(c *gin.Context) {
 		},
 	)
 
+	items, err := utils.SelectFields(response.Agents, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "failed",
+			Message: "Failed to get agent list",
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, system.APIResponse{
 		Code:    http.StatusOK,
 		Status:  "success",
 		Message: "Agent list retrieved successfully",
-		Data:    response,
+		Data:    system.NewPaginatedResponse(items, response.Pagination.Total, response.Pagination.Page, response.Pagination.PageSize),
 	})
 }
 
@@ -549,6 +715,104 @@ func (h *AgentHandler) UpdateAgentStatus(c *gin.Context) {
 	})
 }
 
+// UpdateAgentHeartbeatSettings 更新Agent心跳间隔与离线阈值
+// 说明: 链路较慢的Agent可调大心跳间隔和离线阈值，两项为 0 时恢复默认值；新间隔在Agent下次注册/接入时下发。
+func (h *AgentHandler) UpdateAgentHeartbeatSettings(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	agentID := c.Param("id")
+	if agentID == "" {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Agent ID is required",
+			Error:   "missing agent ID parameter",
+		})
+		return
+	}
+
+	var req agentModel.UpdateAgentHeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request format",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.HeartbeatInterval < 0 || req.HeartbeatInterval > 3600 || req.OfflineThreshold < 0 || req.OfflineThreshold > 86400 {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid heartbeat settings",
+			Error:   "heartbeat_interval must be 0-3600 and offline_threshold must be 0-86400 seconds",
+		})
+		return
+	}
+
+	if err := h.agentMonitorService.UpdateHeartbeatSettings(agentID, &req); err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(
+			err,
+			XRequestID,
+			0,
+			clientIP,
+			pathUrl,
+			"PATCH",
+			map[string]interface{}{
+				"operation":   "update_agent_heartbeat_settings",
+				"option":      "agentMonitorService.UpdateHeartbeatSettings",
+				"func_name":   "handler.agent.UpdateAgentHeartbeatSettings",
+				"user_agent":  userAgent,
+				"agent_id":    agentID,
+				"status_code": statusCode,
+			},
+		)
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to update agent heartbeat settings",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.LogBusinessOperation(
+		"update_agent_heartbeat_settings",
+		0,
+		"",
+		clientIP,
+		XRequestID,
+		"success",
+		"更新Agent心跳配置成功",
+		map[string]interface{}{
+			"func_name":          "handler.agent.UpdateAgentHeartbeatSettings",
+			"option":             "success",
+			"path":               pathUrl,
+			"method":             "PATCH",
+			"user_agent":         userAgent,
+			"agent_id":           agentID,
+			"heartbeat_interval": req.HeartbeatInterval,
+			"offline_threshold":  req.OfflineThreshold,
+		},
+	)
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:   http.StatusOK,
+		Status: "success",
+		Data: map[string]interface{}{
+			"agent_id":           agentID,
+			"heartbeat_interval": req.HeartbeatInterval,
+			"offline_threshold":  req.OfflineThreshold,
+		},
+		Message: "Agent heartbeat settings updated successfully",
+	})
+}
+
 // DeleteAgent 删除Agent
 // 说明: 校验路径参数后，调用服务层删除 Agent，统一日志与响应格式。
 func (h *AgentHandler) DeleteAgent(c *gin.Context) {
@@ -7,6 +7,7 @@
  * - AddAgentTag（添加标签）
  * - RemoveAgentTag（移除标签）
  * - UpdateAgentTags（更新标签列表）
+ * - AutoTagAgent（按匹配规则重新评估自动标签）
  * 重构策略: 保持原有业务逻辑和返回格式不变，统一成功日志使用 LogBusinessOperation。
  */
 
@@ -578,3 +579,75 @@ func (h *AgentHandler) UpdateAgentTags(c *gin.Context) {
 		},
 	})
 }
+
+// AutoTagAgent 按匹配规则重新评估指定Agent的自动标签
+// 处理 POST 请求，用 entity_type 为 agent 的启用规则匹配Agent属性，增删 auto 来源的标签，返回命中的标签名称
+func (h *AgentHandler) AutoTagAgent(c *gin.Context) {
+	// 规范化客户端信息
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	// 获取Agent ID
+	agentID := c.Param("id")
+	if agentID == "" {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Agent ID is required",
+			Error:   "missing agent ID parameter",
+		})
+		return
+	}
+
+	tags, err := h.agentManagerService.AutoTagAgent(c.Request.Context(), agentID)
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":   "auto_tag_agent",
+			"option":      "agentManagerService.AutoTagAgent",
+			"func_name":   "handler.agent.AutoTagAgent",
+			"user_agent":  userAgent,
+			"agent_id":    agentID,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to auto tag agent",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.LogBusinessOperation(
+		"auto_tag_agent",
+		0,
+		"",
+		clientIP,
+		XRequestID,
+		"success",
+		"Agent自动打标成功",
+		map[string]interface{}{
+			"func_name":  "handler.agent.AutoTagAgent",
+			"option":     "success",
+			"path":       pathUrl,
+			"method":     "POST",
+			"user_agent": userAgent,
+			"agent_id":   agentID,
+			"tag_count":  len(tags),
+		},
+	)
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Agent auto tagged successfully",
+		Data: map[string]interface{}{
+			"agent_id":  agentID,
+			"operation": "auto_tag_agent",
+			"tags":      tags,
+		},
+	})
+}
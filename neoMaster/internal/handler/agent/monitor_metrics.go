@@ -175,8 +175,18 @@ func (h *AgentHandler) GetAgentListAllMetrics(c *gin.Context) {
 		keywordPtr = &kw
 	}
 
-	// 调用服务层，分页获取所有Agent的最新性能快照（仓储层SQL分页 + 过滤条件）
-	list, total, err := h.agentMonitorService.GetAgentListAllMetricsFromDB(page, pageSize, workStatusPtr, scanTypePtr, keywordPtr)
+	// 排序参数：sort_by 不在白名单内时由仓储层回退到默认排序(timestamp DESC)
+	sortBy := c.Query("sort_by")
+	sortOrder := c.Query("sort_order")
+
+	// 字段裁剪参数：fields=agent_id,cpu_usage,memory_usage，未携带时返回完整对象
+	fields, ok := h.parseFieldsQuery(c, agentMetricsFields)
+	if !ok {
+		return
+	}
+
+	// 调用服务层，分页获取所有Agent的最新性能快照（仓储层SQL分页 + 过滤条件 + 排序）
+	list, total, err := h.agentMonitorService.GetAgentListAllMetricsFromDB(page, pageSize, workStatusPtr, scanTypePtr, keywordPtr, sortBy, sortOrder)
 	if err != nil {
 		statusCode := h.getErrorStatusCode(err)
 
@@ -226,25 +236,18 @@ func (h *AgentHandler) GetAgentListAllMetrics(c *gin.Context) {
 		return
 	}
 
-	// Service 已进行分页查询，这里直接使用返回的当前页数据
-	pageItems := list
-
-	// 计算总页数（注意类型转换，total 为 int64）
-	totalPages := 0
-	if pageSize > 0 {
-		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
-	}
-
-	// 构造分页响应结构
-	resp := &agentModel.AgentMetricsListResponse{
-		Metrics: pageItems,
-		Pagination: &agentModel.PaginationResponse{
-			Page:       page,
-			PageSize:   pageSize,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+	// Service 已进行分页查询，这里直接使用返回的当前页数据构造统一分页响应
+	items, err := utils.SelectFields(list, fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "failed",
+			Message: "Failed to get all agents metrics",
+			Error:   err.Error(),
+		})
+		return
 	}
+	resp := system.NewPaginatedResponse(items, total, page, pageSize)
 
 	// 成功业务日志（补充分页信息）：统一使用 LogBusinessOperation
 	logger.LogBusinessOperation(
@@ -0,0 +1,162 @@
+/**
+ * Agent实时状态推送控制器
+ * 作者: sun977
+ * 日期: 2026-10-16
+ * 说明: 看板通过 WebSocket 订阅 Agent 状态/性能指标变化，替代轮询列表接口。
+ * - 认证复用用户 JWT 中间件(浏览器无法为 WebSocket 设置请求头时可使用 access_token 查询参数)
+ * - 支持 agent_ids / tag_ids 过滤
+ * - websocket.check_origin 开启时拒绝非同源且不在 CORS 允许列表中的来源(防止跨站劫持 WebSocket)
+ * - 客户端断开或写入失败时立即取消订阅，读写协程随连接一起退出
+ */
+package agent
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+)
+
+// agentEventWriteTimeout 单条事件的写超时，超时视为客户端失联
+const agentEventWriteTimeout = 10 * time.Second
+
+// StreamAgentEvents Agent实时事件 WebSocket 处理器
+// 查询参数: agent_ids(逗号分隔) / tag_ids(逗号分隔)，均为空时订阅全部Agent
+func (h *AgentHandler) StreamAgentEvents(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	currentUserID := utils.GetCurrentUserIDFromGinContext(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.Path // 查询参数中可能带有 access_token，日志只记录路径
+
+	if origin := c.GetHeader("Origin"); !h.isWebSocketOriginAllowed(origin, c.Request.Host) {
+		logger.LogWarn("拒绝非法来源的Agent事件订阅", XRequestID, currentUserID, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation": "stream_agent_events",
+			"option":    "check_origin",
+			"func_name": "handler.agent.StreamAgentEvents",
+			"origin":    origin,
+		})
+		c.JSON(http.StatusForbidden, system.APIResponse{
+			Code:    http.StatusForbidden,
+			Status:  "failed",
+			Message: "websocket origin not allowed",
+			Error:   "origin not allowed",
+		})
+		return
+	}
+
+	var agentIDs []string
+	if v := c.Query("agent_ids"); v != "" {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				agentIDs = append(agentIDs, id)
+			}
+		}
+	}
+	var tagIDs []uint64
+	if v := c.Query("tag_ids"); v != "" {
+		for _, idStr := range strings.Split(v, ",") {
+			if id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 64); err == nil {
+				tagIDs = append(tagIDs, id)
+			}
+		}
+	}
+
+	sub, err := h.agentMonitorService.SubscribeAgentEvents(c.Request.Context(), agentIDs, tagIDs)
+	if err != nil {
+		status := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, currentUserID, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation": "stream_agent_events",
+			"option":    "agentMonitorService.SubscribeAgentEvents",
+			"func_name": "handler.agent.StreamAgentEvents",
+			"agent_ids": agentIDs,
+			"tag_ids":   tagIDs,
+		})
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "failed",
+			Message: "subscribe agent events failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer sub.Close()
+
+	server := websocket.Server{
+		// 来源已在订阅前检查
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			// 读协程只用于感知客户端断开(看板不会发送业务消息)；连接关闭后 Receive 返回错误，协程随之退出
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard string
+				for {
+					if err := websocket.Message.Receive(conn, &discard); err != nil {
+						return
+					}
+				}
+			}()
+
+			for {
+				select {
+				case <-closed:
+					return
+				case event, ok := <-sub.Events():
+					if !ok {
+						return
+					}
+					_ = conn.SetWriteDeadline(time.Now().Add(agentEventWriteTimeout))
+					if err := websocket.JSON.Send(conn, event); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+
+	logger.LogInfo("Agent事件订阅已建立", XRequestID, currentUserID, clientIP, pathUrl, "GET", map[string]interface{}{
+		"operation": "stream_agent_events",
+		"option":    "connected",
+		"func_name": "handler.agent.StreamAgentEvents",
+		"agent_ids": agentIDs,
+		"tag_ids":   tagIDs,
+	})
+	// ServeHTTP 在 Handler 返回后关闭连接，读协程随之退出
+	server.ServeHTTP(c.Writer, c.Request)
+	logger.LogInfo("Agent事件订阅已断开", XRequestID, currentUserID, clientIP, pathUrl, "GET", map[string]interface{}{
+		"operation": "stream_agent_events",
+		"option":    "disconnected",
+		"func_name": "handler.agent.StreamAgentEvents",
+		"dropped":   sub.Dropped(),
+	})
+}
+
+// isWebSocketOriginAllowed 检查 WebSocket 握手的来源
+// 未开启检查或没有 Origin 头(非浏览器客户端)时放行；同源或在允许列表中的来源放行，按 scheme://host[:port] 忽略大小写比较
+func (h *AgentHandler) isWebSocketOriginAllowed(origin, host string) bool {
+	if !h.wsCheckOrigin || origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, host) {
+		return true
+	}
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range h.wsAllowOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
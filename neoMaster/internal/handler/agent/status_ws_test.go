@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"neomaster/internal/config"
+	agentService "neomaster/internal/service/agent"
+)
+
+func newAgentEventsServer(t *testing.T, checkOrigin bool, allowOrigins []string) *httptest.Server {
+	t.Helper()
+	monitor := agentService.NewAgentMonitorService(nil, nil, nil, nil, agentService.NewAgentEventHub(0))
+	h := NewAgentHandler(nil, monitor, nil, nil)
+	h.SetWebSocketOriginPolicy(checkOrigin, config.CORSConfig{AllowOrigins: allowOrigins})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/agent/events/ws", h.StreamAgentEvents)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialAgentEvents(server *httptest.Server, origin string) (*websocket.Conn, error) {
+	return websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/agent/events/ws", "", origin)
+}
+
+func TestStreamAgentEvents_RejectsDisallowedOrigin(t *testing.T) {
+	server := newAgentEventsServer(t, true, []string{"https://console.example.com"})
+
+	if _, err := dialAgentEvents(server, "https://evil.example.com"); err == nil {
+		t.Fatal("expected handshake from a disallowed origin to be rejected")
+	}
+
+	// 普通请求同样返回 403
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/agent/events/ws", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestStreamAgentEvents_AllowsConfiguredAndSameOrigin(t *testing.T) {
+	server := newAgentEventsServer(t, true, []string{"https://console.example.com/"})
+
+	for _, origin := range []string{"https://Console.example.com", server.URL} {
+		conn, err := dialAgentEvents(server, origin)
+		if err != nil {
+			t.Fatalf("origin %s: expected handshake to succeed, got %v", origin, err)
+		}
+		conn.Close()
+	}
+}
+
+func TestStreamAgentEvents_CheckOriginDisabled(t *testing.T) {
+	server := newAgentEventsServer(t, false, nil)
+
+	conn, err := dialAgentEvents(server, "https://evil.example.com")
+	if err != nil {
+		t.Fatalf("expected handshake to succeed when check_origin is disabled, got %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,57 @@
+// Agent 版本升级控制器
+package agent
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+)
+
+// GetAgentsNeedingUpdate 获取需要升级的在线Agent列表
+// 说明: 以最新发布版本为目标，返回当前版本较低的在线Agent及其当前/目标版本
+func (h *AgentHandler) GetAgentsNeedingUpdate(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	currentUserID := utils.GetCurrentUserIDFromGinContext(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	candidates, err := h.agentManagerService.GetAgentsNeedingUpdate(c.Request.Context())
+	if err != nil {
+		statusCode := h.getErrorStatusCode(err)
+		logger.LogBusinessError(err, XRequestID, currentUserID, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation":   "get_agents_needing_update",
+			"option":      "agentManagerService.GetAgentsNeedingUpdate",
+			"func_name":   "handler.agent.GetAgentsNeedingUpdate",
+			"user_agent":  userAgent,
+			"status_code": statusCode,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: "Failed to get agents needing update",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.LogBusinessOperation("get_agents_needing_update", currentUserID, "", clientIP, XRequestID, "success", "获取待升级Agent列表成功", map[string]interface{}{
+		"func_name":  "handler.agent.GetAgentsNeedingUpdate",
+		"option":     "response.success",
+		"path":       pathUrl,
+		"method":     "GET",
+		"user_agent": userAgent,
+		"total":      len(candidates),
+	})
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "OK",
+		Data:    candidates,
+	})
+}
@@ -1,6 +1,7 @@
 package asset
 
 import (
+	"errors"
 	"math"
 	"net/http"
 	"strconv"
@@ -239,8 +240,24 @@ func (h *AssetHostHandler) ListHosts(c *gin.Context) {
 	ip := c.Query("ip")
 	hostname := c.Query("hostname")
 	os := c.Query("os")
+	cidr := c.Query("cidr")
 	tagIDsStr := c.Query("tag_ids")
 
+	openPort := 0
+	if openPortStr := c.Query("open_port"); openPortStr != "" {
+		port, err := strconv.Atoi(openPortStr)
+		if err != nil || port < 1 || port > 65535 {
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "failed",
+				Message: "Invalid open_port",
+				Error:   "open_port must be between 1 and 65535",
+			})
+			return
+		}
+		openPort = port
+	}
+
 	var tagIDs []uint64
 	if tagIDsStr != "" {
 		ids := strings.Split(tagIDsStr, ",")
@@ -251,8 +268,17 @@ func (h *AssetHostHandler) ListHosts(c *gin.Context) {
 		}
 	}
 
-	hosts, total, err := h.service.ListHosts(c.Request.Context(), page, pageSize, ip, hostname, os, tagIDs)
+	hosts, total, err := h.service.ListHosts(c.Request.Context(), page, pageSize, ip, hostname, os, cidr, openPort, tagIDs)
 	if err != nil {
+		if errors.Is(err, assetservice.ErrInvalidHostFilter) {
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "failed",
+				Message: "Invalid host filter",
+				Error:   err.Error(),
+			})
+			return
+		}
 		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "GET", map[string]interface{}{
 			"operation": "list_hosts",
 		})
@@ -286,6 +312,124 @@ func (h *AssetHostHandler) ListHosts(c *gin.Context) {
 	})
 }
 
+// SearchHosts 按网段搜索主机 (cidr 必填，可为单个 IP；open_port 可选)
+func (h *AssetHostHandler) SearchHosts(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	cidr := c.Query("cidr")
+	if cidr == "" {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "cidr is required",
+		})
+		return
+	}
+
+	var openPort *int
+	if openPortStr := c.Query("open_port"); openPortStr != "" {
+		port, err := strconv.Atoi(openPortStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "failed",
+				Message: "Invalid open_port",
+				Error:   err.Error(),
+			})
+			return
+		}
+		openPort = &port
+	}
+
+	hosts, err := h.service.SearchHostsByCIDR(c.Request.Context(), cidr, openPort)
+	if err != nil {
+		if errors.Is(err, assetservice.ErrInvalidHostFilter) {
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "failed",
+				Message: "Invalid host filter",
+				Error:   err.Error(),
+			})
+			return
+		}
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "GET", map[string]interface{}{
+			"operation": "search_hosts",
+			"cidr":      cidr,
+		})
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "failed",
+			Message: "Failed to search hosts",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Hosts retrieved successfully",
+		Data:    hosts,
+	})
+}
+
+// IngestHostRequest 扫描结果主机入库请求
+type IngestHostRequest struct {
+	Host     assetmodel.AssetHost       `json:"host" binding:"required"`
+	Services []*assetmodel.AssetService `json:"services"` // 本次发现的开放端口/服务
+}
+
+// IngestHost 扫描结果主机入库 (按 IP 合并主机，新发现的端口并入已有主机)
+func (h *AssetHostHandler) IngestHost(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	var req IngestHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	host, err := h.service.IngestHost(c.Request.Context(), &req.Host, req.Services)
+	if err != nil {
+		if errors.Is(err, assetservice.ErrInvalidHostIP) {
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "failed",
+				Message: "Invalid host IP",
+				Error:   err.Error(),
+			})
+			return
+		}
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation": "ingest_host",
+			"ip":        req.Host.IP,
+		})
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "failed",
+			Message: "Failed to ingest host",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Host ingested successfully",
+		Data:    host,
+	})
+}
+
 // -----------------------------------------------------------------------------
 // AssetService Handlers
 // -----------------------------------------------------------------------------
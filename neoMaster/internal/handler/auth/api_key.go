@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+	"neomaster/internal/service/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler API 密钥管理接口处理器
+type APIKeyHandler struct {
+	apiKeyService *auth.APIKeyService
+}
+
+// NewAPIKeyHandler 创建 API 密钥处理器实例
+func NewAPIKeyHandler(apiKeyService *auth.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// getErrorStatusCode 根据错误类型获取HTTP状态码
+func (h *APIKeyHandler) getErrorStatusCode(err error) int {
+	var validationErr *system.ValidationError
+	switch {
+	case errors.Is(err, system.ErrAPIKeyScope), errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, system.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Create 创建 API 密钥，响应中的 key 只返回这一次
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+	var req system.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:      http.StatusBadRequest,
+			Status:    "failed",
+			Message:   "invalid request body",
+			Error:     err.Error(),
+			ErrorCode: system.ErrCodeInvalidParam,
+		})
+		return
+	}
+
+	resp, err := h.apiKeyService.Generate(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.fail(c, userID, "api_key_create", "handler.auth.api_key.Create", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, system.APIResponse{
+		Code:    http.StatusCreated,
+		Status:  "success",
+		Message: "API密钥已创建，请立即保存，之后无法再次查看",
+		Data:    resp,
+	})
+}
+
+// List 获取当前用户的 API 密钥列表
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.List(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, userID, "api_key_list", "handler.auth.api_key.List", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "获取API密钥列表成功",
+		Data:    keys,
+	})
+}
+
+// Revoke 吊销当前用户的 API 密钥
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || keyID == 0 {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:      http.StatusBadRequest,
+			Status:    "failed",
+			Message:   "invalid api key id",
+			ErrorCode: system.ErrCodeInvalidParam,
+		})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), userID, uint(keyID)); err != nil {
+		h.fail(c, userID, "api_key_revoke", "handler.auth.api_key.Revoke", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "API密钥已吊销",
+	})
+}
+
+// currentUserID 从认证中间件写入的上下文获取当前用户ID，失败时直接写出401
+func (h *APIKeyHandler) currentUserID(c *gin.Context) (uint, bool) {
+	if userID := utils.GetCurrentUserIDFromGinContext(c); userID != 0 {
+		return userID, true
+	}
+	c.JSON(http.StatusUnauthorized, system.APIResponse{
+		Code:      http.StatusUnauthorized,
+		Status:    "failed",
+		Message:   "用户身份验证失败",
+		ErrorCode: system.ErrCodeUnauthorized,
+	})
+	return 0, false
+}
+
+// fail 记录错误日志并按错误类型写出响应
+func (h *APIKeyHandler) fail(c *gin.Context, userID uint, operation, funcName string, err error) {
+	statusCode := h.getErrorStatusCode(err)
+	logger.LogBusinessError(err, c.GetHeader("X-Request-ID"), userID, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+		"operation":   operation,
+		"func_name":   funcName,
+		"status_code": statusCode,
+		"timestamp":   logger.NowFormatted(),
+	})
+	c.JSON(statusCode, system.APIResponse{
+		Code:      statusCode,
+		Status:    "failed",
+		Message:   "api key request failed",
+		Error:     err.Error(),
+		ErrorCode: system.ErrorCodeOf(err),
+	})
+}
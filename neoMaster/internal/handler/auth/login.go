@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"neomaster/internal/model/system"
 	"net/http"
 	"strings"
@@ -49,6 +50,8 @@ func (h *LoginHandler) validateLoginRequest(req *system.LoginRequest) error {
 func (h *LoginHandler) getErrorStatusCode(err error) int {
 	errorMsg := err.Error()
 	switch {
+	case errors.Is(err, system.ErrTOTPRequired), errors.Is(err, system.ErrTOTPInvalid):
+		return http.StatusUnauthorized
 	case strings.Contains(errorMsg, "invalid username or password"):
 		return http.StatusUnauthorized
 	case strings.Contains(errorMsg, "user account is inactive"):
@@ -133,10 +136,11 @@ func (h *LoginHandler) Login(c *gin.Context) { // c 是 *gin.Context 类型，
 			"timestamp":   logger.NowFormatted(),
 		})
 		c.JSON(statusCode, system.APIResponse{
-			Code:    statusCode,
-			Status:  "failed",
-			Message: "login failed",
-			Error:   err.Error(),
+			Code:      statusCode,
+			Status:    "failed",
+			Message:   "login failed",
+			Error:     err.Error(),
+			ErrorCode: system.ErrorCodeOf(err),
 		})
 		return
 	}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+	"neomaster/internal/service/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TOTPHandler 两步验证接口处理器
+type TOTPHandler struct {
+	totpService *auth.TOTPService
+}
+
+// NewTOTPHandler 创建两步验证处理器实例
+func NewTOTPHandler(totpService *auth.TOTPService) *TOTPHandler {
+	return &TOTPHandler{
+		totpService: totpService,
+	}
+}
+
+// getErrorStatusCode 根据错误类型获取HTTP状态码
+func (h *TOTPHandler) getErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, system.ErrTOTPInvalid):
+		return http.StatusUnauthorized
+	case errors.Is(err, system.ErrTOTPNotEnrolled):
+		return http.StatusBadRequest
+	case errors.Is(err, system.ErrTOTPAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, system.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, system.ErrTOTPUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Enroll 生成两步验证密钥，返回认证器配置URI(需随后调用 Activate 启用)
+func (h *TOTPHandler) Enroll(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.totpService.Enroll(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, userID, "totp_enroll", "handler.auth.totp.Enroll", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "请使用认证器扫描二维码，并提交动态码完成启用",
+		Data:    resp,
+	})
+}
+
+// Activate 校验动态码并启用两步验证
+func (h *TOTPHandler) Activate(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+	req, ok := h.bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.totpService.Activate(c.Request.Context(), userID, req.Code); err != nil {
+		h.fail(c, userID, "totp_activate", "handler.auth.totp.Activate", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "两步验证已启用",
+	})
+}
+
+// Disable 校验动态码并关闭两步验证
+func (h *TOTPHandler) Disable(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+	req, ok := h.bindCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.totpService.Disable(c.Request.Context(), userID, req.Code); err != nil {
+		h.fail(c, userID, "totp_disable", "handler.auth.totp.Disable", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "两步验证已关闭",
+	})
+}
+
+// currentUserID 从JWT中间件写入的上下文获取当前用户ID，失败时直接写出401
+func (h *TOTPHandler) currentUserID(c *gin.Context) (uint, bool) {
+	userID, ok := c.Get("user_id")
+	if id, isUint := userID.(uint); ok && isUint && id != 0 {
+		return id, true
+	}
+	c.JSON(http.StatusUnauthorized, system.APIResponse{
+		Code:      http.StatusUnauthorized,
+		Status:    "failed",
+		Message:   "用户身份验证失败",
+		ErrorCode: system.ErrCodeUnauthorized,
+	})
+	return 0, false
+}
+
+// bindCodeRequest 解析动态码请求体，失败时直接写出400
+func (h *TOTPHandler) bindCodeRequest(c *gin.Context) (*system.TOTPCodeRequest, bool) {
+	var req system.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		errMsg := "code cannot be empty"
+		if err != nil {
+			errMsg = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:      http.StatusBadRequest,
+			Status:    "failed",
+			Message:   "invalid request body",
+			Error:     errMsg,
+			ErrorCode: system.ErrCodeInvalidParam,
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+// fail 记录错误日志并按错误类型写出响应
+func (h *TOTPHandler) fail(c *gin.Context, userID uint, operation, funcName string, err error) {
+	statusCode := h.getErrorStatusCode(err)
+	logger.LogBusinessError(err, c.GetHeader("X-Request-ID"), userID, utils.GetClientIP(c), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+		"operation":   operation,
+		"func_name":   funcName,
+		"status_code": statusCode,
+		"timestamp":   logger.NowFormatted(),
+	})
+	c.JSON(statusCode, system.APIResponse{
+		Code:      statusCode,
+		Status:    "failed",
+		Message:   "two-factor authentication request failed",
+		Error:     err.Error(),
+		ErrorCode: system.ErrorCodeOf(err),
+	})
+}
@@ -0,0 +1,215 @@
+/**
+ * 处理器:服务健康检查
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 提供 /healthz 与 /readyz，探测 MySQL、Redis 等依赖的真实可用性，供编排系统(k8s/负载均衡)判断实例状态
+ * @func:
+ *   - Healthz 健康检查(MySQL + Redis)
+ *   - Readyz  就绪检查(健康检查 + 数据表迁移完成)
+ */
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"time"
+
+	"neomaster/internal/config"
+	"neomaster/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// defaultCheckTimeout 单个依赖探测的超时时间
+const defaultCheckTimeout = 2 * time.Second
+
+// 依赖/整体状态
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DependencyStatus 单个依赖的探测结果
+// 探测接口无需认证，失败原因(可能包含 DSN、主机地址)只写日志，不返回给调用方
+type DependencyStatus struct {
+	Status    string `json:"status"`     // up / down
+	LatencyMs int64  `json:"latency_ms"` // 探测耗时(毫秒)
+	err       error  // 失败原因，仅用于日志
+}
+
+// BuildInfo 构建/版本信息
+type BuildInfo struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Environment string `json:"environment"`
+	GoVersion   string `json:"go_version"`
+	StartedAt   string `json:"started_at"`
+	Uptime      string `json:"uptime"`
+}
+
+// HealthResponse 健康检查响应
+type HealthResponse struct {
+	Status    string                       `json:"status"` // up: 全部依赖可用；down: 任一依赖不可用
+	Timestamp string                       `json:"timestamp"`
+	Build     BuildInfo                    `json:"build"`
+	Checks    map[string]*DependencyStatus `json:"checks"`
+}
+
+// dependencyCheck 依赖探测函数
+type dependencyCheck struct {
+	name  string
+	probe func(ctx context.Context) error
+}
+
+// HealthHandler 健康检查处理器
+type HealthHandler struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	appConfig   *config.AppConfig
+	startedAt   time.Time
+	timeout     time.Duration
+	// requiredTables 就绪检查要求已存在的数据表 (迁移工具未执行时不就绪)
+	requiredTables []string
+}
+
+// NewHealthHandler 创建健康检查处理器
+// db/redisClient 为空时对应依赖视为不可用
+func NewHealthHandler(db *gorm.DB, redisClient *redis.Client, appConfig *config.AppConfig) *HealthHandler {
+	return &HealthHandler{
+		db:             db,
+		redisClient:    redisClient,
+		appConfig:      appConfig,
+		startedAt:      time.Now(),
+		timeout:        defaultCheckTimeout,
+		requiredTables: []string{"schema_migrations", "users", "agents", "projects"},
+	}
+}
+
+// Healthz 健康检查
+// GET /healthz 无需认证；所有依赖可用返回 200，否则返回 503
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	h.respond(c, "healthz", h.healthChecks())
+}
+
+// Readyz 就绪检查
+// GET /readyz 无需认证；在 Healthz 的基础上额外检查数据库迁移是否已完成
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	checks := append(h.healthChecks(), dependencyCheck{name: "migrations", probe: h.checkMigrations})
+	h.respond(c, "readyz", checks)
+}
+
+// healthChecks 基础依赖探测列表
+func (h *HealthHandler) healthChecks() []dependencyCheck {
+	return []dependencyCheck{
+		{name: "mysql", probe: h.checkMySQL},
+		{name: "redis", probe: h.checkRedis},
+	}
+}
+
+// respond 执行探测并输出结果
+func (h *HealthHandler) respond(c *gin.Context, operation string, checks []dependencyCheck) {
+	resp := &HealthResponse{
+		Status:    StatusUp,
+		Timestamp: logger.NowFormatted(),
+		Build:     h.buildInfo(),
+		Checks:    make(map[string]*DependencyStatus, len(checks)),
+	}
+
+	for _, check := range checks {
+		result := h.runCheck(c.Request.Context(), check)
+		resp.Checks[check.name] = result
+		if result.Status != StatusUp {
+			resp.Status = StatusDown
+		}
+	}
+
+	if resp.Status != StatusUp {
+		failures := make(map[string]string)
+		for name, result := range resp.Checks {
+			if result.err != nil {
+				failures[name] = result.err.Error()
+			}
+		}
+		logger.WithFields(map[string]interface{}{
+			"path":      c.Request.URL.String(),
+			"operation": operation,
+			"option":    "HealthHandler.respond",
+			"func_name": "handler.monitor.health." + operation,
+			"failures":  failures,
+		}).Warn("依赖健康检查未通过")
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// runCheck 在超时时间内执行单个探测
+func (h *HealthHandler) runCheck(parent context.Context, check dependencyCheck) *DependencyStatus {
+	ctx, cancel := context.WithTimeout(parent, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.probe(ctx)
+	result := &DependencyStatus{
+		Status:    StatusUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.err = err
+	}
+	return result
+}
+
+// checkMySQL 通过底层 *sql.DB 探测 MySQL 连接
+func (h *HealthHandler) checkMySQL(ctx context.Context) error {
+	if h.db == nil {
+		return errors.New("mysql not configured")
+	}
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkRedis 探测 Redis 连接
+func (h *HealthHandler) checkRedis(ctx context.Context) error {
+	if h.redisClient == nil {
+		return errors.New("redis not configured")
+	}
+	return h.redisClient.Ping(ctx).Err()
+}
+
+// checkMigrations 检查核心数据表是否已创建
+func (h *HealthHandler) checkMigrations(ctx context.Context) error {
+	if h.db == nil {
+		return errors.New("mysql not configured")
+	}
+	migrator := h.db.WithContext(ctx).Migrator()
+	for _, table := range h.requiredTables {
+		if !migrator.HasTable(table) {
+			return errors.New("table " + table + " not migrated")
+		}
+	}
+	return nil
+}
+
+// buildInfo 构建/版本信息
+func (h *HealthHandler) buildInfo() BuildInfo {
+	info := BuildInfo{
+		GoVersion: runtime.Version(),
+		StartedAt: logger.FormatTimestamp(h.startedAt),
+		Uptime:    time.Since(h.startedAt).Round(time.Second).String(),
+	}
+	if h.appConfig != nil {
+		info.Name = h.appConfig.Name
+		info.Version = h.appConfig.Version
+		info.Environment = h.appConfig.Environment
+	}
+	return info
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neomaster/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func serveHealth(t *testing.T, h *HealthHandler, path string) (int, *HealthResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/healthz", h.Healthz)
+	engine.GET("/readyz", h.Readyz)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	if strings.Contains(w.Body.String(), "not configured") || strings.Contains(w.Body.String(), "not migrated") {
+		t.Errorf("response leaks probe error detail: %s", w.Body.String())
+	}
+
+	var resp HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v, body=%s", err, w.Body.String())
+	}
+	return w.Code, &resp
+}
+
+func TestHealthHandler_Healthz(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	h := NewHealthHandler(db, nil, &config.AppConfig{Name: "neoMaster", Version: "1.0.0"})
+
+	code, resp := serveHealth(t, h, "/healthz")
+	if code != http.StatusServiceUnavailable || resp.Status != StatusDown {
+		t.Fatalf("got %d/%s, want 503/down when redis is unavailable", code, resp.Status)
+	}
+	if resp.Checks["mysql"].Status != StatusUp {
+		t.Errorf("mysql check = %+v, want up", resp.Checks["mysql"])
+	}
+	if resp.Checks["redis"].Status != StatusDown {
+		t.Errorf("redis check = %+v, want down", resp.Checks["redis"])
+	}
+	if resp.Build.Version != "1.0.0" || resp.Build.GoVersion == "" {
+		t.Errorf("build info = %+v", resp.Build)
+	}
+}
+
+func TestHealthHandler_ReadyzChecksMigrations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	h := NewHealthHandler(db, nil, nil)
+	h.requiredTables = []string{"projects"}
+
+	_, resp := serveHealth(t, h, "/readyz")
+	if resp.Checks["migrations"] == nil || resp.Checks["migrations"].Status != StatusDown {
+		t.Fatalf("migrations check = %+v, want down before tables exist", resp.Checks["migrations"])
+	}
+
+	if err := db.Exec("CREATE TABLE projects (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	_, resp = serveHealth(t, h, "/readyz")
+	if resp.Checks["migrations"].Status != StatusUp {
+		t.Errorf("migrations check = %+v, want up", resp.Checks["migrations"])
+	}
+}
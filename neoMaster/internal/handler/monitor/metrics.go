@@ -0,0 +1,48 @@
+/**
+ * 处理器:Prometheus 指标接口
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 以 Prometheus 文本格式输出各在线Agent的最新性能指标(按 agent_id 标签区分)，供告警发现饱和的扫描节点
+ * @func:
+ *   - Metrics 指标抓取接口
+ */
+package monitor
+
+import (
+	"bytes"
+	"net/http"
+
+	"neomaster/internal/pkg/logger"
+	agentService "neomaster/internal/service/agent"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prometheusContentType Prometheus 文本格式
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler 指标处理器
+type MetricsHandler struct {
+	agentExporter *agentService.AgentMetricsExporter
+}
+
+// NewMetricsHandler 创建指标处理器
+func NewMetricsHandler(agentExporter *agentService.AgentMetricsExporter) *MetricsHandler {
+	return &MetricsHandler{agentExporter: agentExporter}
+}
+
+// Metrics 指标抓取
+// GET /metrics (路径取 monitor.metrics.path)；查询失败返回 500，本次抓取记为失败而不是输出残缺数据
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := h.agentExporter.WriteMetrics(c.Request.Context(), &buf); err != nil {
+		logger.LogError(err, c.GetHeader("X-Request-ID"), 0, c.ClientIP(), c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+			"operation": "metrics",
+			"option":    "agentExporter.WriteMetrics",
+			"func_name": "handler.monitor.metrics.Metrics",
+		})
+		c.String(http.StatusInternalServerError, "collect agent metrics failed: %v\n", err)
+		return
+	}
+	c.Data(http.StatusOK, prometheusContentType, buf.Bytes())
+}
@@ -0,0 +1,109 @@
+package orchestrator
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	orcModel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+	orchestratorService "neomaster/internal/service/orchestrator"
+)
+
+// maxResultBatchBodySize 解压后的请求体上限
+const maxResultBatchBodySize = 32 << 20
+
+// AgentResultHandler 处理 Agent 扫描结果上报
+type AgentResultHandler struct {
+	service *orchestratorService.ResultBatchService
+}
+
+// NewAgentResultHandler 创建 AgentResultHandler 实例
+func NewAgentResultHandler(service *orchestratorService.ResultBatchService) *AgentResultHandler {
+	return &AgentResultHandler{
+		service: service,
+	}
+}
+
+// SubmitResults Agent 结果批次上报接口
+// 路由: POST /api/v1/agent/results (Agent Token 鉴权，支持 Content-Encoding: gzip)
+// 单条结果的问题 (格式错误/任务状态不允许) 只体现在响应的 quarantined/rejected 中，批次整体仍返回 200；
+// 重放已处理过的批次返回 200 + duplicate=true
+func (h *AgentResultHandler) SubmitResults(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+	agentID := c.GetString("agent_id")
+
+	var req orcModel.AgentResultBatchRequest
+	if err := decodeResultBatch(c.Request, &req); err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	report, err := h.service.SubmitBatch(c.Request.Context(), agentID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to ingest results"
+		switch {
+		case errors.Is(err, orchestratorService.ErrInvalidResultBatch):
+			status, message = http.StatusBadRequest, "Invalid result batch"
+		case errors.Is(err, orchestratorService.ErrResultBatchAgentMismatch):
+			status, message = http.StatusForbidden, "Result batch does not belong to this agent"
+		}
+		logger.LogBusinessError(
+			err,
+			XRequestID,
+			0,
+			clientIP,
+			pathUrl,
+			"POST",
+			map[string]interface{}{
+				"operation": "submit_results",
+				"option":    "ResultBatchService.SubmitBatch",
+				"agent_id":  agentID,
+				"batch_id":  req.BatchID,
+			},
+		)
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "failed",
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Results ingested",
+		Data:    report,
+	})
+}
+
+// decodeResultBatch 解析请求体，gzip 压缩的请求先解压
+func decodeResultBatch(r *http.Request, req *orcModel.AgentResultBatchRequest) error {
+	var body io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		body = zr
+	}
+	return json.NewDecoder(io.LimitReader(body, maxResultBatchBodySize)).Decode(req)
+}
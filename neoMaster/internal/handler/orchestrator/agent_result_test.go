@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	orcModel "neomaster/internal/model/orchestrator"
+)
+
+func TestDecodeResultBatch(t *testing.T) {
+	body := `{"batch_id":"b1","results":[{"task_id":"t1","status":"success","result":{"ports":[]}}]}`
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(body))
+	zw.Close()
+
+	plain := httptest.NewRequest(http.MethodPost, "/api/v1/agent/results", bytes.NewBufferString(body))
+	compressed := httptest.NewRequest(http.MethodPost, "/api/v1/agent/results", &gz)
+	compressed.Header.Set("Content-Encoding", "gzip")
+
+	for name, r := range map[string]*http.Request{"plain": plain, "gzip": compressed} {
+		var req orcModel.AgentResultBatchRequest
+		if err := decodeResultBatch(r, &req); err != nil {
+			t.Fatalf("%s: decode failed: %v", name, err)
+		}
+		if req.BatchID != "b1" || len(req.Results) != 1 || string(req.Results[0].Result) != `{"ports":[]}` {
+			t.Errorf("%s: unexpected request: %+v", name, req)
+		}
+	}
+
+	bad := httptest.NewRequest(http.MethodPost, "/api/v1/agent/results", bytes.NewBufferString(body))
+	bad.Header.Set("Content-Encoding", "gzip")
+	var req orcModel.AgentResultBatchRequest
+	if err := decodeResultBatch(bad, &req); err == nil {
+		t.Error("expected error for invalid gzip body")
+	}
+}
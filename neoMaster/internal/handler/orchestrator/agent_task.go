@@ -157,3 +157,72 @@ func (h *AgentTaskHandler) UpdateTaskStatus(c *gin.Context) {
 		Message: "Task status updated successfully",
 	})
 }
+
+// CancelTask 取消任务接口
+// 路由: POST /api/v1/orchestrator/tasks/:task_id/cancel
+// 已下发的任务进入 canceling，Agent 下次拉取任务时收到取消信号；已结束的任务不受影响
+func (h *AgentTaskHandler) CancelTask(c *gin.Context) {
+	taskID := c.Param("task_id")
+	if err := h.service.CancelTask(c.Request.Context(), taskID); err != nil {
+		logger.LogBusinessError(
+			err,
+			c.GetHeader("X-Request-ID"),
+			utils.GetCurrentUserIDFromGinContext(c),
+			utils.GetClientIP(c),
+			c.Request.URL.String(),
+			"POST",
+			map[string]interface{}{
+				"operation": "cancel_task",
+				"option":    "service.CancelTask",
+				"task_id":   taskID,
+			},
+		)
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "failed",
+			Message: "Failed to cancel task",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Task cancellation requested",
+	})
+}
+
+// GetConcurrencyStatus 任务并发占用情况接口
+// 路由: GET /api/v1/orchestrator/tasks/concurrency
+func (h *AgentTaskHandler) GetConcurrencyStatus(c *gin.Context) {
+	status, err := h.service.GetConcurrencyStatus(c.Request.Context())
+	if err != nil {
+		logger.LogBusinessError(
+			err,
+			c.GetHeader("X-Request-ID"),
+			utils.GetCurrentUserIDFromGinContext(c),
+			utils.GetClientIP(c),
+			c.Request.URL.String(),
+			"GET",
+			map[string]interface{}{
+				"operation": "get_concurrency_status",
+				"option":    "service.GetConcurrencyStatus",
+			},
+		)
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "failed",
+			Message: "Failed to get concurrency status",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    status,
+	})
+}
@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/service/orchestrator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// projectEventsHeartbeat SSE 心跳间隔，需小于常见反向代理的空闲超时(如 nginx 默认 60s)
+const projectEventsHeartbeat = 15 * time.Second
+
+// StreamProjectEvents 以 Server-Sent Events 推送项目执行进度
+// 事件类型: stage_start / stage_complete / progress / done(携带项目汇总，发送后结束流)
+func (h *StageResultHandler) StreamProjectEvents(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid project ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := h.service.WatchProjectEvents(ctx, id, 0)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, orchestrator.ErrProjectNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to watch project events",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲，事件立即下发
+	c.Status(http.StatusOK)
+
+	// 事件流是长连接，清除 server.write_timeout 设置的写超时，否则连接会在超时后被服务端断开
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger.LogBusinessError(err, "", 0, "", c.Request.URL.Path, c.Request.Method, map[string]interface{}{
+			"operation":  "stream_project_events",
+			"option":     "SetWriteDeadline",
+			"func_name":  "handler.orchestrator.project_events.StreamProjectEvents",
+			"project_id": id,
+		})
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"path":       c.Request.URL.Path,
+		"operation":  "stream_project_events",
+		"option":     "connected",
+		"func_name":  "handler.orchestrator.project_events.StreamProjectEvents",
+		"project_id": id,
+	}).Info("项目进度事件流已建立")
+
+	heartbeat := time.NewTicker(projectEventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// 客户端断开：ctx 取消后 WatchProjectEvents 的轮询协程随之退出
+			return
+		case <-h.streamsDone:
+			// 服务关闭：主动结束事件流，避免长连接拖满优雅关闭的等待时间
+			return
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			// 写入失败时 SSEvent 记录错误并中止上下文
+			if c.SSEvent(event.Type, event); c.IsAborted() {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// CloseStreams 结束所有进行中的项目事件流 (服务关闭时调用，重复调用无副作用)
+// http.Server.Shutdown 不会取消进行中请求的 ctx，长连接需要单独通知
+func (h *StageResultHandler) CloseStreams() {
+	h.closeStreamsOnce.Do(func() {
+		close(h.streamsDone)
+	})
+}
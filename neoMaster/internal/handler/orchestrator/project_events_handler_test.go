@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	orcModel "neomaster/internal/model/orchestrator"
+	orcRepo "neomaster/internal/repo/mysql/orchestrator"
+	"neomaster/internal/service/orchestrator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestStreamProjectEvents_OutlivesWriteTimeoutAndStopsOnShutdown(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err = db.AutoMigrate(&orcModel.Project{}, &orcModel.ProjectWorkflow{}, &orcModel.ScanStage{}, &orcModel.AgentTask{}, &orcModel.StageResult{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	project := &orcModel.Project{Name: "sse", Status: orcModel.ProjectStatusRunning}
+	if err = db.Create(project).Error; err != nil {
+		t.Fatalf("seed project: %v", err)
+	}
+	service := orchestrator.NewStageResultService(orcRepo.NewStageResultRepository(db), orcRepo.NewScanStageRepository(db),
+		orcRepo.NewTaskRepository(db), orcRepo.NewProjectRepository(db))
+	h := NewStageResultHandler(service)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/projects/:id/events", h.StreamProjectEvents)
+	server := httptest.NewUnstartedServer(engine)
+	// 写超时远小于事件流的持续时间
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Config.RegisterOnShutdown(h.CloseStreams)
+	server.Start()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/projects/1/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("response = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	go server.Config.Shutdown(context.Background())
+
+	// 超过写超时后流仍然完整结束(收到分块结束标记)，而不是被服务端强制断开
+	reader := bufio.NewReader(resp.Body)
+	if _, err = io.ReadAll(reader); err != nil {
+		t.Fatalf("stream ended with error: %v", err)
+	}
+}
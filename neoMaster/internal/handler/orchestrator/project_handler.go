@@ -1,6 +1,7 @@
 package orchestrator
 
 import (
+	"errors"
 	"math"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"neomaster/internal/model/system"
 	"neomaster/internal/pkg/logger"
 	"neomaster/internal/service/orchestrator"
+	"neomaster/internal/service/orchestrator/core/scheduler"
 
 	"github.com/gin-gonic/gin"
 )
@@ -44,8 +46,12 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 	project.UpdatedBy = uint64(userID)
 
 	if err := h.service.CreateProject(c.Request.Context(), &project); err != nil {
-		c.JSON(http.StatusInternalServerError, system.APIResponse{
-			Code:    http.StatusInternalServerError,
+		status := http.StatusInternalServerError
+		if errors.Is(err, scheduler.ErrInvalidCronExpr) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, system.APIResponse{
+			Code:    status,
 			Status:  "error",
 			Message: "Failed to create project",
 			Error:   err.Error(),
@@ -132,8 +138,12 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	project.UpdatedBy = uint64(userID)
 
 	if err := h.service.UpdateProject(c.Request.Context(), &project); err != nil {
-		c.JSON(http.StatusInternalServerError, system.APIResponse{
-			Code:    http.StatusInternalServerError,
+		status := http.StatusInternalServerError
+		if errors.Is(err, scheduler.ErrInvalidCronExpr) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, system.APIResponse{
+			Code:    status,
 			Status:  "error",
 			Message: "Failed to update project",
 			Error:   err.Error(),
@@ -156,6 +166,60 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	})
 }
 
+// GetUpcomingRuns 获取 cron 项目接下来的执行时间
+// 查询参数 count 为返回数量，默认 5，最大 100
+func (h *ProjectHandler) GetUpcomingRuns(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid project ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", "5"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid count",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	runs, err := h.service.GetUpcomingRuns(c.Request.Context(), id, count)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, orchestrator.ErrProjectNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, scheduler.ErrInvalidCronExpr):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to get upcoming runs",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data: map[string]interface{}{
+			"project_id": id,
+			"runs":       runs,
+		},
+	})
+}
+
 // DeleteProject 删除项目
 func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	idStr := c.Param("id")
@@ -194,6 +258,53 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	})
 }
 
+// CancelProject 取消项目
+// 项目流转为 canceled，未下发的任务直接取消，已下发的任务进入 canceling 等待 Agent 中止执行后确认
+func (h *ProjectHandler) CancelProject(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid project ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.TransitionStatus(c.Request.Context(), id, orcmodel.ProjectStatusCanceled); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, orchestrator.ErrProjectNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, orchestrator.ErrIllegalProjectStatusTransition):
+			status = http.StatusConflict
+		}
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to cancel project",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"path":       c.Request.URL.String(),
+		"operation":  "cancel_project",
+		"option":     "ProjectService.TransitionStatus",
+		"func_name":  "handler.orchestrator.project.CancelProject",
+		"project_id": id,
+	}).Info("项目已取消")
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Project canceled successfully",
+	})
+}
+
 // ListProjects 获取项目列表
 func (h *ProjectHandler) ListProjects(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
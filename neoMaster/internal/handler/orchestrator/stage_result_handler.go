@@ -0,0 +1,150 @@
+package orchestrator
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/service/orchestrator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StageResultHandler 阶段结果处理器
+type StageResultHandler struct {
+	service *orchestrator.StageResultService
+
+	streamsDone      chan struct{} // 服务关闭时关闭，通知进行中的事件流结束
+	closeStreamsOnce sync.Once
+}
+
+// NewStageResultHandler 创建 StageResultHandler
+func NewStageResultHandler(service *orchestrator.StageResultService) *StageResultHandler {
+	return &StageResultHandler{
+		service:     service,
+		streamsDone: make(chan struct{}),
+	}
+}
+
+// GetProjectSummary 获取项目结果汇总
+func (h *StageResultHandler) GetProjectSummary(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid project ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	summary, err := h.service.GetProjectSummary(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrProjectNotFound) {
+			c.JSON(http.StatusNotFound, system.APIResponse{
+				Code:    http.StatusNotFound,
+				Status:  "error",
+				Message: "Project not found",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to get project summary",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"path":       c.Request.URL.String(),
+		"operation":  "get_project_summary",
+		"option":     "StageResultService.GetProjectSummary",
+		"func_name":  "handler.orchestrator.stage_result.GetProjectSummary",
+		"project_id": id,
+	}).Info("获取项目结果汇总成功")
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    summary,
+	})
+}
+
+// DiffProjectRuns 比对项目两轮执行的结果差异
+// 查询参数 run_a / run_b 为执行轮次序号 (Project.RunSeq)，run_a 为基准轮次
+func (h *StageResultHandler) DiffProjectRuns(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid project ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+	runA, errA := strconv.ParseInt(c.Query("run_a"), 10, 64)
+	runB, errB := strconv.ParseInt(c.Query("run_b"), 10, 64)
+	if errA != nil || errB != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "run_a and run_b are required integers",
+		})
+		return
+	}
+
+	diff, err := h.service.DiffProjectRuns(c.Request.Context(), id, runA, runB)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestrator.ErrProjectNotFound):
+			c.JSON(http.StatusNotFound, system.APIResponse{
+				Code:    http.StatusNotFound,
+				Status:  "error",
+				Message: "Project not found",
+				Error:   err.Error(),
+			})
+		case errors.Is(err, orchestrator.ErrInvalidRunID):
+			c.JSON(http.StatusBadRequest, system.APIResponse{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "Invalid run id",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, system.APIResponse{
+				Code:    http.StatusInternalServerError,
+				Status:  "error",
+				Message: "Failed to diff project runs",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"path":       c.Request.URL.String(),
+		"operation":  "diff_project_runs",
+		"option":     "StageResultService.DiffProjectRuns",
+		"func_name":  "handler.orchestrator.stage_result.DiffProjectRuns",
+		"project_id": id,
+		"run_a":      runA,
+		"run_b":      runB,
+	}).Info("项目执行结果比对成功")
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    diff,
+	})
+}
@@ -25,13 +25,25 @@ func NewScanToolTemplateHandler(service *orchestrator.ScanToolTemplateService) *
 	}
 }
 
+// templateErrorStatus 根据错误码确定 HTTP 状态码
+func templateErrorStatus(errCode string) int {
+	switch errCode {
+	case system.ErrCodeTemplateNotFound:
+		return http.StatusNotFound
+	case system.ErrCodeInvalidToolParams, system.ErrCodeInvalidParam:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // CreateTemplate 创建工具模板
 func (h *ScanToolTemplateHandler) CreateTemplate(c *gin.Context) {
 	var tmpl orcmodel.ScanToolTemplate
 	if err := c.ShouldBindJSON(&tmpl); err != nil {
 		c.JSON(http.StatusBadRequest, system.APIResponse{
 			Code:    http.StatusBadRequest,
-			Status:  "failed",
+			Status:  "error",
 			Message: "Invalid request body",
 			Error:   err.Error(),
 		})
@@ -44,11 +56,14 @@ func (h *ScanToolTemplateHandler) CreateTemplate(c *gin.Context) {
 
 	if err := h.service.CreateTemplate(c.Request.Context(), &tmpl); err != nil {
 		logger.LogBusinessError(err, c.Request.URL.String(), userID, "", "CreateTemplate", "HANDLER", nil)
-		c.JSON(http.StatusInternalServerError, system.APIResponse{
-			Code:    http.StatusInternalServerError,
-			Status:  "error",
-			Message: "Failed to create tool template",
-			Error:   err.Error(),
+		errCode := system.ErrorCodeOf(err)
+		status := templateErrorStatus(errCode)
+		c.JSON(status, system.APIResponse{
+			Code:      status,
+			Status:    "error",
+			Message:   "Failed to create tool template",
+			Error:     err.Error(),
+			ErrorCode: errCode,
 		})
 		return
 	}
@@ -67,20 +82,24 @@ func (h *ScanToolTemplateHandler) GetTemplate(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, system.APIResponse{
-			Code:    http.StatusBadRequest,
-			Status:  "failed",
-			Message: "Invalid template ID",
+			Code:      http.StatusBadRequest,
+			Status:    "error",
+			Message:   "Invalid template ID",
+			ErrorCode: system.ErrCodeInvalidParam,
 		})
 		return
 	}
 
 	tmpl, err := h.service.GetTemplate(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, system.APIResponse{
-			Code:    http.StatusInternalServerError,
-			Status:  "error",
-			Message: "Failed to get tool template",
-			Error:   err.Error(),
+		errCode := system.ErrorCodeOf(err)
+		status := templateErrorStatus(errCode)
+		c.JSON(status, system.APIResponse{
+			Code:      status,
+			Status:    "error",
+			Message:   "Failed to get tool template",
+			Error:     err.Error(),
+			ErrorCode: errCode,
 		})
 		return
 	}
@@ -99,9 +118,10 @@ func (h *ScanToolTemplateHandler) UpdateTemplate(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, system.APIResponse{
-			Code:    http.StatusBadRequest,
-			Status:  "failed",
-			Message: "Invalid template ID",
+			Code:      http.StatusBadRequest,
+			Status:    "error",
+			Message:   "Invalid template ID",
+			ErrorCode: system.ErrCodeInvalidParam,
 		})
 		return
 	}
@@ -110,7 +130,7 @@ func (h *ScanToolTemplateHandler) UpdateTemplate(c *gin.Context) {
 	if err := c.ShouldBindJSON(&tmpl); err != nil {
 		c.JSON(http.StatusBadRequest, system.APIResponse{
 			Code:    http.StatusBadRequest,
-			Status:  "failed",
+			Status:  "error",
 			Message: "Invalid request body",
 			Error:   err.Error(),
 		})
@@ -121,11 +141,14 @@ func (h *ScanToolTemplateHandler) UpdateTemplate(c *gin.Context) {
 
 	if err := h.service.UpdateTemplate(c.Request.Context(), &tmpl); err != nil {
 		logger.LogBusinessError(err, c.Request.URL.String(), c.GetUint("user_id"), "", "UpdateTemplate", "HANDLER", nil)
-		c.JSON(http.StatusInternalServerError, system.APIResponse{
-			Code:    http.StatusInternalServerError,
-			Status:  "error",
-			Message: "Failed to update tool template",
-			Error:   err.Error(),
+		errCode := system.ErrorCodeOf(err)
+		status := templateErrorStatus(errCode)
+		c.JSON(status, system.APIResponse{
+			Code:      status,
+			Status:    "error",
+			Message:   "Failed to update tool template",
+			Error:     err.Error(),
+			ErrorCode: errCode,
 		})
 		return
 	}
@@ -143,20 +166,24 @@ func (h *ScanToolTemplateHandler) DeleteTemplate(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, system.APIResponse{
-			Code:    http.StatusBadRequest,
-			Status:  "failed",
-			Message: "Invalid template ID",
+			Code:      http.StatusBadRequest,
+			Status:    "error",
+			Message:   "Invalid template ID",
+			ErrorCode: system.ErrCodeInvalidParam,
 		})
 		return
 	}
 
 	if err := h.service.DeleteTemplate(c.Request.Context(), id); err != nil {
 		logger.LogBusinessError(err, c.Request.URL.String(), c.GetUint("user_id"), "", "DeleteTemplate", "HANDLER", nil)
-		c.JSON(http.StatusInternalServerError, system.APIResponse{
-			Code:    http.StatusInternalServerError,
-			Status:  "error",
-			Message: "Failed to delete tool template",
-			Error:   err.Error(),
+		errCode := system.ErrorCodeOf(err)
+		status := templateErrorStatus(errCode)
+		c.JSON(status, system.APIResponse{
+			Code:      status,
+			Status:    "error",
+			Message:   "Failed to delete tool template",
+			Error:     err.Error(),
+			ErrorCode: errCode,
 		})
 		return
 	}
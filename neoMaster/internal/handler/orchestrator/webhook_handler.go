@@ -0,0 +1,292 @@
+package orchestrator
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/service/orchestrator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler Webhook 订阅处理器
+type WebhookHandler struct {
+	service *orchestrator.WebhookService
+}
+
+// NewWebhookHandler 创建 WebhookHandler
+func NewWebhookHandler(service *orchestrator.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+	}
+}
+
+// WebhookSubscriptionRequest 创建/更新订阅请求
+// 订阅模型的 Secret 不参与 JSON 序列化(查询时不返回)，因此单独定义请求体接收密钥
+type WebhookSubscriptionRequest struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`   // generic(默认)/slack/dingtalk/email
+	URL        string   `json:"url"`    // email 类型不需要
+	Secret     string   `json:"secret"` // 更新时为空表示沿用原密钥
+	EventTypes []string `json:"event_types"`
+	Recipients []string `json:"recipients"` // email 类型的收件人
+	AttachCSV  bool     `json:"attach_csv"` // email 类型是否附带漏洞 CSV
+	Enabled    *bool    `json:"enabled"`    // 未提交时默认启用
+}
+
+// toSubscription 转换为订阅模型
+func (req *WebhookSubscriptionRequest) toSubscription() *orcmodel.WebhookSubscription {
+	sub := &orcmodel.WebhookSubscription{
+		Name:       req.Name,
+		Type:       req.Type,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		Recipients: req.Recipients,
+		AttachCSV:  req.AttachCSV,
+		Enabled:    true,
+	}
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+	return sub
+}
+
+// webhookErrorStatus 根据错误确定 HTTP 状态码
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orchestrator.ErrWebhookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orchestrator.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseWebhookID 解析路径中的订阅ID，失败时直接写入 400 响应
+func parseWebhookID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid webhook ID",
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateWebhook 创建 Webhook 订阅
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	sub := req.toSubscription()
+	sub.CreatedBy = uint64(userID)
+
+	if err := h.service.CreateSubscription(c.Request.Context(), sub); err != nil {
+		logger.LogBusinessError(err, c.Request.URL.String(), userID, "", "CreateWebhook", "HANDLER", nil)
+		status := webhookErrorStatus(err)
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to create webhook subscription",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, system.APIResponse{
+		Code:    http.StatusCreated,
+		Status:  "success",
+		Message: "Webhook subscription created successfully",
+		Data:    sub,
+	})
+}
+
+// GetWebhook 获取 Webhook 订阅详情
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		status := webhookErrorStatus(err)
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to get webhook subscription",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data:    sub,
+	})
+}
+
+// UpdateWebhook 更新 Webhook 订阅
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	sub := req.toSubscription()
+	sub.ID = id
+	if err := h.service.UpdateSubscription(c.Request.Context(), sub); err != nil {
+		logger.LogBusinessError(err, c.Request.URL.String(), c.GetUint("user_id"), "", "UpdateWebhook", "HANDLER", nil)
+		status := webhookErrorStatus(err)
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to update webhook subscription",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Webhook subscription updated successfully",
+		Data:    sub,
+	})
+}
+
+// DeleteWebhook 删除 Webhook 订阅
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+		logger.LogBusinessError(err, c.Request.URL.String(), c.GetUint("user_id"), "", "DeleteWebhook", "HANDLER", nil)
+		status := webhookErrorStatus(err)
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to delete webhook subscription",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Webhook subscription deleted successfully",
+	})
+}
+
+// ListWebhooks 获取 Webhook 订阅列表
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	subs, total, err := h.service.ListSubscriptions(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to list webhook subscriptions",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data: system.PaginationResponse{
+			Data:       subs,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: int(math.Ceil(float64(total) / float64(pageSize))),
+		},
+	})
+}
+
+// ListWebhookDeliveries 获取 Webhook 订阅的投递记录
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	deliveries, total, err := h.service.ListDeliveries(c.Request.Context(), id, page, pageSize)
+	if err != nil {
+		status := webhookErrorStatus(err)
+		c.JSON(status, system.APIResponse{
+			Code:    status,
+			Status:  "error",
+			Message: "Failed to list webhook deliveries",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Success",
+		Data: system.PaginationResponse{
+			Data:       deliveries,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: int(math.Ceil(float64(total) / float64(pageSize))),
+		},
+	})
+}
@@ -0,0 +1,258 @@
+/**
+ * 审计日志处理器
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 管理员查询与导出审计日志(需要 system:admin 权限)
+ * @func:
+ *   - ListAuditLogs 分页查询审计日志
+ *   - ExportAuditLogs 按条件导出审计日志 CSV(流式输出)
+ */
+package system
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+	authService "neomaster/internal/service/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAuditTimeRange 未指定时间范围时默认查询/导出最近 7 天，避免误导出全表
+const defaultAuditTimeRange = 7 * 24 * time.Hour
+
+// auditCSVHeader 导出文件表头
+var auditCSVHeader = []string{"id", "timestamp", "user_id", "username", "action", "resource", "result", "client_ip", "user_agent", "request_id", "details"}
+
+// AuditLogHandler 审计日志处理器
+type AuditLogHandler struct {
+	auditService *authService.AuditService
+	now          func() time.Time
+}
+
+// NewAuditLogHandler 创建审计日志处理器
+func NewAuditLogHandler(auditService *authService.AuditService) *AuditLogHandler {
+	return &AuditLogHandler{
+		auditService: auditService,
+		now:          time.Now,
+	}
+}
+
+// ListAuditLogs 分页查询审计日志
+// GET /api/v1/admin/audit-logs/list?page=1&limit=10&user_id=&action=&resource_prefix=&start_time=&end_time=
+// 时间参数支持 RFC3339 或 2006-01-02；均未指定时默认最近 7 天
+func (h *AuditLogHandler) ListAuditLogs(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	currentUserID := utils.GetCurrentUserIDFromGinContext(c)
+
+	filter, err := h.parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "invalid audit log filter",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, limit := parsePaginationParams(c)
+	logs, total, err := h.auditService.QueryAuditLogs(c.Request.Context(), filter, (page-1)*limit, limit)
+	if err != nil {
+		logger.LogBusinessError(err, XRequestID, currentUserID, clientIP, "list_audit_logs", "GET", map[string]interface{}{
+			"operation": "list_audit_logs",
+			"option":    "auditService.QueryAuditLogs",
+			"func_name": "handler.system.audit_log.ListAuditLogs",
+			"page":      page,
+			"limit":     limit,
+		})
+		c.JSON(http.StatusInternalServerError, system.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "failed to get audit log list",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "audit log list retrieved successfully",
+		Data: map[string]interface{}{
+			"items": logs,
+			"pagination": map[string]interface{}{
+				"page":  page,
+				"limit": limit,
+				"total": total,
+				"pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+// ExportAuditLogs 导出审计日志 CSV
+// GET /api/v1/admin/audit-logs/export?user_id=&action=&resource_prefix=&start_time=&end_time=
+// 分批读取并逐批写出，不在内存中缓存完整结果集；导出操作本身也记录审计日志
+func (h *AuditLogHandler) ExportAuditLogs(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	currentUserID := utils.GetCurrentUserIDFromGinContext(c)
+
+	filter, err := h.parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "invalid audit log filter",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("neoscan_audit_logs_%s.csv", h.now().Format("20060102_150405"))
+	writer := csv.NewWriter(c.Writer)
+	started := false
+	rows := 0
+	// 首批数据到达时才写响应头，查询在写出前失败仍可返回 JSON 错误
+	startStream := func() error {
+		started = true
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		return writer.Write(auditCSVHeader)
+	}
+
+	err = h.auditService.ExportAuditLogs(c.Request.Context(), filter, func(batch []*system.AuditLog) error {
+		if !started {
+			if err := startStream(); err != nil {
+				return err
+			}
+		}
+		for _, entry := range batch {
+			if err := writer.Write(auditCSVRecord(entry)); err != nil {
+				return err
+			}
+		}
+		rows += len(batch)
+		writer.Flush()
+		c.Writer.Flush()
+		return writer.Error()
+	})
+	if err == nil && !started {
+		// 没有匹配记录时仍返回只有表头的文件
+		err = startStream()
+	}
+	writer.Flush()
+
+	result := "success"
+	if err == nil {
+		err = writer.Error()
+	}
+	if err != nil {
+		result = "failed"
+		logger.LogBusinessError(err, XRequestID, currentUserID, clientIP, "export_audit_logs", "GET", map[string]interface{}{
+			"operation": "export_audit_logs",
+			"option":    "auditService.ExportAuditLogs",
+			"func_name": "handler.system.audit_log.ExportAuditLogs",
+			"rows":      rows,
+		})
+		if !started {
+			c.JSON(http.StatusInternalServerError, system.APIResponse{
+				Code:    http.StatusInternalServerError,
+				Status:  "error",
+				Message: "failed to export audit logs",
+			})
+		}
+	}
+
+	username, _ := c.Get("username")
+	usernameStr, _ := username.(string)
+	logger.LogAuditOperation(currentUserID, usernameStr, "audit_log.export", "audit_logs", result, clientIP, c.GetHeader("User-Agent"), XRequestID, map[string]interface{}{
+		"filename":        filename,
+		"rows":            rows,
+		"filter_user_id":  filter.UserID,
+		"filter_action":   filter.Action,
+		"resource_prefix": filter.ResourcePrefix,
+		"start_time":      filter.StartTime,
+		"end_time":        filter.EndTime,
+	})
+}
+
+// parseAuditFilter 解析查询参数为审计日志过滤条件
+// start_time/end_time 均未指定时默认最近 7 天；只指定 end_time 时取其前 7 天
+func (h *AuditLogHandler) parseAuditFilter(c *gin.Context) (system.AuditFilter, error) {
+	var filter system.AuditFilter
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid user_id: %s", v)
+		}
+		filter.UserID = uint(id)
+	}
+	filter.Action = strings.TrimSpace(c.Query("action"))
+	filter.ResourcePrefix = strings.TrimSpace(c.Query("resource_prefix"))
+
+	var err error
+	if filter.StartTime, err = parseAuditTime(c.Query("start_time")); err != nil {
+		return filter, fmt.Errorf("invalid start_time: %w", err)
+	}
+	if filter.EndTime, err = parseAuditTime(c.Query("end_time")); err != nil {
+		return filter, fmt.Errorf("invalid end_time: %w", err)
+	}
+
+	switch {
+	case filter.StartTime.IsZero() && filter.EndTime.IsZero():
+		filter.EndTime = h.now()
+		filter.StartTime = filter.EndTime.Add(-defaultAuditTimeRange)
+	case filter.StartTime.IsZero():
+		filter.StartTime = filter.EndTime.Add(-defaultAuditTimeRange)
+	}
+	if !filter.EndTime.IsZero() && !filter.StartTime.Before(filter.EndTime) {
+		return filter, errors.New("start_time must be before end_time")
+	}
+	return filter, nil
+}
+
+// parseAuditTime 解析时间参数，支持 RFC3339 与 2006-01-02(按本地时区零点)
+func parseAuditTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, time.Local)
+}
+
+// auditCSVRecord 审计日志转换为 CSV 行
+func auditCSVRecord(entry *system.AuditLog) []string {
+	details := ""
+	if len(entry.Details) > 0 {
+		if b, err := json.Marshal(entry.Details); err == nil {
+			details = string(b)
+		}
+	}
+	return []string{
+		strconv.FormatUint(entry.ID, 10),
+		entry.Timestamp.Format(time.RFC3339),
+		strconv.FormatUint(uint64(entry.UserID), 10),
+		utils.CSVSafe(entry.Username),
+		utils.CSVSafe(entry.Action),
+		utils.CSVSafe(entry.Resource),
+		utils.CSVSafe(entry.Result),
+		utils.CSVSafe(entry.ClientIP),
+		utils.CSVSafe(entry.UserAgent),
+		utils.CSVSafe(entry.RequestID),
+		utils.CSVSafe(details),
+	}
+}
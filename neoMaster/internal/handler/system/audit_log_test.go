@@ -0,0 +1,115 @@
+package system
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"neomaster/internal/model/system"
+	systemrepo "neomaster/internal/repo/mysql/system"
+	authService "neomaster/internal/service/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newAuditLogTestEngine(t *testing.T, now time.Time) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&system.AuditLog{}))
+
+	h := NewAuditLogHandler(authService.NewAuditService(systemrepo.NewAuditLogRepository(db), 0))
+	h.now = func() time.Time { return now }
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/audit-logs/list", h.ListAuditLogs)
+	engine.GET("/audit-logs/export", h.ExportAuditLogs)
+	return engine, db
+}
+
+func TestAuditLogHandler_Export(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	engine, db := newAuditLogTestEngine(t, now)
+	require.NoError(t, db.Create([]*system.AuditLog{
+		{UserID: 1, Username: "admin", Action: "user.delete", Resource: "user:7", Result: "success", Details: system.AuditDetailsJSON{"reason": "left"}, Timestamp: now.Add(-time.Hour)},
+		{UserID: 1, Username: "=cmd()", Action: "login", Resource: "session", Result: "failed", Timestamp: now.Add(-2 * time.Hour)},
+		{UserID: 2, Action: "login", Resource: "session", Timestamp: now.Add(-8 * 24 * time.Hour)}, // 超出默认时间范围
+	}).Error)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-logs/export", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="neoscan_audit_logs_20261016_120000.csv"`, w.Header().Get("Content-Disposition"))
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3) // 表头 + 最近7天内的2条
+	assert.Equal(t, auditCSVHeader, records[0])
+	assert.Equal(t, "user.delete", records[1][4])
+	assert.Equal(t, `{"reason":"left"}`, records[1][10])
+	// 以 = 开头的单元格按文本处理
+	assert.Equal(t, "'=cmd()", records[2][3])
+}
+
+func TestAuditLogHandler_ExportEmpty(t *testing.T) {
+	engine, _ := newAuditLogTestEngine(t, time.Now())
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-logs/export?action=login", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Join(auditCSVHeader, ",")+"\n", w.Body.String())
+}
+
+func TestAuditLogHandler_List(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	engine, db := newAuditLogTestEngine(t, now)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.Create(&system.AuditLog{UserID: 1, Action: "login", Resource: "session", Timestamp: now.Add(-time.Duration(i+1) * time.Hour)}).Error)
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-logs/list?page=2&limit=2&user_id=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			Items      []system.AuditLog `json:"items"`
+			Pagination struct {
+				Page  int   `json:"page"`
+				Limit int   `json:"limit"`
+				Total int64 `json:"total"`
+				Pages int64 `json:"pages"`
+			} `json:"pagination"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Data.Items, 1)
+	assert.Equal(t, 2, resp.Data.Pagination.Page)
+	assert.Equal(t, int64(3), resp.Data.Pagination.Total)
+	assert.Equal(t, int64(2), resp.Data.Pagination.Pages)
+}
+
+func TestAuditLogHandler_InvalidFilter(t *testing.T) {
+	engine, _ := newAuditLogTestEngine(t, time.Now())
+	for _, query := range []string{
+		"user_id=abc",
+		"start_time=yesterday",
+		"start_time=2026-10-16&end_time=2026-10-15",
+	} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-logs/export?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -789,15 +789,31 @@ func (h *UserHandler) UpdateUserByID(c *gin.Context) {
 
 	// 调用service层更新用户信息 - 核心业务逻辑
 	updatedUser, err := h.userService.UpdateUserByID(c.Request.Context(), uint(userID), &req)
+	h.respondUserUpdate(c, uint(userID), "update_user_by_id", "POST", updatedUser, err)
+}
+
+// respondUserUpdate 输出管理员更新用户 (POST 全量/PATCH 局部) 的结果，按错误类型映射状态码
+func (h *UserHandler) respondUserUpdate(c *gin.Context, userID uint, operation, method string, updatedUser *system.User, err error) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+
 	if err != nil {
 		// 根据错误类型返回不同的HTTP状态码
 		var statusCode int
 		var message string
 		errorMsg := err.Error()
 		switch {
+		case errors.Is(err, system.ErrConcurrentModification):
+			// 乐观锁冲突(用户已被其他请求修改)，返回409
+			logger.LogBusinessError(err, XRequestID, userID, clientIP, operation, method, map[string]interface{}{
+				"user_id": userID,
+				"error":   "concurrent_modification",
+			})
+			statusCode = http.StatusConflict
+			message = "user has been modified by another request, please reload and retry"
 		case strings.Contains(errorMsg, "user not found"):
 			// 用户不存在，返回404
-			logger.LogBusinessError(err, XRequestID, uint(userID), clientIP, "update_user_by_id", "POST", map[string]interface{}{
+			logger.LogBusinessError(err, XRequestID, userID, clientIP, operation, method, map[string]interface{}{
 				"user_id": userID,
 				"error":   "user_not_found",
 			})
@@ -805,7 +821,7 @@ func (h *UserHandler) UpdateUserByID(c *gin.Context) {
 			message = "user not found"
 		case strings.Contains(errorMsg, "email already exists"):
 			// 邮箱冲突，返回409
-			logger.LogBusinessError(err, XRequestID, uint(userID), clientIP, "update_user_by_id", "POST", map[string]interface{}{
+			logger.LogBusinessError(err, XRequestID, userID, clientIP, operation, method, map[string]interface{}{
 				"user_id": userID,
 				"error":   "email_conflict",
 			})
@@ -813,15 +829,23 @@ func (h *UserHandler) UpdateUserByID(c *gin.Context) {
 			message = "email already exists"
 		case strings.Contains(errorMsg, "username already exists"):
 			// 用户名冲突，返回409
-			logger.LogBusinessError(err, XRequestID, uint(userID), clientIP, "update_user_by_id", "POST", map[string]interface{}{
+			logger.LogBusinessError(err, XRequestID, userID, clientIP, operation, method, map[string]interface{}{
 				"user_id": userID,
 				"error":   "username_conflict",
 			})
 			statusCode = http.StatusConflict
 			message = "username already exists"
+		case strings.Contains(errorMsg, "不能为空"):
+			// 必填字段被清空，返回400
+			logger.LogBusinessError(err, XRequestID, userID, clientIP, operation, method, map[string]interface{}{
+				"user_id": userID,
+				"error":   "required_field_empty",
+			})
+			statusCode = http.StatusBadRequest
+			message = errorMsg
 		case strings.Contains(errorMsg, "role not found"):
 			// 角色不存在，返回409
-			logger.LogBusinessError(err, XRequestID, uint(userID), clientIP, "update_user_by_id", "POST", map[string]interface{}{
+			logger.LogBusinessError(err, XRequestID, userID, clientIP, operation, method, map[string]interface{}{
 				"user_id": userID,
 				"error":   "role_not_found",
 			})
@@ -829,7 +853,7 @@ func (h *UserHandler) UpdateUserByID(c *gin.Context) {
 			message = "role not found"
 		default:
 			// 其他错误，返回500
-			logger.LogBusinessError(err, XRequestID, uint(userID), clientIP, "update_user_by_id", "POST", map[string]interface{}{
+			logger.LogBusinessError(err, XRequestID, userID, clientIP, operation, method, map[string]interface{}{
 				"user_id": userID,
 				"error":   "update_failed",
 			})
@@ -837,9 +861,10 @@ func (h *UserHandler) UpdateUserByID(c *gin.Context) {
 			message = errorMsg // 其他错误返回原始错误信息
 		}
 		c.JSON(statusCode, system.APIResponse{
-			Code:    statusCode,
-			Status:  "failed",
-			Message: message,
+			Code:      statusCode,
+			Status:    "failed",
+			Message:   message,
+			ErrorCode: system.ErrorCodeOf(err),
 		})
 		return
 	}
@@ -856,10 +881,11 @@ func (h *UserHandler) UpdateUserByID(c *gin.Context) {
 		LastLoginAt: updatedUser.LastLoginAt,
 		CreatedAt:   updatedUser.CreatedAt,
 		Remark:      updatedUser.Remark,
+		LockVersion: updatedUser.LockVersion,
 	}
 
 	// 记录更新成功日志
-	logger.LogBusinessOperation("update_user_by_id", uint(userID), "", clientIP, XRequestID, "success", "用户信息更新成功", map[string]interface{}{
+	logger.LogBusinessOperation(operation, userID, "", clientIP, XRequestID, "success", "用户信息更新成功", map[string]interface{}{
 		"user_id":  userID,
 		"username": updatedUser.Username,
 		"email":    updatedUser.Email,
@@ -874,6 +900,44 @@ func (h *UserHandler) UpdateUserByID(c *gin.Context) {
 	})
 }
 
+// PatchUserByID 局部更新用户信息 (PATCH 语义) - 管理员专用
+// 未携带的字段不修改，携带空字符串的可选字段 (如 remark) 被清空；POST /:id 仍为原有更新方式
+func (h *UserHandler) PatchUserByID(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, "patch_user_by_id", "PATCH", map[string]interface{}{
+			"user_id_str": c.Param("id"),
+			"error":       "invalid_user_id_format",
+		})
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid user id format",
+		})
+		return
+	}
+
+	var req system.PatchUserRequest
+	if bindErr := c.ShouldBindJSON(&req); bindErr != nil {
+		logger.LogBusinessError(bindErr, XRequestID, uint(userID), clientIP, "patch_user_by_id", "PATCH", map[string]interface{}{
+			"user_id": userID,
+			"error":   "request_parse_failed",
+		})
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "invalid request format",
+		})
+		return
+	}
+
+	updatedUser, err := h.userService.PatchUserByID(c.Request.Context(), uint(userID), &req)
+	h.respondUserUpdate(c, uint(userID), "patch_user_by_id", "PATCH", updatedUser, err)
+}
+
 // UserUpdateInfoByID 用户专用更新信息方式，不允许携带角色调整【未完成】
 // 用户专用更新信息方式，不允许携带角色调整
 func (h *UserHandler) UserUpdateInfoByID(c *gin.Context) {
@@ -944,6 +1008,14 @@ func (h *UserHandler) UserUpdateInfoByID(c *gin.Context) {
 		var message string
 		errorMsg := err.Error()
 		switch {
+		case errors.Is(err, system.ErrConcurrentModification):
+			// 乐观锁冲突(用户已被其他请求修改)，返回409
+			logger.LogBusinessError(err, XRequestID, uint(userID), clientIP, "update_user_by_id", "POST", map[string]interface{}{
+				"user_id": userID,
+				"error":   "concurrent_modification",
+			})
+			statusCode = http.StatusConflict
+			message = "user has been modified by another request, please reload and retry"
 		case strings.Contains(errorMsg, "user not found"):
 			// 用户不存在，返回404
 			logger.LogBusinessError(err, XRequestID, uint(userID), clientIP, "update_user_by_id", "POST", map[string]interface{}{
@@ -978,9 +1050,10 @@ func (h *UserHandler) UserUpdateInfoByID(c *gin.Context) {
 			message = errorMsg // 其他错误返回原始错误信息
 		}
 		c.JSON(statusCode, system.APIResponse{
-			Code:    statusCode,
-			Status:  "failed",
-			Message: message,
+			Code:      statusCode,
+			Status:    "failed",
+			Message:   message,
+			ErrorCode: system.ErrorCodeOf(err),
 		})
 		return
 	}
@@ -997,6 +1070,7 @@ func (h *UserHandler) UserUpdateInfoByID(c *gin.Context) {
 		LastLoginAt: updatedUser.LastLoginAt,
 		CreatedAt:   updatedUser.CreatedAt,
 		Remark:      updatedUser.Remark,
+		LockVersion: updatedUser.LockVersion,
 	}
 
 	// 记录更新成功日志
@@ -2,10 +2,12 @@ package tag_system
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"neomaster/internal/model/system"
 	"neomaster/internal/model/tag_system"
@@ -754,3 +756,148 @@ func (h *TagHandler) ApplyRule(c *gin.Context) {
 		},
 	})
 }
+
+// TestRule 用样例数据测试匹配规则 (供规则编辑器在启用前校验)
+// 请求体 rule_id 与 rule_json 二选一；规则无法解析或评估出错时返回 400 并给出具体原因
+func (h *TagHandler) TestRule(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	userAgent := c.GetHeader("User-Agent")
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+
+	var req tag_system.TestRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":  "test_rule",
+			"error":      "invalid_json",
+			"user_agent": userAgent,
+		})
+		c.JSON(http.StatusBadRequest, system.APIResponse{
+			Code:    http.StatusBadRequest,
+			Status:  "failed",
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service.TestRule(c.Request.Context(), &req)
+	if err != nil {
+		statusCode, message := http.StatusBadRequest, "Invalid test request"
+		switch {
+		case errors.Is(err, service.ErrInvalidMatchRule):
+			message = "Invalid match rule"
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			statusCode, message = http.StatusNotFound, "Rule or tag not found"
+		}
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation": "test_rule",
+			"rule_id":   req.RuleID,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Rule tested successfully",
+		Data:    result,
+	})
+}
+
+// StartRetag 后台启动某类型实体的批量重打标 (规则变更后对存量实体重新评估)
+// 上一轮被取消或出错时从中断处续跑
+func (h *TagHandler) StartRetag(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	pathUrl := c.Request.URL.String()
+	entityType := c.Param("entity_type")
+
+	progress, err := h.service.StartRetag(entityType)
+	if err != nil {
+		statusCode, message := http.StatusInternalServerError, "Failed to start retag"
+		switch {
+		case errors.Is(err, service.ErrRetagUnsupportedEntity):
+			statusCode, message = http.StatusBadRequest, "Unsupported entity type"
+		case errors.Is(err, service.ErrRetagRunning):
+			statusCode, message = http.StatusConflict, "Retag is already running"
+		}
+		logger.LogBusinessError(err, XRequestID, 0, clientIP, pathUrl, "POST", map[string]interface{}{
+			"operation":   "start_retag",
+			"entity_type": entityType,
+		})
+		c.JSON(statusCode, system.APIResponse{
+			Code:    statusCode,
+			Status:  "failed",
+			Message: message,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.LogBusinessOperation("start_retag", 0, "", clientIP, XRequestID, "success", "Retag started", map[string]interface{}{
+		"entity_type": entityType,
+		"cursor":      progress.Cursor,
+	})
+
+	c.JSON(http.StatusAccepted, system.APIResponse{
+		Code:    http.StatusAccepted,
+		Status:  "success",
+		Message: "Retag started",
+		Data:    progress,
+	})
+}
+
+// GetRetagProgress 获取批量重打标进度
+func (h *TagHandler) GetRetagProgress(c *gin.Context) {
+	entityType := c.Param("entity_type")
+
+	progress, ok := h.service.GetRetagProgress(entityType)
+	if !ok {
+		c.JSON(http.StatusNotFound, system.APIResponse{
+			Code:    http.StatusNotFound,
+			Status:  "failed",
+			Message: "Retag has never run for this entity type",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Retag progress retrieved successfully",
+		Data:    progress,
+	})
+}
+
+// CancelRetag 取消正在执行的批量重打标，已处理的进度保留
+func (h *TagHandler) CancelRetag(c *gin.Context) {
+	clientIP := utils.GetClientIP(c)
+	XRequestID := c.GetHeader("X-Request-ID")
+	entityType := c.Param("entity_type")
+
+	if !h.service.CancelRetag(entityType) {
+		c.JSON(http.StatusConflict, system.APIResponse{
+			Code:    http.StatusConflict,
+			Status:  "failed",
+			Message: "Retag is not running",
+		})
+		return
+	}
+
+	logger.LogBusinessOperation("cancel_retag", 0, "", clientIP, XRequestID, "success", "Retag cancelled", map[string]interface{}{
+		"entity_type": entityType,
+	})
+
+	c.JSON(http.StatusOK, system.APIResponse{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Retag cancelled",
+	})
+}
@@ -9,10 +9,16 @@ package agent
 
 import (
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"neomaster/internal/model/basemodel"
 	"neomaster/internal/pkg/utils"
+
+	"gorm.io/gorm"
 )
 
 // ============================================================================
@@ -132,8 +138,23 @@ const (
 	AgentTaskStatusRunning   AgentTaskStatus = "running"   // 运行中
 	AgentTaskStatusCompleted AgentTaskStatus = "completed" // 已完成
 	AgentTaskStatusFailed    AgentTaskStatus = "failed"    // 已失败
+	AgentTaskStatusCanceling AgentTaskStatus = "canceling" // 取消中 (Agent 收到后中止执行并上报 canceled)
+	AgentTaskStatusCanceled  AgentTaskStatus = "canceled"  // 已取消
+)
+
+// Agent 心跳默认配置 (Agent 未单独配置时使用)
+const (
+	DefaultHeartbeatInterval  = 30 * time.Second // 默认心跳间隔，与 Agent 端默认值一致
+	DefaultOfflineMissedBeats = 3                // 默认连续丢失多少次心跳判定为离线
 )
 
+// RunningTaskProgress Agent 运行中任务的进度 (随心跳上报)
+type RunningTaskProgress struct {
+	TaskID        string  `json:"task_id"`        // 任务ID
+	Percent       float64 `json:"percent"`        // 进度百分比 (0-100)
+	CurrentTarget string  `json:"current_target"` // 当前正在扫描的目标
+}
+
 // ============================================================================
 // 核心实体：Agent - 相对静态，注册时确定
 // ============================================================================
@@ -170,10 +191,23 @@ type Agent struct {
 	ResultLatestTime *time.Time `json:"result_latest_time" gorm:"comment:最新返回结果时间"`
 	LastHeartbeat    time.Time  `json:"last_heartbeat" gorm:"comment:最后心跳时间"`
 
+	// 心跳配置：链路较慢的 Agent 可调大心跳间隔，避免被过早判定离线；0 表示使用默认值
+	HeartbeatInterval int `json:"heartbeat_interval" gorm:"default:0;comment:心跳间隔(秒)，0使用默认值"`
+	OfflineThreshold  int `json:"offline_threshold" gorm:"default:0;comment:离线阈值(秒)，超过该时长无心跳标记为离线，0使用默认值(心跳间隔的3倍)"`
+
+	// 运行中任务进度：由心跳上报，与 last_heartbeat 同一条 UPDATE 整体覆盖写入
+	RunningTasks []RunningTaskProgress `json:"running_tasks" gorm:"serializer:json;type:json;comment:运行中任务进度(心跳上报)"`
+
 	// 扩展字段
 	Remark      string `json:"remark" gorm:"size:500;comment:备注信息"`
 	ContainerID string `json:"container_id" gorm:"size:100;comment:容器ID"`
 	PID         int    `json:"pid" gorm:"column:pid;comment:进程ID"`
+
+	// 乐观锁版本号：每次 Update 递增，防止并发修改互相覆盖 (version 列已用作 Agent 软件版本号)
+	LockVersion int64 `json:"lock_version" gorm:"not null;default:1;comment:乐观锁版本号"`
+
+	// 软删除：删除后保留历史记录，常规查询自动排除
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:软删除时间"`
 }
 
 // TableName 定义表名
@@ -181,6 +215,13 @@ func (Agent) TableName() string {
 	return "agents"
 }
 
+// agents 表全文索引 (由 cmd/migrate 创建，MySQL 专用)
+// MATCH() 中的列必须与索引定义完全一致，否则 MySQL 报错
+const (
+	AgentSearchIndex        = "ft_agents_search"
+	AgentSearchIndexColumns = "agent_id, hostname, remark, os, arch, version"
+)
+
 // ============================================================================
 // Agent 状态管理方法
 // ============================================================================
@@ -209,9 +250,31 @@ func (a *Agent) GetStatus() AgentStatus {
 	return a.Status
 }
 
+// EffectiveHeartbeatInterval 获取Agent实际使用的心跳间隔，未配置时使用默认值
+func (a *Agent) EffectiveHeartbeatInterval() time.Duration {
+	if a.HeartbeatInterval > 0 {
+		return time.Duration(a.HeartbeatInterval) * time.Second
+	}
+	return DefaultHeartbeatInterval
+}
+
+// EffectiveOfflineThreshold 获取Agent实际使用的离线阈值
+// 未配置时为心跳间隔的 DefaultOfflineMissedBeats 倍，调大心跳间隔的 Agent 阈值随之放宽
+func (a *Agent) EffectiveOfflineThreshold() time.Duration {
+	if a.OfflineThreshold > 0 {
+		return time.Duration(a.OfflineThreshold) * time.Second
+	}
+	return a.EffectiveHeartbeatInterval() * DefaultOfflineMissedBeats
+}
+
+// IsHeartbeatStale 判断在 now 时刻Agent心跳是否已超过离线阈值
+func (a *Agent) IsHeartbeatStale(now time.Time) bool {
+	return now.Sub(a.LastHeartbeat) > a.EffectiveOfflineThreshold()
+}
+
 // IsOnline 判断Agent是否在线（基于状态和心跳时间）
 func (a *Agent) IsOnline() bool {
-	return a.Status == AgentStatusOnline && time.Since(a.LastHeartbeat) < 5*time.Minute
+	return a.Status == AgentStatusOnline && !a.IsHeartbeatStale(time.Now())
 }
 
 // UpdateHeartbeat 更新心跳时间
@@ -286,7 +349,7 @@ type AgentVersion struct {
 	// 引用基类 (ID, CreatedAt, UpdatedAt)
 	basemodel.BaseModel
 
-	Version     string    `json:"version" gorm:"not null;size:50;comment:版本号"`
+	Version     string    `json:"version" gorm:"not null;size:50;uniqueIndex;comment:版本号"`
 	ReleaseDate time.Time `json:"release_date" gorm:"comment:发布日期"`
 	Changelog   string    `json:"changelog" gorm:"type:text;comment:版本更新日志"`
 	DownloadURL string    `json:"download_url" gorm:"size:500;comment:下载地址"`
@@ -325,12 +388,16 @@ type AgentConfig struct {
 	HeartbeatInterval   int                    `json:"heartbeat_interval" gorm:"default:30;comment:心跳间隔(秒)"`
 	TaskPollInterval    int                    `json:"task_poll_interval" gorm:"default:10;comment:任务轮询间隔(秒)"`
 	MaxConcurrentTasks  int                    `json:"max_concurrent_tasks" gorm:"default:5;comment:最大并发任务数"`
-	PluginConfig        map[string]interface{} `json:"plugin_config" gorm:"type:json;comment:插件配置信息"`
+	PluginConfig        map[string]interface{} `json:"plugin_config" gorm:"serializer:json;type:json;comment:插件配置信息"`
 	LogLevel            string                 `json:"log_level" gorm:"default:info;size:20;comment:日志级别"`
 	Timeout             int                    `json:"timeout" gorm:"default:300;comment:超时时间(秒)"`
 	TokenExpiryDuration int                    `json:"token_expiry_duration" gorm:"default:86400;comment:Token过期时间(秒)"`
 	TokenNeverExpire    bool                   `json:"token_never_expire" gorm:"default:false;comment:Token是否永不过期"`
 	IsActive            bool                   `json:"is_active" gorm:"default:true;comment:是否激活"`
+
+	// 各来源写入的值：键为配置项名 (插件配置为 plugin_config.<插件名>)，按写入先后排列，最后一项为生效值；
+	// 未记录的配置项来自默认值。某一来源撤销 (如Agent退出分组) 时由剩余来源重新计算生效值
+	Sources map[string][]ConfigSourceValue `json:"sources,omitempty" gorm:"serializer:json;type:json;comment:配置项各来源写入的值(default/group/agent)"`
 }
 
 // TableName 定义表名
@@ -344,6 +411,33 @@ func (ac *AgentConfig) IsActiveConfig() bool {
 	return ac.IsActive
 }
 
+// DefaultAgentConfig 获取Agent的默认配置 (尚未下发过配置时使用)
+// Version 为 0 表示 Master 从未下发过配置，Agent 使用本地配置运行
+func DefaultAgentConfig(agentID string) *AgentConfig {
+	return &AgentConfig{
+		AgentID:             agentID,
+		Version:             0,
+		HeartbeatInterval:   int(DefaultHeartbeatInterval / time.Second),
+		TaskPollInterval:    10,
+		MaxConcurrentTasks:  5,
+		LogLevel:            "info",
+		Timeout:             300,
+		TokenExpiryDuration: 86400,
+		IsActive:            true,
+	}
+}
+
+// DefaultAgentConfigFor 以默认配置为基础生成指定Agent的配置
+// Agent 单独设置过心跳间隔时 (agents.heartbeat_interval) 以其为准，来源记为 agent
+func DefaultAgentConfigFor(agent *Agent) *AgentConfig {
+	cfg := DefaultAgentConfig(agent.AgentID)
+	if agent.HeartbeatInterval > 0 {
+		cfg.HeartbeatInterval = agent.HeartbeatInterval
+		cfg.SetSource(ConfigKeyHeartbeatInterval, ConfigFieldSource{Source: ConfigSourceAgent}, agent.HeartbeatInterval)
+	}
+	return cfg
+}
+
 // IncrementVersion 增加配置版本号
 // AgentConfig 结构体的方法 - 增加配置版本号并更新时间
 func (ac *AgentConfig) IncrementVersion() {
@@ -351,6 +445,336 @@ func (ac *AgentConfig) IncrementVersion() {
 	ac.UpdatedAt = time.Now()
 }
 
+// 配置项来源层
+const (
+	ConfigSourceDefault = "default" // 默认值
+	ConfigSourceGroup   = "group"   // 分组配置 (AgentGroupConfig)
+	ConfigSourceAgent   = "agent"   // Agent单独配置
+)
+
+// 配置项名称，与 AgentConfigResponse 的 JSON 字段名一致
+const (
+	ConfigKeyHeartbeatInterval   = "heartbeat_interval"
+	ConfigKeyTaskPollInterval    = "task_poll_interval"
+	ConfigKeyMaxConcurrentTasks  = "max_concurrent_tasks"
+	ConfigKeyLogLevel            = "log_level"
+	ConfigKeyTimeout             = "timeout"
+	ConfigKeyTokenExpiryDuration = "token_expiry_duration"
+	ConfigKeyTokenNeverExpire    = "token_never_expire"
+	ConfigKeyPluginPrefix        = "plugin_config." // 插件配置按插件名(顶层键)记录来源
+)
+
+// ConfigFieldSource 配置项来源
+type ConfigFieldSource struct {
+	Source  string `json:"source"`             // 来源层：default/group/agent
+	GroupID uint64 `json:"group_id,omitempty"` // 来源为 group 时的分组标签ID
+}
+
+// ConfigSourceValue 某一来源为配置项写入的值
+type ConfigSourceValue struct {
+	ConfigFieldSource
+	Value interface{} `json:"value"`
+}
+
+// SetSource 记录来源为配置项写入的值，该来源成为配置项的生效来源 (同一来源之前写入的值被替换)
+// 只记录来源，不修改配置字段本身
+func (ac *AgentConfig) SetSource(key string, source ConfigFieldSource, value interface{}) {
+	if ac.Sources == nil {
+		ac.Sources = make(map[string][]ConfigSourceValue)
+	}
+	values := ac.Sources[key][:0:0]
+	for _, v := range ac.Sources[key] {
+		if v.ConfigFieldSource != source {
+			values = append(values, v)
+		}
+	}
+	ac.Sources[key] = append(values, ConfigSourceValue{ConfigFieldSource: source, Value: value})
+}
+
+// SourceOf 获取配置项的生效来源，未记录时为默认值
+func (ac *AgentConfig) SourceOf(key string) ConfigFieldSource {
+	if values := ac.Sources[key]; len(values) > 0 {
+		return values[len(values)-1].ConfigFieldSource
+	}
+	return ConfigFieldSource{Source: ConfigSourceDefault}
+}
+
+// RemoveSource 撤销某一来源写入的全部值，受影响的配置项回退到剩余来源中最近写入的值，没有剩余来源时回退到默认值
+// 返回配置是否发生变化 (含来源记录)
+func (ac *AgentConfig) RemoveSource(source ConfigFieldSource) (bool, error) {
+	defaults := DefaultAgentConfig(ac.AgentID).FieldValues()
+	changed := false
+	for key, values := range ac.Sources {
+		kept := values[:0:0]
+		for _, v := range values {
+			if v.ConfigFieldSource != source {
+				kept = append(kept, v)
+			}
+		}
+		if len(kept) == len(values) {
+			continue
+		}
+		changed = true
+		if len(kept) == 0 {
+			delete(ac.Sources, key)
+			if err := ac.setFieldValue(key, defaults[key]); err != nil {
+				return false, err
+			}
+			continue
+		}
+		ac.Sources[key] = kept
+		if err := ac.setFieldValue(key, kept[len(kept)-1].Value); err != nil {
+			return false, err
+		}
+	}
+	return changed, nil
+}
+
+// setFieldValue 按配置项名写入配置值
+// 值可能来自 JSON 反序列化 (数字为 float64)，经 JSON 转换为字段类型；插件配置值为 nil 时删除该插件配置
+func (ac *AgentConfig) setFieldValue(key string, value interface{}) error {
+	if strings.HasPrefix(key, ConfigKeyPluginPrefix) {
+		name := strings.TrimPrefix(key, ConfigKeyPluginPrefix)
+		plugins := make(map[string]interface{}, len(ac.PluginConfig)+1)
+		for k, v := range ac.PluginConfig {
+			plugins[k] = v
+		}
+		if value == nil {
+			delete(plugins, name)
+		} else {
+			plugins[name] = value
+		}
+		ac.PluginConfig = plugins
+		return nil
+	}
+
+	var field interface{}
+	switch key {
+	case ConfigKeyHeartbeatInterval:
+		field = &ac.HeartbeatInterval
+	case ConfigKeyTaskPollInterval:
+		field = &ac.TaskPollInterval
+	case ConfigKeyMaxConcurrentTasks:
+		field = &ac.MaxConcurrentTasks
+	case ConfigKeyLogLevel:
+		field = &ac.LogLevel
+	case ConfigKeyTimeout:
+		field = &ac.Timeout
+	case ConfigKeyTokenExpiryDuration:
+		field = &ac.TokenExpiryDuration
+	case ConfigKeyTokenNeverExpire:
+		field = &ac.TokenNeverExpire
+	default:
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, field)
+}
+
+// FieldValues 按配置项名展开配置值，插件配置按插件名拆分为 plugin_config.<插件名>
+func (ac *AgentConfig) FieldValues() map[string]interface{} {
+	values := map[string]interface{}{
+		ConfigKeyHeartbeatInterval:   ac.HeartbeatInterval,
+		ConfigKeyTaskPollInterval:    ac.TaskPollInterval,
+		ConfigKeyMaxConcurrentTasks:  ac.MaxConcurrentTasks,
+		ConfigKeyLogLevel:            ac.LogLevel,
+		ConfigKeyTimeout:             ac.Timeout,
+		ConfigKeyTokenExpiryDuration: ac.TokenExpiryDuration,
+		ConfigKeyTokenNeverExpire:    ac.TokenNeverExpire,
+	}
+	for name, v := range ac.PluginConfig {
+		values[ConfigKeyPluginPrefix+name] = v
+	}
+	return values
+}
+
+// MarkChanged 将相对 before 发生变化的配置项记为 source 写入的值
+// before 为修改前的 FieldValues；已不存在的插件配置同时清除其来源记录
+func (ac *AgentConfig) MarkChanged(before map[string]interface{}, source ConfigFieldSource) {
+	after := ac.FieldValues()
+	for key, v := range after {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, v) {
+			ac.SetSource(key, source, v)
+		}
+	}
+	for key := range ac.Sources {
+		if _, ok := after[key]; !ok {
+			delete(ac.Sources, key)
+		}
+	}
+}
+
+// AgentConfigPatch Agent配置局部更新 (用于分组批量下发)
+// 字段为 nil 表示不修改；PluginConfig 按顶层键合并，未出现的键保持原值
+type AgentConfigPatch struct {
+	HeartbeatInterval   *int                   `json:"heartbeat_interval,omitempty"`
+	TaskPollInterval    *int                   `json:"task_poll_interval,omitempty"`
+	MaxConcurrentTasks  *int                   `json:"max_concurrent_tasks,omitempty"`
+	PluginConfig        map[string]interface{} `json:"plugin_config,omitempty"`
+	LogLevel            *string                `json:"log_level,omitempty"`
+	Timeout             *int                   `json:"timeout,omitempty"`
+	TokenExpiryDuration *int                   `json:"token_expiry_duration,omitempty"`
+	TokenNeverExpire    *bool                  `json:"token_never_expire,omitempty"`
+}
+
+// IsEmpty 判断补丁是否没有设置任何字段
+func (p *AgentConfigPatch) IsEmpty() bool {
+	return p.HeartbeatInterval == nil && p.TaskPollInterval == nil && p.MaxConcurrentTasks == nil &&
+		len(p.PluginConfig) == 0 && p.LogLevel == nil && p.Timeout == nil &&
+		p.TokenExpiryDuration == nil && p.TokenNeverExpire == nil
+}
+
+// ApplyTo 将补丁中已设置的字段写入配置 (不修改版本号)
+func (p *AgentConfigPatch) ApplyTo(cfg *AgentConfig) {
+	if p.HeartbeatInterval != nil {
+		cfg.HeartbeatInterval = *p.HeartbeatInterval
+	}
+	if p.TaskPollInterval != nil {
+		cfg.TaskPollInterval = *p.TaskPollInterval
+	}
+	if p.MaxConcurrentTasks != nil {
+		cfg.MaxConcurrentTasks = *p.MaxConcurrentTasks
+	}
+	if len(p.PluginConfig) > 0 {
+		merged := make(map[string]interface{}, len(cfg.PluginConfig)+len(p.PluginConfig))
+		for k, v := range cfg.PluginConfig {
+			merged[k] = v
+		}
+		for k, v := range p.PluginConfig {
+			merged[k] = v
+		}
+		cfg.PluginConfig = merged
+	}
+	if p.LogLevel != nil {
+		cfg.LogLevel = *p.LogLevel
+	}
+	if p.Timeout != nil {
+		cfg.Timeout = *p.Timeout
+	}
+	if p.TokenExpiryDuration != nil {
+		cfg.TokenExpiryDuration = *p.TokenExpiryDuration
+	}
+	if p.TokenNeverExpire != nil {
+		cfg.TokenNeverExpire = *p.TokenNeverExpire
+	}
+}
+
+// ApplyFromGroup 将分组补丁写入配置，补丁中设置的配置项记为该分组写入的值
+// 与单独配置不同，分组写入的配置项即使值未变化也记为分组来源 (按字段后写入者生效)
+func (p *AgentConfigPatch) ApplyFromGroup(cfg *AgentConfig, groupTagID uint64) {
+	p.ApplyTo(cfg)
+	source := ConfigFieldSource{Source: ConfigSourceGroup, GroupID: groupTagID}
+	values := cfg.FieldValues()
+	for _, key := range p.FieldKeys() {
+		cfg.SetSource(key, source, values[key])
+	}
+}
+
+// FieldKeys 返回补丁中已设置的配置项名
+func (p *AgentConfigPatch) FieldKeys() []string {
+	var keys []string
+	set := map[string]bool{
+		ConfigKeyHeartbeatInterval:   p.HeartbeatInterval != nil,
+		ConfigKeyTaskPollInterval:    p.TaskPollInterval != nil,
+		ConfigKeyMaxConcurrentTasks:  p.MaxConcurrentTasks != nil,
+		ConfigKeyLogLevel:            p.LogLevel != nil,
+		ConfigKeyTimeout:             p.Timeout != nil,
+		ConfigKeyTokenExpiryDuration: p.TokenExpiryDuration != nil,
+		ConfigKeyTokenNeverExpire:    p.TokenNeverExpire != nil,
+	}
+	for key, ok := range set {
+		if ok {
+			keys = append(keys, key)
+		}
+	}
+	for name := range p.PluginConfig {
+		keys = append(keys, ConfigKeyPluginPrefix+name)
+	}
+	return keys
+}
+
+// Merge 将另一个补丁合并进来，other 中已设置的字段覆盖当前值
+func (p *AgentConfigPatch) Merge(other *AgentConfigPatch) {
+	if other.HeartbeatInterval != nil {
+		p.HeartbeatInterval = other.HeartbeatInterval
+	}
+	if other.TaskPollInterval != nil {
+		p.TaskPollInterval = other.TaskPollInterval
+	}
+	if other.MaxConcurrentTasks != nil {
+		p.MaxConcurrentTasks = other.MaxConcurrentTasks
+	}
+	if len(other.PluginConfig) > 0 {
+		if p.PluginConfig == nil {
+			p.PluginConfig = make(map[string]interface{}, len(other.PluginConfig))
+		}
+		for k, v := range other.PluginConfig {
+			p.PluginConfig[k] = v
+		}
+	}
+	if other.LogLevel != nil {
+		p.LogLevel = other.LogLevel
+	}
+	if other.Timeout != nil {
+		p.Timeout = other.Timeout
+	}
+	if other.TokenExpiryDuration != nil {
+		p.TokenExpiryDuration = other.TokenExpiryDuration
+	}
+	if other.TokenNeverExpire != nil {
+		p.TokenNeverExpire = other.TokenNeverExpire
+	}
+}
+
+// ============================================================================
+// 相关实体：AgentGroupConfig
+// ============================================================================
+
+// AgentGroupConfig Agent分组配置
+// 分组即 Category 为 agent_group 的标签，这里保存该分组累计下发的配置补丁，
+// 之后加入分组的Agent据此继承分组配置
+type AgentGroupConfig struct {
+	// 引用基类 (ID, CreatedAt, UpdatedAt)
+	basemodel.BaseModel
+
+	GroupTagID uint64           `json:"group_tag_id" gorm:"uniqueIndex;not null;comment:分组标签ID(sys_tags.id)"`
+	Patch      AgentConfigPatch `json:"patch" gorm:"serializer:json;type:json;comment:分组配置补丁(仅包含已设置字段)"`
+	Version    int              `json:"version" gorm:"default:1;comment:分组配置版本号，每次批量更新 +1"`
+}
+
+// TableName 定义表名
+func (AgentGroupConfig) TableName() string {
+	return "agent_group_configs"
+}
+
+// ============================================================================
+// 相关实体：AgentEnrollmentToken
+// ============================================================================
+
+// AgentEnrollmentToken Agent接入令牌
+// 管理员为每次部署签发的一次性接入密钥，库中只保存 SHA-256 哈希；
+// 令牌在过期前只能使用一次，使用后记录使用时间与接入的 Agent
+type AgentEnrollmentToken struct {
+	// 引用基类 (ID, CreatedAt, UpdatedAt)
+	basemodel.BaseModel
+
+	TokenPrefix string     `json:"token_prefix" gorm:"size:16;not null;comment:令牌前缀(用于识别)"`   // 令牌前若干位，列表与日志中用于识别令牌
+	TokenHash   string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:令牌SHA-256哈希"` // 令牌哈希，不在JSON中返回
+	Remark      string     `json:"remark" gorm:"size:255;comment:备注"`                         // 备注，如部署目标
+	CreatedBy   uint       `json:"created_by" gorm:"comment:签发人用户ID"`                         // 签发人用户ID
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null;index;comment:过期时间"`             // 过期时间，过期后不能再使用
+	UsedAt      *time.Time `json:"used_at" gorm:"comment:使用时间"`                               // 使用时间，为空表示尚未使用
+	UsedBy      string     `json:"used_by" gorm:"size:300;comment:使用者(hostname:port)"`        // 使用该令牌接入的 Agent
+}
+
+// TableName 定义表名
+func (AgentEnrollmentToken) TableName() string {
+	return "agent_enrollment_tokens"
+}
+
 // ============================================================================
 // 相关实体：AgentMetrics
 // ============================================================================
@@ -428,7 +852,7 @@ type ScanType struct {
 	// ID字段作为主键和业务标识，统一使用BaseModel.ID(uint64)
 	basemodel.BaseModel
 
-	Name           string             `json:"name" gorm:"not null;size:100;comment:扫描类型名称"`
+	Name           string             `json:"name" gorm:"not null;size:100;uniqueIndex;comment:扫描类型名称"`
 	DisplayName    string             `json:"display_name" gorm:"not null;size:100;comment:扫描类型显示名称"`
 	Description    string             `json:"description" gorm:"size:500;comment:扫描类型描述"`
 	Category       string             `json:"category" gorm:"size:50;comment:扫描类型分类"`
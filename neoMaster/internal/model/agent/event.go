@@ -0,0 +1,26 @@
+/**
+ * 模型:Agent实时事件
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 推送给前端看板的 Agent 状态/性能指标变化事件
+ */
+package agent
+
+import "time"
+
+// AgentEventType 事件类型
+type AgentEventType string
+
+const (
+	AgentEventStatus  AgentEventType = "status"  // 状态变化(心跳、手动更新状态)
+	AgentEventMetrics AgentEventType = "metrics" // 性能指标更新
+)
+
+// AgentEvent Agent实时事件
+type AgentEvent struct {
+	Type      AgentEventType `json:"type"`              // 事件类型
+	AgentID   string         `json:"agent_id"`          // Agent唯一标识ID
+	Status    AgentStatus    `json:"status,omitempty"`  // 最新状态(status事件)
+	Metrics   *AgentMetrics  `json:"metrics,omitempty"` // 最新性能指标(metrics事件)
+	Timestamp time.Time      `json:"timestamp"`         // 事件产生时间
+}
@@ -0,0 +1,20 @@
+/**
+ * 模型:Agent性能指标导出样本
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: Prometheus 抓取时按在线Agent批量读取的最新快照，仅包含导出的字段
+ */
+package agent
+
+import "time"
+
+// AgentMetricsSample 在线Agent的最新性能快照
+// agents LEFT JOIN agent_metrics 的结果，没有快照的Agent指标字段为 nil
+type AgentMetricsSample struct {
+	AgentID      string     `gorm:"column:agent_id"`
+	CPUUsage     *float64   `gorm:"column:cpu_usage"`     // CPU使用率(百分比)
+	MemoryUsage  *float64   `gorm:"column:memory_usage"`  // 内存使用率(百分比)
+	RunningTasks *int       `gorm:"column:running_tasks"` // 正在运行的任务数
+	FailedTasks  *int       `gorm:"column:failed_tasks"`  // 失败任务数
+	Timestamp    *time.Time `gorm:"column:timestamp"`     // 快照时间
+}
@@ -54,6 +54,24 @@ type RegisterAgentRequest struct {
 //     "remark": "测试注册功能数据"
 // }
 
+// EnrollRequest Agent接入请求结构
+// Agent 首次接入时出示管理员签发的一次性接入令牌换取 Token，之后的请求只携带 Token
+type EnrollRequest struct {
+	Hostname         string `json:"hostname" validate:"required"`             // 主机名，必填
+	IPAddress        string `json:"ip_address"`                               // IP地址
+	Port             int    `json:"port" validate:"required,min=1,max=65535"` // 端口，必填，与主机名一起标识Agent
+	Version          string `json:"version"`                                  // Agent版本
+	OS               string `json:"os"`                                       // 操作系统
+	Arch             string `json:"arch"`                                     // 系统架构
+	EnrollmentSecret string `json:"enrollment_secret" validate:"required"`    // 接入令牌(nse_ 前缀)，必填
+}
+
+// CreateEnrollmentTokenRequest 签发Agent接入令牌请求结构
+type CreateEnrollmentTokenRequest struct {
+	TTL    int    `json:"ttl" validate:"min=0"`      // 有效期(秒)，0 使用默认值 24 小时
+	Remark string `json:"remark" validate:"max=255"` // 备注，如部署目标
+}
+
 // HeartbeatRequest Agent心跳请求结构
 // 遵循"好品味"原则：心跳状态信息和性能指标数据完全分离
 // 心跳请求只负责传递心跳状态和性能指标数据
@@ -64,6 +82,14 @@ type HeartbeatRequest struct {
 
 	// 性能指标数据 - 可选，用于存储到agent_metrics表
 	Metrics *AgentMetrics `json:"metrics,omitempty"` // 性能指标数据，可选
+
+	// 运行中任务进度 - 可选，覆盖写入agents表的running_tasks字段
+	// 未携带该字段 (旧版本Agent) 时保留原值；携带空数组表示当前没有运行中任务
+	RunningTasks []RunningTaskProgress `json:"running_tasks,omitempty"`
+
+	// 当前已应用的配置版本 - 可选，与 Master 端版本不一致时心跳响应中携带最新配置
+	// 未携带该字段 (旧版本Agent) 时不做配置对账
+	ConfigVersion *int `json:"config_version,omitempty"`
 }
 
 // GetAgentListRequest 获取Agent列表请求结构
@@ -73,9 +99,11 @@ type GetAgentListRequest struct {
 	PageSize    int         `json:"page_size" validate:"min=1,max=100"` // 每页大小，1-100
 	Status      AgentStatus `json:"status"`                             // 按状态过滤，可选
 	ScanType    string      `json:"scan_type"`                          // 按扫描类型过滤，可选
-	Keyword     string      `json:"keyword"`                            // 关键词搜索(主机名、IP地址)，可选
+	Keyword     string      `json:"keyword"`                            // 关键词搜索(AgentID、主机名、IP、备注、系统、架构、版本)，可选
 	Tags        []string    `json:"tags"`                               // 按标签过滤，可选
 	TaskSupport []string    `json:"task_support"`                       // 按任务支持过滤，可选
+	SortBy      string      `json:"sort_by"`                            // 排序字段(hostname、last_heartbeat 等)，不支持的字段使用默认排序，可选
+	SortOrder   string      `json:"sort_order"`                         // 排序方向 asc/desc，默认 desc，可选
 }
 
 // UpdateAgentStatusRequest 更新Agent状态请求结构
@@ -83,6 +111,13 @@ type UpdateAgentStatusRequest struct {
 	Status AgentStatus `json:"status" validate:"required"` // 新状态，必填
 }
 
+// UpdateAgentHeartbeatRequest Agent心跳配置更新请求结构
+// 两项均为 0 时恢复默认值；离线阈值应大于心跳间隔，否则每个心跳周期之间都会被判定离线
+type UpdateAgentHeartbeatRequest struct {
+	HeartbeatInterval int `json:"heartbeat_interval" validate:"min=0,max=3600"` // 心跳间隔(秒)，0 使用默认值
+	OfflineThreshold  int `json:"offline_threshold" validate:"min=0,max=86400"` // 离线阈值(秒)，0 使用默认值(心跳间隔的3倍)
+}
+
 // AgentConfigUpdateRequest Agent配置更新请求结构
 type AgentConfigUpdateRequest struct {
 	HeartbeatInterval   int                    `json:"heartbeat_interval" validate:"min=5,max=300"`      // 心跳间隔(秒)，5-300秒
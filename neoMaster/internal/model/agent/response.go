@@ -19,33 +19,55 @@ type RegisterAgentResponse struct {
 	TokenExpiry time.Time `json:"token_expiry"` // Token过期时间
 	Status      string    `json:"status"`       // 注册状态
 	Message     string    `json:"message"`      // 响应消息
+
+	HeartbeatInterval int `json:"heartbeat_interval"` // Master 为该 Agent 配置的心跳间隔(秒)，Agent 按此频率上报心跳
+}
+
+// EnrollResponse Agent接入响应结构
+type EnrollResponse struct {
+	AgentID     string    `json:"agent_id"`     // Agent唯一标识ID
+	Token       string    `json:"token"`        // 签名的Agent Token
+	TokenExpiry time.Time `json:"token_expiry"` // Token过期时间
+	Reenrolled  bool      `json:"reenrolled"`   // 是否为已有Agent重新接入(Token已轮换)
+
+	HeartbeatInterval int `json:"heartbeat_interval"` // Master 为该 Agent 配置的心跳间隔(秒)，Agent 按此频率上报心跳
+}
+
+// CreateEnrollmentTokenResponse 签发Agent接入令牌响应结构
+// Token 为令牌明文，只在签发时返回一次，之后无法再次查看
+type CreateEnrollmentTokenResponse struct {
+	Token string `json:"token"` // 接入令牌明文
+	*AgentEnrollmentToken
 }
 
 // AgentInfo Agent信息结构
 // 用于返回Agent的详细信息，包含基础信息和状态
 type AgentInfo struct {
-	ID               uint        `json:"id"`                 // 数据库主键ID
-	AgentID          string      `json:"agent_id"`           // Agent唯一标识ID
-	Hostname         string      `json:"hostname"`           // 主机名
-	IPAddress        string      `json:"ip_address"`         // IP地址
-	Port             int         `json:"port"`               // Agent服务端口
-	Version          string      `json:"version"`            // Agent版本号
-	Status           AgentStatus `json:"status"`             // Agent状态
-	OS               string      `json:"os"`                 // 操作系统
-	Arch             string      `json:"arch"`               // 系统架构
-	CPUCores         int         `json:"cpu_cores"`          // CPU核心数
-	MemoryTotal      int64       `json:"memory_total"`       // 总内存大小(字节)
-	DiskTotal        int64       `json:"disk_total"`         // 总磁盘大小(字节)
-	TaskSupport      []string    `json:"task_support"`       // Agent支持的任务类型列表 (对应ScanType)
-	Feature          []string    `json:"feature"`            // Agent具备的特性功能列表
-	Tags             []string    `json:"tags"`               // Agent标签列表
-	LastHeartbeat    time.Time   `json:"last_heartbeat"`     // 最后心跳时间
-	ResultLatestTime *time.Time  `json:"result_latest_time"` // 最新返回结果时间
-	Remark           string      `json:"remark"`             // 备注信息
-	ContainerID      string      `json:"container_id"`       // 容器ID
-	PID              int         `json:"pid"`                // 进程ID
-	CreatedAt        time.Time   `json:"created_at"`         // 创建时间
-	UpdatedAt        time.Time   `json:"updated_at"`         // 更新时间
+	ID                uint                  `json:"id"`                 // 数据库主键ID
+	AgentID           string                `json:"agent_id"`           // Agent唯一标识ID
+	Hostname          string                `json:"hostname"`           // 主机名
+	IPAddress         string                `json:"ip_address"`         // IP地址
+	Port              int                   `json:"port"`               // Agent服务端口
+	Version           string                `json:"version"`            // Agent版本号
+	Status            AgentStatus           `json:"status"`             // Agent状态
+	OS                string                `json:"os"`                 // 操作系统
+	Arch              string                `json:"arch"`               // 系统架构
+	CPUCores          int                   `json:"cpu_cores"`          // CPU核心数
+	MemoryTotal       int64                 `json:"memory_total"`       // 总内存大小(字节)
+	DiskTotal         int64                 `json:"disk_total"`         // 总磁盘大小(字节)
+	TaskSupport       []string              `json:"task_support"`       // Agent支持的任务类型列表 (对应ScanType)
+	Feature           []string              `json:"feature"`            // Agent具备的特性功能列表
+	Tags              []string              `json:"tags"`               // Agent标签列表
+	LastHeartbeat     time.Time             `json:"last_heartbeat"`     // 最后心跳时间
+	ResultLatestTime  *time.Time            `json:"result_latest_time"` // 最新返回结果时间
+	HeartbeatInterval int                   `json:"heartbeat_interval"` // 心跳间隔(秒)，0 表示使用默认值
+	OfflineThreshold  int                   `json:"offline_threshold"`  // 离线阈值(秒)，0 表示使用默认值
+	RunningTasks      []RunningTaskProgress `json:"running_tasks"`      // 运行中任务进度 (最近一次心跳上报，时间见 last_heartbeat)
+	Remark            string                `json:"remark"`             // 备注信息
+	ContainerID       string                `json:"container_id"`       // 容器ID
+	PID               int                   `json:"pid"`                // 进程ID
+	CreatedAt         time.Time             `json:"created_at"`         // 创建时间
+	UpdatedAt         time.Time             `json:"updated_at"`         // 更新时间
 }
 
 // GetAgentListResponse 获取Agent列表响应结构
@@ -83,13 +105,6 @@ type AgentMetricsResponse struct {
 	Timestamp         time.Time              `json:"timestamp"`          // 指标时间戳
 }
 
-// AgentMetricsListResponse 获取Agent性能指标列表响应结构
-// 包含指标列表和分页信息，保持与Agent列表返回结构一致的风格
-type AgentMetricsListResponse struct {
-	Metrics    []*AgentMetricsResponse `json:"metrics"`    // 指标列表（当前页）
-	Pagination *PaginationResponse     `json:"pagination"` // 分页信息
-}
-
 // AgentConfigResponse Agent配置响应结构
 // 返回Agent的配置信息
 type AgentConfigResponse struct {
@@ -108,6 +123,23 @@ type AgentConfigResponse struct {
 	UpdatedAt           time.Time              `json:"updated_at"`            // 更新时间
 }
 
+// AgentEffectiveConfigResponse Agent生效配置响应结构
+// Config 与 Agent 拉取配置时获得的内容一致，Fields 标注每个配置项的值来自哪一层
+type AgentEffectiveConfigResponse struct {
+	AgentID string                          `json:"agent_id"` // Agent唯一标识ID
+	Version int                             `json:"version"`  // 配置版本号，0 表示从未下发过配置
+	Config  *AgentConfigResponse            `json:"config"`   // 生效配置
+	Fields  map[string]EffectiveConfigField `json:"fields"`   // 配置项名 -> 值与来源，插件配置为 plugin_config.<插件名>
+}
+
+// EffectiveConfigField 生效配置项
+type EffectiveConfigField struct {
+	Value     interface{} `json:"value"`                // 配置值
+	Source    string      `json:"source"`               // 来源层：default/group/agent
+	GroupID   uint64      `json:"group_id,omitempty"`   // 来源为 group 时的分组标签ID
+	GroupName string      `json:"group_name,omitempty"` // 来源为 group 时的分组名称
+}
+
 // AgentStatusResponse Agent状态响应结构
 // 返回Agent状态更新结果
 type AgentStatusResponse struct {
@@ -117,6 +149,15 @@ type AgentStatusResponse struct {
 	UpdatedAt time.Time   `json:"updated_at"` // 更新时间
 }
 
+// AgentUpdateCandidate 待升级Agent信息
+// 在线Agent当前版本低于最新发布版本时返回
+type AgentUpdateCandidate struct {
+	Agent          *AgentInfo `json:"agent"`           // Agent信息
+	CurrentVersion string     `json:"current_version"` // 当前版本
+	TargetVersion  string     `json:"target_version"`  // 目标版本(最新版本)
+	DownloadURL    string     `json:"download_url"`    // 目标版本下载地址
+}
+
 // AgentTaskAssignmentResponse Agent任务分配响应结构
 // 返回任务分配结果
 type AgentTaskAssignmentResponse struct {
@@ -155,6 +196,8 @@ type HeartbeatResponse struct {
 	Message      string            `json:"message"`                 // 响应消息
 	Timestamp    time.Time         `json:"timestamp"`               // 响应时间戳
 	RuleVersions map[string]string `json:"rule_versions,omitempty"` // 规则版本信息 {"fingerprint": "hash...", "poc": "hash..."}
+
+	Config *AgentConfigResponse `json:"config,omitempty"` // Agent上报的配置版本落后时携带最新配置，版本一致时为空
 }
 
 // AgentDeleteResponse Agent删除响应结构
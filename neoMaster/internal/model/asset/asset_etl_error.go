@@ -7,6 +7,7 @@ import (
 // AssetETLError ETL 错误记录
 // 用于记录在 ETL 过程中解析 (Mapper) 或入库 (Merger) 失败的原始数据
 // 充当 "死信队列" 的角色，支持后续手动重试或分析
+// Agent 结果批次中格式错误的条目也记录在此 (error_stage=ingest, status=quarantined)，RawData 为原始条目，不参与重放
 type AssetETLError struct {
 	basemodel.BaseModel
 	ProjectID  uint64 `gorm:"index;not null" json:"project_id"`
@@ -14,9 +15,9 @@ type AssetETLError struct {
 	ResultType string `gorm:"size:64;not null" json:"result_type"`
 	RawData    string `gorm:"type:text" json:"raw_data"`                 // 原始 StageResult JSON
 	ErrorMsg   string `gorm:"type:text" json:"error_msg"`                // 错误堆栈信息
-	ErrorStage string `gorm:"size:20" json:"error_stage"`                // 出错阶段: mapper, merger
+	ErrorStage string `gorm:"size:20" json:"error_stage"`                // 出错阶段: mapper, merger, ingest
 	RetryCount int    `gorm:"default:0" json:"retry_count"`              // 重试次数
-	Status     string `gorm:"size:20;default:'new';index" json:"status"` // new, retrying, resolved, ignored
+	Status     string `gorm:"size:20;default:'new';index" json:"status"` // new, retrying, resolved, ignored, quarantined
 }
 
 // TableName 指定表名
@@ -11,10 +11,15 @@ type AssetHost struct {
 	basemodel.BaseModel
 
 	IP             string     `json:"ip" gorm:"column:ip;size:50;uniqueIndex;not null;comment:IP地址"`
+	IPHex          string     `json:"-" gorm:"column:ip_hex;size:32;index;comment:IP排序键(16字节十六进制)，用于网段范围查询"` // 见 utils.IPSortKey，由仓库层写入
 	Hostname       string     `json:"hostname" gorm:"size:200;comment:主机名"`
 	OS             string     `json:"os" gorm:"size:100;comment:操作系统"`
+	FirstSeenAt    *time.Time `json:"first_seen_at" gorm:"comment:首次发现时间"`
 	LastSeenAt     *time.Time `json:"last_seen_at" gorm:"comment:最后发现时间"`
 	SourceStageIDs string     `json:"source_stage_ids" gorm:"type:json;comment:来源阶段ID列表(JSON)"`
+
+	// OpenPorts 主机上开放的端口/服务 (asset_services.host_id 关联，非数据库字段，按需加载)
+	OpenPorts []*AssetService `json:"open_ports,omitempty" gorm:"-"`
 }
 
 // TableName 定义数据库表名
@@ -37,6 +42,8 @@ type AssetService struct {
 	Banner      string     `json:"banner" gorm:"size:2048;comment:服务横幅信息"` // 新增
 	Fingerprint string     `json:"fingerprint" gorm:"type:json;comment:指纹信息(JSON)"`
 	AssetType   string     `json:"asset_type" gorm:"size:50;default:'service';comment:资产类型(service/database/container)"`
+	DedupKey    string     `json:"dedup_key" gorm:"size:64;index;comment:去重键(IP+端口+服务指纹),见 etl.DedupKey"`
+	SeenCount   int        `json:"seen_count" gorm:"default:1;comment:被扫描发现的次数"`
 	LastSeenAt  *time.Time `json:"last_seen_at" gorm:"comment:最后发现时间"`
 }
 
@@ -6,6 +6,19 @@ import (
 	"neomaster/internal/model/basemodel"
 )
 
+// AgentTask 任务状态
+// 合法流转见 task_dispatcher.ValidateAgentTaskStatusTransition
+const (
+	AgentTaskStatusPending   = "pending"   // 待分发 (含退避中的重试任务)
+	AgentTaskStatusAssigned  = "assigned"  // 已分配
+	AgentTaskStatusRunning   = "running"   // 运行中
+	AgentTaskStatusCompleted = "completed" // 已完成
+	AgentTaskStatusFailed    = "failed"    // 失败，等待退避重试
+	AgentTaskStatusDead      = "dead"      // 重试耗尽
+	AgentTaskStatusCanceling = "canceling" // 取消中: 已下达取消，等待 Agent 中止执行并确认
+	AgentTaskStatusCanceled  = "canceled"  // 已取消
+)
+
 // AgentTask Agent任务实体
 // 记录 Master 分发给 Agent 的具体执行任务
 // ScanStage(定义) -- AgentTask(执行)[下发给Agent节点] -- ScanResult(结果)[Agent节点返回]
@@ -19,8 +32,9 @@ type AgentTask struct {
 	ProjectID    uint64 `json:"project_id" gorm:"index;not null;comment:所属项目ID"`
 	WorkflowID   uint64 `json:"workflow_id" gorm:"index;not null;comment:所属工作流ID"`
 	StageID      uint64 `json:"stage_id" gorm:"index;not null;comment:所属阶段ID"`
+	RunID        int64  `json:"run_id" gorm:"index;default:0;comment:所属项目执行轮次(Project.RunSeq)"`
 	AgentID      string `json:"agent_id" gorm:"index;size:100;comment:执行Agent的ID"`
-	Status       string `json:"status" gorm:"size:20;default:'pending';comment:任务状态(pending/assigned/running/completed/failed)"`
+	Status       string `json:"status" gorm:"size:20;default:'pending';comment:任务状态(pending/assigned/running/completed/failed/dead/canceling/canceled)"`
 	Priority     int    `json:"priority" gorm:"default:0;comment:任务优先级"`
 	TaskType     string `json:"task_type" gorm:"size:20;default:'tool';comment:任务类型"`
 	TaskCategory string `json:"task_category" gorm:"size:20;default:'agent';comment:任务分类(agent/system)"` // agent: 普通任务(通过Agent执行); system: 系统任务(localAgent)
@@ -43,8 +57,11 @@ type AgentTask struct {
 	Timeout    int        `json:"timeout" gorm:"default:3600;comment:超时时间(秒)"`
 
 	// 重试机制
-	RetryCount int `json:"retry_count" gorm:"default:0;comment:已重试次数"`
-	MaxRetries int `json:"max_retries" gorm:"default:3;comment:最大重试次数"`
+	// 失败任务由调度器按指数退避重新置为 pending，NextRetryAt 之前不会被分发
+	// 超过 MaxRetries 的失败任务进入终态 dead
+	RetryCount  int        `json:"retry_count" gorm:"default:0;comment:已重试次数"`
+	MaxRetries  int        `json:"max_retries" gorm:"default:3;comment:最大重试次数"`
+	NextRetryAt *time.Time `json:"next_retry_at" gorm:"index;comment:下次重试时间(退避)"`
 }
 
 // TableName 定义表名
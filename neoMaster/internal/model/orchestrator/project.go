@@ -16,7 +16,7 @@ type Project struct {
 	DisplayName  string         `json:"display_name" gorm:"size:200;comment:显示名称"`
 	Description  string         `json:"description" gorm:"type:text;comment:项目描述"`
 	TargetScope  string         `json:"target_scope" gorm:"type:text;comment:目标范围(CIDR/Domain列表)"` // 目标合集，网段扫描的时候可以是 asset_network.cidr
-	Status       string         `json:"status" gorm:"size:20;default:'idle';comment:运行状态(idle/running/paused/finished/error)"`
+	Status       string         `json:"status" gorm:"size:20;default:'idle';comment:运行状态(idle/running/paused/finished/error/canceled)"`
 	Enabled      bool           `json:"enabled" gorm:"default:true;comment:是否启用"`
 	ScheduleType string         `json:"schedule_type" gorm:"size:20;default:'immediate';comment:调度类型(immediate/cron/api/event)"`
 	CronExpr     string         `json:"cron_expr" gorm:"size:100;comment:Cron表达式"`
@@ -26,11 +26,32 @@ type Project struct {
 	ExtendedData string         `json:"extended_data" gorm:"type:json;comment:扩展数据(JSON)"`
 	LastExecTime *time.Time     `json:"last_exec_time" gorm:"comment:最后一次执行开始时间"`
 	LastExecID   string         `json:"last_exec_id" gorm:"size:100;comment:最后一次执行的任务ID"`
+	RunSeq       int64          `json:"run_seq" gorm:"default:0;comment:执行轮次序号(每次开始新一轮执行时递增)"`
+	NextRunAt    *time.Time     `json:"next_run_at" gorm:"index;comment:下一次定时执行时间(仅cron调度)"`
 	CreatedBy    uint64         `json:"created_by" gorm:"comment:创建者UserID"`
 	UpdatedBy    uint64         `json:"updated_by" gorm:"comment:更新者UserID"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:软删除时间"`
 }
 
+// 项目运行状态枚举
+// 合法的状态流转定义在 service/orchestrator/project.go 的 projectStatusTransitions 中
+const (
+	ProjectStatusIdle     = "idle"     // 空闲(未运行)
+	ProjectStatusRunning  = "running"  // 运行中
+	ProjectStatusPaused   = "paused"   // 已暂停
+	ProjectStatusFinished = "finished" // 已完成
+	ProjectStatusError    = "error"    // 执行失败
+	ProjectStatusCanceled = "canceled" // 已取消
+)
+
+// 项目调度类型枚举
+const (
+	ProjectScheduleImmediate = "immediate" // 立即执行
+	ProjectScheduleCron      = "cron"      // 按 CronExpr 定时执行
+	ProjectScheduleAPI       = "api"       // 由外部 API 触发
+	ProjectScheduleEvent     = "event"     // 由事件触发
+)
+
 // TableName 定义数据库表名
 func (Project) TableName() string {
 	return "projects"
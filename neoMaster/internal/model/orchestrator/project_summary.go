@@ -0,0 +1,56 @@
+package orchestrator
+
+import "time"
+
+// ProjectSummary 项目结果汇总 (非数据库表)
+// 由 StageResult 与 AgentTask 聚合计算得到，用于展示项目整体发现情况
+type ProjectSummary struct {
+	ProjectID    uint64          `json:"project_id"`
+	TotalResults int64           `json:"total_results"` // 结果总条数
+	OpenPorts    int             `json:"open_ports"`    // 开放端口总数 (按 ip:port/proto 去重)
+	Services     map[string]int  `json:"services"`      // 发现的服务 (服务名 -> 数量, 按 ip:port/proto 去重)
+	VulnCounts   map[string]int  `json:"vuln_counts"`   // 漏洞数量 (严重程度 -> 数量)
+	Stages       []*StageSummary `json:"stages"`        // 各阶段执行情况
+	ParseErrors  int             `json:"parse_errors"`  // 结构化属性解析失败的结果条数
+}
+
+// StageSummary 单个阶段的执行汇总
+type StageSummary struct {
+	StageID        uint64 `json:"stage_id"`
+	WorkflowID     uint64 `json:"workflow_id"`
+	StageName      string `json:"stage_name"`
+	StageType      string `json:"stage_type"`
+	Status         string `json:"status"` // not_started/running/success/failed/partial
+	ResultCount    int64  `json:"result_count"`
+	TotalTasks     int64  `json:"total_tasks"`
+	CompletedTasks int64  `json:"completed_tasks"`
+	FailedTasks    int64  `json:"failed_tasks"` // failed + dead
+}
+
+// 阶段汇总状态
+const (
+	StageSummaryNotStarted = "not_started"
+	StageSummaryRunning    = "running"
+	StageSummarySuccess    = "success"
+	StageSummaryFailed     = "failed"
+	StageSummaryPartial    = "partial" // 部分任务成功、部分失败
+)
+
+// ProjectEvent 项目执行进度事件 (SSE 推送，非数据库表)
+type ProjectEvent struct {
+	Type      string          `json:"type"` // stage_start/stage_complete/progress/done
+	ProjectID uint64          `json:"project_id"`
+	Status    string          `json:"status"`            // 项目运行状态
+	Percent   int             `json:"percent"`           // 项目整体进度 0-100
+	Stage     *StageSummary   `json:"stage,omitempty"`   // stage_start/stage_complete 对应的阶段
+	Summary   *ProjectSummary `json:"summary,omitempty"` // done 事件携带项目结果汇总
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// 项目进度事件类型
+const (
+	ProjectEventStageStart    = "stage_start"
+	ProjectEventStageComplete = "stage_complete"
+	ProjectEventProgress      = "progress"
+	ProjectEventDone          = "done"
+)
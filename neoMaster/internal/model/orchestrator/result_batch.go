@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"time"
+
+	"neomaster/internal/model/basemodel"
+)
+
+// ResultBatch Agent 结果批次处理记录
+// 每个批次处理完成后记录一行 (与结果入库在同一事务中)，BatchID 唯一；
+// Agent 重放已处理过的批次时直接返回记录中的处理报告，不会重复入库
+type ResultBatch struct {
+	basemodel.BaseModel
+
+	BatchID     string `json:"batch_id" gorm:"uniqueIndex;size:64;not null;comment:批次ID(Agent生成，重放时不变)"`
+	AgentID     string `json:"agent_id" gorm:"index;size:100;not null;comment:上报的AgentID"`
+	Total       int    `json:"total" gorm:"default:0;comment:批次内结果条数"`
+	Accepted    int    `json:"accepted" gorm:"default:0;comment:入库条数"`
+	Skipped     int    `json:"skipped" gorm:"default:0;comment:跳过条数(执行失败无结果)"`
+	Quarantined int    `json:"quarantined" gorm:"default:0;comment:隔离条数(格式错误)"`
+	Rejected    int    `json:"rejected" gorm:"default:0;comment:拒绝条数(任务不存在/不属于该Agent/不在运行中)"`
+	Report      string `json:"report" gorm:"type:text;comment:处理报告(JSON)，重放时原样返回"`
+}
+
+// TableName 定义数据库表名
+func (ResultBatch) TableName() string {
+	return "agent_result_batches"
+}
+
+// AgentResultBatchRequest Agent 结果批次上报请求 (POST /api/v1/agent/results，非数据库表)
+type AgentResultBatchRequest struct {
+	BatchID   string            `json:"batch_id"`
+	AgentID   string            `json:"agent_id"` // 可选，填写时必须与 Token 对应的 Agent 一致
+	Results   []AgentResultItem `json:"results"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// AgentResultItem 单条扫描结果
+type AgentResultItem struct {
+	TaskID      string          `json:"task_id"`
+	TaskType    string          `json:"task_type"` // Agent 核心任务类型 (port_scan/web_scan/...)
+	Status      string          `json:"status"`    // success/failed
+	Target      string          `json:"target"`    // 扫描目标 (ip/域名/url/网段)
+	Result      json.RawMessage `json:"result"`    // 结构化结果 (JSON 对象或数组)，写入 StageResult.Attributes
+	Error       string          `json:"error"`
+	ExecutedAt  time.Time       `json:"executed_at"`
+	CompletedAt time.Time       `json:"completed_at"`
+}
+
+// AgentResultBatchReport 结果批次处理报告 (非数据库表)
+// Quarantined: 条目格式错误，原始数据写入 asset_etl_errors (error_stage=ingest) 供排查；
+// Rejected: 条目格式正确但任务状态不允许 (不存在/不属于该Agent/已结束)，直接丢弃
+type AgentResultBatchReport struct {
+	BatchID     string               `json:"batch_id"`
+	Accepted    int                  `json:"accepted"`  // 写入 StageResult 的条数
+	Skipped     int                  `json:"skipped"`   // 执行失败 (status=failed) 的条目，无结果数据，不入库
+	Duplicate   bool                 `json:"duplicate"` // 批次已处理过，本次为重放
+	Quarantined []RejectedResultItem `json:"quarantined"`
+	Rejected    []RejectedResultItem `json:"rejected"`
+}
+
+// RejectedResultItem 未入库的结果条目
+type RejectedResultItem struct {
+	Index  int    `json:"index"` // 在批次 Results 中的下标
+	TaskID string `json:"task_id"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,34 @@
+package orchestrator
+
+// ScanDiff 项目两轮执行的结果差异 (非数据库表)
+// 以 StageResult.FindingKey (结果类型+目标+端口+协议) 作为发现标识比对两轮结果:
+// Added 仅出现在 RunB，Removed 仅出现在 RunA，Changed 两轮都有但结构化属性不同
+type ScanDiff struct {
+	ProjectID uint64         `json:"project_id"`
+	RunA      int64          `json:"run_a"`
+	RunB      int64          `json:"run_b"`
+	Summary   ScanDiffCounts `json:"summary"`
+	Added     []*FindingDiff `json:"added"`
+	Removed   []*FindingDiff `json:"removed"`
+	Changed   []*FindingDiff `json:"changed"`
+	Truncated bool           `json:"truncated"` // 某一类差异超过返回上限时为 true，Summary 仍为完整计数
+}
+
+// ScanDiffCounts 各类差异的完整计数
+type ScanDiffCounts struct {
+	Added   int64 `json:"added"`
+	Removed int64 `json:"removed"`
+	Changed int64 `json:"changed"`
+}
+
+// FindingDiff 单个发现的差异
+// Added 只有 After，Removed 只有 Before，Changed 两者都有
+type FindingDiff struct {
+	ResultType  string `json:"result_type"`
+	TargetType  string `json:"target_type"`
+	TargetValue string `json:"target_value"`
+	Port        int    `json:"port,omitempty"`   // 端口级发现的端口
+	Proto       string `json:"proto,omitempty"`  // 端口级发现的传输协议
+	Before      string `json:"before,omitempty"` // RunA 中的结构化属性(JSON)
+	After       string `json:"after,omitempty"`  // RunB 中的结构化属性(JSON)
+}
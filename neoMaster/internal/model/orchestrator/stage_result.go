@@ -12,7 +12,8 @@ import (
 type StageResult struct {
 	basemodel.BaseModel
 
-	ProjectID        uint64    `json:"project_id" gorm:"index;not null;comment:所属项目ID"` // 所属项目ID 为了性能和管理选择让 项目ID 冗余, 方便查询
+	ProjectID        uint64    `json:"project_id" gorm:"index;index:idx_stage_results_run_finding,priority:1;not null;comment:所属项目ID"` // 所属项目ID 为了性能和管理选择让 项目ID 冗余, 方便查询
+	RunID            int64     `json:"run_id" gorm:"index:idx_stage_results_run_finding,priority:2;default:0;comment:所属项目执行轮次(Project.RunSeq)"`
 	WorkflowID       uint64    `json:"workflow_id" gorm:"index;not null;comment:所属工作流ID"`
 	StageID          uint64    `json:"stage_id" gorm:"index;not null;comment:阶段ID"`
 	TaskID           string    `json:"task_id" gorm:"index;size:64;not null;comment:关联的任务ID"` // 新增: 关联具体的 AgentTask
@@ -20,12 +21,16 @@ type StageResult struct {
 	ResultType       string    `json:"result_type" gorm:"size:50;comment:结果类型枚举(ipAlive/serviceScan/PocScan等)"`
 	TargetType       string    `json:"target_type" gorm:"size:50;comment:目标类型(ip/domain/url)"`
 	TargetValue      string    `json:"target_value" gorm:"size:2048;comment:目标值"`
+	Port             int       `json:"port" gorm:"default:0;comment:发现所在端口(端口级结果，取自结构化属性)"`
+	Proto            string    `json:"proto" gorm:"size:10;comment:发现所在端口的传输协议(tcp/udp)"`
 	Attributes       string    `json:"attributes" gorm:"type:json;comment:结构化属性(JSON)"` // 存储扫描结果的详细信息 (JSON 格式)
 	Evidence         string    `json:"evidence" gorm:"type:json;comment:原始证据(JSON)"`    // 存储原始扫描证据 (JSON 格式)
 	ProducedAt       time.Time `json:"produced_at" gorm:"comment:产生时间"`
 	Producer         string    `json:"producer" gorm:"size:100;comment:工具标识与版本"`
 	OutputConfigHash string    `json:"output_config_hash" gorm:"size:64;comment:输出配置指纹"`
 	OutputActions    string    `json:"output_actions" gorm:"type:json;comment:实际执行的轻量动作摘要(JSON)"`
+	FindingKey       string    `json:"finding_key" gorm:"index:idx_stage_results_run_finding,priority:3;size:64;comment:发现标识(结果类型+目标+端口+协议)，用于跨轮次比对"`
+	ContentHash      string    `json:"content_hash" gorm:"size:64;comment:结构化属性指纹，用于判断发现是否变化"`
 }
 
 // TableName 定义数据库表名
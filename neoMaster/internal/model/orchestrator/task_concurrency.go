@@ -0,0 +1,33 @@
+package orchestrator
+
+import "time"
+
+// DispatchLock 任务分发锁表
+// 认领任务前先 SELECT ... FOR UPDATE 锁住对应行，再统计运行中任务数，
+// 多个 Master 实例并发分发时也不会突破并发上限
+type DispatchLock struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:50;comment:锁名称"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 定义数据库表名
+func (DispatchLock) TableName() string {
+	return "dispatch_locks"
+}
+
+// TaskConcurrencyStatus 任务并发占用情况 (非数据库表)
+// 运行中 = assigned + running，排队 = pending (含退避中的重试任务)
+type TaskConcurrencyStatus struct {
+	GlobalLimit   int                   `json:"global_limit"`   // 全局上限，0 表示不限制
+	GlobalRunning int64                 `json:"global_running"` // 全局运行中任务数
+	GlobalPending int64                 `json:"global_pending"` // 全局排队任务数
+	ProjectLimit  int                   `json:"project_limit"`  // 单项目上限，0 表示不限制
+	Projects      []*ProjectConcurrency `json:"projects"`       // 有运行中或排队任务的项目
+}
+
+// ProjectConcurrency 单个项目的并发占用
+type ProjectConcurrency struct {
+	ProjectID uint64 `json:"project_id"`
+	Running   int64  `json:"running"`
+	Pending   int64  `json:"pending"`
+}
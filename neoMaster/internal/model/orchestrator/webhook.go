@@ -0,0 +1,96 @@
+package orchestrator
+
+import (
+	"neomaster/internal/model/basemodel"
+	"time"
+)
+
+// WebhookSubscription Webhook 订阅
+// 订阅的事件发生时向 URL 推送消息，消息格式由 Type 决定:
+// generic 推送原始 JSON 事件，请求头 X-Signature 携带以 Secret 计算的 HMAC-SHA256 签名；slack/dingtalk 推送对应平台的机器人消息；
+// email 不使用 URL，通过 SMTP 向 Recipients 发送 HTML 邮件
+type WebhookSubscription struct {
+	basemodel.BaseModel
+
+	Name       string   `json:"name" gorm:"size:100;not null;comment:订阅名称"`
+	Type       string   `json:"type" gorm:"size:20;default:'generic';comment:消息格式(generic/slack/dingtalk/email)"`
+	URL        string   `json:"url" gorm:"size:500;not null;comment:推送地址(http/https，email类型为空)"`
+	Secret     string   `json:"-" gorm:"size:200;comment:签名密钥(generic必填；dingtalk为机器人加签密钥)"` // 仅在创建/更新时提交，不随查询返回
+	EventTypes []string `json:"event_types" gorm:"serializer:json;type:json;comment:订阅的事件类型列表(JSON数组)"`
+	Recipients []string `json:"recipients" gorm:"serializer:json;type:json;comment:收件人列表(仅email类型)"`
+	AttachCSV  bool     `json:"attach_csv" gorm:"default:false;comment:是否附带漏洞CSV(仅email类型)"`
+	Enabled    bool     `json:"enabled" gorm:"default:true;comment:是否启用"`
+	CreatedBy  uint64   `json:"created_by" gorm:"comment:创建者UserID"`
+}
+
+// TableName 定义数据库表名
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Subscribes 是否订阅了指定事件
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery Webhook 投递记录
+// 每次 HTTP 请求(含重试)记录一行，同一事件的多次尝试共享 EventID；合并投递时 EventID 为其中第一个事件的 ID
+type WebhookDelivery struct {
+	basemodel.BaseModel
+
+	SubscriptionID uint64    `json:"subscription_id" gorm:"index;not null;comment:订阅ID"`
+	EventID        string    `json:"event_id" gorm:"size:64;index;not null;comment:事件ID(接收方可据此去重)"`
+	EventType      string    `json:"event_type" gorm:"size:50;comment:事件类型(合并多种事件时为digest)"`
+	EventCount     int       `json:"event_count" gorm:"default:1;comment:本次投递合并的事件数(限流期间的事件合并为一条消息)"`
+	Payload        string    `json:"payload" gorm:"type:text;comment:推送的请求体(JSON)"`
+	Attempt        int       `json:"attempt" gorm:"comment:第几次尝试(从1开始)"`
+	Status         string    `json:"status" gorm:"size:20;comment:投递结果(success/failed)"`
+	ResponseCode   int       `json:"response_code" gorm:"comment:HTTP响应码(请求未完成时为0)"`
+	Error          string    `json:"error" gorm:"type:text;comment:失败原因"`
+	DurationMs     int64     `json:"duration_ms" gorm:"comment:请求耗时(毫秒)"`
+	DeliveredAt    time.Time `json:"delivered_at" gorm:"comment:尝试时间"`
+}
+
+// TableName 定义数据库表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Webhook 事件类型
+const (
+	WebhookEventProjectCompleted = "project.completed" // 项目执行完成
+	WebhookEventFindingCritical  = "finding.critical"  // 发现严重(critical)漏洞
+)
+
+// WebhookEventDigest 合并投递中包含多种事件时记录的事件类型
+const WebhookEventDigest = "digest"
+
+// Webhook 消息格式 (订阅类型)
+const (
+	WebhookTypeGeneric  = "generic"  // 原始 JSON 事件
+	WebhookTypeSlack    = "slack"    // Slack Incoming Webhook (Block Kit)
+	WebhookTypeDingTalk = "dingtalk" // 钉钉自定义机器人 (markdown)
+	WebhookTypeEmail    = "email"    // SMTP 邮件 (HTML)，需启用 mail 配置
+)
+
+// Webhook 投递结果
+const (
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+)
+
+// WebhookEventTypes 支持订阅的事件类型
+var WebhookEventTypes = []string{WebhookEventProjectCompleted, WebhookEventFindingCritical}
+
+// WebhookEvent 推送给订阅方的事件 (即请求体，非数据库表)
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
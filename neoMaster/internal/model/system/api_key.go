@@ -0,0 +1,60 @@
+/**
+ * 模型:API 密钥模型
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 服务间调用(CI 等)使用的长期 API 密钥，请求头 Authorization: ApiKey <key>
+ *               只保存密钥的 SHA-256 哈希，明文仅在创建时返回一次
+ * @func: APIKey 结构体定义
+ */
+package system
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey API 密钥模型
+// 密钥以所属用户的身份访问接口，Scopes 进一步限制可用权限(resource:action，支持 * 通配)
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`                        // 主键ID
+	Name       string     `json:"name" gorm:"size:100;not null;comment:密钥名称"`                // 密钥名称，如 "gitlab-ci"
+	KeyPrefix  string     `json:"key_prefix" gorm:"size:16;not null;comment:密钥前缀(用于识别)"`     // 密钥前若干位，列表中用于识别密钥
+	KeyHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:密钥SHA-256哈希"` // 密钥哈希，不在JSON中返回
+	UserID     uint       `json:"user_id" gorm:"not null;index;comment:所属用户ID"`              // 所属用户(或服务账号)ID，密钥以该用户身份访问
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;type:json;comment:授权范围"`      // 授权范围，resource:action 列表
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"comment:过期时间"`                            // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"comment:最后使用时间"`                        // 最后使用时间
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:45;comment:最后使用IP"`                // 最后使用IP
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"index;comment:吊销时间"`                      // 吊销时间，吊销后立即失效
+	CreatedAt  time.Time  `json:"created_at"`                                                // 创建时间
+	UpdatedAt  time.Time  `json:"updated_at"`                                                // 更新时间
+}
+
+// TableName 定义表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsExpired 密钥在 now 时是否已过期
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// IsRevoked 密钥是否已吊销
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// AllowsScope 密钥授权范围是否覆盖 resource:action，范围中的资源或操作为 * 时匹配任意值
+func (k *APIKey) AllowsScope(resource, action string) bool {
+	for _, scope := range k.Scopes {
+		r, a, ok := strings.Cut(scope, ":")
+		if !ok {
+			continue
+		}
+		if (r == "*" || r == resource) && (a == "*" || a == action) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+/**
+ * 模型:审计日志模型
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 审计日志持久化模型，LogAuditOperation 写日志流的同时异步落库，供合规报表查询
+ * @func: AuditLog、AuditFilter 结构体定义
+ */
+package system
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"neomaster/internal/pkg/utils"
+)
+
+// AuditDetailsJSON 审计详情JSON类型
+type AuditDetailsJSON map[string]interface{}
+
+// Scan 实现sql.Scanner接口
+func (d *AuditDetailsJSON) Scan(value interface{}) error {
+	result, err := utils.ScanMapFromJSON(value)
+	if err != nil {
+		return err
+	}
+	*d = AuditDetailsJSON(result)
+	return nil
+}
+
+// Value 实现driver.Valuer接口
+func (d AuditDetailsJSON) Value() (driver.Value, error) {
+	return utils.ValueMapToJSON(map[string]interface{}(d))
+}
+
+// AuditLog 审计日志模型
+type AuditLog struct {
+	ID        uint64           `json:"id" gorm:"primaryKey;autoIncrement"`           // 主键ID
+	UserID    uint             `json:"user_id" gorm:"index;comment:操作用户ID"`          // 操作用户ID
+	Username  string           `json:"username" gorm:"size:50;comment:用户名"`          // 用户名
+	Action    string           `json:"action" gorm:"size:100;index;comment:操作动作"`    // 操作动作
+	Resource  string           `json:"resource" gorm:"size:255;index;comment:操作资源"`  // 操作资源
+	Result    string           `json:"result" gorm:"size:20;comment:操作结果"`           // 操作结果(success/failed)
+	ClientIP  string           `json:"client_ip" gorm:"size:45;comment:客户端IP"`       // 客户端IP，支持IPv6
+	UserAgent string           `json:"user_agent" gorm:"size:500;comment:用户代理"`      // 用户代理
+	RequestID string           `json:"request_id" gorm:"size:64;comment:请求追踪ID"`     // 请求追踪ID
+	Details   AuditDetailsJSON `json:"details" gorm:"type:json;comment:详情(额外字段)"`    // 详情(LogAuditOperation 的额外字段)
+	Timestamp time.Time        `json:"timestamp" gorm:"index;not null;comment:操作时间"` // 操作时间
+	CreatedAt time.Time        `json:"created_at"`                                   // 入库时间
+}
+
+// TableName 定义表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditFilter 审计日志查询条件，零值字段不参与过滤
+type AuditFilter struct {
+	UserID         uint      `json:"user_id" form:"user_id"`                 // 操作用户ID
+	Action         string    `json:"action" form:"action"`                   // 操作动作(精确匹配)
+	ResourcePrefix string    `json:"resource_prefix" form:"resource_prefix"` // 操作资源前缀
+	StartTime      time.Time `json:"start_time" form:"start_time"`           // 起始时间(含)
+	EndTime        time.Time `json:"end_time" form:"end_time"`               // 结束时间(不含)
+}
@@ -3,12 +3,45 @@
  * @author: sun977
  * @date: 2025.08.29
  * @description: 系统错误常量和错误类型定义
- * @func: 各种错误常量和ValidationError结构体
+ * @func: 各种错误常量和ValidationError结构体，错误码(ErrorCode)定义与映射
  */
 package system
 
 import "errors"
 
+// 错误码 (APIResponse.ErrorCode)
+// 供前端按错误码做本地化提示，不依赖 Error 文本
+const (
+	// 通用
+	ErrCodeInvalidParam     = "INVALID_PARAM"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeConflict         = "CONFLICT"
+	ErrCodeUnauthorized     = "UNAUTHORIZED"
+	ErrCodePermissionDenied = "PERMISSION_DENIED"
+	ErrCodeInternal         = "INTERNAL_ERROR"
+	ErrCodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
+
+	// 幂等
+	ErrCodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS" // 同一个 Idempotency-Key 的请求仍在处理
+	ErrCodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"  // 同一个 Idempotency-Key 用于不同的请求体
+
+	// 认证/用户
+	ErrCodeInvalidCredentials = "INVALID_CREDENTIALS"
+	ErrCodeTokenExpired       = "TOKEN_EXPIRED"
+	ErrCodeTokenInvalid       = "TOKEN_INVALID"
+	ErrCodeUserNotFound       = "USER_NOT_FOUND"
+	ErrCodeUserDisabled       = "USER_DISABLED"
+	ErrCodeUserAlreadyExists  = "USER_ALREADY_EXISTS"
+	ErrCodeTOTPRequired       = "TOTP_REQUIRED"   // 已启用两步验证，登录需提供动态码
+	ErrCodeTOTPInvalid        = "TOTP_INVALID"    // 动态码错误、过期或已被使用
+	ErrCodeAPIKeyInvalid      = "API_KEY_INVALID" // API 密钥不存在或已吊销
+	ErrCodeAPIKeyExpired      = "API_KEY_EXPIRED" // API 密钥已过期
+
+	// 扫描编排
+	ErrCodeTemplateNotFound  = "TEMPLATE_NOT_FOUND"
+	ErrCodeInvalidToolParams = "INVALID_TOOL_PARAMS"
+)
+
 // 用户相关错误
 var (
 	// 验证错误
@@ -35,6 +68,27 @@ var (
 	ErrUnauthorized     = errors.New("未授权访问")
 )
 
+// 两步验证(TOTP)错误
+var (
+	ErrTOTPRequired       = errors.New("需要两步验证动态码")
+	ErrTOTPInvalid        = errors.New("两步验证动态码无效")
+	ErrTOTPNotEnrolled    = errors.New("尚未绑定两步验证")
+	ErrTOTPAlreadyEnabled = errors.New("两步验证已启用")
+	ErrTOTPUnavailable    = errors.New("两步验证未配置")
+)
+
+// API 密钥错误
+var (
+	ErrAPIKeyInvalid    = errors.New("API密钥无效")
+	ErrAPIKeyExpired    = errors.New("API密钥已过期")
+	ErrAPIKeyNotFound   = errors.New("API密钥不存在")
+	ErrAPIKeyScope      = errors.New("API密钥授权范围格式无效，应为 resource:action")
+	ErrAPIKeyNotAllowed = errors.New("API密钥不能用于管理账号凭据")
+)
+
+// ErrConcurrentModification 乐观锁冲突: 记录在读取之后已被其他请求修改
+var ErrConcurrentModification = errors.New("记录已被其他请求修改，请刷新后重试")
+
 // ValidationError 验证错误结构体
 type ValidationError struct {
 	Field   string `json:"field"`   // 字段名
@@ -58,3 +112,85 @@ func IsValidationError(err error) bool {
 	_, ok := err.(*ValidationError)
 	return ok
 }
+
+// CodedError 携带错误码的错误
+// Service 层用它包装业务错误，Handler 通过 ErrorCodeOf 取出错误码填充 APIResponse.ErrorCode
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+// NewCodedError 创建携带错误码的错误
+func NewCodedError(code string, err error) *CodedError {
+	return &CodedError{Code: code, Err: err}
+}
+
+// Error 实现error接口
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 支持 errors.Is / errors.As
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode 返回错误码
+func (e *CodedError) ErrorCode() string {
+	return e.Code
+}
+
+// sentinelErrorCodes 已有的哨兵错误 -> 错误码
+var sentinelErrorCodes = map[error]string{
+	ErrInvalidUsername:          ErrCodeInvalidParam,
+	ErrInvalidEmail:             ErrCodeInvalidParam,
+	ErrInvalidPassword:          ErrCodeInvalidParam,
+	ErrInvalidPhone:             ErrCodeInvalidParam,
+	ErrUserAlreadyExists:        ErrCodeUserAlreadyExists,
+	ErrEmailAlreadyExists:       ErrCodeUserAlreadyExists,
+	ErrUsernameAlreadyExists:    ErrCodeUserAlreadyExists,
+	ErrUserOrEmailAlreadyExists: ErrCodeUserAlreadyExists,
+	ErrUserNotFound:             ErrCodeUserNotFound,
+	ErrInvalidCredentials:       ErrCodeInvalidCredentials,
+	ErrUserDisabled:             ErrCodeUserDisabled,
+	ErrTokenExpired:             ErrCodeTokenExpired,
+	ErrTokenInvalid:             ErrCodeTokenInvalid,
+	ErrPermissionDenied:         ErrCodePermissionDenied,
+	ErrUnauthorized:             ErrCodeUnauthorized,
+	ErrConcurrentModification:   ErrCodeConflict,
+	ErrTOTPRequired:             ErrCodeTOTPRequired,
+	ErrTOTPInvalid:              ErrCodeTOTPInvalid,
+	ErrTOTPNotEnrolled:          ErrCodeInvalidParam,
+	ErrTOTPAlreadyEnabled:       ErrCodeConflict,
+	ErrTOTPUnavailable:          ErrCodeInternal,
+	ErrAPIKeyInvalid:            ErrCodeAPIKeyInvalid,
+	ErrAPIKeyExpired:            ErrCodeAPIKeyExpired,
+	ErrAPIKeyNotFound:           ErrCodeNotFound,
+	ErrAPIKeyScope:              ErrCodeInvalidParam,
+	ErrAPIKeyNotAllowed:         ErrCodePermissionDenied,
+}
+
+// ErrorCodeOf 获取错误对应的错误码
+// 1. 错误链中实现了 ErrorCode() string 的错误(如 CodedError)优先
+// 2. 已知哨兵错误
+// 3. ValidationError 归为 INVALID_PARAM
+// 无法识别时返回空字符串，保持旧响应不变
+func ErrorCodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	var coder interface{ ErrorCode() string }
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	for sentinel, code := range sentinelErrorCodes {
+		if errors.Is(err, sentinel) {
+			return code
+		}
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return ErrCodeInvalidParam
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"plain_error", errors.New("boom"), ""},
+		{"coded_error", NewCodedError(ErrCodeTemplateNotFound, errors.New("template not found")), ErrCodeTemplateNotFound},
+		{"wrapped_coded_error", fmt.Errorf("update: %w", NewCodedError(ErrCodeConflict, errors.New("dup"))), ErrCodeConflict},
+		{"sentinel", ErrUserNotFound, ErrCodeUserNotFound},
+		{"wrapped_sentinel", fmt.Errorf("login: %w", ErrTokenExpired), ErrCodeTokenExpired},
+		{"validation_error", NewValidationError("bad field"), ErrCodeInvalidParam},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCodeOf(tt.err); got != tt.want {
+				t.Errorf("ErrorCodeOf(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -48,6 +48,11 @@ func (p *Permission) GetFullName() string {
 	return p.Name
 }
 
+// Matches 检查权限是否覆盖 resource:action，资源或操作为 * 时匹配任意值
+func (p *Permission) Matches(resource, action string) bool {
+	return (p.Resource == "*" || p.Resource == resource) && (p.Action == "*" || p.Action == action)
+}
+
 // IsSystemPermission 检查是否为系统级权限
 func (p *Permission) IsSystemPermission() bool {
 	return p.Resource == "system"
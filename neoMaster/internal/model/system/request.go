@@ -7,10 +7,13 @@
  */
 package system
 
+import "time"
+
 // LoginRequest 登录请求结构
 type LoginRequest struct {
 	Username string `json:"username" validate:"required"` // 用户名或者邮箱，必填
 	Password string `json:"password" validate:"required"` // 密码，必填
+	TOTPCode string `json:"totp_code"`                    // 两步验证动态码，已启用两步验证时必填
 }
 
 // RefreshTokenRequest 刷新令牌请求结构
@@ -50,6 +53,65 @@ type UpdateUserRequest struct {
 	SocketID string      `json:"socket_id"`                                  // 套接字ID，可选
 	RoleIDs  []uint      `json:"role_ids"`                                   // 角色ID列表，可选(角色修改单独处理)
 	Remark   string      `json:"remark"`                                     // 用户备注，可选
+	// LockVersion 客户端读取到的乐观锁版本号，可选；与当前版本不一致时返回 409
+	LockVersion *int64 `json:"lock_version"`
+}
+
+// PatchUserRequest 局部更新用户请求结构 (PATCH 语义)
+// 与 UpdateUserRequest 不同，字段为 nil 表示不修改，出现空字符串表示清空该字段；
+// 用户名、邮箱、密码不允许清空
+type PatchUserRequest struct {
+	Username *string     `json:"username" validate:"omitempty,min=3,max=50"` // 用户名，3-50字符
+	Nickname *string     `json:"nickname"`                                   // 用户昵称，可清空
+	Email    *string     `json:"email" validate:"omitempty,email"`           // 邮箱地址
+	Phone    *string     `json:"phone"`                                      // 手机号码，可清空
+	Password *string     `json:"password" validate:"omitempty,min=6"`        // 密码，最少6字符
+	Status   *UserStatus `json:"status"`                                     // 用户状态(激活|禁用)
+	Avatar   *string     `json:"avatar"`                                     // 用户头像，可清空
+	SocketID *string     `json:"socket_id"`                                  // 套接字ID，可清空
+	RoleIDs  []uint      `json:"role_ids"`                                   // 角色ID列表，未携带时不修改，空数组表示移除全部角色
+	Remark   *string     `json:"remark"`                                     // 用户备注，可清空
+	// LockVersion 客户端读取到的乐观锁版本号，可选；与当前版本不一致时返回 409
+	LockVersion *int64 `json:"lock_version"`
+}
+
+// ToUpdateRequest 转换为 UpdateUserRequest，复用其校验与更新流程
+// 可清空的文本字段不在此转换 (UpdateUserRequest 会把空字符串当作不修改)，由 ApplyClearableFields 直接写入
+func (r *PatchUserRequest) ToUpdateRequest() *UpdateUserRequest {
+	req := &UpdateUserRequest{
+		Status:      r.Status,
+		RoleIDs:     r.RoleIDs,
+		LockVersion: r.LockVersion,
+	}
+	if r.Username != nil {
+		req.Username = *r.Username
+	}
+	if r.Email != nil {
+		req.Email = *r.Email
+	}
+	if r.Password != nil {
+		req.Password = *r.Password
+	}
+	return req
+}
+
+// ApplyClearableFields 将请求中携带的可清空字段写入用户 (含空字符串)
+func (r *PatchUserRequest) ApplyClearableFields(user *User) {
+	if r.Nickname != nil {
+		user.Nickname = *r.Nickname
+	}
+	if r.Phone != nil {
+		user.Phone = *r.Phone
+	}
+	if r.Avatar != nil {
+		user.Avatar = *r.Avatar
+	}
+	if r.SocketID != nil {
+		user.SocketId = *r.SocketID
+	}
+	if r.Remark != nil {
+		user.Remark = *r.Remark
+	}
 }
 
 // ChangePasswordRequest 修改密码请求结构
@@ -58,12 +120,25 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required,min=6"` // 新密码，必填，最少6字符
 }
 
+// TOTPCodeRequest 两步验证动态码请求结构(启用/关闭两步验证)
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required"` // 认证器当前显示的6位动态码，必填
+}
+
+// CreateAPIKeyRequest 创建 API 密钥请求结构
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"` // 密钥名称，必填
+	Scopes    []string   `json:"scopes" validate:"required,min=1"` // 授权范围，resource:action 列表，必填
+	ExpiresAt *time.Time `json:"expires_at"`                       // 过期时间，可选，为空表示永不过期
+}
+
 // CreateRoleRequest 创建角色请求结构
 type CreateRoleRequest struct {
 	Name          string `json:"name" validate:"required"` // 角色名称，必填
 	DisplayName   string `json:"display_name"`             // 角色显示名称，可选
 	Description   string `json:"description"`              // 角色描述，可选
 	PermissionIDs []uint `json:"permission_ids"`           // 权限ID列表，可选
+	ParentRoleID  *uint  `json:"parent_role_id"`           // 父角色ID，可选，继承父角色权限
 }
 
 // UpdateRoleRequest 更新角色请求结构
@@ -73,6 +148,7 @@ type UpdateRoleRequest struct {
 	Description   string      `json:"description"`    // 角色描述，可选
 	Status        *RoleStatus `json:"status"`         // 角色状态，可选，使用指针以区分零值和未设置
 	PermissionIDs []uint      `json:"permission_ids"` // 权限ID列表，可选
+	ParentRoleID  *uint       `json:"parent_role_id"` // 父角色ID，可选；nil 不修改，0 取消继承
 }
 
 // CreatePermissionRequest 创建权限请求结构
@@ -36,6 +36,18 @@ type RegisterResponse struct {
 	Message string `json:"message"` // 注册成功消息
 }
 
+// TOTPEnrollResponse 两步验证绑定响应结构
+type TOTPEnrollResponse struct {
+	Secret     string `json:"secret"`      // base32 密钥，供无法扫码时手动输入
+	OTPAuthURL string `json:"otpauth_url"` // otpauth:// 配置URI，前端据此生成二维码
+}
+
+// CreateAPIKeyResponse 创建 API 密钥响应结构，Key 只在此时返回一次
+type CreateAPIKeyResponse struct {
+	Key    string  `json:"key"`     // 完整密钥，请立即保存，之后无法再次查看
+	APIKey *APIKey `json:"api_key"` // 密钥信息
+}
+
 // UserInfo 用户信息响应结构
 type UserInfo struct {
 	ID          uint       `json:"id"`                    // 用户ID
@@ -50,16 +62,18 @@ type UserInfo struct {
 	Roles       []string   `json:"roles,omitempty"`       // 用户角色名称列表
 	Permissions []string   `json:"permissions,omitempty"` // 用户权限名称列表
 	Remark      string     `json:"remark,omitempty"`      // 备注
+	LockVersion int64      `json:"lock_version"`          // 乐观锁版本号，更新用户时回传
 }
 
 // APIResponse 通用API响应结构
 type APIResponse struct {
-	Code    int               `json:"code"`             // 响应状态码
-	Status  string            `json:"status"`           // 响应状态："success" 或 "failed"
-	Message string            `json:"message"`          // 响应消息
-	Data    interface{}       `json:"data,omitempty"`   // 响应数据，可选
-	Error   string            `json:"error,omitempty"`  // 错误信息，可选
-	Errors  []ValidationError `json:"errors,omitempty"` // 验证错误列表，可选
+	Code      int               `json:"code"`                 // 响应状态码
+	Status    string            `json:"status"`               // 响应状态："success" 或 "failed"
+	Message   string            `json:"message"`              // 响应消息
+	Data      interface{}       `json:"data,omitempty"`       // 响应数据，可选
+	Error     string            `json:"error,omitempty"`      // 错误信息，可选
+	ErrorCode string            `json:"error_code,omitempty"` // 机器可读错误码，可选，见 ErrCode* 常量
+	Errors    []ValidationError `json:"errors,omitempty"`     // 验证错误列表，可选
 }
 
 // PaginationResponse 分页响应结构
@@ -73,6 +87,38 @@ type PaginationResponse struct {
 	Data        interface{} `json:"data"`         // 分页数据
 }
 
+// PaginatedResponse 统一分页列表响应结构
+// 列表接口直接返回当前页数据与完整分页信息，客户端无需自行推算总页数
+type PaginatedResponse struct {
+	Items      interface{} `json:"items"`       // 当前页数据
+	Total      int64       `json:"total"`       // 总记录数
+	Page       int         `json:"page"`        // 当前页码
+	PageSize   int         `json:"page_size"`   // 每页大小
+	TotalPages int         `json:"total_pages"` // 总页数
+	HasNext    bool        `json:"has_next"`    // 是否有下一页
+}
+
+// NewPaginatedResponse 构造分页列表响应，总页数向上取整，pageSize <= 0 时总页数为 0
+func NewPaginatedResponse(items interface{}, total int64, page, pageSize int) *PaginatedResponse {
+	totalPages := CalcTotalPages(total, pageSize)
+	return &PaginatedResponse{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+	}
+}
+
+// CalcTotalPages 计算总页数(向上取整)，pageSize 非正或无记录时返回 0
+func CalcTotalPages(total int64, pageSize int) int {
+	if pageSize <= 0 || total <= 0 {
+		return 0
+	}
+	return int((total + int64(pageSize) - 1) / int64(pageSize))
+}
+
 // UserListResponse 用户列表响应结构
 type UserListResponse struct {
 	Users      []UserInfo          `json:"users"`                // 用户列表
@@ -0,0 +1,32 @@
+package system
+
+import "testing"
+
+func TestNewPaginatedResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		total          int64
+		page, pageSize int
+		wantPages      int
+		wantHasNext    bool
+	}{
+		{"exact_division", 20, 1, 10, 2, true},
+		{"ceiling", 21, 2, 10, 3, true},
+		{"last_page", 21, 3, 10, 3, false},
+		{"empty", 0, 1, 10, 0, false},
+		{"zero_page_size", 5, 1, 0, 0, false},
+		{"page_beyond_end", 5, 4, 10, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewPaginatedResponse([]int{}, tt.total, tt.page, tt.pageSize)
+			if got.TotalPages != tt.wantPages || got.HasNext != tt.wantHasNext {
+				t.Errorf("NewPaginatedResponse(total=%d, page=%d, size=%d) = pages %d, has_next %t; want %d, %t",
+					tt.total, tt.page, tt.pageSize, got.TotalPages, got.HasNext, tt.wantPages, tt.wantHasNext)
+			}
+			if got.Total != tt.total || got.Page != tt.page || got.PageSize != tt.pageSize {
+				t.Errorf("NewPaginatedResponse() echoed paging fields = %+v", got)
+			}
+		})
+	}
+}
@@ -22,6 +22,9 @@ type Role struct {
 	UpdatedAt   time.Time  `json:"updated_at"`                                                   // 更新时间，自动管理
 	DeletedAt   *time.Time `json:"-" gorm:"index"`                                               // 软删除时间，不在JSON中返回
 
+	// 角色继承：除自身权限外，还继承父角色链上所有角色的权限
+	ParentRoleID *uint `json:"parent_role_id" gorm:"index;comment:父角色ID,继承父角色权限"` // 父角色ID，为空表示顶层角色
+
 	// 关联关系
 	Users       []User       `json:"-" gorm:"many2many:user_roles;"`                 // 拥有此角色的用户，多对多关系
 	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"` // 角色拥有的权限，多对多关系
@@ -18,6 +18,7 @@ type User struct {
 	Email       string     `json:"email" gorm:"uniqueIndex;not null;size:100" validate:"required,email"`          // 邮箱地址，唯一索引，必须符合邮箱格式
 	Password    string     `json:"-" gorm:"not null;size:255"`                                                    // 用户密码，加密存储，不在JSON中返回
 	PasswordV   int64      `json:"-" gorm:"default:1;comment:密码版本号,用于使旧token失效"`                                  // 密码版本控制，用于token失效机制
+	LockVersion int64      `json:"lock_version" gorm:"not null;default:1;comment:乐观锁版本号"`                         // 乐观锁版本号，每次更新递增，防止并发编辑互相覆盖
 	Nickname    string     `json:"nickname" gorm:"size:50"`                                                       // 用户昵称，最大50字符
 	Avatar      string     `json:"avatar" gorm:"size:255"`                                                        // 用户头像URL，最大255字符
 	Phone       string     `json:"phone" gorm:"size:20"`                                                          // 手机号码，最大20字符
@@ -26,6 +27,8 @@ type User struct {
 	Status      UserStatus `json:"status" gorm:"default:1;comment:用户状态:0-禁用,1-启用"`                                // 用户状态，默认启用
 	LastLoginAt *time.Time `json:"last_login_at" gorm:"comment:最后登录时间"`                                           // 最后登录时间，可为空
 	LastLoginIP string     `json:"last_login_ip" gorm:"size:45;comment:最后登录IP"`                                   // 最后登录IP地址，支持IPv6
+	TOTPSecret  string     `json:"-" gorm:"size:255;comment:TOTP密钥(加密存储)"`                                        // TOTP 密钥密文(AES-GCM)，不在JSON中返回
+	TOTPEnabled bool       `json:"totp_enabled" gorm:"not null;default:false;comment:是否启用TOTP"`                   // 是否启用 TOTP 两步验证，启用后登录需提供动态码
 	CreatedAt   time.Time  `json:"created_at"`                                                                    // 创建时间，自动管理
 	UpdatedAt   time.Time  `json:"updated_at"`                                                                    // 更新时间，自动管理
 	DeletedAt   *time.Time `json:"-" gorm:"index"`                                                                // 软删除时间，不在JSON中返回
@@ -1,6 +1,10 @@
 package tag_system
 
-import "neomaster/internal/pkg/matcher"
+import (
+	"encoding/json"
+
+	"neomaster/internal/pkg/matcher"
+)
 
 // CreateTagRequest 创建标签请求
 type CreateTagRequest struct {
@@ -48,6 +52,15 @@ type AutoTagRequest struct {
 	Attributes map[string]interface{} `json:"attributes" validate:"required"`
 }
 
+// TestRuleRequest 匹配规则测试请求
+// rule_id 与 rule_json 二选一：rule_id 测试已保存的规则，rule_json 测试编辑中尚未保存的规则
+type TestRuleRequest struct {
+	RuleID   uint64                 `json:"rule_id"`                  // 已保存的规则ID
+	RuleJSON json.RawMessage        `json:"rule_json"`                // 待测试的匹配规则 (JSON对象，保留原文以便报告解析错误)
+	TagID    uint64                 `json:"tag_id"`                   // 命中时将打的标签ID (测试 rule_json 时可选)
+	Data     map[string]interface{} `json:"data" validate:"required"` // 样例实体数据
+}
+
 // ManualTagRequest 手动打标请求
 type ManualTagRequest struct {
 	EntityType string   `json:"entity_type" validate:"required"`
@@ -40,3 +40,32 @@ type EntityTagResponse struct {
 	Source  string `json:"source"`
 	RuleID  uint64 `json:"rule_id"`
 }
+
+// TestRuleResponse 匹配规则测试结果
+type TestRuleResponse struct {
+	Matched bool     `json:"matched"`           // 样例数据是否命中规则
+	RuleID  uint64   `json:"rule_id,omitempty"` // 测试的已保存规则ID
+	Tags    []SysTag `json:"tags"`              // 命中时将打的标签 (未命中或未指定标签时为空)
+}
+
+// 批量重打标任务状态
+const (
+	RetagStatusRunning   = "running"
+	RetagStatusCompleted = "completed"
+	RetagStatusCancelled = "cancelled" // 已取消，下次执行从 Cursor 续跑
+	RetagStatusFailed    = "failed"    // 出错中断，下次执行从 Cursor 续跑
+)
+
+// RetagProgress 批量重打标进度 (按实体类型)
+type RetagProgress struct {
+	EntityType string     `json:"entity_type"`
+	Status     string     `json:"status"`
+	Processed  int        `json:"processed"` // 本轮已评估的实体数 (续跑时累计)
+	Changed    int        `json:"changed"`   // 本轮 auto 标签发生变化的实体数 (续跑时累计)
+	Failed     int        `json:"failed"`    // 本轮打标失败而跳过的实体数
+	Cursor     uint64     `json:"cursor"`    // 已处理到的最大实体主键ID
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
@@ -3,6 +3,8 @@
 package tag_system
 
 import (
+	"time"
+
 	"neomaster/internal/model/basemodel"
 )
 
@@ -54,3 +56,22 @@ type SysEntityTag struct {
 func (SysEntityTag) TableName() string {
 	return "sys_entity_tags"
 }
+
+// SysRetagJob 批量重打标任务进度表 (每种实体类型一行)
+// 进度随批次落库，master 重启后从 Cursor 之后续跑
+type SysRetagJob struct {
+	basemodel.BaseModel
+	EntityType string     `json:"entity_type" gorm:"size:50;not null;uniqueIndex"`
+	Status     string     `json:"status" gorm:"size:20;not null;index"`     // running, completed, cancelled, failed
+	Cursor     uint64     `json:"cursor" gorm:"column:cursor_id;default:0"` // 最后处理完成的实体主键ID (cursor 为 MySQL 保留字)
+	Processed  int        `json:"processed" gorm:"default:0"`
+	Changed    int        `json:"changed" gorm:"default:0"`
+	Failed     int        `json:"failed" gorm:"default:0"`
+	Error      string     `json:"error" gorm:"type:text"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+func (SysRetagJob) TableName() string {
+	return "sys_retag_jobs"
+}
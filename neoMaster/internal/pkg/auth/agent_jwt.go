@@ -3,9 +3,15 @@
 package auth
 
 import (
+	"errors"
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// agentTokenAudience Agent Token 的受众，用于与用户系统的 Token 区分
+const agentTokenAudience = "neoscan-agent"
+
 // AgentClaims 定义 Agent 专属的 JWT Claims
 // 区别于用户系统的 Claims，这里只包含 Agent 及其宿主机的身份信息
 type AgentClaims struct {
@@ -14,8 +20,65 @@ type AgentClaims struct {
 	jwt.RegisteredClaims
 }
 
-// TODO: 后续实现 AgentJWTManager
-// type AgentJWTManager struct {
-//     secretKey []byte
-//     ...
-// }
+// AgentJWTManager Agent Token 管理器
+// 签名密钥应与用户系统的 JWT 密钥分开配置，避免两类 Token 互相冒用
+type AgentJWTManager struct {
+	secretKey []byte
+	tokenTTL  time.Duration
+}
+
+// NewAgentJWTManager 创建 Agent Token 管理器
+func NewAgentJWTManager(secretKey string, tokenTTL time.Duration) *AgentJWTManager {
+	return &AgentJWTManager{
+		secretKey: []byte(secretKey),
+		tokenTTL:  tokenTTL,
+	}
+}
+
+// GenerateToken 为 Agent 签发 Token，返回 Token 及其过期时间
+// now 由调用方传入，便于与数据库中记录的 TokenExpiry 保持一致
+func (m *AgentJWTManager) GenerateToken(agentID, hostname string, now time.Time) (string, time.Time, error) {
+	if agentID == "" {
+		return "", time.Time{}, errors.New("agent id is required")
+	}
+	expiry := now.Add(m.tokenTTL)
+	claims := &AgentClaims{
+		AgentID:  agentID,
+		Hostname: hostname,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "neoscan",
+			Subject:   agentID,
+			Audience:  []string{agentTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(expiry),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        generateJTI(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(m.secretKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiry, nil
+}
+
+// ValidateToken 验证 Agent Token 的签名、受众与有效期
+// 过期的 Token 返回的错误满足 errors.Is(err, jwt.ErrTokenExpired)
+func (m *AgentJWTManager) ValidateToken(tokenString string, now time.Time) (*AgentClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &AgentClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return m.secretKey, nil
+	}, jwt.WithAudience(agentTokenAudience), jwt.WithTimeFunc(func() time.Time { return now }))
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*AgentClaims); ok && token.Valid {
+		return claims, nil
+	}
+	return nil, errors.New("invalid agent token")
+}
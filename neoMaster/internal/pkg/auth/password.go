@@ -6,6 +6,7 @@
  * @func:
  * 	1.哈希密码
  * 	2.验证密码
+ * 	3.检测是否需要按当前参数重新哈希
  */
 package auth
 
@@ -34,7 +35,7 @@ var DefaultPasswordConfig = &PasswordConfig{
 	Memory:      64 * 1024, // 64MB
 	Iterations:  3,
 	Parallelism: 2,
-	SaltLength:  16,
+	SaltLength:  32, // 与历史部署一致，未配置 security.password 时不降低盐长度
 	KeyLength:   32,
 }
 
@@ -43,13 +44,28 @@ type PasswordManager struct {
 	config *PasswordConfig
 }
 
-// NewPasswordManager 创建密码管理器
+// NewPasswordManager 创建密码管理器，config 为 nil 或其中为 0 的项使用默认配置
 func NewPasswordManager(config *PasswordConfig) *PasswordManager {
-	if config == nil {
-		config = DefaultPasswordConfig
+	merged := *DefaultPasswordConfig
+	if config != nil {
+		if config.Memory > 0 {
+			merged.Memory = config.Memory
+		}
+		if config.Iterations > 0 {
+			merged.Iterations = config.Iterations
+		}
+		if config.Parallelism > 0 {
+			merged.Parallelism = config.Parallelism
+		}
+		if config.SaltLength > 0 {
+			merged.SaltLength = config.SaltLength
+		}
+		if config.KeyLength > 0 {
+			merged.KeyLength = config.KeyLength
+		}
 	}
 	return &PasswordManager{
-		config: config,
+		config: &merged,
 	}
 }
 
@@ -118,6 +134,21 @@ func (pm *PasswordManager) VerifyPassword(password, encodedHash string) (bool, e
 	return subtle.ConstantTimeCompare(hash, otherHash) == 1, nil
 }
 
+// NeedsRehash 判断哈希使用的参数是否弱于当前配置
+// 任一参数低于当前配置(或哈希无法解析)时返回 true，调用方应在持有明文时(如登录成功后)按当前参数重新哈希
+// 参数高于当前配置的哈希不会被降级
+func (pm *PasswordManager) NeedsRehash(encodedHash string) bool {
+	config, _, _, err := pm.decodeHash(encodedHash)
+	if err != nil {
+		return true
+	}
+	return config.Memory < pm.config.Memory ||
+		config.Iterations < pm.config.Iterations ||
+		config.Parallelism < pm.config.Parallelism ||
+		config.SaltLength < pm.config.SaltLength ||
+		config.KeyLength < pm.config.KeyLength
+}
+
 // decodeHash 解码哈希字符串
 func (pm *PasswordManager) decodeHash(encodedHash string) (*PasswordConfig, []byte, []byte, error) {
 	parts := strings.Split(encodedHash, "$")
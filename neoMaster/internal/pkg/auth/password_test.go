@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"testing"
+)
+
+// seededAdminHash 迁移脚本写入的默认管理员哈希(m=65536,t=3,p=2，16 字节盐)
+const seededAdminHash = "$argon2id$v=19$m=65536,t=3,p=2$lMamQlbNnoIXZfszn4jWqw$zVTokU4nXju4CdOR1bH5ABOMbaEagr8mTXrhAh/p0kQ"
+
+// 低成本参数，保证测试速度
+var weakPasswordConfig = &PasswordConfig{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordManager_VerifyOldHashAfterParamsUpgrade(t *testing.T) {
+	oldHash, err := NewPasswordManager(weakPasswordConfig).HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	// 参数调高后，旧哈希仍按其自身编码的参数验证
+	upgraded := NewPasswordManager(&PasswordConfig{Memory: 2048, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	if ok, err := upgraded.VerifyPassword("secret123", oldHash); err != nil || !ok {
+		t.Errorf("VerifyPassword(old hash) = %v, %v; want true", ok, err)
+	}
+	if ok, _ := upgraded.VerifyPassword("wrong123", oldHash); ok {
+		t.Error("VerifyPassword(wrong password) = true")
+	}
+}
+
+func TestPasswordManager_NeedsRehash(t *testing.T) {
+	weakHash, err := NewPasswordManager(weakPasswordConfig).HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config *PasswordConfig
+		hash   string
+		want   bool
+	}{
+		{"same params", weakPasswordConfig, weakHash, false},
+		{"memory raised", &PasswordConfig{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, weakHash, true},
+		{"iterations raised", &PasswordConfig{Memory: 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}, weakHash, true},
+		{"parallelism raised", &PasswordConfig{Memory: 1024, Iterations: 1, Parallelism: 2, SaltLength: 16, KeyLength: 32}, weakHash, true},
+		{"salt length raised", &PasswordConfig{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 32, KeyLength: 32}, weakHash, true},
+		{"params lowered", &PasswordConfig{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 8, KeyLength: 16}, weakHash, false},
+		{"seeded hash with default params", nil, seededAdminHash, true}, // 默认盐长度 32 字节，16 字节盐的哈希登录后升级
+		{"seeded hash with 16 byte salt", &PasswordConfig{SaltLength: 16}, seededAdminHash, false},
+		{"malformed hash", weakPasswordConfig, "not-a-hash", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPasswordManager(tt.config).NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 按当前参数重新哈希后不再需要重新哈希
+	upgraded := NewPasswordManager(&PasswordConfig{Memory: 2048, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	rehashed, err := upgraded.HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if upgraded.NeedsRehash(rehashed) {
+		t.Error("NeedsRehash(rehashed) = true, want false")
+	}
+}
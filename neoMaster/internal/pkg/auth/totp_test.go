@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录B SHA1 测试密钥 "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 附录B 给出的是8位动态码，6位动态码取其后6位
+	vectors := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, v := range vectors {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) error = %v", v.unix, err)
+		}
+		if got != v.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", v.unix, got, v.want)
+		}
+	}
+}
+
+func TestValidateTOTP_DriftWindow(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		code, _ := TOTPCode(rfc6238Secret, uint64(int64(step)+offset))
+		matched, ok, err := ValidateTOTP(rfc6238Secret, code, now)
+		if err != nil || !ok || matched != uint64(int64(step)+offset) {
+			t.Errorf("offset %d: ValidateTOTP() = %d, %v, %v", offset, matched, ok, err)
+		}
+	}
+	for _, offset := range []int64{-2, 2} {
+		code, _ := TOTPCode(rfc6238Secret, uint64(int64(step)+offset))
+		if _, ok, _ := ValidateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("offset %d: code outside drift window accepted", offset)
+		}
+	}
+	if _, ok, _ := ValidateTOTP(rfc6238Secret, "12345", now); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestGenerateTOTPSecret_ProvisioningURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	if key, err := decodeTOTPSecret(secret); err != nil || len(key) != totpSecretSize {
+		t.Fatalf("secret %q decodes to %d bytes, err = %v", secret, len(key), err)
+	}
+
+	uri, err := url.Parse(TOTPProvisioningURI("NeoScan", "admin", secret))
+	if err != nil {
+		t.Fatalf("parse uri: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || !strings.HasSuffix(uri.Path, "NeoScan:admin") {
+		t.Errorf("uri = %s", uri)
+	}
+	if q := uri.Query(); q.Get("secret") != secret || q.Get("issuer") != "NeoScan" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("uri query = %v", q)
+	}
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrBeginTransaction 开启事务失败
+	ErrBeginTransaction = errors.New("开始事务失败")
+	// ErrCommitTransaction 提交事务失败
+	ErrCommitTransaction = errors.New("提交事务失败")
+)
+
+// WithTransaction 在事务中执行 fn
+// 1. fn 返回错误时回滚事务，并原样返回该错误(可用 errors.Is/As 判断)
+// 2. fn 发生 panic 时回滚事务后重新抛出，交由上层 Recovery 处理
+// 3. fn 正常返回时提交事务，提交失败返回包装了 ErrCommitTransaction 的错误
+// fn 内的所有数据库操作必须使用传入的 tx，而不是外层的 db
+func WithTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	tx := db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("%w: %w", ErrBeginTransaction, tx.Error)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		// 回滚失败不覆盖业务错误
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%w: %w", ErrCommitTransaction, err)
+	}
+	return nil
+}
+
+// IsTransactionError 判断错误是否来自事务的开启或提交，而不是 fn 本身
+func IsTransactionError(err error) bool {
+	return errors.Is(err, ErrBeginTransaction) || errors.Is(err, ErrCommitTransaction)
+}
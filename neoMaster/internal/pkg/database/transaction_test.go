@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type txRecord struct {
+	ID   uint
+	Name string
+}
+
+// newTxTestDB :memory: 数据库每个连接独立，限制为单连接保证事务内外看到同一个库
+func newTxTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql.DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&txRecord{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	return db
+}
+
+func countTxRecords(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&txRecord{}).Count(&count).Error; err != nil {
+		t.Fatalf("count records: %v", err)
+	}
+	return count
+}
+
+func TestWithTransaction_Commit(t *testing.T) {
+	db := newTxTestDB(t)
+
+	err := WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		return tx.Create(&txRecord{Name: "a"}).Error
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction() error = %v", err)
+	}
+	if n := countTxRecords(t, db); n != 1 {
+		t.Errorf("records = %d, want 1", n)
+	}
+}
+
+func TestWithTransaction_RollbackReturnsOriginalError(t *testing.T) {
+	db := newTxTestDB(t)
+	errBusiness := errors.New("business failed")
+
+	err := WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		if err := tx.Create(&txRecord{Name: "a"}).Error; err != nil {
+			return err
+		}
+		return errBusiness
+	})
+	if err != errBusiness {
+		t.Fatalf("WithTransaction() error = %v, want %v", err, errBusiness)
+	}
+	if IsTransactionError(err) {
+		t.Errorf("business error reported as transaction error")
+	}
+	if n := countTxRecords(t, db); n != 0 {
+		t.Errorf("records = %d, want 0 after rollback", n)
+	}
+}
+
+func TestWithTransaction_PanicRollsBackAndRepanics(t *testing.T) {
+	db := newTxTestDB(t)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, want boom", r)
+			}
+		}()
+		_ = WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+			tx.Create(&txRecord{Name: "a"})
+			panic("boom")
+		})
+	}()
+
+	if n := countTxRecords(t, db); n != 0 {
+		t.Errorf("records = %d, want 0 after panic", n)
+	}
+}
+
+func TestWithTransaction_CommitError(t *testing.T) {
+	db := newTxTestDB(t)
+
+	// fn 内提前结束事务，随后的提交必然失败且错误不能被吞掉
+	err := WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		return tx.Rollback().Error
+	})
+	if !errors.Is(err, ErrCommitTransaction) || !IsTransactionError(err) {
+		t.Errorf("WithTransaction() error = %v, want ErrCommitTransaction", err)
+	}
+}
@@ -0,0 +1,33 @@
+// 审计日志旁路输出
+package logger
+
+import "sync"
+
+// AuditSink 审计日志旁路输出
+// LogAuditOperation 写日志流的同时把条目交给 AuditSink(如异步落库)；
+// 实现必须立即返回，不得阻塞请求路径
+type AuditSink interface {
+	WriteAudit(entry *AuditLogEntry)
+}
+
+var (
+	auditSinkMu sync.RWMutex
+	auditSink   AuditSink
+)
+
+// SetAuditSink 设置审计日志旁路输出，传入 nil 关闭旁路输出
+func SetAuditSink(sink AuditSink) {
+	auditSinkMu.Lock()
+	defer auditSinkMu.Unlock()
+	auditSink = sink
+}
+
+// writeAuditSink 将审计条目交给旁路输出(未设置时忽略)
+func writeAuditSink(entry *AuditLogEntry) {
+	auditSinkMu.RLock()
+	sink := auditSink
+	auditSinkMu.RUnlock()
+	if sink != nil {
+		sink.WriteAudit(entry)
+	}
+}
@@ -0,0 +1,126 @@
+// 请求上下文中的日志关联信息
+package logger
+
+import (
+	"context"
+
+	"neomaster/internal/pkg/utils"
+)
+
+// requestIDKey 请求ID在 context 中的键
+type requestIDKey struct{}
+
+// userIDKey 当前用户ID在 context 中的键
+type userIDKey struct{}
+
+// ContextWithRequestID 将请求ID写入 context
+// 由请求ID中间件调用，Service/Repo 层通过 RequestIDFromContext 取出，无需再从 Header 中读取
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 从 context 中获取请求ID，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// ContextWithUserID 将当前用户ID写入 context (由 JWT 认证中间件调用)
+func ContextWithUserID(ctx context.Context, userID uint) context.Context {
+	if userID == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext 从 context 中获取当前用户ID，未认证时返回 0
+func UserIDFromContext(ctx context.Context) uint {
+	if ctx == nil {
+		return 0
+	}
+	if userID, ok := ctx.Value(userIDKey{}).(uint); ok {
+		return userID
+	}
+	return 0
+}
+
+// ContextLogger 绑定了请求关联信息(请求ID/用户ID/客户端IP)的日志记录器
+// 由 WithContext 创建，调用方只需传入错误/消息与额外字段，
+// 关联字段自动填充，等价于对应的 LogXxx 位置参数版本
+type ContextLogger struct {
+	requestID string
+	userID    uint
+	clientIP  string
+	path      string
+	method    string
+}
+
+// WithContext 从 context 中提取中间件写入的请求ID、用户ID、客户端IP，返回绑定这些字段的日志记录器
+// 用法示例: logger.WithContext(ctx).WithOperation("user_create", "POST").BusinessError(err, fields)
+func WithContext(ctx context.Context) *ContextLogger {
+	l := &ContextLogger{}
+	if ctx == nil {
+		return l
+	}
+	l.requestID = RequestIDFromContext(ctx)
+	l.userID = UserIDFromContext(ctx)
+	l.clientIP = utils.GetClientIPFromContext(ctx)
+	return l
+}
+
+// WithOperation 返回设置了 path/method 字段的副本 (对应 LogXxx 的 path、method 参数)
+func (l *ContextLogger) WithOperation(path, method string) *ContextLogger {
+	clone := *l
+	clone.path = path
+	clone.method = method
+	return &clone
+}
+
+// WithUserID 返回覆盖了用户ID的副本 (用于 context 中尚无用户信息、业务中途才确定用户的场景，如令牌解析)
+func (l *ContextLogger) WithUserID(userID uint) *ContextLogger {
+	clone := *l
+	clone.userID = userID
+	return &clone
+}
+
+// RequestID 绑定的请求ID
+func (l *ContextLogger) RequestID() string { return l.requestID }
+
+// UserID 绑定的用户ID
+func (l *ContextLogger) UserID() uint { return l.userID }
+
+// ClientIP 绑定的客户端IP
+func (l *ContextLogger) ClientIP() string { return l.clientIP }
+
+// Error 记录系统错误日志，见 LogError
+func (l *ContextLogger) Error(err error, extraFields map[string]interface{}) {
+	LogError(err, l.requestID, l.userID, l.clientIP, l.path, l.method, extraFields)
+}
+
+// BusinessError 记录业务错误日志，见 LogBusinessError
+func (l *ContextLogger) BusinessError(err error, extraFields map[string]interface{}) {
+	LogBusinessError(err, l.requestID, l.userID, l.clientIP, l.path, l.method, extraFields)
+}
+
+// Info 记录信息日志，见 LogInfo
+func (l *ContextLogger) Info(message string, extraFields map[string]interface{}) {
+	LogInfo(message, l.requestID, l.userID, l.clientIP, l.path, l.method, extraFields)
+}
+
+// Warn 记录警告日志，见 LogWarn
+func (l *ContextLogger) Warn(message string, extraFields map[string]interface{}) {
+	LogWarn(message, l.requestID, l.userID, l.clientIP, l.path, l.method, extraFields)
+}
+
+// BusinessOperation 记录业务操作日志，见 LogBusinessOperation
+func (l *ContextLogger) BusinessOperation(operation, username, result, message string, extraFields map[string]interface{}) {
+	LogBusinessOperation(operation, l.userID, username, l.clientIP, l.requestID, result, message, extraFields)
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"neomaster/internal/pkg/utils"
+)
+
+func TestWithContext(t *testing.T) {
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ctx = ContextWithUserID(ctx, 42)
+	ctx = context.WithValue(ctx, utils.ContextKeyClientIP, "10.0.0.1")
+
+	l := WithContext(ctx)
+	if l.RequestID() != "req-1" || l.UserID() != 42 || l.ClientIP() != "10.0.0.1" {
+		t.Fatalf("WithContext() = {%q %d %q}", l.RequestID(), l.UserID(), l.ClientIP())
+	}
+
+	// WithUserID/WithOperation 返回副本，不影响原记录器
+	other := l.WithOperation("user_create", "POST").WithUserID(7)
+	if other.UserID() != 7 || other.path != "user_create" || other.method != "POST" {
+		t.Errorf("derived logger = %+v", other)
+	}
+	if l.UserID() != 42 || l.path != "" {
+		t.Errorf("original logger modified: %+v", l)
+	}
+
+	empty := WithContext(context.Background())
+	if empty.RequestID() != "" || empty.UserID() != 0 || empty.ClientIP() != "" {
+		t.Errorf("WithContext(empty) = %+v", empty)
+	}
+}
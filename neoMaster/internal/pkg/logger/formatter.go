@@ -443,23 +443,26 @@ func LogSystemEvent(component, event, message string, level LogLevel, extraField
 
 // LogAuditOperation 记录审计日志
 // 用于记录安全相关的操作，满足审计和合规要求
+// 设置了 AuditSink 时同时交给旁路输出(审计日志落库)，与日志流是否初始化无关
 func LogAuditOperation(userID uint, username, action, resource, result, clientIP, userAgent, requestID string, extraFields map[string]interface{}) {
+	entry := AuditLogEntry{
+		Timestamp:   time.Now(),
+		UserID:      userID,
+		Username:    username,
+		Action:      action,
+		Resource:    resource,
+		Result:      result,
+		ClientIP:    clientIP,
+		UserAgent:   userAgent,
+		RequestID:   requestID,
+		ExtraFields: extraFields,
+	}
+	writeAuditSink(&entry)
+
 	if LoggerInstance == nil {
 		return
 	}
 
-	// 构建审计日志条目（移除未使用的Timestamp字段）
-	entry := AuditLogEntry{
-		UserID:    userID,
-		Username:  username,
-		Action:    action,
-		Resource:  resource,
-		Result:    result,
-		ClientIP:  clientIP,
-		UserAgent: userAgent,
-		RequestID: requestID,
-	}
-
 	// 构建日志字段（移除重复的timestamp字段，使用logrus自带的时间戳）
 	fields := logrus.Fields{
 		"type":       AuditLog,
@@ -15,6 +15,7 @@ import (
 // FileHook 是一个自定义Hook，用于将不同类型的日志写入不同的文件
 type FileHook struct {
 	logConfig *config.LogConfig
+	filePath  string // 主日志文件路径，为空表示控制台输出，不写文件
 	writers   map[string]io.Writer
 	formatter logrus.Formatter
 	mutex     sync.Mutex
@@ -24,6 +25,7 @@ type FileHook struct {
 func NewFileHook(logConfig *config.LogConfig) *FileHook {
 	hook := &FileHook{
 		logConfig: logConfig,
+		filePath:  resolveLogFilePath(logConfig),
 		writers:   make(map[string]io.Writer),
 		formatter: &logrus.JSONFormatter{
 			TimestampFormat: "2006-01-02 15:04:05.000",
@@ -45,16 +47,21 @@ func NewFileHook(logConfig *config.LogConfig) *FileHook {
 
 // initDefaultWriter 初始化默认writer（主日志文件）
 func (hook *FileHook) initDefaultWriter() {
-	if hook.logConfig.Output == "file" && hook.logConfig.FilePath != "" {
-		// 确保日志目录存在
-		_ = os.MkdirAll(filepath.Dir(hook.logConfig.FilePath), 0755)
-		hook.writers["default"] = &lumberjack.Logger{
-			Filename:   hook.logConfig.FilePath,
-			MaxSize:    hook.logConfig.MaxSize,
-			MaxBackups: hook.logConfig.MaxBackups,
-			MaxAge:     hook.logConfig.MaxAge,
-			Compress:   hook.logConfig.Compress,
-		}
+	if hook.filePath != "" {
+		hook.writers["default"] = hook.newRotatingWriter(hook.filePath)
+	}
+}
+
+// newRotatingWriter 创建按大小/天数/备份数轮转的文件writer
+func (hook *FileHook) newRotatingWriter(filename string) io.Writer {
+	// 确保日志目录存在
+	_ = os.MkdirAll(filepath.Dir(filename), 0755)
+	return &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    hook.logConfig.MaxSize,
+		MaxBackups: hook.logConfig.MaxBackups,
+		MaxAge:     hook.logConfig.MaxAge,
+		Compress:   hook.logConfig.Compress,
 	}
 }
 
@@ -110,10 +117,15 @@ func (hook *FileHook) getWriter(logType string) io.Writer {
 		return writer
 	}
 
+	// 控制台输出模式下不创建任何日志文件
+	if hook.filePath == "" {
+		return nil
+	}
+
 	// 根据日志类型创建对应的文件writer
 	var filename string
-	// 使用配置中的file_path获取日志目录
-	logDir := filepath.Dir(hook.logConfig.FilePath)
+	// 使用主日志文件路径获取日志目录
+	logDir := filepath.Dir(hook.filePath)
 
 	switch logType {
 	case "access":
@@ -133,17 +145,8 @@ func (hook *FileHook) getWriter(logType string) io.Writer {
 		return hook.writers["default"]
 	}
 
-	// 确保日志目录存在
-	_ = os.MkdirAll(filepath.Dir(filename), 0755)
-
 	// 创建新的lumberjack logger
-	writer := &lumberjack.Logger{
-		Filename:   filename,
-		MaxSize:    hook.logConfig.MaxSize,
-		MaxBackups: hook.logConfig.MaxBackups,
-		MaxAge:     hook.logConfig.MaxAge,
-		Compress:   hook.logConfig.Compress,
-	}
+	writer := hook.newRotatingWriter(filename)
 
 	// 保存到writers map中
 	hook.writers[logType] = writer
@@ -101,9 +101,19 @@ func setLogFormatter(logger *logrus.Logger, cfg *config.LogConfig) error {
 }
 
 // setLogOutput 设置日志输出目标
-// 使用Hook机制实现日志分离功能，将日志输出设置为io.Discard
-// 实际的日志输出将由FileHook处理
+// 文件输出时使用Hook机制实现日志分离功能，将日志输出设置为io.Discard
+// 实际的日志输出将由FileHook处理(按 max_size/max_backups/max_age/compress 轮转)
+// 控制台输出(stdout/stderr)时直接写控制台，轮转配置不生效
 func setLogOutput(logger *logrus.Logger, cfg *config.LogConfig) error {
+	switch strings.ToLower(cfg.Output) {
+	case "stdout", "":
+		logger.SetOutput(os.Stdout)
+		return nil
+	case "stderr":
+		logger.SetOutput(os.Stderr)
+		return nil
+	}
+
 	// 在调试模式下，同时输出到控制台和Hook机制
 	// 在生产模式下，只使用Hook机制
 	if strings.ToLower(cfg.Level) == "debug" {
@@ -118,6 +128,20 @@ func setLogOutput(logger *logrus.Logger, cfg *config.LogConfig) error {
 	return nil
 }
 
+// resolveLogFilePath 解析日志文件路径
+// output 为 file 时使用 file_path；output 为 stdout/stderr 时返回空(不写文件)；
+// 其他取值视为日志文件路径本身 (如 output: "/var/log/neoscan/app.log")
+func resolveLogFilePath(cfg *config.LogConfig) string {
+	switch strings.ToLower(cfg.Output) {
+	case "stdout", "stderr", "":
+		return ""
+	case "file":
+		return cfg.FilePath
+	default:
+		return cfg.Output
+	}
+}
+
 // GetLogger 获取logrus实例
 func (lm *LoggerManager) GetLogger() *logrus.Logger {
 	return lm.logger
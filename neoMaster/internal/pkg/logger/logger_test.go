@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"neomaster/internal/config"
+)
+
+func TestResolveLogFilePath(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.LogConfig
+		want string
+	}{
+		{"stdout", config.LogConfig{Output: "stdout", FilePath: "logs/app.log"}, ""},
+		{"stderr", config.LogConfig{Output: "STDERR", FilePath: "logs/app.log"}, ""},
+		{"file", config.LogConfig{Output: "file", FilePath: "logs/app.log"}, "logs/app.log"},
+		{"path", config.LogConfig{Output: "/var/log/neoscan/app.log"}, "/var/log/neoscan/app.log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveLogFilePath(&tt.cfg); got != tt.want {
+				t.Errorf("resolveLogFilePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitLogger_FileOutputWritesRotatingFiles(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.LogConfig{
+		Level:      "info",
+		Format:     "json",
+		Output:     filepath.Join(dir, "app.log"),
+		MaxSize:    1,
+		MaxBackups: 2,
+		MaxAge:     1,
+	}
+	lm, err := InitLogger(cfg)
+	if err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	if lm.GetLogger() == nil {
+		t.Fatal("InitLogger() returned manager without logger")
+	}
+
+	LogError(errors.New("boom"), "req-1", 0, "", "op", "TEST", nil)
+	LogBusinessOperation("op", 0, "", "", "req-1", "success", "done", nil)
+
+	for _, name := range []string{"error.log", "business.log"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if !strings.Contains(string(data), "req-1") {
+			t.Errorf("%s missing log entry: %s", name, data)
+		}
+	}
+}
+
+func TestInitLogger_StdoutIgnoresRotation(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.LogConfig{
+		Level:    "info",
+		Format:   "json",
+		Output:   "stdout",
+		FilePath: filepath.Join(dir, "app.log"),
+		MaxSize:  1,
+	}
+	if _, err := InitLogger(cfg); err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+
+	LogError(errors.New("boom"), "req-2", 0, "", "op", "TEST", nil)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("stdout output should not create log files, got %d entries", len(entries))
+	}
+}
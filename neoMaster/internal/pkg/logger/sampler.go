@@ -0,0 +1,129 @@
+// 高频日志采样
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sampledFlushInterval 被抑制日志汇总的输出间隔
+const sampledFlushInterval = time.Minute
+
+// sampleCounter 单个采样键的计数
+type sampleCounter struct {
+	seen       uint64    // 累计调用次数
+	suppressed uint64    // 上次汇总后被抑制的条数
+	lastFlush  time.Time // 上次输出汇总的时间
+}
+
+// logSampler 按键采样的日志计数器
+// 每个键独立计数，第 1、N+1、2N+1... 条输出，其余条数累计后定期汇总输出
+type logSampler struct {
+	mu       sync.Mutex
+	counters map[string]*sampleCounter
+	interval time.Duration
+}
+
+// defaultSampler 全局采样器
+var defaultSampler = newLogSampler(sampledFlushInterval)
+
+// newLogSampler 创建采样器
+func newLogSampler(interval time.Duration) *logSampler {
+	return &logSampler{
+		counters: make(map[string]*sampleCounter),
+		interval: interval,
+	}
+}
+
+// allow 判断本条日志是否输出
+// 返回值 flushed 大于 0 时表示需要先输出一条"已抑制 flushed 条"的汇总
+func (s *logSampler) allow(key string, rate int, now time.Time) (emit bool, flushed uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &sampleCounter{lastFlush: now}
+		s.counters[key] = counter
+	}
+
+	emit = rate <= 1 || counter.seen%uint64(rate) == 0
+	counter.seen++
+	if !emit {
+		counter.suppressed++
+	}
+
+	if counter.suppressed > 0 && now.Sub(counter.lastFlush) >= s.interval {
+		flushed = counter.suppressed
+		counter.suppressed = 0
+		counter.lastFlush = now
+	}
+	return emit, flushed
+}
+
+// drain 取出所有键的待汇总抑制条数并清零
+func (s *logSampler) drain() map[string]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := make(map[string]uint64)
+	now := time.Now()
+	for key, counter := range s.counters {
+		if counter.suppressed > 0 {
+			pending[key] = counter.suppressed
+			counter.suppressed = 0
+			counter.lastFlush = now
+		}
+	}
+	return pending
+}
+
+// LogInfoSampled 按采样率记录信息日志
+// 用于 Repo 层列表查询成功等高频、低价值的日志：同一 key 每 rate 条只输出 1 条，
+// 被抑制的条数不会丢失，每隔一段时间以"suppressed X messages"汇总输出一次
+// rate <= 1 时等同于 LogInfo；审计、错误类日志不应使用采样
+func LogInfoSampled(key string, rate int, message string, requestID string, userID uint, clientIP, path, method string, extraFields map[string]interface{}) {
+	if LoggerInstance == nil {
+		return
+	}
+
+	emit, flushed := defaultSampler.allow(key, rate, time.Now())
+	if flushed > 0 {
+		logSuppressedSummary(key, rate, flushed)
+	}
+	if !emit {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(extraFields)+1)
+	for k, v := range extraFields {
+		fields[k] = v
+	}
+	if rate > 1 {
+		fields["sample_rate"] = rate
+	}
+	LogInfo(message, requestID, userID, clientIP, path, method, fields)
+}
+
+// FlushSampledLogs 立即输出所有采样键尚未汇总的抑制条数 (用于服务关闭前)
+func FlushSampledLogs() {
+	if LoggerInstance == nil {
+		return
+	}
+	for key, suppressed := range defaultSampler.drain() {
+		logSuppressedSummary(key, 0, suppressed)
+	}
+}
+
+// logSuppressedSummary 输出被抑制日志的汇总
+func logSuppressedSummary(key string, rate int, suppressed uint64) {
+	fields := map[string]interface{}{
+		"sample_key": key,
+		"suppressed": suppressed,
+	}
+	if rate > 1 {
+		fields["sample_rate"] = rate
+	}
+	LogInfo(fmt.Sprintf("suppressed %d messages", suppressed), "", 0, "", "logger.sampler", "", fields)
+}
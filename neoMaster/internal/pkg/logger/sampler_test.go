@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogSampler_Allow(t *testing.T) {
+	s := newLogSampler(time.Minute)
+	start := time.Now()
+
+	var emitted []int
+	for i := 0; i < 7; i++ {
+		emit, flushed := s.allow("repo.agent.GetList", 3, start)
+		if emit {
+			emitted = append(emitted, i)
+		}
+		if flushed != 0 {
+			t.Fatalf("call %d flushed %d before interval elapsed", i, flushed)
+		}
+	}
+	if want := []int{0, 3, 6}; len(emitted) != len(want) || emitted[0] != 0 || emitted[1] != 3 || emitted[2] != 6 {
+		t.Fatalf("emitted = %v, want %v", emitted, want)
+	}
+
+	// 间隔到期后汇总被抑制的条数: 此前 4 条 + 本次(第 8 条)
+	emit, flushed := s.allow("repo.agent.GetList", 3, start.Add(time.Minute))
+	if emit || flushed != 5 {
+		t.Fatalf("after interval: emit=%t flushed=%d, want false/5", emit, flushed)
+	}
+
+	// 不同键互不影响，rate <= 1 不采样
+	for i := 0; i < 3; i++ {
+		if emit, _ := s.allow("other", 1, start); !emit {
+			t.Fatalf("rate 1 should always emit")
+		}
+	}
+}
+
+func TestLogSampler_Drain(t *testing.T) {
+	s := newLogSampler(time.Hour)
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		s.allow("a", 5, now)
+	}
+	s.allow("b", 5, now)
+
+	pending := s.drain()
+	if pending["a"] != 8 {
+		t.Errorf("pending[a] = %d, want 8", pending["a"])
+	}
+	if _, ok := pending["b"]; ok {
+		t.Errorf("key b has nothing suppressed, should not be drained")
+	}
+	if again := s.drain(); len(again) != 0 {
+		t.Errorf("second drain = %v, want empty", again)
+	}
+}
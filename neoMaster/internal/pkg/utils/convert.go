@@ -8,9 +8,14 @@
 package utils
 
 import (
+	"bytes"
 	"database/sql/driver"
+	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
@@ -74,8 +79,10 @@ func StringToFloat64(str string, defaultValue float64) float64 {
 // StringToBool 字符串转布尔值，支持多种格式
 // 参数: str - 待转换的字符串, defaultValue - 转换失败时的默认值
 // 返回: 转换后的布尔值
-// 支持的true值: "true", "1", "yes", "on", "enabled"
-// 支持的false值: "false", "0", "no", "off", "disabled"
+// 忽略大小写和首尾空白，支持的取值:
+// true值: "true", "t", "1", "yes", "y", "on", "enable", "enabled"
+// false值: "false", "f", "0", "no", "n", "off", "disable", "disabled"
+// 空字符串及其他取值返回默认值
 func StringToBool(str string, defaultValue bool) bool {
 	if str == "" {
 		return defaultValue
@@ -83,9 +90,9 @@ func StringToBool(str string, defaultValue bool) bool {
 
 	str = strings.ToLower(strings.TrimSpace(str))
 	switch str {
-	case "true", "1", "yes", "on", "enabled":
+	case "true", "t", "1", "yes", "y", "on", "enable", "enabled":
 		return true
-	case "false", "0", "no", "off", "disabled":
+	case "false", "f", "0", "no", "n", "off", "disable", "disabled":
 		return false
 	default:
 		return defaultValue
@@ -414,6 +421,23 @@ func StringToSlice(str, separator string) []string {
 	return strings.Split(str, separator)
 }
 
+// ParseQueryStringSlice 解析多值查询参数
+// 参数: values - URL查询参数, key - 参数名
+// 返回: 字符串切片，参数不存在或全为空时返回nil
+// 同时支持重复参数与逗号分隔两种写法，可混用:
+// tags=2,7 / tags=2&tags=7 / tags=2,7&tags=9，每个值去除首尾空白，空值丢弃
+func ParseQueryStringSlice(values url.Values, key string) []string {
+	var result []string
+	for _, raw := range values[key] {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
 // SliceToString 切片按分隔符转字符串
 // 参数: slice - 字符串切片, separator - 分隔符
 // 返回: 连接后的字符串
@@ -548,19 +572,76 @@ func ConvertType(src interface{}, dst interface{}) error {
 // 参数: src - 源数据, dst - 目标数据指针
 // 返回: 错误信息
 // 注意: 这种方法简单但性能较低，且要求数据可JSON序列化
+// 局限: 未导出字段会被丢弃；interface{} 字段反序列化后变为 map/float64 等通用类型；
+// time.Time 丢失单调时钟与时区名称(仅保留偏移)；func/chan/complex 及 NaN/Inf 无法序列化，返回的错误中指明出错的类型或值
+// 需要更高保真度时使用 DeepCopyWithOptions 并选择 DeepCopyGob
 func DeepCopy(src interface{}, dst interface{}) error {
-	// 序列化源数据
-	data, err := json.Marshal(src)
-	if err != nil {
-		return fmt.Errorf("序列化源数据失败: %v", err)
-	}
+	return DeepCopyWithOptions(src, dst, DeepCopyOptions{Mode: DeepCopyJSON})
+}
 
-	// 反序列化到目标数据
-	if err := json.Unmarshal(data, dst); err != nil {
-		return fmt.Errorf("反序列化到目标数据失败: %v", err)
+// DeepCopyMode 深拷贝编码方式
+type DeepCopyMode int
+
+const (
+	DeepCopyJSON DeepCopyMode = iota // JSON 序列化 (默认)
+	DeepCopyGob                      // gob 编码
+)
+
+// DeepCopyOptions 深拷贝选项
+type DeepCopyOptions struct {
+	Mode DeepCopyMode // 编码方式
+}
+
+// DeepCopyWithOptions 按指定编码方式深拷贝
+// 参数: src - 源数据, dst - 目标数据指针, opts - 拷贝选项
+// 返回: 错误信息，包含无法编码的具体类型
+// gob 方式相比 JSON 的优势:
+// 1. interface{} 字段保留具体类型 (具体类型需事先 gob.Register)
+// 2. 支持 NaN/Inf 浮点数，实现 GobEncoder/BinaryMarshaler 的类型可自行编码未导出状态
+// gob 方式同样不拷贝未导出字段，且不支持 func/chan 以及没有导出字段的结构体
+func DeepCopyWithOptions(src interface{}, dst interface{}, opts DeepCopyOptions) error {
+	switch opts.Mode {
+	case DeepCopyGob:
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(src); err != nil {
+			return fmt.Errorf("gob编码源数据(%T)失败: %w", src, err)
+		}
+		if err := gob.NewDecoder(&buf).Decode(dst); err != nil {
+			return fmt.Errorf("gob解码到目标数据(%T)失败: %w", dst, err)
+		}
+		return nil
+	case DeepCopyJSON:
+		data, err := json.Marshal(src)
+		if err != nil {
+			return fmt.Errorf("序列化源数据失败: %s: %w", jsonErrorType(src, err), err)
+		}
+		if err := json.Unmarshal(data, dst); err != nil {
+			return fmt.Errorf("反序列化到目标数据(%T)失败: %w", dst, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的深拷贝方式: %d", opts.Mode)
 	}
+}
 
-	return nil
+// jsonErrorType 从JSON序列化错误中提取出错的类型，无法提取时返回源数据类型
+func jsonErrorType(src interface{}, err error) string {
+	var typeErr *json.UnsupportedTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("类型 %s 不支持JSON序列化", typeErr.Type)
+	}
+	var valueErr *json.UnsupportedValueError
+	if errors.As(err, &valueErr) {
+		if valueErr.Value.IsValid() {
+			return fmt.Sprintf("类型 %s 的值 %s 不支持JSON序列化", valueErr.Value.Type(), valueErr.Str)
+		}
+		return fmt.Sprintf("值 %s 不支持JSON序列化", valueErr.Str)
+	}
+	var marshalerErr *json.MarshalerError
+	if errors.As(err, &marshalerErr) {
+		return fmt.Sprintf("类型 %s 的 MarshalJSON 失败", marshalerErr.Type)
+	}
+	return fmt.Sprintf("源数据类型 %T", src)
 }
 
 // ==================== 数据验证转换 ====================
@@ -605,6 +686,20 @@ func SafeStringToFloat64(str string, min, max float64) (float64, error) {
 	return value, nil
 }
 
+// SafeInterfaceToInt 安全的接口转整数（带验证）
+// 参数: value - 接口值, min - 最小值, max - 最大值
+// 返回: 转换后的整数值和错误信息，支持的类型同 InterfaceToInt
+func SafeInterfaceToInt(value interface{}, min, max int) (int, error) {
+	result, err := interfaceToInt(value)
+	if err != nil {
+		return 0, err
+	}
+	if result < min || result > max {
+		return 0, fmt.Errorf("值%d超出范围[%d, %d]", result, min, max)
+	}
+	return result, nil
+}
+
 // ==================== JSON数组转换 ====================
 
 // JSONArrayToStringSlice JSON数组字符串转字符串切片
@@ -824,6 +919,96 @@ func InterfaceToString(value interface{}) string {
 	return fmt.Sprintf("%v", value)
 }
 
+// InterfaceToInt 接口转整数，支持默认值
+// 参数: value - 接口值, defaultValue - 无法转换时的默认值
+// 返回: 转换后的整数值
+// 支持 int/uint 各类整型、float32/float64 (向零截断)、json.Number 和数字字符串 (如 "100"、"1.5")
+// 溢出、NaN/Inf 以及其他类型均返回默认值。JSON 解码得到的 float64 参数可直接使用
+func InterfaceToInt(value interface{}, defaultValue int) int {
+	if result, err := interfaceToInt(value); err == nil {
+		return result
+	}
+	return defaultValue
+}
+
+// interfaceToInt InterfaceToInt 与 SafeInterfaceToInt 的公共转换逻辑
+func interfaceToInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, fmt.Errorf("值不能为空")
+	case int:
+		return v, nil
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		if v < math.MinInt || v > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case uint:
+		if v > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case uint8:
+		return int(v), nil
+	case uint16:
+		return int(v), nil
+	case uint32:
+		if uint64(v) > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case uint64:
+		if v > math.MaxInt {
+			return 0, fmt.Errorf("值%d超出int范围", v)
+		}
+		return int(v), nil
+	case float32:
+		return floatToInt(float64(v))
+	case float64:
+		return floatToInt(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return interfaceToInt(i)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("'%s'不是有效数字: %v", v, err)
+		}
+		return floatToInt(f)
+	case string:
+		str := strings.TrimSpace(v)
+		if i, err := strconv.Atoi(str); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return 0, fmt.Errorf("字符串'%s'不是有效数字", v)
+		}
+		return floatToInt(f)
+	default:
+		return 0, fmt.Errorf("不支持的类型%T", value)
+	}
+}
+
+// floatToInt 浮点数向零截断为整数，NaN/Inf 或超出int范围时返回错误
+func floatToInt(f float64) (int, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("值%v不是有限数", f)
+	}
+	t := math.Trunc(f)
+	// float64(math.MaxInt) 会进位为 2^63，因此上界使用 >=
+	if t < math.MinInt || t >= math.MaxInt {
+		return 0, fmt.Errorf("值%v超出int范围", f)
+	}
+	return int(t), nil
+}
+
 // IsZeroValue 检查值是否为零值
 // 参数: value - 待检查的值
 // 返回: 是否为零值
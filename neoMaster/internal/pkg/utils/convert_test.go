@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type CopyInner struct {
+	Name string
+}
+
+type copyPayload struct {
+	Port int
+}
+
+type copySample struct {
+	*CopyInner
+	CreatedAt time.Time
+	Extra     interface{}
+	Score     float64
+	secret    string
+}
+
+func init() {
+	gob.Register(copyPayload{})
+}
+
+func TestDeepCopyJSONVsGob(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	src := copySample{
+		CopyInner: &CopyInner{Name: "agent-1"},
+		CreatedAt: time.Date(2026, 10, 16, 8, 30, 0, 123456789, loc),
+		Extra:     copyPayload{Port: 443},
+		secret:    "hidden",
+	}
+
+	var viaJSON copySample
+	if err := DeepCopy(src, &viaJSON); err != nil {
+		t.Fatalf("DeepCopy() error = %v", err)
+	}
+	var viaGob copySample
+	if err := DeepCopyWithOptions(src, &viaGob, DeepCopyOptions{Mode: DeepCopyGob}); err != nil {
+		t.Fatalf("DeepCopyWithOptions(gob) error = %v", err)
+	}
+
+	for name, got := range map[string]copySample{"json": viaJSON, "gob": viaGob} {
+		if got.CopyInner == nil || got.Name != "agent-1" {
+			t.Errorf("%s: embedded pointer not copied: %+v", name, got.CopyInner)
+		} else if got.CopyInner == src.CopyInner {
+			t.Errorf("%s: embedded pointer shared with source", name)
+		}
+		if !got.CreatedAt.Equal(src.CreatedAt) {
+			t.Errorf("%s: CreatedAt = %v, want %v", name, got.CreatedAt, src.CreatedAt)
+		}
+		if got.secret != "" {
+			t.Errorf("%s: unexported field should not be copied", name)
+		}
+	}
+
+	// JSON 将 interface{} 字段还原为 map，gob 保留具体类型
+	if _, ok := viaJSON.Extra.(map[string]interface{}); !ok {
+		t.Errorf("json: Extra = %T, want map[string]interface {}", viaJSON.Extra)
+	}
+	if p, ok := viaGob.Extra.(copyPayload); !ok || p.Port != 443 {
+		t.Errorf("gob: Extra = %#v, want copyPayload{Port:443}", viaGob.Extra)
+	}
+
+	// NaN 无法JSON序列化，错误中应指明出错的值
+	src.Score = math.NaN()
+	err := DeepCopy(src, &viaJSON)
+	if err == nil || !strings.Contains(err.Error(), "NaN") {
+		t.Errorf("DeepCopy(NaN) error = %v, want mention of NaN", err)
+	}
+	if err := DeepCopyWithOptions(src, &viaGob, DeepCopyOptions{Mode: DeepCopyGob}); err != nil || !math.IsNaN(viaGob.Score) {
+		t.Errorf("gob copy of NaN = %v, %v", viaGob.Score, err)
+	}
+
+	err = DeepCopy(map[string]interface{}{"cb": func() {}}, &viaJSON)
+	if err == nil || !strings.Contains(err.Error(), "func()") {
+		t.Errorf("DeepCopy(func) error = %v, want mention of func()", err)
+	}
+}
+
+func TestInterfaceToInt(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  int
+	}{
+		{name: "int", value: 42, want: 42},
+		{name: "int64", value: int64(-7), want: -7},
+		{name: "uint8", value: uint8(255), want: 255},
+		{name: "float64_truncated", value: 1000.9, want: 1000},
+		{name: "negative_float_truncated", value: -2.7, want: -2},
+		{name: "json_number", value: json.Number("8080"), want: 8080},
+		{name: "json_number_float", value: json.Number("3.5"), want: 3},
+		{name: "string", value: " 100 ", want: 100},
+		{name: "string_float", value: "1.5", want: 1},
+		{name: "nil", value: nil, want: -1},
+		{name: "bool", value: true, want: -1},
+		{name: "bad_string", value: "fast", want: -1},
+		{name: "nan", value: math.NaN(), want: -1},
+		{name: "float_overflow", value: 1e30, want: -1},
+		{name: "uint64_overflow", value: uint64(math.MaxUint64), want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InterfaceToInt(tt.value, -1); got != tt.want {
+				t.Errorf("InterfaceToInt(%#v, -1) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeInterfaceToInt(t *testing.T) {
+	// JSON 解码后的数字为 float64
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"rate":500,"timeout":-1,"mode":"auto"}`), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := SafeInterfaceToInt(params["rate"], 1, 10000); err != nil || got != 500 {
+		t.Errorf("SafeInterfaceToInt(rate) = %d, %v; want 500", got, err)
+	}
+	if _, err := SafeInterfaceToInt(params["timeout"], 0, 3600); err == nil {
+		t.Error("SafeInterfaceToInt(timeout) expected out of range error")
+	}
+	if _, err := SafeInterfaceToInt(params["mode"], 0, 10); err == nil {
+		t.Error("SafeInterfaceToInt(mode) expected conversion error")
+	}
+	if _, err := SafeInterfaceToInt(params["missing"], 0, 10); err == nil {
+		t.Error("SafeInterfaceToInt(missing) expected error for nil")
+	}
+}
+
+func TestStringToBool(t *testing.T) {
+	truthy := []string{"true", "t", "1", "yes", "y", "on", "enable", "enabled"}
+	falsy := []string{"false", "f", "0", "no", "n", "off", "disable", "disabled"}
+
+	for _, token := range truthy {
+		for _, s := range []string{token, strings.ToUpper(token), " " + token + " "} {
+			if !StringToBool(s, false) {
+				t.Errorf("StringToBool(%q, false) = false, want true", s)
+			}
+		}
+	}
+	for _, token := range falsy {
+		for _, s := range []string{token, strings.ToUpper(token), " " + token + " "} {
+			if StringToBool(s, true) {
+				t.Errorf("StringToBool(%q, true) = true, want false", s)
+			}
+		}
+	}
+	// 无法识别的取值返回默认值
+	for _, s := range []string{"", "  ", "maybe", "2", "yess", "o"} {
+		if !StringToBool(s, true) || StringToBool(s, false) {
+			t.Errorf("StringToBool(%q) should fall back to the default value", s)
+		}
+	}
+}
+
+func TestParseQueryStringSlice(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "comma", query: "tags=2,7", want: []string{"2", "7"}},
+		{name: "repeated", query: "tags=2&tags=7", want: []string{"2", "7"}},
+		{name: "mixed", query: "tags=2,7&tags=9", want: []string{"2", "7", "9"}},
+		{name: "trim_and_drop_empty", query: "tags=+2+,,7,&tags=", want: []string{"2", "7"}},
+		{name: "missing", query: "status=online", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ParseQueryStringSlice(values, "tags")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQueryStringSlice(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+/**
+ * 工具包:CSV 导出
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: CSV 导出的公共处理 (审计日志导出、邮件附件等)
+ * @func:
+ *   - CSVSafe 防止 CSV 公式注入
+ */
+package utils
+
+import "strings"
+
+// CSVSafe 防止 CSV 公式注入: 以 = + - @ 或控制字符开头的单元格在表格软件中会被当作公式执行，前置单引号按文本处理
+func CSVSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
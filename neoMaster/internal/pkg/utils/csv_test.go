@@ -0,0 +1,22 @@
+package utils
+
+import "testing"
+
+func TestCSVSafe(t *testing.T) {
+	tests := map[string]string{
+		"":            "",
+		"admin":       "admin",
+		"=1+1":        "'=1+1",
+		"+cmd":        "'+cmd",
+		"-2":          "'-2",
+		"@SUM(A1)":    "'@SUM(A1)",
+		"\tindent":    "'\tindent",
+		"a=b":         "a=b",
+		"192.168.1.1": "192.168.1.1",
+	}
+	for in, want := range tests {
+		if got := CSVSafe(in); got != want {
+			t.Errorf("CSVSafe(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type fieldsBase struct {
+	ID uint64 `json:"id"`
+}
+
+type fieldsSample struct {
+	fieldsBase
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags,omitempty"`
+	Secret   string   `json:"-"`
+	Plain    int
+	internal string
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	got := JSONFieldNames(&fieldsSample{})
+	want := []string{"id", "name", "tags", "Plain"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONFieldNames() = %v, want %v", got, want)
+	}
+	if JSONFieldNames(42) != nil {
+		t.Error("JSONFieldNames of non-struct should be nil")
+	}
+}
+
+func TestFieldAllowlist_ParseFields(t *testing.T) {
+	allowlist := NewFieldAllowlist(JSONFieldNames(fieldsSample{})...)
+
+	fields, err := allowlist.ParseFields(url.Values{})
+	if err != nil || fields != nil {
+		t.Errorf("missing fields should mean full object, got %v, %v", fields, err)
+	}
+
+	fields, err = allowlist.ParseFields(url.Values{"fields": {"name, id", "name"}})
+	if err != nil || !reflect.DeepEqual(fields, []string{"name", "id"}) {
+		t.Errorf("ParseFields() = %v, %v", fields, err)
+	}
+
+	_, err = allowlist.ParseFields(url.Values{"fields": {"id,Secret"}})
+	if !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected ErrUnknownField, got %v", err)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	items := []*fieldsSample{
+		{fieldsBase: fieldsBase{ID: 1}, Name: "a", Tags: []string{"x"}},
+		{fieldsBase: fieldsBase{ID: 2}, Name: "b"},
+	}
+
+	// 未指定字段时原样返回
+	same, err := SelectFields(items, nil)
+	if err != nil || !reflect.DeepEqual(same, items) {
+		t.Errorf("SelectFields(nil) should return data as is, got %v, %v", same, err)
+	}
+
+	got, err := SelectFields(items, []string{"id", "tags"})
+	if err != nil {
+		t.Fatalf("SelectFields() error = %v", err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"id": float64(1), "tags": []interface{}{"x"}},
+		map[string]interface{}{"id": float64(2)}, // omitempty 的字段不补齐
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SelectFields() = %#v, want %#v", got, want)
+	}
+
+	one, err := SelectFields(items[0], []string{"name"})
+	if err != nil || !reflect.DeepEqual(one, map[string]interface{}{"name": "a"}) {
+		t.Errorf("SelectFields(object) = %v, %v", one, err)
+	}
+}
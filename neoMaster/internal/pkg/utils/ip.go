@@ -4,6 +4,7 @@
 package utils
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/url"
@@ -223,6 +224,43 @@ func Int2IP(nn uint32) net.IP {
 	return ip
 }
 
+// IPSortKey 将 IP 转换为可排序的键: 16 字节形式的小写十六进制 (32 位)
+// IPv4 按 IPv4-mapped 地址编码 (::ffff:a.b.c.d)，字符串序与数值序一致，可直接用于 BETWEEN 范围查询；无效 IP 返回空串
+func IPSortKey(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	return hex.EncodeToString(parsed.To16())
+}
+
+// CIDRSortKeyRange 计算 CIDR (或单个 IP) 对应的 IPSortKey 闭区间 [start, end]
+// 示例: "10.0.0.0/30" -> 网络地址 10.0.0.0 到广播地址 10.0.0.3 的键
+func CIDRSortKeyRange(cidr string) (string, string, error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		key := IPSortKey(cidr)
+		if key == "" {
+			return "", "", fmt.Errorf("invalid IP or CIDR: %s", cidr)
+		}
+		return key, key, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid CIDR format: %w", err)
+	}
+	start := ipNet.IP
+	if v4 := start.To4(); v4 != nil && len(ipNet.Mask) == net.IPv4len {
+		start = v4
+	}
+	end := make(net.IP, len(start))
+	for i := range start {
+		end[i] = start[i] | ^ipNet.Mask[i]
+	}
+	return hex.EncodeToString(start.To16()), hex.EncodeToString(end.To16()), nil
+}
+
 // MergeIPs 合并 IP 列表并去重排序
 func MergeIPs(ips []string) []string {
 	uniqueIPs := make(map[string]struct{})
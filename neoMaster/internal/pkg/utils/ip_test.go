@@ -378,3 +378,40 @@ func TestValidators(t *testing.T) {
 		}
 	})
 }
+
+func TestCIDRSortKeyRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		cidr      string
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{"ipv4_cidr", "10.44.96.0/24", IPSortKey("10.44.96.0"), IPSortKey("10.44.96.255"), false},
+		{"ipv4_unaligned", "10.44.96.77/30", IPSortKey("10.44.96.76"), IPSortKey("10.44.96.79"), false},
+		{"single_ip", "10.44.96.7", IPSortKey("10.44.96.7"), IPSortKey("10.44.96.7"), false},
+		{"ipv6_cidr", "2001:db8::/126", IPSortKey("2001:db8::"), IPSortKey("2001:db8::3"), false},
+		{"invalid", "10.44.96.0/33", "", "", true},
+		{"invalid_ip", "host-1", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := CIDRSortKeyRange(tt.cidr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CIDRSortKeyRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("CIDRSortKeyRange() = [%s, %s], want [%s, %s]", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	// 键的字符串序与数值序一致
+	if !(IPSortKey("10.0.0.9") < IPSortKey("10.0.0.10") && IPSortKey("9.255.255.255") < IPSortKey("10.0.0.0")) {
+		t.Error("IPSortKey is not ordered numerically")
+	}
+	if len(IPSortKey("10.0.0.1")) != 32 || IPSortKey("::ffff:10.0.0.1") != IPSortKey("10.0.0.1") {
+		t.Error("IPSortKey should encode IPv4 as 16-byte mapped address")
+	}
+}
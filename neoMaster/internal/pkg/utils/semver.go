@@ -0,0 +1,194 @@
+/**
+ * 工具包:语义化版本比较
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 提供语义化版本号(SemVer 2.0.0)的解析与比较
+ * @func:
+ *   - CompareSemver 比较两个版本号
+ *   - SemverLess    版本号小于判断，便于排序
+ */
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver 解析后的版本号
+type semver struct {
+	major, minor, patch uint64
+	prerelease          []string // 预发布标识，按 "." 切分 (如 rc.1 -> ["rc","1"])
+}
+
+// parseSemver 解析版本号
+// 支持格式: [v]MAJOR.MINOR[.PATCH][-PRERELEASE][+BUILD]
+// 1. 前缀 v/V 可选
+// 2. 缺省 PATCH 视为 0 (v1.0 等同 v1.0.0)，MAJOR.MINOR 不可缺省
+// 3. 构建元数据(+BUILD)不参与比较
+// 4. 数字段不允许前导零，预发布标识只允许 [0-9A-Za-z-]
+func parseSemver(version string) (semver, error) {
+	v := strings.TrimSpace(version)
+	if strings.HasPrefix(v, "v") || strings.HasPrefix(v, "V") {
+		v = v[1:]
+	}
+
+	if idx := strings.Index(v, "+"); idx >= 0 {
+		if !validSemverIdentifiers(v[idx+1:]) {
+			return semver{}, fmt.Errorf("invalid semver %q: malformed build metadata", version)
+		}
+		v = v[:idx]
+	}
+
+	var result semver
+	if idx := strings.Index(v, "-"); idx >= 0 {
+		pre := v[idx+1:]
+		if !validSemverIdentifiers(pre) {
+			return semver{}, fmt.Errorf("invalid semver %q: malformed pre-release", version)
+		}
+		for _, id := range strings.Split(pre, ".") {
+			if isNumericIdentifier(id) && len(id) > 1 && id[0] == '0' {
+				return semver{}, fmt.Errorf("invalid semver %q: pre-release %q has leading zero", version, id)
+			}
+			result.prerelease = append(result.prerelease, id)
+		}
+		v = v[:idx]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return semver{}, fmt.Errorf("invalid semver %q: expected MAJOR.MINOR[.PATCH]", version)
+	}
+	nums := make([]uint64, 3)
+	for i, p := range parts {
+		if !isNumericIdentifier(p) || (len(p) > 1 && p[0] == '0') {
+			return semver{}, fmt.Errorf("invalid semver %q: %q is not a valid number", version, p)
+		}
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return semver{}, fmt.Errorf("invalid semver %q: %v", version, err)
+		}
+		nums[i] = n
+	}
+	result.major, result.minor, result.patch = nums[0], nums[1], nums[2]
+	return result, nil
+}
+
+// validSemverIdentifiers 检查以 "." 分隔的标识符是否均非空且只含 [0-9A-Za-z-]
+func validSemverIdentifiers(s string) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isNumericIdentifier 判断标识符是否全为数字
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareSemver 比较两个版本号
+// 返回 -1 (a < b)、0 (a == b)、1 (a > b)，任一版本号格式非法时返回错误
+// 预发布版本低于对应正式版本 (v2.0.0-rc1 < v2.0.0)
+func CompareSemver(a, b string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+	if c := compareUint(va.major, vb.major); c != 0 {
+		return c, nil
+	}
+	if c := compareUint(va.minor, vb.minor); c != 0 {
+		return c, nil
+	}
+	if c := compareUint(va.patch, vb.patch); c != 0 {
+		return c, nil
+	}
+	return comparePrerelease(va.prerelease, vb.prerelease), nil
+}
+
+// SemverLess 判断 a 是否小于 b，便于 sort.Slice 使用
+// 格式非法的版本号排在合法版本号之前，两者均非法时按字符串比较，保证排序结果稳定
+func SemverLess(a, b string) bool {
+	c, err := CompareSemver(a, b)
+	if err == nil {
+		return c < 0
+	}
+	_, errA := parseSemver(a)
+	_, errB := parseSemver(b)
+	switch {
+	case errA != nil && errB != nil:
+		return a < b
+	case errA != nil:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareUint 比较两个无符号整数
+func compareUint(x, y uint64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease 按 SemVer 规则比较预发布标识
+// 1. 无预发布标识的版本更高
+// 2. 逐段比较: 数字段按数值，字母段按 ASCII，数字段低于字母段
+// 3. 前缀相同时段数多的更高
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		aNum, bNum := isNumericIdentifier(a[i]), isNumericIdentifier(b[i])
+		switch {
+		case aNum && bNum:
+			if len(a[i]) != len(b[i]) {
+				// 无前导零，位数多的数值更大 (避免超长数字溢出)
+				return compareUint(uint64(len(a[i])), uint64(len(b[i])))
+			}
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		case aNum:
+			return -1
+		case bNum:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
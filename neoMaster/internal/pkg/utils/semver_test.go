@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{name: "equal", a: "1.2.3", b: "1.2.3", want: 0},
+		{name: "leading_v", a: "v1.2.3", b: "1.2.3", want: 0},
+		{name: "missing_patch", a: "v1.0", b: "v1.0.0", want: 0},
+		{name: "double_digit_minor", a: "v1.10.0", b: "v1.9.0", want: 1},
+		{name: "patch_less", a: "1.0.1", b: "1.0.2", want: -1},
+		{name: "major_wins", a: "2.0.0", b: "1.99.99", want: 1},
+		{name: "prerelease_below_release", a: "v2.0.0-rc1", b: "v2.0.0", want: -1},
+		{name: "release_above_prerelease", a: "v2.0.0", b: "v2.0.0-rc1", want: 1},
+		{name: "prerelease_alpha_beta", a: "1.0.0-alpha", b: "1.0.0-beta", want: -1},
+		{name: "prerelease_numeric", a: "1.0.0-rc.2", b: "1.0.0-rc.10", want: -1},
+		{name: "prerelease_numeric_below_alpha", a: "1.0.0-1", b: "1.0.0-alpha", want: -1},
+		{name: "prerelease_more_fields", a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{name: "build_metadata_ignored", a: "1.0.0+build.5", b: "1.0.0+build.9", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CompareSemver(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("CompareSemver(%q, %q) error = %v", tt.a, tt.b, err)
+			}
+			if got != tt.want {
+				t.Errorf("CompareSemver(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSemverMalformed(t *testing.T) {
+	malformed := []string{"", "v", "1", "1.2.3.4", "1.x.0", "01.2.3", "1.2.3-", "1.2.3-rc..1", "1.2.3-01", "1.2.3+", "1.2.3-rc_1", "-1.2.3"}
+	for _, v := range malformed {
+		if _, err := CompareSemver(v, "1.0.0"); err == nil {
+			t.Errorf("CompareSemver(%q, 1.0.0) expected error", v)
+		}
+		if _, err := CompareSemver("1.0.0", v); err == nil {
+			t.Errorf("CompareSemver(1.0.0, %q) expected error", v)
+		}
+	}
+}
+
+func TestSemverLess(t *testing.T) {
+	versions := []string{"v1.10.0", "v1.2.0", "bad", "v2.0.0", "v2.0.0-rc1", "v1.2"}
+	sort.SliceStable(versions, func(i, j int) bool { return SemverLess(versions[i], versions[j]) })
+
+	want := []string{"bad", "v1.2.0", "v1.2", "v1.10.0", "v2.0.0-rc1", "v2.0.0"}
+	for i := range want {
+		if versions[i] != want[i] {
+			t.Fatalf("sorted = %v, want %v", versions, want)
+		}
+	}
+}
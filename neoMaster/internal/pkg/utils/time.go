@@ -9,6 +9,10 @@ package utils
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,32 +34,89 @@ const (
 	TimestampFormat = "1136239445"
 )
 
+// defaultLocation 格式化输出使用的默认时区，未设置时保持时间对象自身的时区
+var defaultLocation atomic.Pointer[time.Location]
+
+// SetDefaultTimeZone 设置格式化输出的默认时区
+// 参数: zone - IANA时区名称，如 "UTC"、"Asia/Shanghai"；空字符串表示取消默认时区
+// 返回: 时区名称无效时返回错误，原默认时区保持不变
+// 设置后 FormatDateTime/FormatDate/FormatTime/FormatCustom 及 GetCurrent* 均按该时区输出
+func SetDefaultTimeZone(zone string) error {
+	if zone == "" {
+		defaultLocation.Store(nil)
+		return nil
+	}
+	loc, err := loadTimeZone(zone)
+	if err != nil {
+		return err
+	}
+	defaultLocation.Store(loc)
+	return nil
+}
+
+// DefaultTimeZone 获取格式化输出的默认时区
+// 返回: 默认时区，未设置时返回 nil
+func DefaultTimeZone() *time.Location {
+	return defaultLocation.Load()
+}
+
+// inDefaultZone 将时间转换到默认时区，未设置默认时区时原样返回
+func inDefaultZone(t time.Time) time.Time {
+	if loc := defaultLocation.Load(); loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// loadTimeZone 加载时区，错误信息包含时区名称
+func loadTimeZone(zone string) (*time.Location, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区 %q: %w", zone, err)
+	}
+	return loc, nil
+}
+
 // FormatDateTime 格式化时间为标准日期时间字符串
 // 参数: t - 要格式化的时间
 // 返回: 格式化后的字符串 "2006-01-02 15:04:05"
 func FormatDateTime(t time.Time) string {
-	return t.Format(DateTimeFormat)
+	return inDefaultZone(t).Format(DateTimeFormat)
+}
+
+// FormatDateTimeInZone 按指定时区格式化为标准日期时间字符串
+// 参数: t - 要格式化的时间, zone - IANA时区名称，空字符串表示使用默认时区
+// 返回: 格式化后的字符串 "2006-01-02 15:04:05" 和错误信息(时区名称无效)
+func FormatDateTimeInZone(t time.Time, zone string) (string, error) {
+	if zone == "" {
+		return FormatDateTime(t), nil
+	}
+	loc, err := loadTimeZone(zone)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format(DateTimeFormat), nil
 }
 
 // FormatDate 格式化时间为日期字符串
 // 参数: t - 要格式化的时间
 // 返回: 格式化后的字符串 "2006-01-02"
 func FormatDate(t time.Time) string {
-	return t.Format(DateFormat)
+	return inDefaultZone(t).Format(DateFormat)
 }
 
 // FormatTime 格式化时间为时间字符串
 // 参数: t - 要格式化的时间
 // 返回: 格式化后的字符串 "15:04:05"
 func FormatTime(t time.Time) string {
-	return t.Format(TimeFormat)
+	return inDefaultZone(t).Format(TimeFormat)
 }
 
 // FormatCustom 使用自定义格式格式化时间
 // 参数: t - 要格式化的时间, layout - 自定义格式 "2006年01月02日 15:04:05"
 // 返回: 格式化后的字符串
 func FormatCustom(t time.Time, layout string) string {
-	return t.Format(layout)
+	return inDefaultZone(t).Format(layout)
 }
 
 // ParseDateTime 解析日期时间字符串（智能识别格式）
@@ -98,19 +159,19 @@ func ParseCustom(timeStr, layout string) (time.Time, error) {
 // GetCurrentDateTime 获取当前日期时间字符串
 // 返回: 当前时间的标准格式字符串 "2006-01-02 15:04:05"
 func GetCurrentDateTime() string {
-	return time.Now().Format(DateTimeFormat)
+	return FormatDateTime(time.Now())
 }
 
 // GetCurrentDate 获取当前日期字符串
 // 返回: 当前日期的格式字符串 "2006-01-02"
 func GetCurrentDate() string {
-	return time.Now().Format(DateFormat)
+	return FormatDate(time.Now())
 }
 
 // GetCurrentTime 获取当前时间字符串
 // 返回: 当前时间的格式字符串 "15:04:05"
 func GetCurrentTime() string {
-	return time.Now().Format(TimeFormat)
+	return FormatTime(time.Now())
 }
 
 // GetCurrentTimestamp 获取当前Unix时间戳（秒）
@@ -292,6 +353,76 @@ func GetEndOfMonth(t time.Time) time.Time {
 	return startOfNextMonth.Add(-time.Nanosecond)
 }
 
+// defaultWorkdays 未指定工作日时使用周一至周五
+var defaultWorkdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// IsWithinBusinessHours 判断时间是否处于工作时段内
+// 参数: t - 要判断的时间, workStart/workEnd - 工作时段起止小时 [workStart, workEnd)，取值 0-24,
+// workdays - 工作日列表(为空时默认周一至周五), holidays - 节假日(按 t 所在时区的日期比较)
+// 返回: 是否处于工作时段内，工作时段无效(workStart >= workEnd 或超出 0-24)时返回 false
+func IsWithinBusinessHours(t time.Time, workStart, workEnd int, workdays []time.Weekday, holidays ...time.Time) bool {
+	if !validBusinessWindow(workStart, workEnd) || !isBusinessDay(t, workdays, holidays) {
+		return false
+	}
+	return t.Hour() >= workStart && t.Hour() < workEnd
+}
+
+// NextBusinessTime 获取从 from 开始最近的工作时段内时刻
+// 参数: 同 IsWithinBusinessHours，from 为起始时间
+// 返回: from 已在工作时段内时原样返回；当天工作时段未开始时返回当天 workStart；
+// 否则(含已过 workEnd 的情况)顺延到下一个工作日的 workStart。工作时段无效时原样返回 from
+func NextBusinessTime(from time.Time, workStart, workEnd int, workdays []time.Weekday, holidays ...time.Time) time.Time {
+	if !validBusinessWindow(workStart, workEnd) {
+		return from
+	}
+	if IsWithinBusinessHours(from, workStart, workEnd, workdays, holidays...) {
+		return from
+	}
+
+	day := GetStartOfDay(from)
+	if isBusinessDay(day, workdays, holidays) && from.Hour() < workStart {
+		return time.Date(day.Year(), day.Month(), day.Day(), workStart, 0, 0, 0, from.Location())
+	}
+	// 最多向后查找约十年，避免节假日配置异常导致死循环
+	for i := 1; i <= 3660; i++ {
+		// 使用 time.Date 而不是 Add(24h)，跨夏令时切换时仍落在当天
+		next := time.Date(day.Year(), day.Month(), day.Day()+i, workStart, 0, 0, 0, from.Location())
+		if isBusinessDay(next, workdays, holidays) {
+			return next
+		}
+	}
+	return from
+}
+
+// validBusinessWindow 检查工作时段是否有效
+func validBusinessWindow(workStart, workEnd int) bool {
+	return workStart >= 0 && workEnd <= 24 && workStart < workEnd
+}
+
+// isBusinessDay 判断是否为工作日且不是节假日
+func isBusinessDay(t time.Time, workdays []time.Weekday, holidays []time.Time) bool {
+	if len(workdays) == 0 {
+		workdays = defaultWorkdays
+	}
+	isWorkday := false
+	for _, d := range workdays {
+		if t.Weekday() == d {
+			isWorkday = true
+			break
+		}
+	}
+	if !isWorkday {
+		return false
+	}
+	for _, h := range holidays {
+		hy, hm, hd := h.Date()
+		if y, m, d := t.Date(); y == hy && m == hm && d == hd {
+			return false
+		}
+	}
+	return true
+}
+
 // GetAge 根据生日计算年龄
 // 参数: birthday - 生日时间
 // 返回: 年龄
@@ -310,6 +441,7 @@ func GetAge(birthday time.Time) int {
 // FormatDuration 格式化时间间隔为可读字符串
 // 参数: d - 时间间隔
 // 返回: 格式化后的字符串，如 "2天3小时4分钟5秒"
+// 解析 "1d12h" 形式的配置值见 ParseHumanDuration
 func FormatDuration(d time.Duration) string {
 	if d < 0 {
 		d = -d
@@ -337,6 +469,99 @@ func FormatDuration(d time.Duration) string {
 	return result
 }
 
+// humanDurationUnits ParseHumanDuration 支持的单位 (d/w 为扩展单位)
+var humanDurationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// ParseHumanDuration 解析可读的时间间隔字符串
+// 参数: s - 时间间隔字符串，如 "1d12h"、"2w"、"1d2h30m"、"1.5h"，各段之间允许空格
+// 返回: 解析后的时间间隔和错误信息
+// 在 time.ParseDuration 的基础上支持 d(天=24h) 和 w(周=7d)，单位区分大小写("m" 为分钟)
+// 以下输入返回错误: 空字符串、负数、缺少单位(除 "0" 外)、未知单位、同一单位重复出现、结果溢出
+func ParseHumanDuration(s string) (time.Duration, error) {
+	input := strings.Join(strings.Fields(s), "")
+	if input == "" {
+		return 0, fmt.Errorf("时间间隔不能为空")
+	}
+	if input == "0" {
+		return 0, nil
+	}
+
+	var total time.Duration
+	seen := make(map[string]bool)
+	rest := input
+	for rest != "" {
+		// 数值部分: 数字和小数点
+		i := 0
+		for i < len(rest) && (rest[i] == '.' || (rest[i] >= '0' && rest[i] <= '9')) {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("时间间隔 %q 无效: %q 处缺少数值(不支持负数)", s, rest)
+		}
+		number := rest[:i]
+		rest = rest[i:]
+
+		// 单位部分: 直到下一个数字为止
+		j := 0
+		for j < len(rest) && rest[j] != '.' && (rest[j] < '0' || rest[j] > '9') {
+			j++
+		}
+		unit := rest[:j]
+		rest = rest[j:]
+		if unit == "" {
+			return 0, fmt.Errorf("时间间隔 %q 无效: 数值 %s 缺少单位", s, number)
+		}
+		unitDuration, ok := humanDurationUnits[unit]
+		if !ok {
+			return 0, fmt.Errorf("时间间隔 %q 无效: 未知单位 %q，支持 ns/us/ms/s/m/h/d/w", s, unit)
+		}
+		if seen[unit] {
+			return 0, fmt.Errorf("时间间隔 %q 无效: 单位 %q 重复", s, unit)
+		}
+		seen[unit] = true
+
+		part, err := scaleDuration(number, unitDuration)
+		if err != nil {
+			return 0, fmt.Errorf("时间间隔 %q 无效: %v", s, err)
+		}
+		if part > math.MaxInt64-total {
+			return 0, fmt.Errorf("时间间隔 %q 超出范围", s)
+		}
+		total += part
+	}
+	return total, nil
+}
+
+// scaleDuration 计算 数值*单位，整数走整型运算避免精度损失，小数按浮点计算
+func scaleDuration(number string, unit time.Duration) (time.Duration, error) {
+	if !strings.Contains(number, ".") {
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil || n > int64(math.MaxInt64/unit) {
+			return 0, fmt.Errorf("数值 %s 超出范围", number)
+		}
+		return time.Duration(n) * unit, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("数值 %q 格式错误", number)
+	}
+	scaled := f * float64(unit)
+	if scaled >= math.MaxInt64 {
+		return 0, fmt.Errorf("数值 %s 超出范围", number)
+	}
+	return time.Duration(scaled), nil
+}
+
 // IsLeapYear 判断指定年份是否为闰年
 // 参数: year - 年份
 // 返回: 是否为闰年
@@ -366,9 +591,9 @@ func TimeZoneOffset(t time.Time) int {
 // 参数: t - 原时间, targetLocation - 目标时区
 // 返回: 转换后的时间和错误信息
 func ConvertTimeZone(t time.Time, targetLocation string) (time.Time, error) {
-	loc, err := time.LoadLocation(targetLocation)
+	loc, err := loadTimeZone(targetLocation)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("加载时区失败: %w", err)
+		return time.Time{}, err
 	}
 	return t.In(loc), nil
 }
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // 保证测试环境缺少系统时区数据库时也能加载时区
+)
+
+func TestFormatDateTimeInZone(t *testing.T) {
+	// 2026-03-08 美国东部夏令时开始: 02:00 EST 直接跳到 03:00 EDT (07:00 UTC)
+	tests := []struct {
+		name string
+		t    time.Time
+		zone string
+		want string
+	}{
+		{name: "before_dst", t: time.Date(2026, 3, 8, 6, 59, 59, 0, time.UTC), zone: "America/New_York", want: "2026-03-08 01:59:59"},
+		{name: "after_dst", t: time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), zone: "America/New_York", want: "2026-03-08 03:00:00"},
+		{name: "utc", t: time.Date(2026, 10, 16, 8, 0, 0, 0, time.FixedZone("CST", 8*3600)), zone: "UTC", want: "2026-10-16 00:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatDateTimeInZone(tt.t, tt.zone)
+			if err != nil {
+				t.Fatalf("FormatDateTimeInZone() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FormatDateTimeInZone(%v, %q) = %s, want %s", tt.t, tt.zone, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FormatDateTimeInZone(time.Now(), "Mars/Olympus"); err == nil || !strings.Contains(err.Error(), "Mars/Olympus") {
+		t.Errorf("FormatDateTimeInZone(invalid) error = %v, want error naming the zone", err)
+	}
+}
+
+func TestSetDefaultTimeZone(t *testing.T) {
+	t.Cleanup(func() { _ = SetDefaultTimeZone("") })
+
+	ts := time.Date(2026, 10, 16, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	if got := FormatDateTime(ts); got != "2026-10-16 08:00:00" {
+		t.Errorf("FormatDateTime() without default zone = %s, want original zone", got)
+	}
+
+	if err := SetDefaultTimeZone("UTC"); err != nil {
+		t.Fatalf("SetDefaultTimeZone(UTC) error = %v", err)
+	}
+	if got := FormatDateTime(ts); got != "2026-10-16 00:00:00" {
+		t.Errorf("FormatDateTime() = %s, want UTC output", got)
+	}
+	if got := FormatDate(ts.Add(-9 * time.Hour)); got != "2026-10-15" {
+		t.Errorf("FormatDate() = %s, want 2026-10-15", got)
+	}
+
+	// 无效时区不影响已设置的默认时区
+	if err := SetDefaultTimeZone("Not/AZone"); err == nil {
+		t.Error("SetDefaultTimeZone(invalid) expected error")
+	}
+	if loc := DefaultTimeZone(); loc == nil || loc.String() != "UTC" {
+		t.Errorf("DefaultTimeZone() = %v, want UTC", loc)
+	}
+}
+
+func TestNextBusinessTime(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 10, day, hour, min, 0, 0, loc) }
+	// 2026-10-16 为周五，10-19 为周一
+	holiday := at(19, 0, 0)
+
+	tests := []struct {
+		name     string
+		from     time.Time
+		holidays []time.Time
+		want     time.Time
+		within   bool
+	}{
+		{name: "within_window", from: at(15, 10, 30), want: at(15, 10, 30), within: true},
+		{name: "before_start", from: at(15, 7, 45), want: at(15, 9, 0)},
+		{name: "after_end_wraps_to_next_day", from: at(15, 19, 30), want: at(16, 9, 0)},
+		{name: "friday_evening_skips_weekend", from: at(16, 20, 0), want: at(19, 9, 0)},
+		{name: "saturday", from: at(17, 11, 0), want: at(19, 9, 0)},
+		{name: "holiday_monday", from: at(16, 20, 0), holidays: []time.Time{holiday}, want: at(20, 9, 0)},
+		{name: "end_hour_is_exclusive", from: at(15, 18, 0), want: at(16, 9, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextBusinessTime(tt.from, 9, 18, nil, tt.holidays...)
+			if !got.Equal(tt.want) {
+				t.Errorf("NextBusinessTime(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+			if within := IsWithinBusinessHours(tt.from, 9, 18, nil, tt.holidays...); within != tt.within {
+				t.Errorf("IsWithinBusinessHours(%v) = %t, want %t", tt.from, within, tt.within)
+			}
+		})
+	}
+
+	// 自定义工作日: 仅周末
+	weekend := []time.Weekday{time.Saturday, time.Sunday}
+	if got := NextBusinessTime(at(16, 12, 0), 10, 16, weekend); !got.Equal(at(17, 10, 0)) {
+		t.Errorf("NextBusinessTime(weekend workdays) = %v, want %v", got, at(17, 10, 0))
+	}
+	// 无效工作时段原样返回
+	if got := NextBusinessTime(at(15, 20, 0), 18, 9, nil); !got.Equal(at(15, 20, 0)) {
+		t.Errorf("NextBusinessTime(invalid window) = %v, want unchanged", got)
+	}
+}
+
+func TestParseHumanDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{in: "0", want: 0},
+		{in: "90s", want: 90 * time.Second},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "1d2h30m", want: 26*time.Hour + 30*time.Minute},
+		{in: "1w2d", want: 9 * 24 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: " 1d 12h ", want: 36 * time.Hour},
+		{in: "500ms", want: 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got, err := ParseHumanDuration(tt.in)
+		if err != nil {
+			t.Errorf("ParseHumanDuration(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHumanDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "10", "-1h", "1x", "1D", "h", "1h1h", "1..5h", "99999999w", "1d-2h"} {
+		if _, err := ParseHumanDuration(in); err == nil {
+			t.Errorf("ParseHumanDuration(%q) expected error", in)
+		}
+	}
+}
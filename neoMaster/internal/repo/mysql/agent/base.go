@@ -11,7 +11,13 @@
  * - Update: 更新Agent [实际操作是更新数据库记录]
  * - UpdateStatus: 更新Agent状态
  * - UpdateLastHeartbeat: 更新Agent最后心跳时间
- * - Delete: 删除Agent [实际操作是删除数据库记录]
+ * - UpdateHeartbeatProgress: 更新Agent最后心跳时间及运行中任务进度
+ * - UpdateHeartbeatSettings: 更新Agent心跳间隔与离线阈值
+ * - MarkStaleAgentsOffline: 按各Agent自身离线阈值将心跳超时的Agent标记为离线
+ * - Delete: 软删除Agent [设置 deleted_at，常规查询自动排除]
+ * - HardDelete: 彻底删除Agent及其性能快照
+ * - ListDeletedAgents: 获取已软删除的Agent列表
+ * - RestoreAgent: 恢复已软删除的Agent
  * - GetList: 获取Agent列表
  * - GetByStatus: 根据状态获取Agent列表
  * 从原来 agent.go 中分离出
@@ -19,6 +25,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
@@ -26,6 +33,7 @@ import (
 	"gorm.io/gorm"
 
 	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/system"
 	"neomaster/internal/pkg/logger"
 )
 
@@ -217,8 +225,10 @@ func (r *agentRepository) GetByHostname(hostname string) (*agentModel.Agent, err
 }
 
 // Update 更新Agent数据 [更新数据库记录]
-// 参数: agentData - Agent数据指针
+// 参数: agentData - Agent数据指针，LockVersion 为读取记录时的乐观锁版本号
 // 返回: error - 更新过程中的错误信息
+// 乐观锁: LockVersion > 0 时仅当数据库中的版本号未变化才更新，并将版本号加一；
+// 版本不一致(读取后已被其他请求修改)返回 system.ErrConcurrentModification。LockVersion 为 0 时不校验版本
 func (r *agentRepository) Update(agentData *agentModel.Agent) error {
 	// 参数校验
 	if agentData == nil || agentData.AgentID == "" {
@@ -237,16 +247,41 @@ func (r *agentRepository) Update(agentData *agentModel.Agent) error {
 
 	// 让 GORM 自动维护 UpdatedAt
 	// 使用 Updates 方法更新指定字段,前端提供什么字段就更新什么字段
-	err := r.db.Model(&agentModel.Agent{}).Where("agent_id = ?", agentData.AgentID).Updates(agentData).Error
+	// 每次更新都递增 lock_version：携带读取版本时按版本 CAS；未携带版本的写入同样递增，使持有旧版本的读者失效
+	readVersion := agentData.LockVersion
+	var err error
+	if readVersion > 0 {
+		agentData.LockVersion = readVersion + 1
+		result := r.db.Model(&agentModel.Agent{}).
+			Where("agent_id = ? AND lock_version = ?", agentData.AgentID, readVersion).
+			Updates(agentData)
+		err = result.Error
+		if err == nil && result.RowsAffected == 0 {
+			err = system.ErrConcurrentModification
+		}
+	} else {
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			query := tx.Model(&agentModel.Agent{}).Where("agent_id = ?", agentData.AgentID)
+			if err := query.Session(&gorm.Session{}).Updates(agentData).Error; err != nil {
+				return err
+			}
+			if err := query.Session(&gorm.Session{}).UpdateColumn("lock_version", gorm.Expr("lock_version + 1")).Error; err != nil {
+				return err
+			}
+			return query.Session(&gorm.Session{}).Select("lock_version").Scan(&agentData.LockVersion).Error
+		})
+	}
 	if err != nil {
+		agentData.LockVersion = readVersion
 		logger.LogError(
 			err,
 			"", 0, "", "repo.mysql.agent", "gorm",
 			map[string]interface{}{
-				"operation": "update_agent",
-				"option":    "repo.agent.Update",
-				"func_name": "repo.mysql.agent.Update",
-				"agent_id":  agentData.AgentID,
+				"operation":    "update_agent",
+				"option":       "repo.agent.Update",
+				"func_name":    "repo.mysql.agent.Update",
+				"agent_id":     agentData.AgentID,
+				"lock_version": readVersion,
 			},
 		)
 		return err
@@ -375,7 +410,121 @@ func (r *agentRepository) UpdateLastHeartbeat(agentID string) error {
 	return nil
 }
 
-// Delete 删除Agent [删除数据库记录]
+// UpdateHeartbeatProgress 更新Agent的最后心跳时间及运行中任务进度
+// 参数: agentID - Agent的业务ID; runningTasks - 运行中任务进度，整体覆盖 running_tasks 字段
+// 进度与心跳时间在同一条 UPDATE 中写入，不按任务逐条插入
+func (r *agentRepository) UpdateHeartbeatProgress(agentID string, runningTasks []agentModel.RunningTaskProgress) error {
+	if agentID == "" {
+		return gorm.ErrInvalidData
+	}
+	if runningTasks == nil {
+		runningTasks = []agentModel.RunningTaskProgress{}
+	}
+	// Updates(map) 不经过字段的 serializer，这里手动序列化
+	progress, err := json.Marshal(runningTasks)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := r.db.Model(&agentModel.Agent{}).
+		Where("agent_id = ?", agentID).
+		Updates(map[string]interface{}{
+			"last_heartbeat": now,
+			"updated_at":     now,
+			"running_tasks":  string(progress),
+		})
+	if result.Error != nil {
+		logger.LogError(result.Error, "", 0, "", "repo.agent.UpdateHeartbeatProgress", "", map[string]interface{}{
+			"operation": "update_agent_heartbeat_progress",
+			"option":    "repo.agent.UpdateHeartbeatProgress",
+			"func_name": "repo.mysql.agent.UpdateHeartbeatProgress",
+			"agent_id":  agentID,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("agent not found")
+	}
+	return nil
+}
+
+// UpdateHeartbeatSettings 更新Agent的心跳间隔与离线阈值(秒)，0 表示使用默认值
+func (r *agentRepository) UpdateHeartbeatSettings(agentID string, heartbeatInterval, offlineThreshold int) error {
+	if agentID == "" {
+		return gorm.ErrInvalidData
+	}
+	// 使用 map 更新，保证 0 值(恢复默认)也能写入
+	result := r.db.Model(&agentModel.Agent{}).
+		Where("agent_id = ?", agentID).
+		Updates(map[string]interface{}{
+			"heartbeat_interval": heartbeatInterval,
+			"offline_threshold":  offlineThreshold,
+			"updated_at":         time.Now(),
+		})
+	if result.Error != nil {
+		logger.LogError(result.Error, "", 0, "", "repo.agent.UpdateHeartbeatSettings", "", map[string]interface{}{
+			"operation": "update_agent_heartbeat_settings",
+			"option":    "repo.agent.UpdateHeartbeatSettings",
+			"func_name": "repo.mysql.agent.UpdateHeartbeatSettings",
+			"agent_id":  agentID,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("agent not found")
+	}
+	return nil
+}
+
+// MarkStaleAgentsOffline 将在 now 时刻心跳已超过自身离线阈值的在线Agent标记为离线
+// 各Agent阈值不同，先取出在线Agent在内存中判定，再逐个带条件更新：
+// 判定与更新之间若收到新心跳，last_heartbeat 条件不再满足，不会被误标离线
+// 返回: 本次被标记为离线的Agent ID列表
+func (r *agentRepository) MarkStaleAgentsOffline(now time.Time) ([]string, error) {
+	var agents []*agentModel.Agent
+	err := r.db.Select("id", "agent_id", "status", "last_heartbeat", "heartbeat_interval", "offline_threshold").
+		Where("status = ?", agentModel.AgentStatusOnline).
+		Find(&agents).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.MarkStaleAgentsOffline", "", map[string]interface{}{
+			"operation": "mark_stale_agents_offline",
+			"option":    "repo.agent.MarkStaleAgentsOffline",
+			"func_name": "repo.mysql.agent.MarkStaleAgentsOffline",
+		})
+		return nil, err
+	}
+
+	var marked []string
+	for _, agent := range agents {
+		if !agent.IsHeartbeatStale(now) {
+			continue
+		}
+		cutoff := now.Add(-agent.EffectiveOfflineThreshold())
+		result := r.db.Model(&agentModel.Agent{}).
+			Where("agent_id = ? AND status = ? AND last_heartbeat < ?", agent.AgentID, agentModel.AgentStatusOnline, cutoff).
+			Updates(map[string]interface{}{
+				"status":     agentModel.AgentStatusOffline,
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			logger.LogError(result.Error, "", 0, "", "repo.agent.MarkStaleAgentsOffline", "", map[string]interface{}{
+				"operation": "mark_stale_agents_offline",
+				"option":    "repo.agent.MarkStaleAgentsOffline",
+				"func_name": "repo.mysql.agent.MarkStaleAgentsOffline",
+				"agent_id":  agent.AgentID,
+			})
+			return marked, result.Error
+		}
+		if result.RowsAffected > 0 {
+			marked = append(marked, agent.AgentID)
+		}
+	}
+	return marked, nil
+}
+
+// Delete 软删除Agent [设置 deleted_at，保留历史记录与任务/指标关联]
+// 软删除后 GetByID、GetList 等查询自动排除该Agent，可通过 RestoreAgent 恢复
 func (r *agentRepository) Delete(agentID string) error {
 	// 参数校验
 	if agentID == "" {
@@ -422,10 +571,93 @@ func (r *agentRepository) Delete(agentID string) error {
 	return nil
 }
 
-// GetList 获取Agent列表（支持分页、按状态、关键词、标签、任务支持过滤）
-// 参数: page - 页码, pageSize - 每页大小, status - 状态过滤, keyword - 关键字过滤, tags - 标签过滤, taskSupport - 任务支持过滤
+// HardDelete 彻底删除Agent [删除数据库记录，包括已软删除的记录]
+// 同时删除该Agent的性能快照，用于清理测试数据等少数需要彻底清除的场景
+func (r *agentRepository) HardDelete(agentID string) error {
+	if agentID == "" {
+		return gorm.ErrInvalidData
+	}
+
+	var rowsAffected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("agent_id = ?", agentID).Delete(&agentModel.AgentMetrics{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("agent_id = ?", agentID).Delete(&agentModel.Agent{})
+		rowsAffected = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.HardDelete", "gorm", map[string]interface{}{
+			"operation": "hard_delete_agent",
+			"option":    "repo.agent.HardDelete",
+			"func_name": "repo.mysql.agent.HardDelete",
+			"agent_id":  agentID,
+		})
+		return err
+	}
+
+	logger.LogInfo("Agent record purged", "", 0, "", "repo.agent.HardDelete", "gorm", map[string]interface{}{
+		"operation": "hard_delete_agent",
+		"option":    "repo.agent.HardDelete",
+		"func_name": "repo.mysql.agent.HardDelete",
+		"agent_id":  agentID,
+		"deleted":   rowsAffected,
+	})
+	return nil
+}
+
+// ListDeletedAgents 获取已软删除的Agent列表，按删除时间倒序
+func (r *agentRepository) ListDeletedAgents() ([]*agentModel.Agent, error) {
+	var agents []*agentModel.Agent
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&agents).Error; err != nil {
+		logger.LogError(err, "", 0, "", "repo.mysql.agent", "gorm", map[string]interface{}{
+			"operation": "list_deleted_agents",
+			"option":    "repo.agent.ListDeletedAgents",
+			"func_name": "repo.mysql.agent.ListDeletedAgents",
+		})
+		return nil, err
+	}
+	return agents, nil
+}
+
+// RestoreAgent 恢复已软删除的Agent
+// 返回: Agent不存在或未被删除时返回 gorm.ErrRecordNotFound
+func (r *agentRepository) RestoreAgent(agentID string) error {
+	if agentID == "" {
+		return gorm.ErrInvalidData
+	}
+
+	result := r.db.Unscoped().Model(&agentModel.Agent{}).
+		Where("agent_id = ? AND deleted_at IS NOT NULL", agentID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		logger.LogError(result.Error, "", 0, "", "repo.agent.RestoreAgent", "gorm", map[string]interface{}{
+			"operation": "restore_agent",
+			"option":    "repo.agent.RestoreAgent",
+			"func_name": "repo.mysql.agent.RestoreAgent",
+			"agent_id":  agentID,
+		})
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	logger.LogInfo("Agent record restored", "", 0, "", "repo.agent.RestoreAgent", "gorm", map[string]interface{}{
+		"operation": "restore_agent",
+		"option":    "repo.agent.RestoreAgent",
+		"func_name": "repo.mysql.agent.RestoreAgent",
+		"agent_id":  agentID,
+	})
+	return nil
+}
+
+// GetList 获取Agent列表（支持分页、按状态、关键词、标签、任务支持过滤，支持排序）
+// 参数: page - 页码, pageSize - 每页大小, status - 状态过滤, keyword - 关键字过滤, tags - 标签过滤, taskSupport - 任务支持过滤,
+// sortBy/sortOrder - 排序字段与方向(字段需在 agentSortColumns 白名单内，否则有关键词时按相关度、无关键词时按 updated_at DESC)
 // 返回: []*agentModel.Agent - Agent列表, int64 - 总数量, error - 错误信息
-func (r *agentRepository) GetList(page, pageSize int, status *agentModel.AgentStatus, keyword *string, tags []string, taskSupport []string) ([]*agentModel.Agent, int64, error) {
+func (r *agentRepository) GetList(page, pageSize int, status *agentModel.AgentStatus, keyword *string, tags []string, taskSupport []string, sortBy, sortOrder string) ([]*agentModel.Agent, int64, error) {
 	var agents []*agentModel.Agent
 	var total int64
 
@@ -436,43 +668,15 @@ func (r *agentRepository) GetList(page, pageSize int, status *agentModel.AgentSt
 	if status != nil {
 		query = query.Where("status = ?", *status)
 	}
-	// 关键词过滤（AgentID、主机名、IP、备注等）
-	if keyword != nil && *keyword != "" {
-		like := fmt.Sprintf("%%%s%%", *keyword)
-		// 修正字段名为 ip_address，以匹配模型定义
-		query = query.Where("agent_id LIKE ? OR hostname LIKE ? OR ip_address LIKE ? OR remark LIKE ?", like, like, like, like)
+	// 关键词过滤（AgentID、主机名、IP、备注、操作系统、架构、版本）
+	hasKeyword := keyword != nil && *keyword != ""
+	if hasKeyword {
+		query = applyKeywordFilter(query, *keyword)
 	}
 	// 标签过滤
-	if len(tags) > 0 {
-		// 转换标签ID格式
-		var tagIDs []uint64
-		for _, t := range tags {
-			if id, err := strconv.ParseUint(t, 10, 64); err == nil {
-				tagIDs = append(tagIDs, id)
-			}
-		}
-
-		if len(tagIDs) > 0 {
-			// 使用子查询过滤：找出拥有所有指定标签的AgentID
-			// SELECT entity_id FROM sys_entity_tags WHERE entity_type = 'agent' AND tag_id IN (?) GROUP BY entity_id HAVING COUNT(DISTINCT tag_id) = ?
-			subQuery := r.db.Table("sys_entity_tags").
-				Select("entity_id").
-				Where("entity_type = ? AND tag_id IN ?", "agent", tagIDs).
-				Group("entity_id").
-				Having("COUNT(DISTINCT tag_id) = ?", len(tagIDs))
-
-			query = query.Where("agent_id IN (?)", subQuery)
-		} else {
-			// 如果提供了标签参数但无法解析出任何有效ID，则应该返回空列表
-			query = query.Where("1 = 0")
-		}
-	}
+	query = r.applyTagFilter(query, tags)
 	// 任务支持过滤 (TaskSupport) - 替代原 Capabilities
-	if len(taskSupport) > 0 {
-		for _, task := range taskSupport {
-			query = query.Where("JSON_CONTAINS(task_support, JSON_QUOTE(?))", task)
-		}
-	}
+	query = applyTaskSupportFilter(query, taskSupport)
 
 	// 统计总数
 	if err := query.Count(&total).Error; err != nil {
@@ -488,8 +692,14 @@ func (r *agentRepository) GetList(page, pageSize int, status *agentModel.AgentSt
 		return nil, 0, err
 	}
 
+	// 未指定有效排序字段时，关键词搜索结果按相关度排序
+	var order interface{} = buildOrderClause(agentSortColumns, sortBy, sortOrder, defaultAgentOrder)
+	if hasKeyword && !isSortable(agentSortColumns, sortBy) {
+		order = r.keywordRelevanceOrder(*keyword, defaultAgentOrder)
+	}
+
 	// 分页查询
-	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Order("updated_at DESC").Find(&agents).Error; err != nil {
+	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Order(order).Find(&agents).Error; err != nil {
 		logger.LogError(
 			err,
 			"", 0, "", "repo.mysql.agent", "gorm",
@@ -501,7 +711,8 @@ func (r *agentRepository) GetList(page, pageSize int, status *agentModel.AgentSt
 		)
 		return nil, 0, err
 	}
-	logger.LogInfo("Agent list fetched successfully", "", 0, "", "repo.agent.GetList", "gorm", map[string]interface{}{
+	// 列表查询调用频繁，按采样率输出成功日志
+	logger.LogInfoSampled("repo.agent.GetList", listLogSampleRate, "Agent list fetched successfully", "", 0, "", "repo.agent.GetList", "gorm", map[string]interface{}{
 		"operation": "get_agent_list",
 		"option":    "repo.agent.GetList",
 		"func_name": "repo.mysql.agent.GetList",
@@ -512,6 +723,40 @@ func (r *agentRepository) GetList(page, pageSize int, status *agentModel.AgentSt
 	return agents, total, nil
 }
 
+// applyTagFilter 标签过滤：只保留拥有全部指定标签的Agent
+// tags 为标签ID字符串，提供了标签但无法解析出任何有效ID时返回空结果
+func (r *agentRepository) applyTagFilter(query *gorm.DB, tags []string) *gorm.DB {
+	if len(tags) == 0 {
+		return query
+	}
+	// 转换标签ID格式
+	var tagIDs []uint64
+	for _, t := range tags {
+		if id, err := strconv.ParseUint(t, 10, 64); err == nil {
+			tagIDs = append(tagIDs, id)
+		}
+	}
+	if len(tagIDs) == 0 {
+		return query.Where("1 = 0")
+	}
+	// 使用子查询过滤：找出拥有所有指定标签的AgentID
+	// SELECT entity_id FROM sys_entity_tags WHERE entity_type = 'agent' AND tag_id IN (?) GROUP BY entity_id HAVING COUNT(DISTINCT tag_id) = ?
+	subQuery := r.db.Table("sys_entity_tags").
+		Select("entity_id").
+		Where("entity_type = ? AND tag_id IN ?", "agent", tagIDs).
+		Group("entity_id").
+		Having("COUNT(DISTINCT tag_id) = ?", len(tagIDs))
+	return query.Where("agents.agent_id IN (?)", subQuery)
+}
+
+// applyTaskSupportFilter 任务支持过滤：只保留支持全部指定任务类型的Agent
+func applyTaskSupportFilter(query *gorm.DB, taskSupport []string) *gorm.DB {
+	for _, task := range taskSupport {
+		query = query.Where("JSON_CONTAINS(agents.task_support, JSON_QUOTE(?))", task)
+	}
+	return query
+}
+
 // GetByStatus 按状态获取所有Agent
 // 参数: status - 状态过滤
 // 返回: []*agentModel.Agent - Agent列表, error - 错误信息
@@ -11,10 +11,16 @@
  * - metrics.go 性能指标操作
  * - capability.go 能力操作
  * - tag.go 标签操作
+ * - version.go 版本操作
+ * - config.go 配置操作
+ * - dispatch.go 任务分发候选查询
+ * - enrollment.go 接入令牌操作
  */
 package agent
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -32,20 +38,45 @@ type AgentRepository interface {
 	GetByHostname(hostname string) (*agentModel.Agent, error)
 	GetByHostnameAndPort(hostname string, port int) (*agentModel.Agent, error) // 根据主机名和端口获取Agent
 	Update(agentData *agentModel.Agent) error
-	Delete(agentID string) error
+	Delete(agentID string) error     // 软删除
+	HardDelete(agentID string) error // 彻底删除(含性能快照)
+	ListDeletedAgents() ([]*agentModel.Agent, error)
+	RestoreAgent(agentID string) error
 	// Agent 查询操作
-	GetList(page, pageSize int, status *agentModel.AgentStatus, keyword *string, tags []string, taskSupport []string) ([]*agentModel.Agent, int64, error)
+	GetList(page, pageSize int, status *agentModel.AgentStatus, keyword *string, tags []string, taskSupport []string, sortBy, sortOrder string) ([]*agentModel.Agent, int64, error)
 	GetByStatus(status agentModel.AgentStatus) ([]*agentModel.Agent, error)
 
+	// Agent 任务分发 - 在线 + 能力全部满足 + 标签全部命中，按负载升序
+	GetEligibleAgents(ctx context.Context, requiredCapabilities []string, requiredTags []string) ([]*agentModel.Agent, error)
+
 	// Agent 状态和心跳管理
 	UpdateStatus(agentID string, status agentModel.AgentStatus) error
 	UpdateLastHeartbeat(agentID string) error
+	UpdateHeartbeatProgress(agentID string, runningTasks []agentModel.RunningTaskProgress) error // 心跳时间与运行中任务进度一并更新
+	UpdateHeartbeatSettings(agentID string, heartbeatInterval, offlineThreshold int) error       // 更新心跳间隔与离线阈值(秒)
+	MarkStaleAgentsOffline(now time.Time) ([]string, error)                                      // 按各Agent自身离线阈值标记心跳超时的Agent为离线
 
 	// Agent 性能指标管理 - 直接操作agent_metrics表
 	CreateMetrics(metrics *agentModel.AgentMetrics) error
 	GetLatestMetrics(agentID string) (*agentModel.AgentMetrics, error)
 	UpdateAgentMetrics(agentID string, metrics *agentModel.AgentMetrics) error
-	GetMetricsList(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string) ([]*agentModel.AgentMetrics, int64, error) // 性能指标批量查询（分页 + 过滤）
+	GetMetricsList(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string, sortBy, sortOrder string) ([]*agentModel.AgentMetrics, int64, error) // 性能指标批量查询（分页 + 过滤 + 排序）
+
+	// Agent 版本管理 - agent_versions 表，最新版本唯一
+	SetLatestVersion(version string) error               // 将指定版本设为唯一的最新版本（版本需存在且已激活）
+	GetLatestVersion() (*agentModel.AgentVersion, error) // 获取当前最新版本，未设置时返回 nil
+
+	// Agent 配置管理 - agent_configs 表，每次修改版本号 +1
+	GetConfig(agentID string) (*agentModel.AgentConfig, error)                                              // 获取Agent配置，未配置时返回 nil
+	SaveConfig(cfg *agentModel.AgentConfig) error                                                           // 保存配置并递增版本号，版本冲突返回 ErrConcurrentModification
+	GetGroupConfig(groupTagID uint64) (*agentModel.AgentGroupConfig, error)                                 // 获取分组配置补丁，未配置时返回 nil
+	ApplyGroupConfig(groupTagID uint64, patch *agentModel.AgentConfigPatch, agentIDs []string) (int, error) // 事务内保存分组补丁并应用到成员Agent
+	ApplyConfigPatch(groupTagID uint64, patch *agentModel.AgentConfigPatch, agentIDs []string) (int, error) // 事务内将分组补丁应用到指定Agent (加入分组时继承)
+	RevertGroupConfig(groupTagID uint64, agentIDs []string) (int, error)                                    // 事务内撤销分组写入指定Agent的配置值 (退出分组时回退)
+
+	// Agent 接入令牌 - agent_enrollment_tokens 表，令牌一次性使用
+	CreateEnrollmentToken(token *agentModel.AgentEnrollmentToken) error                  // 保存接入令牌(仅哈希)
+	ConsumeEnrollmentToken(tokenHash string, usedBy string, now time.Time) (bool, error) // 消费未使用且未过期的令牌，返回是否成功
 
 	// Capability (ScanType) Management
 	GetAllScanTypes() ([]*agentModel.ScanType, error)
@@ -56,6 +87,7 @@ type AgentRepository interface {
 	GetMetricsSince(since time.Time) ([]*agentModel.AgentMetrics, error)                              // 获取指定时间窗口内的快照（timestamp >= since）
 	GetMetricsByAgentIDs(agentIDs []string) ([]*agentModel.AgentMetrics, error)                       // 按AgentID集合过滤获取快照
 	GetMetricsByAgentIDsSince(agentIDs []string, since time.Time) ([]*agentModel.AgentMetrics, error) // 按AgentID集合+时间窗口过滤获取快照
+	GetOnlineAgentMetricsSamples(ctx context.Context) ([]*agentModel.AgentMetricsSample, error)       // 一次查询获取全部在线Agent的最新快照(Prometheus 导出)
 
 	// Agent 能力管理 - 能力是Agent自己属性,需要结合Agent实际情况(Agent需要有自检能力的方法),不同于标签
 	// IsValidCapabilityId(capability string) bool                 // 判断能力ID是否有效
@@ -91,9 +123,16 @@ type AgentRepository interface {
 
 }
 
+// listLogSampleRate 列表查询成功日志的采样率 (每 N 条输出 1 条)
+// 列表接口被前端轮询、调度器频繁调用，全量输出会淹没其他日志
+const listLogSampleRate = 20
+
 // agentRepository Agent仓库实现
 type agentRepository struct {
 	db *gorm.DB // 数据库连接
+
+	searchIndexOnce  sync.Once // 全文索引检测只执行一次
+	searchIndexReady bool      // agents 表是否存在全文索引
 }
 
 // NewAgentRepository 创建Agent仓库实例
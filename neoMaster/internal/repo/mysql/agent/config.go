@@ -0,0 +1,286 @@
+/**
+ * @author: Sun977
+ * @date: 2026.10.16
+ * @description: Agent 配置表(agent_configs)数据访问
+ * @func:
+ * - GetConfig: 获取Agent当前配置
+ * - SaveConfig: 保存Agent配置并递增配置版本号
+ * - GetGroupConfig: 获取分组配置补丁
+ * - ApplyGroupConfig: 事务内保存分组配置补丁并应用到全部成员Agent
+ * - ApplyConfigPatch: 事务内将配置补丁应用到指定Agent (加入分组时继承分组配置)
+ * 约束：配置版本号只增不减，每次修改 +1；Agent 心跳上报的版本与之不一致时下发最新配置
+ */
+package agent
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+)
+
+// agentConfigColumns 保存配置时写入的字段 (显式列出以便零值也能写入)
+var agentConfigColumns = []string{
+	"version", "heartbeat_interval", "task_poll_interval", "max_concurrent_tasks", "plugin_config",
+	"log_level", "timeout", "token_expiry_duration", "token_never_expire", "is_active", "sources", "updated_at",
+}
+
+// GetConfig 获取Agent当前配置
+// 从未保存过配置时返回 nil, nil
+func (r *agentRepository) GetConfig(agentID string) (*agentModel.AgentConfig, error) {
+	var cfg agentModel.AgentConfig
+	err := r.db.Where("agent_id = ?", agentID).First(&cfg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.LogError(err, "", 0, "", "repo.agent.GetConfig", "gorm", map[string]interface{}{
+			"operation": "get_agent_config",
+			"option":    "agentRepository.GetConfig",
+			"func_name": "repo.agent.GetConfig",
+			"agent_id":  agentID,
+		})
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SaveConfig 保存Agent配置并递增配置版本号
+// cfg.Version 为读取时的版本：0 表示首次保存(新建，版本为1)；
+// 否则仅当库中版本仍为 cfg.Version 时更新，版本号 +1，被并发修改时返回 system.ErrConcurrentModification
+// 成功后 cfg.Version 为新版本号
+func (r *agentRepository) SaveConfig(cfg *agentModel.AgentConfig) error {
+	if cfg == nil || cfg.AgentID == "" {
+		return gorm.ErrInvalidData
+	}
+
+	readVersion := cfg.Version
+	var err error
+	if readVersion == 0 {
+		cfg.Version = 1
+		err = r.db.Create(cfg).Error
+	} else {
+		cfg.IncrementVersion()
+		result := r.db.Model(&agentModel.AgentConfig{}).
+			Where("agent_id = ? AND version = ?", cfg.AgentID, readVersion).
+			Select(agentConfigColumns).
+			Updates(cfg)
+		err = result.Error
+		if err == nil && result.RowsAffected == 0 {
+			err = system.ErrConcurrentModification
+		}
+	}
+	if err != nil {
+		cfg.Version = readVersion
+		logger.LogError(err, "", 0, "", "repo.agent.SaveConfig", "gorm", map[string]interface{}{
+			"operation": "save_agent_config",
+			"option":    "agentRepository.SaveConfig",
+			"func_name": "repo.agent.SaveConfig",
+			"agent_id":  cfg.AgentID,
+			"version":   readVersion,
+		})
+		return err
+	}
+	return nil
+}
+
+// GetGroupConfig 获取分组配置补丁
+// 分组从未下发过配置时返回 nil, nil
+func (r *agentRepository) GetGroupConfig(groupTagID uint64) (*agentModel.AgentGroupConfig, error) {
+	var groupCfg agentModel.AgentGroupConfig
+	err := r.db.Where("group_tag_id = ?", groupTagID).First(&groupCfg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.LogError(err, "", 0, "", "repo.agent.GetGroupConfig", "gorm", map[string]interface{}{
+			"operation":    "get_agent_group_config",
+			"option":       "agentRepository.GetGroupConfig",
+			"func_name":    "repo.agent.GetGroupConfig",
+			"group_tag_id": groupTagID,
+		})
+		return nil, err
+	}
+	return &groupCfg, nil
+}
+
+// ApplyGroupConfig 在一个事务内保存分组配置补丁并应用到成员Agent
+// patch 合并进分组已有补丁 (供之后加入的Agent继承)；每个成员Agent的配置应用 patch 后版本号 +1
+// 任一Agent更新失败整体回滚；不存在(含已删除)的Agent跳过，返回实际更新的Agent数
+func (r *agentRepository) ApplyGroupConfig(groupTagID uint64, patch *agentModel.AgentConfigPatch, agentIDs []string) (int, error) {
+	var updated int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var groupCfg agentModel.AgentGroupConfig
+		err := tx.Where("group_tag_id = ?", groupTagID).First(&groupCfg).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			groupCfg = agentModel.AgentGroupConfig{GroupTagID: groupTagID, Patch: *patch, Version: 1}
+			err = tx.Create(&groupCfg).Error
+		case err == nil:
+			groupCfg.Patch.Merge(patch)
+			err = tx.Model(&agentModel.AgentGroupConfig{}).
+				Where("id = ?", groupCfg.ID).
+				Select("patch", "version", "updated_at").
+				Updates(&agentModel.AgentGroupConfig{Patch: groupCfg.Patch, Version: groupCfg.Version + 1}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		updated, err = applyConfigPatchTx(tx, groupTagID, patch, agentIDs)
+		return err
+	})
+	if err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.ApplyGroupConfig", "gorm", map[string]interface{}{
+			"operation":    "apply_agent_group_config",
+			"option":       "agentRepository.ApplyGroupConfig",
+			"func_name":    "repo.agent.ApplyGroupConfig",
+			"group_tag_id": groupTagID,
+			"agent_count":  len(agentIDs),
+		})
+		return 0, err
+	}
+	return updated, nil
+}
+
+// ApplyConfigPatch 在一个事务内将分组配置补丁应用到指定Agent，版本号 +1
+// 返回实际更新的Agent数
+func (r *agentRepository) ApplyConfigPatch(groupTagID uint64, patch *agentModel.AgentConfigPatch, agentIDs []string) (int, error) {
+	var updated int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updated, err = applyConfigPatchTx(tx, groupTagID, patch, agentIDs)
+		return err
+	})
+	if err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.ApplyConfigPatch", "gorm", map[string]interface{}{
+			"operation":    "apply_agent_config_patch",
+			"option":       "agentRepository.ApplyConfigPatch",
+			"func_name":    "repo.agent.ApplyConfigPatch",
+			"group_tag_id": groupTagID,
+			"agent_count":  len(agentIDs),
+		})
+		return 0, err
+	}
+	return updated, nil
+}
+
+// RevertGroupConfig 在一个事务内撤销分组写入指定Agent的配置值 (Agent退出分组时调用)
+// 受影响的配置项回退到其他来源最近写入的值或默认值，配置有变化的Agent版本号 +1；
+// 心跳间隔随之变化时同步离线巡检使用的 agents.heartbeat_interval。返回实际更新的Agent数
+func (r *agentRepository) RevertGroupConfig(groupTagID uint64, agentIDs []string) (int, error) {
+	if len(agentIDs) == 0 {
+		return 0, nil
+	}
+	source := agentModel.ConfigFieldSource{Source: agentModel.ConfigSourceGroup, GroupID: groupTagID}
+	var updated int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var configs []agentModel.AgentConfig
+		if err := tx.Where("agent_id IN ?", agentIDs).Find(&configs).Error; err != nil {
+			return err
+		}
+		for i := range configs {
+			cfg := &configs[i]
+			readVersion, readInterval := cfg.Version, cfg.HeartbeatInterval
+			changed, err := cfg.RemoveSource(source)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			cfg.IncrementVersion()
+			result := tx.Model(&agentModel.AgentConfig{}).
+				Where("agent_id = ? AND version = ?", cfg.AgentID, readVersion).
+				Select(agentConfigColumns).
+				Updates(cfg)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return system.ErrConcurrentModification
+			}
+			if cfg.HeartbeatInterval != readInterval {
+				// 原离线阈值不大于新间隔时恢复默认(间隔的3倍)
+				err = tx.Model(&agentModel.Agent{}).
+					Where("agent_id = ?", cfg.AgentID).
+					Updates(map[string]interface{}{
+						"heartbeat_interval": cfg.HeartbeatInterval,
+						"offline_threshold":  gorm.Expr("CASE WHEN offline_threshold > 0 AND offline_threshold <= ? THEN 0 ELSE offline_threshold END", cfg.HeartbeatInterval),
+					}).Error
+				if err != nil {
+					return err
+				}
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.RevertGroupConfig", "gorm", map[string]interface{}{
+			"operation":    "revert_agent_group_config",
+			"option":       "agentRepository.RevertGroupConfig",
+			"func_name":    "repo.agent.RevertGroupConfig",
+			"group_tag_id": groupTagID,
+			"agent_count":  len(agentIDs),
+		})
+		return 0, err
+	}
+	return updated, nil
+}
+
+// applyConfigPatchTx 事务内逐个Agent应用分组配置补丁，补丁中的配置项来源记为该分组
+// 没有配置记录的Agent以默认配置为基础新建；补丁包含心跳间隔时同步离线巡检使用的 agents.heartbeat_interval
+func applyConfigPatchTx(tx *gorm.DB, groupTagID uint64, patch *agentModel.AgentConfigPatch, agentIDs []string) (int, error) {
+	if len(agentIDs) == 0 {
+		return 0, nil
+	}
+	var agents []*agentModel.Agent
+	if err := tx.Where("agent_id IN ?", agentIDs).Find(&agents).Error; err != nil {
+		return 0, err
+	}
+
+	for _, agent := range agents {
+		var cfg agentModel.AgentConfig
+		err := tx.Where("agent_id = ?", agent.AgentID).First(&cfg).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			created := agentModel.DefaultAgentConfigFor(agent)
+			patch.ApplyFromGroup(created, groupTagID)
+			created.Version = 1
+			err = tx.Create(created).Error
+		} else if err == nil {
+			readVersion := cfg.Version
+			patch.ApplyFromGroup(&cfg, groupTagID)
+			cfg.IncrementVersion()
+			result := tx.Model(&agentModel.AgentConfig{}).
+				Where("agent_id = ? AND version = ?", agent.AgentID, readVersion).
+				Select(agentConfigColumns).
+				Updates(&cfg)
+			err = result.Error
+			if err == nil && result.RowsAffected == 0 {
+				err = system.ErrConcurrentModification
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if patch.HeartbeatInterval != nil {
+		// 原离线阈值不大于新间隔时恢复默认(间隔的3倍)
+		interval := *patch.HeartbeatInterval
+		err := tx.Model(&agentModel.Agent{}).
+			Where("agent_id IN ?", agentIDs).
+			Updates(map[string]interface{}{
+				"heartbeat_interval": interval,
+				"offline_threshold":  gorm.Expr("CASE WHEN offline_threshold > 0 AND offline_threshold <= ? THEN 0 ELSE offline_threshold END", interval),
+			}).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(agents), nil
+}
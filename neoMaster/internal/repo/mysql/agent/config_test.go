@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/system"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAgentRepository_SaveConfig_VersionBump(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.AgentConfig{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	repo := &agentRepository{db: db}
+
+	if cfg, err := repo.GetConfig("agent-1"); err != nil || cfg != nil {
+		t.Fatalf("GetConfig() before save = (%v, %v), want (nil, nil)", cfg, err)
+	}
+	created := &agentModel.AgentConfig{AgentID: "agent-1", LogLevel: "info", PluginConfig: map[string]interface{}{"nmap": "fast"}}
+	if err := repo.SaveConfig(created); err != nil {
+		t.Fatalf("SaveConfig(new) error = %v", err)
+	}
+	if created.Version != 1 {
+		t.Fatalf("new config version = %d, want 1", created.Version)
+	}
+
+	// 两次并发修改读到同一版本
+	first, _ := repo.GetConfig("agent-1")
+	second, _ := repo.GetConfig("agent-1")
+	first.LogLevel = "debug"
+	first.TokenNeverExpire = true
+	if err := repo.SaveConfig(first); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("version after update = %d, want 2", first.Version)
+	}
+
+	second.LogLevel = "error"
+	if err := repo.SaveConfig(second); !errors.Is(err, system.ErrConcurrentModification) {
+		t.Fatalf("stale SaveConfig() error = %v, want ErrConcurrentModification", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("failed SaveConfig() changed version to %d", second.Version)
+	}
+
+	got, _ := repo.GetConfig("agent-1")
+	if got.Version != 2 || got.LogLevel != "debug" || !got.TokenNeverExpire || got.PluginConfig["nmap"] != "fast" {
+		t.Errorf("stored config = %+v", got)
+	}
+
+	// 零值也能写回
+	got.TokenNeverExpire = false
+	if err := repo.SaveConfig(got); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	if got, _ = repo.GetConfig("agent-1"); got.TokenNeverExpire || got.Version != 3 {
+		t.Errorf("stored config = (never_expire %v, version %d), want (false, 3)", got.TokenNeverExpire, got.Version)
+	}
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	agentModel "neomaster/internal/model/agent"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAgentRepository_SoftDelete(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.Agent{}, &agentModel.AgentMetrics{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	agents := []*agentModel.Agent{{AgentID: "agent-1"}, {AgentID: "agent-2"}}
+	if err := db.Create(&agents).Error; err != nil {
+		t.Fatalf("seed agents: %v", err)
+	}
+	if err := db.Create(&agentModel.AgentMetrics{AgentID: "agent-1"}).Error; err != nil {
+		t.Fatalf("seed metrics: %v", err)
+	}
+	repo := &agentRepository{db: db}
+
+	if err := repo.Delete("agent-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	// 软删除后常规查询不可见，记录与性能快照仍保留
+	if _, err := repo.GetByID("agent-1"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByID(deleted) error = %v, want ErrRecordNotFound", err)
+	}
+	list, total, err := repo.GetList(1, 10, nil, nil, nil, nil, "", "")
+	if err != nil || total != 1 || fmt.Sprint(agentIDs(list)) != "[agent-2]" {
+		t.Errorf("GetList() = %v (total %d, err %v), want [agent-2]", agentIDs(list), total, err)
+	}
+	deleted, err := repo.ListDeletedAgents()
+	if err != nil || fmt.Sprint(agentIDs(deleted)) != "[agent-1]" {
+		t.Errorf("ListDeletedAgents() = %v, %v; want [agent-1]", agentIDs(deleted), err)
+	}
+	if m, err := repo.GetLatestMetrics("agent-1"); err != nil || m == nil {
+		t.Errorf("metrics of soft-deleted agent should be kept: %v, %v", m, err)
+	}
+
+	// 恢复
+	if err := repo.RestoreAgent("agent-1"); err != nil {
+		t.Fatalf("RestoreAgent() error = %v", err)
+	}
+	if _, err := repo.GetByID("agent-1"); err != nil {
+		t.Errorf("GetByID(restored) error = %v", err)
+	}
+	if err := repo.RestoreAgent("agent-1"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("RestoreAgent(not deleted) error = %v, want ErrRecordNotFound", err)
+	}
+
+	// 彻底删除：软删除后的记录同样可以清除，性能快照一并删除
+	if err := repo.Delete("agent-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := repo.HardDelete("agent-1"); err != nil {
+		t.Fatalf("HardDelete() error = %v", err)
+	}
+	var remaining int64
+	db.Unscoped().Model(&agentModel.Agent{}).Where("agent_id = ?", "agent-1").Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("HardDelete() left %d agent rows", remaining)
+	}
+	db.Model(&agentModel.AgentMetrics{}).Where("agent_id = ?", "agent-1").Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("HardDelete() left %d metrics rows", remaining)
+	}
+	if deleted, _ := repo.ListDeletedAgents(); len(deleted) != 0 {
+		t.Errorf("ListDeletedAgents() after purge = %v, want empty", agentIDs(deleted))
+	}
+}
@@ -0,0 +1,52 @@
+/**
+ * @author: Sun977
+ * @date: 2026.10.16
+ * @description: Agent 任务分发候选查询
+ * @func:
+ * - GetEligibleAgents: 按在线状态、任务支持(能力)、标签筛选可分发任务的Agent，按负载升序返回
+ */
+package agent
+
+import (
+	"context"
+
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/pkg/logger"
+)
+
+// GetEligibleAgents 获取可接收任务的Agent
+// 1. 只返回在线 (status = online) 的Agent
+// 2. requiredCapabilities 需全部包含在 task_support 中 (JSON_CONTAINS)
+// 3. requiredTags 可选，为标签ID，需全部命中
+// 4. 按最新性能快照中的 running_tasks 升序排列，没有性能快照的Agent排在最后
+func (r *agentRepository) GetEligibleAgents(ctx context.Context, requiredCapabilities []string, requiredTags []string) ([]*agentModel.Agent, error) {
+	var agents []*agentModel.Agent
+
+	query := r.db.WithContext(ctx).Model(&agentModel.Agent{}).
+		Select("agents.*").
+		Joins("LEFT JOIN agent_metrics ON agent_metrics.agent_id = agents.agent_id").
+		Where("agents.status = ?", agentModel.AgentStatusOnline)
+	query = applyTaskSupportFilter(query, requiredCapabilities)
+	query = r.applyTagFilter(query, requiredTags)
+
+	err := query.
+		Order("agent_metrics.running_tasks IS NULL").
+		Order("agent_metrics.running_tasks ASC").
+		Order("agents.id ASC").
+		Find(&agents).Error
+	if err != nil {
+		logger.LogError(
+			err,
+			"", 0, "", "repo.mysql.agent", "gorm",
+			map[string]interface{}{
+				"operation":    "get_eligible_agents",
+				"option":       "repo.agent.GetEligibleAgents",
+				"func_name":    "repo.mysql.agent.GetEligibleAgents",
+				"capabilities": requiredCapabilities,
+				"tags":         requiredTags,
+			},
+		)
+		return nil, err
+	}
+	return agents, nil
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	agentModel "neomaster/internal/model/agent"
+	tagSystemModel "neomaster/internal/model/tag_system"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+var registerJSONContainsOnce sync.Once
+
+// registerJSONContains 为 sqlite 注册 MySQL 的 JSON_CONTAINS (仅支持标量候选值)
+func registerJSONContains(t *testing.T) {
+	t.Helper()
+	registerJSONContainsOnce.Do(func() {
+		gosqlite.MustRegisterDeterministicScalarFunction("JSON_CONTAINS", 2, func(_ *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			var target []interface{}
+			var candidate interface{}
+			if err := json.Unmarshal([]byte(fmt.Sprint(args[0])), &target); err != nil {
+				return int64(0), nil
+			}
+			if err := json.Unmarshal([]byte(fmt.Sprint(args[1])), &candidate); err != nil {
+				return nil, err
+			}
+			for _, v := range target {
+				if v == candidate {
+					return int64(1), nil
+				}
+			}
+			return int64(0), nil
+		})
+	})
+}
+
+func newDispatchTestRepo(t *testing.T) *agentRepository {
+	t.Helper()
+	registerJSONContains(t)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.Agent{}, &agentModel.AgentMetrics{}, &tagSystemModel.SysEntityTag{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	agents := []*agentModel.Agent{
+		{AgentID: "agent-busy", Status: agentModel.AgentStatusOnline, TaskSupport: agentModel.StringSlice{"webScan", "portScan"}},
+		{AgentID: "agent-idle", Status: agentModel.AgentStatusOnline, TaskSupport: agentModel.StringSlice{"portScan", "webScan"}},
+		{AgentID: "agent-no-metrics", Status: agentModel.AgentStatusOnline, TaskSupport: agentModel.StringSlice{"webScan"}},
+		{AgentID: "agent-port-only", Status: agentModel.AgentStatusOnline, TaskSupport: agentModel.StringSlice{"portScan"}},
+		{AgentID: "agent-offline", Status: agentModel.AgentStatusOffline, TaskSupport: agentModel.StringSlice{"webScan"}},
+	}
+	if err := db.Create(&agents).Error; err != nil {
+		t.Fatalf("seed agents: %v", err)
+	}
+	metrics := []*agentModel.AgentMetrics{
+		{AgentID: "agent-busy", RunningTasks: 5},
+		{AgentID: "agent-idle", RunningTasks: 1},
+		{AgentID: "agent-port-only", RunningTasks: 0},
+	}
+	if err := db.Create(&metrics).Error; err != nil {
+		t.Fatalf("seed metrics: %v", err)
+	}
+	tags := []*tagSystemModel.SysEntityTag{
+		{EntityType: "agent", EntityID: "agent-busy", TagID: 7},
+		{EntityType: "agent", EntityID: "agent-no-metrics", TagID: 7},
+	}
+	if err := db.Create(&tags).Error; err != nil {
+		t.Fatalf("seed tags: %v", err)
+	}
+	return &agentRepository{db: db}
+}
+
+func agentIDs(agents []*agentModel.Agent) []string {
+	ids := make([]string, 0, len(agents))
+	for _, a := range agents {
+		ids = append(ids, a.AgentID)
+	}
+	return ids
+}
+
+func TestAgentRepository_GetEligibleAgents(t *testing.T) {
+	repo := newDispatchTestRepo(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		caps []string
+		tags []string
+		want []string
+	}{
+		// 不支持 webScan 与离线的Agent被排除，按运行任务数升序，无快照的排在最后
+		{name: "capability", caps: []string{"webScan"}, want: []string{"agent-idle", "agent-busy", "agent-no-metrics"}},
+		{name: "all_capabilities", caps: []string{"webScan", "portScan"}, want: []string{"agent-idle", "agent-busy"}},
+		{name: "capability_and_tag", caps: []string{"webScan"}, tags: []string{"7"}, want: []string{"agent-busy", "agent-no-metrics"}},
+		{name: "unknown_capability", caps: []string{"vulnScan"}, want: []string{}},
+		{name: "invalid_tag", caps: []string{"webScan"}, tags: []string{"abc"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agents, err := repo.GetEligibleAgents(ctx, tt.caps, tt.tags)
+			if err != nil {
+				t.Fatalf("GetEligibleAgents() error = %v", err)
+			}
+			got := agentIDs(agents)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetEligibleAgents(%v, %v) = %v, want %v", tt.caps, tt.tags, got, tt.want)
+			}
+		})
+	}
+}
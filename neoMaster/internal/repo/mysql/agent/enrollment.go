@@ -0,0 +1,50 @@
+/**
+ * @author: Sun977
+ * @date: 2026.10.16
+ * @description: Agent 接入令牌表(agent_enrollment_tokens)数据访问
+ * @func:
+ * - CreateEnrollmentToken: 保存新签发的接入令牌(仅哈希)
+ * - ConsumeEnrollmentToken: 原子地消费未使用且未过期的接入令牌
+ * 约束：同一令牌只能被消费一次，并发接入时只有一个请求成功
+ */
+package agent
+
+import (
+	"time"
+
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/pkg/logger"
+)
+
+// CreateEnrollmentToken 保存接入令牌
+func (r *agentRepository) CreateEnrollmentToken(token *agentModel.AgentEnrollmentToken) error {
+	if err := r.db.Create(token).Error; err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.CreateEnrollmentToken", "gorm", map[string]interface{}{
+			"operation":    "create_enrollment_token",
+			"option":       "agentRepository.CreateEnrollmentToken",
+			"func_name":    "repo.agent.CreateEnrollmentToken",
+			"token_prefix": token.TokenPrefix,
+		})
+		return err
+	}
+	return nil
+}
+
+// ConsumeEnrollmentToken 消费接入令牌
+// 条件更新: 仅当令牌存在、未使用且在 now 时未过期才写入使用时间，返回是否消费成功
+// 令牌不存在、已使用或已过期均返回 false，不区分原因，避免泄露令牌状态
+func (r *agentRepository) ConsumeEnrollmentToken(tokenHash string, usedBy string, now time.Time) (bool, error) {
+	result := r.db.Model(&agentModel.AgentEnrollmentToken{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Updates(map[string]interface{}{"used_at": now, "used_by": usedBy})
+	if result.Error != nil {
+		logger.LogError(result.Error, "", 0, "", "repo.agent.ConsumeEnrollmentToken", "gorm", map[string]interface{}{
+			"operation": "consume_enrollment_token",
+			"option":    "agentRepository.ConsumeEnrollmentToken",
+			"func_name": "repo.agent.ConsumeEnrollmentToken",
+			"used_by":   usedBy,
+		})
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -6,8 +6,9 @@
  * @func: 提供Agent性能指标的CRUD操作，不包含业务逻辑
  * - CreateMetrics 创建Agent性能指标记录
  * - GetLatestMetrics 获取Agent最新的性能指标（每个Agent唯一快照）
- * - GetMetricsList 获取Agent性能指标列表（分页查询）
+ * - GetMetricsList 获取Agent性能指标列表（分页查询，支持排序）
  * - UpdateAgentMetrics 更新Agent性能指标记录
+ * - GetOnlineAgentMetricsSamples 批量获取在线Agent的最新快照（Prometheus 导出）
  * 重构说明：agent.go 中分离出
  * - 统一使用 logger.LogInfo 和 logger.LogError 进行结构化日志记录
  * - 对齐模型字段：使用 BaseModel 的 CreatedAt/UpdatedAt 以及 AgentMetrics.Timestamp
@@ -15,6 +16,7 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -122,8 +124,8 @@ func (r *agentRepository) GetLatestMetrics(agentID string) (*agentModel.AgentMet
 // GetMetricsList 性能指标批量查询（分页 + 过滤）
 // 说明：
 // - 支持按 work_status、scan_type 和 agent_id 关键词过滤
-// - 统一按 UpdatedAt DESC（最新更新时间）排序
-func (r *agentRepository) GetMetricsList(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string) ([]*agentModel.AgentMetrics, int64, error) {
+// - 默认按 timestamp DESC（最新快照）排序，sortBy 在 metricsSortColumns 白名单内时按指定字段排序
+func (r *agentRepository) GetMetricsList(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string, sortBy, sortOrder string) ([]*agentModel.AgentMetrics, int64, error) {
 	var metricsList []*agentModel.AgentMetrics
 	var total int64
 
@@ -180,8 +182,8 @@ func (r *agentRepository) GetMetricsList(page, pageSize int, workStatus *agentMo
 	// 计算偏移量
 	offset := (page - 1) * pageSize
 
-	// 获取分页数据，按更新时间戳倒序，必要时可增加次级排序保证稳定性
-	if err := query.Order("timestamp DESC").Offset(offset).Limit(pageSize).Find(&metricsList).Error; err != nil {
+	// 获取分页数据，排序字段经白名单校验
+	if err := query.Order(buildOrderClause(metricsSortColumns, sortBy, sortOrder, defaultMetricsOrder)).Offset(offset).Limit(pageSize).Find(&metricsList).Error; err != nil {
 		logger.LogError(err, "", 0, "", "repo.agent.GetMetricsList", "gorm", map[string]interface{}{
 			"operation": "get_metrics_list",
 			"option":    "agentRepository.GetMetricsList.query",
@@ -210,7 +212,7 @@ func (r *agentRepository) GetMetricsList(page, pageSize int, workStatus *agentMo
 		return nil, 0, err
 	}
 
-	logger.LogInfo("Agent metrics list retrieved successfully", "", 0, "", "repo.agent.GetMetricsList", "", map[string]interface{}{
+	logger.LogInfoSampled("repo.agent.GetMetricsList", listLogSampleRate, "Agent metrics list retrieved successfully", "", 0, "", "repo.agent.GetMetricsList", "", map[string]interface{}{
 		"operation": "get_metrics_list",
 		"option":    "agentRepository.GetMetricsList",
 		"func_name": "repo.agent.GetMetricsList",
@@ -442,3 +444,25 @@ func (r *agentRepository) GetMetricsByAgentIDsSince(agentIDs []string, since tim
 	})
 	return list, nil
 }
+
+// GetOnlineAgentMetricsSamples 获取全部在线Agent的最新性能快照
+// 说明：单快照模型下每个Agent最多一条快照，agents LEFT JOIN agent_metrics 一次查询即可取全，
+// 只读取导出所需的列(不加载 plugin_status)，供 Prometheus 每次抓取调用；没有快照的Agent也会返回
+func (r *agentRepository) GetOnlineAgentMetricsSamples(ctx context.Context) ([]*agentModel.AgentMetricsSample, error) {
+	var list []*agentModel.AgentMetricsSample
+	err := r.db.WithContext(ctx).Table("agents").
+		Select("agents.agent_id, m.cpu_usage, m.memory_usage, m.running_tasks, m.failed_tasks, m.timestamp").
+		Joins("LEFT JOIN agent_metrics m ON m.agent_id = agents.agent_id").
+		Where("agents.status = ? AND agents.deleted_at IS NULL", agentModel.AgentStatusOnline).
+		Order("agents.agent_id").
+		Scan(&list).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.GetOnlineAgentMetricsSamples", "gorm", map[string]interface{}{
+			"operation": "get_online_agent_metrics_samples",
+			"option":    "db.Scan(agents JOIN agent_metrics)",
+			"func_name": "repo.agent.GetOnlineAgentMetricsSamples",
+		})
+		return nil, err
+	}
+	return list, nil
+}
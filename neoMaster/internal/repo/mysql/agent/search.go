@@ -0,0 +1,70 @@
+/**
+ * Agent 关键词搜索
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: GetList 的关键词过滤与相关度排序。
+ *               过滤始终使用 LIKE 子串匹配(兼容原有行为，支持IP片段等非分词内容)；
+ *               相关度按 精确匹配 agent_id/hostname > 前缀匹配 > 其他 分档，
+ *               agents 表存在全文索引时同档内再按 MATCH ... AGAINST 得分排序。
+ */
+package agent
+
+import (
+	"strings"
+
+	agentModel "neomaster/internal/model/agent"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// applyKeywordFilter 关键词过滤：agent_id、hostname、ip_address、remark、os、arch、version 任一包含关键词
+func applyKeywordFilter(query *gorm.DB, keyword string) *gorm.DB {
+	like := "%" + keyword + "%"
+	return query.Where("agent_id LIKE ? OR hostname LIKE ? OR ip_address LIKE ? OR remark LIKE ? OR os LIKE ? OR arch LIKE ? OR version LIKE ?",
+		like, like, like, like, like, like, like)
+}
+
+// keywordRelevanceOrder 生成按关键词相关度排序的 ORDER BY 表达式，相关度相同时按 fallbackOrder 排序
+// GORM 不会合并多个表达式形式的 ORDER BY，因此相关度与兜底排序需放在同一个表达式中
+func (r *agentRepository) keywordRelevanceOrder(keyword, fallbackOrder string) clause.OrderBy {
+	prefix := keyword + "%"
+	sql := "CASE WHEN agent_id = ? OR hostname = ? THEN 0 WHEN agent_id LIKE ? OR hostname LIKE ? THEN 1 ELSE 2 END"
+	vars := []interface{}{keyword, keyword, prefix, prefix}
+
+	if terms := fulltextTerms(keyword); terms != "" && r.hasSearchIndex() {
+		sql += ", MATCH(" + agentModel.AgentSearchIndexColumns + ") AGAINST (? IN BOOLEAN MODE) DESC"
+		vars = append(vars, terms)
+	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                sql + ", " + fallbackOrder,
+		Vars:               vars,
+		WithoutParentheses: true,
+	}}
+}
+
+// hasSearchIndex 检测 agents 表是否已创建全文索引，结果在仓库实例内缓存
+// 非 MySQL 数据库(如测试使用的 sqlite)不支持 MATCH，直接返回 false
+func (r *agentRepository) hasSearchIndex() bool {
+	r.searchIndexOnce.Do(func() {
+		r.searchIndexReady = r.db.Dialector.Name() == "mysql" &&
+			r.db.Migrator().HasIndex(&agentModel.Agent{}, agentModel.AgentSearchIndex)
+	})
+	return r.searchIndexReady
+}
+
+// fulltextTerms 将关键词转换为 BOOLEAN MODE 检索词：去掉布尔运算符，每个词按前缀匹配
+func fulltextTerms(keyword string) string {
+	clean := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`+-<>()~*"@`, r) {
+			return ' '
+		}
+		return r
+	}, keyword)
+
+	words := strings.Fields(clean)
+	for i, w := range words {
+		words[i] = w + "*"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+
+	agentModel "neomaster/internal/model/agent"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAgentRepository_GetListKeywordRelevance(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.Agent{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	// 插入顺序即 id 顺序，默认排序 updated_at DESC 在同一时间写入时不保证顺序，由相关度决定
+	agents := []*agentModel.Agent{
+		{AgentID: "agent-1", Hostname: "db-web", Remark: "web tier"},
+		{AgentID: "agent-2", Hostname: "webserver"},
+		{AgentID: "agent-3", Hostname: "web"},
+		{AgentID: "agent-4", Hostname: "scanner", OS: "linux", Arch: "amd64", Version: "1.2.0"},
+		{AgentID: "agent-5", Hostname: "mail", IPAddress: "10.0.0.5"},
+	}
+	if err := db.Create(&agents).Error; err != nil {
+		t.Fatalf("seed agents: %v", err)
+	}
+	repo := &agentRepository{db: db}
+
+	tests := []struct {
+		name    string
+		keyword string
+		sortBy  string
+		want    []string
+	}{
+		// 精确匹配 > 前缀匹配 > 其他字段包含
+		{name: "relevance", keyword: "web", want: []string{"agent-3", "agent-2", "agent-1"}},
+		{name: "os", keyword: "linux", want: []string{"agent-4"}},
+		{name: "arch", keyword: "amd64", want: []string{"agent-4"}},
+		{name: "version", keyword: "1.2", want: []string{"agent-4"}},
+		{name: "ip_fragment", keyword: "0.0.5", want: []string{"agent-5"}},
+		{name: "exact_agent_id", keyword: "agent-2", want: []string{"agent-2"}},
+		// 显式排序优先于相关度
+		{name: "explicit_sort", keyword: "web", sortBy: "hostname", want: []string{"agent-2", "agent-3", "agent-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kw := tt.keyword
+			got, total, err := repo.GetList(1, 10, nil, &kw, nil, nil, tt.sortBy, "")
+			if err != nil {
+				t.Fatalf("GetList() error = %v", err)
+			}
+			if int(total) != len(tt.want) || fmt.Sprint(agentIDs(got)) != fmt.Sprint(tt.want) {
+				t.Errorf("GetList(keyword=%q) = %v (total %d), want %v", tt.keyword, agentIDs(got), total, tt.want)
+			}
+		})
+	}
+
+	// sqlite 没有全文索引，走 LIKE 路径
+	if repo.hasSearchIndex() {
+		t.Error("hasSearchIndex() = true on sqlite")
+	}
+}
+
+func TestFulltextTerms(t *testing.T) {
+	tests := map[string]string{
+		"web":          "web*",
+		"web server":   "web* server*",
+		"+web -db (x)": "web* db* x*",
+		`"quoted"~@`:   "quoted*",
+		"  *<>  ":      "",
+		"agent-1":      "agent* 1*",
+	}
+	for in, want := range tests {
+		if got := fulltextTerms(in); got != want {
+			t.Errorf("fulltextTerms(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/**
+ * Agent 列表排序
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 列表查询的排序字段白名单。排序字段无法使用占位符绑定，
+ *               只能拼接进 ORDER BY，因此调用方传入的字段必须先经白名单映射。
+ */
+package agent
+
+import "strings"
+
+// agentSortColumns GetList 允许排序的字段(请求字段名 -> 列名)
+var agentSortColumns = map[string]string{
+	"agent_id":       "agent_id",
+	"hostname":       "hostname",
+	"ip_address":     "ip_address",
+	"status":         "status",
+	"version":        "version",
+	"os":             "os",
+	"last_heartbeat": "last_heartbeat",
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+}
+
+// metricsSortColumns GetMetricsList 允许排序的字段(请求字段名 -> 列名)
+var metricsSortColumns = map[string]string{
+	"agent_id":      "agent_id",
+	"cpu_usage":     "cpu_usage",
+	"memory_usage":  "memory_usage",
+	"disk_usage":    "disk_usage",
+	"running_tasks": "running_tasks",
+	"work_status":   "work_status",
+	"timestamp":     "timestamp",
+}
+
+const (
+	defaultAgentOrder   = "updated_at DESC"
+	defaultMetricsOrder = "timestamp DESC"
+)
+
+// buildOrderClause 根据白名单生成 ORDER BY 子句
+// 不在白名单内的字段(包括空值)使用默认排序；sortOrder 仅识别 asc/desc，其余按 desc 处理
+// 非默认排序追加 id 作为次级排序，保证排序字段取值相同时分页结果稳定
+func buildOrderClause(columns map[string]string, sortBy, sortOrder, defaultOrder string) string {
+	column, ok := columns[normalizeSortField(sortBy)]
+	if !ok {
+		return defaultOrder
+	}
+	direction := "DESC"
+	if strings.EqualFold(strings.TrimSpace(sortOrder), "asc") {
+		direction = "ASC"
+	}
+	return column + " " + direction + ", id " + direction
+}
+
+// isSortable 排序字段是否在白名单内
+func isSortable(columns map[string]string, sortBy string) bool {
+	_, ok := columns[normalizeSortField(sortBy)]
+	return ok
+}
+
+func normalizeSortField(sortBy string) string {
+	return strings.ToLower(strings.TrimSpace(sortBy))
+}
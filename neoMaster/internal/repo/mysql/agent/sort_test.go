@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	agentModel "neomaster/internal/model/agent"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newSortTestRepo(t *testing.T) *agentRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.Agent{}, &agentModel.AgentMetrics{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	agents := []*agentModel.Agent{
+		{AgentID: "agent-b", Hostname: "bravo", LastHeartbeat: base.Add(-time.Minute)},
+		{AgentID: "agent-c", Hostname: "charlie", LastHeartbeat: base.Add(-time.Hour)},
+		{AgentID: "agent-a", Hostname: "alpha", LastHeartbeat: base},
+	}
+	for i, a := range agents {
+		// 创建时间依次递增，默认排序(updated_at DESC)结果与插入顺序相反
+		a.UpdatedAt = base.Add(time.Duration(i) * time.Second)
+	}
+	if err := db.Create(&agents).Error; err != nil {
+		t.Fatalf("seed agents: %v", err)
+	}
+	metrics := []*agentModel.AgentMetrics{
+		{AgentID: "agent-b", CPUUsage: 50, Timestamp: base.Add(-time.Minute)},
+		{AgentID: "agent-c", CPUUsage: 10, Timestamp: base},
+		{AgentID: "agent-a", CPUUsage: 90, Timestamp: base.Add(-time.Hour)},
+	}
+	if err := db.Create(&metrics).Error; err != nil {
+		t.Fatalf("seed metrics: %v", err)
+	}
+	return &agentRepository{db: db}
+}
+
+func TestAgentRepository_GetListSort(t *testing.T) {
+	repo := newSortTestRepo(t)
+
+	tests := []struct {
+		name      string
+		sortBy    string
+		sortOrder string
+		want      []string
+	}{
+		{name: "default", want: []string{"agent-a", "agent-c", "agent-b"}},
+		{name: "hostname_asc", sortBy: "hostname", sortOrder: "asc", want: []string{"agent-a", "agent-b", "agent-c"}},
+		{name: "last_heartbeat_default_desc", sortBy: "last_heartbeat", want: []string{"agent-a", "agent-b", "agent-c"}},
+		{name: "order_case_insensitive", sortBy: "Last_Heartbeat", sortOrder: "ASC", want: []string{"agent-c", "agent-b", "agent-a"}},
+		// 不在白名单内的字段被忽略，回退到默认排序而不是拼接进 SQL
+		{name: "injection_ignored", sortBy: "password; DROP TABLE", sortOrder: "asc", want: []string{"agent-a", "agent-c", "agent-b"}},
+		{name: "invalid_order_uses_desc", sortBy: "hostname", sortOrder: "asc; DROP TABLE agents", want: []string{"agent-c", "agent-b", "agent-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agents, total, err := repo.GetList(1, 10, nil, nil, nil, nil, tt.sortBy, tt.sortOrder)
+			if err != nil {
+				t.Fatalf("GetList() error = %v", err)
+			}
+			if total != 3 {
+				t.Errorf("GetList() total = %d, want 3", total)
+			}
+			if got := agentIDs(agents); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetList(sortBy=%q, sortOrder=%q) = %v, want %v", tt.sortBy, tt.sortOrder, got, tt.want)
+			}
+		})
+	}
+
+	// 注入字符串未被执行，表仍然可用
+	if err := repo.db.Model(&agentModel.Agent{}).Count(new(int64)).Error; err != nil {
+		t.Errorf("agents table unusable after injection attempt: %v", err)
+	}
+}
+
+func TestAgentRepository_GetMetricsListSort(t *testing.T) {
+	repo := newSortTestRepo(t)
+
+	tests := []struct {
+		name   string
+		sortBy string
+		want   []string
+	}{
+		{name: "default_timestamp_desc", want: []string{"agent-c", "agent-b", "agent-a"}},
+		{name: "cpu_usage_desc", sortBy: "cpu_usage", want: []string{"agent-a", "agent-b", "agent-c"}},
+		{name: "injection_ignored", sortBy: "password; DROP TABLE", want: []string{"agent-c", "agent-b", "agent-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, _, err := repo.GetMetricsList(1, 10, nil, nil, nil, tt.sortBy, "")
+			if err != nil {
+				t.Fatalf("GetMetricsList() error = %v", err)
+			}
+			got := make([]string, 0, len(list))
+			for _, m := range list {
+				got = append(got, m.AgentID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetMetricsList(sortBy=%q) = %v, want %v", tt.sortBy, got, tt.want)
+			}
+		})
+	}
+}
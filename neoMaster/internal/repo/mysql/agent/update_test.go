@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/system"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAgentRepository_Update_OptimisticLock(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.Agent{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	if err := db.Create(&agentModel.Agent{AgentID: "agent-1", Hostname: "scanner"}).Error; err != nil {
+		t.Fatalf("seed agent: %v", err)
+	}
+	repo := &agentRepository{db: db}
+
+	// 两次并发修改读到同一版本
+	first, _ := repo.GetByID("agent-1")
+	second, _ := repo.GetByID("agent-1")
+	if first.LockVersion != 1 {
+		t.Fatalf("initial lock_version = %d, want 1", first.LockVersion)
+	}
+
+	if err := repo.Update(&agentModel.Agent{AgentID: "agent-1", Remark: "first", LockVersion: first.LockVersion}); err != nil {
+		t.Fatalf("first Update() error = %v", err)
+	}
+	stale := &agentModel.Agent{AgentID: "agent-1", Remark: "second", LockVersion: second.LockVersion}
+	if err := repo.Update(stale); !errors.Is(err, system.ErrConcurrentModification) {
+		t.Fatalf("stale Update() error = %v, want ErrConcurrentModification", err)
+	}
+	if stale.LockVersion != second.LockVersion {
+		t.Errorf("failed Update() changed LockVersion to %d", stale.LockVersion)
+	}
+
+	got, _ := repo.GetByID("agent-1")
+	if got.Remark != "first" || got.LockVersion != 2 {
+		t.Errorf("stored agent = (remark %q, lock_version %d), want (first, 2)", got.Remark, got.LockVersion)
+	}
+
+	// 基于最新版本的修改成功
+	if err := repo.Update(&agentModel.Agent{AgentID: "agent-1", Remark: "third", LockVersion: got.LockVersion}); err != nil {
+		t.Fatalf("Update() with current version error = %v", err)
+	}
+	if got, _ = repo.GetByID("agent-1"); got.Remark != "third" || got.LockVersion != 3 {
+		t.Errorf("stored agent = (remark %q, lock_version %d), want (third, 3)", got.Remark, got.LockVersion)
+	}
+
+	// 未携带版本号的写入同样递增版本，之前读取的版本失效
+	holder, _ := repo.GetByID("agent-1")
+	unversioned := &agentModel.Agent{AgentID: "agent-1", Remark: "unversioned"}
+	if err := repo.Update(unversioned); err != nil {
+		t.Fatalf("unversioned Update() error = %v", err)
+	}
+	if unversioned.LockVersion != 4 {
+		t.Errorf("unversioned Update() LockVersion = %d, want 4", unversioned.LockVersion)
+	}
+	if err := repo.Update(&agentModel.Agent{AgentID: "agent-1", Remark: "stale", LockVersion: holder.LockVersion}); !errors.Is(err, system.ErrConcurrentModification) {
+		t.Errorf("Update() after unversioned write error = %v, want ErrConcurrentModification", err)
+	}
+}
+
+func TestAgentRepository_UpdateHeartbeatProgress(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.Agent{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	if err := db.Create(&agentModel.Agent{AgentID: "agent-1", Hostname: "scanner"}).Error; err != nil {
+		t.Fatalf("seed agent: %v", err)
+	}
+	repo := &agentRepository{db: db}
+
+	progress := []agentModel.RunningTaskProgress{
+		{TaskID: "task-1", Percent: 42.5, CurrentTarget: "10.0.0.1"},
+		{TaskID: "task-2", Percent: 0},
+	}
+	if err := repo.UpdateHeartbeatProgress("agent-1", progress); err != nil {
+		t.Fatalf("UpdateHeartbeatProgress() error = %v", err)
+	}
+	got, _ := repo.GetByID("agent-1")
+	if len(got.RunningTasks) != 2 || got.RunningTasks[0].Percent != 42.5 || got.RunningTasks[0].CurrentTarget != "10.0.0.1" {
+		t.Errorf("stored running_tasks = %+v", got.RunningTasks)
+	}
+	if got.LastHeartbeat.IsZero() {
+		t.Error("last_heartbeat not updated")
+	}
+
+	// 仅更新心跳时间 (旧版本Agent) 不影响已有进度
+	if err := repo.UpdateLastHeartbeat("agent-1"); err != nil {
+		t.Fatalf("UpdateLastHeartbeat() error = %v", err)
+	}
+	if got, _ = repo.GetByID("agent-1"); len(got.RunningTasks) != 2 {
+		t.Errorf("running_tasks changed by timestamp-only heartbeat: %+v", got.RunningTasks)
+	}
+
+	// 空列表清空进度
+	if err := repo.UpdateHeartbeatProgress("agent-1", []agentModel.RunningTaskProgress{}); err != nil {
+		t.Fatalf("UpdateHeartbeatProgress(empty) error = %v", err)
+	}
+	if got, _ = repo.GetByID("agent-1"); len(got.RunningTasks) != 0 {
+		t.Errorf("running_tasks not cleared: %+v", got.RunningTasks)
+	}
+
+	if err := repo.UpdateHeartbeatProgress("missing", progress); err == nil {
+		t.Error("expected error for unknown agent")
+	}
+}
+
+func TestAgentRepository_MarkStaleAgentsOffline_PerAgentThreshold(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.Agent{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	now := time.Now()
+	// 两个Agent最后心跳时间相同(2分钟前)，离线阈值不同
+	lastBeat := now.Add(-2 * time.Minute)
+	for _, agent := range []*agentModel.Agent{
+		{AgentID: "default", Hostname: "h1", Status: agentModel.AgentStatusOnline, LastHeartbeat: lastBeat},                                                  // 默认阈值 90s
+		{AgentID: "slow-link", Hostname: "h2", Status: agentModel.AgentStatusOnline, LastHeartbeat: lastBeat, HeartbeatInterval: 120, OfflineThreshold: 600}, // 阈值 10min
+		{AgentID: "slow-default", Hostname: "h3", Status: agentModel.AgentStatusOnline, LastHeartbeat: lastBeat, HeartbeatInterval: 60},                      // 阈值默认为 3 倍间隔 180s
+		{AgentID: "maintenance", Hostname: "h4", Status: agentModel.AgentStatusMaintenance, LastHeartbeat: lastBeat},
+	} {
+		if err := db.Create(agent).Error; err != nil {
+			t.Fatalf("seed agent: %v", err)
+		}
+	}
+	repo := &agentRepository{db: db}
+
+	marked, err := repo.MarkStaleAgentsOffline(now)
+	if err != nil {
+		t.Fatalf("MarkStaleAgentsOffline() error = %v", err)
+	}
+	if len(marked) != 1 || marked[0] != "default" {
+		t.Errorf("marked = %v, want [default]", marked)
+	}
+	for id, want := range map[string]agentModel.AgentStatus{
+		"default":      agentModel.AgentStatusOffline,
+		"slow-link":    agentModel.AgentStatusOnline,
+		"slow-default": agentModel.AgentStatusOnline,
+		"maintenance":  agentModel.AgentStatusMaintenance,
+	} {
+		if got, _ := repo.GetByID(id); got.Status != want {
+			t.Errorf("agent %s status = %s, want %s", id, got.Status, want)
+		}
+	}
+
+	// 更晚的巡检时间超过 slow-default 的阈值，但仍在 slow-link 阈值内
+	marked, err = repo.MarkStaleAgentsOffline(now.Add(2 * time.Minute))
+	if err != nil {
+		t.Fatalf("MarkStaleAgentsOffline() error = %v", err)
+	}
+	if len(marked) != 1 || marked[0] != "slow-default" {
+		t.Errorf("second sweep marked = %v, want [slow-default]", marked)
+	}
+}
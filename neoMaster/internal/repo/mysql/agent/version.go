@@ -0,0 +1,90 @@
+/**
+ * @author: Sun977
+ * @date: 2026.10.16
+ * @description: Agent 版本表(agent_versions)数据访问
+ * @func:
+ * - SetLatestVersion: 在事务中将指定版本设为唯一的最新版本
+ * - GetLatestVersion: 获取当前最新版本
+ * 约束：任意时刻最多只有一个 is_latest = true 的版本，Agent 自动更新以此为目标版本
+ */
+package agent
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/pkg/logger"
+)
+
+var (
+	// ErrAgentVersionNotFound 版本不存在
+	ErrAgentVersionNotFound = errors.New("agent version not found")
+	// ErrAgentVersionInactive 版本未激活，不能设为最新版本
+	ErrAgentVersionInactive = errors.New("agent version is not active")
+)
+
+// SetLatestVersion 将指定版本设为最新版本
+// 事务内先清除所有版本的 is_latest，再设置目标版本，保证最新版本唯一
+// 版本不存在返回 ErrAgentVersionNotFound，版本未激活返回 ErrAgentVersionInactive
+func (r *agentRepository) SetLatestVersion(version string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var target agentModel.AgentVersion
+		if err := tx.Where("version = ?", version).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAgentVersionNotFound
+			}
+			return err
+		}
+		if !target.IsActive {
+			return ErrAgentVersionInactive
+		}
+
+		if err := tx.Model(&agentModel.AgentVersion{}).
+			Where("is_latest = ? AND id <> ?", true, target.ID).
+			Update("is_latest", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&target).Update("is_latest", true).Error
+	})
+	if err != nil {
+		logger.LogError(err, "", 0, "", "repo.agent.SetLatestVersion", "gorm", map[string]interface{}{
+			"operation": "set_latest_agent_version",
+			"option":    "agentRepository.SetLatestVersion",
+			"func_name": "repo.agent.SetLatestVersion",
+			"version":   version,
+		})
+		return err
+	}
+
+	logger.LogInfo("Agent latest version updated", "", 0, "", "repo.agent.SetLatestVersion", "gorm", map[string]interface{}{
+		"operation": "set_latest_agent_version",
+		"option":    "agentRepository.SetLatestVersion",
+		"func_name": "repo.agent.SetLatestVersion",
+		"version":   version,
+	})
+	return nil
+}
+
+// GetLatestVersion 获取当前最新版本
+// 没有设置最新版本时返回 nil, nil
+// 兼容历史数据中存在多个 is_latest 的情况：取发布日期最新的一条
+func (r *agentRepository) GetLatestVersion() (*agentModel.AgentVersion, error) {
+	var latest agentModel.AgentVersion
+	err := r.db.Where("is_latest = ?", true).
+		Order("release_date DESC").Order("id DESC").
+		First(&latest).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.LogError(err, "", 0, "", "repo.agent.GetLatestVersion", "gorm", map[string]interface{}{
+			"operation": "get_latest_agent_version",
+			"option":    "agentRepository.GetLatestVersion",
+			"func_name": "repo.agent.GetLatestVersion",
+		})
+		return nil, err
+	}
+	return &latest, nil
+}
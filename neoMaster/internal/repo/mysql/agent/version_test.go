@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	agentModel "neomaster/internal/model/agent"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newVersionTestRepo(t *testing.T) (*agentRepository, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&agentModel.AgentVersion{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	versions := []agentModel.AgentVersion{
+		{Version: "v1.0.0", ReleaseDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), IsActive: true, IsLatest: true},
+		{Version: "v1.1.0", ReleaseDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), IsActive: true, IsLatest: true},
+		{Version: "v1.2.0-rc1", ReleaseDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	if err := db.Create(&versions).Error; err != nil {
+		t.Fatalf("seed versions: %v", err)
+	}
+	// IsActive 默认值为 true，零值不会写入，需要显式更新
+	if err := db.Model(&agentModel.AgentVersion{}).Where("version = ?", "v1.2.0-rc1").Update("is_active", false).Error; err != nil {
+		t.Fatalf("deactivate version: %v", err)
+	}
+	return &agentRepository{db: db}, db
+}
+
+func TestAgentRepository_SetLatestVersion(t *testing.T) {
+	repo, db := newVersionTestRepo(t)
+
+	// 历史数据中存在两个 latest 时取发布日期最新的一条
+	latest, err := repo.GetLatestVersion()
+	if err != nil || latest == nil || latest.Version != "v1.1.0" {
+		t.Fatalf("GetLatestVersion() = %v, %v; want v1.1.0", latest, err)
+	}
+
+	if err := repo.SetLatestVersion("v1.0.0"); err != nil {
+		t.Fatalf("SetLatestVersion(v1.0.0) error = %v", err)
+	}
+	var count int64
+	db.Model(&agentModel.AgentVersion{}).Where("is_latest = ?", true).Count(&count)
+	if count != 1 {
+		t.Fatalf("latest versions = %d, want exactly 1", count)
+	}
+	if latest, _ := repo.GetLatestVersion(); latest == nil || latest.Version != "v1.0.0" {
+		t.Fatalf("GetLatestVersion() = %v, want v1.0.0", latest)
+	}
+
+	if err := repo.SetLatestVersion("v9.9.9"); !errors.Is(err, ErrAgentVersionNotFound) {
+		t.Errorf("SetLatestVersion(missing) error = %v, want ErrAgentVersionNotFound", err)
+	}
+	if err := repo.SetLatestVersion("v1.2.0-rc1"); !errors.Is(err, ErrAgentVersionInactive) {
+		t.Errorf("SetLatestVersion(inactive) error = %v, want ErrAgentVersionInactive", err)
+	}
+	// 失败时不影响原有的最新版本
+	if latest, _ := repo.GetLatestVersion(); latest == nil || latest.Version != "v1.0.0" {
+		t.Errorf("latest changed after failed SetLatestVersion: %v", latest)
+	}
+}
@@ -3,10 +3,14 @@ package asset
 import (
 	"context"
 	"errors"
+	"time"
+
 	"neomaster/internal/model/asset"
 	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // AssetHostRepository 资产主机仓库
@@ -29,6 +33,7 @@ func (r *AssetHostRepository) CreateHost(ctx context.Context, host *asset.AssetH
 	if host == nil {
 		return errors.New("host is nil")
 	}
+	host.IPHex = utils.IPSortKey(host.IP)
 	err := r.db.WithContext(ctx).Create(host).Error
 	if err != nil {
 		logger.LogError(err, "", 0, "", "create_host", "REPO", map[string]interface{}{
@@ -79,6 +84,9 @@ func (r *AssetHostRepository) UpdateHost(ctx context.Context, host *asset.AssetH
 	if host == nil || host.ID == 0 {
 		return errors.New("invalid host or id")
 	}
+	if host.IP != "" {
+		host.IPHex = utils.IPSortKey(host.IP)
+	}
 	// 使用 Updates 而不是 Save，以支持部分更新并避免覆盖 CreatedAt 等字段
 	err := r.db.WithContext(ctx).Model(host).Updates(host).Error
 	if err != nil {
@@ -106,8 +114,80 @@ func (r *AssetHostRepository) DeleteHost(ctx context.Context, id uint64) error {
 	return nil
 }
 
+// UpsertHost 按 IP 创建或合并主机
+// 已存在时只用非空字段覆盖 Hostname/OS，保留 FirstSeenAt，刷新 LastSeenAt；返回库中最新的主机记录
+func (r *AssetHostRepository) UpsertHost(ctx context.Context, host *asset.AssetHost) (*asset.AssetHost, error) {
+	if host == nil {
+		return nil, errors.New("host is nil")
+	}
+	now := time.Now()
+	seenAt := host.LastSeenAt
+	if seenAt == nil {
+		seenAt = &now
+	}
+
+	var result *asset.AssetHost
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing asset.AssetHost
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("ip = ?", host.IP).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if host.FirstSeenAt == nil {
+				host.FirstSeenAt = seenAt
+			}
+			host.LastSeenAt = seenAt
+			if host.SourceStageIDs == "" {
+				host.SourceStageIDs = "[]"
+			}
+			host.IPHex = utils.IPSortKey(host.IP)
+			if err = tx.Create(host).Error; err != nil {
+				return err
+			}
+			result = host
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if host.Hostname != "" {
+			existing.Hostname = host.Hostname
+		}
+		if host.OS != "" {
+			existing.OS = host.OS
+		}
+		if existing.IPHex == "" {
+			existing.IPHex = utils.IPSortKey(existing.IP)
+		}
+		if existing.FirstSeenAt == nil {
+			// 首次发现时间字段上线前的历史数据，以创建时间为准
+			createdAt := existing.CreatedAt
+			existing.FirstSeenAt = &createdAt
+		}
+		if existing.LastSeenAt == nil || seenAt.After(*existing.LastSeenAt) {
+			existing.LastSeenAt = seenAt
+		}
+		if (existing.SourceStageIDs == "" || existing.SourceStageIDs == "[]") && host.SourceStageIDs != "" {
+			existing.SourceStageIDs = host.SourceStageIDs
+		}
+		if err = tx.Save(&existing).Error; err != nil {
+			return err
+		}
+		result = &existing
+		return nil
+	})
+	if err != nil {
+		logger.LogError(err, "", 0, "", "upsert_host", "REPO", map[string]interface{}{
+			"operation": "upsert_host",
+			"ip":        host.IP,
+		})
+		return nil, err
+	}
+	return result, nil
+}
+
 // ListHosts 获取主机列表 (分页 + 筛选)
-func (r *AssetHostRepository) ListHosts(ctx context.Context, page, pageSize int, ip, hostname, os string, hostIDs []uint64) ([]*asset.AssetHost, int64, error) {
+// cidr 不为空时只返回该网段 (或单个 IP) 内的主机；openPort > 0 时只返回开放了该端口的主机
+func (r *AssetHostRepository) ListHosts(ctx context.Context, page, pageSize int, ip, hostname, os, cidr string, openPort int, hostIDs []uint64) ([]*asset.AssetHost, int64, error) {
 	var hosts []*asset.AssetHost
 	var total int64
 
@@ -125,6 +205,16 @@ func (r *AssetHostRepository) ListHosts(ctx context.Context, page, pageSize int,
 	if os != "" {
 		query = query.Where("os LIKE ?", "%"+os+"%")
 	}
+	if openPort > 0 {
+		query = query.Where("id IN (?)", r.db.Model(&asset.AssetService{}).Select("host_id").Where("port = ?", openPort))
+	}
+	if cidr != "" {
+		start, end, err := utils.CIDRSortKeyRange(cidr)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("ip_hex BETWEEN ? AND ?", start, end)
+	}
 
 	err := query.Count(&total).Error
 	if err != nil {
@@ -146,6 +236,32 @@ func (r *AssetHostRepository) ListHosts(ctx context.Context, page, pageSize int,
 	return hosts, total, nil
 }
 
+// SearchByCIDR 按网段 (或单个 IP) 搜索主机，openPort 不为空时只返回开放了该端口的主机
+// 网段换算为 ip_hex 闭区间做范围查询，走 idx_asset_hosts_ip_hex 索引，结果按 IP 升序
+func (r *AssetHostRepository) SearchByCIDR(ctx context.Context, cidr string, openPort *int) ([]*asset.AssetHost, error) {
+	start, end, err := utils.CIDRSortKeyRange(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []*asset.AssetHost
+	query := r.db.WithContext(ctx).Model(&asset.AssetHost{}).
+		Where("asset_hosts.ip_hex BETWEEN ? AND ?", start, end)
+	if openPort != nil {
+		query = query.Distinct("asset_hosts.*").
+			Joins("JOIN asset_services ON asset_services.host_id = asset_hosts.id").
+			Where("asset_services.port = ?", *openPort)
+	}
+	if err = query.Order("asset_hosts.ip_hex asc").Find(&hosts).Error; err != nil {
+		logger.LogError(err, "", 0, "", "search_hosts_by_cidr", "REPO", map[string]interface{}{
+			"operation": "search_hosts_by_cidr",
+			"cidr":      cidr,
+		})
+		return nil, err
+	}
+	return hosts, nil
+}
+
 // -----------------------------------------------------------------------------
 // AssetService (服务资产) CRUD
 // -----------------------------------------------------------------------------
@@ -206,6 +322,23 @@ func (r *AssetHostRepository) GetServiceByHostIDAndPort(ctx context.Context, hos
 	return &service, nil
 }
 
+// GetServiceByDedupKey 根据去重键获取服务
+func (r *AssetHostRepository) GetServiceByDedupKey(ctx context.Context, dedupKey string) (*asset.AssetService, error) {
+	var service asset.AssetService
+	err := r.db.WithContext(ctx).Where("dedup_key = ?", dedupKey).First(&service).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.LogError(err, "", 0, "", "get_service_by_dedup_key", "REPO", map[string]interface{}{
+			"operation": "get_service_by_dedup_key",
+			"dedup_key": dedupKey,
+		})
+		return nil, err
+	}
+	return &service, nil
+}
+
 // UpdateService 更新服务
 func (r *AssetHostRepository) UpdateService(ctx context.Context, service *asset.AssetService) error {
 	if service == nil || service.ID == 0 {
@@ -249,6 +382,23 @@ func (r *AssetHostRepository) ListServicesByHostID(ctx context.Context, hostID u
 	return services, nil
 }
 
+// ListServicesByHostIDs 批量获取多个主机的服务列表 (用于加载主机开放端口)
+func (r *AssetHostRepository) ListServicesByHostIDs(ctx context.Context, hostIDs []uint64) ([]*asset.AssetService, error) {
+	var services []*asset.AssetService
+	if len(hostIDs) == 0 {
+		return services, nil
+	}
+	err := r.db.WithContext(ctx).Where("host_id IN ?", hostIDs).Order("host_id asc, port asc").Find(&services).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "list_services_by_host_ids", "REPO", map[string]interface{}{
+			"operation": "list_services_by_host_ids",
+			"host_ids":  hostIDs,
+		})
+		return nil, err
+	}
+	return services, nil
+}
+
 // ListServices 获取服务列表 (分页 + 筛选)
 func (r *AssetHostRepository) ListServices(ctx context.Context, page, pageSize int, port int, name, proto string, serviceIDs []uint64) ([]*asset.AssetService, int64, error) {
 	var services []*asset.AssetService
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TaskRepository Agent任务仓库接口
@@ -22,10 +23,56 @@ type TaskRepository interface {
 	GetTasksByProjectID(ctx context.Context, projectID uint64) ([]*agentModel.AgentTask, error)
 	ClaimTask(ctx context.Context, taskID string, agentID string) error
 	HasRunningTasks(ctx context.Context, projectID uint64) (bool, error)
-	GetRunningTasks(ctx context.Context) ([]*agentModel.AgentTask, error) // 获取所有正在运行的任务(用于超时监控)
+	GetRunningTasks(ctx context.Context) ([]*agentModel.AgentTask, error) // 获取所有正在运行/取消中的任务(用于超时监控)
 	RetryTask(ctx context.Context, taskID string, retryCount int, errorMsg string) error
+	GetDueFailedTasks(ctx context.Context, now time.Time) ([]*agentModel.AgentTask, error)            // 获取已到期的失败任务(用于退避重试)
+	ScheduleRetry(ctx context.Context, taskIDs []string, retryCount int, nextRetryAt time.Time) error // 批量按退避时间重新置为待处理
+	MarkTasksDead(ctx context.Context, taskIDs []string) error                                        // 批量标记为 dead(重试耗尽)
+	CountTasksByStageStatus(ctx context.Context, projectID uint64) ([]StageTaskCount, error)          // 按阶段和状态统计项目任务数
+	// ClaimTaskWithinLimits 在并发上限内认领任务，达到上限时返回 ErrGlobalConcurrencyLimit/ErrProjectConcurrencyLimit
+	ClaimTaskWithinLimits(ctx context.Context, task *agentModel.AgentTask, agentID string, limits TaskConcurrencyLimits) error
+	CountTasksByProjectStatus(ctx context.Context, category string, statuses []string) ([]ProjectTaskCount, error) // 按项目和状态统计任务数
+	// TransitionTaskStatus 条件更新任务状态，仅当前状态为 from 时生效，返回是否更新成功
+	TransitionTaskStatus(ctx context.Context, taskID string, from string, to string, result string, errorMsg string) (bool, error)
+	CancelProjectTasks(ctx context.Context, projectID uint64) (canceled int64, canceling int64, err error) // 取消项目下所有未结束的任务
 }
 
+// StageTaskCount 按阶段和状态统计的任务数
+type StageTaskCount struct {
+	StageID uint64
+	Status  string
+	Count   int64
+}
+
+// ProjectTaskCount 按项目和状态统计的任务数
+type ProjectTaskCount struct {
+	ProjectID uint64
+	Status    string
+	Count     int64
+}
+
+// TaskConcurrencyLimits 任务并发上限，<= 0 表示不限制
+type TaskConcurrencyLimits struct {
+	Global     int // 全局运行中任务数上限
+	PerProject int // 单个项目运行中任务数上限
+}
+
+// 并发上限错误，任务保持 pending 排队，等运行中任务结束后再被认领
+var (
+	ErrGlobalConcurrencyLimit  = errors.New("global task concurrency limit reached")
+	ErrProjectConcurrencyLimit = errors.New("project task concurrency limit reached")
+)
+
+// activeTaskStatuses 占用并发额度的任务状态
+// canceling 的任务在 Agent 确认前仍在执行，同样占用额度
+var activeTaskStatuses = []string{"assigned", "running", "canceling"}
+
+// finishedTaskStatuses 本次执行已结束的状态，流转到这些状态时记录结果与完成时间
+var finishedTaskStatuses = map[string]bool{"completed": true, "failed": true, "canceled": true}
+
+// taskClaimLockName 认领 Agent 任务时使用的分发锁
+const taskClaimLockName = "agent_task_claim"
+
 type taskRepository struct {
 	db *gorm.DB
 }
@@ -69,6 +116,7 @@ func (r *taskRepository) GetPendingTasks(ctx context.Context, category string, l
 	var tasks []*agentModel.AgentTask
 	err := r.db.WithContext(ctx).
 		Where("status = ? AND task_category = ?", "pending", category).
+		Where("next_retry_at IS NULL OR next_retry_at <= ?", time.Now()). // 退避中的重试任务暂不分发
 		Order("priority desc, created_at asc").
 		Limit(limit).
 		Find(&tasks).Error
@@ -104,11 +152,12 @@ func (r *taskRepository) GetLatestTaskByProjectID(ctx context.Context, projectID
 	return &task, nil
 }
 
-// GetTasksByAgentID 获取指定 Agent 的所有任务
+// GetTasksByAgentID 获取指定 Agent 未结束的任务 (assigned, running, canceling)
+// canceling 的任务需要下发给 Agent，由 Agent 中止执行后确认取消
 func (r *taskRepository) GetTasksByAgentID(ctx context.Context, agentID string) ([]*agentModel.AgentTask, error) {
 	var tasks []*agentModel.AgentTask
 	err := r.db.WithContext(ctx).
-		Where("agent_id = ?", agentID).
+		Where("agent_id = ? AND status IN ?", agentID, activeTaskStatuses).
 		Find(&tasks).Error
 	if err != nil {
 		return nil, err
@@ -165,11 +214,11 @@ func (r *taskRepository) ClaimTask(ctx context.Context, taskID string, agentID s
 	return nil
 }
 
-// HasRunningTasks 检查是否有正在运行的任务 (包括 pending, assigned, running)
+// HasRunningTasks 检查是否有正在运行的任务 (包括 pending, assigned, running, canceling)
 func (r *taskRepository) HasRunningTasks(ctx context.Context, projectID uint64) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&agentModel.AgentTask{}).
-		Where("project_id = ? AND status IN ?", projectID, []string{"pending", "assigned", "running"}).
+		Where("project_id = ? AND status IN ?", projectID, []string{"pending", "assigned", "running", "canceling"}).
 		Count(&count).Error
 	if err != nil {
 		return false, err
@@ -178,13 +227,203 @@ func (r *taskRepository) HasRunningTasks(ctx context.Context, projectID uint64)
 }
 
 // GetRunningTasks 获取所有正在运行的任务 (用于超时监控)
+// 包含等待 Agent 确认的 canceling 任务，Agent 失联时由超时监控兜底
 func (r *taskRepository) GetRunningTasks(ctx context.Context) ([]*agentModel.AgentTask, error) {
 	var tasks []*agentModel.AgentTask
 	err := r.db.WithContext(ctx).
-		Where("status = ?", "running").
+		Where("status IN ?", []string{"running", "canceling"}).
 		Find(&tasks).Error
 	if err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
+
+// GetDueFailedTasks 获取已到期的失败任务 (用于退避重试)
+// 一次查询取出 next_retry_at 为空或不晚于 now 的 failed 任务，由调用方区分重试或标记 dead
+func (r *taskRepository) GetDueFailedTasks(ctx context.Context, now time.Time) ([]*agentModel.AgentTask, error) {
+	var tasks []*agentModel.AgentTask
+	err := r.db.WithContext(ctx).
+		Where("status = ?", "failed").
+		Where("next_retry_at IS NULL OR next_retry_at <= ?", now).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ScheduleRetry 批量重新调度失败任务
+// 状态置为 pending 并设置 next_retry_at，到期前 GetPendingTasks 不会返回这些任务
+// 条件中带上 status = failed，避免覆盖并发期间已被其他流程修改的任务
+func (r *taskRepository) ScheduleRetry(ctx context.Context, taskIDs []string, retryCount int, nextRetryAt time.Time) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	updates := map[string]interface{}{
+		"status":        "pending",
+		"retry_count":   retryCount,
+		"next_retry_at": nextRetryAt,
+		"agent_id":      "",  // 释放任务，允许其他 Agent 领取
+		"started_at":    nil, // 重置开始时间
+		"assigned_at":   nil, // 重置分配时间
+	}
+	return r.db.WithContext(ctx).Model(&agentModel.AgentTask{}).
+		Where("task_id IN ? AND status = ?", taskIDs, "failed").
+		Updates(updates).Error
+}
+
+// MarkTasksDead 批量标记任务为 dead (重试次数耗尽的终态)
+func (r *taskRepository) MarkTasksDead(ctx context.Context, taskIDs []string) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&agentModel.AgentTask{}).
+		Where("task_id IN ? AND status = ?", taskIDs, "failed").
+		Updates(map[string]interface{}{
+			"status":        "dead",
+			"next_retry_at": nil,
+			"finished_at":   time.Now(),
+		}).Error
+}
+
+// CountTasksByStageStatus 按阶段和状态分组统计项目任务数 (一次 GROUP BY 查询)
+func (r *taskRepository) CountTasksByStageStatus(ctx context.Context, projectID uint64) ([]StageTaskCount, error) {
+	var counts []StageTaskCount
+	err := r.db.WithContext(ctx).Model(&agentModel.AgentTask{}).
+		Select("stage_id, status, COUNT(*) AS count").
+		Where("project_id = ?", projectID).
+		Group("stage_id, status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ClaimTaskWithinLimits 在并发上限内认领任务
+// 未配置上限时等同于 ClaimTask；否则在事务内锁住分发锁行，统计运行中的 Agent 任务数后再认领，
+// 统计与认领之间不会有其他实例插入，上限在多实例并发分发时同样有效
+func (r *taskRepository) ClaimTaskWithinLimits(ctx context.Context, task *agentModel.AgentTask, agentID string, limits TaskConcurrencyLimits) error {
+	if limits.Global <= 0 && limits.PerProject <= 0 {
+		return r.ClaimTask(ctx, task.TaskID, agentID)
+	}
+
+	// 锁行不存在时先创建 (并发创建由主键冲突忽略)
+	lock := agentModel.DispatchLock{Name: taskClaimLockName}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", taskClaimLockName).
+			First(&agentModel.DispatchLock{}).Error; err != nil {
+			return err
+		}
+
+		if limits.Global > 0 {
+			var running int64
+			if err := tx.Model(&agentModel.AgentTask{}).
+				Where("task_category = ? AND status IN ?", "agent", activeTaskStatuses).
+				Count(&running).Error; err != nil {
+				return err
+			}
+			if running >= int64(limits.Global) {
+				return ErrGlobalConcurrencyLimit
+			}
+		}
+		if limits.PerProject > 0 {
+			var running int64
+			if err := tx.Model(&agentModel.AgentTask{}).
+				Where("project_id = ? AND task_category = ? AND status IN ?", task.ProjectID, "agent", activeTaskStatuses).
+				Count(&running).Error; err != nil {
+				return err
+			}
+			if running >= int64(limits.PerProject) {
+				return ErrProjectConcurrencyLimit
+			}
+		}
+
+		result := tx.Model(&agentModel.AgentTask{}).
+			Where("task_id = ? AND status = ?", task.TaskID, "pending").
+			Updates(map[string]interface{}{
+				"status":     "running",
+				"agent_id":   agentID,
+				"started_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("task %s not found or not in pending status", task.TaskID)
+		}
+		return nil
+	})
+}
+
+// CountTasksByProjectStatus 按项目和状态分组统计指定分类的任务数 (一次 GROUP BY 查询)
+func (r *taskRepository) CountTasksByProjectStatus(ctx context.Context, category string, statuses []string) ([]ProjectTaskCount, error) {
+	var counts []ProjectTaskCount
+	err := r.db.WithContext(ctx).Model(&agentModel.AgentTask{}).
+		Select("project_id, status, COUNT(*) AS count").
+		Where("task_category = ? AND status IN ?", category, statuses).
+		Group("project_id, status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// TransitionTaskStatus 条件更新任务状态
+// 使用 WHERE status = from 防止 Agent 上报与取消操作并发时相互覆盖；
+// 流转到本次执行已结束的状态 (completed/failed/canceled) 时同时记录结果、错误信息与完成时间
+func (r *taskRepository) TransitionTaskStatus(ctx context.Context, taskID string, from string, to string, result string, errorMsg string) (bool, error) {
+	updates := map[string]interface{}{
+		"status": to,
+	}
+	if finishedTaskStatuses[to] {
+		// 扫描结果经结果批次通道上报，Agent 上报终态时通常不再携带结果，保留原值(output_result 为 JSON 列，不能写入空串)
+		if result != "" {
+			updates["output_result"] = result
+		}
+		updates["error_msg"] = errorMsg
+		updates["finished_at"] = time.Now()
+	}
+	res := r.db.WithContext(ctx).Model(&agentModel.AgentTask{}).
+		Where("task_id = ? AND status = ?", taskID, from).
+		Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// CancelProjectTasks 取消项目下所有未结束的任务
+// 1. 尚未下发的 pending 任务与等待重试的 failed 任务直接置为 canceled
+// 2. 已下发的 assigned/running 任务置为 canceling，等待 Agent 中止执行后确认
+func (r *taskRepository) CancelProjectTasks(ctx context.Context, projectID uint64) (canceled int64, canceling int64, err error) {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&agentModel.AgentTask{}).
+			Where("project_id = ? AND status IN ?", projectID, []string{"pending", "failed"}).
+			Updates(map[string]interface{}{
+				"status":      "canceled",
+				"finished_at": time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		canceled = res.RowsAffected
+
+		res = tx.Model(&agentModel.AgentTask{}).
+			Where("project_id = ? AND status IN ?", projectID, []string{"assigned", "running"}).
+			Update("status", "canceling")
+		if res.Error != nil {
+			return res.Error
+		}
+		canceling = res.RowsAffected
+		return nil
+	})
+	return canceled, canceling, err
+}
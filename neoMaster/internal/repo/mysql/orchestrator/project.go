@@ -3,6 +3,8 @@ package orchestrator
 import (
 	"context"
 	"errors"
+	"time"
+
 	orcmodel "neomaster/internal/model/orchestrator"
 	"neomaster/internal/pkg/logger"
 
@@ -65,10 +67,17 @@ func (r *ProjectRepository) GetRunningProjects(ctx context.Context) ([]*orcmodel
 	return projects, nil
 }
 
-// GetScheduledProjects 获取所有配置了Cron调度的空闲项目
+// GetScheduledProjects 获取所有配置了Cron调度且可以再次触发的项目
+// 运行中和已暂停的项目不参与定时触发，已完成/失败/取消的项目在下一个周期继续执行
 func (r *ProjectRepository) GetScheduledProjects(ctx context.Context) ([]*orcmodel.Project, error) {
 	var projects []*orcmodel.Project
-	err := r.db.WithContext(ctx).Where("status = ? AND enabled = ? AND schedule_type = ?", "idle", true, "cron").Find(&projects).Error
+	statuses := []string{
+		orcmodel.ProjectStatusIdle,
+		orcmodel.ProjectStatusFinished,
+		orcmodel.ProjectStatusError,
+		orcmodel.ProjectStatusCanceled,
+	}
+	err := r.db.WithContext(ctx).Where("status IN ? AND enabled = ? AND schedule_type = ?", statuses, true, orcmodel.ProjectScheduleCron).Find(&projects).Error
 	if err != nil {
 		logger.LogError(err, "", 0, "", "get_scheduled_projects", "REPO", map[string]interface{}{
 			"operation": "get_scheduled_projects",
@@ -112,6 +121,81 @@ func (r *ProjectRepository) UpdateProject(ctx context.Context, project *orcmodel
 	return nil
 }
 
+// UpdateProjectStatus 条件更新项目状态 (CAS)
+// 仅当当前状态等于 expected 时才更新为 target，返回是否更新成功
+// 用于防止并发状态流转相互覆盖
+func (r *ProjectRepository) UpdateProjectStatus(ctx context.Context, id uint64, expected, target string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&orcmodel.Project{}).
+		Where("id = ? AND status = ?", id, expected).
+		Update("status", target)
+	if result.Error != nil {
+		logger.LogError(result.Error, "", 0, "", "update_project_status", "REPO", map[string]interface{}{
+			"operation": "update_project_status",
+			"id":        id,
+			"expected":  expected,
+			"target":    target,
+		})
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// StartProjectRun 开始新一轮执行 (CAS)
+// 仅当当前状态等于 expected 时才置为 running，同时递增执行轮次并记录执行时间
+func (r *ProjectRepository) StartProjectRun(ctx context.Context, id uint64, expected string, execTime time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&orcmodel.Project{}).
+		Where("id = ? AND status = ?", id, expected).
+		Updates(map[string]interface{}{
+			"status":         orcmodel.ProjectStatusRunning,
+			"last_exec_time": execTime,
+			"run_seq":        gorm.Expr("run_seq + 1"),
+		})
+	if result.Error != nil {
+		logger.LogError(result.Error, "", 0, "", "start_project_run", "REPO", map[string]interface{}{
+			"operation": "start_project_run",
+			"id":        id,
+			"expected":  expected,
+		})
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateProjectNextRunAt 更新项目下一次定时执行时间，nextRunAt 为 nil 时清空
+func (r *ProjectRepository) UpdateProjectNextRunAt(ctx context.Context, id uint64, nextRunAt *time.Time) error {
+	err := r.db.WithContext(ctx).Model(&orcmodel.Project{}).Where("id = ?", id).Update("next_run_at", nextRunAt).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "update_project_next_run_at", "REPO", map[string]interface{}{
+			"operation": "update_project_next_run_at",
+			"id":        id,
+		})
+		return err
+	}
+	return nil
+}
+
+// MarkScheduledRun 定时触发项目 (CAS)
+// 仅当当前状态等于 expected 时才置为 running，并同时记录本次执行时间和下一次执行时间
+func (r *ProjectRepository) MarkScheduledRun(ctx context.Context, id uint64, expected string, execTime time.Time, nextRunAt *time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&orcmodel.Project{}).
+		Where("id = ? AND status = ?", id, expected).
+		Updates(map[string]interface{}{
+			"status":         orcmodel.ProjectStatusRunning,
+			"last_exec_time": execTime,
+			"next_run_at":    nextRunAt,
+			"run_seq":        gorm.Expr("run_seq + 1"),
+		})
+	if result.Error != nil {
+		logger.LogError(result.Error, "", 0, "", "mark_scheduled_run", "REPO", map[string]interface{}{
+			"operation": "mark_scheduled_run",
+			"id":        id,
+			"expected":  expected,
+		})
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // DeleteProject 删除项目 (软删除)
 func (r *ProjectRepository) DeleteProject(ctx context.Context, id uint64) error {
 	err := r.db.WithContext(ctx).Delete(&orcmodel.Project{}, id).Error
@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	assetModel "neomaster/internal/model/asset"
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+)
+
+// ErrDuplicateResultBatch 批次已处理过 (并发重放时由唯一索引判定)
+var ErrDuplicateResultBatch = errors.New("result batch already processed")
+
+// resultBatchInsertSize 结果批量插入的分批大小
+const resultBatchInsertSize = 100
+
+// ResultBatchRepository Agent 结果批次仓库
+type ResultBatchRepository struct {
+	db *gorm.DB
+}
+
+// NewResultBatchRepository 创建 ResultBatchRepository 实例
+func NewResultBatchRepository(db *gorm.DB) *ResultBatchRepository {
+	return &ResultBatchRepository{db: db}
+}
+
+// GetBatch 根据批次ID获取处理记录，不存在时返回 nil
+func (r *ResultBatchRepository) GetBatch(ctx context.Context, batchID string) (*orcmodel.ResultBatch, error) {
+	var batch orcmodel.ResultBatch
+	err := r.db.WithContext(ctx).Where("batch_id = ?", batchID).First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.LogError(err, "", 0, "", "get_result_batch", "REPO", map[string]interface{}{
+			"operation": "get_result_batch",
+			"batch_id":  batchID,
+		})
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// SaveBatch 在一个事务内写入批次记录、结果与隔离条目
+// 批次记录先插入 (冲突时不写入)，未插入说明其他请求已处理过同一批次，返回 ErrDuplicateResultBatch 并回滚
+func (r *ResultBatchRepository) SaveBatch(ctx context.Context, batch *orcmodel.ResultBatch, results []*orcmodel.StageResult, quarantined []*assetModel.AssetETLError) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(batch)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrDuplicateResultBatch
+		}
+		if len(results) > 0 {
+			if err := tx.CreateInBatches(results, resultBatchInsertSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(quarantined) > 0 {
+			if err := tx.CreateInBatches(quarantined, resultBatchInsertSize).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrDuplicateResultBatch) {
+		logger.LogError(err, "", 0, "", "save_result_batch", "REPO", map[string]interface{}{
+			"operation": "save_result_batch",
+			"batch_id":  batch.BatchID,
+			"agent_id":  batch.AgentID,
+			"results":   len(results),
+		})
+	}
+	return err
+}
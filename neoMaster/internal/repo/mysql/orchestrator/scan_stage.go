@@ -142,3 +142,21 @@ func (r *ScanStageRepository) ListStagesByWorkflowIDAndStageIDs(ctx context.Cont
 	}
 	return stages, nil
 }
+
+// ListStagesByProjectID 获取项目关联的所有工作流下的阶段 (一次 Join 查询)
+func (r *ScanStageRepository) ListStagesByProjectID(ctx context.Context, projectID uint64) ([]*orcmodel.ScanStage, error) {
+	var stages []*orcmodel.ScanStage
+	err := r.db.WithContext(ctx).
+		Joins("JOIN project_workflows ON scan_stages.workflow_id = project_workflows.workflow_id").
+		Where("project_workflows.project_id = ?", projectID).
+		Order("project_workflows.sort_order ASC, scan_stages.id ASC").
+		Find(&stages).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "list_stages_by_project_id", "REPO", map[string]interface{}{
+			"operation":  "list_stages_by_project_id",
+			"project_id": projectID,
+		})
+		return nil, err
+	}
+	return stages, nil
+}
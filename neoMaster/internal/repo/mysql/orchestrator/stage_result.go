@@ -9,6 +9,12 @@ import (
 	"time"
 )
 
+// StageResultCount 按阶段统计的结果条数
+type StageResultCount struct {
+	StageID uint64
+	Count   int64
+}
+
 // StageResultRepository 阶段结果仓库
 type StageResultRepository struct {
 	db *gorm.DB
@@ -102,3 +108,157 @@ func (r *StageResultRepository) DeleteOldResults(ctx context.Context, beforeTime
 	}
 	return nil
 }
+
+// projectResultsQuery 项目结果查询
+// 通过 project_workflows 关联，仅统计项目当前关联的工作流产生的结果
+func (r *StageResultRepository) projectResultsQuery(ctx context.Context, projectID uint64) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&orcmodel.StageResult{}).
+		Joins("JOIN project_workflows ON stage_results.workflow_id = project_workflows.workflow_id AND project_workflows.project_id = stage_results.project_id").
+		Where("stage_results.project_id = ?", projectID)
+}
+
+// CountResultsByStage 按阶段分组统计项目的结果条数
+func (r *StageResultRepository) CountResultsByStage(ctx context.Context, projectID uint64) ([]StageResultCount, error) {
+	var counts []StageResultCount
+	err := r.projectResultsQuery(ctx, projectID).
+		Select("stage_results.stage_id AS stage_id, COUNT(*) AS count").
+		Group("stage_results.stage_id").
+		Scan(&counts).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "count_stage_results_by_stage", "REPO", map[string]interface{}{
+			"operation":  "count_stage_results_by_stage",
+			"project_id": projectID,
+		})
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ListResultAttributesByProject 获取项目指定类型结果的结构化属性
+// 只查询聚合所需的列，避免加载 Evidence 等大字段
+func (r *StageResultRepository) ListResultAttributesByProject(ctx context.Context, projectID uint64, resultTypes []string) ([]*orcmodel.StageResult, error) {
+	var results []*orcmodel.StageResult
+	if len(resultTypes) == 0 {
+		return results, nil
+	}
+	err := r.projectResultsQuery(ctx, projectID).
+		Select("stage_results.id, stage_results.stage_id, stage_results.result_type, stage_results.attributes").
+		Where("stage_results.result_type IN ?", resultTypes).
+		Find(&results).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "list_result_attributes_by_project", "REPO", map[string]interface{}{
+			"operation":    "list_result_attributes_by_project",
+			"project_id":   projectID,
+			"result_types": resultTypes,
+		})
+		return nil, err
+	}
+	return results, nil
+}
+
+// StageResultChange 同一发现在两轮执行中的结果
+type StageResultChange struct {
+	Before *orcmodel.StageResult
+	After  *orcmodel.StageResult
+}
+
+// diffResultColumns 差异比对需要的列，不加载 Evidence 等大字段
+var diffResultColumns = []string{"id", "result_type", "target_type", "target_value", "port", "proto", "attributes"}
+
+// latestRunFindings 子查询: 一轮执行中每个发现的最新一条结果ID
+// 同一发现可能由多个任务(重试/多工具)重复产生，比对时只取最新一条
+func (r *StageResultRepository) latestRunFindings(projectID uint64, runID int64) *gorm.DB {
+	return r.db.Model(&orcmodel.StageResult{}).
+		Select("MAX(id)").
+		Where("project_id = ? AND run_id = ? AND finding_key <> ''", projectID, runID).
+		Group("finding_key")
+}
+
+// ListFindingsOnlyInRun 获取 runID 中存在、otherRunID 中不存在的发现 (集合差，在数据库中完成)
+// 返回最多 limit 条结果与完整计数
+func (r *StageResultRepository) ListFindingsOnlyInRun(ctx context.Context, projectID uint64, runID, otherRunID int64, limit int) ([]*orcmodel.StageResult, int64, error) {
+	var results []*orcmodel.StageResult
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&orcmodel.StageResult{}).
+		Where("id IN (?)", r.latestRunFindings(projectID, runID)).
+		Where("NOT EXISTS (?)", r.db.Table("stage_results AS other").Select("1").
+			Where("other.project_id = ? AND other.run_id = ? AND other.finding_key = stage_results.finding_key", projectID, otherRunID))
+
+	logFields := map[string]interface{}{
+		"operation":    "list_findings_only_in_run",
+		"project_id":   projectID,
+		"run_id":       runID,
+		"other_run_id": otherRunID,
+	}
+	if err := query.Count(&total).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_findings_only_in_run", "REPO", logFields)
+		return nil, 0, err
+	}
+	if total == 0 {
+		return results, 0, nil
+	}
+	if err := query.Select(diffResultColumns).Order("id asc").Limit(limit).Find(&results).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_findings_only_in_run", "REPO", logFields)
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// ListChangedFindings 获取两轮执行都存在但结构化属性不同的发现 (按 content_hash 比较，在数据库中完成)
+// 返回最多 limit 对结果与完整计数
+func (r *StageResultRepository) ListChangedFindings(ctx context.Context, projectID uint64, runA, runB int64, limit int) ([]*StageResultChange, int64, error) {
+	var total int64
+	var pairs []struct {
+		BeforeID uint64
+		AfterID  uint64
+	}
+
+	query := r.db.WithContext(ctx).Table("stage_results AS b").
+		Joins("JOIN stage_results AS a ON a.finding_key = b.finding_key").
+		Where("b.id IN (?)", r.latestRunFindings(projectID, runB)).
+		Where("a.id IN (?)", r.latestRunFindings(projectID, runA)).
+		Where("a.content_hash <> b.content_hash")
+
+	logFields := map[string]interface{}{
+		"operation":  "list_changed_findings",
+		"project_id": projectID,
+		"run_a":      runA,
+		"run_b":      runB,
+	}
+	if err := query.Count(&total).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_changed_findings", "REPO", logFields)
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []*StageResultChange{}, 0, nil
+	}
+	if err := query.Select("a.id AS before_id, b.id AS after_id").Order("b.id asc").Limit(limit).Scan(&pairs).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_changed_findings", "REPO", logFields)
+		return nil, 0, err
+	}
+
+	ids := make([]uint64, 0, len(pairs)*2)
+	for _, p := range pairs {
+		ids = append(ids, p.BeforeID, p.AfterID)
+	}
+	var rows []*orcmodel.StageResult
+	if err := r.db.WithContext(ctx).Select(diffResultColumns).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_changed_findings", "REPO", logFields)
+		return nil, 0, err
+	}
+	byID := make(map[uint64]*orcmodel.StageResult, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	changes := make([]*StageResultChange, 0, len(pairs))
+	for _, p := range pairs {
+		before, after := byID[p.BeforeID], byID[p.AfterID]
+		if before == nil || after == nil {
+			continue
+		}
+		changes = append(changes, &StageResultChange{Before: before, After: after})
+	}
+	return changes, total, nil
+}
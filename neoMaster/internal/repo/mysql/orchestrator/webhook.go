@@ -0,0 +1,176 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRepository Webhook 订阅与投递记录仓库
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建 WebhookRepository 实例
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// -----------------------------------------------------------------------------
+// WebhookSubscription (订阅) CRUD
+// -----------------------------------------------------------------------------
+
+// CreateSubscription 创建订阅
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *orcmodel.WebhookSubscription) error {
+	if sub == nil {
+		return errors.New("subscription is nil")
+	}
+	err := r.db.WithContext(ctx).Create(sub).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "create_webhook_subscription", "REPO", map[string]interface{}{
+			"operation": "create_webhook_subscription",
+			"name":      sub.Name,
+		})
+		return err
+	}
+	return nil
+}
+
+// GetSubscriptionByID 根据ID获取订阅
+func (r *WebhookRepository) GetSubscriptionByID(ctx context.Context, id uint64) (*orcmodel.WebhookSubscription, error) {
+	var sub orcmodel.WebhookSubscription
+	err := r.db.WithContext(ctx).First(&sub, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.LogError(err, "", 0, "", "get_webhook_subscription", "REPO", map[string]interface{}{
+			"operation": "get_webhook_subscription",
+			"id":        id,
+		})
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// UpdateSubscription 更新订阅
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, sub *orcmodel.WebhookSubscription) error {
+	if sub == nil || sub.ID == 0 {
+		return errors.New("invalid subscription or id")
+	}
+	err := r.db.WithContext(ctx).Save(sub).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "update_webhook_subscription", "REPO", map[string]interface{}{
+			"operation": "update_webhook_subscription",
+			"id":        sub.ID,
+		})
+		return err
+	}
+	return nil
+}
+
+// DeleteSubscription 删除订阅 (投递记录保留)
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id uint64) error {
+	err := r.db.WithContext(ctx).Delete(&orcmodel.WebhookSubscription{}, id).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "delete_webhook_subscription", "REPO", map[string]interface{}{
+			"operation": "delete_webhook_subscription",
+			"id":        id,
+		})
+		return err
+	}
+	return nil
+}
+
+// ListSubscriptions 获取订阅列表
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context, page, pageSize int) ([]*orcmodel.WebhookSubscription, int64, error) {
+	var subs []*orcmodel.WebhookSubscription
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&orcmodel.WebhookSubscription{})
+	if err := query.Count(&total).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_webhook_subscriptions_count", "REPO", map[string]interface{}{
+			"operation": "list_webhook_subscriptions_count",
+		})
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("id desc").Find(&subs).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_webhook_subscriptions_find", "REPO", map[string]interface{}{
+			"operation": "list_webhook_subscriptions_find",
+		})
+		return nil, 0, err
+	}
+	return subs, total, nil
+}
+
+// ListEnabledSubscriptions 获取订阅了指定事件的已启用订阅
+// 订阅数量很少，事件类型是 JSON 数组，取出已启用订阅后在内存中过滤
+func (r *WebhookRepository) ListEnabledSubscriptions(ctx context.Context, eventType string) ([]*orcmodel.WebhookSubscription, error) {
+	var subs []*orcmodel.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("enabled = ?", true).Order("id asc").Find(&subs).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "list_enabled_webhook_subscriptions", "REPO", map[string]interface{}{
+			"operation":  "list_enabled_webhook_subscriptions",
+			"event_type": eventType,
+		})
+		return nil, err
+	}
+	matched := subs[:0]
+	for _, sub := range subs {
+		if sub.Subscribes(eventType) {
+			matched = append(matched, sub)
+		}
+	}
+	return matched, nil
+}
+
+// -----------------------------------------------------------------------------
+// WebhookDelivery (投递记录)
+// -----------------------------------------------------------------------------
+
+// CreateDelivery 记录一次投递尝试
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *orcmodel.WebhookDelivery) error {
+	if delivery == nil {
+		return errors.New("delivery is nil")
+	}
+	err := r.db.WithContext(ctx).Create(delivery).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "create_webhook_delivery", "REPO", map[string]interface{}{
+			"operation":       "create_webhook_delivery",
+			"subscription_id": delivery.SubscriptionID,
+			"event_id":        delivery.EventID,
+		})
+		return err
+	}
+	return nil
+}
+
+// ListDeliveries 获取订阅的投递记录 (最新的在前)
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uint64, page, pageSize int) ([]*orcmodel.WebhookDelivery, int64, error) {
+	var deliveries []*orcmodel.WebhookDelivery
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&orcmodel.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if err := query.Count(&total).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_webhook_deliveries_count", "REPO", map[string]interface{}{
+			"operation":       "list_webhook_deliveries_count",
+			"subscription_id": subscriptionID,
+		})
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("id desc").Find(&deliveries).Error; err != nil {
+		logger.LogError(err, "", 0, "", "list_webhook_deliveries_find", "REPO", map[string]interface{}{
+			"operation":       "list_webhook_deliveries_find",
+			"subscription_id": subscriptionID,
+		})
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,79 @@
+/**
+ * 仓库层:API 密钥数据访问
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: API 密钥数据访问，按密钥哈希查找，只保存哈希不保存明文
+ * @func:单纯数据访问,不应该包含业务逻辑
+ */
+package system
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"neomaster/internal/model/system"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository API 密钥仓库结构体
+type APIKeyRepository struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewAPIKeyRepository 创建 API 密钥仓库实例
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// Create 创建 API 密钥
+func (r *APIKeyRepository) Create(ctx context.Context, key *system.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByHash 根据密钥哈希获取 API 密钥，不存在时返回 nil, nil
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*system.APIKey, error) {
+	var key system.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser 获取用户的全部 API 密钥(含已吊销)，按创建时间倒序
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID uint) ([]*system.APIKey, error) {
+	var keys []*system.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke 吊销用户的 API 密钥，返回是否实际吊销(密钥不存在、不属于该用户或已吊销时为 false)
+func (r *APIKeyRepository) Revoke(ctx context.Context, id, userID uint, revokedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&system.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Updates(map[string]interface{}{
+			"revoked_at": revokedAt,
+			"updated_at": revokedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// TouchLastUsed 更新最后使用时间与IP
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time, clientIP string) error {
+	return r.db.WithContext(ctx).Model(&system.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"last_used_at": usedAt,
+			"last_used_ip": clientIP,
+		}).Error
+}
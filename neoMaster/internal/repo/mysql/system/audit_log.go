@@ -0,0 +1,135 @@
+/*
+ * 审计日志仓库层:审计日志数据访问
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 单纯数据访问,不应该包含业务逻辑
+ * @func:
+ * 1.批量写入审计日志
+ * 2.按条件分页查询审计日志
+ * 3.按条件分批读取审计日志(导出)
+ */
+
+//  基础操作:
+//  	CreateAuditLogs - 批量写入审计日志
+//  	QueryAuditLogs - 按用户/动作/资源前缀/时间范围分页查询
+//  	StreamAuditLogs - 按条件分批读取，供导出使用
+
+package system
+
+import (
+	"context"
+	"strings"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// auditLogInsertBatchSize 单条 INSERT 语句写入的最大记录数
+const auditLogInsertBatchSize = 100
+
+// AuditLogRepository 审计日志仓库结构体
+type AuditLogRepository struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewAuditLogRepository 创建审计日志仓库实例
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// CreateAuditLogs 批量写入审计日志
+func (r *AuditLogRepository) CreateAuditLogs(ctx context.Context, logs []*system.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(logs, auditLogInsertBatchSize).Error; err != nil {
+		// 不能再调用 LogAuditOperation，避免写入失败时递归产生审计日志
+		logger.LogError(err, "", 0, "", "repo.mysql.system.CreateAuditLogs", "gorm", map[string]interface{}{
+			"operation": "create_audit_logs",
+			"option":    "db.CreateInBatches(audit_logs)",
+			"func_name": "repo.mysql.system.CreateAuditLogs",
+			"count":     len(logs),
+		})
+		return err
+	}
+	return nil
+}
+
+// QueryAuditLogs 按条件分页查询审计日志，按操作时间倒序
+// 返回: 当前页记录、满足条件的总数
+func (r *AuditLogRepository) QueryAuditLogs(ctx context.Context, filter system.AuditFilter, offset, limit int) ([]*system.AuditLog, int64, error) {
+	query := applyAuditFilter(r.db.WithContext(ctx).Model(&system.AuditLog{}), filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.LogError(err, "", filter.UserID, "", "repo.mysql.system.QueryAuditLogs", "gorm", map[string]interface{}{
+			"operation": "query_audit_logs",
+			"option":    "db.Count(audit_logs)",
+			"func_name": "repo.mysql.system.QueryAuditLogs",
+		})
+		return nil, 0, err
+	}
+
+	var logs []*system.AuditLog
+	if err := query.Order("timestamp DESC, id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		logger.LogError(err, "", filter.UserID, "", "repo.mysql.system.QueryAuditLogs", "gorm", map[string]interface{}{
+			"operation": "query_audit_logs",
+			"option":    "db.Find(audit_logs)",
+			"func_name": "repo.mysql.system.QueryAuditLogs",
+			"offset":    offset,
+			"limit":     limit,
+		})
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// StreamAuditLogs 按条件分批读取审计日志(按ID升序)，每批交给 fn 处理，fn 返回错误时停止读取
+// 用于导出：内存中最多只保留一批记录
+func (r *AuditLogRepository) StreamAuditLogs(ctx context.Context, filter system.AuditFilter, batchSize int, fn func(batch []*system.AuditLog) error) error {
+	var batch []*system.AuditLog
+	err := applyAuditFilter(r.db.WithContext(ctx).Model(&system.AuditLog{}), filter).
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+	if err != nil {
+		logger.LogError(err, "", filter.UserID, "", "repo.mysql.system.StreamAuditLogs", "gorm", map[string]interface{}{
+			"operation":  "stream_audit_logs",
+			"option":     "db.FindInBatches(audit_logs)",
+			"func_name":  "repo.mysql.system.StreamAuditLogs",
+			"batch_size": batchSize,
+		})
+		return err
+	}
+	return nil
+}
+
+// applyAuditFilter 追加审计日志查询条件，零值字段不参与过滤
+func applyAuditFilter(query *gorm.DB, filter system.AuditFilter) *gorm.DB {
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ResourcePrefix != "" {
+		query = query.Where("resource LIKE ? ESCAPE '!'", escapeLikePattern(filter.ResourcePrefix)+"%")
+	}
+	if !filter.StartTime.IsZero() {
+		query = query.Where("timestamp >= ?", filter.StartTime)
+	}
+	if !filter.EndTime.IsZero() {
+		query = query.Where("timestamp < ?", filter.EndTime)
+	}
+	return query
+}
+
+// likePatternEscaper 转义 LIKE 通配符，资源前缀按字面量匹配
+// 使用 ! 作为转义符：MySQL 与 SQLite 对反斜杠的字面量处理不一致
+var likePatternEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+func escapeLikePattern(s string) string {
+	return likePatternEscaper.Replace(s)
+}
@@ -194,6 +194,13 @@ func (r *RoleRepository) RoleExistsByID(ctx context.Context, id uint) (bool, err
 	return count > 0, err
 }
 
+// CountChildRoles 统计以 roleID 为父角色的子角色数量
+func (r *RoleRepository) CountChildRoles(ctx context.Context, roleID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&system.Role{}).Where("parent_role_id = ?", roleID).Count(&count).Error
+	return count, err
+}
+
 // RolePermissionExists 根据权限ID检查权限是否存在(本应该是permission.go中的函数,写在这个里为了方便,主要用于判定角色的权限是否存在)
 func (r *RoleRepository) RolePermissionExists(ctx context.Context, id uint) (bool, error) {
 	var count int64
@@ -13,6 +13,7 @@ import (
 	"neomaster/internal/model/system"
 	"time"
 
+	"neomaster/internal/pkg/database"
 	"neomaster/internal/pkg/logger"
 
 	"gorm.io/gorm"
@@ -140,10 +141,16 @@ func (r *UserRepository) UpdateUser(ctx context.Context, user *system.User) erro
 
 // UpdateUserFields 使用 map 更新用户特定字段
 // 主要用于原子更新操作，如密码和版本号同时更新
+// 每次更新同时递增乐观锁版本号，使之前读取的版本失效
 func (r *UserRepository) UpdateUserFields(ctx context.Context, userID uint, fields map[string]interface{}) error {
+	updates := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		updates[k] = v
+	}
+	updates["lock_version"] = gorm.Expr("lock_version + 1")
 	return r.db.WithContext(ctx).Model(&system.User{}).
 		Where("id = ?", userID).
-		Updates(fields).Error
+		Updates(updates).Error
 }
 
 // UpdateLastLogin 更新用户最后登录时间
@@ -156,6 +163,22 @@ func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID uint, userI
 	}).Error
 }
 
+// ReplacePasswordHash 仅当当前哈希仍为 oldHash 时替换为 newHash
+// 用于同一密码按新参数重新哈希，不递增密码版本号；返回是否实际更新(期间密码已被修改时为 false)
+// 用户资料未变，同样不递增乐观锁版本号，避免登录时的重新哈希让管理员正在进行的编辑误报冲突
+func (r *UserRepository) ReplacePasswordHash(ctx context.Context, userID uint, oldHash, newHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&system.User{}).
+		Where("id = ? AND password = ?", userID, oldHash).
+		Updates(map[string]interface{}{
+			"password":   newHash,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // UpdatePasswordVersion 更新用户密码版本号
 func (r *UserRepository) UpdatePasswordVersion(ctx context.Context, userID uint, passwordV int64) error {
 	return r.db.WithContext(ctx).Model(&system.User{}).Where("id = ?", userID).Update("password_v", passwordV).Error
@@ -222,9 +245,41 @@ func (r *UserRepository) GetUserPermissions(ctx context.Context, userID uint) ([
 	}
 
 	permissionMap := make(map[uint]*system.Permission)
+	visited := make(map[uint]bool, len(user.Roles))
+	var parentIDs []uint
+	collect := func(role *system.Role) {
+		for i := range role.Permissions {
+			permissionMap[role.Permissions[i].ID] = &role.Permissions[i]
+		}
+		if role.ParentRoleID != nil {
+			parentIDs = append(parentIDs, *role.ParentRoleID)
+		}
+	}
 	for _, role := range user.Roles {
-		for _, permission := range role.Permissions {
-			permissionMap[permission.ID] = &permission
+		visited[role.ID] = true
+		collect(role)
+	}
+
+	// 沿父角色链逐层向上继承权限，每层一次查询；已访问过的角色不再加载，父角色链成环时也能终止
+	for len(parentIDs) > 0 {
+		pending := make([]uint, 0, len(parentIDs))
+		for _, id := range parentIDs {
+			if !visited[id] {
+				visited[id] = true
+				pending = append(pending, id)
+			}
+		}
+		parentIDs = nil
+		if len(pending) == 0 {
+			break
+		}
+
+		var parents []system.Role
+		if err := r.db.WithContext(ctx).Preload("Permissions").Where("id IN ?", pending).Find(&parents).Error; err != nil {
+			return nil, err
+		}
+		for i := range parents {
+			collect(&parents[i])
 		}
 	}
 
@@ -304,6 +359,11 @@ func (r *UserRepository) BeginTx(ctx context.Context) *gorm.DB {
 	return r.db.WithContext(ctx).Begin()
 }
 
+// WithTransaction 在事务中执行 fn，出错或 panic 时自动回滚
+func (r *UserRepository) WithTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return database.WithTransaction(ctx, r.db, fn)
+}
+
 // DeleteUserRolesByUserID 删除用户的所有角色关联（事务版本）
 func (r *UserRepository) DeleteUserRolesByUserID(ctx context.Context, tx *gorm.DB, userID uint) error {
 	result := tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&system.UserRole{})
@@ -341,10 +401,19 @@ func (r *UserRepository) DeleteUserWithTx(ctx context.Context, tx *gorm.DB, user
 // 1.保存用户信息到users表中
 // 2.根据用户的模型user.Roles字段中的对象ID，自动维护user_roles关联表，即自动在user_roles表中插入或更新对应的记录
 // 所以开发者只需要操作user对象即可，不需要单独操作关联表动作，GORM会自动处理关联表的操作
+// 乐观锁: 仅当数据库中的 lock_version 仍等于 user.LockVersion(读取时的版本)时更新，并将版本号加一；
+// 版本不一致(已被其他请求修改)时返回 system.ErrConcurrentModification，user.LockVersion 保持不变
 func (r *UserRepository) UpdateUserWithTx(ctx context.Context, tx *gorm.DB, user *system.User) error {
+	readVersion := user.LockVersion
+	user.LockVersion = readVersion + 1
 	user.UpdatedAt = time.Now()
-	err := tx.WithContext(ctx).Save(user).Error
+	result := tx.WithContext(ctx).Model(user).Where("lock_version = ?", readVersion).Select("*").Omit("created_at").Updates(user)
+	err := result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = system.ErrConcurrentModification
+	}
 	if err != nil {
+		user.LockVersion = readVersion
 		// 记录更新失败日志
 		logger.LogError(err, "", uint(user.ID), "", "user_update_with_tx", "PUT", map[string]interface{}{
 			"operation": "update_user_with_transaction",
@@ -0,0 +1,87 @@
+/**
+ * 仓库层:幂等记录
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 保存 Idempotency-Key 对应的请求记录(处理中占位/首次响应)，多实例部署时共享
+ * @func:单纯数据访问,记录格式与有效期由中间件层决定
+ */
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyRepository Redis幂等记录存储库
+type IdempotencyRepository struct {
+	client *redis.Client
+}
+
+// NewIdempotencyRepository 创建幂等记录存储库实例
+func NewIdempotencyRepository(client *redis.Client) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		client: client,
+	}
+}
+
+// Reserve 键不存在时写入记录并返回 true，已存在时返回 false
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key string, data []byte, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, r.getIdempotencyKey(key), data, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	return ok, nil
+}
+
+// Get 获取记录，键不存在时返回 nil
+func (r *IdempotencyRepository) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.client.Get(ctx, r.getIdempotencyKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	return data, nil
+}
+
+// replaceScript 记录仍为 ARGV[1] 时写入 ARGV[2] 并设置有效期 ARGV[3](毫秒)
+var replaceScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0`)
+
+// releaseScript 记录仍为 ARGV[1] 时删除
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Replace 仅当记录仍为 expected 时覆盖写入并重置有效期，返回是否写入
+func (r *IdempotencyRepository) Replace(ctx context.Context, key string, expected []byte, data []byte, ttl time.Duration) (bool, error) {
+	n, err := replaceScript.Run(ctx, r.client, []string{r.getIdempotencyKey(key)}, expected, data, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Release 仅当记录仍为 expected 时删除，返回是否删除
+func (r *IdempotencyRepository) Release(ctx context.Context, key string, expected []byte) (bool, error) {
+	n, err := releaseScript.Run(ctx, r.client, []string{r.getIdempotencyKey(key)}, expected).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to release idempotency record: %w", err)
+	}
+	return n == 1, nil
+}
+
+// getIdempotencyKey 生成幂等记录键
+func (r *IdempotencyRepository) getIdempotencyKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
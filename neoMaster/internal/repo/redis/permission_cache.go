@@ -0,0 +1,104 @@
+/**
+ * 仓库层:用户权限集缓存
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 缓存用户解析后的权限集合，缓存键带版本后缀；
+ *               用户角色/状态变化递增用户版本，角色或权限定义变化递增全局版本，旧版本的缓存自然失效
+ * @func:单纯数据访问,何时失效由服务层决定
+ */
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"neomaster/internal/model/system"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// permissionGlobalVersionKey 全局权限版本键(角色/权限定义变化时递增)
+const permissionGlobalVersionKey = "perm:version:global"
+
+// PermissionCacheRepository Redis用户权限集缓存存储库
+type PermissionCacheRepository struct {
+	client *redis.Client
+}
+
+// NewPermissionCacheRepository 创建用户权限集缓存存储库实例
+func NewPermissionCacheRepository(client *redis.Client) *PermissionCacheRepository {
+	return &PermissionCacheRepository{
+		client: client,
+	}
+}
+
+// GetPermissionVersion 获取用户当前的权限版本(全局版本.用户版本)，版本键不存在时按 0 处理
+func (r *PermissionCacheRepository) GetPermissionVersion(ctx context.Context, userID uint) (string, error) {
+	values, err := r.client.MGet(ctx, permissionGlobalVersionKey, r.getUserVersionKey(userID)).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to get permission version: %w", err)
+	}
+	versions := [2]string{"0", "0"}
+	for i, v := range values {
+		if s, ok := v.(string); ok && s != "" {
+			versions[i] = s
+		}
+	}
+	return versions[0] + "." + versions[1], nil
+}
+
+// GetUserPermissions 获取指定版本的用户权限集，未命中时返回 (nil, false, nil)
+func (r *PermissionCacheRepository) GetUserPermissions(ctx context.Context, userID uint, version string) ([]*system.Permission, bool, error) {
+	data, err := r.client.Get(ctx, r.getPermissionKey(userID, version)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get cached permissions: %w", err)
+	}
+
+	var permissions []*system.Permission
+	if err := json.Unmarshal(data, &permissions); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal cached permissions: %w", err)
+	}
+	return permissions, true, nil
+}
+
+// SetUserPermissions 按版本缓存用户权限集
+func (r *PermissionCacheRepository) SetUserPermissions(ctx context.Context, userID uint, version string, permissions []*system.Permission, expiration time.Duration) error {
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal permissions: %w", err)
+	}
+	if err := r.client.Set(ctx, r.getPermissionKey(userID, version), data, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to cache permissions: %w", err)
+	}
+	return nil
+}
+
+// BumpUserVersion 递增用户权限版本，使该用户已缓存的权限集失效
+func (r *PermissionCacheRepository) BumpUserVersion(ctx context.Context, userID uint) error {
+	if err := r.client.Incr(ctx, r.getUserVersionKey(userID)).Err(); err != nil {
+		return fmt.Errorf("failed to bump user permission version: %w", err)
+	}
+	return nil
+}
+
+// BumpGlobalVersion 递增全局权限版本，使所有用户已缓存的权限集失效
+func (r *PermissionCacheRepository) BumpGlobalVersion(ctx context.Context) error {
+	if err := r.client.Incr(ctx, permissionGlobalVersionKey).Err(); err != nil {
+		return fmt.Errorf("failed to bump global permission version: %w", err)
+	}
+	return nil
+}
+
+// getUserVersionKey 生成用户权限版本键
+func (r *PermissionCacheRepository) getUserVersionKey(userID uint) string {
+	return fmt.Sprintf("perm:version:user:%d", userID)
+}
+
+// getPermissionKey 生成用户权限集缓存键
+func (r *PermissionCacheRepository) getPermissionKey(userID uint, version string) string {
+	return fmt.Sprintf("perm:user:%d:v%s", userID, version)
+}
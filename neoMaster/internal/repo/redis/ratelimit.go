@@ -0,0 +1,69 @@
+/**
+ * 仓库层:限流计数
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 基于 Redis 有序集合的滑动窗口限流计数，多实例部署时共享限流状态
+ * @func:单纯数据访问,限流规则由中间件层决定
+ */
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript 滑动窗口限流脚本(原子执行)
+// KEYS[1] 限流键; ARGV: 当前时间(ms), 窗口(ms), 窗口内最大请求数, 本次请求成员
+// 返回 {是否放行, 需等待的毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+if redis.call('ZCARD', key) < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	return {1, 0}
+end
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, tonumber(oldest[2]) + window - now}
+`)
+
+// RateLimitRepository Redis限流计数存储库
+type RateLimitRepository struct {
+	client *redis.Client
+	seq    uint64 // 同一毫秒内的请求成员去重
+}
+
+// NewRateLimitRepository 创建限流计数存储库实例
+func NewRateLimitRepository(client *redis.Client) *RateLimitRepository {
+	return &RateLimitRepository{
+		client: client,
+	}
+}
+
+// SlidingWindowAllow 在滑动窗口内记录一次请求
+// 窗口内请求数未达到 limit 时放行；否则返回最早一次请求移出窗口前需要等待的时间
+func (r *RateLimitRepository) SlidingWindowAllow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, time.Duration, error) {
+	member := strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.FormatUint(atomic.AddUint64(&r.seq, 1), 10)
+	res, err := slidingWindowScript.Run(ctx, r.client, []string{r.getRateLimitKey(key)},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// getRateLimitKey 生成限流键
+func (r *RateLimitRepository) getRateLimitKey(key string) string {
+	return fmt.Sprintf("ratelimit:%s", key)
+}
@@ -0,0 +1,43 @@
+/**
+ * 仓库层:定时调度锁
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 基于 Redis SET NX 的定时触发锁，多个 Master 实例部署时避免同一次定时任务重复触发
+ * @func:单纯数据访问,锁键与有效期由调度器决定
+ */
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ScheduleLockRepository Redis定时调度锁存储库
+type ScheduleLockRepository struct {
+	client *redis.Client
+}
+
+// NewScheduleLockRepository 创建定时调度锁存储库实例
+func NewScheduleLockRepository(client *redis.Client) *ScheduleLockRepository {
+	return &ScheduleLockRepository{
+		client: client,
+	}
+}
+
+// TryLock 尝试获取锁，键已存在时返回 false
+// 锁不主动释放，到期自动过期，保证有效期内同一个键只会被获取一次
+func (r *ScheduleLockRepository) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, r.getScheduleLockKey(key), time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire schedule lock: %w", err)
+	}
+	return ok, nil
+}
+
+// getScheduleLockKey 生成定时调度锁键
+func (r *ScheduleLockRepository) getScheduleLockKey(key string) string {
+	return fmt.Sprintf("lock:schedule:%s", key)
+}
@@ -0,0 +1,42 @@
+/**
+ * 仓库层:TOTP 已用动态码
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 记录用户已使用过的 TOTP 时间步，防止同一动态码在有效期内被重放
+ * @func:单纯数据访问,有效期由服务层决定
+ */
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TOTPRepository Redis TOTP 防重放存储库
+type TOTPRepository struct {
+	client *redis.Client
+}
+
+// NewTOTPRepository 创建 TOTP 防重放存储库实例
+func NewTOTPRepository(client *redis.Client) *TOTPRepository {
+	return &TOTPRepository{
+		client: client,
+	}
+}
+
+// MarkStepUsed 标记用户的时间步已使用，首次标记返回 true，已使用过返回 false
+func (r *TOTPRepository) MarkStepUsed(ctx context.Context, userID uint, step uint64, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, r.getUsedStepKey(userID, step), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark totp step used: %w", err)
+	}
+	return ok, nil
+}
+
+// getUsedStepKey 生成已用时间步键
+func (r *TOTPRepository) getUsedStepKey(userID uint, step uint64) string {
+	return fmt.Sprintf("totp:used:%d:%d", userID, step)
+}
@@ -3,72 +3,406 @@
  * @author: Sun977
  * @date: 2025.10.14
  * @description: Agent配置核心业务逻辑，遵循"好品味"原则 - 专注配置管理
- * @func: Agent配置获取、更新、推送
+ * @func: Agent配置获取、更新、推送、版本对账
+ * 配置下发流程：
+ * 1. 管理员修改配置 -> 校验通过后保存，配置版本号 +1
+ * 2. Agent 心跳携带当前已应用的配置版本 -> 与 Master 端版本不一致时心跳响应携带最新配置
+ * 3. Agent 校验后整体替换本地配置，下次心跳上报新版本，对账完成
+ * 分组批量配置 (分组即 Category 为 agent_group 的标签)：
+ * - 分组配置是局部补丁，只覆盖补丁中设置的字段，在一个事务内应用到全部成员并各自递增版本号
+ * - 补丁累计保存在分组上，之后加入分组的Agent在加入时继承
+ * 优先级：按字段"后写入者生效"，Agent最终配置是每个字段最近一次写入的值
+ * - 分组批量更新会覆盖成员Agent在补丁字段上的单独配置 (补丁未设置的字段保留单独配置)
+ * - 之后对单个Agent的更新 (UpdateAgentConfig) 覆盖该Agent的分组值，直到分组再次更新该字段
+ * - Agent加入分组时继承分组补丁；同时加入多个分组时按分组标签ID升序依次应用，ID大的分组生效
+ * - Agent退出分组时撤销该分组写入的值，相关字段回退到其他来源最近写入的值，没有其他来源时回退到默认值
+ * 各来源 (default/group/agent) 写入的值随每次写入记录在配置上，生效配置导出 (GetEffectiveConfig) 与
+ * Agent 拉取配置使用同一个 loadAgentConfig，二者不会出现偏差
  */
 package agent
 
 import (
+	"context"
 	"fmt"
 	agentRepository "neomaster/internal/repo/mysql/agent"
+	"sort"
+	"strconv"
 
 	agentModel "neomaster/internal/model/agent"
 	"neomaster/internal/pkg/logger"
+	"neomaster/internal/service/tag_system"
 )
 
+// agentGroupTagCategory Agent分组标签的分类
+const agentGroupTagCategory = "agent_group"
+
 // AgentConfigService Agent配置服务接口
 // 专门负责Agent的配置相关功能，遵循单一职责原则
 type AgentConfigService interface {
 	// Agent配置管理
 	GetAgentConfig(agentID string) (*agentModel.AgentConfigResponse, error)
-	UpdateAgentConfig(agentID string, config *agentModel.AgentConfigUpdateRequest) error
-	PushConfigToAgent(agentID string, config *agentModel.AgentConfigUpdateRequest) error // 推送配置到Agent
+	UpdateAgentConfig(agentID string, config *agentModel.AgentConfigUpdateRequest) (*agentModel.AgentConfigResponse, error) // 校验并保存配置，版本号 +1
+	PushConfigToAgent(agentID string, config *agentModel.AgentConfigUpdateRequest) error                                    // 推送配置到Agent
+	ReconcileConfig(agentID string, reportedVersion int) (*agentModel.AgentConfigResponse, error)                           // 配置对账：版本不一致时返回最新配置，一致时返回 nil
+	GetEffectiveConfig(agentID string) (*agentModel.AgentEffectiveConfigResponse, error)                                    // 导出生效配置及每个配置项的来源
+
+	// 分组配置管理
+	GetGroupConfig(groupID string) (*agentModel.AgentGroupConfig, error)                          // 获取分组配置补丁，未下发过时返回 nil
+	UpdateGroupConfig(groupID string, patch agentModel.AgentConfigPatch) (updated int, err error) // 将配置补丁批量应用到分组全部成员
+	InheritGroupConfig(agentID string, groupTagIDs []uint64) error                                // Agent加入分组时继承分组配置
+	RevertGroupConfig(agentID string, groupTagIDs []uint64) error                                 // Agent退出分组时撤销分组写入的配置值
 }
 
 // agentConfigService Agent配置服务实现
 type agentConfigService struct {
-	agentRepo agentRepository.AgentRepository // Agent数据访问层
+	agentRepo  agentRepository.AgentRepository // Agent数据访问层
+	tagService tag_system.TagService           // Tag服务,用于分组成员查询
 }
 
 // NewAgentConfigService 创建Agent配置服务实例
 // 遵循依赖注入原则，保持代码的可测试性
-func NewAgentConfigService(agentRepo agentRepository.AgentRepository) AgentConfigService {
+func NewAgentConfigService(agentRepo agentRepository.AgentRepository, tagService tag_system.TagService) AgentConfigService {
 	return &agentConfigService{
-		agentRepo: agentRepo,
+		agentRepo:  agentRepo,
+		tagService: tagService,
+	}
+}
+
+// validAgentLogLevels Agent支持的日志级别
+var validAgentLogLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// validateAgentConfig 校验配置内容，取值范围与 AgentConfigUpdateRequest 的 validate 标签一致
+func validateAgentConfig(config *agentModel.AgentConfigUpdateRequest) error {
+	switch {
+	case config == nil:
+		return fmt.Errorf("invalid config: config is required")
+	case config.HeartbeatInterval < 5 || config.HeartbeatInterval > 300:
+		return fmt.Errorf("invalid heartbeat_interval: must be 5-300 seconds")
+	case config.TaskPollInterval < 1 || config.TaskPollInterval > 60:
+		return fmt.Errorf("invalid task_poll_interval: must be 1-60 seconds")
+	case config.MaxConcurrentTasks < 1 || config.MaxConcurrentTasks > 50:
+		return fmt.Errorf("invalid max_concurrent_tasks: must be 1-50")
+	case !validAgentLogLevels[config.LogLevel]:
+		return fmt.Errorf("invalid log_level: %q", config.LogLevel)
+	case config.Timeout < 30 || config.Timeout > 3600:
+		return fmt.Errorf("invalid timeout: must be 30-3600 seconds")
+	case !config.TokenNeverExpire && config.TokenExpiryDuration < 3600:
+		return fmt.Errorf("invalid token_expiry_duration: must be at least 3600 seconds")
+	}
+	return nil
+}
+
+// toAgentConfigResponse 将配置模型转换为响应结构
+func toAgentConfigResponse(cfg *agentModel.AgentConfig) *agentModel.AgentConfigResponse {
+	return &agentModel.AgentConfigResponse{
+		AgentID:             cfg.AgentID,
+		Version:             cfg.Version,
+		HeartbeatInterval:   cfg.HeartbeatInterval,
+		TaskPollInterval:    cfg.TaskPollInterval,
+		MaxConcurrentTasks:  cfg.MaxConcurrentTasks,
+		PluginConfig:        cfg.PluginConfig,
+		LogLevel:            cfg.LogLevel,
+		Timeout:             cfg.Timeout,
+		TokenExpiryDuration: cfg.TokenExpiryDuration,
+		TokenNeverExpire:    cfg.TokenNeverExpire,
+		IsActive:            cfg.IsActive,
+		CreatedAt:           cfg.CreatedAt,
+		UpdatedAt:           cfg.UpdatedAt,
 	}
 }
 
+// loadAgentConfig 获取Agent当前生效的配置
+// 从未下发过配置时以默认配置为基础 (版本号为 0)；Agent拉取、对账与生效配置导出都经由这里
+func (s *agentConfigService) loadAgentConfig(agentID string) (*agentModel.AgentConfig, error) {
+	cfg, err := s.agentRepo.GetConfig(agentID)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.config.loadAgentConfig", "", map[string]interface{}{
+			"operation": "load_agent_config",
+			"option":    "agentRepo.GetConfig",
+			"func_name": "service.agent.config.loadAgentConfig",
+			"agent_id":  agentID,
+		})
+		return nil, err
+	}
+	if cfg != nil {
+		return cfg, nil
+	}
+	agent, err := s.agentRepo.GetByID(agentID)
+	if err != nil {
+		return nil, err
+	}
+	return agentModel.DefaultAgentConfigFor(agent), nil
+}
+
 // GetAgentConfig 获取Agent配置服务
+// 从未下发过配置时返回默认配置 (版本号为 0)
 func (s *agentConfigService) GetAgentConfig(agentID string) (*agentModel.AgentConfigResponse, error) {
-	// TODO: 实现Agent配置获取
-	logger.LogInfo("获取Agent配置", "", 0, "", "service.agent.config.GetAgentConfig", "", map[string]interface{}{
-		"operation": "get_agent_config",
-		"option":    "agentConfigService.GetAgentConfig",
-		"func_name": "service.agent.config.GetAgentConfig",
-		"agent_id":  agentID,
-	})
-	return nil, fmt.Errorf("功能暂未实现")
+	if _, err := s.agentRepo.GetByID(agentID); err != nil {
+		return nil, err
+	}
+	cfg, err := s.loadAgentConfig(agentID)
+	if err != nil {
+		return nil, err
+	}
+	return toAgentConfigResponse(cfg), nil
 }
 
 // UpdateAgentConfig 更新Agent配置服务
-func (s *agentConfigService) UpdateAgentConfig(agentID string, config *agentModel.AgentConfigUpdateRequest) error {
-	// TODO: 实现Agent配置更新
-	logger.LogInfo("更新Agent配置", "", 0, "", "service.agent.config.UpdateAgentConfig", "", map[string]interface{}{
+// 配置整体校验通过后才保存，保存时版本号 +1；Agent 在下次心跳对账时获取新配置
+func (s *agentConfigService) UpdateAgentConfig(agentID string, config *agentModel.AgentConfigUpdateRequest) (*agentModel.AgentConfigResponse, error) {
+	if err := validateAgentConfig(config); err != nil {
+		return nil, err
+	}
+	agent, err := s.agentRepo.GetByID(agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := s.agentRepo.GetConfig(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = agentModel.DefaultAgentConfigFor(agent)
+	}
+	before := cfg.FieldValues()
+	cfg.HeartbeatInterval = config.HeartbeatInterval
+	cfg.TaskPollInterval = config.TaskPollInterval
+	cfg.MaxConcurrentTasks = config.MaxConcurrentTasks
+	cfg.PluginConfig = config.PluginConfig
+	cfg.LogLevel = config.LogLevel
+	cfg.Timeout = config.Timeout
+	cfg.TokenExpiryDuration = config.TokenExpiryDuration
+	cfg.TokenNeverExpire = config.TokenNeverExpire
+	cfg.IsActive = true
+	cfg.MarkChanged(before, agentModel.ConfigFieldSource{Source: agentModel.ConfigSourceAgent})
+
+	if err = s.agentRepo.SaveConfig(cfg); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.config.UpdateAgentConfig", "", map[string]interface{}{
+			"operation": "update_agent_config",
+			"option":    "agentRepo.SaveConfig",
+			"func_name": "service.agent.config.UpdateAgentConfig",
+			"agent_id":  agentID,
+		})
+		return nil, err
+	}
+
+	// 同步离线巡检使用的心跳间隔；原离线阈值不大于新间隔时恢复默认(间隔的3倍)
+	offlineThreshold := agent.OfflineThreshold
+	if offlineThreshold > 0 && offlineThreshold <= config.HeartbeatInterval {
+		offlineThreshold = 0
+	}
+	if err = s.agentRepo.UpdateHeartbeatSettings(agentID, config.HeartbeatInterval, offlineThreshold); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.config.UpdateAgentConfig", "", map[string]interface{}{
+			"operation": "update_agent_config",
+			"option":    "agentRepo.UpdateHeartbeatSettings",
+			"func_name": "service.agent.config.UpdateAgentConfig",
+			"agent_id":  agentID,
+		})
+		return nil, err
+	}
+
+	logger.LogInfo("Agent配置已更新", "", 0, "", "service.agent.config.UpdateAgentConfig", "", map[string]interface{}{
 		"operation": "update_agent_config",
 		"option":    "agentConfigService.UpdateAgentConfig",
 		"func_name": "service.agent.config.UpdateAgentConfig",
 		"agent_id":  agentID,
+		"version":   cfg.Version,
 	})
-	return fmt.Errorf("功能暂未实现")
+	return toAgentConfigResponse(cfg), nil
 }
 
 // PushConfigToAgent 推送配置到Agent服务
+// Master 不主动连接 Agent：保存新版本配置后，由 Agent 下次心跳对账时拉取
 func (s *agentConfigService) PushConfigToAgent(agentID string, config *agentModel.AgentConfigUpdateRequest) error {
-	// TODO: 实现配置推送到Agent
-	logger.LogInfo("推送配置到Agent", "", 0, "", "service.agent.config.PushConfigToAgent", "", map[string]interface{}{
-		"operation": "push_config_to_agent",
-		"option":    "agentConfigService.PushConfigToAgent",
-		"func_name": "service.agent.config.PushConfigToAgent",
-		"agent_id":  agentID,
+	_, err := s.UpdateAgentConfig(agentID, config)
+	return err
+}
+
+// ReconcileConfig 配置对账
+// Master 端从未下发过配置时无需对账；Agent 上报版本与当前版本不一致时返回最新配置
+func (s *agentConfigService) ReconcileConfig(agentID string, reportedVersion int) (*agentModel.AgentConfigResponse, error) {
+	cfg, err := s.loadAgentConfig(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Version == 0 || !cfg.IsActiveConfig() || cfg.Version == reportedVersion {
+		return nil, nil
+	}
+	return toAgentConfigResponse(cfg), nil
+}
+
+// GetEffectiveConfig 导出Agent生效配置
+// 配置内容与Agent拉取时一致，并按配置项标注来源层 (default/group/agent)，用于排查配置漂移
+func (s *agentConfigService) GetEffectiveConfig(agentID string) (*agentModel.AgentEffectiveConfigResponse, error) {
+	if _, err := s.agentRepo.GetByID(agentID); err != nil {
+		return nil, err
+	}
+	cfg, err := s.loadAgentConfig(agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]agentModel.EffectiveConfigField)
+	var groupIDs []uint64
+	for key, value := range cfg.FieldValues() {
+		src := cfg.SourceOf(key)
+		fields[key] = agentModel.EffectiveConfigField{Value: value, Source: src.Source, GroupID: src.GroupID}
+		if src.GroupID != 0 {
+			groupIDs = append(groupIDs, src.GroupID)
+		}
+	}
+
+	// 补充分组名称，分组标签已删除时只保留ID
+	if len(groupIDs) > 0 && s.tagService != nil {
+		tags, tagErr := s.tagService.GetTagsByIDs(context.Background(), groupIDs)
+		if tagErr == nil {
+			names := make(map[uint64]string, len(tags))
+			for _, t := range tags {
+				names[t.ID] = t.Name
+			}
+			for key, field := range fields {
+				if field.GroupID != 0 {
+					field.GroupName = names[field.GroupID]
+					fields[key] = field
+				}
+			}
+		}
+	}
+
+	return &agentModel.AgentEffectiveConfigResponse{
+		AgentID: agentID,
+		Version: cfg.Version,
+		Config:  toAgentConfigResponse(cfg),
+		Fields:  fields,
+	}, nil
+}
+
+// validateAgentConfigPatch 校验配置补丁：补丁应用到默认配置后必须仍是合法配置
+func validateAgentConfigPatch(patch *agentModel.AgentConfigPatch) error {
+	if patch.IsEmpty() {
+		return fmt.Errorf("invalid patch: no field set")
+	}
+	cfg := agentModel.DefaultAgentConfig("")
+	patch.ApplyTo(cfg)
+	return validateAgentConfig(&agentModel.AgentConfigUpdateRequest{
+		HeartbeatInterval:   cfg.HeartbeatInterval,
+		TaskPollInterval:    cfg.TaskPollInterval,
+		MaxConcurrentTasks:  cfg.MaxConcurrentTasks,
+		PluginConfig:        cfg.PluginConfig,
+		LogLevel:            cfg.LogLevel,
+		Timeout:             cfg.Timeout,
+		TokenExpiryDuration: cfg.TokenExpiryDuration,
+		TokenNeverExpire:    cfg.TokenNeverExpire,
 	})
-	return fmt.Errorf("功能暂未实现")
+}
+
+// resolveGroupTag 解析分组ID (分组标签ID) 并确认其为Agent分组标签
+func (s *agentConfigService) resolveGroupTag(ctx context.Context, groupID string) (uint64, error) {
+	tagID, err := strconv.ParseUint(groupID, 10, 64)
+	if err != nil || tagID == 0 {
+		return 0, fmt.Errorf("invalid group ID: %q", groupID)
+	}
+	tag, err := s.tagService.GetTag(ctx, tagID)
+	if err != nil || tag == nil {
+		return 0, fmt.Errorf("agent group not found: %d", tagID)
+	}
+	if tag.Category != agentGroupTagCategory {
+		return 0, fmt.Errorf("invalid group ID: tag %d is not an agent group", tagID)
+	}
+	return tagID, nil
+}
+
+// GetGroupConfig 获取分组配置补丁
+func (s *agentConfigService) GetGroupConfig(groupID string) (*agentModel.AgentGroupConfig, error) {
+	tagID, err := s.resolveGroupTag(context.Background(), groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.agentRepo.GetGroupConfig(tagID)
+}
+
+// UpdateGroupConfig 将配置补丁批量应用到分组全部成员
+// 补丁只包含需要修改的字段；全部成员在一个事务内更新并各自递增配置版本号，下次心跳对账时生效
+// 返回实际更新的Agent数
+func (s *agentConfigService) UpdateGroupConfig(groupID string, patch agentModel.AgentConfigPatch) (int, error) {
+	if err := validateAgentConfigPatch(&patch); err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+	tagID, err := s.resolveGroupTag(ctx, groupID)
+	if err != nil {
+		return 0, err
+	}
+
+	agentIDs, err := s.tagService.GetEntityIDsByTagIDs(ctx, "agent", []uint64{tagID})
+	if err != nil {
+		return 0, fmt.Errorf("获取分组成员失败: %w", err)
+	}
+
+	updated, err := s.agentRepo.ApplyGroupConfig(tagID, &patch, agentIDs)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.config.UpdateGroupConfig", "", map[string]interface{}{
+			"operation": "update_group_config",
+			"option":    "agentRepo.ApplyGroupConfig",
+			"func_name": "service.agent.config.UpdateGroupConfig",
+			"group_id":  groupID,
+		})
+		return 0, err
+	}
+
+	logger.LogInfo("Agent分组配置已批量更新", "", 0, "", "service.agent.config.UpdateGroupConfig", "", map[string]interface{}{
+		"operation": "update_group_config",
+		"option":    "agentConfigService.UpdateGroupConfig",
+		"func_name": "service.agent.config.UpdateGroupConfig",
+		"group_id":  groupID,
+		"updated":   updated,
+	})
+	return updated, nil
+}
+
+// InheritGroupConfig Agent加入分组时继承分组配置
+// 多个分组按标签ID升序依次应用；未下发过配置的分组跳过
+func (s *agentConfigService) InheritGroupConfig(agentID string, groupTagIDs []uint64) error {
+	ids := append([]uint64(nil), groupTagIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, tagID := range ids {
+		groupCfg, err := s.agentRepo.GetGroupConfig(tagID)
+		if err != nil {
+			return err
+		}
+		if groupCfg == nil || groupCfg.Patch.IsEmpty() {
+			continue
+		}
+		if _, err = s.agentRepo.ApplyConfigPatch(tagID, &groupCfg.Patch, []string{agentID}); err != nil {
+			logger.LogBusinessError(err, "", 0, "", "service.agent.config.InheritGroupConfig", "", map[string]interface{}{
+				"operation":    "inherit_group_config",
+				"option":       "agentRepo.ApplyConfigPatch",
+				"func_name":    "service.agent.config.InheritGroupConfig",
+				"agent_id":     agentID,
+				"group_tag_id": tagID,
+			})
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertGroupConfig Agent退出分组时撤销分组写入的配置值
+// 相关配置项回退到其他来源 (其余分组或单独配置) 最近写入的值，没有其他来源时回退到默认值；配置有变化时版本号 +1
+func (s *agentConfigService) RevertGroupConfig(agentID string, groupTagIDs []uint64) error {
+	for _, tagID := range groupTagIDs {
+		if _, err := s.agentRepo.RevertGroupConfig(tagID, []string{agentID}); err != nil {
+			logger.LogBusinessError(err, "", 0, "", "service.agent.config.RevertGroupConfig", "", map[string]interface{}{
+				"operation":    "revert_group_config",
+				"option":       "agentRepo.RevertGroupConfig",
+				"func_name":    "service.agent.config.RevertGroupConfig",
+				"agent_id":     agentID,
+				"group_tag_id": tagID,
+			})
+			return err
+		}
+	}
+	return nil
 }
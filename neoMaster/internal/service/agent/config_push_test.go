@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"strconv"
+	"testing"
+
+	agentModel "neomaster/internal/model/agent"
+	tagSystemModel "neomaster/internal/model/tag_system"
+	agentRepository "neomaster/internal/repo/mysql/agent"
+	tagSystemRepo "neomaster/internal/repo/mysql/tag_system"
+	"neomaster/internal/service/tag_system"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newConfigTestService(t *testing.T) (AgentConfigService, agentRepository.AgentRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&agentModel.Agent{}, &agentModel.AgentConfig{}))
+	require.NoError(t, db.Create(&agentModel.Agent{AgentID: "agent-1", Hostname: "scanner", OfflineThreshold: 60}).Error)
+
+	repo := agentRepository.NewAgentRepository(db)
+	return NewAgentConfigService(repo, nil), repo
+}
+
+func validConfigRequest() *agentModel.AgentConfigUpdateRequest {
+	return &agentModel.AgentConfigUpdateRequest{
+		HeartbeatInterval:   60,
+		TaskPollInterval:    5,
+		MaxConcurrentTasks:  8,
+		PluginConfig:        map[string]interface{}{"nmap": map[string]interface{}{"rate": float64(1000)}},
+		LogLevel:            "debug",
+		Timeout:             600,
+		TokenExpiryDuration: 7200,
+	}
+}
+
+func TestAgentConfigService_UpdateAndReconcile(t *testing.T) {
+	svc, repo := newConfigTestService(t)
+
+	// 未下发过配置：返回默认配置，版本为 0，无需对账
+	cfg, err := svc.GetAgentConfig("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.Version)
+	assert.Equal(t, 30, cfg.HeartbeatInterval)
+	pending, err := svc.ReconcileConfig("agent-1", 0)
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+
+	// 每次更新版本号 +1
+	cfg, err = svc.UpdateAgentConfig("agent-1", validConfigRequest())
+	require.NoError(t, err)
+	assert.Equal(t, 1, cfg.Version)
+	req := validConfigRequest()
+	req.MaxConcurrentTasks = 16
+	cfg, err = svc.UpdateAgentConfig("agent-1", req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.Version)
+
+	// 版本落后返回最新配置，一致返回 nil
+	pending, err = svc.ReconcileConfig("agent-1", 1)
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, 2, pending.Version)
+	assert.Equal(t, 16, pending.MaxConcurrentTasks)
+	assert.Equal(t, float64(1000), pending.PluginConfig["nmap"].(map[string]interface{})["rate"])
+	pending, err = svc.ReconcileConfig("agent-1", 2)
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+
+	// 心跳间隔同步到离线巡检，原阈值不大于新间隔时恢复默认
+	agent, err := repo.GetByID("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, 60, agent.HeartbeatInterval)
+	assert.Equal(t, 0, agent.OfflineThreshold)
+
+	// 校验失败不保存，版本不变
+	bad := validConfigRequest()
+	bad.LogLevel = "verbose"
+	_, err = svc.UpdateAgentConfig("agent-1", bad)
+	assert.Error(t, err)
+	cfg, err = svc.GetAgentConfig("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.Version)
+	assert.Equal(t, "debug", cfg.LogLevel)
+
+	_, err = svc.UpdateAgentConfig("missing", validConfigRequest())
+	assert.Error(t, err)
+}
+
+// newGroupConfigTestEnv 准备分组配置测试环境：agent-1/agent-2 属于分组 prod，agent-3 不在分组中
+func newGroupConfigTestEnv(t *testing.T) (AgentConfigService, AgentManagerService, *tagSystemModel.SysTag, *tagSystemModel.SysTag) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&agentModel.Agent{}, &agentModel.AgentConfig{}, &agentModel.AgentGroupConfig{},
+		&tagSystemModel.SysTag{}, &tagSystemModel.SysMatchRule{}, &tagSystemModel.SysEntityTag{},
+	))
+	for _, id := range []string{"agent-1", "agent-2", "agent-3"} {
+		require.NoError(t, db.Create(&agentModel.Agent{AgentID: id, Hostname: id}).Error)
+	}
+	group := &tagSystemModel.SysTag{Name: "prod", Category: agentGroupTagCategory}
+	plain := &tagSystemModel.SysTag{Name: "linux", Category: "agent"}
+	require.NoError(t, db.Create(group).Error)
+	require.NoError(t, db.Create(plain).Error)
+	for _, id := range []string{"agent-1", "agent-2"} {
+		require.NoError(t, db.Create(&tagSystemModel.SysEntityTag{EntityType: "agent", EntityID: id, TagID: group.ID}).Error)
+	}
+
+	repo := agentRepository.NewAgentRepository(db)
+	tagService := tag_system.NewTagService(tagSystemRepo.NewTagRepository(db), db)
+	svc := NewAgentConfigService(repo, tagService)
+	manager := NewAgentManagerService(nil, repo, tagService, nil)
+	manager.SetConfigService(svc)
+	return svc, manager, group, plain
+}
+
+func TestAgentConfigService_UpdateGroupConfig(t *testing.T) {
+	svc, manager, group, plain := newGroupConfigTestEnv(t)
+	groupID := strconv.FormatUint(group.ID, 10)
+
+	// agent-1 已有单独配置
+	_, err := svc.UpdateAgentConfig("agent-1", validConfigRequest())
+	require.NoError(t, err)
+
+	// 局部补丁：只覆盖设置的字段，成员版本号各自 +1
+	tasks := 12
+	updated, err := svc.UpdateGroupConfig(groupID, agentModel.AgentConfigPatch{MaxConcurrentTasks: &tasks})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated)
+
+	cfg, err := svc.GetAgentConfig("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.Version)
+	assert.Equal(t, 12, cfg.MaxConcurrentTasks)
+	assert.Equal(t, "debug", cfg.LogLevel) // 补丁未设置的字段保留单独配置
+	cfg, err = svc.GetAgentConfig("agent-2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cfg.Version)
+	assert.Equal(t, 12, cfg.MaxConcurrentTasks)
+	pending, err := svc.ReconcileConfig("agent-2", 0)
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+
+	// 分组补丁累计合并
+	level := "warn"
+	_, err = svc.UpdateGroupConfig(groupID, agentModel.AgentConfigPatch{LogLevel: &level})
+	require.NoError(t, err)
+	groupCfg, err := svc.GetGroupConfig(groupID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, groupCfg.Version)
+	assert.Equal(t, 12, *groupCfg.Patch.MaxConcurrentTasks)
+	assert.Equal(t, "warn", *groupCfg.Patch.LogLevel)
+
+	// 之后加入分组的Agent继承分组配置
+	require.NoError(t, manager.AddAgentTag(&agentModel.AgentTagRequest{AgentID: "agent-3", TagID: group.ID}))
+	cfg, err = svc.GetAgentConfig("agent-3")
+	require.NoError(t, err)
+	assert.Equal(t, 12, cfg.MaxConcurrentTasks)
+	assert.Equal(t, "warn", cfg.LogLevel)
+
+	// 非法输入
+	_, err = svc.UpdateGroupConfig(groupID, agentModel.AgentConfigPatch{})
+	assert.Error(t, err)
+	bad := 0
+	_, err = svc.UpdateGroupConfig(groupID, agentModel.AgentConfigPatch{MaxConcurrentTasks: &bad})
+	assert.Error(t, err)
+	_, err = svc.UpdateGroupConfig(strconv.FormatUint(plain.ID, 10), agentModel.AgentConfigPatch{LogLevel: &level})
+	assert.Error(t, err)
+	_, err = svc.UpdateGroupConfig("abc", agentModel.AgentConfigPatch{LogLevel: &level})
+	assert.Error(t, err)
+}
+
+func TestAgentConfigService_GetEffectiveConfig(t *testing.T) {
+	svc, _, group, _ := newGroupConfigTestEnv(t)
+
+	// 未下发过配置：全部来自默认值
+	effective, err := svc.GetEffectiveConfig("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, effective.Version)
+	assert.Equal(t, agentModel.ConfigSourceDefault, effective.Fields["heartbeat_interval"].Source)
+	assert.Equal(t, 30, effective.Fields["heartbeat_interval"].Value)
+
+	// 单独配置只标记与原值不同的配置项
+	req := validConfigRequest()
+	req.Timeout = 300
+	_, err = svc.UpdateAgentConfig("agent-1", req)
+	require.NoError(t, err)
+	rate := map[string]interface{}{"rate": float64(100)}
+	_, err = svc.UpdateGroupConfig(strconv.FormatUint(group.ID, 10), agentModel.AgentConfigPatch{
+		PluginConfig: map[string]interface{}{"nmap": rate},
+	})
+	require.NoError(t, err)
+
+	effective, err = svc.GetEffectiveConfig("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, effective.Version)
+	assert.Equal(t, agentModel.ConfigSourceAgent, effective.Fields["log_level"].Source)
+	assert.Equal(t, agentModel.ConfigSourceDefault, effective.Fields["timeout"].Source)
+	nmap := effective.Fields["plugin_config.nmap"]
+	assert.Equal(t, agentModel.ConfigSourceGroup, nmap.Source)
+	assert.Equal(t, group.ID, nmap.GroupID)
+	assert.Equal(t, "prod", nmap.GroupName)
+	assert.Equal(t, rate, nmap.Value)
+
+	// 与Agent拉取到的配置一致
+	pulled, err := svc.ReconcileConfig("agent-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, pulled, effective.Config)
+
+	_, err = svc.GetEffectiveConfig("missing")
+	assert.Error(t, err)
+}
+
+func TestAgentConfigService_RevertGroupConfigOnLeave(t *testing.T) {
+	svc, manager, group, _ := newGroupConfigTestEnv(t)
+	groupID := strconv.FormatUint(group.ID, 10)
+
+	// agent-1 先有单独配置，之后分组覆盖部分字段，agent-1 再单独修改日志级别
+	_, err := svc.UpdateAgentConfig("agent-1", validConfigRequest())
+	require.NoError(t, err)
+	tasks, level, interval := 12, "warn", 90
+	masscan := map[string]interface{}{"rate": float64(5000)}
+	_, err = svc.UpdateGroupConfig(groupID, agentModel.AgentConfigPatch{
+		MaxConcurrentTasks: &tasks,
+		LogLevel:           &level,
+		HeartbeatInterval:  &interval,
+		PluginConfig:       map[string]interface{}{"masscan": masscan},
+	})
+	require.NoError(t, err)
+	req := validConfigRequest()
+	req.MaxConcurrentTasks = 12
+	req.HeartbeatInterval = 90
+	req.LogLevel = "error"
+	req.PluginConfig["masscan"] = masscan
+	_, err = svc.UpdateAgentConfig("agent-1", req)
+	require.NoError(t, err)
+
+	// 退出分组：分组写入的字段回退到单独配置最近写入的值，单独配置之后写入的字段保持不变
+	require.NoError(t, manager.RemoveAgentTag(&agentModel.AgentTagRequest{AgentID: "agent-1", TagID: group.ID}))
+	effective, err := svc.GetEffectiveConfig("agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, effective.Version)
+	assert.Equal(t, 8, effective.Config.MaxConcurrentTasks)
+	assert.Equal(t, 60, effective.Config.HeartbeatInterval)
+	assert.Equal(t, "error", effective.Config.LogLevel)
+	assert.NotContains(t, effective.Config.PluginConfig, "masscan")
+	assert.Contains(t, effective.Config.PluginConfig, "nmap")
+	for _, key := range []string{"max_concurrent_tasks", "heartbeat_interval", "log_level", "plugin_config.nmap"} {
+		assert.Equal(t, agentModel.ConfigSourceAgent, effective.Fields[key].Source, key)
+	}
+	assert.NotContains(t, effective.Fields, "plugin_config.masscan")
+	pending, err := svc.ReconcileConfig("agent-1", 3)
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, effective.Config, pending)
+
+	// 没有单独配置的Agent退出分组后回退到默认值，离线巡检的心跳间隔同步回退
+	_, _, err = manager.UpdateAgentTags("agent-2", nil)
+	require.NoError(t, err)
+	effective, err = svc.GetEffectiveConfig("agent-2")
+	require.NoError(t, err)
+	assert.Equal(t, 5, effective.Config.MaxConcurrentTasks)
+	assert.Equal(t, "info", effective.Config.LogLevel)
+	assert.Equal(t, 30, effective.Config.HeartbeatInterval)
+	assert.Empty(t, effective.Config.PluginConfig)
+	for key, field := range effective.Fields {
+		assert.Equal(t, agentModel.ConfigSourceDefault, field.Source, key)
+	}
+	agent, err := manager.GetAgentInfo("agent-2")
+	require.NoError(t, err)
+	assert.Equal(t, 30, agent.HeartbeatInterval)
+
+	// 重复退出不再产生新版本
+	version := effective.Version
+	require.NoError(t, svc.RevertGroupConfig("agent-2", []uint64{group.ID}))
+	effective, err = svc.GetEffectiveConfig("agent-2")
+	require.NoError(t, err)
+	assert.Equal(t, version, effective.Version)
+}
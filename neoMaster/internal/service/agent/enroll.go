@@ -0,0 +1,246 @@
+/**
+ * 服务层:Agent接入服务
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 管理员签发一次性接入令牌，Agent 首次接入时出示令牌，Master 创建(或按 hostname+port 匹配)Agent 记录并签发带有效期的 Token。
+ *               接入令牌库中只保存哈希，过期或使用一次后失效；已接入的 Agent 再次接入需要新的令牌，只轮换 Token，不会产生重复记录。
+ * @func:
+ *   - CreateEnrollmentToken 签发一次性接入令牌
+ *   - EnrollAgent Agent接入并签发Token
+ */
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/pkg/auth"
+	"neomaster/internal/pkg/logger"
+)
+
+// defaultAgentTokenTTL 未配置 security.agent.token_ttl 时的 Token 有效期
+const defaultAgentTokenTTL = 24 * time.Hour
+
+const (
+	// EnrollmentTokenPrefix 接入令牌明文前缀，便于在日志与部署脚本中识别泄露的令牌
+	EnrollmentTokenPrefix = "nse_"
+
+	enrollmentTokenRandomBytes      = 32                  // 令牌随机部分长度(字节)
+	enrollmentTokenDisplayPrefixLen = 12                  // 记录中保存的令牌前缀长度
+	defaultEnrollmentTokenTTL       = 24 * time.Hour      // 未指定有效期时的令牌有效期
+	maxEnrollmentTokenTTL           = 30 * 24 * time.Hour // 令牌最长有效期
+)
+
+var (
+	// ErrInvalidEnrollmentSecret 接入密钥错误
+	ErrInvalidEnrollmentSecret = errors.New("authentication failed: invalid enrollment secret")
+	// ErrInvalidEnrollmentTokenTTL 接入令牌有效期超出允许范围
+	ErrInvalidEnrollmentTokenTTL = fmt.Errorf("invalid enrollment token ttl: must not exceed %d seconds", int(maxEnrollmentTokenTTL/time.Second))
+	// ErrEnrollmentNotConfigured 未配置 Token 签名密钥，拒绝所有接入请求
+	ErrEnrollmentNotConfigured = errors.New("agent enrollment is not configured")
+)
+
+// newAgentTokenManager 根据配置创建 Token 签发器，未配置签名密钥时返回 nil
+func newAgentTokenManager(cfg *config.Config) *auth.AgentJWTManager {
+	if cfg == nil || cfg.Security.Agent.TokenSigningKey == "" {
+		return nil
+	}
+	ttl := cfg.Security.Agent.TokenTTL
+	if ttl <= 0 {
+		ttl = defaultAgentTokenTTL
+	}
+	return auth.NewAgentJWTManager(cfg.Security.Agent.TokenSigningKey, ttl)
+}
+
+// generateEnrollmentToken 生成接入令牌明文: 前缀 + 32 字节随机数(URL 安全 Base64)
+func generateEnrollmentToken() (string, error) {
+	buf := make([]byte, enrollmentTokenRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成接入令牌失败: %w", err)
+	}
+	return EnrollmentTokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashEnrollmentToken 计算接入令牌的 SHA-256 哈希(十六进制)
+// 令牌为高熵随机值，无需加盐或慢哈希
+func hashEnrollmentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateEnrollmentToken 签发一次性接入令牌
+// 返回的 Token 为令牌明文，只在签发时返回一次，库中只保存哈希
+func (s *agentManagerService) CreateEnrollmentToken(ctx context.Context, req agentModel.CreateEnrollmentTokenRequest, createdBy uint) (*agentModel.CreateEnrollmentTokenResponse, error) {
+	ttl := time.Duration(req.TTL) * time.Second
+	if req.TTL < 0 || ttl > maxEnrollmentTokenTTL {
+		return nil, ErrInvalidEnrollmentTokenTTL
+	}
+	if ttl == 0 {
+		ttl = defaultEnrollmentTokenTTL
+	}
+
+	rawToken, err := generateEnrollmentToken()
+	if err != nil {
+		return nil, err
+	}
+	record := &agentModel.AgentEnrollmentToken{
+		TokenPrefix: rawToken[:enrollmentTokenDisplayPrefixLen],
+		TokenHash:   hashEnrollmentToken(rawToken),
+		Remark:      strings.TrimSpace(req.Remark),
+		CreatedBy:   createdBy,
+		ExpiresAt:   s.currentTime().Add(ttl),
+	}
+	if err := s.agentRepo.CreateEnrollmentToken(record); err != nil {
+		return nil, fmt.Errorf("保存接入令牌失败: %w", err)
+	}
+
+	logger.LogBusinessOperation("create_enrollment_token", createdBy, "", "", "", "success", "Agent接入令牌已签发", map[string]interface{}{
+		"operation":    "create_enrollment_token",
+		"func_name":    "service.agent.enroll.CreateEnrollmentToken",
+		"token_prefix": record.TokenPrefix,
+		"expires_at":   record.ExpiresAt,
+	})
+	return &agentModel.CreateEnrollmentTokenResponse{Token: rawToken, AgentEnrollmentToken: record}, nil
+}
+
+// EnrollAgent Agent接入服务
+// 1. 消费接入令牌(令牌不存在、已使用或已过期时拒绝接入，不允许匿名接入)
+// 2. 按 hostname+port 查找已有 Agent：存在则轮换 Token，否则创建新 Agent
+// 3. 签发带有效期的 Token 并写入 Agent 记录，供 Agent 认证中间件校验
+func (s *agentManagerService) EnrollAgent(ctx context.Context, req agentModel.EnrollRequest) (*agentModel.EnrollResponse, error) {
+	if req.Hostname == "" || len(req.Hostname) > 255 {
+		return nil, fmt.Errorf("invalid hostname")
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		return nil, fmt.Errorf("port must be between 1 and 65535")
+	}
+
+	if s.tokenManager == nil {
+		logger.LogBusinessError(ErrEnrollmentNotConfigured, "", 0, "", "service.agent.enroll.EnrollAgent", "", map[string]interface{}{
+			"operation": "enroll_agent",
+			"option":    "check_config",
+			"func_name": "service.agent.enroll.EnrollAgent",
+			"hostname":  req.Hostname,
+		})
+		return nil, ErrEnrollmentNotConfigured
+	}
+
+	// 条件更新保证令牌只能使用一次，并发接入时只有一个请求成功
+	consumed := false
+	if strings.HasPrefix(req.EnrollmentSecret, EnrollmentTokenPrefix) {
+		usedBy := req.Hostname + ":" + strconv.Itoa(req.Port)
+		var err error
+		consumed, err = s.agentRepo.ConsumeEnrollmentToken(hashEnrollmentToken(req.EnrollmentSecret), usedBy, s.currentTime())
+		if err != nil {
+			return nil, fmt.Errorf("校验接入令牌失败: %w", err)
+		}
+	}
+	if !consumed {
+		logger.LogBusinessError(ErrInvalidEnrollmentSecret, "", 0, "", "service.agent.enroll.EnrollAgent", "", map[string]interface{}{
+			"operation": "enroll_agent",
+			"option":    "consume_enrollment_token",
+			"func_name": "service.agent.enroll.EnrollAgent",
+			"hostname":  req.Hostname,
+			"port":      req.Port,
+		})
+		return nil, ErrInvalidEnrollmentSecret
+	}
+
+	existingAgent, err := s.agentRepo.GetByHostnameAndPort(req.Hostname, req.Port)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.enroll.EnrollAgent", "", map[string]interface{}{
+			"operation": "enroll_agent",
+			"option":    "agentRepo.GetByHostnameAndPort",
+			"func_name": "service.agent.enroll.EnrollAgent",
+			"hostname":  req.Hostname,
+			"port":      req.Port,
+		})
+		return nil, fmt.Errorf("检查Agent是否存在失败: %v", err)
+	}
+
+	reenrolled := existingAgent != nil
+	agentID := ""
+	if reenrolled {
+		agentID = existingAgent.AgentID
+	} else {
+		agentID = generateAgentID(req.Hostname)
+	}
+
+	now := s.currentTime()
+	token, expiry, err := s.tokenManager.GenerateToken(agentID, req.Hostname, now)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.enroll.EnrollAgent", "", map[string]interface{}{
+			"operation": "enroll_agent",
+			"option":    "tokenManager.GenerateToken",
+			"func_name": "service.agent.enroll.EnrollAgent",
+			"agent_id":  agentID,
+		})
+		return nil, fmt.Errorf("签发Agent Token失败: %v", err)
+	}
+
+	agentData := &agentModel.Agent{
+		AgentID:       agentID,
+		Hostname:      req.Hostname,
+		IPAddress:     req.IPAddress,
+		Port:          req.Port,
+		Version:       req.Version,
+		OS:            req.OS,
+		Arch:          req.Arch,
+		Token:         token,
+		TokenExpiry:   expiry,
+		Status:        agentModel.AgentStatusOnline,
+		LastHeartbeat: now,
+	}
+
+	if reenrolled {
+		// 重新接入：旧 Token 被新 Token 覆盖后立即失效
+		agentData.LockVersion = existingAgent.LockVersion
+		agentData.HeartbeatInterval = existingAgent.HeartbeatInterval
+		agentData.OfflineThreshold = existingAgent.OfflineThreshold
+		err = s.agentRepo.Update(agentData)
+	} else {
+		err = s.agentRepo.Create(agentData)
+	}
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.enroll.EnrollAgent", "", map[string]interface{}{
+			"operation":  "enroll_agent",
+			"option":     "save_agent",
+			"func_name":  "service.agent.enroll.EnrollAgent",
+			"agent_id":   agentID,
+			"reenrolled": reenrolled,
+		})
+		return nil, fmt.Errorf("保存Agent失败: %w", err)
+	}
+
+	// 接入时上报的主机信息参与自动打标
+	s.autoTagAgent(ctx, agentData)
+
+	logger.LogInfo("Agent接入成功", "", 0, "", "service.agent.enroll.EnrollAgent", "", map[string]interface{}{
+		"operation":    "enroll_agent",
+		"option":       "success",
+		"func_name":    "service.agent.enroll.EnrollAgent",
+		"agent_id":     agentID,
+		"hostname":     req.Hostname,
+		"reenrolled":   reenrolled,
+		"token_expiry": expiry,
+	})
+
+	return &agentModel.EnrollResponse{
+		AgentID:     agentID,
+		Token:       token,
+		TokenExpiry: expiry,
+		Reenrolled:  reenrolled,
+
+		HeartbeatInterval: int(agentData.EffectiveHeartbeatInterval() / time.Second),
+	}, nil
+}
@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+	tagSystemModel "neomaster/internal/model/tag_system"
+	"neomaster/internal/pkg/auth"
+	agentRepository "neomaster/internal/repo/mysql/agent"
+	tagSystemRepo "neomaster/internal/repo/mysql/tag_system"
+	"neomaster/internal/service/tag_system"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testSigningKey = "test-agent-signing-key"
+
+func newEnrollTestService(t *testing.T, agentCfg config.AgentConfig) (*agentManagerService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&agentModel.Agent{}, &agentModel.AgentMetrics{}, &agentModel.AgentEnrollmentToken{}))
+
+	cfg := &config.Config{Security: config.SecurityConfig{Agent: agentCfg}}
+	svc := NewAgentManagerService(cfg, agentRepository.NewAgentRepository(db), nil, nil).(*agentManagerService)
+	return svc, db
+}
+
+// issueEnrollmentToken 签发一次性接入令牌并返回明文
+func issueEnrollmentToken(t *testing.T, svc *agentManagerService) string {
+	t.Helper()
+	resp, err := svc.CreateEnrollmentToken(context.Background(), agentModel.CreateEnrollmentTokenRequest{}, 1)
+	require.NoError(t, err)
+	return resp.Token
+}
+
+func TestEnrollAgent(t *testing.T) {
+	svc, db := newEnrollTestService(t, config.AgentConfig{
+		TokenSigningKey: testSigningKey,
+		TokenTTL:        2 * time.Hour,
+	})
+	ctx := context.Background()
+	req := agentModel.EnrollRequest{Hostname: "scanner-01", Port: 5772, Version: "1.0.0", EnrollmentSecret: issueEnrollmentToken(t, svc)}
+
+	first, err := svc.EnrollAgent(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, first.Reenrolled)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), first.TokenExpiry, time.Minute)
+
+	// Token 由签名密钥签发，声明中携带 AgentID
+	claims, err := auth.NewAgentJWTManager(testSigningKey, 2*time.Hour).ValidateToken(first.Token, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, first.AgentID, claims.AgentID)
+
+	// 重新接入需要新的接入令牌，轮换 Token，不产生重复记录
+	req.Version = "1.1.0"
+	req.EnrollmentSecret = issueEnrollmentToken(t, svc)
+	second, err := svc.EnrollAgent(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, second.Reenrolled)
+	assert.Equal(t, first.AgentID, second.AgentID)
+	assert.NotEqual(t, first.Token, second.Token)
+
+	var count int64
+	require.NoError(t, db.Model(&agentModel.Agent{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	stored, err := svc.agentRepo.GetByID(first.AgentID)
+	require.NoError(t, err)
+	assert.Equal(t, second.Token, stored.Token)
+	assert.Equal(t, "1.1.0", stored.Version)
+
+	// 旧 Token 已失效
+	old, err := svc.GetAgentByToken(first.Token)
+	require.NoError(t, err)
+	assert.Nil(t, old)
+}
+
+func TestEnrollAgent_EnrollmentToken(t *testing.T) {
+	svc, db := newEnrollTestService(t, config.AgentConfig{TokenSigningKey: testSigningKey})
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	resp, err := svc.CreateEnrollmentToken(ctx, agentModel.CreateEnrollmentTokenRequest{TTL: 3600, Remark: " rack-1 "}, 7)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Token, EnrollmentTokenPrefix))
+	assert.Equal(t, "rack-1", resp.Remark)
+	assert.Equal(t, uint(7), resp.CreatedBy)
+	assert.True(t, resp.ExpiresAt.Equal(now.Add(time.Hour)))
+
+	// 库中只保存哈希与前缀，不保存明文
+	var stored agentModel.AgentEnrollmentToken
+	require.NoError(t, db.First(&stored).Error)
+	assert.Equal(t, hashEnrollmentToken(resp.Token), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, resp.Token)
+	assert.Equal(t, resp.Token[:enrollmentTokenDisplayPrefixLen], stored.TokenPrefix)
+	assert.Nil(t, stored.UsedAt)
+
+	req := agentModel.EnrollRequest{Hostname: "scanner-01", Port: 5772, EnrollmentSecret: resp.Token}
+	_, err = svc.EnrollAgent(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, db.First(&stored, stored.ID).Error)
+	require.NotNil(t, stored.UsedAt)
+	assert.Equal(t, "scanner-01:5772", stored.UsedBy)
+
+	// 令牌只能使用一次，其他主机也不能复用
+	_, err = svc.EnrollAgent(ctx, req)
+	assert.ErrorIs(t, err, ErrInvalidEnrollmentSecret)
+	_, err = svc.EnrollAgent(ctx, agentModel.EnrollRequest{Hostname: "scanner-02", Port: 5772, EnrollmentSecret: resp.Token})
+	assert.ErrorIs(t, err, ErrInvalidEnrollmentSecret)
+
+	// 过期令牌不能使用
+	expired := issueEnrollmentToken(t, svc)
+	now = now.Add(defaultEnrollmentTokenTTL)
+	_, err = svc.EnrollAgent(ctx, agentModel.EnrollRequest{Hostname: "scanner-03", Port: 5772, EnrollmentSecret: expired})
+	assert.ErrorIs(t, err, ErrInvalidEnrollmentSecret)
+
+	var count int64
+	require.NoError(t, db.Model(&agentModel.Agent{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	for _, ttl := range []int{-1, int(maxEnrollmentTokenTTL/time.Second) + 1} {
+		_, err = svc.CreateEnrollmentToken(ctx, agentModel.CreateEnrollmentTokenRequest{TTL: ttl}, 7)
+		assert.ErrorIs(t, err, ErrInvalidEnrollmentTokenTTL, "ttl %d", ttl)
+	}
+}
+
+func TestEnrollAgent_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.AgentConfig
+		secret  string
+		wantErr error
+	}{
+		{
+			name:    "unknown_token",
+			cfg:     config.AgentConfig{TokenSigningKey: testSigningKey},
+			secret:  EnrollmentTokenPrefix + "guess",
+			wantErr: ErrInvalidEnrollmentSecret,
+		},
+		{
+			name:    "static_secret_not_accepted",
+			cfg:     config.AgentConfig{TokenSecret: "register-secret", TokenSigningKey: testSigningKey},
+			secret:  "register-secret",
+			wantErr: ErrInvalidEnrollmentSecret,
+		},
+		{
+			name:    "empty_secret",
+			cfg:     config.AgentConfig{TokenSigningKey: testSigningKey},
+			secret:  "",
+			wantErr: ErrInvalidEnrollmentSecret,
+		},
+		{
+			name:    "no_signing_key",
+			cfg:     config.AgentConfig{},
+			secret:  EnrollmentTokenPrefix + "guess",
+			wantErr: ErrEnrollmentNotConfigured,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, db := newEnrollTestService(t, tt.cfg)
+			_, err := svc.EnrollAgent(context.Background(), agentModel.EnrollRequest{Hostname: "scanner-01", Port: 5772, EnrollmentSecret: tt.secret})
+			assert.True(t, errors.Is(err, tt.wantErr), "err = %v, want %v", err, tt.wantErr)
+
+			var count int64
+			require.NoError(t, db.Model(&agentModel.Agent{}).Count(&count).Error)
+			assert.Zero(t, count)
+		})
+	}
+}
+
+func TestEnrollAgent_AutoTag(t *testing.T) {
+	svc, db := newEnrollTestService(t, config.AgentConfig{TokenSigningKey: testSigningKey})
+	require.NoError(t, db.AutoMigrate(&tagSystemModel.SysTag{}, &tagSystemModel.SysMatchRule{}, &tagSystemModel.SysEntityTag{}))
+	tag := &tagSystemModel.SysTag{Name: "linux-scanner", Category: "agent"}
+	require.NoError(t, db.Create(tag).Error)
+	require.NoError(t, db.Create(&tagSystemModel.SysMatchRule{
+		TagID: tag.ID, EntityType: "agent", IsEnabled: true,
+		RuleJSON: `{"field": "os", "operator": "contains", "value": "linux", "ignore_case": true}`,
+	}).Error)
+	svc.tagService = tag_system.NewTagService(tagSystemRepo.NewTagRepository(db), db)
+	ctx := context.Background()
+
+	// 接入时按 agent 规则自动打标
+	resp, err := svc.EnrollAgent(ctx, agentModel.EnrollRequest{
+		Hostname: "scanner-01", Port: 5772, OS: "Linux", EnrollmentSecret: issueEnrollmentToken(t, svc),
+	})
+	require.NoError(t, err)
+	ids, err := svc.tagService.GetEntityIDsByTagIDs(ctx, "agent", []uint64{tag.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{resp.AgentID}, ids)
+
+	names, err := svc.AutoTagAgent(ctx, resp.AgentID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linux-scanner"}, names)
+
+	// 属性不再命中时重新评估移除自动标签
+	require.NoError(t, db.Model(&agentModel.Agent{}).Where("agent_id = ?", resp.AgentID).Update("os", "windows").Error)
+	names, err = svc.AutoTagAgent(ctx, resp.AgentID)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	ids, err = svc.tagService.GetEntityIDsByTagIDs(ctx, "agent", []uint64{tag.ID})
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = svc.AutoTagAgent(ctx, "missing")
+	assert.Error(t, err)
+}
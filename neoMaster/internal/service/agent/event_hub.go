@@ -0,0 +1,135 @@
+/**
+ * 服务层:Agent实时事件中心
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 将 Agent 状态/性能指标变化广播给订阅的看板连接(WebSocket)。
+ *               每个订阅者持有独立的有界缓冲区，缓冲区满时丢弃最旧的事件，发布方永远不会被慢客户端阻塞。
+ * @func:
+ *   - Publish 发布事件
+ *   - Subscribe 订阅事件(可按AgentID集合过滤)
+ */
+package agent
+
+import (
+	"sync"
+	"sync/atomic"
+
+	agentModel "neomaster/internal/model/agent"
+)
+
+// defaultEventBufferSize 每个订阅者的默认事件缓冲区大小
+const defaultEventBufferSize = 64
+
+// AgentEventHub Agent事件中心
+// 零值不可用，使用 NewAgentEventHub 创建；nil 指针上的 Publish 为空操作，便于未启用推送时直接调用
+type AgentEventHub struct {
+	mutex       sync.RWMutex
+	subscribers map[*AgentEventSubscription]struct{}
+	bufferSize  int
+}
+
+// NewAgentEventHub 创建事件中心，bufferSize <= 0 时使用默认值
+func NewAgentEventHub(bufferSize int) *AgentEventHub {
+	if bufferSize <= 0 {
+		bufferSize = defaultEventBufferSize
+	}
+	return &AgentEventHub{
+		subscribers: make(map[*AgentEventSubscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// AgentEventSubscription 事件订阅，使用完毕必须调用 Close
+type AgentEventSubscription struct {
+	hub      *AgentEventHub
+	events   chan agentModel.AgentEvent
+	agentIDs map[string]struct{} // nil 表示订阅全部 Agent
+	dropped  atomic.Uint64
+	once     sync.Once
+}
+
+// Subscribe 订阅事件
+// agentIDs 为 nil 时接收全部 Agent 的事件；非 nil(包括空切片)时只接收集合内 Agent 的事件
+func (h *AgentEventHub) Subscribe(agentIDs []string) *AgentEventSubscription {
+	sub := &AgentEventSubscription{
+		hub:    h,
+		events: make(chan agentModel.AgentEvent, h.bufferSize),
+	}
+	if agentIDs != nil {
+		sub.agentIDs = make(map[string]struct{}, len(agentIDs))
+		for _, id := range agentIDs {
+			sub.agentIDs[id] = struct{}{}
+		}
+	}
+
+	h.mutex.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mutex.Unlock()
+	return sub
+}
+
+// Publish 向所有匹配的订阅者广播事件，不会阻塞
+func (h *AgentEventHub) Publish(event agentModel.AgentEvent) {
+	if h == nil {
+		return
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for sub := range h.subscribers {
+		if sub.matches(event.AgentID) {
+			sub.offer(event)
+		}
+	}
+}
+
+// SubscriberCount 当前订阅者数量
+func (h *AgentEventHub) SubscriberCount() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.subscribers)
+}
+
+// Events 事件通道，Close 后关闭
+func (s *AgentEventSubscription) Events() <-chan agentModel.AgentEvent {
+	return s.events
+}
+
+// Dropped 因缓冲区满而丢弃的事件数
+func (s *AgentEventSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close 取消订阅并关闭事件通道，可重复调用
+func (s *AgentEventSubscription) Close() {
+	s.once.Do(func() {
+		// 持有写锁时不会有 Publish 正在向该通道发送，关闭是安全的
+		s.hub.mutex.Lock()
+		delete(s.hub.subscribers, s)
+		close(s.events)
+		s.hub.mutex.Unlock()
+	})
+}
+
+func (s *AgentEventSubscription) matches(agentID string) bool {
+	if s.agentIDs == nil {
+		return true
+	}
+	_, ok := s.agentIDs[agentID]
+	return ok
+}
+
+// offer 非阻塞投递：缓冲区满时丢弃最旧的事件，保证客户端最终看到的是最新状态
+func (s *AgentEventSubscription) offer(event agentModel.AgentEvent) {
+	for {
+		select {
+		case s.events <- event:
+			return
+		default:
+		}
+		select {
+		case <-s.events:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
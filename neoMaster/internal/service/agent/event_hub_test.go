@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	agentModel "neomaster/internal/model/agent"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statusEvent(agentID string, status agentModel.AgentStatus) agentModel.AgentEvent {
+	return agentModel.AgentEvent{Type: agentModel.AgentEventStatus, AgentID: agentID, Status: status}
+}
+
+func drain(sub *AgentEventSubscription) []agentModel.AgentEvent {
+	var events []agentModel.AgentEvent
+	for {
+		select {
+		case e := <-sub.Events():
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestAgentEventHub_Filter(t *testing.T) {
+	hub := NewAgentEventHub(8)
+	all := hub.Subscribe(nil)
+	filtered := hub.Subscribe([]string{"agent-a"})
+	none := hub.Subscribe([]string{}) // 标签未匹配到任何Agent
+	defer all.Close()
+	defer filtered.Close()
+	defer none.Close()
+
+	hub.Publish(statusEvent("agent-a", agentModel.AgentStatusOnline))
+	hub.Publish(statusEvent("agent-b", agentModel.AgentStatusOffline))
+
+	assert.Len(t, drain(all), 2)
+	got := drain(filtered)
+	require.Len(t, got, 1)
+	assert.Equal(t, "agent-a", got[0].AgentID)
+	assert.Empty(t, drain(none))
+}
+
+func TestAgentEventHub_SlowSubscriberDoesNotBlock(t *testing.T) {
+	hub := NewAgentEventHub(2)
+	slow := hub.Subscribe(nil)
+	defer slow.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Publish(statusEvent("agent-a", agentModel.AgentStatusOffline))
+		hub.Publish(statusEvent("agent-a", agentModel.AgentStatusException))
+		hub.Publish(statusEvent("agent-a", agentModel.AgentStatusOnline))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+
+	// 缓冲区满时丢弃最旧事件，保留最新状态
+	got := drain(slow)
+	require.Len(t, got, 2)
+	assert.Equal(t, agentModel.AgentStatusOnline, got[1].Status)
+	assert.Equal(t, uint64(1), slow.Dropped())
+}
+
+func TestAgentEventHub_Close(t *testing.T) {
+	hub := NewAgentEventHub(0)
+	sub := hub.Subscribe(nil)
+	assert.Equal(t, 1, hub.SubscriberCount())
+
+	sub.Close()
+	sub.Close() // 重复关闭不会 panic
+	assert.Equal(t, 0, hub.SubscriberCount())
+	_, ok := <-sub.Events()
+	assert.False(t, ok, "events channel should be closed")
+
+	// 取消订阅后发布不会向已关闭的通道发送
+	hub.Publish(statusEvent("agent-a", agentModel.AgentStatusOnline))
+
+	var nilHub *AgentEventHub
+	nilHub.Publish(statusEvent("agent-a", agentModel.AgentStatusOnline))
+}
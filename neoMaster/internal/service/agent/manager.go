@@ -12,12 +12,16 @@ import (
 	"fmt"
 	"neomaster/internal/config"
 	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/system"
 	tagSystemModel "neomaster/internal/model/tag_system"
+	"neomaster/internal/pkg/auth"
 	"neomaster/internal/pkg/logger"
 	"neomaster/internal/pkg/utils"
 	agentRepository "neomaster/internal/repo/mysql/agent"
 	"neomaster/internal/service/tag_system"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // AgentManagerService Agent基础管理服务接口
@@ -38,6 +42,7 @@ type AgentManagerService interface {
 	RemoveAgentTag(req *agentModel.AgentTagRequest) error                                                        // 移除Agent标签
 	GetAgentTags(agentID string) ([]*tagSystemModel.SysTag, error)                                               // 获取Agent所有标签
 	UpdateAgentTags(agentID string, tagIDs []uint64) ([]*tagSystemModel.SysTag, []*tagSystemModel.SysTag, error) // 更新Agent标签
+	AutoTagAgent(ctx context.Context, agentID string) ([]string, error)                                          // 按匹配规则重新评估Agent的自动标签
 
 	// Agent任务支持管理 (替代能力管理)
 	IsValidTaskSupportId(taskID string) bool                              // 判断任务支持ID是否有效
@@ -51,23 +56,83 @@ type AgentManagerService interface {
 	SyncScanTypesToTags(ctx context.Context) error // 同步ScanType到系统标签
 
 	// Auth (Agent 认证服务)
-	GetAgentByToken(token string) (*agentModel.Agent, error) // 根据Token获取Agent
+	GetAgentByToken(token string) (*agentModel.Agent, error)                                                                                                   // 根据Token获取Agent
+	EnrollAgent(ctx context.Context, req agentModel.EnrollRequest) (*agentModel.EnrollResponse, error)                                                         // Agent接入并签发Token
+	CreateEnrollmentToken(ctx context.Context, req agentModel.CreateEnrollmentTokenRequest, createdBy uint) (*agentModel.CreateEnrollmentTokenResponse, error) // 签发一次性接入令牌
+	AuthenticateAgentToken(token string) (*agentModel.Agent, error)                                                                                            // 校验Token(过期返回ErrAgentTokenExpired)
+	RefreshAgentToken(agentID string) (newToken string, expiry time.Time, err error)                                                                           // Token过期前续期
+
+	// Agent版本管理
+	GetAgentsNeedingUpdate(ctx context.Context) ([]*agentModel.AgentUpdateCandidate, error) // 获取版本低于最新版本的在线Agent
+
+	// 依赖注入
+	SetConfigService(configService AgentConfigService) // 注入配置服务，加入分组时继承分组配置
 }
 
 // agentManagerService Agent基础管理服务实现
 type agentManagerService struct {
-	cfg        *config.Config
-	agentRepo  agentRepository.AgentRepository // Agent数据访问层
-	tagService tag_system.TagService           // 标签系统服务
+	cfg          *config.Config
+	agentRepo    agentRepository.AgentRepository // Agent数据访问层
+	tagService   tag_system.TagService           // 标签系统服务
+	tokenManager *auth.AgentJWTManager           // Agent Token 签发(未配置签名密钥时为nil)
+	eventHub     *AgentEventHub                  // 实时事件中心(可为nil)
+	configSvc    AgentConfigService              // 配置服务(可为nil)，用于加入分组时继承分组配置
+	now          func() time.Time                // 时钟(测试可替换)，为nil时使用 time.Now
 }
 
 // NewAgentManagerService 创建Agent基础管理服务实例
 // 遵循依赖注入原则，保持代码的可测试性
-func NewAgentManagerService(cfg *config.Config, agentRepo agentRepository.AgentRepository, tagService tag_system.TagService) AgentManagerService {
+func NewAgentManagerService(cfg *config.Config, agentRepo agentRepository.AgentRepository, tagService tag_system.TagService, eventHub *AgentEventHub) AgentManagerService {
 	return &agentManagerService{
-		cfg:        cfg,
-		agentRepo:  agentRepo,
-		tagService: tagService,
+		cfg:          cfg,
+		agentRepo:    agentRepo,
+		tagService:   tagService,
+		tokenManager: newAgentTokenManager(cfg),
+		eventHub:     eventHub,
+	}
+}
+
+// SetConfigService 注入配置服务
+// 配置服务依赖 Manager 之后创建，因此通过 setter 注入
+func (s *agentManagerService) SetConfigService(configService AgentConfigService) {
+	s.configSvc = configService
+}
+
+// inheritGroupConfig Agent加入分组后继承分组配置
+// 继承失败只记录日志，不影响标签操作本身
+func (s *agentManagerService) inheritGroupConfig(agentID string, groupTagIDs []uint64) {
+	if s.configSvc == nil || len(groupTagIDs) == 0 {
+		return
+	}
+	if err := s.configSvc.InheritGroupConfig(agentID, groupTagIDs); err != nil {
+		logger.Error("继承分组配置失败",
+			"path", "inheritGroupConfig",
+			"operation", "inherit_group_config",
+			"option", "configSvc.InheritGroupConfig",
+			"func_name", "service.agent.manager.inheritGroupConfig",
+			"agent_id", agentID,
+			"group_tag_ids", groupTagIDs,
+			"error", err.Error(),
+		)
+	}
+}
+
+// revertGroupConfig Agent退出分组后撤销分组写入的配置值
+// 撤销失败只记录日志，不影响标签操作本身
+func (s *agentManagerService) revertGroupConfig(agentID string, groupTagIDs []uint64) {
+	if s.configSvc == nil || len(groupTagIDs) == 0 {
+		return
+	}
+	if err := s.configSvc.RevertGroupConfig(agentID, groupTagIDs); err != nil {
+		logger.Error("撤销分组配置失败",
+			"path", "revertGroupConfig",
+			"operation", "revert_group_config",
+			"option", "configSvc.RevertGroupConfig",
+			"func_name", "service.agent.manager.revertGroupConfig",
+			"agent_id", agentID,
+			"group_tag_ids", groupTagIDs,
+			"error", err.Error(),
+		)
 	}
 }
 
@@ -98,28 +163,31 @@ func generateToken() string {
 // convertToAgentInfo 将Agent模型转换为AgentInfo响应
 func convertToAgentInfo(agent *agentModel.Agent) *agentModel.AgentInfo {
 	return &agentModel.AgentInfo{
-		ID:               uint(agent.ID), // 转换类型从uint64到uint
-		AgentID:          agent.AgentID,
-		Hostname:         agent.Hostname,
-		IPAddress:        agent.IPAddress,
-		Port:             agent.Port,
-		Version:          agent.Version,
-		Status:           agent.Status,
-		OS:               agent.OS,
-		Arch:             agent.Arch,
-		CPUCores:         agent.CPUCores,
-		MemoryTotal:      agent.MemoryTotal,
-		DiskTotal:        agent.DiskTotal,
-		TaskSupport:      agent.TaskSupport,
-		Feature:          agent.Feature,
-		Tags:             nil, // Tags 字段已移除，此处设为nil，后续应通过TagService获取
-		LastHeartbeat:    agent.LastHeartbeat,
-		ResultLatestTime: agent.ResultLatestTime,
-		Remark:           agent.Remark,
-		ContainerID:      agent.ContainerID,
-		PID:              agent.PID,
-		CreatedAt:        agent.CreatedAt,
-		UpdatedAt:        agent.UpdatedAt,
+		ID:                uint(agent.ID), // 转换类型从uint64到uint
+		AgentID:           agent.AgentID,
+		Hostname:          agent.Hostname,
+		IPAddress:         agent.IPAddress,
+		Port:              agent.Port,
+		Version:           agent.Version,
+		Status:            agent.Status,
+		OS:                agent.OS,
+		Arch:              agent.Arch,
+		CPUCores:          agent.CPUCores,
+		MemoryTotal:       agent.MemoryTotal,
+		DiskTotal:         agent.DiskTotal,
+		TaskSupport:       agent.TaskSupport,
+		Feature:           agent.Feature,
+		Tags:              nil, // Tags 字段已移除，此处设为nil，后续应通过TagService获取
+		LastHeartbeat:     agent.LastHeartbeat,
+		ResultLatestTime:  agent.ResultLatestTime,
+		HeartbeatInterval: agent.HeartbeatInterval,
+		OfflineThreshold:  agent.OfflineThreshold,
+		RunningTasks:      agent.RunningTasks,
+		Remark:            agent.Remark,
+		ContainerID:       agent.ContainerID,
+		PID:               agent.PID,
+		CreatedAt:         agent.CreatedAt,
+		UpdatedAt:         agent.UpdatedAt,
 	}
 }
 
@@ -128,6 +196,66 @@ func (s *agentManagerService) GetAgentByToken(token string) (*agentModel.Agent,
 	return s.agentRepo.GetByToken(token)
 }
 
+// GetAgentsNeedingUpdate 获取需要升级的在线Agent
+// 以 is_latest 标记的版本为目标版本，按语义化版本比较，版本号为空或格式非法的Agent跳过
+// 尚未设置最新版本时返回空列表
+func (s *agentManagerService) GetAgentsNeedingUpdate(ctx context.Context) ([]*agentModel.AgentUpdateCandidate, error) {
+	latest, err := s.agentRepo.GetLatestVersion()
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.manager.GetAgentsNeedingUpdate", "", map[string]interface{}{
+			"operation": "get_agents_needing_update",
+			"option":    "agentRepo.GetLatestVersion",
+			"func_name": "service.agent.manager.GetAgentsNeedingUpdate",
+		})
+		return nil, fmt.Errorf("获取最新Agent版本失败: %v", err)
+	}
+	candidates := make([]*agentModel.AgentUpdateCandidate, 0)
+	if latest == nil {
+		return candidates, nil
+	}
+
+	agents, err := s.agentRepo.GetByStatus(agentModel.AgentStatusOnline)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.manager.GetAgentsNeedingUpdate", "", map[string]interface{}{
+			"operation": "get_agents_needing_update",
+			"option":    "agentRepo.GetByStatus",
+			"func_name": "service.agent.manager.GetAgentsNeedingUpdate",
+		})
+		return nil, fmt.Errorf("获取在线Agent列表失败: %v", err)
+	}
+
+	for _, agent := range agents {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if agent.Version == "" {
+			continue
+		}
+		cmp, err := utils.CompareSemver(agent.Version, latest.Version)
+		if err != nil {
+			logger.LogWarn("跳过版本号无法解析的Agent", "", 0, "", "service.agent.manager.GetAgentsNeedingUpdate", "", map[string]interface{}{
+				"operation":      "get_agents_needing_update",
+				"option":         "utils.CompareSemver",
+				"func_name":      "service.agent.manager.GetAgentsNeedingUpdate",
+				"agent_id":       agent.AgentID,
+				"agent_version":  agent.Version,
+				"latest_version": latest.Version,
+				"error":          err.Error(),
+			})
+			continue
+		}
+		if cmp < 0 {
+			candidates = append(candidates, &agentModel.AgentUpdateCandidate{
+				Agent:          convertToAgentInfo(agent),
+				CurrentVersion: agent.Version,
+				TargetVersion:  latest.Version,
+				DownloadURL:    latest.DownloadURL,
+			})
+		}
+	}
+	return candidates, nil
+}
+
 // ========== Agent 基础管理服务 ==========
 
 // validateRegisterRequest 验证Agent注册请求参数
@@ -217,7 +345,8 @@ func (s *agentManagerService) RegisterAgent(req *agentModel.RegisterAgentRequest
 	if req.AgentID != "" && req.Token != "" {
 		existingAgent, err := s.agentRepo.GetByID(req.AgentID)
 		if err == nil && existingAgent != nil {
-			if existingAgent.Token == req.Token {
+			// 过期 Token 不能走快速通道，需出示 Secret 重新注册
+			if existingAgent.Token == req.Token && s.tokenAccepted(existingAgent, s.currentTime()) {
 				isTokenAuthSuccess = true
 				agentToUpdate = existingAgent
 				logger.LogInfo("Agent Token认证成功，进入快速更新模式", "", 0, "", "service.agent.manager.RegisterAgent", "", map[string]interface{}{
@@ -306,14 +435,19 @@ func (s *agentManagerService) RegisterAgent(req *agentModel.RegisterAgentRequest
 
 	// 4. 处理 Token 和执行 DB 操作
 	if agentToUpdate != nil {
+		// 心跳配置由 Master 端维护，重新注册时保留
+		agentData.HeartbeatInterval = agentToUpdate.HeartbeatInterval
+		agentData.OfflineThreshold = agentToUpdate.OfflineThreshold
+		// 基于读取到的版本更新，期间被其他请求修改时返回 ErrConcurrentModification
+		agentData.LockVersion = agentToUpdate.LockVersion
 		if isTokenAuthSuccess {
-			// Update Mode: 复用现有 Token (保持不变)
+			// Update Mode: 复用现有 Token，有效期从本次注册起重新计算(旧版本未设置有效期的 Token 同样补齐)
 			agentData.Token = agentToUpdate.Token
-			agentData.TokenExpiry = agentToUpdate.TokenExpiry
+			agentData.TokenExpiry = s.currentTime().Add(s.tokenTTL())
 		} else {
 			// Overwrite Mode: 必须生成新 Token，因为 Agent 既然走了 Secret 通道，说明它没有(或丢失了)旧 Token
 			agentData.Token = generateToken()
-			agentData.TokenExpiry = time.Now().Add(24 * time.Hour)
+			agentData.TokenExpiry = s.currentTime().Add(s.tokenTTL())
 		}
 
 		// 执行更新
@@ -322,12 +456,12 @@ func (s *agentManagerService) RegisterAgent(req *agentModel.RegisterAgentRequest
 				"operation": "register_agent_update",
 				"agent_id":  agentID,
 			})
-			return nil, fmt.Errorf("更新Agent失败: %v", err)
+			return nil, fmt.Errorf("更新Agent失败: %w", err)
 		}
 	} else {
 		// Create Mode: 生成新 Token
 		agentData.Token = generateToken()
-		agentData.TokenExpiry = time.Now().Add(24 * time.Hour)
+		agentData.TokenExpiry = s.currentTime().Add(s.tokenTTL())
 
 		// 执行创建
 		if err := s.agentRepo.Create(agentData); err != nil {
@@ -384,6 +518,9 @@ func (s *agentManagerService) RegisterAgent(req *agentModel.RegisterAgentRequest
 		}
 	}
 
+	// 自动打标：注册信息可能已变化，按 agent 类型的匹配规则重新评估
+	s.autoTagAgent(context.Background(), agentData)
+
 	logger.LogInfo("Agent注册/更新成功", "", 0, "", "service.agent.manager.RegisterAgent", "", map[string]interface{}{
 		"operation": "register_agent",
 		"option":    "agentManagerService.RegisterAgent",
@@ -398,6 +535,8 @@ func (s *agentManagerService) RegisterAgent(req *agentModel.RegisterAgentRequest
 		TokenExpiry: agentData.TokenExpiry,
 		Status:      "registered",
 		Message:     "Agent注册成功",
+
+		HeartbeatInterval: int(agentData.EffectiveHeartbeatInterval() / time.Second),
 	}, nil
 }
 
@@ -424,8 +563,8 @@ func (s *agentManagerService) GetAgentList(req *agentModel.GetAgentListRequest)
 		keyword = &req.Keyword
 	}
 
-	// 页码 页码大小 状态 关键字 标签 任务支持
-	agents, total, err := s.agentRepo.GetList(req.Page, req.PageSize, status, keyword, req.Tags, req.TaskSupport)
+	// 页码 页码大小 状态 关键字 标签 任务支持 排序(字段由仓储层白名单校验)
+	agents, total, err := s.agentRepo.GetList(req.Page, req.PageSize, status, keyword, req.Tags, req.TaskSupport, req.SortBy, req.SortOrder)
 	if err != nil {
 		logger.LogBusinessError(err, "", 0, "", "service.agent.manager.GetAgentList", "", map[string]interface{}{
 			"operation": "get_agent_list",
@@ -458,9 +597,10 @@ func (s *agentManagerService) GetAgentList(req *agentModel.GetAgentListRequest)
 	return &agentModel.GetAgentListResponse{
 		Agents: agentInfos,
 		Pagination: &agentModel.PaginationResponse{
-			Page:     req.Page,
-			PageSize: req.PageSize,
-			Total:    total,
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: system.CalcTotalPages(total, req.PageSize),
 		},
 	}, nil
 }
@@ -513,6 +653,8 @@ func (s *agentManagerService) UpdateAgentStatus(agentID string, status agentMode
 		return fmt.Errorf("更新Agent状态失败: %v", err)
 	}
 
+	s.eventHub.Publish(agentModel.AgentEvent{Type: agentModel.AgentEventStatus, AgentID: agentID, Status: status, Timestamp: s.currentTime()})
+
 	logger.LogInfo("Agent状态更新成功", "", 0, "", "service.agent.manager.UpdateAgentStatus", "", map[string]interface{}{
 		"operation": "update_agent_status",
 		"option":    "agentManagerService.UpdateAgentStatus",
@@ -595,7 +737,7 @@ func (s *agentManagerService) AddAgentTag(req *agentModel.AgentTagRequest) error
 	ctx := context.Background()
 
 	// 验证 TagID 是否存在
-	_, err := s.tagService.GetTag(ctx, req.TagID)
+	tag, err := s.tagService.GetTag(ctx, req.TagID)
 	if err != nil {
 		logger.Error("标签不存在",
 			"path", "AddAgentTag",
@@ -627,6 +769,11 @@ func (s *agentManagerService) AddAgentTag(req *agentModel.AgentTagRequest) error
 		return fmt.Errorf("添加Agent标签失败: %w", err)
 	}
 
+	// 2. 加入Agent分组时继承分组配置
+	if tag.Category == agentGroupTagCategory {
+		s.inheritGroupConfig(req.AgentID, []uint64{req.TagID})
+	}
+
 	logger.Info("Agent标签添加成功",
 		"path", "AddAgentTag",
 		"operation", "add_agent_tag",
@@ -668,6 +815,11 @@ func (s *agentManagerService) RemoveAgentTag(req *agentModel.AgentTagRequest) er
 		return fmt.Errorf("移除Agent标签失败: %w", err)
 	}
 
+	// 2. 退出Agent分组时撤销分组写入的配置值
+	if tag, tagErr := s.tagService.GetTag(ctx, req.TagID); tagErr == nil && tag != nil && tag.Category == agentGroupTagCategory {
+		s.revertGroupConfig(req.AgentID, []uint64{req.TagID})
+	}
+
 	logger.Info("Agent标签移除成功",
 		"path", "RemoveAgentTag",
 		"operation", "remove_agent_tag",
@@ -734,6 +886,7 @@ func (s *agentManagerService) UpdateAgentTags(agentID string, tagIDs []uint64) (
 	}
 
 	// 验证所有 TagID 是否存在
+	var validTags []tagSystemModel.SysTag
 	if len(tagIDs) > 0 {
 		// 去重 tagIDs
 		uniqueIDs := make(map[uint64]bool)
@@ -741,7 +894,8 @@ func (s *agentManagerService) UpdateAgentTags(agentID string, tagIDs []uint64) (
 			uniqueIDs[id] = true
 		}
 
-		validTags, err1 := s.tagService.GetTagsByIDs(ctx, tagIDs)
+		var err1 error
+		validTags, err1 = s.tagService.GetTagsByIDs(ctx, tagIDs)
 		if err1 != nil {
 			return nil, nil, fmt.Errorf("验证标签失败: %v", err1)
 		}
@@ -769,6 +923,34 @@ func (s *agentManagerService) UpdateAgentTags(agentID string, tagIDs []uint64) (
 		return nil, nil, fmt.Errorf("同步标签失败: %v", err)
 	}
 
+	// 同步后已不再关联的Agent分组撤销其写入的配置值 (非手动来源的标签不受同步影响，以同步后的实际标签为准)
+	if currentTags, err := s.GetAgentTags(agentID); err == nil {
+		current := make(map[uint64]bool, len(currentTags))
+		for _, t := range currentTags {
+			current[t.ID] = true
+		}
+		var leftGroups []uint64
+		for _, t := range oldTags {
+			if t.Category == agentGroupTagCategory && !current[t.ID] {
+				leftGroups = append(leftGroups, t.ID)
+			}
+		}
+		s.revertGroupConfig(agentID, leftGroups)
+	}
+
+	// 新加入的Agent分组继承分组配置 (已在分组中的不重复应用，避免覆盖Agent单独配置)
+	oldTagIDs := make(map[uint64]bool, len(oldTags))
+	for _, t := range oldTags {
+		oldTagIDs[t.ID] = true
+	}
+	var joinedGroups []uint64
+	for _, t := range validTags {
+		if t.Category == agentGroupTagCategory && !oldTagIDs[t.ID] {
+			joinedGroups = append(joinedGroups, t.ID)
+		}
+	}
+	s.inheritGroupConfig(agentID, joinedGroups)
+
 	// 3. 获取新标签 - 用于返回
 	var newTags []*tagSystemModel.SysTag
 	if len(tagIDs) > 0 {
@@ -787,6 +969,81 @@ func (s *agentManagerService) UpdateAgentTags(agentID string, tagIDs []uint64) (
 	return oldTags, newTags, nil
 }
 
+// agentTagEntityType Agent 在标签系统中的实体类型，匹配规则的 entity_type 也使用该值
+const agentTagEntityType = "agent"
+
+// agentTagAttributes 生成Agent用于规则匹配的属性，字段名与 Agent 的 JSON 字段一致
+// 例如规则 {"field":"os","operator":"contains","value":"linux"} 或 {"field":"task_support","operator":"list_contains","value":"portScan"}
+func agentTagAttributes(agent *agentModel.Agent) map[string]interface{} {
+	return map[string]interface{}{
+		"agent_id":     agent.AgentID,
+		"hostname":     agent.Hostname,
+		"ip_address":   agent.IPAddress,
+		"port":         agent.Port,
+		"version":      agent.Version,
+		"status":       string(agent.Status),
+		"os":           agent.OS,
+		"arch":         agent.Arch,
+		"cpu_cores":    agent.CPUCores,
+		"memory_total": agent.MemoryTotal,
+		"disk_total":   agent.DiskTotal,
+		"task_support": []string(agent.TaskSupport),
+		"feature":      []string(agent.Feature),
+		"remark":       agent.Remark,
+	}
+}
+
+// autoTagAgent 按匹配规则对账Agent的自动标签
+// 注册/接入流程中调用，失败只记录日志，不影响注册本身
+func (s *agentManagerService) autoTagAgent(ctx context.Context, agent *agentModel.Agent) {
+	if s.tagService == nil {
+		return
+	}
+	if _, err := s.tagService.AutoTag(ctx, agentTagEntityType, agent.AgentID, agentTagAttributes(agent)); err != nil {
+		logger.LogError(err, "", 0, "", "service.agent.manager.autoTagAgent", "AutoTag", map[string]interface{}{
+			"operation": "auto_tag_agent",
+			"agent_id":  agent.AgentID,
+		})
+	}
+}
+
+// AutoTagAgent 按匹配规则重新评估Agent的自动标签
+// 匹配规则变更后可手动触发；只增删 auto 来源的标签，手动标签不受影响。返回命中的标签名称
+func (s *agentManagerService) AutoTagAgent(ctx context.Context, agentID string) ([]string, error) {
+	if agentID == "" {
+		return nil, fmt.Errorf("agent ID不能为空")
+	}
+	agent, err := s.agentRepo.GetByID(agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagService.AutoTag(ctx, agentTagEntityType, agentID, agentTagAttributes(agent))
+	if err != nil {
+		logger.Error("Agent自动打标失败",
+			"path", "AutoTagAgent",
+			"operation", "auto_tag_agent",
+			"option", "tagService.AutoTag",
+			"func_name", "service.agent.manager.AutoTagAgent",
+			"agent_id", agentID,
+			"error", err.Error(),
+		)
+		return nil, fmt.Errorf("Agent自动打标失败: %w", err)
+	}
+	return tags, nil
+}
+
+// RegisterAgentRetagSource 向标签服务注册Agent的批量重打标数据来源
+// 匹配属性与注册/接入时的自动打标一致；已软删除的Agent不参与
+func RegisterAgentRetagSource(tagService tag_system.TagService, db *gorm.DB) {
+	if tagService == nil {
+		return
+	}
+	tagService.RegisterRetagSource(agentTagEntityType, tag_system.NewModelRetagSource(db, func(agent *agentModel.Agent) tag_system.RetagEntity {
+		return tag_system.RetagEntity{ID: agent.ID, EntityID: agent.AgentID, Attributes: agentTagAttributes(agent)}
+	}))
+}
+
 // ============================================================================
 // Agent 任务支持管理模块 (TaskSupport) - 新增
 // ============================================================================
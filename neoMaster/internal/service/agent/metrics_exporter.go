@@ -0,0 +1,131 @@
+/**
+ * 服务层:Agent性能指标 Prometheus 导出
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 每次抓取时批量读取在线Agent的最新快照，按 agent_id 标签输出 Prometheus 文本格式的 gauge。
+ *               快照超过有效期(或从未上报)的Agent只输出 neoscan_agent_metrics_stale=1，不输出过期的负载数值，
+ *               避免告警基于旧数据误判。
+ * @func:
+ *   - NewAgentMetricsExporter 创建导出器
+ *   - WriteMetrics 输出 Prometheus 文本格式指标
+ */
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+)
+
+// defaultMetricsStaleAfter 未配置 app.master.dispatch.metrics_max_age 时的快照有效期
+const defaultMetricsStaleAfter = 180 * time.Second
+
+// AgentMetricsSource 导出器所需的数据访问能力 (AgentRepository 已实现)
+type AgentMetricsSource interface {
+	GetOnlineAgentMetricsSamples(ctx context.Context) ([]*agentModel.AgentMetricsSample, error)
+}
+
+// AgentMetricsExporter Agent性能指标导出器
+type AgentMetricsExporter struct {
+	source     AgentMetricsSource
+	staleAfter time.Duration
+	now        func() time.Time
+}
+
+// NewAgentMetricsExporter 创建导出器
+// 快照有效期与任务分发一致，取 app.master.dispatch.metrics_max_age
+func NewAgentMetricsExporter(source AgentMetricsSource, cfg *config.Config) *AgentMetricsExporter {
+	e := &AgentMetricsExporter{
+		source:     source,
+		staleAfter: defaultMetricsStaleAfter,
+		now:        time.Now,
+	}
+	if cfg != nil && cfg.App.Master.Dispatch.MetricsMaxAge > 0 {
+		e.staleAfter = time.Duration(cfg.App.Master.Dispatch.MetricsMaxAge) * time.Second
+	}
+	return e
+}
+
+// metricFamily 一个 gauge 指标族
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	agentID string
+	value   float64
+}
+
+// WriteMetrics 输出 Prometheus 文本格式(0.0.4)指标
+// 查询失败时不写入任何内容，由调用方返回错误状态码，Prometheus 会将本次抓取记为失败
+func (e *AgentMetricsExporter) WriteMetrics(ctx context.Context, w io.Writer) error {
+	samples, err := e.source.GetOnlineAgentMetricsSamples(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := e.now()
+	cpu := metricFamily{name: "neoscan_agent_cpu_usage_percent", help: "Agent CPU usage percent from the latest metrics snapshot."}
+	memory := metricFamily{name: "neoscan_agent_memory_usage_percent", help: "Agent memory usage percent from the latest metrics snapshot."}
+	running := metricFamily{name: "neoscan_agent_running_tasks", help: "Number of tasks currently running on the agent."}
+	failed := metricFamily{name: "neoscan_agent_failed_tasks", help: "Number of failed tasks reported by the agent."}
+	age := metricFamily{name: "neoscan_agent_metrics_age_seconds", help: "Seconds since the agent's latest metrics snapshot."}
+	stale := metricFamily{name: "neoscan_agent_metrics_stale", help: "1 if the online agent has no metrics snapshot within the staleness threshold."}
+
+	for _, s := range samples {
+		if s.Timestamp == nil {
+			stale.samples = append(stale.samples, metricSample{s.AgentID, 1})
+			continue
+		}
+		snapshotAge := now.Sub(*s.Timestamp)
+		age.samples = append(age.samples, metricSample{s.AgentID, snapshotAge.Seconds()})
+		if snapshotAge > e.staleAfter {
+			stale.samples = append(stale.samples, metricSample{s.AgentID, 1})
+			continue
+		}
+		stale.samples = append(stale.samples, metricSample{s.AgentID, 0})
+		cpu.samples = append(cpu.samples, metricSample{s.AgentID, floatValue(s.CPUUsage)})
+		memory.samples = append(memory.samples, metricSample{s.AgentID, floatValue(s.MemoryUsage)})
+		running.samples = append(running.samples, metricSample{s.AgentID, intValue(s.RunningTasks)})
+		failed.samples = append(failed.samples, metricSample{s.AgentID, intValue(s.FailedTasks)})
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, family := range []metricFamily{cpu, memory, running, failed, age, stale} {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name)
+		for _, sample := range family.samples {
+			fmt.Fprintf(bw, "%s{agent_id=\"%s\"} %s\n", family.name, escapeLabelValue(sample.agentID), strconv.FormatFloat(sample.value, 'g', -1, 64))
+		}
+	}
+	return bw.Flush()
+}
+
+func floatValue(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func intValue(v *int) float64 {
+	if v == nil {
+		return 0
+	}
+	return float64(*v)
+}
+
+// labelValueEscaper 按文本格式规范转义标签值中的反斜杠、双引号与换行
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+	agentRepository "neomaster/internal/repo/mysql/agent"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAgentMetricsExporter_WriteMetrics(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&agentModel.Agent{}, &agentModel.AgentMetrics{}))
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	agents := []*agentModel.Agent{
+		{AgentID: "fresh", Hostname: "h1", Port: 1, Status: agentModel.AgentStatusOnline},
+		{AgentID: "stale", Hostname: "h2", Port: 2, Status: agentModel.AgentStatusOnline},
+		{AgentID: "silent", Hostname: "h3", Port: 3, Status: agentModel.AgentStatusOnline},
+		{AgentID: "offline", Hostname: "h4", Port: 4, Status: agentModel.AgentStatusOffline},
+		{AgentID: "deleted", Hostname: "h5", Port: 5, Status: agentModel.AgentStatusOnline},
+	}
+	require.NoError(t, db.Create(&agents).Error)
+	require.NoError(t, db.Delete(&agentModel.Agent{}, "agent_id = ?", "deleted").Error)
+	metrics := []*agentModel.AgentMetrics{
+		{AgentID: "fresh", CPUUsage: 87.5, MemoryUsage: 40, RunningTasks: 3, FailedTasks: 1, Timestamp: now.Add(-30 * time.Second)},
+		{AgentID: "stale", CPUUsage: 99, MemoryUsage: 99, RunningTasks: 9, Timestamp: now.Add(-10 * time.Minute)},
+		{AgentID: "offline", CPUUsage: 50, Timestamp: now},
+		{AgentID: "deleted", CPUUsage: 50, Timestamp: now},
+	}
+	require.NoError(t, db.Create(&metrics).Error)
+
+	cfg := &config.Config{}
+	cfg.App.Master.Dispatch.MetricsMaxAge = 60
+	exporter := NewAgentMetricsExporter(agentRepository.NewAgentRepository(db), cfg)
+	exporter.now = func() time.Time { return now }
+
+	var buf bytes.Buffer
+	require.NoError(t, exporter.WriteMetrics(context.Background(), &buf))
+	out := buf.String()
+
+	// 有效期内的快照输出负载数值
+	assert.Contains(t, out, "# TYPE neoscan_agent_cpu_usage_percent gauge\n")
+	assert.Contains(t, out, `neoscan_agent_cpu_usage_percent{agent_id="fresh"} 87.5`)
+	assert.Contains(t, out, `neoscan_agent_memory_usage_percent{agent_id="fresh"} 40`)
+	assert.Contains(t, out, `neoscan_agent_running_tasks{agent_id="fresh"} 3`)
+	assert.Contains(t, out, `neoscan_agent_failed_tasks{agent_id="fresh"} 1`)
+	assert.Contains(t, out, `neoscan_agent_metrics_age_seconds{agent_id="fresh"} 30`)
+	assert.Contains(t, out, `neoscan_agent_metrics_stale{agent_id="fresh"} 0`)
+
+	// 过期快照与从未上报的Agent只输出过期标记
+	assert.Contains(t, out, `neoscan_agent_metrics_stale{agent_id="stale"} 1`)
+	assert.Contains(t, out, `neoscan_agent_metrics_age_seconds{agent_id="stale"} 600`)
+	assert.NotContains(t, out, `neoscan_agent_cpu_usage_percent{agent_id="stale"}`)
+	assert.Contains(t, out, `neoscan_agent_metrics_stale{agent_id="silent"} 1`)
+	assert.NotContains(t, out, `neoscan_agent_running_tasks{agent_id="silent"}`)
+
+	// 离线与已删除的Agent不导出
+	assert.NotContains(t, out, `agent_id="offline"`)
+	assert.NotContains(t, out, `agent_id="deleted"`)
+}
+
+type failingMetricsSource struct{}
+
+func (failingMetricsSource) GetOnlineAgentMetricsSamples(context.Context) ([]*agentModel.AgentMetricsSample, error) {
+	return nil, errors.New("db down")
+}
+
+func TestAgentMetricsExporter_SourceError(t *testing.T) {
+	exporter := NewAgentMetricsExporter(failingMetricsSource{}, nil)
+	var buf bytes.Buffer
+	assert.Error(t, exporter.WriteMetrics(context.Background(), &buf))
+	assert.Zero(t, buf.Len())
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+}
@@ -23,13 +23,19 @@ import (
 // 专门负责Agent的监控相关功能，遵循单一职责原则
 type AgentMonitorService interface {
 	// Agent 心跳和状态监控
-	ProcessHeartbeat(req *agentModel.HeartbeatRequest) (*agentModel.HeartbeatResponse, error)                                                                                                        // 处理Agent发送过来的心跳，更新状态和指标
-	GetAgentMetricsFromDB(agentID string) (*agentModel.AgentMetricsResponse, error)                                                                                                                  // 从数据库获取Agent最新的性能指标
-	GetAgentListAllMetricsFromDB(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string) ([]*agentModel.AgentMetricsResponse, int64, error) // 从数据库分页获取Agent的最新性能指标（支持状态与关键词过滤）
-	PullAgentMetrics(agentID string) (*agentModel.AgentMetricsResponse, error)                                                                                                                       // 从Agent端拉取最新的性能指标
-	PullAgentListAllMetrics() ([]*agentModel.AgentMetricsResponse, error)                                                                                                                            // 从Agent端拉取所有Agent的最新性能指标
-	CreateAgentMetrics(agentID string, metrics *agentModel.AgentMetrics) error                                                                                                                       // 创建Agent性能指标
-	UpdateAgentMetrics(agentID string, metrics *agentModel.AgentMetrics) error                                                                                                                       // 更新Agent性能指标
+	ProcessHeartbeat(req *agentModel.HeartbeatRequest) (*agentModel.HeartbeatResponse, error)  // 处理Agent发送过来的心跳，更新状态和指标
+	GetAgentMetricsFromDB(agentID string) (*agentModel.AgentMetricsResponse, error)            // 从数据库获取Agent最新的性能指标
+	MarkStaleAgentsOffline(now time.Time) ([]string, error)                                    // 按各Agent自身离线阈值将心跳超时的Agent标记为离线
+	UpdateHeartbeatSettings(agentID string, req *agentModel.UpdateAgentHeartbeatRequest) error // 更新Agent心跳间隔与离线阈值
+	// 从数据库分页获取Agent的最新性能指标（支持状态与关键词过滤、排序）
+	GetAgentListAllMetricsFromDB(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string, sortBy, sortOrder string) ([]*agentModel.AgentMetricsResponse, int64, error)
+	PullAgentMetrics(agentID string) (*agentModel.AgentMetricsResponse, error) // 从Agent端拉取最新的性能指标
+	PullAgentListAllMetrics() ([]*agentModel.AgentMetricsResponse, error)      // 从Agent端拉取所有Agent的最新性能指标
+	CreateAgentMetrics(agentID string, metrics *agentModel.AgentMetrics) error // 创建Agent性能指标
+	UpdateAgentMetrics(agentID string, metrics *agentModel.AgentMetrics) error // 更新Agent性能指标
+
+	// Agent 实时事件订阅 (agentIDs/tagIDs 均为空时订阅全部Agent)
+	SubscribeAgentEvents(ctx context.Context, agentIDs []string, tagIDs []uint64) (*AgentEventSubscription, error)
 
 	// Agent 数据分析 (可按标签聚合)
 	GetAgentStatistics(windowSeconds int, tagIDs []uint64) (*agentModel.AgentStatisticsResponse, error)                                              // 获取Agent统计信息
@@ -43,15 +49,19 @@ type agentMonitorService struct {
 	agentRepo     agentRepository.AgentRepository // Agent数据访问层
 	tagService    tag_system.TagService           // Tag服务
 	updateService AgentUpdateService              // 规则更新服务,用于获取规则版本信息返回给Agent
+	configService AgentConfigService              // 配置服务,用于心跳时的配置版本对账
+	eventHub      *AgentEventHub                  // 实时事件中心,心跳/指标更新时向看板推送
 }
 
 // NewAgentMonitorService 创建Agent监控服务实例
 // 遵循依赖注入原则，保持代码的可测试性
-func NewAgentMonitorService(agentRepo agentRepository.AgentRepository, tagService tag_system.TagService, updateService AgentUpdateService) AgentMonitorService {
+func NewAgentMonitorService(agentRepo agentRepository.AgentRepository, tagService tag_system.TagService, updateService AgentUpdateService, configService AgentConfigService, eventHub *AgentEventHub) AgentMonitorService {
 	return &agentMonitorService{
 		agentRepo:     agentRepo,
 		tagService:    tagService,
 		updateService: updateService,
+		configService: configService,
+		eventHub:      eventHub,
 	}
 }
 
@@ -71,13 +81,21 @@ func (s *agentMonitorService) ProcessHeartbeat(req *agentModel.HeartbeatRequest)
 		})
 		return nil, err
 	}
+	s.eventHub.Publish(agentModel.AgentEvent{Type: agentModel.AgentEventStatus, AgentID: req.AgentID, Status: req.Status, Timestamp: time.Now()})
 
 	// 更新最后心跳时间 - agents 表 (同时更新 updated_at 和 last_heartbeat 字段)
-	err = s.agentRepo.UpdateLastHeartbeat(req.AgentID)
+	// 携带运行中任务进度时在同一条 UPDATE 中写入 running_tasks；旧版本Agent不携带，只更新时间
+	option := "agentRepo.UpdateLastHeartbeat"
+	if req.RunningTasks != nil {
+		option = "agentRepo.UpdateHeartbeatProgress"
+		err = s.agentRepo.UpdateHeartbeatProgress(req.AgentID, req.RunningTasks)
+	} else {
+		err = s.agentRepo.UpdateLastHeartbeat(req.AgentID)
+	}
 	if err != nil {
 		logger.LogBusinessError(err, "", 0, "", "service.agent.monitor.ProcessHeartbeat", "", map[string]interface{}{
 			"operation": "process_heartbeat",
-			"option":    "agentRepo.UpdateLastHeartbeat",
+			"option":    option,
 			"func_name": "service.agent.monitor.ProcessHeartbeat",
 			"agent_id":  req.AgentID,
 		})
@@ -101,6 +119,7 @@ func (s *agentMonitorService) ProcessHeartbeat(req *agentModel.HeartbeatRequest)
 			})
 			return nil, err
 		}
+		s.eventHub.Publish(agentModel.AgentEvent{Type: agentModel.AgentEventMetrics, AgentID: req.AgentID, Metrics: req.Metrics, Timestamp: time.Now()})
 
 		logger.LogInfo("Agent性能指标更新成功", "", 0, "", "service.agent.monitor.ProcessHeartbeat", "", map[string]interface{}{
 			"operation": "process_heartbeat",
@@ -142,9 +161,71 @@ func (s *agentMonitorService) ProcessHeartbeat(req *agentModel.HeartbeatRequest)
 		RuleVersions: ruleVersions, // 规则版本信息
 	}
 
+	// 配置版本对账：Agent 上报版本落后时随心跳响应下发最新配置
+	// 对账失败不影响心跳本身，下次心跳重试
+	if req.ConfigVersion != nil && s.configService != nil {
+		cfg, cfgErr := s.configService.ReconcileConfig(req.AgentID, *req.ConfigVersion)
+		if cfgErr != nil {
+			logger.LogBusinessError(cfgErr, "", 0, "", "service.agent.monitor.ProcessHeartbeat", "", map[string]interface{}{
+				"operation": "process_heartbeat",
+				"option":    "configService.ReconcileConfig",
+				"func_name": "service.agent.monitor.ProcessHeartbeat",
+				"agent_id":  req.AgentID,
+			})
+		}
+		response.Config = cfg
+	}
+
 	return response, nil
 }
 
+// MarkStaleAgentsOffline 离线巡检：按各Agent自身的离线阈值判定心跳超时，标记为离线并推送状态事件
+// 由后台定时任务周期调用，now 为本次巡检时间
+func (s *agentMonitorService) MarkStaleAgentsOffline(now time.Time) ([]string, error) {
+	marked, err := s.agentRepo.MarkStaleAgentsOffline(now)
+	for _, agentID := range marked {
+		s.eventHub.Publish(agentModel.AgentEvent{Type: agentModel.AgentEventStatus, AgentID: agentID, Status: agentModel.AgentStatusOffline, Timestamp: now})
+	}
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.monitor.MarkStaleAgentsOffline", "", map[string]interface{}{
+			"operation": "mark_stale_agents_offline",
+			"option":    "agentRepo.MarkStaleAgentsOffline",
+			"func_name": "service.agent.monitor.MarkStaleAgentsOffline",
+		})
+		return marked, err
+	}
+	if len(marked) > 0 {
+		logger.LogInfo("心跳超时Agent已标记为离线", "", 0, "", "service.agent.monitor.MarkStaleAgentsOffline", "", map[string]interface{}{
+			"operation": "mark_stale_agents_offline",
+			"option":    "agentRepo.MarkStaleAgentsOffline",
+			"func_name": "service.agent.monitor.MarkStaleAgentsOffline",
+			"agent_ids": marked,
+		})
+	}
+	return marked, nil
+}
+
+// UpdateHeartbeatSettings 更新Agent心跳间隔与离线阈值
+// 新的心跳间隔在Agent下次注册/接入时下发
+func (s *agentMonitorService) UpdateHeartbeatSettings(agentID string, req *agentModel.UpdateAgentHeartbeatRequest) error {
+	if req.OfflineThreshold > 0 {
+		probe := agentModel.Agent{HeartbeatInterval: req.HeartbeatInterval}
+		if time.Duration(req.OfflineThreshold)*time.Second <= probe.EffectiveHeartbeatInterval() {
+			return fmt.Errorf("invalid offline_threshold: must be greater than heartbeat interval")
+		}
+	}
+	if err := s.agentRepo.UpdateHeartbeatSettings(agentID, req.HeartbeatInterval, req.OfflineThreshold); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.monitor.UpdateHeartbeatSettings", "", map[string]interface{}{
+			"operation": "update_heartbeat_settings",
+			"option":    "agentRepo.UpdateHeartbeatSettings",
+			"func_name": "service.agent.monitor.UpdateHeartbeatSettings",
+			"agent_id":  agentID,
+		})
+		return err
+	}
+	return nil
+}
+
 // GetAgentMetricsFromDB 获取指定Agent性能指标服务 - 从数据库表 agent_metrics 查询
 func (s *agentMonitorService) GetAgentMetricsFromDB(agentID string) (*agentModel.AgentMetricsResponse, error) {
 	// 输入校验：agentID不能为空
@@ -215,7 +296,7 @@ func (s *agentMonitorService) GetAgentMetricsFromDB(agentID string) (*agentModel
 }
 
 // GetAgentListAllMetricsFromDB 获取所有Agent性能指标服务 - 从数据表 agent_metrics 批量查询
-func (s *agentMonitorService) GetAgentListAllMetricsFromDB(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string) ([]*agentModel.AgentMetricsResponse, int64, error) {
+func (s *agentMonitorService) GetAgentListAllMetricsFromDB(page, pageSize int, workStatus *agentModel.AgentWorkStatus, scanType *agentModel.AgentScanType, keyword *string, sortBy, sortOrder string) ([]*agentModel.AgentMetricsResponse, int64, error) {
 	// 输入校验与默认值处理（防御性编程）
 	if page <= 0 {
 		page = 1
@@ -225,7 +306,7 @@ func (s *agentMonitorService) GetAgentListAllMetricsFromDB(page, pageSize int, w
 	}
 
 	// 直接通过仓储层对 agent_metrics 表进行分页查询（单快照模型，避免N+1与全量加载）
-	metricsList, total, err := s.agentRepo.GetMetricsList(page, pageSize, workStatus, scanType, keyword)
+	metricsList, total, err := s.agentRepo.GetMetricsList(page, pageSize, workStatus, scanType, keyword, sortBy, sortOrder)
 	if err != nil {
 		logger.LogBusinessError(err, "", 0, "", "service.agent.monitor.GetAgentListAllMetricsFromDB", "", map[string]interface{}{
 			"operation": "get_agent_list_all_metrics_from_db",
@@ -369,6 +450,7 @@ func (s *agentMonitorService) UpdateAgentMetrics(agentID string, metrics *agentM
 		})
 		return fmt.Errorf("更新Agent性能指标失败: %v", err)
 	}
+	s.eventHub.Publish(agentModel.AgentEvent{Type: agentModel.AgentEventMetrics, AgentID: agentID, Metrics: metrics, Timestamp: time.Now()})
 
 	logger.LogInfo("Agent性能指标更新成功", "", 0, "", "service.agent.monitor.UpdateAgentMetrics", "", map[string]interface{}{
 		"operation": "update_agent_metrics",
@@ -1007,3 +1089,30 @@ func (s *agentMonitorService) GetAgentCapacityAnalysis(windowSeconds int, cpuThr
 	})
 	return resp, nil
 }
+
+// SubscribeAgentEvents 订阅Agent实时事件
+// 指定 tagIDs 时在订阅时刻解析出带有这些标签的Agent，与 agentIDs 合并为过滤集合；两者均为空时订阅全部Agent
+func (s *agentMonitorService) SubscribeAgentEvents(ctx context.Context, agentIDs []string, tagIDs []uint64) (*AgentEventSubscription, error) {
+	if s.eventHub == nil {
+		return nil, fmt.Errorf("agent event hub is not enabled")
+	}
+	if len(agentIDs) == 0 && len(tagIDs) == 0 {
+		return s.eventHub.Subscribe(nil), nil
+	}
+
+	filter := append([]string{}, agentIDs...)
+	if len(tagIDs) > 0 {
+		taggedIDs, err := s.tagService.GetEntityIDsByTagIDs(ctx, "agent", tagIDs)
+		if err != nil {
+			logger.LogBusinessError(err, "", 0, "", "service.agent.monitor.SubscribeAgentEvents", "", map[string]interface{}{
+				"operation": "subscribe_agent_events",
+				"option":    "tagService.GetEntityIDsByTagIDs",
+				"func_name": "service.agent.monitor.SubscribeAgentEvents",
+				"tag_ids":   tagIDs,
+			})
+			return nil, fmt.Errorf("按标签解析Agent失败: %v", err)
+		}
+		filter = append(filter, taggedIDs...)
+	}
+	return s.eventHub.Subscribe(filter), nil
+}
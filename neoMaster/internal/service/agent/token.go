@@ -0,0 +1,143 @@
+/**
+ * 服务层:Agent Token 校验与刷新
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: Agent 认证中间件通过 AuthenticateAgentToken 校验 Token，过期 Token 返回 ErrAgentTokenExpired，
+ *               Agent 收到该错误后需重新接入(EnrollAgent)；Token 过期前可调用 RefreshAgentToken 续期。
+ * @func:
+ *   - AuthenticateAgentToken 校验Agent Token(存在性+有效期)
+ *   - RefreshAgentToken 为在线Agent签发新Token
+ */
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidAgentToken Token 不存在(或已被轮换)
+	ErrInvalidAgentToken = errors.New("invalid agent token")
+	// ErrAgentTokenExpired Token 已过期，Agent 需要重新接入
+	ErrAgentTokenExpired = errors.New("agent token expired, re-enrollment required")
+	// ErrAgentNotOnline 仅在线 Agent 可以刷新 Token
+	ErrAgentNotOnline = errors.New("agent is not online")
+)
+
+// currentTime 当前时间(支持测试替换时钟)
+func (s *agentManagerService) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// tokenActive Token 在 now 时刻是否仍有效；未设置过期时间的视为已过期
+func tokenActive(agent *agentModel.Agent, now time.Time) bool {
+	return !agent.TokenExpiry.IsZero() && now.Before(agent.TokenExpiry)
+}
+
+// tokenExpiryEnforced 是否拒绝过期 Token(security.agent.enforce_token_expiry)
+func (s *agentManagerService) tokenExpiryEnforced() bool {
+	return s.cfg != nil && s.cfg.Security.Agent.EnforceTokenExpiry
+}
+
+// tokenAccepted Token 在 now 时刻是否可用
+// 未开启过期校验时，过期及未设置有效期的旧 Token 仍然可用，保证未升级续期逻辑的 Agent 不被批量踢下线
+func (s *agentManagerService) tokenAccepted(agent *agentModel.Agent, now time.Time) bool {
+	return tokenActive(agent, now) || !s.tokenExpiryEnforced()
+}
+
+// tokenTTL Agent Token 有效期，未配置时使用 defaultAgentTokenTTL
+func (s *agentManagerService) tokenTTL() time.Duration {
+	if s.cfg != nil && s.cfg.Security.Agent.TokenTTL > 0 {
+		return s.cfg.Security.Agent.TokenTTL
+	}
+	return defaultAgentTokenTTL
+}
+
+// AuthenticateAgentToken 校验 Agent Token，返回 Token 对应的 Agent
+// 已软删除的 Agent 查询不到，按无效 Token 处理
+func (s *agentManagerService) AuthenticateAgentToken(token string) (*agentModel.Agent, error) {
+	if token == "" {
+		return nil, ErrInvalidAgentToken
+	}
+	agent, err := s.agentRepo.GetByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if agent == nil {
+		return nil, ErrInvalidAgentToken
+	}
+	now := s.currentTime()
+	if !tokenActive(agent, now) {
+		if s.tokenExpiryEnforced() {
+			return nil, ErrAgentTokenExpired
+		}
+		logger.LogWarn("Agent Token已过期，未开启过期校验，继续放行", "", 0, "", "service.agent.token.AuthenticateAgentToken", "", map[string]interface{}{
+			"agent_id":     agent.AgentID,
+			"token_expiry": agent.TokenExpiry,
+		})
+	}
+	return agent, nil
+}
+
+// RefreshAgentToken 在 Token 过期前为 Agent 签发新 Token，旧 Token 随即失效
+// 仅在线且未删除的 Agent 可以刷新；开启过期校验且 Token 已过期时返回 ErrAgentTokenExpired，需重新接入
+func (s *agentManagerService) RefreshAgentToken(agentID string) (string, time.Time, error) {
+	if s.tokenManager == nil {
+		return "", time.Time{}, ErrEnrollmentNotConfigured
+	}
+
+	agent, err := s.agentRepo.GetByID(agentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", time.Time{}, fmt.Errorf("agent not found")
+		}
+		return "", time.Time{}, err
+	}
+	if agent.Status != agentModel.AgentStatusOnline {
+		return "", time.Time{}, ErrAgentNotOnline
+	}
+
+	now := s.currentTime()
+	if !s.tokenAccepted(agent, now) {
+		return "", time.Time{}, ErrAgentTokenExpired
+	}
+
+	token, expiry, err := s.tokenManager.GenerateToken(agent.AgentID, agent.Hostname, now)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.token.RefreshAgentToken", "", map[string]interface{}{
+			"operation": "refresh_agent_token",
+			"option":    "tokenManager.GenerateToken",
+			"func_name": "service.agent.token.RefreshAgentToken",
+			"agent_id":  agentID,
+		})
+		return "", time.Time{}, fmt.Errorf("签发Agent Token失败: %v", err)
+	}
+
+	if err := s.agentRepo.Update(&agentModel.Agent{AgentID: agent.AgentID, Token: token, TokenExpiry: expiry, LockVersion: agent.LockVersion}); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.agent.token.RefreshAgentToken", "", map[string]interface{}{
+			"operation": "refresh_agent_token",
+			"option":    "agentRepo.Update",
+			"func_name": "service.agent.token.RefreshAgentToken",
+			"agent_id":  agentID,
+		})
+		return "", time.Time{}, fmt.Errorf("保存Agent Token失败: %w", err)
+	}
+
+	logger.LogInfo("Agent Token刷新成功", "", 0, "", "service.agent.token.RefreshAgentToken", "", map[string]interface{}{
+		"operation":    "refresh_agent_token",
+		"option":       "success",
+		"func_name":    "service.agent.token.RefreshAgentToken",
+		"agent_id":     agentID,
+		"token_expiry": expiry,
+	})
+	return token, expiry, nil
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/service/tag_system"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentTokenExpiryAndRefresh(t *testing.T) {
+	svc, _ := newEnrollTestService(t, config.AgentConfig{
+		TokenSigningKey:    testSigningKey,
+		TokenTTL:           time.Hour,
+		EnforceTokenExpiry: true,
+	})
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return clock }
+
+	enrolled, err := svc.EnrollAgent(context.Background(), agentModel.EnrollRequest{Hostname: "scanner-01", Port: 5772, EnrollmentSecret: issueEnrollmentToken(t, svc)})
+	require.NoError(t, err)
+	assert.Equal(t, clock.Add(time.Hour), enrolled.TokenExpiry)
+
+	agent, err := svc.AuthenticateAgentToken(enrolled.Token)
+	require.NoError(t, err)
+	assert.Equal(t, enrolled.AgentID, agent.AgentID)
+
+	// 过期前刷新：新 Token 从当前时间起算有效期，旧 Token 失效
+	clock = clock.Add(50 * time.Minute)
+	newToken, expiry, err := svc.RefreshAgentToken(enrolled.AgentID)
+	require.NoError(t, err)
+	assert.Equal(t, clock.Add(time.Hour), expiry)
+	_, err = svc.AuthenticateAgentToken(enrolled.Token)
+	assert.True(t, errors.Is(err, ErrInvalidAgentToken), "old token: err = %v", err)
+
+	// 到达过期时间后拒绝请求，且不能再刷新，只能重新接入
+	clock = expiry
+	_, err = svc.AuthenticateAgentToken(newToken)
+	assert.True(t, errors.Is(err, ErrAgentTokenExpired), "expired token: err = %v", err)
+	_, _, err = svc.RefreshAgentToken(enrolled.AgentID)
+	assert.True(t, errors.Is(err, ErrAgentTokenExpired), "refresh expired: err = %v", err)
+
+	reenrolled, err := svc.EnrollAgent(context.Background(), agentModel.EnrollRequest{Hostname: "scanner-01", Port: 5772, EnrollmentSecret: issueEnrollmentToken(t, svc)})
+	require.NoError(t, err)
+	_, err = svc.AuthenticateAgentToken(reenrolled.Token)
+	assert.NoError(t, err)
+}
+
+func TestRefreshAgentToken_Rejected(t *testing.T) {
+	svc, _ := newEnrollTestService(t, config.AgentConfig{
+		TokenSigningKey: testSigningKey,
+	})
+	enrolled, err := svc.EnrollAgent(context.Background(), agentModel.EnrollRequest{Hostname: "scanner-01", Port: 5772, EnrollmentSecret: issueEnrollmentToken(t, svc)})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.agentRepo.UpdateStatus(enrolled.AgentID, agentModel.AgentStatusOffline))
+	_, _, err = svc.RefreshAgentToken(enrolled.AgentID)
+	assert.True(t, errors.Is(err, ErrAgentNotOnline), "offline: err = %v", err)
+
+	require.NoError(t, svc.agentRepo.UpdateStatus(enrolled.AgentID, agentModel.AgentStatusOnline))
+	require.NoError(t, svc.agentRepo.Delete(enrolled.AgentID))
+	_, _, err = svc.RefreshAgentToken(enrolled.AgentID)
+	assert.EqualError(t, err, "agent not found")
+
+	// 软删除的 Agent 其 Token 也不再可用
+	_, err = svc.AuthenticateAgentToken(enrolled.Token)
+	assert.True(t, errors.Is(err, ErrInvalidAgentToken), "deleted: err = %v", err)
+}
+
+// noopTagService 注册流程中的标签同步不做任何事
+type noopTagService struct{ tag_system.TagService }
+
+func (noopTagService) SyncEntityTags(context.Context, string, string, []uint64, string, uint64) error {
+	return nil
+}
+
+func (noopTagService) AutoTag(context.Context, string, string, map[string]interface{}) ([]string, error) {
+	return nil, nil
+}
+
+func TestAgentToken_LegacyTokenWithoutEnforcement(t *testing.T) {
+	svc, db := newEnrollTestService(t, config.AgentConfig{
+		TokenSecret:     "register-secret",
+		TokenSigningKey: testSigningKey,
+		TokenTTL:        time.Hour,
+	})
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return clock }
+	svc.tagService = noopTagService{}
+	require.NoError(t, db.AutoMigrate(&agentModel.ScanType{}))
+	require.NoError(t, db.Create(&agentModel.ScanType{Name: "portScan", DisplayName: "端口扫描", IsActive: true, TagID: 1}).Error)
+
+	// 旧版本注册的 Token 没有有效期
+	legacy := &agentModel.Agent{AgentID: "legacy-1", Hostname: "scanner-01", Port: 5772, Token: "legacy-token", Status: agentModel.AgentStatusOnline}
+	require.NoError(t, db.Create(legacy).Error)
+
+	// 未开启过期校验时仍可认证与刷新
+	_, err := svc.AuthenticateAgentToken("legacy-token")
+	require.NoError(t, err)
+
+	// 凭 Token 重新注册保留 Token，并补齐有效期
+	resp, err := svc.RegisterAgent(&agentModel.RegisterAgentRequest{AgentID: "legacy-1", Token: "legacy-token", Hostname: "scanner-01", Port: 5772, TaskSupport: []string{"portScan"}})
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-token", resp.Token)
+	assert.Equal(t, clock.Add(time.Hour), resp.TokenExpiry)
+
+	// 再次注册时从注册时刻起延长有效期
+	clock = clock.Add(50 * time.Minute)
+	resp, err = svc.RegisterAgent(&agentModel.RegisterAgentRequest{AgentID: "legacy-1", Token: "legacy-token", Hostname: "scanner-01", Port: 5772, TaskSupport: []string{"portScan"}})
+	require.NoError(t, err)
+	assert.Equal(t, clock.Add(time.Hour), resp.TokenExpiry)
+
+	// 已过期也可以刷新换取新 Token
+	clock = clock.Add(2 * time.Hour)
+	newToken, expiry, err := svc.RefreshAgentToken("legacy-1")
+	require.NoError(t, err)
+	assert.Equal(t, clock.Add(time.Hour), expiry)
+	_, err = svc.AuthenticateAgentToken(newToken)
+	assert.NoError(t, err)
+
+	// 开启过期校验后，过期 Token 被拒绝
+	svc.cfg.Security.Agent.EnforceTokenExpiry = true
+	clock = expiry
+	_, err = svc.AuthenticateAgentToken(newToken)
+	assert.True(t, errors.Is(err, ErrAgentTokenExpired), "enforced: err = %v", err)
+}
@@ -3,12 +3,26 @@ package asset
 import (
 	"context"
 	"errors"
+	"fmt"
 	"neomaster/internal/model/asset"
 	tagsystem "neomaster/internal/model/tag_system"
 	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
 	assetrepo "neomaster/internal/repo/mysql/asset"
+	"neomaster/internal/service/asset/etl"
 	tagservice "neomaster/internal/service/tag_system"
+	"net"
 	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidHostFilter 主机列表筛选条件非法 (CIDR/端口)
+	ErrInvalidHostFilter = errors.New("invalid host filter")
+	// ErrInvalidHostIP 入库主机IP非法
+	ErrInvalidHostIP = errors.New("invalid host ip")
+	// ErrAssetMergerNotConfigured 未注入 ETL 资产合并器，无法入库扫描结果
+	ErrAssetMergerNotConfigured = errors.New("asset merger is not configured")
 )
 
 // // AssetHostServiceInterface 资产主机服务接口
@@ -24,6 +38,7 @@ import (
 type AssetHostService struct {
 	repo       *assetrepo.AssetHostRepository
 	tagService tagservice.TagService
+	merger     etl.AssetMerger // 扫描结果入库与 ETL 共用的资产合并器
 }
 
 // NewAssetHostService 创建 AssetHostService 实例
@@ -34,6 +49,11 @@ func NewAssetHostService(repo *assetrepo.AssetHostRepository, tagService tagserv
 	}
 }
 
+// SetAssetMerger 注入 ETL 资产合并器，IngestHost 与 ETL 结果处理共用同一合并逻辑与合并策略
+func (s *AssetHostService) SetAssetMerger(merger etl.AssetMerger) {
+	s.merger = merger
+}
+
 // -----------------------------------------------------------------------------
 // AssetHost 业务逻辑
 // -----------------------------------------------------------------------------
@@ -76,6 +96,9 @@ func (s *AssetHostService) GetHost(ctx context.Context, id uint64) (*asset.Asset
 	if host == nil {
 		return nil, errors.New("host not found")
 	}
+	if err = s.loadOpenPorts(ctx, []*asset.AssetHost{host}); err != nil {
+		return nil, err
+	}
 	return host, nil
 }
 
@@ -124,9 +147,19 @@ func (s *AssetHostService) DeleteHost(ctx context.Context, id uint64) error {
 }
 
 // ListHosts 获取主机列表
-func (s *AssetHostService) ListHosts(ctx context.Context, page, pageSize int, ip, hostname, os string, tagIDs []uint64) ([]*asset.AssetHost, int64, error) {
+// cidr 按网段或单个 IP 筛选 (如 10.0.0.0/24)，openPort > 0 时只返回开放了该端口的主机
+func (s *AssetHostService) ListHosts(ctx context.Context, page, pageSize int, ip, hostname, os, cidr string, openPort int, tagIDs []uint64) ([]*asset.AssetHost, int64, error) {
 	var hostIDs []uint64
 
+	if cidr = strings.TrimSpace(cidr); cidr != "" {
+		if _, _, err := utils.CIDRSortKeyRange(cidr); err != nil {
+			return nil, 0, fmt.Errorf("%w: cidr %q", ErrInvalidHostFilter, cidr)
+		}
+	}
+	if openPort < 0 || openPort > 65535 {
+		return nil, 0, fmt.Errorf("%w: open_port %d", ErrInvalidHostFilter, openPort)
+	}
+
 	// 如果指定了标签，先从标签系统获取对应的 HostID 列表
 	if len(tagIDs) > 0 {
 		entityIDsStr, err := s.tagService.GetEntityIDsByTagIDs(ctx, "host", tagIDs)
@@ -158,19 +191,118 @@ func (s *AssetHostService) ListHosts(ctx context.Context, page, pageSize int, ip
 	}
 
 	// 根据 tagIDs 获取 hostIDs 列表，然后用主机列表获取主机信息
-	list, total, err := s.repo.ListHosts(ctx, page, pageSize, ip, hostname, os, hostIDs)
+	list, total, err := s.repo.ListHosts(ctx, page, pageSize, ip, hostname, os, cidr, openPort, hostIDs)
 	if err != nil {
 		logger.LogBusinessError(err, "", 0, "", "list_hosts", "SERVICE", map[string]interface{}{
 			"operation": "list_hosts",
 			"page":      page,
 			"page_size": pageSize,
+			"cidr":      cidr,
+			"open_port": openPort,
 			"tag_ids":   tagIDs,
 		})
 		return nil, 0, err
 	}
+	if err = s.loadOpenPorts(ctx, list); err != nil {
+		return nil, 0, err
+	}
 	return list, total, nil
 }
 
+// SearchHostsByCIDR 按网段 (或单个 IP) 搜索主机，可选只返回开放了指定端口的主机
+func (s *AssetHostService) SearchHostsByCIDR(ctx context.Context, cidr string, openPort *int) ([]*asset.AssetHost, error) {
+	cidr = strings.TrimSpace(cidr)
+	if _, _, err := utils.CIDRSortKeyRange(cidr); err != nil {
+		return nil, fmt.Errorf("%w: cidr %q", ErrInvalidHostFilter, cidr)
+	}
+	if openPort != nil && (*openPort < 1 || *openPort > 65535) {
+		return nil, fmt.Errorf("%w: open_port %d", ErrInvalidHostFilter, *openPort)
+	}
+
+	hosts, err := s.repo.SearchByCIDR(ctx, cidr, openPort)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "search_hosts_by_cidr", "SERVICE", map[string]interface{}{
+			"operation": "search_hosts_by_cidr",
+			"cidr":      cidr,
+		})
+		return nil, err
+	}
+	if err = s.loadOpenPorts(ctx, hosts); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// IngestHost 将扫描结果中的主机及其开放端口入库
+// 校验后交给 ETL 资产合并器处理，与 Agent 结果的 ETL 入库走同一合并逻辑:
+// 主机按 IP 合并，端口/服务按去重键 (IP, 端口, 服务指纹) 并入已有主机，冲突字段按配置的 MergePolicy 合并；
+// 只追加和更新，本次扫描未出现的端口不会被删除
+func (s *AssetHostService) IngestHost(ctx context.Context, host *asset.AssetHost, services []*asset.AssetService) (*asset.AssetHost, error) {
+	if host == nil {
+		return nil, errors.New("host data cannot be nil")
+	}
+	ip := utils.NormalizeIP(host.IP)
+	if net.ParseIP(ip) == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHostIP, host.IP)
+	}
+	if s.merger == nil {
+		return nil, ErrAssetMergerNotConfigured
+	}
+	host.IP = ip
+
+	bundle := &etl.AssetBundle{Host: host, Services: make([]*asset.AssetService, 0, len(services))}
+	for _, svc := range services {
+		if svc == nil || svc.Port <= 0 || svc.Port > 65535 {
+			continue
+		}
+		bundle.Services = append(bundle.Services, svc)
+	}
+	if err := s.merger.Merge(ctx, bundle); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "ingest_host", "SERVICE", map[string]interface{}{
+			"operation": "ingest_host",
+			"ip":        ip,
+			"services":  len(bundle.Services),
+		})
+		return nil, err
+	}
+
+	stored, err := s.repo.GetHostByIP(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("host %s not found after ingest", ip)
+	}
+	if err = s.loadOpenPorts(ctx, []*asset.AssetHost{stored}); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// loadOpenPorts 批量加载主机的开放端口
+func (s *AssetHostService) loadOpenPorts(ctx context.Context, hosts []*asset.AssetHost) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	hostIDs := make([]uint64, 0, len(hosts))
+	byID := make(map[uint64]*asset.AssetHost, len(hosts))
+	for _, h := range hosts {
+		hostIDs = append(hostIDs, h.ID)
+		byID[h.ID] = h
+		h.OpenPorts = []*asset.AssetService{}
+	}
+	services, err := s.repo.ListServicesByHostIDs(ctx, hostIDs)
+	if err != nil {
+		return err
+	}
+	for _, svc := range services {
+		if h, ok := byID[svc.HostID]; ok {
+			h.OpenPorts = append(h.OpenPorts, svc)
+		}
+	}
+	return nil
+}
+
 // AddTagToHost 添加标签到主机
 func (s *AssetHostService) AddTagToHost(ctx context.Context, hostID uint64, tagID uint64) error {
 	// 检查主机是否存在
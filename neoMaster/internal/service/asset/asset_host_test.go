@@ -0,0 +1,210 @@
+package asset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"neomaster/internal/model/asset"
+	assetrepo "neomaster/internal/repo/mysql/asset"
+	"neomaster/internal/service/asset/etl"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newHostTestService(t *testing.T, opts ...etl.MergerOption) *AssetHostService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err = db.AutoMigrate(&asset.AssetHost{}, &asset.AssetService{}, &asset.AssetUnified{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	hostRepo := assetrepo.NewAssetHostRepository(db)
+	svc := NewAssetHostService(hostRepo, nil)
+	svc.SetAssetMerger(etl.NewAssetMerger(hostRepo, assetrepo.NewAssetWebRepository(db), assetrepo.NewAssetVulnRepository(db),
+		assetrepo.NewAssetUnifiedRepository(db), opts...))
+	return svc
+}
+
+func TestIngestHost_MergesPorts(t *testing.T) {
+	ctx := context.Background()
+	svc := newHostTestService(t)
+
+	first, err := svc.IngestHost(ctx, &asset.AssetHost{IP: "10.0.0.5", Hostname: "web-01"}, []*asset.AssetService{
+		{Port: 22, Name: "ssh"},
+		{Port: 80, Proto: "tcp", Name: "http"},
+	})
+	if err != nil {
+		t.Fatalf("first ingest: %v", err)
+	}
+	if first.FirstSeenAt == nil || first.LastSeenAt == nil {
+		t.Fatalf("seen times not set: %+v", first)
+	}
+
+	// 第二次扫描只发现了 80 和新端口 443，主机名为空
+	second, err := svc.IngestHost(ctx, &asset.AssetHost{IP: "::ffff:10.0.0.5", OS: "linux"}, []*asset.AssetService{
+		{Port: 80, Proto: "TCP", Version: "nginx 1.24"},
+		{Port: 443, Proto: "tcp", Name: "https"},
+	})
+	if err != nil {
+		t.Fatalf("second ingest: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("host id = %d, want %d (same host)", second.ID, first.ID)
+	}
+	if second.Hostname != "web-01" || second.OS != "linux" {
+		t.Errorf("hostname/os = %q/%q, want web-01/linux", second.Hostname, second.OS)
+	}
+	if !second.FirstSeenAt.Equal(*first.FirstSeenAt) {
+		t.Errorf("first_seen_at changed: %v -> %v", first.FirstSeenAt, second.FirstSeenAt)
+	}
+
+	ports := map[int]*asset.AssetService{}
+	for _, s := range second.OpenPorts {
+		ports[s.Port] = s
+	}
+	if len(ports) != 3 {
+		t.Fatalf("open ports = %v, want 22/80/443", ports)
+	}
+	if ports[80].Name != "http" || ports[80].Version != "nginx 1.24" || ports[80].SeenCount != 2 {
+		t.Errorf("port 80 = %+v, want merged http/nginx 1.24 seen twice", ports[80])
+	}
+	if ports[22].SeenCount != 1 {
+		t.Errorf("port 22 seen_count = %d, want 1", ports[22].SeenCount)
+	}
+
+	if _, err = svc.IngestHost(ctx, &asset.AssetHost{IP: "not-an-ip"}, nil); !errors.Is(err, ErrInvalidHostIP) {
+		t.Errorf("invalid ip error = %v, want ErrInvalidHostIP", err)
+	}
+}
+
+func TestIngestHost_RespectsMergePolicy(t *testing.T) {
+	ctx := context.Background()
+	svc := newHostTestService(t, etl.WithMergePolicy(etl.MergeKeepFirst))
+
+	if _, err := svc.IngestHost(ctx, &asset.AssetHost{IP: "10.0.0.7", Hostname: "db-01"}, []*asset.AssetService{
+		{Port: 5432, Name: "postgresql"},
+	}); err != nil {
+		t.Fatalf("first ingest: %v", err)
+	}
+	host, err := svc.IngestHost(ctx, &asset.AssetHost{IP: "10.0.0.7", Hostname: "db-renamed", OS: "linux"}, []*asset.AssetService{
+		{Port: 5432, Name: "postgres", Product: "PostgreSQL", Version: "16.2"},
+	})
+	if err != nil {
+		t.Fatalf("second ingest: %v", err)
+	}
+
+	// keep_first 保留首次发现的值，只补全为空的字段
+	if host.Hostname != "db-01" || host.OS != "linux" {
+		t.Errorf("hostname/os = %q/%q, want db-01/linux", host.Hostname, host.OS)
+	}
+	if len(host.OpenPorts) != 1 {
+		t.Fatalf("open ports = %d, want 1", len(host.OpenPorts))
+	}
+	if got := host.OpenPorts[0]; got.Name != "postgresql" || got.Product != "PostgreSQL" || got.SeenCount != 2 {
+		t.Errorf("port 5432 = %+v, want postgresql/PostgreSQL seen twice", got)
+	}
+
+	unconfigured := NewAssetHostService(nil, nil)
+	if _, err = unconfigured.IngestHost(ctx, &asset.AssetHost{IP: "10.0.0.8"}, nil); !errors.Is(err, ErrAssetMergerNotConfigured) {
+		t.Errorf("unconfigured merger error = %v, want ErrAssetMergerNotConfigured", err)
+	}
+}
+
+func TestListHosts_CIDRAndOpenPort(t *testing.T) {
+	ctx := context.Background()
+	svc := newHostTestService(t)
+
+	fixtures := []struct {
+		ip    string
+		ports []int
+	}{
+		{"10.0.0.1", []int{22}},
+		{"10.0.0.200", []int{80, 443}},
+		{"10.0.1.1", []int{80}},
+		{"192.168.1.10", []int{80}},
+	}
+	for _, f := range fixtures {
+		var services []*asset.AssetService
+		for _, p := range f.ports {
+			services = append(services, &asset.AssetService{Port: p})
+		}
+		if _, err := svc.IngestHost(ctx, &asset.AssetHost{IP: f.ip}, services); err != nil {
+			t.Fatalf("ingest %s: %v", f.ip, err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		cidr     string
+		openPort int
+		want     int64
+	}{
+		{"cidr /24", "10.0.0.0/24", 0, 2},
+		{"cidr /16", "10.0.0.0/16", 0, 3},
+		{"cidr /25", "10.0.0.128/25", 0, 1},
+		{"open port", "", 80, 3},
+		{"cidr and open port", "10.0.0.0/16", 80, 2},
+		{"no match", "172.16.0.0/12", 0, 0},
+	}
+	for _, tc := range cases {
+		hosts, total, err := svc.ListHosts(ctx, 1, 10, "", "", "", tc.cidr, tc.openPort, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if total != tc.want || int64(len(hosts)) != tc.want {
+			t.Errorf("%s: total = %d (%d rows), want %d", tc.name, total, len(hosts), tc.want)
+		}
+	}
+
+	if _, _, err := svc.ListHosts(ctx, 1, 10, "", "", "", "10.0.0.0/33", 0, nil); !errors.Is(err, ErrInvalidHostFilter) {
+		t.Errorf("invalid cidr error = %v, want ErrInvalidHostFilter", err)
+	}
+}
+
+func TestSearchHostsByCIDR(t *testing.T) {
+	ctx := context.Background()
+	svc := newHostTestService(t)
+
+	for ip, ports := range map[string][]int{
+		"10.44.96.9":   {3389},
+		"10.44.96.10":  {3389, 445},
+		"10.44.96.200": {22},
+		"10.44.97.1":   {3389},
+	} {
+		var services []*asset.AssetService
+		for _, p := range ports {
+			services = append(services, &asset.AssetService{Port: p})
+		}
+		if _, err := svc.IngestHost(ctx, &asset.AssetHost{IP: ip}, services); err != nil {
+			t.Fatalf("ingest %s: %v", ip, err)
+		}
+	}
+
+	rdp := 3389
+	hosts, err := svc.SearchHostsByCIDR(ctx, "10.44.96.0/24", &rdp)
+	if err != nil {
+		t.Fatalf("SearchHostsByCIDR: %v", err)
+	}
+	// 按 IP 数值升序，10.44.96.9 在 10.44.96.10 之前
+	if len(hosts) != 2 || hosts[0].IP != "10.44.96.9" || hosts[1].IP != "10.44.96.10" {
+		t.Fatalf("hosts = %v, want 10.44.96.9, 10.44.96.10", hosts)
+	}
+	if len(hosts[1].OpenPorts) != 2 {
+		t.Errorf("open ports of %s = %d, want 2", hosts[1].IP, len(hosts[1].OpenPorts))
+	}
+
+	if hosts, err = svc.SearchHostsByCIDR(ctx, "10.44.96.0/23", nil); err != nil || len(hosts) != 4 {
+		t.Errorf("search /23 = %d hosts, %v; want 4", len(hosts), err)
+	}
+	if hosts, err = svc.SearchHostsByCIDR(ctx, "10.44.96.200", nil); err != nil || len(hosts) != 1 {
+		t.Errorf("search single ip = %d hosts, %v; want 1", len(hosts), err)
+	}
+	badPort := 70000
+	if _, err = svc.SearchHostsByCIDR(ctx, "10.44.96.0/24", &badPort); !errors.Is(err, ErrInvalidHostFilter) {
+		t.Errorf("invalid port error = %v, want ErrInvalidHostFilter", err)
+	}
+}
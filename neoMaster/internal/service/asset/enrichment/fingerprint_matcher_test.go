@@ -8,6 +8,7 @@ import (
 	tagModel "neomaster/internal/model/tag_system"
 	repo "neomaster/internal/repo/mysql/asset"
 	"neomaster/internal/service/fingerprint"
+	tagService "neomaster/internal/service/tag_system"
 
 	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
@@ -97,9 +98,25 @@ func (m *MockTagService) GetEntityIDsByTagIDs(ctx context.Context, entityType st
 	return nil, nil
 }
 
-func (m *MockTagService) AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) error {
+func (m *MockTagService) TestRule(ctx context.Context, req *tagModel.TestRuleRequest) (*tagModel.TestRuleResponse, error) {
+	return nil, nil
+}
+func (m *MockTagService) RegisterRetagSource(entityType string, source tagService.RetagSource) {}
+func (m *MockTagService) RetagAllEntities(ctx context.Context, entityType string) (int, int, error) {
+	return 0, 0, nil
+}
+func (m *MockTagService) StartRetag(entityType string) (*tagModel.RetagProgress, error) {
+	return nil, nil
+}
+func (m *MockTagService) GetRetagProgress(entityType string) (*tagModel.RetagProgress, bool) {
+	return nil, false
+}
+func (m *MockTagService) CancelRetag(entityType string) bool { return false }
+func (m *MockTagService) ResumeRetags() []string             { return nil }
+
+func (m *MockTagService) AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) ([]string, error) {
 	args := m.Called(ctx, entityType, entityID, attributes)
-	return args.Error(0)
+	return nil, args.Error(0)
 }
 
 // --- Tests ---
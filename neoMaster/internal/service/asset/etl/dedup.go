@@ -0,0 +1,189 @@
+// 结果去重
+// 职责: 计算发现(finding)的去重键，并按 MergePolicy 合并重复发现的冲突字段
+// 多个项目扫描重叠网段时，同一 IP+端口+服务指纹 只保留一行服务资产，
+// 重复发现只递增 SeenCount 并刷新 LastSeenAt；同一端口识别出不同的服务(产品/版本/Banner 不同)各自保留一行
+package etl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	assetModel "neomaster/internal/model/asset"
+)
+
+// dedupKeyVersion 去重键算法版本，修改 DedupKey 的拼接规则时必须递增，避免新旧键混用
+const dedupKeyVersion = "v2"
+
+// bannerHashLen 服务指纹中 Banner 摘要的长度(十六进制字符)
+const bannerHashLen = 16
+
+// MergePolicy 重复发现的冲突字段合并策略
+type MergePolicy string
+
+const (
+	MergeKeepLatest MergePolicy = "keep_latest" // 以最新一次扫描结果为准 (默认)
+	MergeKeepFirst  MergePolicy = "keep_first"  // 保留首次发现的值，仅补全为空的字段
+)
+
+// ParseMergePolicy 解析配置中的合并策略，空值使用 keep_latest
+func ParseMergePolicy(s string) (MergePolicy, error) {
+	switch MergePolicy(strings.ToLower(strings.TrimSpace(s))) {
+	case "", MergeKeepLatest:
+		return MergeKeepLatest, nil
+	case MergeKeepFirst:
+		return MergeKeepFirst, nil
+	default:
+		return "", fmt.Errorf("unknown merge policy %q (expected keep_latest or keep_first)", s)
+	}
+}
+
+// DedupKey 计算发现的去重键 (稳定函数，结果会持久化到 asset_services.dedup_key)
+// 规则: sha256("v2|<ip>|<port>|<fingerprint>") 的十六进制串，固定 64 位
+//   - ip: 解析后的规范形式 (IPv4 映射地址转为 IPv4，IPv6 使用压缩写法)；无法解析时取去空格后的小写原值
+//   - port: 十进制端口号
+//   - fingerprint: 服务指纹，去空格后转小写，见 ServiceFingerprint
+func DedupKey(ip string, port int, fingerprint string) string {
+	raw := strings.Join([]string{
+		dedupKeyVersion,
+		canonicalIP(ip),
+		strconv.Itoa(port),
+		strings.ToLower(strings.TrimSpace(fingerprint)),
+	}, "|")
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeProto 规范化传输协议 (tcp/udp)，为空时按 tcp
+func NormalizeProto(proto string) string {
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if proto == "" {
+		return "tcp"
+	}
+	return proto
+}
+
+// ServiceFingerprint 服务指纹: "<proto>|<product>|<version>|<banner_hash>"
+//   - proto: 传输协议，见 NormalizeProto
+//   - product/version: 去空格后转小写
+//   - banner_hash: Banner 去首尾空白后 sha256 的前 16 位十六进制，Banner 为空时为空串
+//
+// 同一端口识别出不同的产品、版本或 Banner 视为不同的发现；服务名、CPE 等描述字段不参与指纹，按 MergePolicy 合并
+func ServiceFingerprint(svc *assetModel.AssetService) string {
+	bannerHash := ""
+	if banner := strings.TrimSpace(svc.Banner); banner != "" {
+		sum := sha256.Sum256([]byte(banner))
+		bannerHash = hex.EncodeToString(sum[:])[:bannerHashLen]
+	}
+	return strings.Join([]string{
+		NormalizeProto(svc.Proto),
+		strings.ToLower(strings.TrimSpace(svc.Product)),
+		strings.ToLower(strings.TrimSpace(svc.Version)),
+		bannerHash,
+	}, "|")
+}
+
+// ServiceDedupKey 计算服务资产的去重键
+func ServiceDedupKey(ip string, svc *assetModel.AssetService) string {
+	return DedupKey(ip, svc.Port, ServiceFingerprint(svc))
+}
+
+// isUnidentifiedService 服务是否只有端口信息(未识别出产品、版本与 Banner)
+// 仅端口扫描得到的发现不携带指纹，不与同端口已识别的服务冲突
+func isUnidentifiedService(svc *assetModel.AssetService) bool {
+	return strings.TrimSpace(svc.Product) == "" && strings.TrimSpace(svc.Version) == "" && strings.TrimSpace(svc.Banner) == ""
+}
+
+// matchPortService 去重键未命中时，在同一主机同端口同协议的已有服务中查找可合并的记录
+//   - 已有记录的去重键与其当前字段不一致 (去重键上线前或指纹规则升级前写入、事后补全指纹): 视为同一发现并补写去重键
+//   - 已有记录未识别: 新发现补全其指纹
+//   - 新发现未识别: 视为对已有服务的再次发现，取最近一次发现的记录
+//
+// 双方都已识别且指纹不同时返回 nil，新发现单独成行
+func matchPortService(ip string, incoming *assetModel.AssetService, candidates []*assetModel.AssetService) *assetModel.AssetService {
+	proto := NormalizeProto(incoming.Proto)
+	var match *assetModel.AssetService
+	for _, c := range candidates {
+		if c == nil || c.Port != incoming.Port || NormalizeProto(c.Proto) != proto {
+			continue
+		}
+		if c.DedupKey != ServiceDedupKey(ip, c) || isUnidentifiedService(c) {
+			return c
+		}
+		if isUnidentifiedService(incoming) && (match == nil || seenAfter(c, match)) {
+			match = c
+		}
+	}
+	return match
+}
+
+// seenAfter a 的最后发现时间是否晚于 b
+func seenAfter(a, b *assetModel.AssetService) bool {
+	if a.LastSeenAt == nil {
+		return false
+	}
+	return b.LastSeenAt == nil || a.LastSeenAt.After(*b.LastSeenAt)
+}
+
+// canonicalIP IP 规范化，保证同一地址的不同写法得到相同的去重键
+func canonicalIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return strings.ToLower(ip)
+}
+
+// pickField 按合并策略选择冲突字段的值
+// keep_latest: 新值非空即覆盖；keep_first: 仅在已有值为空时填充
+func pickField(old, new string, policy MergePolicy) string {
+	if new == "" {
+		return old
+	}
+	if policy == MergeKeepFirst && old != "" {
+		return old
+	}
+	return new
+}
+
+// mergeServiceFields 按合并策略把新发现的服务字段合并到已有记录，见 pickField
+func mergeServiceFields(existing, incoming *assetModel.AssetService, policy MergePolicy) {
+	pick := func(old, new string) string {
+		return pickField(old, new, policy)
+	}
+	existing.Name = pick(existing.Name, incoming.Name)
+	existing.Product = pick(existing.Product, incoming.Product)
+	existing.Version = pick(existing.Version, incoming.Version)
+	existing.CPE = pick(existing.CPE, incoming.CPE)
+	existing.Banner = pick(existing.Banner, incoming.Banner)
+	existing.Fingerprint = pick(existing.Fingerprint, incoming.Fingerprint)
+}
+
+// dedupServices 合并同一资产包内重复的服务 (同一结果批次内多次上报同一端口)
+// 去重键相同，或同端口同协议且其中一方未识别时视为重复；保持首次出现的顺序，重复项按合并策略合并到首次出现的记录
+func dedupServices(ip string, services []*assetModel.AssetService, policy MergePolicy) []*assetModel.AssetService {
+	result := make([]*assetModel.AssetService, 0, len(services))
+	for _, svc := range services {
+		if svc == nil {
+			continue
+		}
+		key := ServiceDedupKey(ip, svc)
+		var first *assetModel.AssetService
+		for _, r := range result {
+			if ServiceDedupKey(ip, r) == key ||
+				(r.Port == svc.Port && NormalizeProto(r.Proto) == NormalizeProto(svc.Proto) && (isUnidentifiedService(r) || isUnidentifiedService(svc))) {
+				first = r
+				break
+			}
+		}
+		if first != nil {
+			mergeServiceFields(first, svc, policy)
+			continue
+		}
+		result = append(result, svc)
+	}
+	return result
+}
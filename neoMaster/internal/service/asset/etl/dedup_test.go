@@ -0,0 +1,176 @@
+package etl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	assetModel "neomaster/internal/model/asset"
+	assetRepo "neomaster/internal/repo/mysql/asset"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupKey_Stable(t *testing.T) {
+	key := DedupKey("10.0.0.1", 22, "tcp")
+	assert.Len(t, key, 64)
+	// 同一地址的不同写法、指纹大小写与空白不影响去重键
+	assert.Equal(t, key, DedupKey(" ::ffff:10.0.0.1 ", 22, " TCP "))
+	assert.Equal(t, DedupKey("2001:db8::1", 443, "tcp"), DedupKey("2001:0DB8:0:0:0:0:0:1", 443, "tcp"))
+
+	assert.NotEqual(t, key, DedupKey("10.0.0.1", 23, "tcp"))
+	assert.NotEqual(t, key, DedupKey("10.0.0.1", 22, "udp"))
+	assert.NotEqual(t, key, DedupKey("10.0.0.2", 22, "tcp"))
+
+	assert.Equal(t, "tcp", NormalizeProto(""))
+	assert.Equal(t, "udp", NormalizeProto("UDP"))
+}
+
+func TestServiceFingerprint(t *testing.T) {
+	base := &assetModel.AssetService{Port: 22, Proto: "tcp", Name: "ssh", Product: "OpenSSH", Version: "8.9", Banner: "SSH-2.0-OpenSSH_8.9"}
+	fp := ServiceFingerprint(base)
+	assert.True(t, strings.HasPrefix(fp, "tcp|openssh|8.9|"))
+	assert.NotContains(t, fp, base.Banner, "banner is hashed")
+
+	// 大小写、空白与服务名、CPE 等描述字段不影响指纹
+	same := &assetModel.AssetService{Port: 22, Proto: " TCP", Name: "openssh-server", CPE: "cpe:/a:openbsd:openssh:8.9",
+		Product: " openssh ", Version: "8.9", Banner: "SSH-2.0-OpenSSH_8.9\r\n"}
+	assert.Equal(t, fp, ServiceFingerprint(same))
+	assert.Equal(t, ServiceDedupKey("10.0.0.1", base), ServiceDedupKey("10.0.0.1", same))
+
+	// 产品、版本、Banner 任一不同即为不同的发现
+	for _, other := range []*assetModel.AssetService{
+		{Port: 22, Proto: "tcp", Product: "Dropbear", Version: "8.9", Banner: base.Banner},
+		{Port: 22, Proto: "tcp", Product: "OpenSSH", Version: "7.4", Banner: base.Banner},
+		{Port: 22, Proto: "tcp", Product: "OpenSSH", Version: "8.9", Banner: "SSH-2.0-OpenSSH_8.9p1"},
+		{Port: 22, Proto: "udp", Product: "OpenSSH", Version: "8.9", Banner: base.Banner},
+	} {
+		assert.NotEqual(t, ServiceDedupKey("10.0.0.1", base), ServiceDedupKey("10.0.0.1", other), "%+v", other)
+	}
+	assert.Equal(t, "tcp|||", ServiceFingerprint(&assetModel.AssetService{Port: 80}))
+}
+
+func TestParseMergePolicy(t *testing.T) {
+	policy, err := ParseMergePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, MergeKeepLatest, policy)
+
+	policy, err = ParseMergePolicy(" Keep_First ")
+	require.NoError(t, err)
+	assert.Equal(t, MergeKeepFirst, policy)
+
+	_, err = ParseMergePolicy("keep_all")
+	assert.Error(t, err)
+}
+
+func mergeOverlappingScans(t *testing.T, policy MergePolicy) *assetModel.AssetService {
+	t.Helper()
+	db := newTestDB(t)
+	hostRepo := assetRepo.NewAssetHostRepository(db)
+	merger := NewAssetMerger(hostRepo, assetRepo.NewAssetWebRepository(db), assetRepo.NewAssetVulnRepository(db),
+		assetRepo.NewAssetUnifiedRepository(db), WithMergePolicy(policy))
+	ctx := context.Background()
+
+	// 两个项目扫描到同一台主机的同一服务，描述字段不同
+	scans := []*AssetBundle{
+		{
+			ProjectID: 1,
+			Host:      &assetModel.AssetHost{IP: "10.0.0.1", SourceStageIDs: "[]"},
+			Services: []*assetModel.AssetService{
+				{Port: 22, Proto: "tcp", Name: "ssh", Product: "OpenSSH", Version: "8.9"},
+				// 同一批次内仅有端口信息的重复上报
+				{Port: 22, Proto: "tcp", Name: "ssh-alt"},
+			},
+		},
+		{
+			ProjectID: 2,
+			Host:      &assetModel.AssetHost{IP: "10.0.0.1", SourceStageIDs: "[]"},
+			Services: []*assetModel.AssetService{
+				{Port: 22, Proto: "tcp", Name: "openssh-server", Product: "openssh", Version: "8.9", CPE: "cpe:/a:openbsd:openssh:8.9"},
+			},
+		},
+	}
+	for _, bundle := range scans {
+		require.NoError(t, merger.Merge(ctx, bundle))
+	}
+
+	var services []assetModel.AssetService
+	require.NoError(t, db.Find(&services).Error)
+	require.Len(t, services, 1)
+	return &services[0]
+}
+
+func TestAssetMerger_Services_DedupOverlappingScans(t *testing.T) {
+	latest := mergeOverlappingScans(t, MergeKeepLatest)
+	assert.Equal(t, 2, latest.SeenCount)
+	assert.Equal(t, ServiceDedupKey("10.0.0.1", latest), latest.DedupKey)
+	assert.NotNil(t, latest.LastSeenAt)
+	assert.Equal(t, "openssh-server", latest.Name)
+	assert.Equal(t, "openssh", latest.Product)
+
+	first := mergeOverlappingScans(t, MergeKeepFirst)
+	assert.Equal(t, 2, first.SeenCount)
+	assert.Equal(t, "ssh", first.Name)
+	assert.Equal(t, "OpenSSH", first.Product)
+	// keep_first 仍会补全首次发现时为空的字段
+	assert.Equal(t, "cpe:/a:openbsd:openssh:8.9", first.CPE)
+}
+
+func TestAssetMerger_Services_DistinctFingerprintsOnSamePort(t *testing.T) {
+	db := newTestDB(t)
+	merger := NewAssetMerger(assetRepo.NewAssetHostRepository(db), assetRepo.NewAssetWebRepository(db), assetRepo.NewAssetVulnRepository(db),
+		assetRepo.NewAssetUnifiedRepository(db))
+	ctx := context.Background()
+	merge := func(services ...*assetModel.AssetService) {
+		require.NoError(t, merger.Merge(ctx, &AssetBundle{
+			Host:     &assetModel.AssetHost{IP: "10.0.0.1", SourceStageIDs: "[]"},
+			Services: services,
+		}))
+	}
+
+	// 端口扫描只发现端口，指纹识别补全同一行
+	merge(&assetModel.AssetService{Port: 8080, Proto: "tcp"})
+	merge(&assetModel.AssetService{Port: 8080, Proto: "tcp", Name: "http", Product: "nginx", Version: "1.18.0"})
+	// 同一端口换成了另一个服务，单独成行
+	merge(&assetModel.AssetService{Port: 8080, Proto: "tcp", Name: "http", Product: "Apache httpd", Version: "2.4.57"})
+	// 之后仅有端口信息的发现计入最近发现的服务
+	merge(&assetModel.AssetService{Port: 8080, Proto: "tcp"})
+
+	var services []assetModel.AssetService
+	require.NoError(t, db.Order("id").Find(&services).Error)
+	require.Len(t, services, 2)
+	assert.Equal(t, "nginx", services[0].Product)
+	assert.Equal(t, 2, services[0].SeenCount)
+	assert.Equal(t, ServiceDedupKey("10.0.0.1", &services[0]), services[0].DedupKey)
+	assert.Equal(t, "Apache httpd", services[1].Product)
+	assert.Equal(t, 2, services[1].SeenCount)
+	assert.NotEqual(t, services[0].DedupKey, services[1].DedupKey)
+}
+
+func TestAssetMerger_Services_BackfillLegacyDedupKey(t *testing.T) {
+	db := newTestDB(t)
+	hostRepo := assetRepo.NewAssetHostRepository(db)
+	merger := NewAssetMerger(hostRepo, assetRepo.NewAssetWebRepository(db), assetRepo.NewAssetVulnRepository(db),
+		assetRepo.NewAssetUnifiedRepository(db))
+	ctx := context.Background()
+
+	host := &assetModel.AssetHost{IP: "10.0.0.9", SourceStageIDs: "[]"}
+	require.NoError(t, hostRepo.CreateHost(ctx, host))
+	// 去重键上线前写入的历史服务
+	legacy := &assetModel.AssetService{HostID: host.ID, Port: 80, Proto: "tcp", Name: "http", SeenCount: 1}
+	require.NoError(t, hostRepo.CreateService(ctx, legacy))
+
+	require.NoError(t, merger.Merge(ctx, &AssetBundle{
+		Host:     &assetModel.AssetHost{IP: "10.0.0.9", SourceStageIDs: "[]"},
+		Services: []*assetModel.AssetService{{Port: 80, Proto: "tcp", Name: "nginx"}},
+	}))
+
+	var services []assetModel.AssetService
+	require.NoError(t, db.Find(&services).Error)
+	require.Len(t, services, 1)
+	assert.Equal(t, legacy.ID, services[0].ID)
+	assert.Equal(t, DedupKey("10.0.0.9", 80, "tcp|||"), services[0].DedupKey)
+	assert.Equal(t, 2, services[0].SeenCount)
+	assert.Equal(t, "nginx", services[0].Name)
+}
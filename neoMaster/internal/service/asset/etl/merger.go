@@ -17,9 +17,15 @@ import (
 	"time"
 
 	assetModel "neomaster/internal/model/asset"
+	orcModel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/utils"
 	assetRepo "neomaster/internal/repo/mysql/asset"
+	"neomaster/internal/service/notify/webhook"
 )
 
+// vulnSeverityCritical 触发即时通知的漏洞严重程度
+const vulnSeverityCritical = "critical"
+
 // AssetMerger 资产合并器接口
 type AssetMerger interface {
 	// Merge 将资产包合并到数据库
@@ -32,6 +38,27 @@ type assetMerger struct {
 	webRepo     *assetRepo.AssetWebRepository
 	vulnRepo    *assetRepo.AssetVulnRepository
 	unifiedRepo *assetRepo.AssetUnifiedRepository
+	mergePolicy MergePolicy      // 重复发现的冲突字段合并策略
+	notifier    webhook.Notifier // 严重漏洞事件通知，为空时不发送
+}
+
+// MergerOption 资产合并器可选配置
+type MergerOption func(*assetMerger)
+
+// WithMergePolicy 设置重复发现的冲突字段合并策略，默认 keep_latest
+func WithMergePolicy(policy MergePolicy) MergerOption {
+	return func(m *assetMerger) {
+		if policy != "" {
+			m.mergePolicy = policy
+		}
+	}
+}
+
+// WithWebhookNotifier 设置严重漏洞(finding.critical)事件通知
+func WithWebhookNotifier(notifier webhook.Notifier) MergerOption {
+	return func(m *assetMerger) {
+		m.notifier = notifier
+	}
 }
 
 // NewAssetMerger 创建资产合并器
@@ -40,13 +67,19 @@ func NewAssetMerger(
 	webRepo *assetRepo.AssetWebRepository,
 	vulnRepo *assetRepo.AssetVulnRepository,
 	unifiedRepo *assetRepo.AssetUnifiedRepository,
+	opts ...MergerOption,
 ) AssetMerger {
-	return &assetMerger{
+	m := &assetMerger{
 		hostRepo:    hostRepo,
 		webRepo:     webRepo,
 		vulnRepo:    vulnRepo,
 		unifiedRepo: unifiedRepo,
+		mergePolicy: MergeKeepLatest,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
 }
 
 // Merge 将资产包合并到数据库
@@ -68,7 +101,7 @@ func (m *assetMerger) Merge(ctx context.Context, bundle *AssetBundle) error {
 
 	// 2. 处理 Services
 	if len(bundle.Services) > 0 {
-		if err := m.upsertServices(ctx, hostID, bundle.Services); err != nil {
+		if err := m.upsertServices(ctx, hostID, bundle.Host.IP, bundle.Services); err != nil {
 			return fmt.Errorf("failed to upsert services: %w", err)
 		}
 	}
@@ -87,7 +120,7 @@ func (m *assetMerger) Merge(ctx context.Context, bundle *AssetBundle) error {
 
 	// 6. 处理 Vulns
 	if len(bundle.Vulns) > 0 {
-		if err := m.upsertVulns(ctx, hostID, bundle.Vulns); err != nil {
+		if err := m.upsertVulns(ctx, bundle.ProjectID, hostID, bundle.Host.IP, bundle.Vulns); err != nil {
 			return fmt.Errorf("failed to upsert vulns: %w", err)
 		}
 	}
@@ -235,12 +268,13 @@ func (m *assetMerger) upsertHost(ctx context.Context, host *assetModel.AssetHost
 	if existing != nil {
 		// Update
 		existing.LastSeenAt = &now
-		if host.Hostname != "" {
-			existing.Hostname = host.Hostname
-		}
-		if host.OS != "" {
-			existing.OS = host.OS
+		if existing.FirstSeenAt == nil {
+			createdAt := existing.CreatedAt
+			existing.FirstSeenAt = &createdAt
 		}
+		// 主机名与操作系统按 mergePolicy 合并
+		existing.Hostname = pickField(existing.Hostname, host.Hostname, m.mergePolicy)
+		existing.OS = pickField(existing.OS, host.OS, m.mergePolicy)
 
 		// 合并 SourceStageIDs
 		mergedIDs, err := mergeJSONIDs(existing.SourceStageIDs, host.SourceStageIDs)
@@ -265,6 +299,12 @@ func (m *assetMerger) upsertHost(ctx context.Context, host *assetModel.AssetHost
 	if host.LastSeenAt == nil {
 		host.LastSeenAt = &now
 	}
+	if host.SourceStageIDs == "" {
+		host.SourceStageIDs = "[]"
+	}
+	if host.FirstSeenAt == nil {
+		host.FirstSeenAt = host.LastSeenAt
+	}
 	if err := m.hostRepo.CreateHost(ctx, host); err != nil {
 		return 0, fmt.Errorf("create host failed: %w", err)
 	}
@@ -272,37 +312,41 @@ func (m *assetMerger) upsertHost(ctx context.Context, host *assetModel.AssetHost
 }
 
 // upsertServices 更新或插入服务列表
-func (m *assetMerger) upsertServices(ctx context.Context, hostID uint64, services []*assetModel.AssetService) error {
-	for _, svc := range services {
+// 按 DedupKey(IP+端口+服务指纹) 去重: 已存在的发现只递增 SeenCount、刷新 LastSeenAt，
+// 冲突字段按 mergePolicy 合并，不会因重叠扫描产生重复行；同一端口指纹不同的服务各自成行
+func (m *assetMerger) upsertServices(ctx context.Context, hostID uint64, hostIP string, services []*assetModel.AssetService) error {
+	for _, svc := range dedupServices(hostIP, services, m.mergePolicy) {
 		svc.HostID = hostID
-		existing, err := m.hostRepo.GetServiceByHostIDAndPort(ctx, hostID, svc.Port, svc.Proto)
+		existing, err := m.hostRepo.GetServiceByDedupKey(ctx, ServiceDedupKey(hostIP, svc))
 		if err != nil {
 			return fmt.Errorf("check service existence failed: %w", err)
 		}
+		if existing == nil {
+			// 同端口的历史数据(去重键缺失或已过时)及仅有端口信息的发现，见 matchPortService
+			candidates, err := m.hostRepo.ListServicesByHostID(ctx, hostID)
+			if err != nil {
+				return fmt.Errorf("check service existence failed: %w", err)
+			}
+			existing = matchPortService(hostIP, svc, candidates)
+		}
 
 		now := time.Now()
 		if existing != nil {
-			// Update
+			// Update: 重复发现，合并后按合并结果重算去重键
+			existing.SeenCount++
 			existing.LastSeenAt = &now
-			if svc.Name != "" {
-				existing.Name = svc.Name
-			}
-			if svc.Version != "" {
-				existing.Version = svc.Version
-			}
-			if svc.CPE != "" {
-				existing.CPE = svc.CPE
-			}
-			// Product field is not present in AssetService model, Name covers it.
-			if svc.Fingerprint != "" {
-				existing.Fingerprint = svc.Fingerprint
-			}
+			mergeServiceFields(existing, svc, m.mergePolicy)
+			existing.Proto = NormalizeProto(existing.Proto)
+			existing.DedupKey = ServiceDedupKey(hostIP, existing)
 
 			if err := m.hostRepo.UpdateService(ctx, existing); err != nil {
 				return fmt.Errorf("update service failed: %w", err)
 			}
 		} else {
 			// Create
+			svc.Proto = NormalizeProto(svc.Proto)
+			svc.DedupKey = ServiceDedupKey(hostIP, svc)
+			svc.SeenCount = 1
 			if svc.LastSeenAt == nil {
 				svc.LastSeenAt = &now
 			}
@@ -378,7 +422,8 @@ func (m *assetMerger) upsertWebAssets(ctx context.Context, hostID uint64, webAss
 }
 
 // upsertVulns 创建或更新漏洞资产 - AssetVuln
-func (m *assetMerger) upsertVulns(ctx context.Context, hostID uint64, vulns []*assetModel.AssetVuln) error {
+// 首次发现或严重程度升级为 critical 的漏洞发送 finding.critical 事件，重复扫描到的同一漏洞不再通知
+func (m *assetMerger) upsertVulns(ctx context.Context, projectID, hostID uint64, hostIP string, vulns []*assetModel.AssetVuln) error {
 	now := time.Now()
 	for _, v := range vulns {
 		if v == nil {
@@ -403,13 +448,49 @@ func (m *assetMerger) upsertVulns(ctx context.Context, hostID uint64, vulns []*a
 			v.FirstSeenAt = &now
 		}
 		v.LastSeenAt = &now
+
+		critical := m.notifier != nil && strings.EqualFold(strings.TrimSpace(v.Severity), vulnSeverityCritical)
+		var previous *assetModel.AssetVuln
+		if critical {
+			previous, err = m.vulnRepo.GetVulnByTargetAndAlias(ctx, v.TargetType, v.TargetRefID, v.IDAlias)
+			if err != nil {
+				return err
+			}
+		}
 		if err := m.vulnRepo.UpsertVuln(ctx, v); err != nil {
 			return err
 		}
+		if critical && (previous == nil || !strings.EqualFold(previous.Severity, vulnSeverityCritical)) {
+			m.notifyCriticalVuln(projectID, hostIP, v, previous)
+		}
 	}
 	return nil
 }
 
+// notifyCriticalVuln 发送 finding.critical 事件
+// previous 为空表示首次发现，此时 v.ID 为新插入记录的 ID
+func (m *assetMerger) notifyCriticalVuln(projectID uint64, hostIP string, v, previous *assetModel.AssetVuln) {
+	data := &webhook.CriticalFindingData{
+		ProjectID:   projectID,
+		VulnID:      v.ID,
+		HostIP:      hostIP,
+		TargetType:  v.TargetType,
+		TargetRefID: v.TargetRefID,
+		CVE:         v.CVE,
+		IDAlias:     v.IDAlias,
+		Severity:    vulnSeverityCritical,
+		FirstSeenAt: v.FirstSeenAt,
+	}
+	if previous != nil {
+		data.VulnID = previous.ID
+		data.FirstSeenAt = previous.FirstSeenAt
+		if data.CVE == "" {
+			data.CVE = previous.CVE
+		}
+	}
+	m.notifier.Notify(orcModel.WebhookEventFindingCritical, data)
+}
+
 // resolveVulnTarget 解析漏洞资产目标
 func (m *assetMerger) resolveVulnTarget(ctx context.Context, hostID uint64, targetType string, v *assetModel.AssetVuln) (uint64, string, error) {
 	switch targetType {
@@ -488,21 +569,7 @@ func extractPortFromVulnAttributes(raw string) int {
 	if err := json.Unmarshal([]byte(raw), &m); err != nil {
 		return 0
 	}
-	v, ok := m["port"]
-	if !ok {
-		return 0
-	}
-	switch vv := v.(type) {
-	case float64:
-		return int(vv)
-	case int:
-		return vv
-	case string:
-		p, _ := strconv.Atoi(vv)
-		return p
-	default:
-		return 0
-	}
+	return utils.InterfaceToInt(m["port"], 0)
 }
 
 // extractURLFromVulnAttributes 尝试从漏洞资产属性中提取URL
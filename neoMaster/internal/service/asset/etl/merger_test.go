@@ -6,7 +6,9 @@ import (
 	"time"
 
 	assetModel "neomaster/internal/model/asset"
+	orcModel "neomaster/internal/model/orchestrator"
 	assetRepo "neomaster/internal/repo/mysql/asset"
+	"neomaster/internal/service/notify/webhook"
 
 	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
@@ -178,3 +180,44 @@ func TestAssetMerger_Vuln_ServiceTargetCreatesStubService(t *testing.T) {
 	assert.Equal(t, "service", existing.TargetType)
 	assert.Equal(t, svc.ID, existing.TargetRefID)
 }
+
+// recordingNotifier 记录发布的事件
+type recordingNotifier struct {
+	events []interface{}
+}
+
+func (n *recordingNotifier) Notify(eventType string, data interface{}) {
+	if eventType == orcModel.WebhookEventFindingCritical {
+		n.events = append(n.events, data)
+	}
+}
+
+func TestAssetMerger_Vuln_NotifiesCriticalOnce(t *testing.T) {
+	db := newTestDB(t)
+	notifier := &recordingNotifier{}
+	merger := NewAssetMerger(assetRepo.NewAssetHostRepository(db), assetRepo.NewAssetWebRepository(db),
+		assetRepo.NewAssetVulnRepository(db), assetRepo.NewAssetUnifiedRepository(db), WithWebhookNotifier(notifier))
+	ctx := context.Background()
+
+	scan := func(aliasSeverity map[string]string) {
+		t.Helper()
+		bundle := &AssetBundle{ProjectID: 3, Host: &assetModel.AssetHost{IP: "10.0.0.8", SourceStageIDs: "[]"}}
+		for alias, severity := range aliasSeverity {
+			bundle.Vulns = append(bundle.Vulns, &assetModel.AssetVuln{IDAlias: alias, Severity: severity, Status: "open"})
+		}
+		assert.NoError(t, merger.Merge(ctx, bundle))
+	}
+
+	scan(map[string]string{"CVE-2024-3400": "critical", "WEAK-TLS": "medium"})
+	// 重复扫描到同一漏洞不再通知，严重程度升级为 critical 时通知
+	scan(map[string]string{"CVE-2024-3400": "critical", "WEAK-TLS": "critical"})
+
+	if assert.Len(t, notifier.events, 2) {
+		first := notifier.events[0].(*webhook.CriticalFindingData)
+		assert.Equal(t, "CVE-2024-3400", first.IDAlias)
+		assert.Equal(t, uint64(3), first.ProjectID)
+		assert.Equal(t, "10.0.0.8", first.HostIP)
+		assert.NotZero(t, first.VulnID)
+		assert.Equal(t, "WEAK-TLS", notifier.events[1].(*webhook.CriticalFindingData).IDAlias)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: API 密钥服务，供 CI 等服务间调用使用
+ * @func:
+ * 1.Generate 生成密钥，明文只在此时返回一次，库中只保存 SHA-256 哈希
+ * 2.List 列出当前用户的密钥
+ * 3.Revoke 吊销密钥
+ * 4.Authenticate 认证请求头中的密钥(校验吊销、过期与所属用户状态)
+ */
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	systemrepo "neomaster/internal/repo/mysql/system"
+)
+
+const (
+	// APIKeyPrefix 密钥明文前缀，便于在日志与代码仓库中识别泄露的密钥
+	APIKeyPrefix = "nsk_"
+
+	apiKeyRandomBytes      = 32              // 密钥随机部分长度(字节)
+	apiKeyDisplayPrefixLen = 12              // 列表中展示的密钥前缀长度
+	apiKeyTouchInterval    = 1 * time.Minute // 最后使用时间的最小更新间隔，避免每个请求都写库
+)
+
+// APIKeyService API 密钥服务
+type APIKeyService struct {
+	apiKeyRepo *systemrepo.APIKeyRepository
+	userRepo   *systemrepo.UserRepository
+	now        func() time.Time // 当前时间，测试时可替换
+}
+
+// NewAPIKeyService 创建 API 密钥服务实例
+func NewAPIKeyService(apiKeyRepo *systemrepo.APIKeyRepository, userRepo *systemrepo.UserRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		now:        time.Now,
+	}
+}
+
+// Generate 为用户生成 API 密钥
+// 返回的 Key 为密钥明文，只在创建时返回一次，之后无法再次查看
+func (s *APIKeyService) Generate(ctx context.Context, userID uint, req *system.CreateAPIKeyRequest) (*system.CreateAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &system.ValidationError{Field: "name", Message: "name cannot be empty"}
+	}
+	scopes, err := normalizeAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, &system.ValidationError{Field: "expires_at", Message: "expires_at must be in the future"}
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key := &system.APIKey{
+		Name:      name,
+		KeyPrefix: rawKey[:apiKeyDisplayPrefixLen],
+		KeyHash:   hashAPIKey(rawKey),
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("保存API密钥失败: %w", err)
+	}
+
+	logger.LogBusinessOperation("api_key_create", userID, "", "", "", "success", "API密钥已创建", map[string]interface{}{
+		"operation":  "api_key_create",
+		"func_name":  "service.auth.api_key.Generate",
+		"api_key_id": key.ID,
+		"key_prefix": key.KeyPrefix,
+		"scopes":     strings.Join(scopes, ","),
+	})
+	return &system.CreateAPIKeyResponse{Key: rawKey, APIKey: key}, nil
+}
+
+// List 获取用户的 API 密钥列表(不含明文与哈希)
+func (s *APIKeyService) List(ctx context.Context, userID uint) ([]*system.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥列表失败: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke 吊销用户的 API 密钥，吊销后立即失效
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uint) error {
+	revoked, err := s.apiKeyRepo.Revoke(ctx, keyID, userID, s.now())
+	if err != nil {
+		return fmt.Errorf("吊销API密钥失败: %w", err)
+	}
+	if !revoked {
+		return system.ErrAPIKeyNotFound
+	}
+
+	logger.LogBusinessOperation("api_key_revoke", userID, "", "", "", "success", "API密钥已吊销", map[string]interface{}{
+		"operation":  "api_key_revoke",
+		"func_name":  "service.auth.api_key.Revoke",
+		"api_key_id": keyID,
+	})
+	return nil
+}
+
+// Authenticate 认证 API 密钥，返回密钥及其所属用户
+// 密钥不存在或已吊销返回 ErrAPIKeyInvalid，已过期返回 ErrAPIKeyExpired，所属用户被禁用返回 ErrUserDisabled
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey, clientIP string) (*system.APIKey, *system.User, error) {
+	if !strings.HasPrefix(rawKey, APIKeyPrefix) {
+		return nil, nil, system.ErrAPIKeyInvalid
+	}
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+	if key == nil || key.IsRevoked() {
+		return nil, nil, system.ErrAPIKeyInvalid
+	}
+	now := s.now()
+	if key.IsExpired(now) {
+		return nil, nil, system.ErrAPIKeyExpired
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, key.UserID)
+	if err != nil || user == nil {
+		// 所属用户已删除的密钥视为无效
+		return nil, nil, system.ErrAPIKeyInvalid
+	}
+	if !user.IsActive() {
+		return nil, nil, system.ErrUserDisabled
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// 最后使用时间仅用于展示，更新失败不影响本次认证
+		if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now, clientIP); err != nil {
+			logger.LogWarn("failed to update api key last used", "", user.ID, clientIP, "api_key_auth", "SERVICE", map[string]interface{}{
+				"operation":  "api_key_auth",
+				"option":     "apiKeyRepo.TouchLastUsed",
+				"func_name":  "service.auth.api_key.Authenticate",
+				"api_key_id": key.ID,
+				"error":      err.Error(),
+			})
+		} else {
+			key.LastUsedAt = &now
+			key.LastUsedIP = clientIP
+		}
+	}
+	return key, user, nil
+}
+
+// normalizeAPIKeyScopes 校验并去重授权范围，每项须为 resource:action(可用 * 通配)
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, system.ErrAPIKeyScope
+	}
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		resource, action, ok := strings.Cut(scope, ":")
+		if !ok || resource == "" || action == "" || strings.Contains(action, ":") {
+			return nil, fmt.Errorf("%w: %q", system.ErrAPIKeyScope, scope)
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+// generateAPIKey 生成密钥明文: nsk_ + 32 字节随机数(base64url)
+func generateAPIKey() (string, error) {
+	b := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成API密钥失败: %w", err)
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKey 计算密钥的 SHA-256 哈希(hex)
+// 密钥本身为高熵随机数，无需加盐慢哈希，按哈希可直接索引查找
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
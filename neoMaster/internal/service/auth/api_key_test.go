@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"neomaster/internal/model/system"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPIKeyTestService(t *testing.T) *APIKeyService {
+	t.Helper()
+	db := newUserUpdateTestDB(t)
+	require.NoError(t, db.AutoMigrate(&system.APIKey{}))
+	return NewAPIKeyService(systemrepo.NewAPIKeyRepository(db), systemrepo.NewUserRepository(db))
+}
+
+func TestAPIKeyService_GenerateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	svc := newAPIKeyTestService(t)
+
+	resp, err := svc.Generate(ctx, 2, &system.CreateAPIKeyRequest{Name: "gitlab-ci", Scopes: []string{"project:create", "project:create", "scan:*"}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Key, APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(resp.Key, resp.APIKey.KeyPrefix))
+	assert.Equal(t, []string{"project:create", "scan:*"}, resp.APIKey.Scopes)
+	assert.NotContains(t, resp.APIKey.KeyHash, resp.Key, "key must not be stored in plaintext")
+
+	key, user, err := svc.Authenticate(ctx, resp.Key, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), user.ID)
+	assert.Equal(t, resp.APIKey.ID, key.ID)
+	assert.True(t, key.AllowsScope("scan", "submit"))
+	assert.False(t, key.AllowsScope("system", "admin"))
+
+	// 列表中不返回明文，且记录了最后使用信息
+	keys, err := svc.List(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].LastUsedAt)
+	assert.Equal(t, "10.0.0.1", keys[0].LastUsedIP)
+
+	_, _, err = svc.Authenticate(ctx, resp.Key+"x", "")
+	assert.ErrorIs(t, err, system.ErrAPIKeyInvalid)
+}
+
+func TestAPIKeyService_ExpiredKeyRejected(t *testing.T) {
+	ctx := context.Background()
+	svc := newAPIKeyTestService(t)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	expiresAt := now.Add(time.Hour)
+	resp, err := svc.Generate(ctx, 2, &system.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"*:*"}, ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	_, _, err = svc.Authenticate(ctx, resp.Key, "")
+	require.NoError(t, err)
+
+	now = expiresAt
+	_, _, err = svc.Authenticate(ctx, resp.Key, "")
+	assert.ErrorIs(t, err, system.ErrAPIKeyExpired)
+}
+
+func TestAPIKeyService_RevokedKeyRejected(t *testing.T) {
+	ctx := context.Background()
+	svc := newAPIKeyTestService(t)
+
+	resp, err := svc.Generate(ctx, 2, &system.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"scan:read"}})
+	require.NoError(t, err)
+
+	// 只能吊销自己的密钥
+	assert.ErrorIs(t, svc.Revoke(ctx, 3, resp.APIKey.ID), system.ErrAPIKeyNotFound)
+	require.NoError(t, svc.Revoke(ctx, 2, resp.APIKey.ID))
+	assert.ErrorIs(t, svc.Revoke(ctx, 2, resp.APIKey.ID), system.ErrAPIKeyNotFound)
+
+	_, _, err = svc.Authenticate(ctx, resp.Key, "")
+	assert.ErrorIs(t, err, system.ErrAPIKeyInvalid)
+}
+
+func TestAPIKeyService_GenerateValidation(t *testing.T) {
+	ctx := context.Background()
+	svc := newAPIKeyTestService(t)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		req  *system.CreateAPIKeyRequest
+	}{
+		{name: "no_scopes", req: &system.CreateAPIKeyRequest{Name: "ci"}},
+		{name: "scope_without_action", req: &system.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"scan"}}},
+		{name: "scope_empty_resource", req: &system.CreateAPIKeyRequest{Name: "ci", Scopes: []string{":read"}}},
+		{name: "expired_on_create", req: &system.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"scan:read"}, ExpiresAt: &past}},
+		{name: "blank_name", req: &system.CreateAPIKeyRequest{Name: "  ", Scopes: []string{"scan:read"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Generate(ctx, 2, tt.req)
+			require.Error(t, err)
+			assert.Equal(t, system.ErrCodeInvalidParam, system.ErrorCodeOf(err))
+		})
+	}
+}
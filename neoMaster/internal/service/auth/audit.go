@@ -0,0 +1,243 @@
+/*
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 审计日志持久化服务
+ * @func:
+ * 1.作为 logger.AuditSink 接收 LogAuditOperation 的审计条目，缓冲后批量写入 audit_logs
+ * 2.按用户/动作/资源前缀/时间范围查询审计日志(合规报表)
+ */
+
+//  写入:
+//  	WriteAudit - 非阻塞入队，缓冲区满时丢弃并计数
+//  	Start / Stop - 启动后台批量写入 / 停止并写完缓冲区中剩余条目
+//  查询:
+//  	QueryAuditLogs - 分页查询审计日志
+//  	ExportAuditLogs - 按条件分批读取审计日志(流式导出)
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"neomaster/internal/model/system"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"neomaster/internal/pkg/logger"
+)
+
+const (
+	defaultAuditBufferSize    = 1024            // 审计条目缓冲区大小
+	defaultAuditBatchSize     = 100             // 单次批量写入的最大条数
+	defaultAuditFlushInterval = time.Second     // 缓冲区未满时的写入间隔
+	auditWriteTimeout         = 5 * time.Second // 单次批量写入超时
+	maxAuditQueryLimit        = 1000            // 单页最大条数
+	auditExportBatchSize      = 500             // 导出时单批读取条数
+)
+
+// AuditService 审计日志服务
+// 请求路径只做非阻塞入队，落库由后台协程批量完成，数据库慢或不可用时不会拖慢请求
+type AuditService struct {
+	auditRepo     *systemrepo.AuditLogRepository // 审计日志数据仓库
+	entries       chan *system.AuditLog
+	batchSize     int
+	flushInterval time.Duration
+	dropped       uint64 // 因缓冲区满被丢弃的条目数
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewAuditService 创建审计日志服务实例，bufferSize <= 0 时使用默认缓冲区大小
+func NewAuditService(auditRepo *systemrepo.AuditLogRepository, bufferSize int) *AuditService {
+	if bufferSize <= 0 {
+		bufferSize = defaultAuditBufferSize
+	}
+	return &AuditService{
+		auditRepo:     auditRepo,
+		entries:       make(chan *system.AuditLog, bufferSize),
+		batchSize:     defaultAuditBatchSize,
+		flushInterval: defaultAuditFlushInterval,
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// Start 启动后台批量写入协程(重复调用无副作用)
+func (s *AuditService) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+// Stop 停止后台写入，返回前写完缓冲区中已入队的条目
+func (s *AuditService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.Start() // 未启动过时也要启动一次，由写入协程负责写完剩余条目
+		<-s.stopped
+	})
+}
+
+// WriteAudit 实现 logger.AuditSink，非阻塞入队
+// 缓冲区已满或服务已停止时丢弃该条目(日志流中仍有记录)
+func (s *AuditService) WriteAudit(entry *logger.AuditLogEntry) {
+	if entry == nil {
+		return
+	}
+	select {
+	case <-s.done:
+		atomic.AddUint64(&s.dropped, 1)
+		return
+	default:
+	}
+
+	// 复制额外字段，调用方返回后可能继续修改原 map
+	var details system.AuditDetailsJSON
+	if len(entry.ExtraFields) > 0 {
+		details = make(system.AuditDetailsJSON, len(entry.ExtraFields))
+		for k, v := range entry.ExtraFields {
+			details[k] = v
+		}
+	}
+	record := &system.AuditLog{
+		UserID:    entry.UserID,
+		Username:  entry.Username,
+		Action:    entry.Action,
+		Resource:  entry.Resource,
+		Result:    entry.Result,
+		ClientIP:  entry.ClientIP,
+		UserAgent: entry.UserAgent,
+		RequestID: entry.RequestID,
+		Details:   details,
+		Timestamp: entry.Timestamp,
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+
+	select {
+	case s.entries <- record:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// Dropped 因缓冲区满或服务已停止而未落库的条目数
+func (s *AuditService) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// run 后台写入循环：攒满一批或到达写入间隔时批量落库
+func (s *AuditService) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*system.AuditLog, 0, s.batchSize)
+	var reportedDropped uint64
+	flush := func() {
+		if len(batch) > 0 {
+			s.flush(batch)
+			batch = make([]*system.AuditLog, 0, s.batchSize)
+		}
+		if dropped := s.Dropped(); dropped != reportedDropped {
+			logger.LogWarn("审计日志缓冲区已满，部分条目未落库", "", 0, "", "service.auth.audit.run", "", map[string]interface{}{
+				"operation": "audit_log_persist",
+				"option":    "buffer_full",
+				"func_name": "service.auth.audit.run",
+				"dropped":   dropped - reportedDropped,
+			})
+			reportedDropped = dropped
+		}
+	}
+
+	for {
+		select {
+		case record := <-s.entries:
+			batch = append(batch, record)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.done:
+			// 写完停止前已入队的条目
+			for {
+				select {
+				case record := <-s.entries:
+					batch = append(batch, record)
+					if len(batch) >= s.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush 批量写入一批审计日志，失败时只记录错误日志(日志流中已有这些条目)
+func (s *AuditService) flush(batch []*system.AuditLog) {
+	ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+	defer cancel()
+	if err := s.auditRepo.CreateAuditLogs(ctx, batch); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.auth.audit.flush", "", map[string]interface{}{
+			"operation": "audit_log_persist",
+			"option":    "auditRepo.CreateAuditLogs",
+			"func_name": "service.auth.audit.flush",
+			"count":     len(batch),
+		})
+	}
+}
+
+// QueryAuditLogs 分页查询审计日志
+// filter 中的零值字段不参与过滤；limit 取值 1-1000
+func (s *AuditService) QueryAuditLogs(ctx context.Context, filter system.AuditFilter, offset, limit int) ([]*system.AuditLog, int64, error) {
+	if offset < 0 {
+		return nil, 0, fmt.Errorf("offset must be non-negative")
+	}
+	if limit <= 0 || limit > maxAuditQueryLimit {
+		return nil, 0, fmt.Errorf("limit must be between 1 and %d", maxAuditQueryLimit)
+	}
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && !filter.StartTime.Before(filter.EndTime) {
+		return nil, 0, fmt.Errorf("start_time must be before end_time")
+	}
+
+	logs, total, err := s.auditRepo.QueryAuditLogs(ctx, filter, offset, limit)
+	if err != nil {
+		logger.LogBusinessError(err, "", filter.UserID, "", "service.auth.audit.QueryAuditLogs", "", map[string]interface{}{
+			"operation": "query_audit_logs",
+			"option":    "auditRepo.QueryAuditLogs",
+			"func_name": "service.auth.audit.QueryAuditLogs",
+			"filter":    filter,
+		})
+		return nil, 0, fmt.Errorf("查询审计日志失败: %v", err)
+	}
+	return logs, total, nil
+}
+
+// ExportAuditLogs 按条件分批读取审计日志(按ID升序)，每批交给 fn 写出
+// 导出不分页，内存中最多只保留一批记录；fn 返回错误(如客户端断开)时停止读取
+func (s *AuditService) ExportAuditLogs(ctx context.Context, filter system.AuditFilter, fn func(batch []*system.AuditLog) error) error {
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && !filter.StartTime.Before(filter.EndTime) {
+		return fmt.Errorf("start_time must be before end_time")
+	}
+	if err := s.auditRepo.StreamAuditLogs(ctx, filter, auditExportBatchSize, fn); err != nil {
+		logger.LogBusinessError(err, "", filter.UserID, "", "service.auth.audit.ExportAuditLogs", "", map[string]interface{}{
+			"operation": "export_audit_logs",
+			"option":    "auditRepo.StreamAuditLogs",
+			"func_name": "service.auth.audit.ExportAuditLogs",
+			"filter":    filter,
+		})
+		return err
+	}
+	return nil
+}
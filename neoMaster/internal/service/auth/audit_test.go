@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newAuditTestService(t *testing.T, bufferSize int) (*AuditService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&system.AuditLog{}))
+	return NewAuditService(systemrepo.NewAuditLogRepository(db), bufferSize), db
+}
+
+func TestAuditService_DualWrite(t *testing.T) {
+	svc, db := newAuditTestService(t, 0)
+	svc.Start()
+	logger.SetAuditSink(svc)
+	defer logger.SetAuditSink(nil)
+
+	extra := map[string]interface{}{"target_user_id": float64(7)}
+	logger.LogAuditOperation(1, "admin", "user.delete", "user:7", "success", "10.0.0.1", "curl/8.0", "req-1", extra)
+	extra["target_user_id"] = float64(8) // 入队后修改原 map 不影响落库内容
+
+	// Stop 写完缓冲区中剩余条目
+	svc.Stop()
+
+	var logs []*system.AuditLog
+	require.NoError(t, db.Find(&logs).Error)
+	require.Len(t, logs, 1)
+	got := logs[0]
+	assert.Equal(t, uint(1), got.UserID)
+	assert.Equal(t, "admin", got.Username)
+	assert.Equal(t, "user.delete", got.Action)
+	assert.Equal(t, "user:7", got.Resource)
+	assert.Equal(t, "success", got.Result)
+	assert.Equal(t, "10.0.0.1", got.ClientIP)
+	assert.Equal(t, "curl/8.0", got.UserAgent)
+	assert.Equal(t, "req-1", got.RequestID)
+	assert.Equal(t, float64(7), got.Details["target_user_id"])
+	assert.False(t, got.Timestamp.IsZero())
+
+	// 停止后不再入队
+	logger.LogAuditOperation(1, "admin", "user.delete", "user:9", "success", "", "", "", nil)
+	assert.Equal(t, uint64(1), svc.Dropped())
+}
+
+func TestAuditService_WriteNeverBlocks(t *testing.T) {
+	svc, _ := newAuditTestService(t, 2)
+	// 未启动写入协程，缓冲区满后直接丢弃
+	for i := 0; i < 5; i++ {
+		svc.WriteAudit(&logger.AuditLogEntry{Action: "login"})
+	}
+	assert.Equal(t, uint64(3), svc.Dropped())
+	svc.Stop()
+}
+
+func TestAuditService_QueryAuditLogs(t *testing.T) {
+	svc, db := newAuditTestService(t, 0)
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	seed := []*system.AuditLog{
+		{UserID: 1, Action: "login", Resource: "session", Timestamp: base},
+		{UserID: 1, Action: "user.update", Resource: "user:2", Timestamp: base.Add(time.Hour)},
+		{UserID: 2, Action: "user.update", Resource: "user:3", Timestamp: base.Add(2 * time.Hour)},
+		{UserID: 2, Action: "role.update", Resource: "user_role:3", Timestamp: base.Add(3 * time.Hour)},
+	}
+	require.NoError(t, db.Create(&seed).Error)
+
+	tests := []struct {
+		name      string
+		filter    system.AuditFilter
+		wantTotal int64
+		wantFirst string
+	}{
+		{name: "all", filter: system.AuditFilter{}, wantTotal: 4, wantFirst: "user_role:3"},
+		{name: "by_user", filter: system.AuditFilter{UserID: 1}, wantTotal: 2, wantFirst: "user:2"},
+		{name: "by_action", filter: system.AuditFilter{Action: "user.update"}, wantTotal: 2, wantFirst: "user:3"},
+		// 前缀按字面量匹配，_ 不是通配符
+		{name: "by_resource_prefix", filter: system.AuditFilter{ResourcePrefix: "user:"}, wantTotal: 2, wantFirst: "user:3"},
+		{name: "by_resource_prefix_literal", filter: system.AuditFilter{ResourcePrefix: "user_"}, wantTotal: 1, wantFirst: "user_role:3"},
+		{name: "by_time_range", filter: system.AuditFilter{StartTime: base.Add(time.Hour), EndTime: base.Add(3 * time.Hour)}, wantTotal: 2, wantFirst: "user:3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, total, err := svc.QueryAuditLogs(ctx, tt.filter, 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			require.NotEmpty(t, logs)
+			assert.Equal(t, tt.wantFirst, logs[0].Resource)
+		})
+	}
+
+	// 分页
+	logs, total, err := svc.QueryAuditLogs(ctx, system.AuditFilter{}, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "user:3", logs[0].Resource)
+
+	_, _, err = svc.QueryAuditLogs(ctx, system.AuditFilter{}, 0, 0)
+	assert.Error(t, err)
+	_, _, err = svc.QueryAuditLogs(ctx, system.AuditFilter{StartTime: base, EndTime: base}, 0, 10)
+	assert.Error(t, err)
+}
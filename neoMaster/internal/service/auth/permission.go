@@ -16,6 +16,7 @@ import (
 // 仅处理权限自身的增删改查，不与角色分配、RBAC、用户授权等逻辑重叠
 type PermissionService struct {
 	permissionRepo *systemrepo.PermissionRepository
+	permCache      PermissionCacheStore // 用户权限集缓存，权限变更后使其失效
 }
 
 // NewPermissionService 创建权限服务
@@ -220,7 +221,12 @@ func (s *PermissionService) UpdatePermissionByID(ctx context.Context, permission
 	if err != nil {
 		return nil, err
 	}
-	return s.executePermissionUpdate(ctx, permission, req)
+	updated, err := s.executePermissionUpdate(ctx, permission, req)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateAllPermissions(ctx)
+	return updated, nil
 }
 
 func (s *PermissionService) validateUpdatePermissionParams(ctx context.Context, permissionID uint, req *system.UpdatePermissionRequest) error {
@@ -456,6 +462,7 @@ func (s *PermissionService) DeletePermission(ctx context.Context, permissionID u
 		})
 		return fmt.Errorf("提交事务失败: %w", err)
 	}
+	s.invalidateAllPermissions(ctx)
 
 	// 记录成功日志
 	logger.LogBusinessOperation("delete_permission", 0, "", clientIP, "", "success", "权限删除成功", map[string]interface{}{
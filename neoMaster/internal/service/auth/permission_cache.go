@@ -0,0 +1,147 @@
+/*
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 用户权限集缓存
+ * @func:
+ * 1.GetUserPermissions 等读取路径先查缓存，未命中或缓存异常时回源数据库
+ * 2.用户角色/状态变化递增用户版本，角色或权限定义变化递增全局版本
+ */
+package auth
+
+import (
+	"context"
+	"time"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/logger"
+)
+
+// defaultPermissionCacheTTL 未配置 security.auth.permission_cache_ttl 时的缓存有效期
+const defaultPermissionCacheTTL = 5 * time.Minute
+
+// PermissionCacheStore 用户权限集缓存存储 (redis.PermissionCacheRepository 已实现)
+// 缓存按版本存取：读取方在查询数据库之前取版本，写入方在数据库更新之后递增版本，
+// 并发时旧数据只会写到旧版本的键上
+type PermissionCacheStore interface {
+	GetPermissionVersion(ctx context.Context, userID uint) (string, error)
+	GetUserPermissions(ctx context.Context, userID uint, version string) ([]*system.Permission, bool, error)
+	SetUserPermissions(ctx context.Context, userID uint, version string, permissions []*system.Permission, expiration time.Duration) error
+	BumpUserVersion(ctx context.Context, userID uint) error
+	BumpGlobalVersion(ctx context.Context) error
+}
+
+// ResolvePermissionCacheTTL 解析缓存有效期：0 使用默认值，负值关闭缓存(返回 0)
+func ResolvePermissionCacheTTL(ttl time.Duration) time.Duration {
+	switch {
+	case ttl < 0:
+		return 0
+	case ttl == 0:
+		return defaultPermissionCacheTTL
+	default:
+		return ttl
+	}
+}
+
+// SetPermissionCache 注入用户权限集缓存，ttl <= 0 时不启用缓存
+func (s *UserService) SetPermissionCache(store PermissionCacheStore, ttl time.Duration) {
+	if ttl <= 0 {
+		store = nil
+	}
+	s.permCache = store
+	s.permCacheTTL = ttl
+}
+
+// loadUserPermissions 获取用户权限集：先查缓存，未命中时查询数据库并回写缓存
+func (s *UserService) loadUserPermissions(ctx context.Context, userID uint) ([]*system.Permission, error) {
+	permissions, version, hit := s.getCachedUserPermissions(ctx, userID)
+	if hit {
+		return permissions, nil
+	}
+	permissions, err := s.userRepo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.storeCachedUserPermissions(ctx, userID, version, permissions)
+	return permissions, nil
+}
+
+// getCachedUserPermissions 从缓存读取用户权限集，同时返回读取时的版本供未命中时回写
+// 未启用缓存或缓存异常时返回空版本，调用方直接回源数据库
+func (s *UserService) getCachedUserPermissions(ctx context.Context, userID uint) ([]*system.Permission, string, bool) {
+	if s.permCache == nil {
+		return nil, "", false
+	}
+	// 版本必须在查询数据库之前读取
+	version, err := s.permCache.GetPermissionVersion(ctx, userID)
+	if err != nil {
+		logPermissionCacheError(err, userID, "permCache.GetPermissionVersion", "service.auth.permission_cache.getCachedUserPermissions")
+		return nil, "", false
+	}
+	permissions, hit, err := s.permCache.GetUserPermissions(ctx, userID, version)
+	if err != nil {
+		logPermissionCacheError(err, userID, "permCache.GetUserPermissions", "service.auth.permission_cache.getCachedUserPermissions")
+		return nil, version, false
+	}
+	return permissions, version, hit
+}
+
+// storeCachedUserPermissions 按读取时的版本回写缓存(版本为空时跳过)
+func (s *UserService) storeCachedUserPermissions(ctx context.Context, userID uint, version string, permissions []*system.Permission) {
+	if s.permCache == nil || version == "" {
+		return
+	}
+	if err := s.permCache.SetUserPermissions(ctx, userID, version, permissions, s.permCacheTTL); err != nil {
+		logPermissionCacheError(err, userID, "permCache.SetUserPermissions", "service.auth.permission_cache.storeCachedUserPermissions")
+	}
+}
+
+// invalidateUserPermissions 使单个用户的权限集缓存失效(角色分配/移除、状态变更、删除后调用)
+func (s *UserService) invalidateUserPermissions(ctx context.Context, userID uint) {
+	if s.permCache == nil {
+		return
+	}
+	if err := s.permCache.BumpUserVersion(ctx, userID); err != nil {
+		logPermissionCacheError(err, userID, "permCache.BumpUserVersion", "service.auth.permission_cache.invalidateUserPermissions")
+	}
+}
+
+// SetPermissionCache 注入用户权限集缓存，角色变更后使所有用户的缓存失效
+func (s *RoleService) SetPermissionCache(store PermissionCacheStore) {
+	s.permCache = store
+}
+
+// invalidateAllPermissions 角色定义或角色权限变化后使所有用户的权限集缓存失效
+func (s *RoleService) invalidateAllPermissions(ctx context.Context) {
+	bumpGlobalPermissionVersion(ctx, s.permCache, "service.auth.role.invalidateAllPermissions")
+}
+
+// SetPermissionCache 注入用户权限集缓存，权限变更后使所有用户的缓存失效
+func (s *PermissionService) SetPermissionCache(store PermissionCacheStore) {
+	s.permCache = store
+}
+
+// invalidateAllPermissions 权限定义变化后使所有用户的权限集缓存失效
+func (s *PermissionService) invalidateAllPermissions(ctx context.Context) {
+	bumpGlobalPermissionVersion(ctx, s.permCache, "service.auth.permission.invalidateAllPermissions")
+}
+
+// bumpGlobalPermissionVersion 递增全局权限版本
+func bumpGlobalPermissionVersion(ctx context.Context, store PermissionCacheStore, funcName string) {
+	if store == nil {
+		return
+	}
+	if err := store.BumpGlobalVersion(ctx); err != nil {
+		logPermissionCacheError(err, 0, "permCache.BumpGlobalVersion", funcName)
+	}
+}
+
+// logPermissionCacheError 缓存异常只告警，调用方回源数据库
+// 注意：失效失败时旧缓存最长保留一个 TTL
+func logPermissionCacheError(err error, userID uint, option, funcName string) {
+	logger.LogWarn("用户权限缓存操作失败: "+err.Error(), "", userID, "", funcName, "", map[string]interface{}{
+		"operation": "permission_cache",
+		"option":    option,
+		"func_name": funcName,
+		"user_id":   userID,
+	})
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"neomaster/internal/model/system"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memoryPermissionCache 进程内权限集缓存，与 redis.PermissionCacheRepository 的版本语义一致
+type memoryPermissionCache struct {
+	global  int
+	users   map[uint]int
+	entries map[string][]*system.Permission
+	hits    int
+	failGet bool
+}
+
+func newMemoryPermissionCache() *memoryPermissionCache {
+	return &memoryPermissionCache{users: map[uint]int{}, entries: map[string][]*system.Permission{}}
+}
+
+func (m *memoryPermissionCache) GetPermissionVersion(ctx context.Context, userID uint) (string, error) {
+	if m.failGet {
+		return "", errors.New("redis unavailable")
+	}
+	return fmt.Sprintf("%d.%d", m.global, m.users[userID]), nil
+}
+
+func (m *memoryPermissionCache) GetUserPermissions(ctx context.Context, userID uint, version string) ([]*system.Permission, bool, error) {
+	perms, ok := m.entries[fmt.Sprintf("%d:%s", userID, version)]
+	if ok {
+		m.hits++
+	}
+	return perms, ok, nil
+}
+
+func (m *memoryPermissionCache) SetUserPermissions(ctx context.Context, userID uint, version string, permissions []*system.Permission, expiration time.Duration) error {
+	m.entries[fmt.Sprintf("%d:%s", userID, version)] = permissions
+	return nil
+}
+
+func (m *memoryPermissionCache) BumpUserVersion(ctx context.Context, userID uint) error {
+	m.users[userID]++
+	return nil
+}
+
+func (m *memoryPermissionCache) BumpGlobalVersion(ctx context.Context) error {
+	m.global++
+	return nil
+}
+
+func newPermissionCacheTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&system.User{}, &system.Role{}, &system.Permission{}))
+
+	require.NoError(t, db.Create(&system.User{ID: 2, Username: "alice", Email: "alice@example.com", Password: "x", Status: system.UserStatusEnabled}).Error)
+	require.NoError(t, db.Create(&system.Permission{ID: 1, Name: "user:read", Resource: "user", Action: "read", Status: system.PermissionStatusEnabled}).Error)
+	require.NoError(t, db.Create(&system.Permission{ID: 2, Name: "user:update", Resource: "user", Action: "update", Status: system.PermissionStatusEnabled}).Error)
+	require.NoError(t, db.Create(&system.Role{ID: 10, Name: "viewer", Status: system.RoleStatusEnabled}).Error)
+	require.NoError(t, db.Create(&system.Role{ID: 11, Name: "editor", Status: system.RoleStatusEnabled}).Error)
+	require.NoError(t, db.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (10, 1), (11, 1), (11, 2)").Error)
+	require.NoError(t, db.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (2, 10)").Error)
+	return db
+}
+
+func permissionNames(perms []*system.Permission) []string {
+	names := make([]string, 0, len(perms))
+	for _, p := range perms {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestUserService_AssignRoleInvalidatesPermissionCache(t *testing.T) {
+	ctx := context.Background()
+	db := newPermissionCacheTestDB(t)
+	cache := newMemoryPermissionCache()
+	svc := NewUserService(systemrepo.NewUserRepository(db), nil, nil, nil)
+	svc.SetPermissionCache(cache, time.Minute)
+
+	perms, err := svc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:read"}, permissionNames(perms))
+
+	// 第二次读取命中缓存
+	_, err = svc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cache.hits)
+
+	require.NoError(t, svc.AssignRoleToUser(ctx, 2, 11))
+	perms, err = svc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:read", "user:update"}, permissionNames(perms))
+	assert.Equal(t, 1, cache.hits, "角色分配后不应读到旧缓存")
+
+	require.NoError(t, svc.RemoveRoleFromUser(ctx, 2, 11))
+	perms, err = svc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:read"}, permissionNames(perms))
+}
+
+func TestRoleService_PermissionChangeInvalidatesAllUsers(t *testing.T) {
+	ctx := context.Background()
+	db := newPermissionCacheTestDB(t)
+	cache := newMemoryPermissionCache()
+	userSvc := NewUserService(systemrepo.NewUserRepository(db), nil, nil, nil)
+	userSvc.SetPermissionCache(cache, time.Minute)
+	roleSvc := NewRoleService(systemrepo.NewRoleRepository(db))
+	roleSvc.SetPermissionCache(cache)
+
+	_, err := userSvc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, roleSvc.AssignPermissionToRole(ctx, 10, 2))
+	perms, err := userSvc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:read", "user:update"}, permissionNames(perms))
+	assert.Equal(t, 0, cache.hits)
+}
+
+func TestUserService_PermissionCacheFallsBackToDB(t *testing.T) {
+	ctx := context.Background()
+	db := newPermissionCacheTestDB(t)
+	cache := newMemoryPermissionCache()
+	cache.failGet = true
+	svc := NewUserService(systemrepo.NewUserRepository(db), nil, nil, nil)
+	svc.SetPermissionCache(cache, time.Minute)
+
+	perms, err := svc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:read"}, permissionNames(perms))
+	assert.Empty(t, cache.entries, "缓存不可用时不回写")
+}
@@ -218,27 +218,9 @@ func (s *RBACService) ValidateResourceAccess(ctx context.Context, userID uint, r
 	return nil
 }
 
-// matchPermission 匹配权限
+// matchPermission 匹配权限(精确匹配或 * 通配)
 func (s *RBACService) matchPermission(permission *system.Permission, resource, action string) bool {
-	// 精确匹配
-	if permission.Resource == resource && permission.Action == action {
-		return true
-	}
-
-	// 通配符匹配
-	if permission.Resource == "*" && permission.Action == "*" {
-		return true
-	}
-
-	if permission.Resource == resource && permission.Action == "*" {
-		return true
-	}
-
-	if permission.Resource == "*" && permission.Action == action {
-		return true
-	}
-
-	return false
+	return permission.Matches(resource, action)
 }
 
 // GetPermissionString 获取权限字符串表示
@@ -15,7 +15,7 @@
 //  	GetRoleByName - 根据角色名获取角色
 //  	GetRoleList - 分页获取角色列表
 //  	UpdateRoleByID - 更新角色信息（包含权限更新）
-//  	DeleteRole - 删除角色（包含级联删除）
+//  	DeleteRole - 删除角色（包含级联删除，存在子角色时拒绝删除）
 //  状态管理:
 //  	UpdateRoleStatus - 通用状态更新函数
 //  	ActivateRole - 激活角色
@@ -25,6 +25,8 @@
 //  	GetRolePermissions - 获取角色权限
 //  	AssignPermissionToRole - 为角色分配权限
 //  	RemovePermissionFromRole - 移除角色权限
+//  角色继承:
+//  	validateParentRole - 校验父角色存在且不会形成继承环
 
 package auth
 
@@ -43,7 +45,8 @@ import (
 // RoleService 角色服务
 // 负责角色相关的业务逻辑，包括角色创建、获取角色信息等
 type RoleService struct {
-	roleRepo *systemrepo.RoleRepository // 角色数据仓库
+	roleRepo  *systemrepo.RoleRepository // 角色数据仓库
+	permCache PermissionCacheStore       // 用户权限集缓存，角色变更后使其失效
 }
 
 // NewRoleService 创建新的角色服务实例
@@ -88,12 +91,22 @@ func (s *RoleService) CreateRole(ctx context.Context, req *system.CreateRoleRequ
 		return nil, errors.New("角色名称已存在")
 	}
 
+	// 校验父角色(新角色没有子角色，只需确认父角色存在)
+	var parentRoleID *uint
+	if req.ParentRoleID != nil && *req.ParentRoleID != 0 {
+		if err := s.validateParentRole(ctx, 0, *req.ParentRoleID); err != nil {
+			return nil, err
+		}
+		parentRoleID = req.ParentRoleID
+	}
+
 	// 创建角色模型
 	role := &system.Role{
-		Name:        req.Name,
-		DisplayName: req.DisplayName,
-		Description: req.Description,
-		Status:      system.RoleStatusEnabled, // 默认启用状态
+		Name:         req.Name,
+		DisplayName:  req.DisplayName,
+		Description:  req.Description,
+		Status:       system.RoleStatusEnabled, // 默认启用状态
+		ParentRoleID: parentRoleID,
 	}
 
 	// 存储到数据库
@@ -311,7 +324,12 @@ func (s *RoleService) UpdateRoleByID(ctx context.Context, roleID uint, req *syst
 	}
 
 	// 第三层：事务处理层
-	return s.executeRoleUpdate(ctx, role, req)
+	updated, err := s.executeRoleUpdate(ctx, role, req)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateAllPermissions(ctx)
+	return updated, nil
 }
 
 // validateUpdateRoleParams 验证更新角色的参数
@@ -442,6 +460,13 @@ func (s *RoleService) validateRoleForUpdate(ctx context.Context, roleID uint, re
 		}
 	}
 
+	// 父角色校验：父角色必须存在，且不能是自身或自身的子孙角色
+	if req.ParentRoleID != nil && *req.ParentRoleID != 0 {
+		if err := s.validateParentRole(ctx, roleID, *req.ParentRoleID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 业务规则：系统角色保护机制（可以根据需要添加）
 	// 例如：某些系统内置角色不能被修改(角色1为系统管理员角色)
 	if roleID == 1 {
@@ -503,6 +528,15 @@ func (s *RoleService) executeRoleUpdate(ctx context.Context, role *system.Role,
 	if req.Status != nil && *req.Status != role.Status {
 		role.Status = *req.Status
 	}
+	parentChanged := false
+	if req.ParentRoleID != nil {
+		var parentRoleID *uint
+		if *req.ParentRoleID != 0 {
+			parentRoleID = req.ParentRoleID
+		}
+		parentChanged = !sameRoleID(role.ParentRoleID, parentRoleID)
+		role.ParentRoleID = parentRoleID
+	}
 
 	// 更新权限（如果有指定）
 	if req.PermissionIDs != nil {
@@ -563,6 +597,9 @@ func (s *RoleService) executeRoleUpdate(ctx context.Context, role *system.Role,
 		changes["permissions_changed"] = true
 		changes["permission_count"] = len(req.PermissionIDs)
 	}
+	if parentChanged {
+		changes["parent_role_changed"] = role.ParentRoleID
+	}
 
 	logger.LogBusinessOperation("update_role", 0, "", clientIP, "", "success", "角色更新成功", map[string]interface{}{
 		"operation":  "role_update_success",
@@ -579,6 +616,7 @@ func (s *RoleService) executeRoleUpdate(ctx context.Context, role *system.Role,
 
 // DeleteRole 删除角色
 // 完整的业务逻辑包括：参数验证、业务规则检查、级联删除、事务处理、审计日志
+// 角色仍被其他角色作为父角色时拒绝删除：子角色会静默失去继承的权限，需先调整子角色的父角色
 func (s *RoleService) DeleteRole(ctx context.Context, roleID uint) error {
 	// 第一层：参数验证层
 	if err := s.validateDeleteRoleParams(ctx, roleID); err != nil {
@@ -596,7 +634,11 @@ func (s *RoleService) DeleteRole(ctx context.Context, roleID uint) error {
 	}
 
 	// 第三层：事务处理层
-	return s.executeRoleDeletion(ctx, role)
+	if err := s.executeRoleDeletion(ctx, role); err != nil {
+		return err
+	}
+	s.invalidateAllPermissions(ctx)
+	return nil
 }
 
 // validateDeleteRoleParams 验证删除角色的参数
@@ -664,6 +706,28 @@ func (s *RoleService) validateRoleForDeletion(ctx context.Context, roleID uint)
 		return nil, errors.New("系统角色不能被删除")
 	}
 
+	// 业务规则：存在子角色时不能删除
+	childCount, err := s.roleRepo.CountChildRoles(ctx, roleID)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, clientIP, "delete_role", "SERVICE", map[string]interface{}{
+			"operation": "child_role_check",
+			"role_id":   roleID,
+			"error":     "database_query_failed",
+			"timestamp": logger.NowFormatted(),
+		})
+		return nil, fmt.Errorf("检查子角色失败: %w", err)
+	}
+	if childCount > 0 {
+		logger.LogBusinessError(errors.New("role has child roles"), "", 0, clientIP, "delete_role", "SERVICE", map[string]interface{}{
+			"operation":   "business_rule_check",
+			"role_id":     roleID,
+			"child_count": childCount,
+			"error":       "role_has_children",
+			"timestamp":   logger.NowFormatted(),
+		})
+		return nil, fmt.Errorf("角色存在%d个子角色，请先调整子角色的父角色", childCount)
+	}
+
 	return role, nil
 }
 
@@ -835,6 +899,7 @@ func (s *RoleService) UpdateRoleStatus(ctx context.Context, roleID uint, status
 		})
 		return fmt.Errorf("%s角色失败: %w", statusText, err)
 	}
+	s.invalidateAllPermissions(ctx)
 
 	// 审计日志层 - 记录成功操作
 	statusText := "禁用"
@@ -932,7 +997,11 @@ func (s *RoleService) AssignPermissionToRole(ctx context.Context, roleID, permis
 	}
 
 	// 调用数据访问层分配权限
-	return s.roleRepo.AssignPermissionToRole(ctx, roleID, permissionID)
+	if err := s.roleRepo.AssignPermissionToRole(ctx, roleID, permissionID); err != nil {
+		return err
+	}
+	s.invalidateAllPermissions(ctx)
+	return nil
 }
 
 // RemovePermissionFromRole 移除角色权限
@@ -946,5 +1015,74 @@ func (s *RoleService) RemovePermissionFromRole(ctx context.Context, roleID, perm
 	}
 
 	// 调用数据访问层移除权限
-	return s.roleRepo.RemovePermissionFromRole(ctx, roleID, permissionID)
+	if err := s.roleRepo.RemovePermissionFromRole(ctx, roleID, permissionID); err != nil {
+		return err
+	}
+	s.invalidateAllPermissions(ctx)
+	return nil
+}
+
+// validateParentRole 校验父角色：父角色必须存在，且沿父角色链向上不能回到 roleID(否则形成继承环)
+// roleID 为 0 表示新建角色，只校验父角色存在
+func (s *RoleService) validateParentRole(ctx context.Context, roleID, parentID uint) error {
+	clientIP := utils.GetClientIPFromContext(ctx)
+	if parentID == roleID {
+		logger.LogBusinessError(errors.New("role cannot inherit from itself"), "", 0, clientIP, "validate_parent_role", "SERVICE", map[string]interface{}{
+			"operation": "parent_role_check",
+			"role_id":   roleID,
+			"error":     "self_parent",
+			"timestamp": logger.NowFormatted(),
+		})
+		return errors.New("角色不能继承自身")
+	}
+
+	visited := make(map[uint]bool)
+	for id := parentID; id != 0; {
+		if id == roleID {
+			logger.LogBusinessError(errors.New("role inheritance cycle"), "", 0, clientIP, "validate_parent_role", "SERVICE", map[string]interface{}{
+				"operation":      "parent_role_check",
+				"role_id":        roleID,
+				"parent_role_id": parentID,
+				"error":          "inheritance_cycle",
+				"timestamp":      logger.NowFormatted(),
+			})
+			return errors.New("父角色不能是当前角色的子孙角色")
+		}
+		// 已有数据中存在环时停止向上查找，避免死循环
+		if visited[id] {
+			break
+		}
+		visited[id] = true
+
+		role, err := s.roleRepo.GetRoleByID(ctx, id)
+		if err != nil {
+			logger.LogBusinessError(err, "", 0, clientIP, "validate_parent_role", "SERVICE", map[string]interface{}{
+				"operation":      "parent_role_check",
+				"role_id":        roleID,
+				"parent_role_id": id,
+				"error":          "database_query_failed",
+				"timestamp":      logger.NowFormatted(),
+			})
+			return fmt.Errorf("获取父角色失败: %w", err)
+		}
+		if role == nil {
+			if id == parentID {
+				return errors.New("父角色不存在")
+			}
+			break
+		}
+		id = 0
+		if role.ParentRoleID != nil {
+			id = *role.ParentRoleID
+		}
+	}
+	return nil
+}
+
+// sameRoleID 比较两个可空角色ID是否相同
+func sameRoleID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"neomaster/internal/model/system"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newRoleHierarchyTestDB 三级角色链: auditor(20) <- operator(21) <- manager(22)
+// 用户 2 只直接拥有 manager
+func newRoleHierarchyTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&system.User{}, &system.Role{}, &system.Permission{}))
+
+	uintPtr := func(v uint) *uint { return &v }
+	require.NoError(t, db.Create(&system.User{ID: 2, Username: "alice", Email: "alice@example.com", Password: "x", Status: system.UserStatusEnabled}).Error)
+	require.NoError(t, db.Create([]*system.Permission{
+		{ID: 1, Name: "scan:read", Resource: "scan", Action: "read"},
+		{ID: 2, Name: "scan:create", Resource: "scan", Action: "create"},
+		{ID: 3, Name: "user:manage", Resource: "user", Action: "manage"},
+	}).Error)
+	require.NoError(t, db.Create([]*system.Role{
+		{ID: 20, Name: "auditor", Status: system.RoleStatusEnabled},
+		{ID: 21, Name: "operator", Status: system.RoleStatusEnabled, ParentRoleID: uintPtr(20)},
+		{ID: 22, Name: "manager", Status: system.RoleStatusEnabled, ParentRoleID: uintPtr(21)},
+	}).Error)
+	// operator 与 auditor 都有 scan:read，继承后应去重
+	require.NoError(t, db.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (20, 1), (21, 1), (21, 2), (22, 3)").Error)
+	require.NoError(t, db.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (2, 22)").Error)
+	return db
+}
+
+func TestUserService_GetUserPermissions_InheritsParentChain(t *testing.T) {
+	ctx := context.Background()
+	db := newRoleHierarchyTestDB(t)
+	svc := NewUserService(systemrepo.NewUserRepository(db), nil, nil, nil)
+
+	perms, err := svc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"scan:read", "scan:create", "user:manage"}, permissionNames(perms))
+
+	// 数据中存在环(auditor 的父角色被改成 manager)时解析仍能终止
+	require.NoError(t, db.Model(&system.Role{}).Where("id = ?", 20).Update("parent_role_id", 22).Error)
+	perms, err = svc.GetUserPermissions(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, perms, 3)
+}
+
+func TestRoleService_ParentRoleValidation(t *testing.T) {
+	ctx := context.Background()
+	db := newRoleHierarchyTestDB(t)
+	svc := NewRoleService(systemrepo.NewRoleRepository(db))
+	uintPtr := func(v uint) *uint { return &v }
+
+	// 把链顶 auditor 的父角色设为链底 manager 会形成环
+	_, err := svc.UpdateRoleByID(ctx, 20, &system.UpdateRoleRequest{ParentRoleID: uintPtr(22)})
+	assert.Error(t, err)
+	_, err = svc.UpdateRoleByID(ctx, 21, &system.UpdateRoleRequest{ParentRoleID: uintPtr(21)})
+	assert.Error(t, err)
+	_, err = svc.CreateRole(ctx, &system.CreateRoleRequest{Name: "orphan", ParentRoleID: uintPtr(99)})
+	assert.Error(t, err)
+
+	child, err := svc.CreateRole(ctx, &system.CreateRoleRequest{Name: "intern", ParentRoleID: uintPtr(20)})
+	require.NoError(t, err)
+	require.NotNil(t, child.ParentRoleID)
+	assert.Equal(t, uint(20), *child.ParentRoleID)
+
+	// 存在子角色的角色不能删除；子角色取消继承后可以删除
+	assert.Error(t, svc.DeleteRole(ctx, 21))
+	_, err = svc.UpdateRoleByID(ctx, 22, &system.UpdateRoleRequest{ParentRoleID: uintPtr(0)})
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteRole(ctx, 21))
+
+	var manager system.Role
+	require.NoError(t, db.First(&manager, 22).Error)
+	assert.Nil(t, manager.ParentRoleID)
+}
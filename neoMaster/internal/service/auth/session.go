@@ -41,6 +41,7 @@ type SessionService struct {
 	tokenGenerator  TokenGenerator // 使用接口而不是具体实现
 	rbacService     *RBACService
 	sessionRepo     *redis.SessionRepository
+	totpService     *TOTPService // 两步验证服务，未设置时已启用两步验证的用户无法登录
 }
 
 // NewSessionService 创建会话服务实例
@@ -64,6 +65,19 @@ func (s *SessionService) SetTokenGenerator(tokenGenerator TokenGenerator) {
 	s.tokenGenerator = tokenGenerator
 }
 
+// SetTOTPService 设置两步验证服务
+func (s *SessionService) SetTOTPService(totpService *TOTPService) {
+	s.totpService = totpService
+}
+
+// verifyTOTP 校验登录动态码，未设置两步验证服务时拒绝登录
+func (s *SessionService) verifyTOTP(ctx context.Context, user *system.User, code string) error {
+	if s.totpService == nil {
+		return system.ErrTOTPUnavailable
+	}
+	return s.totpService.VerifyLoginCode(ctx, user, code)
+}
+
 // Login 用户登录
 // clientIP: 客户端IP地址，从HTTP请求中获取
 // userAgent: 用户代理信息，从HTTP请求头中获取
@@ -203,6 +217,36 @@ func (s *SessionService) Login(ctx context.Context, req *system.LoginRequest, cl
 		return nil, errors.New("invalid username or password")
 	}
 
+	// 已启用两步验证的用户还需校验动态码
+	if user.TOTPEnabled {
+		if err := s.verifyTOTP(ctx, user, req.TOTPCode); err != nil {
+			logger.LogBusinessError(err, "", uint(user.ID), clientIP, "user_login", "POST", map[string]interface{}{
+				"operation":  "login",
+				"option":     "VerifyTOTP",
+				"func_name":  "service.auth.session.Login",
+				"client_ip":  clientIP,
+				"user_agent": userAgent,
+				"user_id":    user.ID,
+				"username":   user.Username,
+				"timestamp":  logger.NowFormatted(),
+			})
+			return nil, err
+		}
+	}
+
+	// 哈希参数弱于当前配置时用明文重新哈希，失败不影响登录
+	if s.passwordManager.NeedsRehash(user.Password) {
+		if err := s.userService.RehashPassword(ctx, user, req.Password); err != nil {
+			logger.LogWarn("Failed to rehash password", "", uint(user.ID), clientIP, "user_login", "POST", map[string]interface{}{
+				"operation": "login",
+				"option":    "RehashPassword",
+				"func_name": "service.auth.session.Login",
+				"user_id":   user.ID,
+				"error":     err.Error(),
+			})
+		}
+	}
+
 	// 生成JWT令牌对
 	tokenPair, err := s.tokenGenerator.GenerateTokens(ctx, user)
 	if err != nil {
@@ -0,0 +1,250 @@
+/*
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: 两步验证(TOTP)服务
+ * @func:
+ * 1.Enroll 生成密钥并加密保存(未启用)，返回认证器配置URI
+ * 2.Activate 校验动态码后启用两步验证
+ * 3.Disable 校验动态码后关闭两步验证并清除密钥
+ * 4.VerifyLoginCode 登录时校验动态码
+ */
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"neomaster/internal/model/system"
+	"neomaster/internal/pkg/auth"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"gorm.io/gorm"
+)
+
+// defaultTOTPIssuer 未配置 security.totp.issuer 时认证器中显示的签发方
+const defaultTOTPIssuer = "NeoScan"
+
+// totpUsedStepTTL 已用时间步的保留时间，覆盖动态码可被接受的整个窗口
+const totpUsedStepTTL = (2*auth.TOTPSkew + 1) * auth.TOTPPeriod * time.Second
+
+// TOTPUsedStepStore 已用时间步存储 (redis.TOTPRepository 已实现)
+type TOTPUsedStepStore interface {
+	MarkStepUsed(ctx context.Context, userID uint, step uint64, ttl time.Duration) (bool, error)
+}
+
+// TOTPService 两步验证服务
+type TOTPService struct {
+	userRepo      *systemrepo.UserRepository
+	usedSteps     TOTPUsedStepStore
+	issuer        string
+	encryptionKey string           // 密钥落库加密密钥，为空时不能绑定
+	now           func() time.Time // 当前时间，测试时可替换
+}
+
+// NewTOTPService 创建两步验证服务实例
+func NewTOTPService(userRepo *systemrepo.UserRepository, usedSteps TOTPUsedStepStore, issuer, encryptionKey string) *TOTPService {
+	if issuer == "" {
+		issuer = defaultTOTPIssuer
+	}
+	return &TOTPService{
+		userRepo:      userRepo,
+		usedSteps:     usedSteps,
+		issuer:        issuer,
+		encryptionKey: encryptionKey,
+		now:           time.Now,
+	}
+}
+
+// Enroll 为用户生成新的 TOTP 密钥
+// 密钥加密后保存，两步验证保持未启用，直到 Activate 校验通过；重复调用会替换未启用的密钥
+func (s *TOTPService) Enroll(ctx context.Context, userID uint) (*system.TOTPEnrollResponse, error) {
+	if s.encryptionKey == "" {
+		return nil, system.ErrTOTPUnavailable
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, system.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateUserFields(ctx, userID, map[string]interface{}{
+		"totp_secret":  encrypted,
+		"totp_enabled": false,
+		"updated_at":   time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("保存两步验证密钥失败: %w", err)
+	}
+
+	logger.LogBusinessOperation("totp_enroll", userID, user.Username, "", "", "success", "两步验证密钥已生成", map[string]interface{}{
+		"operation": "totp_enroll",
+		"func_name": "service.auth.totp.Enroll",
+		"user_id":   userID,
+	})
+	return &system.TOTPEnrollResponse{
+		Secret:     secret,
+		OTPAuthURL: auth.TOTPProvisioningURI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Activate 校验认证器生成的动态码，通过后启用两步验证
+func (s *TOTPService) Activate(ctx context.Context, userID uint, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return system.ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return system.ErrTOTPNotEnrolled
+	}
+	if err := s.verifyCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateUserFields(ctx, userID, map[string]interface{}{
+		"totp_enabled": true,
+		"updated_at":   time.Now(),
+	}); err != nil {
+		return fmt.Errorf("启用两步验证失败: %w", err)
+	}
+
+	logger.LogBusinessOperation("totp_activate", userID, user.Username, "", "", "success", "两步验证已启用", map[string]interface{}{
+		"operation": "totp_activate",
+		"func_name": "service.auth.totp.Activate",
+		"user_id":   userID,
+	})
+	return nil
+}
+
+// Disable 校验动态码后关闭两步验证并清除密钥
+func (s *TOTPService) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return system.ErrTOTPNotEnrolled
+	}
+	if err := s.verifyCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateUserFields(ctx, userID, map[string]interface{}{
+		"totp_secret":  "",
+		"totp_enabled": false,
+		"updated_at":   time.Now(),
+	}); err != nil {
+		return fmt.Errorf("关闭两步验证失败: %w", err)
+	}
+
+	logger.LogBusinessOperation("totp_disable", userID, user.Username, "", "", "success", "两步验证已关闭", map[string]interface{}{
+		"operation": "totp_disable",
+		"func_name": "service.auth.totp.Disable",
+		"user_id":   userID,
+	})
+	return nil
+}
+
+// VerifyLoginCode 登录时校验动态码，用户未启用两步验证时直接通过
+func (s *TOTPService) VerifyLoginCode(ctx context.Context, user *system.User, code string) error {
+	if !user.TOTPEnabled {
+		return nil
+	}
+	if code == "" {
+		return system.ErrTOTPRequired
+	}
+	return s.verifyCode(ctx, user, code)
+}
+
+// verifyCode 解密用户密钥并校验动态码，同一时间步的动态码只能使用一次
+func (s *TOTPService) verifyCode(ctx context.Context, user *system.User, code string) error {
+	secret, err := s.decryptSecret(user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+	step, ok, err := auth.ValidateTOTP(secret, code, s.now())
+	if err != nil {
+		return fmt.Errorf("校验两步验证动态码失败: %w", err)
+	}
+	if !ok {
+		return system.ErrTOTPInvalid
+	}
+
+	// 防重放存储不可用时拒绝校验，不能退化为允许重放
+	if s.usedSteps == nil {
+		return system.ErrTOTPUnavailable
+	}
+	first, err := s.usedSteps.MarkStepUsed(ctx, user.ID, step, totpUsedStepTTL)
+	if err != nil {
+		return fmt.Errorf("记录两步验证动态码失败: %w", err)
+	}
+	if !first {
+		logger.LogWarn("TOTP code replayed", "", user.ID, "", "totp_verify", "SERVICE", map[string]interface{}{
+			"operation": "totp_verify",
+			"option":    "replay_rejected",
+			"func_name": "service.auth.totp.verifyCode",
+			"user_id":   user.ID,
+		})
+		return system.ErrTOTPInvalid
+	}
+	return nil
+}
+
+// getUser 获取用户，不存在时返回 ErrUserNotFound
+func (s *TOTPService) getUser(ctx context.Context, userID uint) (*system.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, system.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, system.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// encryptSecret 使用配置的加密密钥加密 TOTP 密钥(AES-GCM，base64 存储)
+func (s *TOTPService) encryptSecret(secret string) (string, error) {
+	ciphertext, err := utils.EncryptDataAESGCM(s.encryptionKey, []byte(secret))
+	if err != nil {
+		return "", fmt.Errorf("加密两步验证密钥失败: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptSecret 解密落库的 TOTP 密钥
+func (s *TOTPService) decryptSecret(encrypted string) (string, error) {
+	if s.encryptionKey == "" {
+		return "", system.ErrTOTPUnavailable
+	}
+	if encrypted == "" {
+		return "", system.ErrTOTPNotEnrolled
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("解密两步验证密钥失败: %w", err)
+	}
+	plaintext, err := utils.DecryptDataAESGCM(s.encryptionKey, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("解密两步验证密钥失败: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"neomaster/internal/model/system"
+	pkgauth "neomaster/internal/pkg/auth"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryTOTPUsedSteps 进程内的已用时间步存储(忽略有效期)
+type memoryTOTPUsedSteps struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+func (m *memoryTOTPUsedSteps) MarkStepUsed(_ context.Context, userID uint, step uint64, _ time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := fmt.Sprintf("%d:%d", userID, step)
+	if m.used[key] {
+		return false, nil
+	}
+	m.used[key] = true
+	return true, nil
+}
+
+func TestTOTPService_EnrollActivateLoginDisable(t *testing.T) {
+	ctx := context.Background()
+	repo := systemrepo.NewUserRepository(newUserUpdateTestDB(t))
+	svc := NewTOTPService(repo, &memoryTOTPUsedSteps{used: map[string]bool{}}, "", "test-totp-key")
+	now := time.Unix(1700000000, 0)
+	svc.now = func() time.Time { return now }
+	codeAt := func(secret string, at time.Time) string {
+		code, err := pkgauth.TOTPCode(secret, pkgauth.TOTPStep(at))
+		require.NoError(t, err)
+		return code
+	}
+
+	enroll, err := svc.Enroll(ctx, 2)
+	require.NoError(t, err)
+	assert.Contains(t, enroll.OTPAuthURL, "NeoScan:alice")
+
+	// 密钥加密落库，且绑定后尚未启用
+	stored, err := repo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	assert.False(t, stored.TOTPEnabled)
+	assert.NotEmpty(t, stored.TOTPSecret)
+	assert.False(t, strings.Contains(stored.TOTPSecret, enroll.Secret), "secret must not be stored in plaintext")
+
+	assert.ErrorIs(t, svc.Activate(ctx, 2, "000000"), system.ErrTOTPInvalid)
+	require.NoError(t, svc.Activate(ctx, 2, codeAt(enroll.Secret, now)))
+	_, err = svc.Enroll(ctx, 2)
+	assert.ErrorIs(t, err, system.ErrTOTPAlreadyEnabled)
+
+	user, err := repo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	require.True(t, user.TOTPEnabled)
+
+	// 登录：缺少动态码、重放启用时用过的动态码均被拒绝
+	assert.ErrorIs(t, svc.VerifyLoginCode(ctx, user, ""), system.ErrTOTPRequired)
+	assert.ErrorIs(t, svc.VerifyLoginCode(ctx, user, codeAt(enroll.Secret, now)), system.ErrTOTPInvalid)
+
+	// 下一个时间步的动态码只能使用一次；认证器时钟快一步的动态码同样被接受
+	now = now.Add(pkgauth.TOTPPeriod * time.Second)
+	code := codeAt(enroll.Secret, now)
+	require.NoError(t, svc.VerifyLoginCode(ctx, user, code))
+	assert.ErrorIs(t, svc.VerifyLoginCode(ctx, user, code), system.ErrTOTPInvalid)
+	now = now.Add(pkgauth.TOTPPeriod * time.Second)
+	require.NoError(t, svc.VerifyLoginCode(ctx, user, codeAt(enroll.Secret, now.Add(pkgauth.TOTPPeriod*time.Second))))
+
+	// 关闭后清除密钥
+	now = now.Add(2 * pkgauth.TOTPPeriod * time.Second)
+	require.NoError(t, svc.Disable(ctx, 2, codeAt(enroll.Secret, now)))
+	user, err = repo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	assert.False(t, user.TOTPEnabled)
+	assert.Empty(t, user.TOTPSecret)
+	assert.NoError(t, svc.VerifyLoginCode(ctx, user, ""))
+}
+
+func TestTOTPService_EnrollRequiresEncryptionKey(t *testing.T) {
+	svc := NewTOTPService(systemrepo.NewUserRepository(newUserUpdateTestDB(t)), &memoryTOTPUsedSteps{used: map[string]bool{}}, "", "")
+	_, err := svc.Enroll(context.Background(), 2)
+	assert.ErrorIs(t, err, system.ErrTOTPUnavailable)
+}
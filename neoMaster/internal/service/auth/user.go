@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"neomaster/internal/pkg/auth"
+	"neomaster/internal/pkg/database"
 	"neomaster/internal/pkg/logger"
 	"neomaster/internal/pkg/utils"
 	"neomaster/internal/repo/redis"
@@ -34,6 +35,8 @@ type UserService struct {
 	redisRepo       *redis.SessionRepository   // Redis缓存仓库
 	passwordManager *auth.PasswordManager      // 密码管理器
 	jwtManager      *auth.JWTManager           // JWT管理器
+	permCache       PermissionCacheStore       // 用户权限集缓存，未注入时直接查询数据库
+	permCacheTTL    time.Duration              // 权限集缓存有效期
 }
 
 // NewUserService 创建新的用户服务实例
@@ -361,11 +364,11 @@ func (s *UserService) GetUserIDFromToken(ctx context.Context, accessToken string
 // GetCurrentUserInfo 获取当前用户信息（从访问令牌获取用户ID）
 // 通过访问令牌获取当前登录用户的详细信息
 func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string) (*system.UserInfo, error) {
-	// 从标准上下文中 context 获取必要的信息[已在中间件中做过标准化处理]
-	clientIP := utils.GetClientIPFromContext(ctx)
+	// 从标准上下文中 context 获取请求ID、客户端IP等关联信息[已在中间件中做过标准化处理]
+	log := logger.WithContext(ctx).WithOperation("get_current_user", "GET")
 	// 验证访问令牌
 	if accessToken == "" {
-		logger.LogBusinessError(errors.New("access token is empty"), "", 0, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(errors.New("access token is empty"), map[string]interface{}{
 			"operation": "get_current_user",
 			"timestamp": logger.NowFormatted(),
 		})
@@ -375,7 +378,7 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 	// 解析JWT令牌
 	claims, err := s.jwtManager.ValidateAccessToken(accessToken)
 	if err != nil {
-		logger.LogBusinessError(err, "", 0, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(err, map[string]interface{}{
 			"operation": "get_current_user",
 			"timestamp": logger.NowFormatted(),
 		})
@@ -383,11 +386,12 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 	}
 
 	userID := claims.UserID
+	log = log.WithUserID(userID)
 
 	// 检查会话是否有效
 	sessionData, err := s.redisRepo.GetSession(ctx, uint64(userID))
 	if err != nil {
-		logger.LogBusinessError(err, "", userID, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(err, map[string]interface{}{
 			"operation": "get_current_user",
 			"user_id":   userID,
 			"timestamp": logger.NowFormatted(),
@@ -396,7 +400,7 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 	}
 
 	if sessionData == nil {
-		logger.LogBusinessError(errors.New("session not found"), "", userID, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(errors.New("session not found"), map[string]interface{}{
 			"operation": "get_current_user",
 			"user_id":   userID,
 			"timestamp": logger.NowFormatted(),
@@ -407,7 +411,7 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 	// 获取用户信息
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
-		logger.LogBusinessError(err, "", userID, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(err, map[string]interface{}{
 			"operation": "get_current_user",
 			"user_id":   userID,
 			"timestamp": logger.NowFormatted(),
@@ -416,7 +420,7 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 	}
 
 	if user == nil {
-		logger.LogBusinessError(errors.New("user not found"), "", userID, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(errors.New("user not found"), map[string]interface{}{
 			"operation": "get_current_user",
 			"user_id":   userID,
 			"timestamp": logger.NowFormatted(),
@@ -427,7 +431,7 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 	// 获取用户角色和权限
 	roles, err := s.userRepo.GetUserRoles(ctx, userID)
 	if err != nil {
-		logger.LogBusinessError(err, "", userID, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(err, map[string]interface{}{
 			"operation": "get_current_user",
 			"user_id":   userID,
 			"username":  user.Username,
@@ -436,9 +440,9 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 		return nil, fmt.Errorf("获取用户角色失败: %w", err)
 	}
 
-	permissions, err := s.userRepo.GetUserPermissions(ctx, userID)
+	permissions, err := s.loadUserPermissions(ctx, userID)
 	if err != nil {
-		logger.LogBusinessError(err, "", userID, clientIP, "get_current_user", "GET", map[string]interface{}{
+		log.BusinessError(err, map[string]interface{}{
 			"operation": "get_current_user",
 			"user_id":   userID,
 			"username":  user.Username,
@@ -472,10 +476,11 @@ func (s *UserService) GetCurrentUserInfo(ctx context.Context, accessToken string
 		Roles:       roleNames,
 		Permissions: permissionNames,
 		Remark:      user.Remark,
+		LockVersion: user.LockVersion,
 	}
 
 	// 记录成功获取用户信息的业务日志
-	logger.LogBusinessOperation("get_current_user", userID, user.Username, clientIP, "", "success", "获取当前用户信息成功", map[string]interface{}{
+	log.BusinessOperation("get_current_user", user.Username, "success", "获取当前用户信息成功", map[string]interface{}{
 		"user_id":   userID,
 		"username":  user.Username,
 		"timestamp": logger.NowFormatted(),
@@ -539,7 +544,7 @@ func (s *UserService) GetUserInfoByID(ctx context.Context, userID uint) (*system
 		return nil, fmt.Errorf("获取用户角色失败: %w", err)
 	}
 
-	permissions, err := s.userRepo.GetUserPermissions(ctx, userID)
+	permissions, err := s.loadUserPermissions(ctx, userID)
 	if err != nil {
 		logger.LogBusinessError(err, "", userID, clientIP, "get_user_info_by_id", "SERVICE", map[string]interface{}{
 			"operation": "get_user_info_by_id",
@@ -575,6 +580,7 @@ func (s *UserService) GetUserInfoByID(ctx context.Context, userID uint) (*system
 		Roles:       roleNames,
 		Permissions: permissionNames,
 		Remark:      user.Remark,
+		LockVersion: user.LockVersion,
 	}
 
 	// 记录成功获取用户信息的业务日志
@@ -620,6 +626,57 @@ func (s *UserService) UpdateUserByID(ctx context.Context, userID uint, req *syst
 	return s.executeUserUpdate(ctx, user, req)
 }
 
+// PatchUserByID 局部更新用户信息 (PATCH 语义)
+// 字段为 nil 表示不修改；昵称、手机、头像、备注、SocketID 携带空字符串时清空，
+// 这是与 UpdateUserByID (空字符串视为不修改) 的唯一区别，其余校验与更新流程相同
+// @param ctx 上下文
+// @param userID 用户ID
+// @param req 局部更新用户请求（管理员使用）
+// @return 更新后的用户信息和错误
+func (s *UserService) PatchUserByID(ctx context.Context, userID uint, req *system.PatchUserRequest) (*system.User, error) {
+	if req == nil {
+		return nil, s.validateUpdateUserParams(ctx, userID, nil)
+	}
+	if err := validatePatchUserRequiredFields(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	updateReq := req.ToUpdateRequest()
+	if err := s.validateUpdateUserParams(ctx, userID, updateReq); err != nil {
+		return nil, err
+	}
+	user, err := s.validateUserForUpdate(ctx, userID, updateReq)
+	if err != nil {
+		return nil, err
+	}
+
+	req.ApplyClearableFields(user)
+	return s.executeUserUpdate(ctx, user, updateReq)
+}
+
+// validatePatchUserRequiredFields 用户名、邮箱、密码不允许通过 PATCH 清空
+func validatePatchUserRequiredFields(ctx context.Context, userID uint, req *system.PatchUserRequest) error {
+	clientIP := utils.GetClientIPFromContext(ctx)
+	fields := []struct {
+		name  string
+		value *string
+	}{{"username", req.Username}, {"email", req.Email}, {"password", req.Password}}
+	for _, field := range fields {
+		if field.value == nil || *field.value != "" {
+			continue
+		}
+		logger.LogBusinessError(errors.New("required field cleared"), "", 0, clientIP, "patch_user", "SERVICE", map[string]interface{}{
+			"operation": "parameter_validation",
+			"user_id":   userID,
+			"field":     field.name,
+			"error":     "required_field_empty",
+			"timestamp": logger.NowFormatted(),
+		})
+		return fmt.Errorf("%s 不能为空", field.name)
+	}
+	return nil
+}
+
 // validateUpdateUserParams 验证更新用户的参数
 func (s *UserService) validateUpdateUserParams(ctx context.Context, userID uint, req *system.UpdateUserRequest) error {
 	// 从标准上下文中 context 获取必要的信息[已在中间件中做过标准化处理]
@@ -725,6 +782,11 @@ func (s *UserService) validateUserForUpdate(ctx context.Context, userID uint, re
 		return nil, errors.New("user already deleted, cannot update")
 	}
 
+	// 乐观锁：客户端携带的版本号已过期，说明编辑期间用户已被他人修改
+	if err := checkUserLockVersion(clientIP, user, req); err != nil {
+		return nil, err
+	}
+
 	// 业务规则：系统管理员账户的特殊限制
 	if userID == 1 {
 		// 系统管理员不能被禁用或锁定
@@ -826,35 +888,26 @@ func (s *UserService) validateUserForUpdate(ctx context.Context, userID uint, re
 	return user, nil
 }
 
+// checkUserLockVersion 校验请求携带的乐观锁版本号，未携带时以读取到的版本为准
+func checkUserLockVersion(clientIP string, user *system.User, req *system.UpdateUserRequest) error {
+	if req.LockVersion == nil || *req.LockVersion == user.LockVersion {
+		return nil
+	}
+	logger.LogBusinessError(system.ErrConcurrentModification, "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
+		"operation":       "lock_version_check",
+		"user_id":         user.ID,
+		"request_version": *req.LockVersion,
+		"current_version": user.LockVersion,
+		"error":           "concurrent_modification",
+		"timestamp":       logger.NowFormatted(),
+	})
+	return system.ErrConcurrentModification
+}
+
 // executeUserUpdate 执行用户更新操作（包含事务处理）
 func (s *UserService) executeUserUpdate(ctx context.Context, user *system.User, req *system.UpdateUserRequest) (*system.User, error) {
 	// 从标准上下文中 context 获取必要的信息[已在log中间件中做过标准化处理]
 	clientIP := utils.GetClientIPFromContext(ctx)
-	// 开始事务
-	tx := s.userRepo.BeginTx(ctx)
-	if tx == nil {
-		logger.LogBusinessError(errors.New("failed to begin transaction"), "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
-			"operation": "transaction_begin",
-			"user_id":   user.ID,
-			"error":     "transaction_begin_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return nil, errors.New("开始事务失败")
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			logger.LogBusinessError(fmt.Errorf("panic during user update: %v", r), "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
-				"operation": "panic_recovery",
-				"user_id":   user.ID,
-				"error":     "panic_occurred",
-				"panic":     r,
-				"timestamp": logger.NowFormatted(),
-			})
-		}
-	}()
-
 	// 记录更新前的状态
 	oldEmail := user.Email
 	oldStatus := user.Status
@@ -893,7 +946,6 @@ func (s *UserService) executeUserUpdate(ctx context.Context, user *system.User,
 	if req.Password != "" {
 		hashedPassword, err := s.passwordManager.HashPassword(req.Password)
 		if err != nil {
-			tx.Rollback()
 			logger.LogBusinessError(err, "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
 				"operation": "password_hash",
 				"user_id":   user.ID,
@@ -907,7 +959,7 @@ func (s *UserService) executeUserUpdate(ctx context.Context, user *system.User,
 		passwordChanged = true
 	}
 
-	// 更新用户角色信息(如果有指定角色ID)
+	// 更新用户角色信息(如果有指定角色ID)，后续更新操作 UpdateUserWithTx 会创建新的关联
 	if req.RoleIDs != nil {
 		// 获取用户角色信息封装到结构体中
 		roles := make([]*system.Role, len(req.RoleIDs))
@@ -915,21 +967,6 @@ func (s *UserService) executeUserUpdate(ctx context.Context, user *system.User,
 			roles[i] = &system.Role{ID: id}
 		}
 		user.Roles = roles
-
-		// 先删除旧的角色关联
-		if err := s.userRepo.DeleteUserRolesByUserID(ctx, tx, user.ID); err != nil {
-			tx.Rollback()
-			logger.LogBusinessError(err, "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
-				"operation": "cascade_delete_user_roles",
-				"user_id":   user.ID,
-				"error":     "delete_user_roles_failed",
-				"timestamp": logger.NowFormatted(),
-			})
-			return nil, fmt.Errorf("删除用户角色关联失败: %w", err)
-		}
-
-		// 然后更新为新的角色关联(后续更新操作 UpdateUserWithTx 会创建新的关联)
-
 	}
 
 	// socket_id 更新
@@ -937,27 +974,46 @@ func (s *UserService) executeUserUpdate(ctx context.Context, user *system.User,
 		user.SocketId = req.SocketID
 	}
 
-	// 更新到数据库
-	if err := s.userRepo.UpdateUserWithTx(ctx, tx, user); err != nil {
-		tx.Rollback()
-		logger.LogBusinessError(err, "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
-			"operation": "database_update",
-			"user_id":   user.ID,
-			"error":     "update_user_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return nil, fmt.Errorf("更新用户失败: %w", err)
-	}
+	err := s.userRepo.WithTransaction(ctx, func(tx *gorm.DB) error {
+		// 先删除旧的角色关联
+		if req.RoleIDs != nil {
+			if err := s.userRepo.DeleteUserRolesByUserID(ctx, tx, user.ID); err != nil {
+				logger.LogBusinessError(err, "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
+					"operation": "cascade_delete_user_roles",
+					"user_id":   user.ID,
+					"error":     "delete_user_roles_failed",
+					"timestamp": logger.NowFormatted(),
+				})
+				return fmt.Errorf("删除用户角色关联失败: %w", err)
+			}
+		}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		logger.LogBusinessError(err, "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
-			"operation": "transaction_commit",
-			"user_id":   user.ID,
-			"error":     "transaction_commit_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return nil, fmt.Errorf("提交事务失败: %w", err)
+		// 更新到数据库
+		if err := s.userRepo.UpdateUserWithTx(ctx, tx, user); err != nil {
+			logger.LogBusinessError(err, "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
+				"operation": "database_update",
+				"user_id":   user.ID,
+				"error":     "update_user_failed",
+				"timestamp": logger.NowFormatted(),
+			})
+			return fmt.Errorf("更新用户失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if database.IsTransactionError(err) {
+			logger.LogBusinessError(err, "", 0, clientIP, "update_user", "SERVICE", map[string]interface{}{
+				"operation": "transaction",
+				"user_id":   user.ID,
+				"error":     "transaction_failed",
+				"timestamp": logger.NowFormatted(),
+			})
+		}
+		return nil, err
+	}
+	// 角色或状态变化后缓存的权限集失效
+	if req.RoleIDs != nil || (req.Status != nil && *req.Status != oldStatus) {
+		s.invalidateUserPermissions(ctx, user.ID)
 	}
 
 	// 记录成功更新日志
@@ -1125,6 +1181,11 @@ func (s *UserService) validateUserUpdateInfo(ctx context.Context, userID uint, r
 		return nil, errors.New("user already deleted, cannot update")
 	}
 
+	// 乐观锁：客户端携带的版本号已过期，说明编辑期间用户已被他人修改
+	if err := checkUserLockVersion(clientIP, user, req); err != nil {
+		return nil, err
+	}
+
 	// 业务规则：系统管理员账户的特殊限制
 	if userID == 1 {
 		// 系统管理员不能被禁用或锁定
@@ -1202,31 +1263,6 @@ func (s *UserService) validateUserUpdateInfo(ctx context.Context, userID uint, r
 func (s *UserService) executeUserUpdateInfo(ctx context.Context, user *system.User, req *system.UpdateUserRequest) (*system.User, error) {
 	// 从标准上下文中 context 获取必要的信息[已在log中间件中做过标准化处理]
 	clientIP := utils.GetClientIPFromContext(ctx)
-	// 开始事务
-	tx := s.userRepo.BeginTx(ctx)
-	if tx == nil {
-		logger.LogBusinessError(errors.New("failed to begin transaction"), "", user.ID, clientIP, "update_user", "SERVICE", map[string]interface{}{
-			"operation": "transaction_begin",
-			"user_id":   user.ID,
-			"error":     "transaction_begin_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return nil, errors.New("开始事务失败")
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			logger.LogBusinessError(fmt.Errorf("panic during user update: %v", r), "", user.ID, clientIP, "update_user", "SERVICE", map[string]interface{}{
-				"operation": "panic_recovery",
-				"user_id":   user.ID,
-				"error":     "panic_occurred",
-				"panic":     r,
-				"timestamp": logger.NowFormatted(),
-			})
-		}
-	}()
-
 	// 记录更新前的状态
 	oldEmail := user.Email
 	oldUsername := user.Username
@@ -1273,26 +1309,23 @@ func (s *UserService) executeUserUpdateInfo(ctx context.Context, user *system.Us
 	}
 
 	// 更新到数据库
-	if err := s.userRepo.UpdateUserWithTx(ctx, tx, user); err != nil {
-		tx.Rollback()
-		logger.LogBusinessError(err, "", user.ID, clientIP, "update_user", "SERVICE", map[string]interface{}{
-			"operation": "database_update",
-			"user_id":   user.ID,
-			"error":     "update_user_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return nil, fmt.Errorf("更新用户失败: %w", err)
-	}
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
+	err := s.userRepo.WithTransaction(ctx, func(tx *gorm.DB) error {
+		return s.userRepo.UpdateUserWithTx(ctx, tx, user)
+	})
+	if err != nil {
+		operation, errType := "database_update", "update_user_failed"
+		if database.IsTransactionError(err) {
+			operation, errType = "transaction", "transaction_failed"
+		} else {
+			err = fmt.Errorf("更新用户失败: %w", err)
+		}
 		logger.LogBusinessError(err, "", user.ID, clientIP, "update_user", "SERVICE", map[string]interface{}{
-			"operation": "transaction_commit",
+			"operation": operation,
 			"user_id":   user.ID,
-			"error":     "transaction_commit_failed",
+			"error":     errType,
 			"timestamp": logger.NowFormatted(),
 		})
-		return nil, fmt.Errorf("提交事务失败: %w", err)
+		return nil, err
 	}
 
 	// 记录成功更新日志
@@ -1442,65 +1475,42 @@ func (s *UserService) validateUserForDeletion(ctx context.Context, userID uint)
 func (s *UserService) executeUserDeletion(ctx context.Context, user *system.User) error {
 	// 从标准上下文中 context 获取必要的信息[已在log中间件中做过标准化处理]
 	clientIP := utils.GetClientIPFromContext(ctx)
-	// 开始事务
-	tx := s.userRepo.BeginTx(ctx)
-	if tx == nil {
-		logger.LogBusinessError(errors.New("failed to begin transaction"), "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
-			"operation": "transaction_begin",
-			"user_id":   user.ID,
-			"error":     "transaction_begin_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return errors.New("开始事务失败")
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			logger.LogBusinessError(fmt.Errorf("panic during user deletion: %v", r), "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
-				"operation": "panic_recovery",
+	err := s.userRepo.WithTransaction(ctx, func(tx *gorm.DB) error {
+		// 1. 删除用户角色关联
+		if err := s.userRepo.DeleteUserRolesByUserID(ctx, tx, user.ID); err != nil {
+			logger.LogBusinessError(err, "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
+				"operation": "cascade_delete_user_roles",
 				"user_id":   user.ID,
-				"error":     "panic_occurred",
-				"panic":     r,
+				"error":     "delete_user_roles_failed",
 				"timestamp": logger.NowFormatted(),
 			})
+			return fmt.Errorf("删除用户角色关联失败: %w", err)
 		}
-	}()
 
-	// 1. 删除用户角色关联
-	if err := s.userRepo.DeleteUserRolesByUserID(ctx, tx, user.ID); err != nil {
-		tx.Rollback()
-		logger.LogBusinessError(err, "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
-			"operation": "cascade_delete_user_roles",
-			"user_id":   user.ID,
-			"error":     "delete_user_roles_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return fmt.Errorf("删除用户角色关联失败: %w", err)
-	}
-
-	// 2. 删除用户
-	if err := s.userRepo.DeleteUserWithTx(ctx, tx, user.ID); err != nil {
-		tx.Rollback()
-		logger.LogBusinessError(err, "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
-			"operation": "soft_delete_user",
-			"user_id":   user.ID,
-			"error":     "delete_user_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return fmt.Errorf("删除用户失败: %w", err)
-	}
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		logger.LogBusinessError(err, "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
-			"operation": "transaction_commit",
-			"user_id":   user.ID,
-			"error":     "transaction_commit_failed",
-			"timestamp": logger.NowFormatted(),
-		})
-		return fmt.Errorf("提交事务失败: %w", err)
+		// 2. 删除用户
+		if err := s.userRepo.DeleteUserWithTx(ctx, tx, user.ID); err != nil {
+			logger.LogBusinessError(err, "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
+				"operation": "soft_delete_user",
+				"user_id":   user.ID,
+				"error":     "delete_user_failed",
+				"timestamp": logger.NowFormatted(),
+			})
+			return fmt.Errorf("删除用户失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if database.IsTransactionError(err) {
+			logger.LogBusinessError(err, "", 0, clientIP, "delete_user", "SERVICE", map[string]interface{}{
+				"operation": "transaction",
+				"user_id":   user.ID,
+				"error":     "transaction_failed",
+				"timestamp": logger.NowFormatted(),
+			})
+		}
+		return err
 	}
+	s.invalidateUserPermissions(ctx, user.ID)
 
 	// 记录成功删除日志
 	logger.LogBusinessOperation("delete_user", user.ID, user.Username, clientIP, "", "success", "用户删除成功", map[string]interface{}{
@@ -1723,6 +1733,12 @@ func (s *UserService) GetUserPermissions(ctx context.Context, userID uint) ([]*s
 	default:
 	}
 
+	// 优先读取缓存：用户状态变更、删除及角色变化都会使缓存失效，命中即说明用户仍有效
+	cached, cacheVersion, hit := s.getCachedUserPermissions(ctx, userID)
+	if hit {
+		return cached, nil
+	}
+
 	// 首先验证用户是否存在
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
@@ -1767,6 +1783,8 @@ func (s *UserService) GetUserPermissions(ctx context.Context, userID uint) ([]*s
 		})
 		return nil, fmt.Errorf("获取用户权限失败: %w", err)
 	}
+	// 按查询前读取的版本回写缓存
+	s.storeCachedUserPermissions(ctx, userID, cacheVersion, permissions)
 
 	// 记录成功获取权限的业务日志
 	permissionNames := make([]string, len(permissions))
@@ -1960,6 +1978,29 @@ func (s *UserService) UpdatePasswordWithVersionHashed(ctx context.Context, userI
 	return nil
 }
 
+// RehashPassword 按当前哈希参数重新哈希用户密码(登录校验通过后调用)
+// 密码本身未变，不递增密码版本号，已签发的令牌保持有效
+// 哈希已被并发修改(如同时修改了密码)时放弃本次重新哈希
+func (s *UserService) RehashPassword(ctx context.Context, user *system.User, password string) error {
+	if user == nil || user.ID == 0 {
+		return errors.New("用户不能为空")
+	}
+
+	hashedPassword, err := s.passwordManager.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("密码哈希失败: %w", err)
+	}
+
+	updated, err := s.userRepo.ReplacePasswordHash(ctx, user.ID, user.Password, hashedPassword)
+	if err != nil {
+		return fmt.Errorf("更新密码哈希失败: %w", err)
+	}
+	if updated {
+		user.Password = hashedPassword
+	}
+	return nil
+}
+
 // GetUserPasswordVersion 获取用户密码版本号
 // 用于密码版本控制，确保修改密码后旧token失效
 // 注意：此方法接收已哈希的密码，主要供内部服务调用
@@ -2028,7 +2069,11 @@ func (s *UserService) AssignRoleToUser(ctx context.Context, userID, roleID uint)
 	}
 
 	// 调用数据访问层分配角色
-	return s.userRepo.AssignRoleToUser(ctx, userID, roleID)
+	if err := s.userRepo.AssignRoleToUser(ctx, userID, roleID); err != nil {
+		return err
+	}
+	s.invalidateUserPermissions(ctx, userID)
+	return nil
 }
 
 // RemoveRoleFromUser 移除用户角色
@@ -2042,7 +2087,11 @@ func (s *UserService) RemoveRoleFromUser(ctx context.Context, userID, roleID uin
 	}
 
 	// 调用数据访问层移除角色
-	return s.userRepo.RemoveRoleFromUser(ctx, userID, roleID)
+	if err := s.userRepo.RemoveRoleFromUser(ctx, userID, roleID); err != nil {
+		return err
+	}
+	s.invalidateUserPermissions(ctx, userID)
+	return nil
 }
 
 // UpdateLastLogin 更新用户最后登录时间（包含客户端IP）
@@ -2164,6 +2213,8 @@ func (s *UserService) UpdateUserStatus(ctx context.Context, userID uint, status
 		})
 		return fmt.Errorf("%s用户失败: %w", statusText, err)
 	}
+	// 禁用后缓存的权限集不能继续生效
+	s.invalidateUserPermissions(ctx, userID)
 
 	// 审计日志层 - 记录成功操作
 	statusText := "禁用"
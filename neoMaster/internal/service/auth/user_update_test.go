@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"neomaster/internal/model/system"
+	pkgauth "neomaster/internal/pkg/auth"
+	systemrepo "neomaster/internal/repo/mysql/system"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newUserUpdateTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&system.User{}, &system.Role{}))
+	require.NoError(t, db.Create(&system.User{ID: 2, Username: "alice", Email: "alice@example.com", Password: "x", Status: system.UserStatusEnabled}).Error)
+	require.NoError(t, db.Create([]*system.Role{{ID: 10, Name: "auditor"}, {ID: 11, Name: "operator"}}).Error)
+	return db
+}
+
+// 两个管理员同时打开编辑页(读到同一版本)，先提交的成功，后提交的检测到冲突而不是覆盖
+func TestUserRepository_UpdateUserWithTx_DetectsConcurrentModification(t *testing.T) {
+	ctx := context.Background()
+	db := newUserUpdateTestDB(t)
+	repo := systemrepo.NewUserRepository(db)
+
+	first, err := repo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	second, err := repo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), first.LockVersion)
+
+	update := func(user *system.User) error {
+		tx := repo.BeginTx(ctx)
+		if err := repo.UpdateUserWithTx(ctx, tx, user); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit().Error
+	}
+
+	first.Nickname = "first"
+	require.NoError(t, update(first))
+	assert.Equal(t, int64(2), first.LockVersion)
+
+	second.Nickname = "second"
+	err = update(second)
+	assert.ErrorIs(t, err, system.ErrConcurrentModification)
+	assert.Equal(t, int64(1), second.LockVersion, "failed update must keep the read version")
+
+	stored, err := repo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stored.LockVersion)
+	assert.Equal(t, "first", stored.Nickname, "stale update must not overwrite the first one")
+}
+
+func TestUserService_UpdateUserByID_LockVersion(t *testing.T) {
+	ctx := context.Background()
+	db := newUserUpdateTestDB(t)
+	svc := NewUserService(systemrepo.NewUserRepository(db), nil, nil, nil)
+	version := func(v int64) *int64 { return &v }
+
+	updated, err := svc.UpdateUserByID(ctx, 2, &system.UpdateUserRequest{Nickname: "a", RoleIDs: []uint{10, 11}, LockVersion: version(1)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.LockVersion)
+
+	// 基于旧版本的编辑被拒绝，数据保持不变
+	_, err = svc.UpdateUserByID(ctx, 2, &system.UpdateUserRequest{Nickname: "stale", LockVersion: version(1)})
+	assert.ErrorIs(t, err, system.ErrConcurrentModification)
+	assert.Equal(t, system.ErrCodeConflict, system.ErrorCodeOf(err))
+
+	// 未携带版本号时以读取到的版本为准
+	updated, err = svc.UpdateUserByID(ctx, 2, &system.UpdateUserRequest{Remark: "r"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), updated.LockVersion)
+
+	var stored system.User
+	require.NoError(t, db.Preload("Roles").First(&stored, 2).Error)
+	assert.Equal(t, "a", stored.Nickname)
+	assert.Equal(t, "r", stored.Remark)
+	assert.Len(t, stored.Roles, 2)
+}
+
+func TestUserService_PatchUserByID(t *testing.T) {
+	ctx := context.Background()
+	db := newUserUpdateTestDB(t)
+	svc := NewUserService(systemrepo.NewUserRepository(db), nil, nil, nil)
+	str := func(v string) *string { return &v }
+
+	_, err := svc.UpdateUserByID(ctx, 2, &system.UpdateUserRequest{Nickname: "alice", Remark: "vip", RoleIDs: []uint{10}})
+	require.NoError(t, err)
+
+	// 未携带 remark：保持不变
+	updated, err := svc.PatchUserByID(ctx, 2, &system.PatchUserRequest{Nickname: str("al")})
+	require.NoError(t, err)
+	assert.Equal(t, "al", updated.Nickname)
+	assert.Equal(t, "vip", updated.Remark)
+
+	// 携带空字符串：清空 remark，其他字段与角色不受影响
+	updated, err = svc.PatchUserByID(ctx, 2, &system.PatchUserRequest{Remark: str("")})
+	require.NoError(t, err)
+	assert.Equal(t, "", updated.Remark)
+
+	var stored system.User
+	require.NoError(t, db.Preload("Roles").First(&stored, 2).Error)
+	assert.Equal(t, "", stored.Remark)
+	assert.Equal(t, "al", stored.Nickname)
+	assert.Equal(t, "alice@example.com", stored.Email)
+	assert.Len(t, stored.Roles, 1)
+
+	// 全量更新的空字符串仍视为不修改
+	_, err = svc.UpdateUserByID(ctx, 2, &system.UpdateUserRequest{Remark: "kept"})
+	require.NoError(t, err)
+	updated, err = svc.UpdateUserByID(ctx, 2, &system.UpdateUserRequest{Remark: ""})
+	require.NoError(t, err)
+	assert.Equal(t, "kept", updated.Remark)
+
+	// 必填字段不允许清空，格式校验与全量更新一致
+	_, err = svc.PatchUserByID(ctx, 2, &system.PatchUserRequest{Email: str("")})
+	assert.Error(t, err)
+	_, err = svc.PatchUserByID(ctx, 2, &system.PatchUserRequest{Email: str("not-an-email")})
+	assert.Error(t, err)
+	_, err = svc.PatchUserByID(ctx, 2, &system.PatchUserRequest{Remark: str("x"), LockVersion: func(v int64) *int64 { return &v }(1)})
+	assert.ErrorIs(t, err, system.ErrConcurrentModification)
+}
+
+// 登录时按新参数重新哈希不改变乐观锁版本，管理员基于之前读取版本的编辑仍然成功
+func TestUserService_RehashPassword_KeepsLockVersion(t *testing.T) {
+	ctx := context.Background()
+	db := newUserUpdateTestDB(t)
+	userRepo := systemrepo.NewUserRepository(db)
+	svc := NewUserService(userRepo, nil, pkgauth.NewPasswordManager(&pkgauth.PasswordConfig{Memory: 1024, Iterations: 1, Parallelism: 1}), nil)
+
+	user, err := userRepo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, svc.RehashPassword(ctx, user, "secret"))
+	assert.NotEqual(t, "x", user.Password)
+
+	stored, err := userRepo.GetUserByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, user.Password, stored.Password)
+	assert.Equal(t, int64(1), stored.LockVersion)
+
+	version := int64(1)
+	_, err = svc.UpdateUserByID(ctx, 2, &system.UpdateUserRequest{Nickname: "edited", LockVersion: &version})
+	assert.NoError(t, err)
+}
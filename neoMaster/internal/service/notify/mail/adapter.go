@@ -0,0 +1,113 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/utils"
+	"neomaster/internal/service/notify/webhook"
+)
+
+// Adapter email 订阅的消息适配器
+// Render 生成 HTML 正文(即投递记录中的 payload)，Send 通过 SMTP 发送给订阅的收件人，
+// 订阅开启 AttachCSV 时附带消息中全部严重漏洞的 CSV
+type Adapter struct {
+	sender *Sender
+}
+
+var (
+	_ webhook.Adapter = (*Adapter)(nil)
+	_ webhook.Sender  = (*Adapter)(nil)
+)
+
+// NewAdapter 创建邮件适配器，需通过 webhook.RegisterAdapter 注册为 email 类型
+func NewAdapter(sender *Sender) *Adapter {
+	return &Adapter{sender: sender}
+}
+
+// Render 渲染 HTML 正文
+func (a *Adapter) Render(msg *webhook.Message, opts webhook.RenderOptions) ([]byte, error) {
+	_, body, err := render(msg, opts)
+	return body, err
+}
+
+// Send 发送邮件，主题由消息内容生成
+func (a *Adapter) Send(ctx context.Context, sub *orcmodel.WebhookSubscription, msg *webhook.Message, body []byte) error {
+	if len(sub.Recipients) == 0 {
+		return errors.New("email subscription has no recipients")
+	}
+	subject, _, err := render(msg, webhook.RenderOptions{})
+	if err != nil {
+		return err
+	}
+	email := &Email{To: sub.Recipients, Subject: subject, HTML: body}
+	if sub.AttachCSV {
+		if data, rows, csvErr := findingsCSV(msg); csvErr != nil {
+			return csvErr
+		} else if rows > 0 {
+			email.Attachments = append(email.Attachments, &Attachment{
+				Filename:    "neoscan-critical-findings-" + time.Now().Format("20060102150405") + ".csv",
+				ContentType: "text/csv; charset=UTF-8",
+				Data:        data,
+			})
+		}
+	}
+	return a.sender.Send(ctx, email)
+}
+
+// Prepare 邮件不经过 HTTP，不会被调用
+func (a *Adapter) Prepare(*http.Request, string, []byte) error { return nil }
+
+// CheckResponse 邮件不经过 HTTP，不会被调用
+func (a *Adapter) CheckResponse(int, []byte) error { return nil }
+
+// Digest 邮件同样按限流间隔合并，避免大批漏洞刷屏收件箱
+func (a *Adapter) Digest() bool { return true }
+
+// findingsCSV 导出消息中的严重漏洞，返回 CSV 内容与数据行数
+func findingsCSV(msg *webhook.Message) ([]byte, int, error) {
+	var buf bytes.Buffer
+	// UTF-8 BOM，便于 Excel 正确识别中文
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"project_id", "vuln_id", "severity", "cve", "id_alias", "host_ip", "target_type", "target_ref_id", "first_seen_at"}); err != nil {
+		return nil, 0, err
+	}
+	rows := 0
+	for _, e := range msg.Events {
+		data, ok := e.Data.(*webhook.CriticalFindingData)
+		if !ok {
+			continue
+		}
+		firstSeen := ""
+		if data.FirstSeenAt != nil {
+			firstSeen = data.FirstSeenAt.Format(time.RFC3339)
+		}
+		record := []string{
+			strconv.FormatUint(data.ProjectID, 10),
+			strconv.FormatUint(data.VulnID, 10),
+			data.Severity,
+			data.CVE,
+			data.IDAlias,
+			data.HostIP,
+			data.TargetType,
+			strconv.FormatUint(data.TargetRefID, 10),
+			firstSeen,
+		}
+		for i := range record {
+			record[i] = utils.CSVSafe(record[i])
+		}
+		if err := w.Write(record); err != nil {
+			return nil, 0, err
+		}
+		rows++
+	}
+	w.Flush()
+	return buf.Bytes(), rows, w.Error()
+}
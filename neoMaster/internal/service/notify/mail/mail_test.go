@@ -0,0 +1,229 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/service/notify/webhook"
+)
+
+func sampleMessage() *webhook.Message {
+	seen := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	return &webhook.Message{Total: 3, Events: []*orcmodel.WebhookEvent{
+		{Type: orcmodel.WebhookEventProjectCompleted, Data: &webhook.ProjectCompletedData{
+			ProjectID: 7, Name: `<script>alert("x")</script>`, RunID: 3, Status: "finished", FinishedAt: seen,
+		}},
+		{Type: orcmodel.WebhookEventFindingCritical, Data: &webhook.CriticalFindingData{
+			ProjectID: 7, VulnID: 42, HostIP: "10.0.0.1", TargetType: "web", CVE: "CVE-2024-0001",
+			IDAlias: `"><img src=x onerror=alert(1)>`, Severity: "critical", FirstSeenAt: &seen,
+		}},
+		{Type: orcmodel.WebhookEventFindingCritical, Data: &webhook.CriticalFindingData{
+			VulnID: 43, HostIP: "10.0.0.2", TargetType: "service", IDAlias: "=HYPERLINK(\"http://evil\")", Severity: "critical",
+		}},
+	}}
+}
+
+func TestRender_EscapesFindingData(t *testing.T) {
+	subject, body, err := render(sampleMessage(), webhook.RenderOptions{MaxItems: 10, UIBaseURL: "https://neoscan.example.com/"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	html := string(body)
+
+	if subject != "NeoScan 事件汇总 (3)" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, injected := range []string{"<script>", "<img", `"><img`} {
+		if strings.Contains(html, injected) {
+			t.Errorf("unescaped %q in html:\n%s", injected, html)
+		}
+	}
+	for _, escaped := range []string{"&lt;script&gt;", "&lt;img src=x onerror=alert(1)&gt;"} {
+		if !strings.Contains(html, escaped) {
+			t.Errorf("missing escaped text %q", escaped)
+		}
+	}
+	for _, link := range []string{`href="https://neoscan.example.com/projects/7"`, `href="https://neoscan.example.com/assets/vulns/42"`} {
+		if !strings.Contains(html, link) {
+			t.Errorf("missing link %s", link)
+		}
+	}
+	if !strings.Contains(html, "严重漏洞 (2)") || !strings.Contains(html, "2026-10-16 08:00:00") {
+		t.Errorf("summary content missing:\n%s", html)
+	}
+}
+
+func TestRender_UnsafeBaseURL(t *testing.T) {
+	_, body, err := render(sampleMessage(), webhook.RenderOptions{UIBaseURL: "javascript:alert(1)//"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if strings.Contains(string(body), "javascript:") {
+		t.Errorf("javascript url rendered into href:\n%s", body)
+	}
+}
+
+func TestRender_TruncatesToMaxItems(t *testing.T) {
+	msg := &webhook.Message{Total: 30}
+	for i := 1; i <= 5; i++ {
+		msg.Events = append(msg.Events, &orcmodel.WebhookEvent{Type: orcmodel.WebhookEventFindingCritical,
+			Data: &webhook.CriticalFindingData{VulnID: uint64(i), CVE: "CVE-2024-000" + strconv.Itoa(i), Severity: "critical"}})
+	}
+	subject, body, err := render(msg, webhook.RenderOptions{MaxItems: 2, UIBaseURL: "https://neoscan.example.com"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	html := string(body)
+	if subject != "NeoScan 发现 30 个严重漏洞" {
+		t.Errorf("subject = %q", subject)
+	}
+	if strings.Contains(html, "CVE-2024-0003") || !strings.Contains(html, "CVE-2024-0002") {
+		t.Errorf("items not truncated to 2:\n%s", html)
+	}
+	if !strings.Contains(html, "其余 28 条") || !strings.Contains(html, "/assets/vulns?severity=critical") {
+		t.Errorf("omitted summary missing:\n%s", html)
+	}
+}
+
+func TestFindingsCSV(t *testing.T) {
+	data, rows, err := findingsCSV(sampleMessage())
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if rows != 2 {
+		t.Fatalf("rows = %d, want 2", rows)
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != 3 || records[1][1] != "42" || records[1][8] != "2026-10-16T08:00:00Z" {
+		t.Fatalf("records = %v", records)
+	}
+	if got := records[2][4]; got != `'=HYPERLINK("http://evil")` {
+		t.Errorf("formula cell = %q, want quote-prefixed", got)
+	}
+}
+
+func TestSender_BuildRejectsHeaderInjection(t *testing.T) {
+	s := NewSender(config.MailConfig{FromEmail: "noreply@neoscan.com", FromName: "NeoScan"})
+	raw, err := s.build(&Email{To: []string{"a@example.com"}, Subject: "hi\r\nBcc: victim@example.com", HTML: []byte("<p>x</p>"),
+		Attachments: []*Attachment{{Filename: "findings.csv", ContentType: "text/csv", Data: []byte("a,b\n")}}}, time.Now())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	header := string(raw[:strings.Index(string(raw), "\r\n\r\n")])
+	if strings.Contains(header, "\r\nBcc:") {
+		t.Errorf("subject injected a header:\n%s", header)
+	}
+	if !strings.Contains(string(raw), `filename="findings.csv"`) {
+		t.Errorf("attachment missing:\n%s", raw)
+	}
+}
+
+// fakeSMTP 最小 SMTP 服务器，记录收到的信封与正文
+func fakeSMTP(t *testing.T) (addr string, received chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received = make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 fake ESMTP")
+		var transcript strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				transcript.WriteString(line)
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					transcript.WriteString(l)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- transcript.String()
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestAdapter_SendsToRecipients(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	adapter := NewAdapter(NewSender(config.MailConfig{SMTPHost: host, SMTPPort: portNum, FromEmail: "noreply@neoscan.com", Encryption: EncryptionNone}))
+
+	msg := sampleMessage()
+	body, err := adapter.Render(msg, webhook.RenderOptions{})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	sub := &orcmodel.WebhookSubscription{Type: orcmodel.WebhookTypeEmail, Recipients: []string{"a@example.com", "b@example.com"}, AttachCSV: true}
+	if err = adapter.Send(context.Background(), sub, msg, body); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	select {
+	case transcript := <-received:
+		for _, want := range []string{"RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "text/html", "neoscan-critical-findings-"} {
+			if !strings.Contains(transcript, want) {
+				t.Errorf("transcript missing %q", want)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mail not received")
+	}
+}
+
+func TestSender_FailsFastWhenServerDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close()
+
+	s := NewSender(config.MailConfig{SMTPHost: "127.0.0.1", SMTPPort: addr.Port, FromEmail: "noreply@neoscan.com", Timeout: 1})
+	start := time.Now()
+	if err = s.Send(context.Background(), &Email{To: []string{"a@example.com"}, Subject: "x", HTML: []byte("x")}); err == nil {
+		t.Fatal("send to closed port succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("send took %v, want fail within timeout", elapsed)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: SMTP 邮件发送
+ * @func:
+ * 1.按 mail 配置连接 SMTP 服务器(SSL / STARTTLS / 明文)，有用户名时进行 PLAIN 认证
+ * 2.组装 multipart/mixed 邮件: HTML 正文 + 可选附件
+ * 3.连接与发送受超时控制，SMTP 不可用时返回错误而不阻塞调用方过久
+ */
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"neomaster/internal/config"
+)
+
+// 连接加密方式
+const (
+	EncryptionAuto     = "auto"     // 465 端口使用 SSL，其余端口在服务器支持时使用 STARTTLS
+	EncryptionSSL      = "ssl"      // 隐式 TLS (SMTPS)
+	EncryptionSTARTTLS = "starttls" // 必须升级为 TLS，服务器不支持时发送失败
+	EncryptionNone     = "none"     // 明文
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	smtpsPort      = 465
+	base64LineLen  = 76 // RFC 2045 规定的编码行长度上限
+)
+
+// Attachment 邮件附件
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Email 一封待发送的邮件
+type Email struct {
+	To          []string
+	Subject     string
+	HTML        []byte
+	Attachments []*Attachment
+}
+
+// Sender SMTP 邮件发送器
+type Sender struct {
+	host       string
+	port       int
+	username   string
+	password   string
+	from       mail.Address
+	encryption string
+	timeout    time.Duration
+}
+
+// NewSender 由 mail 配置创建发送器
+func NewSender(cfg config.MailConfig) *Sender {
+	s := &Sender{
+		host:       cfg.SMTPHost,
+		port:       cfg.SMTPPort,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		from:       mail.Address{Name: cfg.FromName, Address: cfg.FromEmail},
+		encryption: strings.ToLower(strings.TrimSpace(cfg.Encryption)),
+		timeout:    time.Duration(cfg.Timeout) * time.Second,
+	}
+	if s.encryption == "" {
+		s.encryption = EncryptionAuto
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultTimeout
+	}
+	return s
+}
+
+// Send 发送邮件，ctx 的截止时间与发送器超时取较早者
+func (s *Sender) Send(ctx context.Context, email *Email) error {
+	if len(email.To) == 0 {
+		return errors.New("mail has no recipients")
+	}
+	body, err := s.build(email, time.Now())
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn, err := s.dial(ctx, deadline)
+	if err != nil {
+		return fmt.Errorf("connect smtp server: %w", err)
+	}
+	// 整个会话共用一个截止时间，服务器无响应时不会无限等待
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if err = s.startTLS(client); err != nil {
+		return err
+	}
+	if s.username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err = client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err = client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	for _, to := range email.To {
+		if err = client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err = w.Write(body); err != nil {
+		return fmt.Errorf("write mail body: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial 建立连接，SSL 模式直接进行 TLS 握手
+func (s *Sender) dial(ctx context.Context, deadline time.Time) (net.Conn, error) {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	dialer := &net.Dialer{Deadline: deadline}
+	if s.encryption == EncryptionSSL || (s.encryption == EncryptionAuto && s.port == smtpsPort) {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.host}}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// startTLS 按加密方式升级连接，SSL 连接已加密无需处理
+func (s *Sender) startTLS(client *smtp.Client) error {
+	if _, isTLS := client.TLSConnectionState(); isTLS || s.encryption == EncryptionNone || s.encryption == EncryptionSSL {
+		return nil
+	}
+	if ok, _ := client.Extension("STARTTLS"); !ok {
+		if s.encryption == EncryptionSTARTTLS {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		return nil
+	}
+	if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+		return fmt.Errorf("smtp STARTTLS: %w", err)
+	}
+	return nil
+}
+
+// build 组装 MIME 邮件
+func (s *Sender) build(email *Email, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", encodeHeader(email.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err = qp.Write(email.HTML); err != nil {
+		return nil, err
+	}
+	if err = qp.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, a := range email.Attachments {
+		filename := mime.QEncoding.Encode("utf-8", a.Filename)
+		part, err = mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", a.ContentType, filename)},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", filename)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = writeBase64Lines(part, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeHeader 去除换行(防止头部注入)并对非 ASCII 内容做 RFC 2047 编码
+func encodeHeader(v string) string {
+	v = strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+	return mime.QEncoding.Encode("utf-8", v)
+}
+
+// writeBase64Lines 以 76 字符为一行写入 base64 编码内容
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := base64LineLen
+		if n > len(encoded) {
+			n = len(encoded)
+		}
+		if _, err := w.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"neomaster/internal/service/notify/webhook"
+)
+
+const defaultMaxItems = 10
+
+// 邮件模板使用 html/template，事件中的项目名、漏洞编号等字段按所在上下文(正文/属性/URL)自动转义
+// layout 按消息中包含的事件类型引用 project_completed / critical_findings 两个子模板
+const layoutTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>{{.Title}}</title></head>
+<body style="font-family:Arial,'Microsoft YaHei',sans-serif;font-size:14px;color:#333;">
+<h2 style="margin:0 0 16px;">{{.Title}}</h2>
+{{if .Projects}}{{template "project_completed" .}}{{end}}
+{{if .Findings}}{{template "critical_findings" .}}{{end}}
+{{if .Omitted}}<p style="color:#888;">其余 {{.Omitted}} 条事件未列出{{if .MoreURL}}，<a href="{{.MoreURL}}">在 NeoScan 中查看全部</a>{{end}}。</p>{{end}}
+<p style="color:#aaa;font-size:12px;">此邮件由 NeoScan 自动发送 · {{.GeneratedAt}}</p>
+</body>
+</html>`
+
+const projectCompletedTemplate = `{{define "project_completed"}}<h3>项目执行完成</h3>
+<table cellpadding="6" cellspacing="0" border="1" style="border-collapse:collapse;border-color:#ddd;">
+<tr style="background:#f5f5f5;"><th>项目</th><th>执行轮次</th><th>状态</th><th>完成时间</th></tr>
+{{range .Projects}}<tr>
+<td>{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td>
+<td>{{.RunID}}</td>
+<td>{{.Status}}</td>
+<td>{{.FinishedAt}}</td>
+</tr>
+{{end}}</table>
+{{end}}`
+
+const criticalFindingsTemplate = `{{define "critical_findings"}}<h3>严重漏洞 ({{.FindingTotal}})</h3>
+<table cellpadding="6" cellspacing="0" border="1" style="border-collapse:collapse;border-color:#ddd;">
+<tr style="background:#f5f5f5;"><th>漏洞</th><th>等级</th><th>主机</th><th>目标类型</th><th>首次发现</th></tr>
+{{range .Findings}}<tr>
+<td>{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td>
+<td style="color:#c0392b;font-weight:bold;">{{.Severity}}</td>
+<td>{{.HostIP}}</td>
+<td>{{.TargetType}}</td>
+<td>{{.FirstSeenAt}}</td>
+</tr>
+{{end}}</table>
+{{end}}`
+
+var mailTemplate = template.Must(template.New("layout").Parse(layoutTemplate + projectCompletedTemplate + criticalFindingsTemplate))
+
+// projectView 项目完成事件的展示数据
+type projectView struct {
+	Name       string
+	RunID      int64
+	Status     string
+	FinishedAt string
+	Link       string
+}
+
+// findingView 严重漏洞事件的展示数据
+type findingView struct {
+	Name        string
+	Severity    string
+	HostIP      string
+	TargetType  string
+	FirstSeenAt string
+	Link        string
+}
+
+// mailView 邮件模板数据
+type mailView struct {
+	Title        string
+	Projects     []*projectView
+	Findings     []*findingView
+	FindingTotal int
+	Omitted      int
+	MoreURL      string
+	GeneratedAt  string
+}
+
+// render 渲染邮件主题与 HTML 正文，只逐条列出前 MaxItems 个事件
+func render(msg *webhook.Message, opts webhook.RenderOptions) (string, []byte, error) {
+	maxItems := opts.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultMaxItems
+	}
+	base := strings.TrimRight(opts.UIBaseURL, "/")
+	link := func(path string) string {
+		if base == "" {
+			return ""
+		}
+		return base + path
+	}
+
+	total := msg.Total
+	if total < len(msg.Events) {
+		total = len(msg.Events)
+	}
+	view := &mailView{GeneratedAt: time.Now().Format(time.DateTime)}
+	listed, critical := 0, 0
+	for _, e := range msg.Events {
+		data, ok := e.Data.(*webhook.CriticalFindingData)
+		if !ok {
+			continue
+		}
+		critical++
+		if listed >= maxItems {
+			continue
+		}
+		listed++
+		view.Findings = append(view.Findings, &findingView{
+			Name:        findingName(data),
+			Severity:    data.Severity,
+			HostIP:      data.HostIP,
+			TargetType:  data.TargetType,
+			FirstSeenAt: formatTime(data.FirstSeenAt),
+			Link:        link(fmt.Sprintf("/assets/vulns/%d", data.VulnID)),
+		})
+	}
+	for _, e := range msg.Events {
+		data, ok := e.Data.(*webhook.ProjectCompletedData)
+		if !ok || listed >= maxItems {
+			continue
+		}
+		listed++
+		view.Projects = append(view.Projects, &projectView{
+			Name:       data.Name,
+			RunID:      data.RunID,
+			Status:     data.Status,
+			FinishedAt: formatTime(&data.FinishedAt),
+			Link:       link(fmt.Sprintf("/projects/%d", data.ProjectID)),
+		})
+	}
+	view.FindingTotal = critical
+	view.Omitted = total - listed
+
+	allCritical := critical == len(msg.Events)
+	switch {
+	case total == 1 && allCritical:
+		view.Title = "NeoScan 发现严重漏洞"
+	case total == 1 && len(view.Projects) == 1:
+		view.Title = fmt.Sprintf("NeoScan 项目执行完成: %s", view.Projects[0].Name)
+	case allCritical:
+		view.Title = fmt.Sprintf("NeoScan 发现 %d 个严重漏洞", total)
+		view.FindingTotal = total
+	default:
+		view.Title = fmt.Sprintf("NeoScan 事件汇总 (%d)", total)
+	}
+	if view.Omitted > 0 && base != "" {
+		if allCritical {
+			view.MoreURL = base + "/assets/vulns?severity=critical"
+		} else {
+			view.MoreURL = base + "/projects"
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := mailTemplate.ExecuteTemplate(&buf, "layout", view); err != nil {
+		return "", nil, err
+	}
+	return view.Title, buf.Bytes(), nil
+}
+
+// findingName 漏洞展示名称: CVE 优先，附带别名
+func findingName(data *webhook.CriticalFindingData) string {
+	switch {
+	case data.CVE == "":
+		return data.IDAlias
+	case data.IDAlias != "" && data.IDAlias != data.CVE:
+		return data.CVE + " / " + data.IDAlias
+	default:
+		return data.CVE
+	}
+}
+
+// formatTime 格式化时间，未知时显示 -
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.DateTime)
+}
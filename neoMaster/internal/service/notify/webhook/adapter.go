@@ -0,0 +1,241 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+)
+
+// Message 一次投递的内容
+// 通用订阅每次只投递一个事件；聊天平台与邮件订阅在限流间隔内到达的事件会合并为一条消息
+type Message struct {
+	Events []*orcmodel.WebhookEvent // 保留的事件(按发生顺序)，渲染时只逐条列出前 MaxItems 个
+	Total  int                      // 合并的事件总数，超出缓冲上限的事件只计数不保留
+}
+
+// RenderOptions 消息渲染参数
+type RenderOptions struct {
+	MaxItems  int    // 最多逐条列出的事件数，其余只汇总数量
+	UIBaseURL string // Web 控制台地址，为空时不生成跳转链接
+}
+
+// Adapter 消息平台适配器: 将内部事件转换为目标平台的请求
+// 新增平台只需实现该接口并通过 RegisterAdapter 注册，订阅的 type 字段即可选用
+type Adapter interface {
+	// Render 生成请求体
+	Render(msg *Message, opts RenderOptions) ([]byte, error)
+	// Prepare 发送前的平台特定处理，如签名请求头或签名查询参数
+	Prepare(req *http.Request, secret string, body []byte) error
+	// CheckResponse 判断平台是否接受了消息(部分平台以 HTTP 200 + 错误码的形式返回失败)
+	CheckResponse(statusCode int, body []byte) error
+	// Digest 是否对订阅限流，并把限流期间的事件合并为一条摘要消息
+	Digest() bool
+}
+
+// Sender 不经过 HTTP 的渠道(如邮件)由适配器自行发送
+// 适配器实现该接口时分发器调用 Send 代替 HTTP 请求，Prepare/CheckResponse 不会被调用
+type Sender interface {
+	// Send 发送 Render 生成的内容，msg 为原始消息(可用于生成附件)
+	Send(ctx context.Context, sub *orcmodel.WebhookSubscription, msg *Message, body []byte) error
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[string]Adapter{
+		orcmodel.WebhookTypeGeneric:  genericAdapter{},
+		orcmodel.WebhookTypeSlack:    slackAdapter{},
+		orcmodel.WebhookTypeDingTalk: dingTalkAdapter{},
+	}
+)
+
+// RegisterAdapter 注册(或替换)消息平台适配器
+func RegisterAdapter(subscriptionType string, adapter Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	adapters[subscriptionType] = adapter
+}
+
+// GetAdapter 获取订阅类型对应的适配器，类型为空时使用 generic
+func GetAdapter(subscriptionType string) (Adapter, bool) {
+	if subscriptionType == "" {
+		subscriptionType = orcmodel.WebhookTypeGeneric
+	}
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	adapter, ok := adapters[subscriptionType]
+	return adapter, ok
+}
+
+// AdapterTypes 已注册的订阅类型(排序后)
+func AdapterTypes() []string {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+	types := make([]string, 0, len(adapters))
+	for t := range adapters {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// baseAdapter 适配器公共行为: 附加 X-Webhook-Timestamp，有密钥时对 "<timestamp>.<body>" 签名，2xx 视为成功
+type baseAdapter struct{}
+
+func (baseAdapter) Prepare(req *http.Request, secret string, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	}
+	return nil
+}
+
+func (baseAdapter) CheckResponse(statusCode int, _ []byte) error {
+	if statusCode < 200 || statusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", statusCode)
+	}
+	return nil
+}
+
+// genericAdapter 原始 JSON 事件 (每次一个事件，不限流)
+type genericAdapter struct{ baseAdapter }
+
+func (genericAdapter) Render(msg *Message, _ RenderOptions) ([]byte, error) {
+	if msg == nil || len(msg.Events) != 1 {
+		return nil, errors.New("generic webhook delivers exactly one event per request")
+	}
+	return json.Marshal(msg.Events[0])
+}
+
+func (genericAdapter) Digest() bool { return false }
+
+// -----------------------------------------------------------------------------
+// 聊天平台消息的公共文本渲染
+// -----------------------------------------------------------------------------
+
+const (
+	defaultRenderMaxItems = 10
+	maxItemRunes          = 300 // 单条事件描述的最大字符数，防止超长字段撑爆平台限制
+)
+
+// chatItem 单个事件渲染后的文本
+type chatItem struct {
+	Text string // 已转义的一行描述(不含链接)
+	Link string // 跳转链接，可能为空
+}
+
+// chatSummary 聊天消息的结构化内容，由各平台适配器转换为自己的格式
+type chatSummary struct {
+	Title   string
+	Items   []chatItem
+	Omitted int    // 未逐条列出的事件数
+	MoreURL string // "查看全部"链接，可能为空
+}
+
+// summarize 将消息整理为标题 + 前 MaxItems 条事件 + 剩余数量
+// escape 用于转义平台 markdown 的特殊字符
+func summarize(msg *Message, opts RenderOptions, escape func(string) string) *chatSummary {
+	maxItems := opts.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultRenderMaxItems
+	}
+	base := strings.TrimRight(opts.UIBaseURL, "/")
+
+	total := msg.Total
+	if total < len(msg.Events) {
+		total = len(msg.Events)
+	}
+	var completed, critical int
+	for _, e := range msg.Events {
+		switch e.Type {
+		case orcmodel.WebhookEventProjectCompleted:
+			completed++
+		case orcmodel.WebhookEventFindingCritical:
+			critical++
+		}
+	}
+
+	s := &chatSummary{}
+	switch {
+	case total == 1 && critical == 1:
+		s.Title = "NeoScan 发现严重漏洞"
+	case total == 1 && completed == 1:
+		s.Title = "NeoScan 项目执行完成"
+	case critical == len(msg.Events):
+		s.Title = fmt.Sprintf("NeoScan 发现 %d 个严重漏洞", total)
+	default:
+		s.Title = fmt.Sprintf("NeoScan 事件汇总 (%d)", total)
+	}
+
+	for i, e := range msg.Events {
+		if i >= maxItems {
+			break
+		}
+		s.Items = append(s.Items, renderChatItem(e, base, escape))
+	}
+	s.Omitted = total - len(s.Items)
+	if s.Omitted > 0 && base != "" {
+		if critical == len(msg.Events) {
+			s.MoreURL = base + "/assets/vulns?severity=critical"
+		} else {
+			s.MoreURL = base + "/projects"
+		}
+	}
+	return s
+}
+
+// renderChatItem 渲染单个事件的描述与链接
+func renderChatItem(e *orcmodel.WebhookEvent, base string, escape func(string) string) chatItem {
+	var text, path string
+	switch data := e.Data.(type) {
+	case *ProjectCompletedData:
+		text = fmt.Sprintf("项目 %s 第 %d 轮执行完成 (%s)", escape(data.Name), data.RunID, escape(data.Status))
+		path = fmt.Sprintf("/projects/%d", data.ProjectID)
+	case *CriticalFindingData:
+		name := data.CVE
+		if name == "" {
+			name = data.IDAlias
+		} else if data.IDAlias != "" && data.IDAlias != data.CVE {
+			name = data.CVE + " / " + data.IDAlias
+		}
+		text = fmt.Sprintf("[%s] %s @ %s (%s)", escape(data.Severity), escape(name), escape(data.HostIP), escape(data.TargetType))
+		path = fmt.Sprintf("/assets/vulns/%d", data.VulnID)
+	default:
+		text = escape(e.Type)
+	}
+
+	item := chatItem{Text: truncateRunes(text, maxItemRunes)}
+	if base != "" && path != "" {
+		item.Link = base + path
+	}
+	return item
+}
+
+// truncateRunes 按字符截断，超出时以省略号结尾
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
+
+// truncateBytes 按字节上限截断且不切断多字节字符
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && (s[max]&0xC0) == 0x80 {
+		max--
+	}
+	return s[:max]
+}
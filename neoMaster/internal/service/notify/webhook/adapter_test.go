@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+)
+
+// criticalMessage 构造 total 个严重漏洞事件合并的消息，只保留前 kept 个
+func criticalMessage(total, kept int) *Message {
+	msg := &Message{Total: total}
+	for i := 1; i <= kept; i++ {
+		msg.Events = append(msg.Events, &orcmodel.WebhookEvent{
+			ID:   "evt",
+			Type: orcmodel.WebhookEventFindingCritical,
+			Data: &CriticalFindingData{VulnID: uint64(i), HostIP: "10.0.0.1", TargetType: "service", CVE: "CVE-2024-0001", Severity: "critical"},
+		})
+	}
+	return msg
+}
+
+func TestGetAdapter(t *testing.T) {
+	for _, typ := range []string{"", orcmodel.WebhookTypeGeneric, orcmodel.WebhookTypeSlack, orcmodel.WebhookTypeDingTalk} {
+		if _, ok := GetAdapter(typ); !ok {
+			t.Errorf("adapter %q not registered", typ)
+		}
+	}
+	if _, ok := GetAdapter("teams"); ok {
+		t.Error("unknown type resolved to an adapter")
+	}
+}
+
+func TestSlackAdapter_RenderTruncates(t *testing.T) {
+	adapter, _ := GetAdapter(orcmodel.WebhookTypeSlack)
+	body, err := adapter.Render(criticalMessage(25, 5), RenderOptions{MaxItems: 3, UIBaseURL: "https://neoscan.example.com/"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	var msg slackMessage
+	if err = json.Unmarshal(body, &msg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// header + 3 条 section + context
+	if len(msg.Blocks) != 5 {
+		t.Fatalf("blocks = %d, want 5: %s", len(msg.Blocks), body)
+	}
+	if msg.Blocks[0].Type != "header" || !strings.Contains(msg.Blocks[0].Text.Text, "25") {
+		t.Errorf("header = %+v", msg.Blocks[0].Text)
+	}
+	if got := msg.Blocks[1].Text.Text; !strings.Contains(got, "<https://neoscan.example.com/assets/vulns/1|") {
+		t.Errorf("first item = %q, want link to vuln 1", got)
+	}
+	more := msg.Blocks[4]
+	if more.Type != "context" || !strings.Contains(more.Elements[0].Text, "其余 22 条") ||
+		!strings.Contains(more.Elements[0].Text, "https://neoscan.example.com/assets/vulns?severity=critical") {
+		t.Errorf("context = %+v", more.Elements)
+	}
+}
+
+func TestSlackAdapter_EscapesMarkup(t *testing.T) {
+	adapter, _ := GetAdapter(orcmodel.WebhookTypeSlack)
+	msg := &Message{Total: 1, Events: []*orcmodel.WebhookEvent{{
+		Type: orcmodel.WebhookEventProjectCompleted,
+		Data: &ProjectCompletedData{ProjectID: 1, Name: "<!channel> & co", RunID: 1, Status: "finished"},
+	}}}
+	body, err := adapter.Render(msg, RenderOptions{})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var out slackMessage
+	if err = json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := out.Blocks[1].Text.Text; strings.Contains(got, "<!channel>") || !strings.Contains(got, "&lt;!channel&gt; &amp; co") {
+		t.Errorf("project name not escaped: %q", got)
+	}
+}
+
+func TestDingTalkAdapter_Render(t *testing.T) {
+	adapter, _ := GetAdapter(orcmodel.WebhookTypeDingTalk)
+	body, err := adapter.Render(criticalMessage(12, 12), RenderOptions{MaxItems: 10, UIBaseURL: "https://neoscan.example.com"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	var msg dingTalkMessage
+	if err = json.Unmarshal(body, &msg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if msg.MsgType != "markdown" || msg.Markdown == nil {
+		t.Fatalf("message = %s", body)
+	}
+	if got := strings.Count(msg.Markdown.Text, "\n- "); got != 10 {
+		t.Errorf("listed items = %d, want 10", got)
+	}
+	if !strings.Contains(msg.Markdown.Text, "其余 2 条") || !strings.Contains(msg.Markdown.Text, "(https://neoscan.example.com/assets/vulns?severity=critical)") {
+		t.Errorf("summary line missing: %s", msg.Markdown.Text)
+	}
+}
+
+func TestDingTalkAdapter_PrepareSigns(t *testing.T) {
+	adapter, _ := GetAdapter(orcmodel.WebhookTypeDingTalk)
+	req, _ := http.NewRequest(http.MethodPost, "https://oapi.dingtalk.com/robot/send?access_token=abc", nil)
+	if err := adapter.Prepare(req, "SECtest", nil); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+
+	query := req.URL.Query()
+	if query.Get("access_token") != "abc" {
+		t.Errorf("access_token lost: %s", req.URL)
+	}
+	mac := hmac.New(sha256.New, []byte("SECtest"))
+	mac.Write([]byte(query.Get("timestamp") + "\nSECtest"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); query.Get("sign") != want {
+		t.Errorf("sign = %q, want %q", query.Get("sign"), want)
+	}
+}
+
+func TestDingTalkAdapter_CheckResponse(t *testing.T) {
+	adapter, _ := GetAdapter(orcmodel.WebhookTypeDingTalk)
+	if err := adapter.CheckResponse(http.StatusOK, []byte(`{"errcode":0,"errmsg":"ok"}`)); err != nil {
+		t.Errorf("ok response rejected: %v", err)
+	}
+	if err := adapter.CheckResponse(http.StatusOK, []byte(`{"errcode":310000,"errmsg":"sign not match"}`)); err == nil {
+		t.Error("errcode response accepted")
+	}
+	if err := adapter.CheckResponse(http.StatusBadGateway, nil); err == nil {
+		t.Error("502 response accepted")
+	}
+}
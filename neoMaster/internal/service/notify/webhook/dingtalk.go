@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dingTalkMaxText 钉钉 markdown 消息正文上限为 20000 字节，预留余量
+const dingTalkMaxText = 18000
+
+var dingTalkEscaper = strings.NewReplacer("\n", " ", "\r", " ")
+
+type dingTalkMarkdown struct {
+	Title string `json:"title"` // 会话列表中显示的摘要
+	Text  string `json:"text"`
+}
+
+type dingTalkMessage struct {
+	MsgType  string            `json:"msgtype"`
+	Markdown *dingTalkMarkdown `json:"markdown"`
+}
+
+// dingTalkResponse 钉钉接口无论成功与否都返回 HTTP 200，以 errcode 区分
+type dingTalkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// dingTalkAdapter 钉钉自定义机器人，消息使用 markdown 格式
+// 订阅配置了密钥时按钉钉"加签"方式在 URL 上附加 timestamp 与 sign 参数
+type dingTalkAdapter struct{}
+
+func (dingTalkAdapter) Render(msg *Message, opts RenderOptions) ([]byte, error) {
+	s := summarize(msg, opts, dingTalkEscaper.Replace)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", s.Title)
+	for _, item := range s.Items {
+		if item.Link != "" {
+			fmt.Fprintf(&b, "- [%s](%s)\n", item.Text, item.Link)
+		} else {
+			fmt.Fprintf(&b, "- %s\n", item.Text)
+		}
+	}
+	if s.Omitted > 0 {
+		fmt.Fprintf(&b, "\n> 其余 %d 条未列出", s.Omitted)
+		if s.MoreURL != "" {
+			fmt.Fprintf(&b, "，[在 NeoScan 中查看全部](%s)", s.MoreURL)
+		}
+		b.WriteString("\n")
+	}
+
+	return json.Marshal(&dingTalkMessage{
+		MsgType:  "markdown",
+		Markdown: &dingTalkMarkdown{Title: s.Title, Text: truncateBytes(b.String(), dingTalkMaxText)},
+	})
+}
+
+func (dingTalkAdapter) Prepare(req *http.Request, secret string, _ []byte) error {
+	if secret == "" {
+		return nil
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+
+	query := req.URL.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	req.URL.RawQuery = query.Encode()
+	return nil
+}
+
+func (dingTalkAdapter) CheckResponse(statusCode int, body []byte) error {
+	if statusCode < 200 || statusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", statusCode)
+	}
+	var resp dingTalkResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid dingtalk response: %w", err)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("dingtalk errcode %d: %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
+func (dingTalkAdapter) Digest() bool { return true }
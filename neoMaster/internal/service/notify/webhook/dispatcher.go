@@ -0,0 +1,455 @@
+/*
+ * @author: sun977
+ * @date: 2026.10.16
+ * @description: Webhook 事件投递
+ * @func:
+ * 1.Notify 非阻塞发布事件，后台协程查找订阅该事件的已启用订阅并逐个投递
+ * 2.请求体由订阅类型对应的 Adapter 生成 (generic 原始事件 + X-Signature 签名 / slack / dingtalk / email)
+ * 3.聊天平台与邮件订阅按 DigestInterval 限流，间隔内的事件合并为一条摘要消息
+ * 4.投递失败时按指数退避重试，每次尝试记录到 webhook_deliveries
+ */
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"neomaster/internal/config"
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+	"neomaster/internal/pkg/utils"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+)
+
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultMaxAttempts      = 5
+	defaultRetryInterval    = 5 * time.Second
+	defaultRetryMaxInterval = 5 * time.Minute
+	defaultQueueSize        = 1000
+	defaultWorkerNum        = 4
+	defaultDigestMaxItems   = 10
+	digestBufferLimit       = 1000 // 合并消息最多保留的事件数(邮件 CSV 附件使用)，超出部分只计数
+
+	storeTimeout     = 5 * time.Second // 查询订阅/写投递记录的超时
+	maxResponseDrain = 64 << 10        // 读取的响应体上限(平台错误信息足够，且便于复用连接)
+	userAgent        = "NeoScan-Webhook/1.0"
+)
+
+// 请求头
+const (
+	HeaderSignature = "X-Signature"         // 签名 sha256=<hex>，签名内容为 "<timestamp>.<body>"
+	HeaderTimestamp = "X-Webhook-Timestamp" // 发送时间(Unix 秒)，每次尝试重新生成，接收方据此拒绝重放
+	HeaderEvent     = "X-Webhook-Event"     // 事件类型
+	HeaderEventID   = "X-Webhook-Id"        // 事件ID，重试时不变，接收方据此去重
+	HeaderAttempt   = "X-Webhook-Attempt"   // 第几次尝试(从1开始)
+)
+
+// Options 投递参数
+type Options struct {
+	Timeout          time.Duration // 单次请求超时
+	MaxAttempts      int           // 最大尝试次数(含首次)
+	RetryInterval    time.Duration // 指数退避基数
+	RetryMaxInterval time.Duration // 指数退避上限
+	QueueSize        int           // 待投递事件队列容量
+	WorkerNum        int           // 投递协程数
+	DigestInterval   time.Duration // 聊天平台订阅两条消息的最小间隔，<=0 不限流
+	DigestMaxItems   int           // 单条消息最多列出的事件数
+	UIBaseURL        string        // Web 控制台地址，用于生成消息中的跳转链接
+	// AllowPrivateNetworks 是否允许投递到内网/回环地址，默认拒绝(防 SSRF)，仅内网部署的接收端需要开启
+	AllowPrivateNetworks bool
+}
+
+// OptionsFromConfig 由配置生成投递参数，未配置或非法的项使用默认值
+func OptionsFromConfig(cfg config.WebhookConfig) Options {
+	return Options{
+		Timeout:          time.Duration(cfg.Timeout) * time.Second,
+		MaxAttempts:      cfg.MaxAttempts,
+		RetryInterval:    time.Duration(cfg.RetryInterval) * time.Second,
+		RetryMaxInterval: time.Duration(cfg.RetryMaxInterval) * time.Second,
+		QueueSize:        cfg.QueueSize,
+		WorkerNum:        cfg.WorkerNum,
+		DigestInterval:   time.Duration(cfg.DigestInterval) * time.Second,
+		DigestMaxItems:   cfg.DigestMaxItems,
+		UIBaseURL:        cfg.UIBaseURL,
+
+		AllowPrivateNetworks: cfg.AllowPrivateNetworks,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = defaultRetryInterval
+	}
+	if o.RetryMaxInterval <= 0 {
+		o.RetryMaxInterval = defaultRetryMaxInterval
+	}
+	if o.RetryMaxInterval < o.RetryInterval {
+		o.RetryMaxInterval = o.RetryInterval
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = defaultQueueSize
+	}
+	if o.WorkerNum <= 0 {
+		o.WorkerNum = defaultWorkerNum
+	}
+	if o.DigestMaxItems <= 0 {
+		o.DigestMaxItems = defaultDigestMaxItems
+	}
+	return o
+}
+
+// deliveryJob 对一个订阅的一次投递(一个事件或一条合并摘要)
+type deliveryJob struct {
+	sub        *orcmodel.WebhookSubscription
+	adapter    Adapter
+	eventID    string // 合并投递时为第一个事件的 ID
+	eventType  string
+	eventCount int
+	msg        *Message
+	body       []byte
+}
+
+// digestState 聊天平台订阅的限流状态
+type digestState struct {
+	lastSent time.Time
+	pending  *Message // 等待合并发送的事件，为空表示没有待发送的摘要
+}
+
+// Dispatcher Webhook 事件分发器，实现 Notifier
+// 事件先进入内存队列，由后台协程展开为对各订阅的投递；同一订阅的重试在投递协程内串行完成
+// 停止时正在退避等待的重试与队列中尚未展开的事件会被放弃(投递记录中可见已完成的尝试)
+type Dispatcher struct {
+	repo    *orcrepo.WebhookRepository
+	client  *http.Client
+	opts    Options
+	events  chan *orcmodel.WebhookEvent
+	jobs    chan *deliveryJob
+	dropped uint64 // 因队列满或已停止而丢弃的事件数
+
+	digestMu sync.Mutex
+	digests  map[uint64]*digestState // 订阅ID -> 限流状态
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewDispatcher 创建 Webhook 分发器
+func NewDispatcher(repo *orcrepo.WebhookRepository, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	return &Dispatcher{
+		repo:    repo,
+		client:  newHTTPClient(opts.Timeout, opts.AllowPrivateNetworks),
+		opts:    opts,
+		events:  make(chan *orcmodel.WebhookEvent, opts.QueueSize),
+		jobs:    make(chan *deliveryJob),
+		digests: make(map[uint64]*digestState),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start 启动后台分发与投递协程(重复调用无副作用)
+func (d *Dispatcher) Start() {
+	d.startOnce.Do(func() {
+		d.wg.Add(1 + d.opts.WorkerNum)
+		go d.fanout()
+		for i := 0; i < d.opts.WorkerNum; i++ {
+			go d.worker()
+		}
+	})
+}
+
+// Stop 停止分发，等待进行中的请求结束后返回
+func (d *Dispatcher) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.done)
+		d.wg.Wait()
+	})
+}
+
+// Dropped 因队列满或已停止而未投递的事件数
+func (d *Dispatcher) Dropped() uint64 {
+	return atomic.LoadUint64(&d.dropped)
+}
+
+// Notify 实现 Notifier，非阻塞入队
+func (d *Dispatcher) Notify(eventType string, data interface{}) {
+	select {
+	case <-d.done:
+		atomic.AddUint64(&d.dropped, 1)
+		return
+	default:
+	}
+
+	eventID, err := utils.GenerateUUID()
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.notify.webhook.Notify", "", map[string]interface{}{
+			"operation":  "webhook_notify",
+			"option":     "utils.GenerateUUID",
+			"func_name":  "service.notify.webhook.Notify",
+			"event_type": eventType,
+		})
+		return
+	}
+	event := &orcmodel.WebhookEvent{ID: eventID, Type: eventType, CreatedAt: time.Now(), Data: data}
+
+	select {
+	case d.events <- event:
+	default:
+		atomic.AddUint64(&d.dropped, 1)
+		logger.LogWarn("Webhook 事件队列已满，事件被丢弃", "", 0, "", "service.notify.webhook.Notify", "", map[string]interface{}{
+			"operation":  "webhook_notify",
+			"option":     "queue_full",
+			"func_name":  "service.notify.webhook.Notify",
+			"event_id":   eventID,
+			"event_type": eventType,
+		})
+	}
+}
+
+// fanout 查找订阅了事件的已启用订阅，为每个订阅生成一次投递
+func (d *Dispatcher) fanout() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.done:
+			return
+		case event := <-d.events:
+			ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			subs, err := d.repo.ListEnabledSubscriptions(ctx, event.Type)
+			cancel()
+			if err != nil {
+				continue
+			}
+			for _, sub := range subs {
+				adapter, ok := GetAdapter(sub.Type)
+				if !ok {
+					logger.LogWarn("Webhook 订阅类型未注册，跳过投递", "", 0, "", "service.notify.webhook.fanout", "", map[string]interface{}{
+						"operation":       "webhook_fanout",
+						"option":          "unknown_type",
+						"func_name":       "service.notify.webhook.fanout",
+						"subscription_id": sub.ID,
+						"type":            sub.Type,
+					})
+					continue
+				}
+				if adapter.Digest() && d.opts.DigestInterval > 0 {
+					d.enqueueDigest(sub, adapter, event)
+					continue
+				}
+				if !d.submit(sub, adapter, &Message{Events: []*orcmodel.WebhookEvent{event}, Total: 1}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// enqueueDigest 聊天平台订阅限流: 距上次发送已满 DigestInterval 时立即发送，
+// 否则暂存，到期后把期间的所有事件合并为一条消息发送
+func (d *Dispatcher) enqueueDigest(sub *orcmodel.WebhookSubscription, adapter Adapter, event *orcmodel.WebhookEvent) {
+	d.digestMu.Lock()
+	state, ok := d.digests[sub.ID]
+	if !ok {
+		state = &digestState{}
+		d.digests[sub.ID] = state
+	}
+	if state.pending != nil {
+		// 已有待发送的摘要，超出缓冲上限的事件只计数
+		state.pending.Total++
+		if len(state.pending.Events) < digestBufferLimit {
+			state.pending.Events = append(state.pending.Events, event)
+		}
+		d.digestMu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	wait := state.lastSent.Add(d.opts.DigestInterval).Sub(now)
+	if wait <= 0 {
+		state.lastSent = now
+		d.digestMu.Unlock()
+		d.submit(sub, adapter, &Message{Events: []*orcmodel.WebhookEvent{event}, Total: 1})
+		return
+	}
+	state.pending = &Message{Events: []*orcmodel.WebhookEvent{event}, Total: 1}
+	d.digestMu.Unlock()
+
+	time.AfterFunc(wait, func() {
+		d.digestMu.Lock()
+		msg := state.pending
+		state.pending = nil
+		state.lastSent = time.Now()
+		d.digestMu.Unlock()
+		if msg != nil {
+			d.submit(sub, adapter, msg)
+		}
+	})
+}
+
+// submit 渲染消息并交给投递协程，分发器已停止时返回 false
+func (d *Dispatcher) submit(sub *orcmodel.WebhookSubscription, adapter Adapter, msg *Message) bool {
+	first := msg.Events[0]
+	job := &deliveryJob{sub: sub, adapter: adapter, eventID: first.ID, eventType: first.Type, eventCount: msg.Total, msg: msg}
+	for _, e := range msg.Events[1:] {
+		if e.Type != first.Type {
+			job.eventType = orcmodel.WebhookEventDigest
+			break
+		}
+	}
+
+	body, err := adapter.Render(msg, RenderOptions{MaxItems: d.opts.DigestMaxItems, UIBaseURL: d.opts.UIBaseURL})
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "service.notify.webhook.submit", "", map[string]interface{}{
+			"operation":       "webhook_submit",
+			"option":          "adapter.Render",
+			"func_name":       "service.notify.webhook.submit",
+			"subscription_id": sub.ID,
+			"type":            sub.Type,
+			"event_id":        job.eventID,
+		})
+		return true
+	}
+	job.body = body
+
+	select {
+	case d.jobs <- job:
+		return true
+	case <-d.done:
+		return false
+	}
+}
+
+// worker 投递协程
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.done:
+			return
+		case job := <-d.jobs:
+			d.deliver(job)
+		}
+	}
+}
+
+// deliver 投递一次事件，失败时按指数退避重试，直到成功、尝试次数用尽或分发器停止
+func (d *Dispatcher) deliver(job *deliveryJob) {
+	for attempt := 1; attempt <= d.opts.MaxAttempts; attempt++ {
+		if d.attempt(job, attempt) {
+			return
+		}
+		if attempt == d.opts.MaxAttempts {
+			break
+		}
+		timer := time.NewTimer(d.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-d.done:
+			timer.Stop()
+			return
+		}
+	}
+
+	logger.LogWarn("Webhook 投递失败，重试次数已用尽", "", 0, "", "service.notify.webhook.deliver", "", map[string]interface{}{
+		"operation":       "webhook_deliver",
+		"option":          "attempts_exhausted",
+		"func_name":       "service.notify.webhook.deliver",
+		"subscription_id": job.sub.ID,
+		"event_id":        job.eventID,
+		"event_type":      job.eventType,
+		"attempts":        d.opts.MaxAttempts,
+	})
+}
+
+// backoff 第 attempt 次失败后的等待时间: RetryInterval * 2^(attempt-1)，不超过 RetryMaxInterval
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.opts.RetryInterval
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= d.opts.RetryMaxInterval {
+			return d.opts.RetryMaxInterval
+		}
+	}
+	return wait
+}
+
+// attempt 发送一次请求并记录投递结果，是否成功由订阅类型的 Adapter 判定
+func (d *Dispatcher) attempt(job *deliveryJob, attempt int) bool {
+	start := time.Now()
+	code, err := d.send(job, attempt)
+	record := &orcmodel.WebhookDelivery{
+		SubscriptionID: job.sub.ID,
+		EventID:        job.eventID,
+		EventType:      job.eventType,
+		EventCount:     job.eventCount,
+		Payload:        string(job.body),
+		Attempt:        attempt,
+		Status:         orcmodel.WebhookDeliverySuccess,
+		ResponseCode:   code,
+		DurationMs:     time.Since(start).Milliseconds(),
+		DeliveredAt:    start,
+	}
+	if err != nil {
+		record.Status = orcmodel.WebhookDeliveryFailed
+		record.Error = err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	// 记录失败只影响审计，不影响投递结果
+	_ = d.repo.CreateDelivery(ctx, record)
+	return err == nil
+}
+
+// send 发送一次消息，实现了 Sender 的适配器自行发送(响应码为0)，其余通过 HTTP POST
+func (d *Dispatcher) send(job *deliveryJob, attempt int) (int, error) {
+	sender, ok := job.adapter.(Sender)
+	if !ok {
+		return d.post(job, attempt)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	defer cancel()
+	return 0, sender.Send(ctx, job.sub, job.msg, job.body)
+}
+
+// post 发送消息，返回响应码与平台判定的投递错误
+func (d *Dispatcher) post(job *deliveryJob, attempt int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.sub.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, job.eventType)
+	req.Header.Set(HeaderEventID, job.eventID)
+	req.Header.Set(HeaderAttempt, strconv.Itoa(attempt))
+	if err = job.adapter.Prepare(req, job.sub.Secret, job.body); err != nil {
+		return 0, err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseDrain))
+	return resp.StatusCode, job.adapter.CheckResponse(resp.StatusCode, respBody)
+}
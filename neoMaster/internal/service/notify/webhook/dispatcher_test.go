@@ -0,0 +1,317 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"1","type":"project.completed"}`)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := Sign("0123456789abcdef", ts, body)
+	if len(sig) != len("sha256=")+64 {
+		t.Fatalf("signature = %q, want sha256=<64 hex>", sig)
+	}
+	if !Verify("0123456789abcdef", ts, body, sig) {
+		t.Error("valid signature rejected")
+	}
+	if Verify("another-secret-value", ts, body, sig) {
+		t.Error("signature with wrong secret accepted")
+	}
+	if Verify("0123456789abcdef", ts, []byte(`{"id":"2","type":"project.completed"}`), sig) {
+		t.Error("signature of tampered body accepted")
+	}
+	if Verify("0123456789abcdef", ts, body, sig[len("sha256="):]) {
+		t.Error("signature without algorithm prefix accepted")
+	}
+	if Verify("0123456789abcdef", strconv.FormatInt(time.Now().Unix()+1, 10), body, sig) {
+		t.Error("signature with tampered timestamp accepted")
+	}
+	// 超出允许偏差的旧请求(重放)即使签名正确也拒绝
+	old := strconv.FormatInt(time.Now().Add(-SignatureTolerance-time.Minute).Unix(), 10)
+	if Verify("0123456789abcdef", old, body, Sign("0123456789abcdef", old, body)) {
+		t.Error("stale timestamp accepted")
+	}
+}
+
+func newTestRepo(t *testing.T) *orcrepo.WebhookRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// 分发协程与测试协程共用同一个内存库
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&orcmodel.WebhookSubscription{}, &orcmodel.WebhookDelivery{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return orcrepo.NewWebhookRepository(db)
+}
+
+func TestDispatcher_RetriesUntilSuccess(t *testing.T) {
+	const secret = "webhook-test-secret"
+	var calls int32
+	received := make(chan *orcmodel.WebhookEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			t.Errorf("bad signature %q", r.Header.Get(HeaderSignature))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// 前两次返回 500，第三次成功
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var event orcmodel.WebhookEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if r.Header.Get(HeaderEventID) != event.ID || r.Header.Get(HeaderAttempt) != "3" {
+			t.Errorf("headers id=%q attempt=%q, event id %q", r.Header.Get(HeaderEventID), r.Header.Get(HeaderAttempt), event.ID)
+		}
+		received <- &event
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	sub := &orcmodel.WebhookSubscription{Name: "ci", URL: server.URL, Secret: secret, Enabled: true,
+		EventTypes: []string{orcmodel.WebhookEventProjectCompleted}}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	// 未订阅该事件的订阅不应收到投递
+	other := &orcmodel.WebhookSubscription{Name: "vuln-only", URL: server.URL + "/other", Secret: secret, Enabled: true,
+		EventTypes: []string{orcmodel.WebhookEventFindingCritical}}
+	if err := repo.CreateSubscription(ctx, other); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	d := NewDispatcher(repo, Options{Timeout: time.Second, MaxAttempts: 5, RetryInterval: 10 * time.Millisecond, WorkerNum: 1, AllowPrivateNetworks: true})
+	d.Start()
+	d.Notify(orcmodel.WebhookEventProjectCompleted, &ProjectCompletedData{ProjectID: 7, Name: "weekly", RunID: 3, Status: "finished"})
+
+	var event *orcmodel.WebhookEvent
+	select {
+	case event = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	d.Stop()
+
+	if event.Type != orcmodel.WebhookEventProjectCompleted {
+		t.Errorf("event type = %q", event.Type)
+	}
+	if data, ok := event.Data.(map[string]interface{}); !ok || data["project_id"] != float64(7) || data["run_id"] != float64(3) {
+		t.Errorf("event data = %#v", event.Data)
+	}
+
+	deliveries, total, err := repo.ListDeliveries(ctx, sub.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("deliveries = %d, want 3 attempts", total)
+	}
+	// 最新的在前
+	if last := deliveries[0]; last.Attempt != 3 || last.Status != orcmodel.WebhookDeliverySuccess || last.ResponseCode != http.StatusNoContent {
+		t.Errorf("last attempt = %+v", last)
+	}
+	if first := deliveries[2]; first.Attempt != 1 || first.Status != orcmodel.WebhookDeliveryFailed || first.ResponseCode != http.StatusInternalServerError || first.EventID != event.ID {
+		t.Errorf("first attempt = %+v", first)
+	}
+	if _, total, _ = repo.ListDeliveries(ctx, other.ID, 1, 10); total != 0 {
+		t.Errorf("unsubscribed webhook got %d deliveries", total)
+	}
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// 超过请求超时才响应
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	sub := &orcmodel.WebhookSubscription{Name: "slow", URL: server.URL, Secret: "webhook-test-secret", Enabled: true,
+		EventTypes: []string{orcmodel.WebhookEventFindingCritical}}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	d := NewDispatcher(repo, Options{Timeout: 50 * time.Millisecond, MaxAttempts: 2, RetryInterval: 10 * time.Millisecond, WorkerNum: 1, AllowPrivateNetworks: true})
+	d.Start()
+	d.Notify(orcmodel.WebhookEventFindingCritical, &CriticalFindingData{VulnID: 1, Severity: "critical"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	var deliveries []*orcmodel.WebhookDelivery
+	for time.Now().Before(deadline) {
+		deliveries, _, _ = repo.ListDeliveries(ctx, sub.ID, 1, 10)
+		if len(deliveries) == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	d.Stop()
+
+	if len(deliveries) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(deliveries))
+	}
+	for _, delivery := range deliveries {
+		if delivery.Status != orcmodel.WebhookDeliveryFailed || delivery.ResponseCode != 0 || delivery.Error == "" {
+			t.Errorf("attempt %d = %+v, want timeout failure", delivery.Attempt, delivery)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(nil, Options{RetryInterval: time.Second, RetryMaxInterval: 5 * time.Second})
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestDispatcher_DigestMergesChatEvents(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	sub := &orcmodel.WebhookSubscription{Name: "slack", Type: orcmodel.WebhookTypeSlack, URL: server.URL, Enabled: true,
+		EventTypes: []string{orcmodel.WebhookEventFindingCritical}}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	d := NewDispatcher(repo, Options{Timeout: time.Second, MaxAttempts: 1, WorkerNum: 1,
+		DigestInterval: 300 * time.Millisecond, DigestMaxItems: 3, AllowPrivateNetworks: true})
+	d.Start()
+	// 第一个事件立即发送，其余在限流间隔内到达，合并为一条摘要
+	for i := 1; i <= 6; i++ {
+		d.Notify(orcmodel.WebhookEventFindingCritical, &CriticalFindingData{VulnID: uint64(i), Severity: "critical"})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var deliveries []*orcmodel.WebhookDelivery
+	for time.Now().Before(deadline) {
+		deliveries, _, _ = repo.ListDeliveries(ctx, sub.ID, 1, 10)
+		if len(deliveries) == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	d.Stop()
+
+	if len(deliveries) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(deliveries))
+	}
+	if first := deliveries[1]; first.EventCount != 1 || first.Status != orcmodel.WebhookDeliverySuccess {
+		t.Errorf("first delivery = %+v", first)
+	}
+	digest := deliveries[0]
+	if digest.EventCount != 5 || digest.EventType != orcmodel.WebhookEventFindingCritical || digest.Status != orcmodel.WebhookDeliverySuccess {
+		t.Errorf("digest delivery = %+v", digest)
+	}
+	if !strings.Contains(digest.Payload, "其余 2 条") {
+		t.Errorf("digest payload = %s", digest.Payload)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+}
+
+func TestDispatcher_RejectsPrivateDestination(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	sub := &orcmodel.WebhookSubscription{Name: "internal", URL: server.URL, Secret: "webhook-test-secret", Enabled: true,
+		EventTypes: []string{orcmodel.WebhookEventFindingCritical}}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	// 默认拒绝回环地址，拨号前即失败
+	d := NewDispatcher(repo, Options{Timeout: time.Second, MaxAttempts: 1, WorkerNum: 1})
+	d.Start()
+	d.Notify(orcmodel.WebhookEventFindingCritical, &CriticalFindingData{VulnID: 1, Severity: "critical"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	var deliveries []*orcmodel.WebhookDelivery
+	for time.Now().Before(deadline) {
+		if deliveries, _, _ = repo.ListDeliveries(ctx, sub.ID, 1, 10); len(deliveries) == 1 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	d.Stop()
+
+	if len(deliveries) != 1 || deliveries[0].Status != orcmodel.WebhookDeliveryFailed || !strings.Contains(deliveries[0].Error, "not allowed") {
+		t.Fatalf("deliveries = %+v, want one rejected attempt", deliveries)
+	}
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Errorf("server calls = %d, want 0", got)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://100.100.100.200/",
+		"http://[::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if err := CheckURL(context.Background(), rawURL); !errors.Is(err, ErrForbiddenDestination) {
+			t.Errorf("CheckURL(%s) = %v, want ErrForbiddenDestination", rawURL, err)
+		}
+	}
+	if err := CheckURL(context.Background(), "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("CheckURL(public ip) = %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package webhook
+
+import "time"
+
+// Notifier 事件通知接口
+// 编排器(项目状态流转)与 ETL(漏洞入库)只依赖此接口，投递细节由 Dispatcher 负责
+type Notifier interface {
+	// Notify 异步发布事件，不阻塞调用方，也不返回投递结果
+	Notify(eventType string, data interface{})
+}
+
+// ProjectCompletedData project.completed 事件数据
+type ProjectCompletedData struct {
+	ProjectID  uint64    `json:"project_id"`
+	Name       string    `json:"name"`
+	RunID      int64     `json:"run_id"` // 完成的执行轮次
+	Status     string    `json:"status"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// CriticalFindingData finding.critical 事件数据
+type CriticalFindingData struct {
+	ProjectID   uint64     `json:"project_id,omitempty"` // 产生该发现的项目(来源不是项目扫描时为空)
+	VulnID      uint64     `json:"vuln_id"`
+	HostIP      string     `json:"host_ip"`
+	TargetType  string     `json:"target_type"` // host/service/web
+	TargetRefID uint64     `json:"target_ref_id"`
+	CVE         string     `json:"cve,omitempty"`
+	IDAlias     string     `json:"id_alias"`
+	Severity    string     `json:"severity"`
+	FirstSeenAt *time.Time `json:"first_seen_at"`
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenDestination 订阅地址指向内网、回环、链路本地或云元数据地址
+var ErrForbiddenDestination = errors.New("webhook destination address is not allowed")
+
+// forbiddenNetworks IsPrivate/IsLoopback 等未覆盖的保留网段
+var forbiddenNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // 本网络
+	"100.64.0.0/10", // 运营商级 NAT (部分云厂商元数据服务，如 100.100.100.200)
+	"192.0.0.0/24",  // IETF 协议分配
+	"198.18.0.0/15", // 基准测试
+	"240.0.0.0/4",   // 保留
+	"64:ff9b::/96",  // NAT64，可映射到任意 IPv4 内网地址
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// CheckIP 校验投递目标 IP，拒绝回环、私有、链路本地(含 169.254.169.254 元数据地址)、组播及保留地址
+func CheckIP(ip net.IP) error {
+	if ip == nil {
+		return fmt.Errorf("%w: invalid ip", ErrForbiddenDestination)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, ip)
+	}
+	for _, n := range forbiddenNetworks {
+		if n.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenDestination, ip)
+		}
+	}
+	return nil
+}
+
+// CheckURL 保存订阅时校验投递地址
+// IP 字面量与 localhost 直接校验；域名解析成功时校验全部解析结果，解析失败时放行(DNS 可能暂时不可用)，
+// 投递时拨号层仍会校验实际连接的地址，防止 DNS 重绑定绕过
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckIP(ip)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := CheckIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guardedControl 拨号前校验解析后的目标地址
+func guardedControl(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	return CheckIP(net.ParseIP(host))
+}
+
+// newHTTPClient 创建投递使用的 HTTP 客户端
+// allowPrivate 为 false 时在拨号层拒绝内网地址(含重定向后的地址)，且不使用环境变量代理，避免经代理绕过校验
+func newHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: timeout}
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Control: guardedControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
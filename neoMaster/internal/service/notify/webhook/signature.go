@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signaturePrefix 签名值前缀，标明摘要算法，便于将来平滑升级算法
+const signaturePrefix = "sha256="
+
+// SignatureTolerance 接收方允许的发送时间偏差，超出视为重放
+const SignatureTolerance = 5 * time.Minute
+
+// Sign 计算签名: "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+// timestamp 为 X-Webhook-Timestamp 请求头的值，签名覆盖时间戳，截获的请求无法修改时间后重放
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验 X-Signature 与 X-Webhook-Timestamp 请求头，使用常量时间比较防止时序攻击
+// 时间戳与当前时间相差超过 SignatureTolerance 时拒绝
+// 接收方须对收到的原始请求体字节计算签名，不能先反序列化再重新编码
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	signature = strings.TrimSpace(signature)
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := time.Since(time.Unix(sec, 0)); skew > SignatureTolerance || skew < -SignatureTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
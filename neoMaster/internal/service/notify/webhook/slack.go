@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// slackMaxItems Slack 单条消息最多 50 个 block，扣除标题与汇总后逐条列出的上限
+const slackMaxItems = 40
+
+// slackEscaper Slack mrkdwn 需要转义的字符 (https://api.slack.com/reference/surfaces/formatting#escaping)
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\n", " ")
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string       `json:"type"`
+	Text     *slackText   `json:"text,omitempty"`
+	Elements []*slackText `json:"elements,omitempty"`
+}
+
+type slackMessage struct {
+	Text   string        `json:"text"` // 通知栏等不支持 blocks 的场景显示的文本
+	Blocks []*slackBlock `json:"blocks"`
+}
+
+// slackAdapter Slack Incoming Webhook，消息使用 Block Kit 格式
+type slackAdapter struct{ baseAdapter }
+
+func (slackAdapter) Render(msg *Message, opts RenderOptions) ([]byte, error) {
+	if opts.MaxItems > slackMaxItems {
+		opts.MaxItems = slackMaxItems
+	}
+	s := summarize(msg, opts, slackEscaper.Replace)
+
+	out := &slackMessage{
+		Text: s.Title,
+		Blocks: []*slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: s.Title}},
+		},
+	}
+	for _, item := range s.Items {
+		text := "• " + item.Text
+		if item.Link != "" {
+			text = fmt.Sprintf("• <%s|%s>", item.Link, item.Text)
+		}
+		out.Blocks = append(out.Blocks, &slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}})
+	}
+	if s.Omitted > 0 {
+		more := fmt.Sprintf("其余 %d 条未列出", s.Omitted)
+		if s.MoreURL != "" {
+			more += fmt.Sprintf("，<%s|在 NeoScan 中查看全部>", s.MoreURL)
+		}
+		out.Blocks = append(out.Blocks, &slackBlock{Type: "context", Elements: []*slackText{{Type: "mrkdwn", Text: more}}})
+	}
+	return json.Marshal(out)
+}
+
+func (slackAdapter) Digest() bool { return true }
@@ -0,0 +1,152 @@
+// AgentSelector Agent选择器
+// 职责: 在满足能力要求的在线 Agent 中，结合最新性能快照选出负载最低的 Agent。
+// 对应文档: 1.3 Resource Allocator -> AgentSelector (智能匹配)
+package allocator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/pkg/logger"
+)
+
+// ErrNoEligibleAgent 没有满足条件的在线Agent
+var ErrNoEligibleAgent = errors.New("no eligible agent")
+
+const (
+	defaultCPUWeight      = 0.4
+	defaultTaskWeight     = 0.4
+	defaultMemoryWeight   = 0.2
+	defaultMetricsMaxAge  = 180 * time.Second
+	defaultMaxConcurrency = 5
+)
+
+// AgentLoadRepository 选择Agent所需的数据访问能力 (AgentRepository 已实现)
+type AgentLoadRepository interface {
+	GetEligibleAgents(ctx context.Context, requiredCapabilities []string, requiredTags []string) ([]*agentModel.Agent, error)
+	GetMetricsByAgentIDs(agentIDs []string) ([]*agentModel.AgentMetrics, error)
+}
+
+// AgentSelector Agent选择器接口
+type AgentSelector interface {
+	// SelectBestAgent 选出支持全部能力且负载最低的在线Agent，没有候选时返回 ErrNoEligibleAgent
+	SelectBestAgent(ctx context.Context, requiredCapabilities []string) (*agentModel.Agent, error)
+}
+
+// LoadWeights 负载打分权重
+type LoadWeights struct {
+	CPU    float64 // CPU使用率权重
+	Tasks  float64 // 运行任务数权重
+	Memory float64 // 内存使用率权重
+}
+
+type agentSelector struct {
+	repo           AgentLoadRepository
+	weights        LoadWeights
+	metricsMaxAge  time.Duration
+	maxConcurrency int
+	now            func() time.Time
+}
+
+// NewAgentSelector 创建Agent选择器
+// 权重取自 app.master.dispatch，全部未配置(或非正)时使用默认权重 CPU 0.4 / 任务数 0.4 / 内存 0.2
+func NewAgentSelector(repo AgentLoadRepository, cfg *config.Config) AgentSelector {
+	s := &agentSelector{
+		repo:           repo,
+		weights:        LoadWeights{CPU: defaultCPUWeight, Tasks: defaultTaskWeight, Memory: defaultMemoryWeight},
+		metricsMaxAge:  defaultMetricsMaxAge,
+		maxConcurrency: defaultMaxConcurrency,
+		now:            time.Now,
+	}
+	if cfg == nil {
+		return s
+	}
+
+	dispatch := cfg.App.Master.Dispatch
+	weights := LoadWeights{
+		CPU:    nonNegative(dispatch.CPUWeight),
+		Tasks:  nonNegative(dispatch.TaskWeight),
+		Memory: nonNegative(dispatch.MemoryWeight),
+	}
+	if weights.CPU+weights.Tasks+weights.Memory > 0 {
+		s.weights = weights
+	}
+	if dispatch.MetricsMaxAge > 0 {
+		s.metricsMaxAge = time.Duration(dispatch.MetricsMaxAge) * time.Second
+	}
+	if cfg.App.Master.Task.MaxConcurrency > 0 {
+		s.maxConcurrency = cfg.App.Master.Task.MaxConcurrency
+	}
+	return s
+}
+
+// SelectBestAgent 选择负载最低的Agent
+// 1. GetEligibleAgents 过滤在线且支持全部能力的Agent
+// 2. 有效期内的性能快照按权重打分，分数最低者胜出 (同分保持候选顺序)
+// 3. 没有快照或快照过期的Agent负载未知，仅在没有已知负载的候选时才被选中
+func (s *agentSelector) SelectBestAgent(ctx context.Context, requiredCapabilities []string) (*agentModel.Agent, error) {
+	agents, err := s.repo.GetEligibleAgents(ctx, requiredCapabilities, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, ErrNoEligibleAgent
+	}
+
+	agentIDs := make([]string, 0, len(agents))
+	for _, a := range agents {
+		agentIDs = append(agentIDs, a.AgentID)
+	}
+	metricsList, err := s.repo.GetMetricsByAgentIDs(agentIDs)
+	if err != nil {
+		// 指标查询失败不影响分发，退化为候选顺序(运行任务数升序)
+		logger.LogWarn("Failed to load agent metrics, falling back to eligibility order", "", 0, "", "service.orchestrator.allocator.SelectBestAgent", "", map[string]interface{}{
+			"error":        err.Error(),
+			"capabilities": requiredCapabilities,
+		})
+		return agents[0], nil
+	}
+
+	// 快照按时间倒序返回，每个Agent取第一条
+	latest := make(map[string]*agentModel.AgentMetrics, len(metricsList))
+	for _, m := range metricsList {
+		if _, ok := latest[m.AgentID]; !ok {
+			latest[m.AgentID] = m
+		}
+	}
+
+	cutoff := s.now().Add(-s.metricsMaxAge)
+	var best *agentModel.Agent
+	bestScore := 0.0
+	for _, a := range agents {
+		m, ok := latest[a.AgentID]
+		if !ok || m.Timestamp.Before(cutoff) {
+			continue
+		}
+		if score := s.score(m); best == nil || score < bestScore {
+			best, bestScore = a, score
+		}
+	}
+	if best == nil {
+		best = agents[0]
+	}
+	return best, nil
+}
+
+// score 计算负载分数，各项归一化到 [0,1] 左右后加权求和
+// 运行任务数按单Agent最大并发数归一化，超出并发上限时分数大于 1
+func (s *agentSelector) score(m *agentModel.AgentMetrics) float64 {
+	return s.weights.CPU*m.CPUUsage/100 +
+		s.weights.Tasks*float64(m.RunningTasks)/float64(s.maxConcurrency) +
+		s.weights.Memory*m.MemoryUsage/100
+}
+
+func nonNegative(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+package allocator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+)
+
+type fakeLoadRepo struct {
+	agents     []*agentModel.Agent
+	metrics    []*agentModel.AgentMetrics
+	metricsErr error
+}
+
+func (f *fakeLoadRepo) GetEligibleAgents(ctx context.Context, caps []string, tags []string) ([]*agentModel.Agent, error) {
+	return f.agents, nil
+}
+
+func (f *fakeLoadRepo) GetMetricsByAgentIDs(agentIDs []string) ([]*agentModel.AgentMetrics, error) {
+	return f.metrics, f.metricsErr
+}
+
+func TestAgentSelector_SelectBestAgent(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	agents := []*agentModel.Agent{
+		{AgentID: "no-metrics"},
+		{AgentID: "stale"},
+		{AgentID: "cpu-heavy"},
+		{AgentID: "task-heavy"},
+	}
+	metrics := []*agentModel.AgentMetrics{
+		{AgentID: "stale", Timestamp: now.Add(-time.Hour)},
+		{AgentID: "cpu-heavy", CPUUsage: 90, RunningTasks: 0, Timestamp: now},
+		{AgentID: "task-heavy", CPUUsage: 10, RunningTasks: 5, Timestamp: now},
+	}
+
+	newSelector := func(repo AgentLoadRepository, dispatch config.DispatchConfig) AgentSelector {
+		cfg := &config.Config{}
+		cfg.App.Master.Dispatch = dispatch
+		cfg.App.Master.Task.MaxConcurrency = 5
+		s := NewAgentSelector(repo, cfg).(*agentSelector)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		repo     *fakeLoadRepo
+		dispatch config.DispatchConfig
+		want     string
+	}{
+		// 默认权重: cpu-heavy = 0.4*0.9 = 0.36, task-heavy = 0.4*0.1 + 0.4*5/5 = 0.44
+		{name: "default_weights", repo: &fakeLoadRepo{agents: agents, metrics: metrics}, want: "cpu-heavy"},
+		{name: "favor_cpu", repo: &fakeLoadRepo{agents: agents, metrics: metrics}, dispatch: config.DispatchConfig{CPUWeight: 1, TaskWeight: 0.1}, want: "task-heavy"},
+		{name: "favor_tasks", repo: &fakeLoadRepo{agents: agents, metrics: metrics}, dispatch: config.DispatchConfig{CPUWeight: 0.1, TaskWeight: 1}, want: "cpu-heavy"},
+		// 没有有效快照时退化为候选顺序
+		{name: "unknown_load_only", repo: &fakeLoadRepo{agents: agents[:2], metrics: metrics}, want: "no-metrics"},
+		{name: "metrics_error", repo: &fakeLoadRepo{agents: agents, metricsErr: errors.New("db down")}, want: "no-metrics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSelector(tt.repo, tt.dispatch).SelectBestAgent(context.Background(), []string{"webScan"})
+			if err != nil {
+				t.Fatalf("SelectBestAgent() error = %v", err)
+			}
+			if got.AgentID != tt.want {
+				t.Errorf("SelectBestAgent() = %s, want %s", got.AgentID, tt.want)
+			}
+		})
+	}
+
+	if _, err := newSelector(&fakeLoadRepo{}, config.DispatchConfig{}).SelectBestAgent(context.Background(), nil); !errors.Is(err, ErrNoEligibleAgent) {
+		t.Errorf("SelectBestAgent() with no candidates error = %v, want ErrNoEligibleAgent", err)
+	}
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrInvalidCronExpr Cron 表达式非法
+var ErrInvalidCronExpr = errors.New("invalid cron expression")
+
+// cronParser 标准 Cron 解析器 (5位: 分 时 日 月 周)，同时支持 @daily/@every 1h 等描述符
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// scheduleLockTTL 定时触发锁有效期，需覆盖多实例间读到旧 NextRunAt 的时间窗口
+const scheduleLockTTL = 5 * time.Minute
+
+// ScheduleLocker 定时触发分布式锁 (redis.ScheduleLockRepository 已实现)
+// 多个 Master 实例同时轮询时，同一项目的同一次触发只允许一个实例执行
+type ScheduleLocker interface {
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// ParseCronExpr 解析项目 Cron 表达式，错误统一包装为 ErrInvalidCronExpr
+func ParseCronExpr(expr string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("%w: expression is empty", ErrInvalidCronExpr)
+	}
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v (expected 5 fields: minute hour day-of-month month day-of-week)", ErrInvalidCronExpr, expr, err)
+	}
+	return schedule, nil
+}
+
+// NextRunTimes 计算 from 之后的 n 次执行时间
+// 表达式不再匹配任何时间时(如 2 月 30 日)提前结束，返回的数量可能小于 n
+func NextRunTimes(expr string, from time.Time, n int) ([]time.Time, error) {
+	schedule, err := ParseCronExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]time.Time, 0, n)
+	next := from
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
+
+// NextRunAfter 从上一个周期点 prev 出发，计算 now 之后的下一次执行时间
+// 标准 cron 的周期点本身是绝对时间，等价于 schedule.Next(now)；
+// @every 这类固定间隔调度按 prev + k*间隔 推算，保证重新提交或补触发后节奏不随当前时间漂移
+// prev 为零值时按 now 计算
+func NextRunAfter(schedule cron.Schedule, prev, now time.Time) time.Time {
+	every, ok := schedule.(cron.ConstantDelaySchedule)
+	if !ok || prev.IsZero() || every.Delay <= 0 {
+		return schedule.Next(now)
+	}
+	if prev.After(now) {
+		return prev
+	}
+	steps := now.Sub(prev)/every.Delay + 1
+	return prev.Add(steps * every.Delay)
+}
+
+// scheduleLockKey 按项目和本次触发时间生成锁键，下一次触发使用新键
+func scheduleLockKey(projectID uint64, runAt time.Time) string {
+	return fmt.Sprintf("project:%d:%d", projectID, runAt.Unix())
+}
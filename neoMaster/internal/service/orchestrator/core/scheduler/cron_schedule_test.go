@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	orcModel "neomaster/internal/model/orchestrator"
+	orcRepo "neomaster/internal/repo/mysql/orchestrator"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// memoryScheduleLocker 进程内定时触发锁，多个调度器实例共享同一个实例模拟 Redis
+type memoryScheduleLocker struct {
+	mu   sync.Mutex
+	keys map[string]bool
+	err  error
+}
+
+func (m *memoryScheduleLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
+
+func TestParseCronExpr(t *testing.T) {
+	valid := []string{"*/5 * * * *", "0 2 * * 1-5", " 30 3 1 * * ", "@daily", "@every 1h"}
+	for _, expr := range valid {
+		if _, err := ParseCronExpr(expr); err != nil {
+			t.Errorf("ParseCronExpr(%q) unexpected error: %v", expr, err)
+		}
+	}
+
+	invalid := []string{"", "   ", "* * * *", "0 0 * * * *", "61 * * * *", "every day"}
+	for _, expr := range invalid {
+		_, err := ParseCronExpr(expr)
+		if !errors.Is(err, ErrInvalidCronExpr) {
+			t.Errorf("ParseCronExpr(%q) error = %v, want ErrInvalidCronExpr", expr, err)
+		}
+	}
+}
+
+func TestNextRunTimes(t *testing.T) {
+	from := time.Date(2026, 10, 16, 1, 30, 0, 0, time.UTC)
+	runs, err := NextRunTimes("0 2 * * *", from, 3)
+	if err != nil {
+		t.Fatalf("NextRunTimes error: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 18, 2, 0, 0, 0, time.UTC),
+	}
+	if len(runs) != len(want) {
+		t.Fatalf("NextRunTimes returned %d runs, want %d", len(runs), len(want))
+	}
+	for i := range want {
+		if !runs[i].Equal(want[i]) {
+			t.Errorf("run[%d] = %v, want %v", i, runs[i], want[i])
+		}
+	}
+
+	// 2 月 30 日永远不会到来
+	runs, err = NextRunTimes("0 0 30 2 *", from, 3)
+	if err != nil || len(runs) != 0 {
+		t.Errorf("NextRunTimes(never) = %v, %v; want empty", runs, err)
+	}
+}
+
+func TestNextRunAfter(t *testing.T) {
+	prev := time.Date(2026, 10, 16, 0, 10, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 16, 3, 45, 0, 0, time.UTC)
+
+	every, _ := ParseCronExpr("@every 1h")
+	if got, want := NextRunAfter(every, prev, now), time.Date(2026, 10, 16, 4, 10, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("@every next = %v, want %v (keep cadence from previous slot)", got, want)
+	}
+	if got, want := NextRunAfter(every, time.Time{}, now), now.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("@every without previous slot = %v, want %v", got, want)
+	}
+
+	daily, _ := ParseCronExpr("0 2 * * *")
+	if got, want := NextRunAfter(daily, prev, now), time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("cron next = %v, want %v", got, want)
+	}
+}
+
+func newScheduledProjectDB(t *testing.T) (*gorm.DB, *orcRepo.ProjectRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err = db.AutoMigrate(&orcModel.Project{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db, orcRepo.NewProjectRepository(db)
+}
+
+func TestCheckScheduledProjects_FiresOnceAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newScheduledProjectDB(t)
+
+	due := time.Now().Add(-time.Minute)
+	project := &orcModel.Project{
+		Name:         "nightly",
+		Status:       orcModel.ProjectStatusFinished,
+		Enabled:      true,
+		ScheduleType: orcModel.ProjectScheduleCron,
+		CronExpr:     "*/5 * * * *",
+		NextRunAt:    &due,
+	}
+	if err := repo.CreateProject(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	// 两个 Master 实例在同一轮读到相同的到期项目
+	locker := &memoryScheduleLocker{keys: map[string]bool{}}
+	first := &schedulerService{projectRepo: repo, scheduleLocker: locker}
+	second := &schedulerService{projectRepo: repo, scheduleLocker: locker}
+	projects, err := repo.GetScheduledProjects(ctx)
+	if err != nil || len(projects) != 1 {
+		t.Fatalf("GetScheduledProjects = %v, %v", projects, err)
+	}
+	schedule, _ := ParseCronExpr(project.CronExpr)
+	now := time.Now()
+	first.triggerScheduledProject(ctx, projects[0], schedule, due, now)
+	if len(locker.keys) != 1 {
+		t.Fatalf("lock keys = %v, want 1", locker.keys)
+	}
+
+	// 把状态改回 finished，让状态 CAS 拦不住第二个实例，只验证锁本身
+	if err = db.Model(&orcModel.Project{}).Where("id = ?", project.ID).Update("status", orcModel.ProjectStatusFinished).Error; err != nil {
+		t.Fatalf("reset status: %v", err)
+	}
+	second.triggerScheduledProject(ctx, projects[0], schedule, due, now)
+
+	stored, err := repo.GetProjectByID(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if stored.Status != orcModel.ProjectStatusFinished {
+		t.Errorf("status = %s, second instance should not fire again", stored.Status)
+	}
+	if stored.NextRunAt == nil || !stored.NextRunAt.After(now) {
+		t.Errorf("next_run_at = %v, want after %v", stored.NextRunAt, now)
+	}
+	if stored.LastExecTime == nil {
+		t.Error("last_exec_time not recorded")
+	}
+	if stored.RunSeq != 1 {
+		t.Errorf("run_seq = %d, want 1 (one new run)", stored.RunSeq)
+	}
+}
+
+func TestCheckScheduledProjects(t *testing.T) {
+	ctx := context.Background()
+	_, repo := newScheduledProjectDB(t)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	projects := []*orcModel.Project{
+		{Name: "due", Status: orcModel.ProjectStatusIdle, Enabled: true, ScheduleType: orcModel.ProjectScheduleCron, CronExpr: "0 * * * *", NextRunAt: &past},
+		{Name: "not_due", Status: orcModel.ProjectStatusIdle, Enabled: true, ScheduleType: orcModel.ProjectScheduleCron, CronExpr: "0 * * * *", NextRunAt: &future},
+		{Name: "paused", Status: orcModel.ProjectStatusPaused, Enabled: true, ScheduleType: orcModel.ProjectScheduleCron, CronExpr: "0 * * * *", NextRunAt: &past},
+		{Name: "legacy", Status: orcModel.ProjectStatusIdle, Enabled: true, ScheduleType: orcModel.ProjectScheduleCron, CronExpr: "0 0 1 1 *"},
+	}
+	for _, p := range projects {
+		if err := repo.CreateProject(ctx, p); err != nil {
+			t.Fatalf("create project %s: %v", p.Name, err)
+		}
+	}
+
+	svc := &schedulerService{projectRepo: repo}
+	svc.checkScheduledProjects(ctx)
+
+	wantStatus := map[string]string{
+		"due":     orcModel.ProjectStatusRunning,
+		"not_due": orcModel.ProjectStatusIdle,
+		"paused":  orcModel.ProjectStatusPaused,
+		// 没有 NextRunAt 的历史数据按创建时间推算(下一次在 1 月 1 日)，只补写 NextRunAt
+		"legacy": orcModel.ProjectStatusIdle,
+	}
+	for _, p := range projects {
+		stored, err := repo.GetProjectByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("get project %s: %v", p.Name, err)
+		}
+		if stored.Status != wantStatus[p.Name] {
+			t.Errorf("%s status = %s, want %s", p.Name, stored.Status, wantStatus[p.Name])
+		}
+		if stored.NextRunAt == nil {
+			t.Errorf("%s next_run_at not persisted", p.Name)
+		}
+	}
+
+	// 锁服务异常时跳过本轮，不触发
+	failing := &schedulerService{projectRepo: repo, scheduleLocker: &memoryScheduleLocker{err: errors.New("redis down")}}
+	if err := repo.UpdateProjectNextRunAt(ctx, projects[1].ID, &past); err != nil {
+		t.Fatalf("update next_run_at: %v", err)
+	}
+	failing.checkScheduledProjects(ctx)
+	stored, _ := repo.GetProjectByID(ctx, projects[1].ID)
+	if stored.Status != orcModel.ProjectStatusIdle {
+		t.Errorf("not_due status = %s, want idle when lock unavailable", stored.Status)
+	}
+}
@@ -10,11 +10,11 @@
 // 5.策略执行：在任务执行前进行安全和合规性检查
 // 调度引擎的工作流程如下：
 // 1.启动后按照设定的时间间隔（默认10秒）循环执行调度逻辑
-// 2.每次调度首先检查是否有定时项目需要触发执行
+// 2.每次调度首先检查是否有定时项目需要触发执行，并按指数退避重新调度失败任务
 // 3.获取所有处于"running"状态的项目进行处理
 // 4.对每个项目：
 // - 检查是否有正在运行的任务，如有则等待
-// - 获取最新任务状态，如果上一个任务失败则暂停项目
+// - 获取最新任务状态，如果上一个任务重试耗尽(dead)则暂停项目
 // - 查找下一个需要执行的阶段
 // - 如果没有下一阶段则标记项目为完成
 // - 否则生成新任务并存入数据库供agent执行
@@ -33,6 +33,7 @@ import (
 	agentRepo "neomaster/internal/repo/mysql/agent"
 	assetRepo "neomaster/internal/repo/mysql/asset"
 	orcRepo "neomaster/internal/repo/mysql/orchestrator"
+	"neomaster/internal/service/notify/webhook"
 	"neomaster/internal/service/orchestrator/policy" // 策略执行器模块
 
 	"github.com/robfig/cron/v3" // 定时任务库
@@ -44,6 +45,9 @@ type SchedulerService interface {
 	Start(ctx context.Context)
 	Stop()
 	ProcessProject(ctx context.Context, project *orcModel.Project)
+	SetScheduleLocker(locker ScheduleLocker)
+	SetWebhookNotifier(notifier webhook.Notifier)
+	RescheduleFailedTasks(ctx context.Context) (int, error)
 }
 
 type schedulerService struct {
@@ -55,9 +59,14 @@ type schedulerService struct {
 	taskGenerator  TaskGenerator         // 任务生成器接口
 	targetProvider policy.TargetProvider // 目标提供者接口
 	policyEnforcer policy.PolicyEnforcer // 策略执行器接口
+	scheduleLocker ScheduleLocker        // 定时触发分布式锁，为空时仅依赖状态 CAS
+	notifier       webhook.Notifier      // 项目完成事件通知，为空时不发送
 
 	stopChan chan struct{} // 停止信号通道
 	interval time.Duration // 轮询间隔, 默认10秒
+
+	retryBaseInterval time.Duration // 失败重试退避基数
+	retryMaxInterval  time.Duration // 失败重试退避上限
 }
 
 // NewSchedulerService 创建调度引擎服务
@@ -76,6 +85,18 @@ func NewSchedulerService(
 		interval = 10 * time.Second
 	}
 
+	retryBase := time.Duration(cfg.App.Master.Task.RetryInterval) * time.Second
+	if retryBase <= 0 {
+		retryBase = 10 * time.Second
+	}
+	retryMax := time.Duration(cfg.App.Master.Task.RetryMaxInterval) * time.Second
+	if retryMax <= 0 {
+		retryMax = 600 * time.Second
+	}
+	if retryMax < retryBase {
+		retryMax = retryBase
+	}
+
 	// 初始化策略仓库
 	policyRepo := assetRepo.NewAssetPolicyRepository(db)
 
@@ -90,9 +111,22 @@ func NewSchedulerService(
 		policyEnforcer: policy.NewPolicyEnforcer(policyRepo),
 		stopChan:       make(chan struct{}),
 		interval:       interval,
+
+		retryBaseInterval: retryBase,
+		retryMaxInterval:  retryMax,
 	}
 }
 
+// SetScheduleLocker 注入定时触发分布式锁，需在 Start 之前调用
+func (s *schedulerService) SetScheduleLocker(locker ScheduleLocker) {
+	s.scheduleLocker = locker
+}
+
+// SetWebhookNotifier 注入 Webhook 事件通知，需在 Start 之前调用
+func (s *schedulerService) SetWebhookNotifier(notifier webhook.Notifier) {
+	s.notifier = notifier
+}
+
 // Start 启动调度引擎
 func (s *schedulerService) Start(ctx context.Context) {
 	logger.LogInfo("Starting Scheduler Engine...", "", 0, "", "service.scheduler.Start", "", map[string]interface{}{
@@ -140,6 +174,13 @@ func (s *schedulerService) schedule(ctx context.Context) {
 	// 0.5 检查任务超时
 	s.checkTaskTimeouts(ctx)
 
+	// 0.6 按指数退避重新调度失败任务 (放在超时检查之后，本轮超时的任务可立即进入退避)
+	if _, err := s.RescheduleFailedTasks(ctx); err != nil {
+		logger.LogError(err, "", 0, "", "service.scheduler.schedule", "REPO", map[string]interface{}{
+			"msg": "failed to reschedule failed tasks",
+		})
+	}
+
 	// 1. 获取运行中的项目
 	projects, err := s.projectRepo.GetRunningProjects(ctx)
 	if err != nil {
@@ -159,9 +200,9 @@ func (s *schedulerService) schedule(ctx context.Context) {
 }
 
 // checkScheduledProjects 检查是否有定时任务需要触发
-// 1. 获取所有已配置定时任务的项目
-// 2. 解析 Cron 表达式
-// 3. 检查是否到了执行时间
+// 1. 获取所有已配置定时任务且可以再次触发的项目
+// 2. 解析 Cron 表达式，确定本次应触发的时间 (NextRunAt，持久化后重启不丢失)
+// 3. 到期时获取分布式锁并触发执行，同时写入下一次执行时间
 func (s *schedulerService) checkScheduledProjects(ctx context.Context) {
 	projects, err := s.projectRepo.GetScheduledProjects(ctx)
 	if err != nil {
@@ -173,17 +214,13 @@ func (s *schedulerService) checkScheduledProjects(ctx context.Context) {
 		return
 	}
 
-	// 使用标准 Cron 解析器 (5位: 分 时 日 月 周)
-	// 如果需要支持秒级，可以使用 cron.NewParser(cron.Second | cron.Minute | ...)
-	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
 	now := time.Now()
-
 	for _, project := range projects {
 		if project.CronExpr == "" {
 			continue
 		}
 
-		schedule, err := parser.Parse(project.CronExpr)
+		schedule, err := ParseCronExpr(project.CronExpr)
 		if err != nil {
 			logger.LogError(err, "", 0, "", "service.scheduler.checkScheduledProjects", "INTERNAL", map[string]interface{}{
 				"project_id": project.ID,
@@ -193,47 +230,100 @@ func (s *schedulerService) checkScheduledProjects(ctx context.Context) {
 			continue
 		}
 
-		// 计算上次执行后的下一次执行时间
-		// 如果 LastExecTime 为 nil (从未执行过)，则假设上次执行时间为 CreatedAt
-		var lastTime time.Time
-		if project.LastExecTime != nil {
-			lastTime = *project.LastExecTime
-		} else {
-			lastTime = project.CreatedAt
+		runAt := scheduledRunAt(project, schedule)
+		// cron 表达式不再匹配任何时间
+		if runAt.IsZero() {
+			continue
 		}
-
-		// 防御性编程：如果 lastTime 是零值，设为很久以前
-		if lastTime.IsZero() {
-			lastTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		if runAt.After(now) {
+			// 历史数据没有 NextRunAt 时补写，便于前端展示
+			if project.NextRunAt == nil {
+				if err := s.projectRepo.UpdateProjectNextRunAt(ctx, project.ID, &runAt); err != nil {
+					logger.LogError(err, "", 0, "", "service.scheduler.checkScheduledProjects", "REPO", map[string]interface{}{
+						"project_id": project.ID,
+					})
+				}
+			}
+			continue
 		}
 
-		nextTime := schedule.Next(lastTime)
+		s.triggerScheduledProject(ctx, project, schedule, runAt, now)
+	}
+}
+
+// scheduledRunAt 计算项目本次应触发的时间
+// 优先使用持久化的 NextRunAt；历史数据为空时按上次执行时间(从未执行过则按创建时间)推算
+func scheduledRunAt(project *orcModel.Project, schedule cron.Schedule) time.Time {
+	if project.NextRunAt != nil {
+		return *project.NextRunAt
+	}
+
+	var lastTime time.Time
+	if project.LastExecTime != nil {
+		lastTime = *project.LastExecTime
+	} else {
+		lastTime = project.CreatedAt
+	}
+
+	// 防御性编程：如果 lastTime 是零值，设为很久以前
+	if lastTime.IsZero() {
+		lastTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return schedule.Next(lastTime)
+}
 
-		// 如果下一次执行时间 <= 当前时间，说明到了执行时间 (或者错过了执行时间)
-		// 并且 nextTime 不能是零值 (如果 cron 表达式不再匹配任何时间)
-		if !nextTime.IsZero() && (nextTime.Before(now) || nextTime.Equal(now)) {
-			logger.LogInfo("Triggering scheduled project", "", 0, "", "service.scheduler.checkScheduledProjects", "", map[string]interface{}{
+// triggerScheduledProject 触发到期的定时项目
+// 1. 按 项目+触发时间 获取分布式锁，未配置锁时仅依赖状态 CAS
+// 2. 状态 CAS 置为 running，同时以本次周期点为基准写入下一次执行时间 (错过的多个周期只补触发一次)
+func (s *schedulerService) triggerScheduledProject(ctx context.Context, project *orcModel.Project, schedule cron.Schedule, runAt, now time.Time) {
+	if s.scheduleLocker != nil {
+		locked, err := s.scheduleLocker.TryLock(ctx, scheduleLockKey(project.ID, runAt), scheduleLockTTL)
+		if err != nil {
+			// 锁服务不可用时跳过本轮，避免多实例重复触发
+			logger.LogError(err, "", 0, "", "service.scheduler.triggerScheduledProject", "REDIS", map[string]interface{}{
 				"project_id": project.ID,
-				"next_time":  nextTime,
-				"now":        now,
+				"run_at":     runAt,
 			})
-
-			// 触发项目执行
-			project.Status = "running"
-			project.LastExecTime = &now
-			if err := s.projectRepo.UpdateProject(ctx, project); err != nil {
-				logger.LogError(err, "", 0, "", "service.scheduler.checkScheduledProjects", "REPO", map[string]interface{}{
-					"project_id": project.ID,
-				})
-			}
+			return
+		}
+		if !locked {
+			return
 		}
 	}
+
+	var nextRunAt *time.Time
+	if next := NextRunAfter(schedule, runAt, now); !next.IsZero() {
+		nextRunAt = &next
+	}
+
+	triggered, err := s.projectRepo.MarkScheduledRun(ctx, project.ID, project.Status, now, nextRunAt)
+	if err != nil {
+		logger.LogError(err, "", 0, "", "service.scheduler.triggerScheduledProject", "REPO", map[string]interface{}{
+			"project_id": project.ID,
+		})
+		return
+	}
+	if !triggered {
+		// 状态已被其他实例或用户操作修改
+		return
+	}
+
+	logger.LogInfo("Triggering scheduled project", "", 0, "", "service.scheduler.triggerScheduledProject", "", map[string]interface{}{
+		"project_id":  project.ID,
+		"run_at":      runAt,
+		"next_run_at": nextRunAt,
+		"now":         now,
+	})
 }
 
+// cancelAckTimeout 等待 Agent 确认取消的最长时间，超过后直接标记为 canceled
+const cancelAckTimeout = 10 * time.Minute
+
 // checkTaskTimeouts 检查运行中任务是否超时
-// 1. 获取所有状态为 running 的任务
+// 1. 获取所有状态为 running/canceling 的任务
 // 2. 检查 StartedAt 与当前时间的差值是否超过 Timeout
 // 3. 如果超时，将任务标记为 failed，并记录错误信息
+// 4. canceling 任务从下达取消起超过 cancelAckTimeout 未确认 (Agent 失联/重启)，直接标记为 canceled
 func (s *schedulerService) checkTaskTimeouts(ctx context.Context) {
 	tasks, err := s.taskRepo.GetRunningTasks(ctx)
 	if err != nil {
@@ -244,6 +334,13 @@ func (s *schedulerService) checkTaskTimeouts(ctx context.Context) {
 	}
 
 	for _, task := range tasks {
+		if task.Status == orcModel.AgentTaskStatusCanceling {
+			if time.Since(task.UpdatedAt) > cancelAckTimeout {
+				s.expireTaskCancel(ctx, task)
+			}
+			continue
+		}
+
 		// 如果没有开始时间，可能是刚分配但未开始，或者数据异常
 		// 这里暂不处理，等待 Agent 更新状态
 		if task.StartedAt == nil {
@@ -264,34 +361,25 @@ func (s *schedulerService) checkTaskTimeouts(ctx context.Context) {
 	}
 }
 
-// handleTaskFailure 处理任务失败 (包含重试机制)
-func (s *schedulerService) handleTaskFailure(ctx context.Context, task *orcModel.AgentTask, errorMsg string) {
-	// 检查重试次数
-	if task.RetryCount < task.MaxRetries {
-		retryCount := task.RetryCount + 1
-		retryMsg := fmt.Sprintf("Retry %d/%d: %s", retryCount, task.MaxRetries, errorMsg)
-
-		logger.LogWarn("Task failed, retrying...", "", 0, "", "service.scheduler.handleTaskFailure", "", map[string]interface{}{
-			"task_id":     task.TaskID,
-			"retry_count": retryCount,
-			"max_retries": task.MaxRetries,
-			"reason":      errorMsg,
+// expireTaskCancel Agent 未确认取消，由 Master 结束任务
+func (s *schedulerService) expireTaskCancel(ctx context.Context, task *orcModel.AgentTask) {
+	errMsg := fmt.Sprintf("Cancellation not acknowledged by agent within %s", cancelAckTimeout)
+	if _, err := s.taskRepo.TransitionTaskStatus(ctx, task.TaskID, orcModel.AgentTaskStatusCanceling, orcModel.AgentTaskStatusCanceled, task.OutputResult, errMsg); err != nil {
+		logger.LogError(err, "", 0, "", "service.scheduler.expireTaskCancel", "REPO", map[string]interface{}{
+			"task_id":  task.TaskID,
+			"agent_id": task.AgentID,
 		})
-
-		if err := s.taskRepo.RetryTask(ctx, task.TaskID, retryCount, retryMsg); err != nil {
-			logger.LogError(err, "", 0, "", "service.scheduler.handleTaskFailure", "REPO", map[string]interface{}{
-				"task_id": task.TaskID,
-				"msg":     "failed to retry task",
-			})
-		}
-		return
 	}
+}
 
-	// 超过重试次数，标记为失败
-	logger.LogWarn("Task failed permanently", "", 0, "", "service.scheduler.handleTaskFailure", "", map[string]interface{}{
-		"task_id": task.TaskID,
-		"reason":  errorMsg,
-		"retries": task.RetryCount,
+// handleTaskFailure 处理任务失败
+// 仅标记为 failed，是否重试以及何时重试由 RescheduleFailedTasks 按退避策略统一决定
+func (s *schedulerService) handleTaskFailure(ctx context.Context, task *orcModel.AgentTask, errorMsg string) {
+	logger.LogWarn("Task failed", "", 0, "", "service.scheduler.handleTaskFailure", "", map[string]interface{}{
+		"task_id":     task.TaskID,
+		"reason":      errorMsg,
+		"retry_count": task.RetryCount,
+		"max_retries": task.MaxRetries,
 	})
 
 	if err := s.taskRepo.UpdateTaskResult(ctx, task.TaskID, "", errorMsg, "failed"); err != nil {
@@ -302,6 +390,89 @@ func (s *schedulerService) handleTaskFailure(ctx context.Context, task *orcModel
 	}
 }
 
+// RescheduleFailedTasks 按指数退避重新调度失败任务，返回本轮处理(重试或标记 dead)的任务数
+// 1. 一次查询取出所有已到期(next_retry_at 为空或不晚于当前时间)的 failed 任务
+// 2. 未超过 MaxRetries 的任务: retry_count+1，状态置为 pending，next_retry_at = now + 退避时间
+// 3. 已超过 MaxRetries 的任务: 标记为 dead (终态，项目随之进入 error)
+// 同一重试次数的任务退避时间相同，按 retry_count 分组批量更新；部分批次失败时返回首个错误
+func (s *schedulerService) RescheduleFailedTasks(ctx context.Context) (int, error) {
+	now := time.Now()
+	tasks, err := s.taskRepo.GetDueFailedTasks(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("get due failed tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		return 0, nil
+	}
+
+	var deadIDs []string
+	retryGroups := make(map[int][]string) // 新的 retry_count -> task_ids
+	for _, task := range tasks {
+		if task.RetryCount >= task.MaxRetries {
+			deadIDs = append(deadIDs, task.TaskID)
+			continue
+		}
+		retryGroups[task.RetryCount+1] = append(retryGroups[task.RetryCount+1], task.TaskID)
+	}
+
+	processed := 0
+	var firstErr error
+	for retryCount, taskIDs := range retryGroups {
+		// 第 n 次重试的等待时间为 base * 2^(n-1)
+		nextRetryAt := now.Add(RetryBackoff(s.retryBaseInterval, s.retryMaxInterval, retryCount-1))
+		if err := s.taskRepo.ScheduleRetry(ctx, taskIDs, retryCount, nextRetryAt); err != nil {
+			logger.LogError(err, "", 0, "", "service.scheduler.RescheduleFailedTasks", "REPO", map[string]interface{}{
+				"task_ids":    taskIDs,
+				"retry_count": retryCount,
+				"msg":         "failed to schedule task retry",
+			})
+			if firstErr == nil {
+				firstErr = fmt.Errorf("schedule retry: %w", err)
+			}
+			continue
+		}
+		processed += len(taskIDs)
+		logger.LogInfo("Failed tasks scheduled for retry", "", 0, "", "service.scheduler.RescheduleFailedTasks", "", map[string]interface{}{
+			"task_ids":      taskIDs,
+			"retry_count":   retryCount,
+			"next_retry_at": nextRetryAt,
+		})
+	}
+
+	if len(deadIDs) > 0 {
+		if err := s.taskRepo.MarkTasksDead(ctx, deadIDs); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("mark tasks dead: %w", err)
+			}
+			return processed, firstErr
+		}
+		processed += len(deadIDs)
+		logger.LogWarn("Tasks exhausted retries, marked dead", "", 0, "", "service.scheduler.RescheduleFailedTasks", "", map[string]interface{}{
+			"task_ids": deadIDs,
+		})
+	}
+	return processed, firstErr
+}
+
+// RetryBackoff 计算第 attempt 次(从0开始)重试前的等待时间
+// 等待时间 = base * 2^attempt，不超过 maxDelay
+func RetryBackoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	delay := base
+	for i := 0; i < attempt; i++ {
+		if delay >= maxDelay/2 {
+			return maxDelay
+		}
+		delay *= 2
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
 // ProcessProject 处理单个项目的调度逻辑 (Public for testing and manual trigger)
 func (s *schedulerService) ProcessProject(ctx context.Context, project *orcModel.Project) {
 	loggerFields := map[string]interface{}{
@@ -325,11 +496,14 @@ func (s *schedulerService) ProcessProject(ctx context.Context, project *orcModel
 	}
 
 	// 3. 判断状态
-	// Case B: 上一个任务失败，暂停项目
-	if lastTask != nil && lastTask.Status == "failed" {
-		logger.LogInfo("Project paused due to task failure", "", 0, "", "service.scheduler.processProject", "", loggerFields)
-		project.Status = "error" // 或者 paused
-		s.projectRepo.UpdateProject(ctx, project)
+	// Case B: 上一个任务重试耗尽，暂停项目
+	// failed 且还有重试次数的任务会在下一轮被重新调度，这里不处理
+	// 上一轮遗留的失败任务不影响新一轮执行
+	if lastTask != nil && lastTask.RunID == project.RunSeq &&
+		(lastTask.Status == "dead" || (lastTask.Status == "failed" && lastTask.RetryCount >= lastTask.MaxRetries)) {
+		if s.transitionProjectStatus(ctx, project, orcModel.ProjectStatusError, loggerFields) {
+			logger.LogInfo("Project paused due to task failure", "", 0, "", "service.scheduler.processProject", "", loggerFields)
+		}
 		return
 	}
 
@@ -349,10 +523,20 @@ func (s *schedulerService) ProcessProject(ctx context.Context, project *orcModel
 		}
 
 		// 确实没有可执行的 Stage，且没有正在运行的任务，则认为项目完成
+		// 仅状态流转成功的一方发送完成事件，并发的调度/取消不会重复通知
+		if !s.transitionProjectStatus(ctx, project, orcModel.ProjectStatusFinished, loggerFields) {
+			return
+		}
 		logger.LogInfo("Project finished", "", 0, "", "service.scheduler.processProject", "", loggerFields)
-		project.Status = "finished"
-		project.LastExecTime = nil // Optional: update finish time if needed
-		s.projectRepo.UpdateProject(ctx, project)
+		if s.notifier != nil {
+			s.notifier.Notify(orcModel.WebhookEventProjectCompleted, &webhook.ProjectCompletedData{
+				ProjectID:  project.ID,
+				Name:       project.Name,
+				RunID:      project.RunSeq,
+				Status:     project.Status,
+				FinishedAt: time.Now(),
+			})
+		}
 		return
 	}
 
@@ -362,6 +546,25 @@ func (s *schedulerService) ProcessProject(ctx context.Context, project *orcModel
 	}
 }
 
+// transitionProjectStatus 将运行中的项目条件更新为 target 状态 (CAS)
+// 项目已被其他流程(取消、暂停、另一个调度实例)修改时放弃本次流转，返回 false
+func (s *schedulerService) transitionProjectStatus(ctx context.Context, project *orcModel.Project, target string, loggerFields map[string]interface{}) bool {
+	updated, err := s.projectRepo.UpdateProjectStatus(ctx, uint64(project.ID), orcModel.ProjectStatusRunning, target)
+	if err != nil {
+		logger.LogError(err, "", 0, "", "service.scheduler.transitionProjectStatus", "REPO", loggerFields)
+		return false
+	}
+	if !updated {
+		logger.LogInfo("Project status changed concurrently, skip transition", "", 0, "", "service.scheduler.transitionProjectStatus", "", map[string]interface{}{
+			"project_id": project.ID,
+			"target":     target,
+		})
+		return false
+	}
+	project.Status = target
+	return true
+}
+
 // generateTasksForStage 为单个 Stage 生成任务
 func (s *schedulerService) generateTasksForStage(ctx context.Context, project *orcModel.Project, nextStage *orcModel.ScanStage) {
 	loggerFields := map[string]interface{}{
@@ -430,6 +633,7 @@ func (s *schedulerService) generateTasksForStage(ctx context.Context, project *o
 
 	// 保存任务到数据库
 	for _, task := range newTasks {
+		task.RunID = project.RunSeq // 标记所属执行轮次，结果按轮次归档
 		// 3. 策略检查 (Policy Enforcer)
 		if err := s.policyEnforcer.Enforce(ctx, task); err != nil {
 			logger.LogWarn("Task blocked by policy", "", 0, "", "service.scheduler.processProject", "", map[string]interface{}{
@@ -438,6 +642,7 @@ func (s *schedulerService) generateTasksForStage(ctx context.Context, project *o
 			})
 			task.Status = "failed"
 			task.ErrorMsg = "Policy violation: " + err.Error()
+			task.MaxRetries = 0 // 策略拦截属于确定性失败，不参与重试
 		}
 
 		if err := s.taskRepo.CreateTask(ctx, task); err != nil {
@@ -489,6 +694,10 @@ func (s *schedulerService) findNextStages(ctx context.Context, project *orcModel
 	// 简单起见，我们假设 GetTasksByProjectID 返回所有任务，我们只关心最新的
 	stageStatus := make(map[uint64]string)
 	for _, task := range tasks {
+		// 只看本轮执行的任务，历史轮次的任务不影响本轮的 DAG 判定
+		if task.RunID != project.RunSeq {
+			continue
+		}
 		// 简单的覆盖策略：后遍历到的覆盖前面的 (假设 DB 返回顺序大致符合时间)
 		// 更严谨的做法是比较 TaskID 或 CreateTime
 		stageStatus[task.StageID] = task.Status
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	orcModel "neomaster/internal/model/orchestrator"
+	orcRepo "neomaster/internal/repo/mysql/orchestrator"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	base := 10 * time.Second
+	maxDelay := 600 * time.Second
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{-1, 10 * time.Second},
+		{0, 10 * time.Second},
+		{1, 20 * time.Second},
+		{2, 40 * time.Second},
+		{5, 320 * time.Second},
+		{6, 600 * time.Second}, // 640s 超过上限
+		{100, 600 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := RetryBackoff(base, maxDelay, tt.attempt); got != tt.want {
+			t.Errorf("RetryBackoff(%v, %v, %d) = %v, want %v", base, maxDelay, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRescheduleFailedTasks_OnlyDueTasks(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&orcModel.AgentTask{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	for _, task := range []*orcModel.AgentTask{
+		{TaskID: "fresh", Status: "failed", MaxRetries: 3},
+		{TaskID: "due", Status: "failed", RetryCount: 1, MaxRetries: 3, NextRetryAt: &past},
+		{TaskID: "backoff", Status: "failed", RetryCount: 1, MaxRetries: 3, NextRetryAt: &future},
+		{TaskID: "exhausted", Status: "failed", RetryCount: 3, MaxRetries: 3},
+		{TaskID: "running", Status: "running", MaxRetries: 3},
+	} {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("seed task: %v", err)
+		}
+	}
+	s := &schedulerService{
+		taskRepo:          orcRepo.NewTaskRepository(db),
+		retryBaseInterval: 10 * time.Second,
+		retryMaxInterval:  time.Minute,
+	}
+
+	n, err := s.RescheduleFailedTasks(context.Background())
+	if err != nil {
+		t.Fatalf("RescheduleFailedTasks() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("RescheduleFailedTasks() = %d, want 3", n)
+	}
+	for id, want := range map[string]struct {
+		status     string
+		retryCount int
+	}{
+		"fresh":     {"pending", 1},
+		"due":       {"pending", 2},
+		"backoff":   {"failed", 1}, // 退避未到期，本轮不处理
+		"exhausted": {"dead", 3},
+		"running":   {"running", 0},
+	} {
+		var task orcModel.AgentTask
+		db.Where("task_id = ?", id).First(&task)
+		if task.Status != want.status || task.RetryCount != want.retryCount {
+			t.Errorf("task %s = (%s, retry %d), want (%s, retry %d)", id, task.Status, task.RetryCount, want.status, want.retryCount)
+		}
+	}
+
+	// 再次执行时已无到期任务
+	if n, err = s.RescheduleFailedTasks(context.Background()); err != nil || n != 0 {
+		t.Errorf("second RescheduleFailedTasks() = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestProcessProject_StatusTransitionIsConditional(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&orcModel.Project{}, &orcModel.AgentTask{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	s := &schedulerService{
+		projectRepo: orcRepo.NewProjectRepository(db),
+		taskRepo:    orcRepo.NewTaskRepository(db),
+	}
+
+	for _, tt := range []struct {
+		name     string
+		dbStatus string
+		want     string
+	}{
+		{"running project paused on dead task", orcModel.ProjectStatusRunning, orcModel.ProjectStatusError},
+		{"concurrently canceled project kept", orcModel.ProjectStatusCanceled, orcModel.ProjectStatusCanceled},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &orcModel.Project{Name: tt.name, Status: tt.dbStatus, RunSeq: 1}
+			if err := db.Create(project).Error; err != nil {
+				t.Fatalf("seed project: %v", err)
+			}
+			if err := db.Create(&orcModel.AgentTask{TaskID: tt.name, ProjectID: uint64(project.ID), RunID: 1, Status: "dead"}).Error; err != nil {
+				t.Fatalf("seed task: %v", err)
+			}
+
+			// 调度器持有的是取消前读到的快照
+			project.Status = orcModel.ProjectStatusRunning
+			s.ProcessProject(context.Background(), project)
+
+			var got orcModel.Project
+			db.First(&got, project.ID)
+			if got.Status != tt.want {
+				t.Errorf("stored status = %s, want %s", got.Status, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,6 @@
 // 任务分配服务
-// 任务状态：pending(待处理)、running(运行中)、completed(已完成)、failed(失败)、cancelled(取消)
+// 任务状态：pending(待处理)、assigned(已分配)、running(运行中)、completed(已完成)、failed(失败，等待退避重试)、dead(重试耗尽)、
+// canceling(取消中，等待 Agent 确认)、canceled(已取消)
 package task_dispatcher
 
 import (
@@ -8,11 +9,52 @@ import (
 	"time"
 
 	agentModel "neomaster/internal/model/agent"
+	orchestratorModel "neomaster/internal/model/orchestrator"
 	"neomaster/internal/pkg/logger"
 	agentRepository "neomaster/internal/repo/mysql/agent"
 	orchestratorRepository "neomaster/internal/repo/mysql/orchestrator"
 )
 
+// agentTaskStatusTransitions 任务状态机: 当前状态 -> 允许流转的目标状态集合
+// 取消分两步: 已下发的任务先进入 canceling，Agent 中止执行后上报 canceled；
+// Agent 收到取消前任务已完成的，以实际的 completed 为准；canceling 期间上报 failed 的记为 canceled，
+// 不进入 failed，避免被退避重试重新置为 pending 再次下发 (见 UpdateTaskStatus)
+var agentTaskStatusTransitions = map[string][]string{
+	orchestratorModel.AgentTaskStatusPending:   {orchestratorModel.AgentTaskStatusAssigned, orchestratorModel.AgentTaskStatusRunning, orchestratorModel.AgentTaskStatusCanceled},
+	orchestratorModel.AgentTaskStatusAssigned:  {orchestratorModel.AgentTaskStatusRunning, orchestratorModel.AgentTaskStatusFailed, orchestratorModel.AgentTaskStatusCanceling},
+	orchestratorModel.AgentTaskStatusRunning:   {orchestratorModel.AgentTaskStatusCompleted, orchestratorModel.AgentTaskStatusFailed, orchestratorModel.AgentTaskStatusCanceling},
+	orchestratorModel.AgentTaskStatusFailed:    {orchestratorModel.AgentTaskStatusPending, orchestratorModel.AgentTaskStatusDead, orchestratorModel.AgentTaskStatusCanceled},
+	orchestratorModel.AgentTaskStatusCanceling: {orchestratorModel.AgentTaskStatusCanceled, orchestratorModel.AgentTaskStatusCompleted},
+	orchestratorModel.AgentTaskStatusCompleted: {},
+	orchestratorModel.AgentTaskStatusDead:      {},
+	orchestratorModel.AgentTaskStatusCanceled:  {},
+}
+
+// agentReportableStatuses Agent 可上报的任务状态
+var agentReportableStatuses = map[string]bool{
+	orchestratorModel.AgentTaskStatusRunning:   true,
+	orchestratorModel.AgentTaskStatusCompleted: true,
+	orchestratorModel.AgentTaskStatusFailed:    true,
+	orchestratorModel.AgentTaskStatusCanceled:  true,
+}
+
+// ValidateAgentTaskStatusTransition 校验任务状态流转是否合法
+func ValidateAgentTaskStatusTransition(from, to string) error {
+	allowed, ok := agentTaskStatusTransitions[from]
+	if !ok {
+		return fmt.Errorf("unknown task status: %q", from)
+	}
+	if _, known := agentTaskStatusTransitions[to]; !known {
+		return fmt.Errorf("unknown target task status: %q", to)
+	}
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal task status transition: %s -> %s (allowed: %v)", from, to, allowed)
+}
+
 // AgentTaskService Agent任务服务接口
 // 专门负责Agent的任务相关功能，遵循单一职责原则
 type AgentTaskService interface {
@@ -20,7 +62,8 @@ type AgentTaskService interface {
 	AssignTask(req *agentModel.AgentTaskAssignRequest) (*agentModel.AgentTaskAssignmentResponse, error)
 	FetchTasks(ctx context.Context, agentID string) ([]*agentModel.AgentTaskAssignmentResponse, error)
 	UpdateTaskStatus(ctx context.Context, taskID string, status string, result string, errorMsg string) error // 更新任务状态
-	CancelTask(ctx context.Context, taskID string) error                                                      // 取消任务
+	CancelTask(ctx context.Context, taskID string) error                                                      // 取消任务 (已下发的任务进入 canceling，等待 Agent 确认)
+	GetConcurrencyStatus(ctx context.Context) (*orchestratorModel.TaskConcurrencyStatus, error)               // 任务并发占用情况
 }
 
 // agentTaskService Agent任务服务实现
@@ -129,79 +172,100 @@ func (s *agentTaskService) FetchTasks(ctx context.Context, agentID string) ([]*a
 	return response, nil
 }
 
-// UpdateTaskStatus 更新任务状态服务
-func (s *agentTaskService) UpdateTaskStatus(ctx context.Context, taskID string, status string, result string, errorMsg string) error {
-	// 1. 验证任务是否存在
-	task, err := s.taskRepo.GetTaskByID(ctx, taskID)
-	if err != nil {
-		return err
-	}
-	if task == nil {
-		return fmt.Errorf("task not found: %s", taskID)
+// UpdateTaskStatus 更新任务状态服务 (Agent 上报)
+// 1. Agent 只能上报 running/completed/failed/canceled
+// 2. 按状态机校验流转，使用条件更新防止与取消操作并发覆盖
+// 3. 条件更新失败说明状态刚被修改 (如任务被取消)，按最新状态重新校验一次
+// 4. canceling 任务上报 failed 时记为 canceled (保留错误信息)：任务已被取消，不应再进入退避重试
+func (s *agentTaskService) UpdateTaskStatus(ctx context.Context, taskID string, reported string, result string, errorMsg string) error {
+	if !agentReportableStatuses[reported] {
+		return fmt.Errorf("status %q cannot be reported by agent", reported)
 	}
 
-	// 2. 状态机检查 (Simple State Machine)
-	// Valid transitions:
-	// pending -> assigned (handled by ClaimTask, but here checking current status)
-	// assigned -> running
-	// running -> completed
-	// running -> failed
-	// * -> cancelled
-
-	currentStatus := task.Status
-
-	if status == "cancelled" {
-		// Always allow cancellation unless already completed
-		if currentStatus == "completed" {
-			return fmt.Errorf("cannot cancel completed task")
+	for attempt := 0; attempt < 2; attempt++ {
+		task, err := s.taskRepo.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return err
 		}
-	} else {
-		switch currentStatus {
-		case "assigned":
-			if status != "running" && status != "failed" {
-				return fmt.Errorf("invalid transition from assigned to %s", status)
-			}
-		case "running":
-			if status != "completed" && status != "failed" {
-				return fmt.Errorf("invalid transition from running to %s", status)
-			}
-		case "completed", "failed":
-			return fmt.Errorf("task already in terminal state: %s", currentStatus)
-		case "pending":
-			return fmt.Errorf("task must be claimed (assigned) before updates")
-		default:
-			// unknown state
+		if task == nil {
+			return fmt.Errorf("task not found: %s", taskID)
 		}
-	}
 
-	// 3. 更新状态和结果
-	if status == "completed" {
-		return s.taskRepo.UpdateTaskResult(ctx, taskID, result, errorMsg, status)
-	}
+		status := reported
+		if task.Status == orchestratorModel.AgentTaskStatusCanceling && status == orchestratorModel.AgentTaskStatusFailed {
+			status = orchestratorModel.AgentTaskStatusCanceled
+		}
 
-	if status == "failed" {
-		// 检查重试逻辑
-		// 如果未超过最大重试次数，重试任务
-		if task.RetryCount < task.MaxRetries {
-			retryCount := task.RetryCount + 1
-			retryMsg := fmt.Sprintf("Retry %d/%d: %s", retryCount, task.MaxRetries, errorMsg)
+		if err = ValidateAgentTaskStatusTransition(task.Status, status); err != nil {
+			return err
+		}
 
-			logger.LogWarn("Task failed, retrying...", "", 0, "", "service.agent.task.UpdateTaskStatus", "", map[string]interface{}{
+		updated, err := s.taskRepo.TransitionTaskStatus(ctx, taskID, task.Status, status, result, errorMsg)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			continue
+		}
+
+		if status == orchestratorModel.AgentTaskStatusFailed {
+			// 仅记录失败，重试由调度器按指数退避统一处理 (见 scheduler.RescheduleFailedTasks)
+			logger.LogWarn("Task failed, waiting for retry scheduling", "", 0, "", "service.agent.task.UpdateTaskStatus", "", map[string]interface{}{
 				"task_id":     taskID,
-				"retry_count": retryCount,
+				"retry_count": task.RetryCount,
+				"max_retries": task.MaxRetries,
 				"reason":      errorMsg,
 			})
-
-			return s.taskRepo.RetryTask(ctx, taskID, retryCount, retryMsg)
 		}
-		// 超过重试次数，标记为失败
-		return s.taskRepo.UpdateTaskResult(ctx, taskID, result, errorMsg, status)
+		if task.Status == orchestratorModel.AgentTaskStatusCanceling && reported != orchestratorModel.AgentTaskStatusCanceled {
+			// 任务在 Agent 收到取消前已结束，取消不再生效
+			logger.LogInfo("Task finished before cancellation took effect", "", 0, "", "service.agent.task.UpdateTaskStatus", "", map[string]interface{}{
+				"task_id":  taskID,
+				"reported": reported,
+				"status":   status,
+			})
+		}
+		return nil
 	}
-
-	return s.taskRepo.UpdateTaskStatus(ctx, taskID, status)
+	return fmt.Errorf("task %s status changed concurrently, please retry", taskID)
 }
 
 // CancelTask 取消任务服务
+// pending 任务尚未下发，直接取消；assigned/running 任务置为 canceling，
+// 由 Agent 拉取任务时收到取消信号，中止执行后上报 canceled；
+// 已结束或正在取消的任务不做处理
 func (s *agentTaskService) CancelTask(ctx context.Context, taskID string) error {
-	return s.taskRepo.UpdateTaskStatus(ctx, taskID, "cancelled")
+	for attempt := 0; attempt < 2; attempt++ {
+		task, err := s.taskRepo.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return fmt.Errorf("task not found: %s", taskID)
+		}
+
+		var target string
+		switch task.Status {
+		case orchestratorModel.AgentTaskStatusPending, orchestratorModel.AgentTaskStatusFailed:
+			target = orchestratorModel.AgentTaskStatusCanceled
+		case orchestratorModel.AgentTaskStatusAssigned, orchestratorModel.AgentTaskStatusRunning:
+			target = orchestratorModel.AgentTaskStatusCanceling
+		default:
+			return nil
+		}
+
+		updated, err := s.taskRepo.TransitionTaskStatus(ctx, taskID, task.Status, target, task.OutputResult, task.ErrorMsg)
+		if err != nil {
+			return err
+		}
+		if updated {
+			return nil
+		}
+	}
+	return fmt.Errorf("task %s status changed concurrently, please retry", taskID)
+}
+
+// GetConcurrencyStatus 获取任务并发占用情况服务
+func (s *agentTaskService) GetConcurrencyStatus(ctx context.Context) (*orchestratorModel.TaskConcurrencyStatus, error) {
+	return s.dispatcher.ConcurrencyStatus(ctx)
 }
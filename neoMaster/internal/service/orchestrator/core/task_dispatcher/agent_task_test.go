@@ -0,0 +1,160 @@
+package task_dispatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"neomaster/internal/model/orchestrator"
+	orcRepo "neomaster/internal/repo/mysql/orchestrator"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestValidateAgentTaskStatusTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  bool
+	}{
+		{"pending", "running", false},
+		{"pending", "canceled", false},
+		{"pending", "canceling", true},
+		{"assigned", "canceling", false},
+		{"running", "canceling", false},
+		{"running", "canceled", true},
+		{"canceling", "canceled", false},
+		{"canceling", "completed", false},
+		{"canceling", "failed", true},
+		{"canceling", "running", true},
+		{"failed", "canceled", false},
+		{"completed", "canceled", true},
+		{"canceled", "running", true},
+		{"dead", "pending", true},
+		{"cancelled", "canceled", true},
+		{"running", "unknown", true},
+	}
+	for _, tt := range tests {
+		err := ValidateAgentTaskStatusTransition(tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAgentTaskStatusTransition(%q, %q) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+}
+
+func newCancelTestService(t *testing.T) (orcRepo.TaskRepository, AgentTaskService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err = db.AutoMigrate(&orchestrator.AgentTask{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, task := range []*orchestrator.AgentTask{
+		{TaskID: "pending", ProjectID: 1, Status: "pending"},
+		{TaskID: "running", ProjectID: 1, AgentID: "agent-1", Status: "running"},
+		{TaskID: "racing", ProjectID: 1, AgentID: "agent-1", Status: "running"},
+		{TaskID: "failing", ProjectID: 1, AgentID: "agent-1", Status: "running", MaxRetries: 3},
+		{TaskID: "completed", ProjectID: 1, AgentID: "agent-1", Status: "completed", OutputResult: `{"ok":true}`},
+		{TaskID: "retrying", ProjectID: 2, Status: "failed"},
+		{TaskID: "assigned", ProjectID: 2, AgentID: "agent-1", Status: "assigned"},
+	} {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+	repo := orcRepo.NewTaskRepository(db)
+	return repo, NewAgentTaskService(nil, repo, nil)
+}
+
+func assertTaskStatus(t *testing.T, repo orcRepo.TaskRepository, taskID, want string) {
+	t.Helper()
+	task, err := repo.GetTaskByID(context.Background(), taskID)
+	if err != nil || task == nil {
+		t.Fatalf("get task %s: %v", taskID, err)
+	}
+	if task.Status != want {
+		t.Errorf("task %s status = %q, want %q", taskID, task.Status, want)
+	}
+}
+
+func TestAgentTaskService_CancelTask(t *testing.T) {
+	ctx := context.Background()
+	repo, svc := newCancelTestService(t)
+
+	for _, id := range []string{"pending", "running", "racing", "failing", "completed"} {
+		if err := svc.CancelTask(ctx, id); err != nil {
+			t.Fatalf("CancelTask(%s): %v", id, err)
+		}
+	}
+	assertTaskStatus(t, repo, "pending", "canceled")
+	assertTaskStatus(t, repo, "running", "canceling")
+	assertTaskStatus(t, repo, "completed", "completed")
+
+	// 重复取消不报错
+	if err := svc.CancelTask(ctx, "running"); err != nil {
+		t.Errorf("repeat CancelTask: %v", err)
+	}
+
+	// canceling 任务下发给 Agent
+	tasks, err := repo.GetTasksByAgentID(ctx, "agent-1")
+	if err != nil {
+		t.Fatalf("GetTasksByAgentID: %v", err)
+	}
+	if len(tasks) != 4 {
+		t.Errorf("expected 4 unfinished tasks for agent, got %d", len(tasks))
+	}
+
+	// Agent 确认取消
+	if err := svc.UpdateTaskStatus(ctx, "running", "canceled", "", ""); err != nil {
+		t.Fatalf("ack cancel: %v", err)
+	}
+	assertTaskStatus(t, repo, "running", "canceled")
+
+	// 取消到达前任务已完成: 以实际结果为准
+	if err := svc.UpdateTaskStatus(ctx, "racing", "completed", `{"ok":true}`, ""); err != nil {
+		t.Fatalf("report completed while canceling: %v", err)
+	}
+	assertTaskStatus(t, repo, "racing", "completed")
+
+	// 取消中的任务上报失败: 记为 canceled，不进入退避重试，也不会再次下发
+	if err := svc.UpdateTaskStatus(ctx, "failing", "failed", "", "killed"); err != nil {
+		t.Fatalf("report failed while canceling: %v", err)
+	}
+	assertTaskStatus(t, repo, "failing", "canceled")
+	due, err := repo.GetDueFailedTasks(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("GetDueFailedTasks: %v", err)
+	}
+	for _, task := range due {
+		if task.TaskID == "failing" {
+			t.Error("canceled task picked up for retry")
+		}
+	}
+
+	// 已结束的任务不接受取消确认
+	if err := svc.UpdateTaskStatus(ctx, "completed", "canceled", "", ""); err == nil {
+		t.Error("expected error acknowledging cancel of completed task")
+	}
+	// Agent 不能自行将任务置为 canceling
+	if err := svc.UpdateTaskStatus(ctx, "assigned", "canceling", "", ""); err == nil {
+		t.Error("expected error reporting canceling from agent")
+	}
+}
+
+func TestTaskRepository_CancelProjectTasks(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newCancelTestService(t)
+
+	canceled, canceling, err := repo.CancelProjectTasks(ctx, 2)
+	if err != nil {
+		t.Fatalf("CancelProjectTasks: %v", err)
+	}
+	if canceled != 1 || canceling != 1 {
+		t.Errorf("canceled=%d canceling=%d, want 1/1", canceled, canceling)
+	}
+	assertTaskStatus(t, repo, "retrying", "canceled")
+	assertTaskStatus(t, repo, "assigned", "canceling")
+	assertTaskStatus(t, repo, "running", "running")
+}
@@ -4,6 +4,10 @@ package task_dispatcher
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"time"
+
 	"neomaster/internal/config"
 	"neomaster/internal/model/orchestrator"
 	agentRepo "neomaster/internal/repo/mysql/orchestrator"
@@ -22,27 +26,45 @@ type TaskDispatcher interface {
 	// 检查 Agent 当前负载，从队列中获取待执行任务并分配
 	// 结合 Resource Allocator 和 Policy Enforcer 进行决策
 	Dispatch(ctx context.Context, agent *agentModel.Agent, currentLoad int) ([]*orchestrator.AgentTask, error)
+
+	// ConcurrencyStatus 获取全局与各项目的任务并发占用情况
+	ConcurrencyStatus(ctx context.Context) (*orchestrator.TaskConcurrencyStatus, error)
 }
 
+// defaultPreferWindow 新任务等待负载最低 Agent 领取的默认时长
+const defaultPreferWindow = 30 * time.Second
+
 type taskDispatcher struct {
-	cfg       *config.Config // 配置注入
-	taskRepo  agentRepo.TaskRepository
-	policy    policy.PolicyEnforcer       // 策略执行器注入
-	allocator allocator.ResourceAllocator // 资源分配器注入
+	cfg          *config.Config // 配置注入
+	taskRepo     agentRepo.TaskRepository
+	policy       policy.PolicyEnforcer       // 策略执行器注入
+	allocator    allocator.ResourceAllocator // 资源分配器注入
+	selector     allocator.AgentSelector     // 负载优选，为空时先到先得
+	preferWindow time.Duration               // 任务等待最优 Agent 的时长，超过后任何可执行的 Agent 都可领取
+	now          func() time.Time
 }
 
 // NewTaskDispatcher 创建任务分发器实例
+// selector 为空时不做负载优选，轮询到的 Agent 按先到先得领取任务
 func NewTaskDispatcher(
 	cfg *config.Config,
 	taskRepo agentRepo.TaskRepository,
 	policy policy.PolicyEnforcer,
 	allocator allocator.ResourceAllocator,
+	selector allocator.AgentSelector,
 ) TaskDispatcher {
+	preferWindow := defaultPreferWindow
+	if cfg != nil && cfg.App.Master.Dispatch.PreferWindow > 0 {
+		preferWindow = time.Duration(cfg.App.Master.Dispatch.PreferWindow) * time.Second
+	}
 	return &taskDispatcher{
-		cfg:       cfg,
-		taskRepo:  taskRepo,
-		policy:    policy,
-		allocator: allocator,
+		cfg:          cfg,
+		taskRepo:     taskRepo,
+		policy:       policy,
+		allocator:    allocator,
+		selector:     selector,
+		preferWindow: preferWindow,
+		now:          time.Now,
 	}
 }
 
@@ -81,6 +103,9 @@ func (d *taskDispatcher) Dispatch(ctx context.Context, agent *agentModel.Agent,
 
 	var assignedTasks []*orchestrator.AgentTask
 	assignedCount := 0
+	limits := d.concurrencyLimits()
+	saturatedProjects := make(map[uint64]bool) // 本轮已达到项目并发上限的项目，跳过其余任务
+	bestAgents := make(map[string]string)      // 本轮已查询的 工具 -> 负载最低的 Agent
 
 	// 2. 遍历任务进行分配
 	for _, task := range pendingTasks {
@@ -88,6 +113,10 @@ func (d *taskDispatcher) Dispatch(ctx context.Context, agent *agentModel.Agent,
 			break
 		}
 
+		if saturatedProjects[task.ProjectID] {
+			continue
+		}
+
 		// 2.1 Resource Allocator: 资源调度检查
 		// 检查 Agent 是否有能力执行该任务 (Match Capability & Tags)
 		if !d.allocator.CanExecute(ctx, agent, task) {
@@ -99,18 +128,35 @@ func (d *taskDispatcher) Dispatch(ctx context.Context, agent *agentModel.Agent,
 		// 最后一道防线：检查任务是否合规 (Whitelist, Scope)
 		if err := d.policy.Enforce(ctx, task); err != nil {
 			// 策略违规！
-			logger.LogInfo("Task policy violation, marking as failed", "", 0, "", "service.orchestrator.dispatcher.Dispatch", "", map[string]interface{}{
+			logger.LogInfo("Task policy violation, marking as dead", "", 0, "", "service.orchestrator.dispatcher.Dispatch", "", map[string]interface{}{
 				"task_id": task.TaskID,
 				"reason":  err.Error(),
 			})
-			// 标记任务失败，避免反复调度
-			d.taskRepo.UpdateTaskResult(ctx, task.TaskID, "", "Policy Violation: "+err.Error(), "failed")
+			// 策略违规属于确定性失败，直接标记为 dead，避免进入退避重试后反复调度
+			d.taskRepo.UpdateTaskResult(ctx, task.TaskID, "", "Policy Violation: "+err.Error(), "dead")
+			continue
+		}
+
+		// 2.3 负载优选: 新任务优先留给负载最低的 Agent，等待超过 preferWindow 后不再挑选
+		if d.deferToBetterAgent(ctx, agent, task, bestAgents) {
 			continue
 		}
 
-		// 2.3 尝试领取任务 (CAS / Transaction)
-		// ClaimTask 应该是原子操作 (UPDATE ... WHERE status='pending')
-		if err := d.taskRepo.ClaimTask(ctx, task.TaskID, agent.AgentID); err != nil {
+		// 2.4 尝试领取任务 (CAS / Transaction)
+		// 在全局/项目并发上限内认领 (UPDATE ... WHERE status='pending')，达到上限的任务保持 pending 排队
+		err := d.taskRepo.ClaimTaskWithinLimits(ctx, task, agent.AgentID, limits)
+		if errors.Is(err, agentRepo.ErrGlobalConcurrencyLimit) {
+			logger.LogInfo("global task concurrency limit reached, remaining tasks stay pending", "", 0, "", "service.orchestrator.dispatcher.Dispatch", "", map[string]interface{}{
+				"agent_id":     agent.AgentID,
+				"global_limit": limits.Global,
+			})
+			break
+		}
+		if errors.Is(err, agentRepo.ErrProjectConcurrencyLimit) {
+			saturatedProjects[task.ProjectID] = true
+			continue
+		}
+		if err != nil {
 			// 领取失败（可能被其他 Agent 抢占），记录日志但继续尝试下一个
 			logger.LogInfo("failed to claim task (race condition?)", "", 0, "", "service.orchestrator.dispatcher.Dispatch", "", map[string]interface{}{
 				"task_id":  task.TaskID,
@@ -134,3 +180,84 @@ func (d *taskDispatcher) Dispatch(ctx context.Context, agent *agentModel.Agent,
 
 	return assignedTasks, nil
 }
+
+// deferToBetterAgent 任务是否应留给负载更低的 Agent
+// Pull 模式下只能在轮询时决定是否领取: 任务进入待分发状态未满 preferWindow 且存在负载更低的可执行 Agent 时跳过；
+// 超过等待时长后任何可执行的 Agent 都可领取，避免最优 Agent 未轮询时任务饿死。
+// 有标签要求的任务由 Allocator 按标签限定候选范围，不参与负载优选；查询失败时不阻塞分发
+func (d *taskDispatcher) deferToBetterAgent(ctx context.Context, agent *agentModel.Agent, task *orchestrator.AgentTask, bestAgents map[string]string) bool {
+	if d.selector == nil || (task.RequiredTags != "" && task.RequiredTags != "[]") {
+		return false
+	}
+	readyAt := task.CreatedAt
+	if task.NextRetryAt != nil && task.NextRetryAt.After(readyAt) {
+		readyAt = *task.NextRetryAt
+	}
+	if d.now().Sub(readyAt) >= d.preferWindow {
+		return false
+	}
+
+	bestID, ok := bestAgents[task.ToolName]
+	if !ok {
+		best, err := d.selector.SelectBestAgent(ctx, []string{task.ToolName})
+		if err != nil {
+			if !errors.Is(err, allocator.ErrNoEligibleAgent) {
+				logger.LogWarn("failed to select best agent, dispatching to polling agent", "", 0, "", "service.orchestrator.dispatcher.deferToBetterAgent", "", map[string]interface{}{
+					"task_id":   task.TaskID,
+					"tool_name": task.ToolName,
+					"error":     err.Error(),
+				})
+			}
+			bestAgents[task.ToolName] = ""
+			return false
+		}
+		bestID = best.AgentID
+		bestAgents[task.ToolName] = bestID
+	}
+	return bestID != "" && bestID != agent.AgentID
+}
+
+// concurrencyLimits 从配置读取任务并发上限 (<= 0 不限制)
+func (d *taskDispatcher) concurrencyLimits() agentRepo.TaskConcurrencyLimits {
+	return agentRepo.TaskConcurrencyLimits{
+		Global:     d.cfg.App.Master.Task.GlobalMaxRunning,
+		PerProject: d.cfg.App.Master.Task.ProjectMaxRunning,
+	}
+}
+
+// ConcurrencyStatus 获取任务并发占用情况
+// 只统计 Agent 任务，系统任务由本地 Agent 执行，不占用 Agent 集群的并发额度
+func (d *taskDispatcher) ConcurrencyStatus(ctx context.Context) (*orchestrator.TaskConcurrencyStatus, error) {
+	counts, err := d.taskRepo.CountTasksByProjectStatus(ctx, "agent", []string{"pending", "assigned", "running"})
+	if err != nil {
+		logger.LogError(err, "failed to count tasks", 0, "", "service.orchestrator.dispatcher.ConcurrencyStatus", "REPO", nil)
+		return nil, err
+	}
+
+	limits := d.concurrencyLimits()
+	status := &orchestrator.TaskConcurrencyStatus{
+		GlobalLimit:  max(limits.Global, 0),
+		ProjectLimit: max(limits.PerProject, 0),
+		Projects:     []*orchestrator.ProjectConcurrency{},
+	}
+	projects := make(map[uint64]*orchestrator.ProjectConcurrency)
+	for _, c := range counts {
+		project, ok := projects[c.ProjectID]
+		if !ok {
+			project = &orchestrator.ProjectConcurrency{ProjectID: c.ProjectID}
+			projects[c.ProjectID] = project
+			status.Projects = append(status.Projects, project)
+		}
+		if c.Status == "pending" {
+			project.Pending += c.Count
+			status.GlobalPending += c.Count
+		} else {
+			project.Running += c.Count
+			status.GlobalRunning += c.Count
+		}
+	}
+	sort.Slice(status.Projects, func(i, j int) bool {
+		return status.Projects[i].ProjectID < status.Projects[j].ProjectID
+	})
+	return status, nil
+}
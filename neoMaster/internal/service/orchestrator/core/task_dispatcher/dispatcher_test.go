@@ -0,0 +1,196 @@
+package task_dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"neomaster/internal/config"
+	agentModel "neomaster/internal/model/agent"
+	"neomaster/internal/model/orchestrator"
+	orcRepo "neomaster/internal/repo/mysql/orchestrator"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// allowAllAllocator 不做能力与频率限制，只验证并发上限
+type allowAllAllocator struct{}
+
+func (allowAllAllocator) CanExecute(ctx context.Context, agent *agentModel.Agent, task *orchestrator.AgentTask) bool {
+	return true
+}
+
+func (allowAllAllocator) Allow(ctx context.Context, agentID string) bool { return true }
+
+type allowAllPolicy struct{}
+
+func (allowAllPolicy) Enforce(ctx context.Context, task *orchestrator.AgentTask) error { return nil }
+
+func newConcurrencyTestDispatcher(t *testing.T, globalLimit, projectLimit int) (*gorm.DB, orcRepo.TaskRepository, TaskDispatcher) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// :memory: 每个连接是独立的库，固定单连接
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&orchestrator.AgentTask{}, &orchestrator.DispatchLock{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.App.Master.Task.MaxConcurrency = 10
+	cfg.App.Master.Task.GlobalMaxRunning = globalLimit
+	cfg.App.Master.Task.ProjectMaxRunning = projectLimit
+	repo := orcRepo.NewTaskRepository(db)
+	return db, repo, NewTaskDispatcher(cfg, repo, allowAllPolicy{}, allowAllAllocator{}, nil)
+}
+
+func createPendingTasks(t *testing.T, repo orcRepo.TaskRepository, projectID uint64, n, priority int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		task := &orchestrator.AgentTask{
+			TaskID:       fmt.Sprintf("p%d-t%d", projectID, i),
+			ProjectID:    projectID,
+			WorkflowID:   1,
+			StageID:      1,
+			Status:       "pending",
+			Priority:     priority,
+			TaskCategory: "agent",
+		}
+		if err := repo.CreateTask(context.Background(), task); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+}
+
+func TestDispatch_EnforcesConcurrencyLimits(t *testing.T) {
+	ctx := context.Background()
+	_, repo, dispatcher := newConcurrencyTestDispatcher(t, 4, 2)
+
+	createPendingTasks(t, repo, 1, 4, 10)
+	createPendingTasks(t, repo, 2, 3, 5)
+	// 项目 2 已有一个运行中任务
+	if err := repo.ClaimTask(ctx, "p2-t0", "agent-0"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	assigned, err := dispatcher.Dispatch(ctx, &agentModel.Agent{AgentID: "agent-1"}, 0)
+	if err != nil {
+		t.Fatalf("Dispatch error: %v", err)
+	}
+	// 项目 1 受单项目上限限制领取 2 个，项目 2 再领取 1 个后达到全局上限
+	perProject := map[uint64]int{}
+	for _, task := range assigned {
+		perProject[task.ProjectID]++
+	}
+	if perProject[1] != 2 || perProject[2] != 1 {
+		t.Fatalf("assigned per project = %v, want map[1:2 2:1]", perProject)
+	}
+
+	status, err := dispatcher.ConcurrencyStatus(ctx)
+	if err != nil {
+		t.Fatalf("ConcurrencyStatus error: %v", err)
+	}
+	if status.GlobalLimit != 4 || status.ProjectLimit != 2 || status.GlobalRunning != 4 || status.GlobalPending != 3 {
+		t.Errorf("status = %+v, want limit 4/2 running 4 pending 3", status)
+	}
+	if len(status.Projects) != 2 || status.Projects[0].Running != 2 || status.Projects[0].Pending != 2 ||
+		status.Projects[1].Running != 2 || status.Projects[1].Pending != 1 {
+		t.Errorf("projects = %+v %+v", status.Projects[0], status.Projects[1])
+	}
+
+	// 上限已满时不再分配，任务保持 pending
+	assigned, err = dispatcher.Dispatch(ctx, &agentModel.Agent{AgentID: "agent-2"}, 0)
+	if err != nil || len(assigned) != 0 {
+		t.Fatalf("Dispatch at limit = %d tasks, %v; want none", len(assigned), err)
+	}
+
+	// 运行中任务结束后排队任务被认领
+	if err = repo.UpdateTaskResult(ctx, runningTaskID(t, repo, 1), "{}", "", "completed"); err != nil {
+		t.Fatalf("complete task: %v", err)
+	}
+	assigned, err = dispatcher.Dispatch(ctx, &agentModel.Agent{AgentID: "agent-2"}, 0)
+	if err != nil || len(assigned) != 1 || assigned[0].ProjectID != 1 {
+		t.Fatalf("Dispatch after completion = %v, %v; want one task of project 1", assigned, err)
+	}
+}
+
+// runningTaskID 返回项目中任意一个运行中的任务ID
+func runningTaskID(t *testing.T, repo orcRepo.TaskRepository, projectID uint64) string {
+	t.Helper()
+	tasks, err := repo.GetTasksByProjectID(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("get tasks: %v", err)
+	}
+	for _, task := range tasks {
+		if task.Status == "running" {
+			return task.TaskID
+		}
+	}
+	t.Fatalf("project %d has no running task", projectID)
+	return ""
+}
+
+func TestDispatch_ConcurrentAgentsRespectGlobalLimit(t *testing.T) {
+	ctx := context.Background()
+	db, repo, dispatcher := newConcurrencyTestDispatcher(t, 3, 0)
+	createPendingTasks(t, repo, 1, 10, 0)
+	createPendingTasks(t, repo, 2, 10, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := dispatcher.Dispatch(ctx, &agentModel.Agent{AgentID: fmt.Sprintf("agent-%d", i)}, 0); err != nil {
+				t.Errorf("Dispatch error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var running int64
+	if err := db.Model(&orchestrator.AgentTask{}).Where("status = ?", "running").Count(&running).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if running != 3 {
+		t.Errorf("running tasks = %d, want 3", running)
+	}
+}
+
+// fixedSelector 始终选择同一个 Agent
+type fixedSelector struct{ agentID string }
+
+func (s fixedSelector) SelectBestAgent(ctx context.Context, requiredCapabilities []string) (*agentModel.Agent, error) {
+	return &agentModel.Agent{AgentID: s.agentID}, nil
+}
+
+func TestDispatch_PrefersLeastLoadedAgent(t *testing.T) {
+	ctx := context.Background()
+	db, repo, _ := newConcurrencyTestDispatcher(t, 0, 0)
+	cfg := &config.Config{}
+	cfg.App.Master.Task.MaxConcurrency = 10
+	d := NewTaskDispatcher(cfg, repo, allowAllPolicy{}, allowAllAllocator{}, fixedSelector{agentID: "idle-agent"}).(*taskDispatcher)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	createPendingTasks(t, repo, 1, 2, 10)
+	// p1-t1 已等待超过优选时长
+	if err := db.Model(&orchestrator.AgentTask{}).Where("task_id = ?", "p1-t1").Update("created_at", now.Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("age task: %v", err)
+	}
+
+	assigned, err := d.Dispatch(ctx, &agentModel.Agent{AgentID: "busy-agent"}, 0)
+	if err != nil || len(assigned) != 1 || assigned[0].TaskID != "p1-t1" {
+		t.Fatalf("busy agent got %v, %v; want only the aged task", assigned, err)
+	}
+	assigned, err = d.Dispatch(ctx, &agentModel.Agent{AgentID: "idle-agent"}, 0)
+	if err != nil || len(assigned) != 1 || assigned[0].TaskID != "p1-t0" {
+		t.Fatalf("idle agent got %v, %v; want the fresh task", assigned, err)
+	}
+}
@@ -19,5 +19,8 @@ type PolicyEnforcer = policy.PolicyEnforcer
 // ResourceAllocator 资源调度器接口别名
 type ResourceAllocator = allocator.ResourceAllocator
 
+// AgentSelector Agent选择器接口别名
+type AgentSelector = allocator.AgentSelector
+
 // AgentTaskService Agent任务服务接口别名
 type AgentTaskService = task_dispatcher.AgentTaskService
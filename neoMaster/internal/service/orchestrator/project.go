@@ -3,20 +3,63 @@ package orchestrator
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	orcmodel "neomaster/internal/model/orchestrator"
 	tagmodel "neomaster/internal/model/tag_system"
 	"neomaster/internal/pkg/logger"
 	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+	"neomaster/internal/service/notify/webhook"
+	"neomaster/internal/service/orchestrator/core/scheduler"
 	"neomaster/internal/service/tag_system"
 )
 
+// 即将执行时间查询数量限制
+const (
+	defaultUpcomingRuns = 5
+	maxUpcomingRuns     = 100
+)
+
+// projectStatusTransitions 项目状态机: 当前状态 -> 允许流转的目标状态集合
+// 所有合法流转集中定义于此，便于审计；未列出的流转一律拒绝
+var projectStatusTransitions = map[string][]string{
+	orcmodel.ProjectStatusIdle:     {orcmodel.ProjectStatusRunning},
+	orcmodel.ProjectStatusRunning:  {orcmodel.ProjectStatusPaused, orcmodel.ProjectStatusFinished, orcmodel.ProjectStatusError, orcmodel.ProjectStatusCanceled},
+	orcmodel.ProjectStatusPaused:   {orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusCanceled, orcmodel.ProjectStatusIdle},
+	orcmodel.ProjectStatusFinished: {orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusIdle},
+	orcmodel.ProjectStatusError:    {orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusIdle},
+	orcmodel.ProjectStatusCanceled: {orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusIdle},
+}
+
+// ErrIllegalProjectStatusTransition 项目状态流转不合法
+var ErrIllegalProjectStatusTransition = errors.New("illegal project status transition")
+
+// ValidateProjectStatusTransition 校验项目状态流转是否合法
+func ValidateProjectStatusTransition(from, to string) error {
+	allowed, ok := projectStatusTransitions[from]
+	if !ok {
+		return fmt.Errorf("unknown project status: %q", from)
+	}
+	if _, known := projectStatusTransitions[to]; !known {
+		return fmt.Errorf("unknown target project status: %q", to)
+	}
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s (allowed: %v)", ErrIllegalProjectStatusTransition, from, to, allowed)
+}
+
 // ProjectService 项目服务
 // 负责处理项目的业务逻辑
 type ProjectService struct {
 	repo       *orcrepo.ProjectRepository
 	tagService tag_system.TagService
+	notifier   webhook.Notifier       // 项目完成事件通知，为空时不发送
+	taskRepo   orcrepo.TaskRepository // 项目取消时取消其下任务，为空时不处理
 }
 
 // NewProjectService 创建 ProjectService 实例
@@ -27,11 +70,24 @@ func NewProjectService(repo *orcrepo.ProjectRepository, tagService tag_system.Ta
 	}
 }
 
+// SetWebhookNotifier 注入 Webhook 事件通知
+func (s *ProjectService) SetWebhookNotifier(notifier webhook.Notifier) {
+	s.notifier = notifier
+}
+
+// SetTaskRepository 注入任务仓库，用于项目取消时取消其下任务
+func (s *ProjectService) SetTaskRepository(taskRepo orcrepo.TaskRepository) {
+	s.taskRepo = taskRepo
+}
+
 // CreateProject 创建项目
 func (s *ProjectService) CreateProject(ctx context.Context, project *orcmodel.Project) error {
 	if project == nil {
 		return errors.New("project data cannot be nil")
 	}
+	if err := applyCronSchedule(project, project.ScheduleType, project.CronExpr, time.Time{}, time.Now()); err != nil {
+		return err
+	}
 
 	err := s.repo.CreateProject(ctx, project)
 	if err != nil {
@@ -74,6 +130,26 @@ func (s *ProjectService) UpdateProject(ctx context.Context, project *orcmodel.Pr
 		return errors.New("project not found")
 	}
 
+	// NextRunAt 由服务端维护，仅在调度配置变化时重新计算
+	project.NextRunAt = nil
+	scheduleChanged := project.ScheduleType != "" || project.CronExpr != ""
+	if scheduleChanged {
+		// 部分更新：未提交的调度字段沿用原值
+		scheduleType, cronExpr := project.ScheduleType, project.CronExpr
+		if scheduleType == "" {
+			scheduleType = existing.ScheduleType
+		}
+		if cronExpr == "" {
+			cronExpr = existing.CronExpr
+		}
+		if scheduleType == existing.ScheduleType && cronExpr == existing.CronExpr && existing.NextRunAt != nil {
+			// 原样重新提交调度配置：保留已排定的下一次执行时间，不打乱节奏
+			scheduleChanged = false
+		} else if err = applyCronSchedule(project, scheduleType, cronExpr, previousRunSlot(existing), time.Now()); err != nil {
+			return err
+		}
+	}
+
 	err = s.repo.UpdateProject(ctx, project)
 	if err != nil {
 		logger.LogBusinessError(err, "", 0, "", "update_project", "SERVICE", map[string]interface{}{
@@ -82,9 +158,85 @@ func (s *ProjectService) UpdateProject(ctx context.Context, project *orcmodel.Pr
 		})
 		return err
 	}
+
+	// 改为非 cron 调度时清除遗留的下一次执行时间 (Updates 会忽略 nil 字段)
+	if scheduleChanged && project.NextRunAt == nil && existing.NextRunAt != nil {
+		if err = s.repo.UpdateProjectNextRunAt(ctx, project.ID, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// previousRunSlot 项目上一个调度周期点 (已排定的下一次执行时间优先，其次为上次执行时间)
+// 从未调度过的项目返回零值，按当前时间计算
+func previousRunSlot(project *orcmodel.Project) time.Time {
+	if project.NextRunAt != nil {
+		return *project.NextRunAt
+	}
+	if project.LastExecTime != nil {
+		return *project.LastExecTime
+	}
+	return time.Time{}
+}
+
+// applyCronSchedule 校验调度配置并计算下一次执行时间
+// cron 调度必须配置合法的 CronExpr；其他调度类型提交了 CronExpr 时同样校验，NextRunAt 置空
+// prev 为上一个周期点，下一次执行时间以它为基准推算 (见 scheduler.NextRunAfter)
+func applyCronSchedule(project *orcmodel.Project, scheduleType, cronExpr string, prev, now time.Time) error {
+	project.NextRunAt = nil
+	if scheduleType != orcmodel.ProjectScheduleCron {
+		if project.CronExpr != "" {
+			if _, err := scheduler.ParseCronExpr(project.CronExpr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	schedule, err := scheduler.ParseCronExpr(cronExpr)
+	if err != nil {
+		return err
+	}
+	if next := scheduler.NextRunAfter(schedule, prev, now); !next.IsZero() {
+		project.NextRunAt = &next
+	}
 	return nil
 }
 
+// GetUpcomingRuns 获取 cron 项目接下来 n 次执行时间，供前端展示
+// 非 cron 调度或已停用的项目返回空列表；n 默认 5，最大 100
+func (s *ProjectService) GetUpcomingRuns(ctx context.Context, projectID uint64, n int) ([]time.Time, error) {
+	if n <= 0 {
+		n = defaultUpcomingRuns
+	}
+	if n > maxUpcomingRuns {
+		n = maxUpcomingRuns
+	}
+
+	project, err := s.repo.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.ScheduleType != orcmodel.ProjectScheduleCron || !project.Enabled {
+		return []time.Time{}, nil
+	}
+
+	// 已持久化的 NextRunAt 在未来时作为第一次执行时间，与调度器实际触发时间保持一致
+	now := time.Now()
+	if project.NextRunAt != nil && project.NextRunAt.After(now) {
+		rest, err := scheduler.NextRunTimes(project.CronExpr, *project.NextRunAt, n-1)
+		if err != nil {
+			return nil, err
+		}
+		return append([]time.Time{*project.NextRunAt}, rest...), nil
+	}
+	return scheduler.NextRunTimes(project.CronExpr, now, n)
+}
+
 // DeleteProject 删除项目
 func (s *ProjectService) DeleteProject(ctx context.Context, id uint64) error {
 	// 检查是否存在
@@ -107,6 +259,72 @@ func (s *ProjectService) DeleteProject(ctx context.Context, id uint64) error {
 	return nil
 }
 
+// TransitionStatus 按状态机流转项目状态
+// 1. 校验流转是否合法
+// 2. 使用 WHERE status = <当前状态> 条件更新，防止并发流转相互覆盖
+func (s *ProjectService) TransitionStatus(ctx context.Context, projectID uint64, target string) error {
+	project, err := s.repo.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return ErrProjectNotFound
+	}
+
+	if err = ValidateProjectStatusTransition(project.Status, target); err != nil {
+		return err
+	}
+
+	var updated bool
+	if target == orcmodel.ProjectStatusRunning && project.Status != orcmodel.ProjectStatusPaused {
+		// 从暂停恢复仍属于同一轮执行，其余进入 running 的流转开始新一轮
+		updated, err = s.repo.StartProjectRun(ctx, projectID, project.Status, time.Now())
+	} else {
+		updated, err = s.repo.UpdateProjectStatus(ctx, projectID, project.Status, target)
+	}
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "transition_project_status", "SERVICE", map[string]interface{}{
+			"operation":  "transition_project_status",
+			"project_id": projectID,
+			"from":       project.Status,
+			"to":         target,
+		})
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("project status changed concurrently (expected %s), please retry", project.Status)
+	}
+
+	if target == orcmodel.ProjectStatusCanceled && s.taskRepo != nil {
+		// 先流转项目状态再取消任务，调度器不会再为已取消的项目生成新任务
+		canceled, canceling, cancelErr := s.taskRepo.CancelProjectTasks(ctx, projectID)
+		if cancelErr != nil {
+			logger.LogBusinessError(cancelErr, "", 0, "", "cancel_project_tasks", "SERVICE", map[string]interface{}{
+				"operation":  "cancel_project_tasks",
+				"project_id": projectID,
+			})
+			return cancelErr
+		}
+		logger.LogInfo("Project canceled", "", 0, "", "service.orchestrator.project.TransitionStatus", "", map[string]interface{}{
+			"project_id":      projectID,
+			"tasks_canceled":  canceled,
+			"tasks_canceling": canceling,
+		})
+	}
+
+	// 手动标记完成与调度器自然完成一样对外通知
+	if target == orcmodel.ProjectStatusFinished && s.notifier != nil {
+		s.notifier.Notify(orcmodel.WebhookEventProjectCompleted, &webhook.ProjectCompletedData{
+			ProjectID:  project.ID,
+			Name:       project.Name,
+			RunID:      project.RunSeq,
+			Status:     target,
+			FinishedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
 // ListProjects 获取项目列表
 func (s *ProjectService) ListProjects(ctx context.Context, page, pageSize int, status string, name string, tagID uint64) ([]*orcmodel.Project, int64, error) {
 	if page < 1 {
@@ -0,0 +1,182 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+)
+
+// ErrProjectNotFound 项目不存在
+var ErrProjectNotFound = errors.New("project not found")
+
+// defaultProjectEventInterval 项目进度轮询间隔默认值
+const defaultProjectEventInterval = 2 * time.Second
+
+// projectProgress 某一时刻的项目进度快照
+type projectProgress struct {
+	status  string
+	percent int
+	stages  []*orcmodel.StageSummary
+}
+
+// WatchProjectEvents 订阅项目执行进度事件
+// 事件源复用项目状态与阶段任务统计(与 GetProjectSummary 同源)，按 interval 轮询并将两次快照的差异转换为事件：
+//   - 首次快照会补发已开始/已完成阶段的事件，客户端中途连接也能拿到完整进度
+//   - 项目进入 finished/error/canceled 后发送携带项目汇总的 done 事件并关闭通道
+//
+// ctx 取消(客户端断开)时轮询协程退出并关闭通道
+func (s *StageResultService) WatchProjectEvents(ctx context.Context, projectID uint64, interval time.Duration) (<-chan *orcmodel.ProjectEvent, error) {
+	if interval <= 0 {
+		interval = defaultProjectEventInterval
+	}
+	project, err := s.projectRepo.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+
+	events := make(chan *orcmodel.ProjectEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var prev *projectProgress
+		for {
+			cur, err := s.loadProjectProgress(ctx, projectID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// 单次查询失败不结束推送，下个周期重试
+				logger.LogBusinessError(err, "", 0, "", "watch_project_events", "SERVICE", map[string]interface{}{
+					"operation":  "watch_project_events",
+					"project_id": projectID,
+				})
+			} else {
+				for _, event := range diffProjectProgress(projectID, prev, cur) {
+					if !sendProjectEvent(ctx, events, event) {
+						return
+					}
+				}
+				prev = cur
+
+				if isProjectTerminal(cur.status) {
+					done := &orcmodel.ProjectEvent{Type: orcmodel.ProjectEventDone, ProjectID: projectID, Status: cur.status, Percent: cur.percent, Timestamp: time.Now()}
+					if summary, err := s.GetProjectSummary(ctx, projectID); err == nil {
+						done.Summary = summary
+					}
+					sendProjectEvent(ctx, events, done)
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return events, nil
+}
+
+// loadProjectProgress 查询项目状态与各阶段任务执行情况
+func (s *StageResultService) loadProjectProgress(ctx context.Context, projectID uint64) (*projectProgress, error) {
+	project, err := s.projectRepo.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+	stages, err := s.stageRepo.ListStagesByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	taskCounts, err := s.taskRepo.CountTasksByStageStatus(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只需要阶段执行情况，不加载结果数据
+	summary := buildProjectSummary(projectID, stages, nil, nil, taskCounts)
+	return &projectProgress{
+		status:  project.Status,
+		percent: projectPercent(summary.Stages),
+		stages:  summary.Stages,
+	}, nil
+}
+
+// diffProjectProgress 比较两次快照生成事件，prev 为 nil 表示首次快照
+func diffProjectProgress(projectID uint64, prev, cur *projectProgress) []*orcmodel.ProjectEvent {
+	now := time.Now()
+	prevStages := make(map[uint64]*orcmodel.StageSummary)
+	if prev != nil {
+		for _, stage := range prev.stages {
+			prevStages[stage.StageID] = stage
+		}
+	}
+
+	var events []*orcmodel.ProjectEvent
+	newEvent := func(eventType string, stage *orcmodel.StageSummary) *orcmodel.ProjectEvent {
+		return &orcmodel.ProjectEvent{Type: eventType, ProjectID: projectID, Status: cur.status, Percent: cur.percent, Stage: stage, Timestamp: now}
+	}
+	for _, stage := range cur.stages {
+		before, seen := prevStages[stage.StageID]
+		wasStarted := seen && before.Status != orcmodel.StageSummaryNotStarted
+		wasComplete := seen && isStageComplete(before.Status)
+
+		if stage.Status != orcmodel.StageSummaryNotStarted && !wasStarted {
+			events = append(events, newEvent(orcmodel.ProjectEventStageStart, stage))
+		}
+		if isStageComplete(stage.Status) && !wasComplete {
+			events = append(events, newEvent(orcmodel.ProjectEventStageComplete, stage))
+		}
+	}
+	if prev == nil || prev.percent != cur.percent || prev.status != cur.status {
+		events = append(events, newEvent(orcmodel.ProjectEventProgress, nil))
+	}
+	return events
+}
+
+// projectPercent 项目整体进度: 各阶段进度的平均值
+// 已结束的阶段计 100%，运行中的阶段按已结束任务占比计算
+func projectPercent(stages []*orcmodel.StageSummary) int {
+	if len(stages) == 0 {
+		return 0
+	}
+	var total float64
+	for _, stage := range stages {
+		switch {
+		case isStageComplete(stage.Status):
+			total += 1
+		case stage.TotalTasks > 0:
+			total += float64(stage.CompletedTasks+stage.FailedTasks) / float64(stage.TotalTasks)
+		}
+	}
+	return int(total * 100 / float64(len(stages)))
+}
+
+func isStageComplete(status string) bool {
+	return status == orcmodel.StageSummarySuccess || status == orcmodel.StageSummaryFailed || status == orcmodel.StageSummaryPartial
+}
+
+func isProjectTerminal(status string) bool {
+	return status == orcmodel.ProjectStatusFinished || status == orcmodel.ProjectStatusError || status == orcmodel.ProjectStatusCanceled
+}
+
+// sendProjectEvent 发送事件，ctx 取消时返回 false
+func sendProjectEvent(ctx context.Context, events chan<- *orcmodel.ProjectEvent, event *orcmodel.ProjectEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+package orchestrator
+
+import (
+	"fmt"
+	"testing"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+)
+
+func stageProgress(id uint64, status string, total, completed, failed int64) *orcmodel.StageSummary {
+	return &orcmodel.StageSummary{StageID: id, Status: status, TotalTasks: total, CompletedTasks: completed, FailedTasks: failed}
+}
+
+func eventTypes(events []*orcmodel.ProjectEvent) []string {
+	types := make([]string, 0, len(events))
+	for _, e := range events {
+		name := e.Type
+		if e.Stage != nil {
+			name = fmt.Sprintf("%s:%d", name, e.Stage.StageID)
+		}
+		types = append(types, name)
+	}
+	return types
+}
+
+func TestProjectPercent(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []*orcmodel.StageSummary
+		want   int
+	}{
+		{name: "no_stages", want: 0},
+		{name: "not_started", stages: []*orcmodel.StageSummary{stageProgress(1, orcmodel.StageSummaryNotStarted, 0, 0, 0)}, want: 0},
+		{
+			name: "mixed",
+			stages: []*orcmodel.StageSummary{
+				stageProgress(1, orcmodel.StageSummaryPartial, 2, 1, 1),
+				stageProgress(2, orcmodel.StageSummaryRunning, 4, 1, 0),
+				stageProgress(3, orcmodel.StageSummaryNotStarted, 0, 0, 0),
+				stageProgress(4, orcmodel.StageSummaryNotStarted, 0, 0, 0),
+			},
+			want: 31, // (1 + 0.25) / 4
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := projectPercent(tt.stages); got != tt.want {
+				t.Errorf("projectPercent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffProjectProgress(t *testing.T) {
+	// 首次快照补发已开始/已完成阶段的事件
+	first := &projectProgress{status: orcmodel.ProjectStatusRunning, percent: 50, stages: []*orcmodel.StageSummary{
+		stageProgress(1, orcmodel.StageSummarySuccess, 1, 1, 0),
+		stageProgress(2, orcmodel.StageSummaryNotStarted, 0, 0, 0),
+	}}
+	got := eventTypes(diffProjectProgress(7, nil, first))
+	if want := []string{"stage_start:1", "stage_complete:1", "progress"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("first snapshot events = %v, want %v", got, want)
+	}
+
+	// 无变化不产生事件
+	if events := diffProjectProgress(7, first, first); len(events) != 0 {
+		t.Errorf("unchanged snapshot events = %v, want none", eventTypes(events))
+	}
+
+	// 阶段2开始并直接完成
+	second := &projectProgress{status: orcmodel.ProjectStatusRunning, percent: 100, stages: []*orcmodel.StageSummary{
+		stageProgress(1, orcmodel.StageSummarySuccess, 1, 1, 0),
+		stageProgress(2, orcmodel.StageSummaryFailed, 1, 0, 1),
+	}}
+	events := diffProjectProgress(7, first, second)
+	got = eventTypes(events)
+	if want := []string{"stage_start:2", "stage_complete:2", "progress"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("second snapshot events = %v, want %v", got, want)
+	}
+	for _, e := range events {
+		if e.ProjectID != 7 || e.Percent != 100 {
+			t.Errorf("event %s = {project %d, percent %d}, want {7, 100}", e.Type, e.ProjectID, e.Percent)
+		}
+	}
+
+	// 仅项目状态变化也推送进度事件
+	third := &projectProgress{status: orcmodel.ProjectStatusFinished, percent: 100, stages: second.stages}
+	if got := eventTypes(diffProjectProgress(7, second, third)); len(got) != 1 || got[0] != "progress" {
+		t.Errorf("status change events = %v, want [progress]", got)
+	}
+}
@@ -0,0 +1,220 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+	"neomaster/internal/service/asset/etl"
+)
+
+// summaryResultTypes 项目汇总需要解析结构化属性的结果类型
+// 其余类型只参与条数统计，不加载 Attributes
+var summaryResultTypes = []string{
+	"fast_port_scan",
+	"full_port_scan",
+	"service_fingerprint",
+	"vuln_finding",
+	"poc_scan",
+}
+
+// GetProjectSummary 获取项目结果汇总
+// 聚合项目关联工作流下所有阶段的结果: 开放端口数、按服务分组的服务数、按严重程度分组的漏洞数、各阶段执行情况
+// 项目不存在时返回 ErrProjectNotFound
+// 固定 4 次批量查询 (阶段 / 结果条数 / 结果属性 / 任务状态)，不随阶段数量增长
+func (s *StageResultService) GetProjectSummary(ctx context.Context, projectID uint64) (*orcmodel.ProjectSummary, error) {
+	logFields := map[string]interface{}{
+		"operation":  "get_project_summary",
+		"project_id": projectID,
+	}
+
+	project, err := s.projectRepo.GetProjectByID(ctx, projectID)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "get_project_summary", "SERVICE", logFields)
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+
+	stages, err := s.stageRepo.ListStagesByProjectID(ctx, projectID)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "get_project_summary", "SERVICE", logFields)
+		return nil, err
+	}
+
+	resultCounts, err := s.repo.CountResultsByStage(ctx, projectID)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "get_project_summary", "SERVICE", logFields)
+		return nil, err
+	}
+
+	results, err := s.repo.ListResultAttributesByProject(ctx, projectID, summaryResultTypes)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "get_project_summary", "SERVICE", logFields)
+		return nil, err
+	}
+
+	taskCounts, err := s.taskRepo.CountTasksByStageStatus(ctx, projectID)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "get_project_summary", "SERVICE", logFields)
+		return nil, err
+	}
+
+	return buildProjectSummary(projectID, stages, resultCounts, results, taskCounts), nil
+}
+
+// buildProjectSummary 根据批量查询结果在内存中计算项目汇总
+func buildProjectSummary(
+	projectID uint64,
+	stages []*orcmodel.ScanStage,
+	resultCounts []orcrepo.StageResultCount,
+	results []*orcmodel.StageResult,
+	taskCounts []orcrepo.StageTaskCount,
+) *orcmodel.ProjectSummary {
+	summary := &orcmodel.ProjectSummary{
+		ProjectID:  projectID,
+		Services:   make(map[string]int),
+		VulnCounts: make(map[string]int),
+		Stages:     make([]*orcmodel.StageSummary, 0, len(stages)),
+	}
+
+	// 1. 阶段执行情况
+	stageIndex := make(map[uint64]*orcmodel.StageSummary, len(stages))
+	for _, stage := range stages {
+		item := &orcmodel.StageSummary{
+			StageID:    stage.ID,
+			WorkflowID: stage.WorkflowID,
+			StageName:  stage.StageName,
+			StageType:  stage.StageType,
+		}
+		stageIndex[stage.ID] = item
+		summary.Stages = append(summary.Stages, item)
+	}
+	for _, rc := range resultCounts {
+		summary.TotalResults += rc.Count
+		if item, ok := stageIndex[rc.StageID]; ok {
+			item.ResultCount += rc.Count
+		}
+	}
+	for _, tc := range taskCounts {
+		item, ok := stageIndex[tc.StageID]
+		if !ok {
+			continue
+		}
+		item.TotalTasks += tc.Count
+		switch tc.Status {
+		case "completed":
+			item.CompletedTasks += tc.Count
+		case "failed", "dead":
+			item.FailedTasks += tc.Count
+		}
+	}
+	for _, item := range summary.Stages {
+		item.Status = stageSummaryStatus(item)
+	}
+
+	// 2. 端口/服务/漏洞 (端口与服务按 ip:port/proto 去重，避免快速扫描与全量扫描重复计数)
+	openPorts := make(map[string]struct{})
+	services := make(map[string]string) // ip:port/proto -> 服务名 (指纹识别结果优先于端口扫描猜测)
+	for _, result := range results {
+		if result.Attributes == "" {
+			continue
+		}
+		var err error
+		switch result.ResultType {
+		case "fast_port_scan", "full_port_scan":
+			var attr etl.PortScanAttributes
+			if err = json.Unmarshal([]byte(result.Attributes), &attr); err == nil {
+				for _, p := range attr.Ports {
+					if p.State != "" && p.State != "open" {
+						continue
+					}
+					key := endpointKey(p.IP, p.Port, p.Proto)
+					openPorts[key] = struct{}{}
+					if _, exists := services[key]; !exists && p.ServiceHint != "" {
+						services[key] = strings.ToLower(p.ServiceHint)
+					}
+				}
+			}
+		case "service_fingerprint":
+			var attr etl.ServiceFingerprintAttributes
+			if err = json.Unmarshal([]byte(result.Attributes), &attr); err == nil {
+				for _, svc := range attr.Services {
+					if svc.Name != "" {
+						services[endpointKey(svc.IP, svc.Port, svc.Proto)] = strings.ToLower(svc.Name)
+					}
+				}
+			}
+		case "vuln_finding":
+			var attr etl.VulnFindingAttributes
+			if err = json.Unmarshal([]byte(result.Attributes), &attr); err == nil {
+				for _, f := range attr.Findings {
+					summary.VulnCounts[normalizeSeverity(f.Severity)]++
+				}
+			}
+		case "poc_scan":
+			var attr etl.PocScanAttributes
+			if err = json.Unmarshal([]byte(result.Attributes), &attr); err == nil {
+				for _, poc := range attr.PocResults {
+					// 只统计验证成功的 PoC
+					if poc.Status == "confirmed" {
+						summary.VulnCounts[normalizeSeverity(poc.Severity)]++
+					}
+				}
+			}
+		}
+		if err != nil {
+			summary.ParseErrors++
+		}
+	}
+
+	summary.OpenPorts = len(openPorts)
+	for _, name := range services {
+		summary.Services[name]++
+	}
+	return summary
+}
+
+// stageSummaryStatus 根据任务统计推导阶段状态
+func stageSummaryStatus(item *orcmodel.StageSummary) string {
+	switch {
+	case item.TotalTasks == 0:
+		return orcmodel.StageSummaryNotStarted
+	case item.CompletedTasks+item.FailedTasks < item.TotalTasks:
+		return orcmodel.StageSummaryRunning
+	case item.FailedTasks == 0:
+		return orcmodel.StageSummarySuccess
+	case item.CompletedTasks == 0:
+		return orcmodel.StageSummaryFailed
+	default:
+		return orcmodel.StageSummaryPartial
+	}
+}
+
+// endpointKey 端口去重键
+func endpointKey(ip string, port int, proto string) string {
+	if proto == "" {
+		proto = "tcp"
+	}
+	return fmt.Sprintf("%s:%d/%s", ip, port, strings.ToLower(proto))
+}
+
+// normalizeSeverity 统一漏洞严重程度写法，未知或为空归为 unknown
+func normalizeSeverity(severity string) string {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	switch severity {
+	case "critical", "high", "medium", "low", "info":
+		return severity
+	case "informational":
+		return "info"
+	case "moderate":
+		return "medium"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,93 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"neomaster/internal/model/basemodel"
+	orcmodel "neomaster/internal/model/orchestrator"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+)
+
+func TestBuildProjectSummary(t *testing.T) {
+	stages := []*orcmodel.ScanStage{
+		{BaseModel: basemodel.BaseModel{ID: 1}, WorkflowID: 10, StageName: "fast port", StageType: "fast_port_scan"},
+		{BaseModel: basemodel.BaseModel{ID: 2}, WorkflowID: 10, StageName: "fingerprint", StageType: "service_fingerprint"},
+		{BaseModel: basemodel.BaseModel{ID: 3}, WorkflowID: 10, StageName: "vuln", StageType: "vuln_finding"},
+		{BaseModel: basemodel.BaseModel{ID: 4}, WorkflowID: 10, StageName: "report", StageType: "other_scan"},
+	}
+	resultCounts := []orcrepo.StageResultCount{
+		{StageID: 1, Count: 2},
+		{StageID: 2, Count: 1},
+		{StageID: 3, Count: 2},
+	}
+	results := []*orcmodel.StageResult{
+		{StageID: 1, ResultType: "fast_port_scan", Attributes: `{"ports":[
+			{"ip":"10.0.0.1","port":22,"proto":"tcp","state":"open","service_hint":"ssh"},
+			{"ip":"10.0.0.1","port":80,"proto":"tcp","state":"open","service_hint":"http"},
+			{"ip":"10.0.0.1","port":81,"proto":"tcp","state":"closed"}]}`},
+		// 全量扫描重复发现的端口不应重复计数
+		{StageID: 1, ResultType: "full_port_scan", Attributes: `{"ports":[
+			{"ip":"10.0.0.1","port":22,"proto":"tcp","state":"open"},
+			{"ip":"10.0.0.2","port":443,"proto":"tcp","state":"open","service_hint":"https"}]}`},
+		// 指纹识别结果覆盖端口扫描的服务猜测
+		{StageID: 2, ResultType: "service_fingerprint", Attributes: `{"services":[
+			{"ip":"10.0.0.1","port":22,"proto":"tcp","name":"OpenSSH"}]}`},
+		{StageID: 3, ResultType: "vuln_finding", Attributes: `{"findings":[
+			{"ip":"10.0.0.1","severity":"High"},{"ip":"10.0.0.1","severity":"critical"},{"ip":"10.0.0.2","severity":""}]}`},
+		{StageID: 3, ResultType: "vuln_finding", Attributes: `not json`},
+	}
+	taskCounts := []orcrepo.StageTaskCount{
+		{StageID: 1, Status: "completed", Count: 2},
+		{StageID: 2, Status: "completed", Count: 1},
+		{StageID: 2, Status: "dead", Count: 1},
+		{StageID: 3, Status: "running", Count: 1},
+	}
+
+	summary := buildProjectSummary(7, stages, resultCounts, results, taskCounts)
+
+	if summary.TotalResults != 5 {
+		t.Errorf("TotalResults = %d, want 5", summary.TotalResults)
+	}
+	if summary.OpenPorts != 3 {
+		t.Errorf("OpenPorts = %d, want 3", summary.OpenPorts)
+	}
+	wantServices := map[string]int{"openssh": 1, "http": 1, "https": 1}
+	for name, want := range wantServices {
+		if got := summary.Services[name]; got != want {
+			t.Errorf("Services[%q] = %d, want %d", name, got, want)
+		}
+	}
+	if len(summary.Services) != len(wantServices) {
+		t.Errorf("Services = %v, want %v", summary.Services, wantServices)
+	}
+	if summary.VulnCounts["high"] != 1 || summary.VulnCounts["critical"] != 1 || summary.VulnCounts["unknown"] != 1 {
+		t.Errorf("VulnCounts = %v", summary.VulnCounts)
+	}
+	if summary.ParseErrors != 1 {
+		t.Errorf("ParseErrors = %d, want 1", summary.ParseErrors)
+	}
+
+	wantStatus := []string{
+		orcmodel.StageSummarySuccess,
+		orcmodel.StageSummaryPartial,
+		orcmodel.StageSummaryRunning,
+		orcmodel.StageSummaryNotStarted,
+	}
+	for i, stage := range summary.Stages {
+		if stage.Status != wantStatus[i] {
+			t.Errorf("stage %d status = %s, want %s", stage.StageID, stage.Status, wantStatus[i])
+		}
+	}
+	if summary.Stages[1].FailedTasks != 1 {
+		t.Errorf("stage 2 FailedTasks = %d, want 1", summary.Stages[1].FailedTasks)
+	}
+}
+
+func TestGetProjectSummary_ProjectNotFound(t *testing.T) {
+	svc, _, _ := newScanDiffTestService(t)
+	if _, err := svc.GetProjectSummary(context.Background(), 404); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("GetProjectSummary() error = %v, want ErrProjectNotFound", err)
+	}
+}
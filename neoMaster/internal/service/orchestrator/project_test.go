@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/service/orchestrator/core/scheduler"
+)
+
+func TestValidateProjectStatusTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{"idle_to_running", orcmodel.ProjectStatusIdle, orcmodel.ProjectStatusRunning, false},
+		{"running_to_finished", orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusFinished, false},
+		{"running_to_error", orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusError, false},
+		{"running_to_canceled", orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusCanceled, false},
+		{"paused_to_running", orcmodel.ProjectStatusPaused, orcmodel.ProjectStatusRunning, false},
+		{"error_to_running", orcmodel.ProjectStatusError, orcmodel.ProjectStatusRunning, false},
+		{"idle_to_finished_skips_running", orcmodel.ProjectStatusIdle, orcmodel.ProjectStatusFinished, true},
+		{"finished_to_error", orcmodel.ProjectStatusFinished, orcmodel.ProjectStatusError, true},
+		{"running_to_running", orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusRunning, true},
+		{"unknown_source", "bogus", orcmodel.ProjectStatusRunning, true},
+		{"unknown_target", orcmodel.ProjectStatusIdle, "bogus", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProjectStatusTransition(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProjectStatusTransition(%q, %q) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyCronSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 16, 1, 30, 0, 0, time.UTC)
+
+	project := &orcmodel.Project{ScheduleType: orcmodel.ProjectScheduleCron, CronExpr: "0 2 * * *"}
+	if err := applyCronSchedule(project, project.ScheduleType, project.CronExpr, time.Time{}, now); err != nil {
+		t.Fatalf("applyCronSchedule error: %v", err)
+	}
+	if want := time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC); project.NextRunAt == nil || !project.NextRunAt.Equal(want) {
+		t.Errorf("NextRunAt = %v, want %v", project.NextRunAt, want)
+	}
+
+	tests := []struct {
+		name         string
+		scheduleType string
+		cronExpr     string
+	}{
+		{"cron_without_expr", orcmodel.ProjectScheduleCron, ""},
+		{"cron_bad_expr", orcmodel.ProjectScheduleCron, "every night"},
+		{"cron_six_fields", orcmodel.ProjectScheduleCron, "0 0 2 * * *"},
+		{"immediate_with_bad_expr", orcmodel.ProjectScheduleImmediate, "99 * * * *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &orcmodel.Project{ScheduleType: tt.scheduleType, CronExpr: tt.cronExpr}
+			err := applyCronSchedule(p, tt.scheduleType, tt.cronExpr, time.Time{}, now)
+			if !errors.Is(err, scheduler.ErrInvalidCronExpr) {
+				t.Errorf("applyCronSchedule error = %v, want ErrInvalidCronExpr", err)
+			}
+		})
+	}
+
+	// 非 cron 调度清空 NextRunAt
+	project.ScheduleType = orcmodel.ProjectScheduleImmediate
+	if err := applyCronSchedule(project, project.ScheduleType, project.CronExpr, time.Time{}, now); err != nil || project.NextRunAt != nil {
+		t.Errorf("applyCronSchedule(immediate) = %v, NextRunAt = %v", err, project.NextRunAt)
+	}
+
+	// @every 固定间隔以上一个周期点为基准，不随提交时间漂移
+	prev := time.Date(2026, 10, 16, 0, 10, 0, 0, time.UTC)
+	every := &orcmodel.Project{ScheduleType: orcmodel.ProjectScheduleCron, CronExpr: "@every 1h"}
+	if err := applyCronSchedule(every, every.ScheduleType, every.CronExpr, prev, now); err != nil {
+		t.Fatalf("applyCronSchedule(@every) error: %v", err)
+	}
+	if want := time.Date(2026, 10, 16, 2, 10, 0, 0, time.UTC); every.NextRunAt == nil || !every.NextRunAt.Equal(want) {
+		t.Errorf("@every NextRunAt = %v, want %v", every.NextRunAt, want)
+	}
+}
@@ -0,0 +1,337 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	assetModel "neomaster/internal/model/asset"
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+	"neomaster/internal/service/orchestrator/ingestor"
+)
+
+var (
+	// ErrInvalidResultBatch 批次本身不合法 (缺少批次ID/结果为空/条数超限)，整批拒绝
+	ErrInvalidResultBatch = errors.New("invalid result batch")
+	// ErrResultBatchAgentMismatch 批次声明的 AgentID 与 Token 对应的 Agent 不一致
+	ErrResultBatchAgentMismatch = errors.New("result batch agent mismatch")
+)
+
+const (
+	maxResultBatchItems    = 1000 // 单批最多结果条数 (Agent 默认每批 100 条)
+	maxResultBatchIDLen    = 64
+	defaultStageResultType = "other_scan" // 阶段已删除时的结果类型
+)
+
+// ResultBatchService Agent 结果批次摄入服务 (POST /api/v1/agent/results)
+// 1. 按批次ID去重: 已处理过的批次直接返回当时的处理报告
+// 2. 逐条校验: 格式错误的条目隔离 (写入 asset_etl_errors)，任务不存在/不属于该 Agent/不在运行中的条目拒绝，其余条目转换为 StageResult
+// 3. 批次记录、StageResult、隔离条目在同一事务中写入
+// 4. 提交成功后 StageResult 推入结果队列，由 ETL 合并到资产表
+type ResultBatchService struct {
+	batchRepo *orcrepo.ResultBatchRepository
+	taskRepo  orcrepo.TaskRepository
+	stageRepo *orcrepo.ScanStageRepository
+	queue     ingestor.ResultQueue // 可为 nil (不做资产合并)
+}
+
+// NewResultBatchService 创建 ResultBatchService 实例
+func NewResultBatchService(batchRepo *orcrepo.ResultBatchRepository, taskRepo orcrepo.TaskRepository, stageRepo *orcrepo.ScanStageRepository, queue ingestor.ResultQueue) *ResultBatchService {
+	return &ResultBatchService{
+		batchRepo: batchRepo,
+		taskRepo:  taskRepo,
+		stageRepo: stageRepo,
+		queue:     queue,
+	}
+}
+
+// SubmitBatch 处理 agentID 上报的结果批次
+// 返回 ErrInvalidResultBatch/ErrResultBatchAgentMismatch 时整批未处理；单条问题只体现在报告中，不返回错误
+func (s *ResultBatchService) SubmitBatch(ctx context.Context, agentID string, req *orcmodel.AgentResultBatchRequest) (*orcmodel.AgentResultBatchReport, error) {
+	if err := validateResultBatch(agentID, req); err != nil {
+		return nil, err
+	}
+
+	// 1. 重放的批次直接返回上次的处理报告
+	if report, err := s.duplicateReport(ctx, agentID, req.BatchID); report != nil || err != nil {
+		return report, err
+	}
+
+	// 2. 逐条校验并转换
+	report := &orcmodel.AgentResultBatchReport{
+		BatchID:     req.BatchID,
+		Quarantined: []orcmodel.RejectedResultItem{},
+		Rejected:    []orcmodel.RejectedResultItem{},
+	}
+	var results []*orcmodel.StageResult
+	var quarantined []*assetModel.AssetETLError
+	tasks := make(map[string]*orcmodel.AgentTask)
+	stageTypes := make(map[uint64]string)
+
+	for i := range req.Results {
+		item := &req.Results[i]
+		if err := validateResultItem(item); err != nil {
+			report.Quarantined = append(report.Quarantined, orcmodel.RejectedResultItem{Index: i, TaskID: item.TaskID, Reason: err.Error()})
+			quarantined = append(quarantined, quarantineResultItem(item, tasks[item.TaskID], err))
+			continue
+		}
+
+		task, err := s.lookupTask(ctx, tasks, item.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		if reason := taskRejectReason(task, agentID); reason != "" {
+			report.Rejected = append(report.Rejected, orcmodel.RejectedResultItem{Index: i, TaskID: item.TaskID, Reason: reason})
+			continue
+		}
+		if item.Status == "failed" {
+			report.Skipped++
+			continue
+		}
+
+		resultType, err := s.lookupStageType(ctx, stageTypes, task.StageID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, newAgentStageResult(task, agentID, resultType, item))
+	}
+	report.Accepted = len(results)
+
+	// 3. 事务写入
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	batch := &orcmodel.ResultBatch{
+		BatchID:     req.BatchID,
+		AgentID:     agentID,
+		Total:       len(req.Results),
+		Accepted:    report.Accepted,
+		Skipped:     report.Skipped,
+		Quarantined: len(report.Quarantined),
+		Rejected:    len(report.Rejected),
+		Report:      string(reportJSON),
+	}
+	if err := s.batchRepo.SaveBatch(ctx, batch, results, quarantined); err != nil {
+		if errors.Is(err, orcrepo.ErrDuplicateResultBatch) {
+			// 同一批次的并发重放，以先提交的为准
+			return s.duplicateReport(ctx, agentID, req.BatchID)
+		}
+		return nil, err
+	}
+
+	// 4. 资产合并 (异步)，队列满时结果已入库，只影响资产表
+	s.enqueueForETL(ctx, results)
+
+	logger.LogInfo("Agent result batch ingested", "", 0, "", "service.orchestrator.result_batch.SubmitBatch", "", map[string]interface{}{
+		"batch_id":    req.BatchID,
+		"agent_id":    agentID,
+		"total":       batch.Total,
+		"accepted":    batch.Accepted,
+		"skipped":     batch.Skipped,
+		"quarantined": batch.Quarantined,
+		"rejected":    batch.Rejected,
+	})
+	return report, nil
+}
+
+// duplicateReport 批次已处理过时返回当时的处理报告 (Duplicate=true)，未处理过时返回 nil
+func (s *ResultBatchService) duplicateReport(ctx context.Context, agentID, batchID string) (*orcmodel.AgentResultBatchReport, error) {
+	existing, err := s.batchRepo.GetBatch(ctx, batchID)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if existing.AgentID != agentID {
+		return nil, fmt.Errorf("%w: batch %s was submitted by another agent", ErrResultBatchAgentMismatch, batchID)
+	}
+	report := &orcmodel.AgentResultBatchReport{}
+	if err := json.Unmarshal([]byte(existing.Report), report); err != nil {
+		report = &orcmodel.AgentResultBatchReport{Accepted: existing.Accepted, Skipped: existing.Skipped}
+	}
+	report.BatchID = batchID
+	report.Duplicate = true
+	return report, nil
+}
+
+// lookupTask 查询任务 (批次内缓存)，任务不存在时返回 nil
+func (s *ResultBatchService) lookupTask(ctx context.Context, cache map[string]*orcmodel.AgentTask, taskID string) (*orcmodel.AgentTask, error) {
+	if task, ok := cache[taskID]; ok {
+		return task, nil
+	}
+	task, err := s.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	cache[taskID] = task
+	return task, nil
+}
+
+// lookupStageType 查询阶段类型作为结果类型 (批次内缓存)
+func (s *ResultBatchService) lookupStageType(ctx context.Context, cache map[uint64]string, stageID uint64) (string, error) {
+	if t, ok := cache[stageID]; ok {
+		return t, nil
+	}
+	resultType := defaultStageResultType
+	stage, err := s.stageRepo.GetStageByID(ctx, stageID)
+	if err != nil {
+		return "", err
+	}
+	if stage != nil && stage.StageType != "" {
+		resultType = stage.StageType
+	}
+	cache[stageID] = resultType
+	return resultType, nil
+}
+
+// enqueueForETL 将已入库的结果推入 ETL 队列
+func (s *ResultBatchService) enqueueForETL(ctx context.Context, results []*orcmodel.StageResult) {
+	if s.queue == nil {
+		return
+	}
+	for _, result := range results {
+		if err := s.queue.Push(ctx, result); err != nil {
+			logger.LogWarn("Failed to enqueue stage result for ETL", "", 0, "", "service.orchestrator.result_batch.enqueueForETL", "", map[string]interface{}{
+				"task_id":   result.TaskID,
+				"result_id": result.ID,
+				"error":     err.Error(),
+			})
+		}
+	}
+}
+
+// validateResultBatch 校验批次本身
+func validateResultBatch(agentID string, req *orcmodel.AgentResultBatchRequest) error {
+	if req == nil {
+		return fmt.Errorf("%w: empty request", ErrInvalidResultBatch)
+	}
+	if req.BatchID == "" || len(req.BatchID) > maxResultBatchIDLen {
+		return fmt.Errorf("%w: batch_id is required (max %d chars)", ErrInvalidResultBatch, maxResultBatchIDLen)
+	}
+	if len(req.Results) == 0 {
+		return fmt.Errorf("%w: results is empty", ErrInvalidResultBatch)
+	}
+	if len(req.Results) > maxResultBatchItems {
+		return fmt.Errorf("%w: too many results (%d > %d)", ErrInvalidResultBatch, len(req.Results), maxResultBatchItems)
+	}
+	if req.AgentID != "" && req.AgentID != agentID {
+		return fmt.Errorf("%w: batch declares agent %s", ErrResultBatchAgentMismatch, req.AgentID)
+	}
+	return nil
+}
+
+// validateResultItem 校验单条结果格式
+// success 条目必须带 JSON 对象或数组形式的结果；failed 条目必须带错误信息
+func validateResultItem(item *orcmodel.AgentResultItem) error {
+	if item.TaskID == "" {
+		return errors.New("missing task_id")
+	}
+	switch item.Status {
+	case "success":
+		raw := bytes.TrimSpace(item.Result)
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			return errors.New("missing result")
+		}
+		if raw[0] != '{' && raw[0] != '[' {
+			return errors.New("result must be a JSON object or array")
+		}
+	case "failed":
+		if item.Error == "" {
+			return errors.New("failed result without error message")
+		}
+	case "":
+		return errors.New("missing status")
+	default:
+		return fmt.Errorf("unsupported status: %s", item.Status)
+	}
+	return nil
+}
+
+// taskRejectReason 任务状态不允许接收结果时返回原因
+// 只接收已分配给该 Agent 且仍在执行 (assigned/running/canceling) 的任务的结果，已完成/取消/失败的任务一律拒绝；
+// canceling 的任务在 Agent 收到取消前可能已执行完，其最后一批结果须能入库，Agent 才能如实上报 completed
+func taskRejectReason(task *orcmodel.AgentTask, agentID string) string {
+	if task == nil {
+		return "task not found"
+	}
+	if task.AgentID != agentID {
+		return "task is not assigned to this agent"
+	}
+	if task.Status != "assigned" && task.Status != "running" && task.Status != "canceling" {
+		return fmt.Sprintf("task is %s", task.Status)
+	}
+	return ""
+}
+
+// newAgentStageResult 结果条目转换为 StageResult
+func newAgentStageResult(task *orcmodel.AgentTask, agentID, resultType string, item *orcmodel.AgentResultItem) *orcmodel.StageResult {
+	producedAt := item.CompletedAt
+	if producedAt.IsZero() {
+		producedAt = time.Now()
+	}
+	target := strings.TrimSpace(item.Target)
+	result := &orcmodel.StageResult{
+		ProjectID:     task.ProjectID,
+		RunID:         task.RunID,
+		WorkflowID:    task.WorkflowID,
+		StageID:       task.StageID,
+		TaskID:        task.TaskID,
+		AgentID:       agentID,
+		ResultType:    resultType,
+		TargetType:    resultTargetType(target),
+		TargetValue:   target,
+		Attributes:    string(item.Result),
+		Evidence:      "{}",
+		OutputActions: "[]",
+		ProducedAt:    producedAt,
+		Producer:      task.ToolName,
+	}
+	setFindingIdentity(result)
+	return result
+}
+
+// quarantineResultItem 格式错误的条目记录为隔离数据，RawData 保存原始条目
+func quarantineResultItem(item *orcmodel.AgentResultItem, task *orcmodel.AgentTask, reason error) *assetModel.AssetETLError {
+	raw, _ := json.Marshal(item)
+	record := &assetModel.AssetETLError{
+		TaskID:     item.TaskID,
+		ResultType: item.TaskType,
+		RawData:    string(raw),
+		ErrorMsg:   reason.Error(),
+		ErrorStage: "ingest",
+		Status:     "quarantined",
+	}
+	if task != nil {
+		record.ProjectID = task.ProjectID
+	}
+	return record
+}
+
+// resultTargetType 根据目标值推断目标类型 (url/ip/ip_range/domain)
+func resultTargetType(target string) string {
+	switch {
+	case target == "":
+		return ""
+	case strings.Contains(target, "://"):
+		return "url"
+	case net.ParseIP(target) != nil:
+		return "ip"
+	case strings.Contains(target, "/") || strings.Contains(target, "-"):
+		if _, _, err := net.ParseCIDR(target); err == nil {
+			return "ip_range"
+		}
+		if start, _, ok := strings.Cut(target, "-"); ok && net.ParseIP(start) != nil {
+			return "ip_range"
+		}
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil && net.ParseIP(host) != nil {
+		return "ip"
+	}
+	return "domain"
+}
@@ -0,0 +1,171 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	assetModel "neomaster/internal/model/asset"
+	"neomaster/internal/model/basemodel"
+	orcmodel "neomaster/internal/model/orchestrator"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+	"neomaster/internal/service/orchestrator/ingestor"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newResultBatchTestService(t *testing.T) (*ResultBatchService, *gorm.DB, *ingestor.MemoryQueue) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err = db.AutoMigrate(&orcmodel.AgentTask{}, &orcmodel.ScanStage{}, &orcmodel.StageResult{}, &orcmodel.ResultBatch{}, &assetModel.AssetETLError{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&orcmodel.ScanStage{BaseModel: basemodel.BaseModel{ID: 3}, WorkflowID: 2, StageType: "fast_port_scan"})
+	for _, task := range []*orcmodel.AgentTask{
+		{TaskID: "running", ProjectID: 1, WorkflowID: 2, StageID: 3, RunID: 4, AgentID: "agent-1", Status: "running", ToolName: "fastPortScan"},
+		{TaskID: "completed", ProjectID: 1, WorkflowID: 2, StageID: 3, AgentID: "agent-1", Status: "completed"},
+		{TaskID: "canceled", ProjectID: 1, WorkflowID: 2, StageID: 3, AgentID: "agent-1", Status: "canceled"},
+		{TaskID: "other-agent", ProjectID: 1, WorkflowID: 2, StageID: 3, AgentID: "agent-2", Status: "running"},
+		{TaskID: "canceling", ProjectID: 1, WorkflowID: 2, StageID: 3, RunID: 4, AgentID: "agent-1", Status: "canceling", ToolName: "fastPortScan"},
+	} {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+	queue := ingestor.NewMemoryQueue(10)
+	taskRepo := orcrepo.NewTaskRepository(db)
+	svc := NewResultBatchService(orcrepo.NewResultBatchRepository(db), taskRepo, orcrepo.NewScanStageRepository(db), queue)
+	return svc, db, queue
+}
+
+func TestResultBatchService_SubmitBatch(t *testing.T) {
+	ctx := context.Background()
+	svc, db, queue := newResultBatchTestService(t)
+
+	req := &orcmodel.AgentResultBatchRequest{
+		BatchID: "batch-1",
+		Results: []orcmodel.AgentResultItem{
+			{TaskID: "running", Status: "success", Target: "10.0.0.1", Result: json.RawMessage(`{"ports":[{"port":22,"state":"open"}]}`)},
+			{TaskID: "running", Status: "success", Result: json.RawMessage(`"not an object"`)},
+			{TaskID: "", Status: "success", Result: json.RawMessage(`{}`)},
+			{TaskID: "completed", Status: "success", Result: json.RawMessage(`{}`)},
+			{TaskID: "canceled", Status: "success", Result: json.RawMessage(`{}`)},
+			{TaskID: "other-agent", Status: "success", Result: json.RawMessage(`{}`)},
+			{TaskID: "missing", Status: "success", Result: json.RawMessage(`{}`)},
+			{TaskID: "running", Status: "failed", Error: "timeout"},
+		},
+	}
+	report, err := svc.SubmitBatch(ctx, "agent-1", req)
+	if err != nil {
+		t.Fatalf("SubmitBatch: %v", err)
+	}
+	if report.Accepted != 1 || report.Skipped != 1 || len(report.Quarantined) != 2 || len(report.Rejected) != 4 || report.Duplicate {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Quarantined[0].Index != 1 || report.Rejected[0].Reason != "task is completed" {
+		t.Errorf("unexpected item details: %+v", report)
+	}
+
+	var results []orcmodel.StageResult
+	db.Find(&results)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 stage result, got %d", len(results))
+	}
+	r := results[0]
+	if r.ResultType != "fast_port_scan" || r.RunID != 4 || r.TargetType != "ip" || r.AgentID != "agent-1" || r.FindingKey == "" || r.Producer != "fastPortScan" {
+		t.Errorf("unexpected stage result: %+v", r)
+	}
+	var quarantined []assetModel.AssetETLError
+	db.Find(&quarantined)
+	if len(quarantined) != 2 || quarantined[0].ErrorStage != "ingest" || quarantined[0].Status != "quarantined" {
+		t.Errorf("unexpected quarantine records: %+v", quarantined)
+	}
+	if n, _ := queue.Len(ctx); n != 1 {
+		t.Errorf("expected 1 result queued for ETL, got %d", n)
+	}
+
+	// 重放: 返回原报告，不重复入库
+	replay, err := svc.SubmitBatch(ctx, "agent-1", req)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !replay.Duplicate || replay.Accepted != 1 || len(replay.Rejected) != 4 {
+		t.Errorf("unexpected replay report: %+v", replay)
+	}
+	var count int64
+	db.Model(&orcmodel.StageResult{}).Count(&count)
+	if count != 1 {
+		t.Errorf("replay should not insert results again, got %d", count)
+	}
+
+	// 其他 Agent 使用相同批次ID
+	if _, err := svc.SubmitBatch(ctx, "agent-2", req); !errors.Is(err, ErrResultBatchAgentMismatch) {
+		t.Errorf("expected ErrResultBatchAgentMismatch, got %v", err)
+	}
+}
+
+func TestResultBatchService_SubmitBatch_CancelingTask(t *testing.T) {
+	ctx := context.Background()
+	svc, db, _ := newResultBatchTestService(t)
+
+	// 取消下达前任务已执行完：最后一批结果照常入库，Agent 随后上报 completed
+	report, err := svc.SubmitBatch(ctx, "agent-1", &orcmodel.AgentResultBatchRequest{
+		BatchID: "batch-canceling",
+		Results: []orcmodel.AgentResultItem{
+			{TaskID: "canceling", Status: "success", Target: "10.0.0.2", Result: json.RawMessage(`{"ports":[{"port":443,"state":"open"}]}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SubmitBatch: %v", err)
+	}
+	if report.Accepted != 1 || len(report.Rejected) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	var count int64
+	db.Model(&orcmodel.StageResult{}).Where("task_id = ?", "canceling").Count(&count)
+	if count != 1 {
+		t.Errorf("expected 1 stage result for canceling task, got %d", count)
+	}
+}
+
+func TestResultBatchService_InvalidBatch(t *testing.T) {
+	svc, _, _ := newResultBatchTestService(t)
+	item := orcmodel.AgentResultItem{TaskID: "running", Status: "success", Result: json.RawMessage(`{}`)}
+
+	cases := map[string]*orcmodel.AgentResultBatchRequest{
+		"missing batch id": {Results: []orcmodel.AgentResultItem{item}},
+		"empty results":    {BatchID: "b"},
+	}
+	for name, req := range cases {
+		if _, err := svc.SubmitBatch(context.Background(), "agent-1", req); !errors.Is(err, ErrInvalidResultBatch) {
+			t.Errorf("%s: expected ErrInvalidResultBatch, got %v", name, err)
+		}
+	}
+	req := &orcmodel.AgentResultBatchRequest{BatchID: "b", AgentID: "agent-2", Results: []orcmodel.AgentResultItem{item}}
+	if _, err := svc.SubmitBatch(context.Background(), "agent-1", req); !errors.Is(err, ErrResultBatchAgentMismatch) {
+		t.Errorf("expected ErrResultBatchAgentMismatch, got %v", err)
+	}
+}
+
+func TestResultTargetType(t *testing.T) {
+	cases := map[string]string{
+		"":                         "",
+		"10.0.0.1":                 "ip",
+		"10.0.0.1:8080":            "ip",
+		"10.0.0.0/24":              "ip_range",
+		"10.0.0.1-10.0.0.9":        "ip_range",
+		"https://example.com/path": "url",
+		"example.com":              "domain",
+		"my-host.example.com":      "domain",
+	}
+	for target, want := range cases {
+		if got := resultTargetType(target); got != want {
+			t.Errorf("resultTargetType(%q) = %q, want %q", target, got, want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+)
+
+// ErrInvalidRunID 执行轮次非法 (不存在或两轮相同)
+var ErrInvalidRunID = errors.New("invalid run id")
+
+// scanDiffMaxItems 每类差异最多返回的条数，完整数量见 ScanDiff.Summary
+const scanDiffMaxItems = 1000
+
+// stageResultFindingKey 发现标识: sha256(结果类型|目标类型|目标值|端口|协议)
+// 同一目标在不同轮次产生的同类结果视为同一个发现；端口级结果(如每个开放端口一条)按端口区分，
+// 比对时能识别已知主机上新开放或关闭的端口。非端口级结果端口为 0、协议为空
+func stageResultFindingKey(result *orcmodel.StageResult) string {
+	raw := strings.Join([]string{
+		strings.TrimSpace(result.ResultType),
+		strings.TrimSpace(result.TargetType),
+		strings.ToLower(strings.TrimSpace(result.TargetValue)),
+		strconv.Itoa(result.Port),
+		result.Proto,
+	}, "|")
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// stageResultPort 从结构化属性中提取端口级结果的端口与协议
+// 属性为 JSON 对象且顶层带有 port (Agent 端口服务扫描为每个端口上报一条) 时为端口级结果，
+// 协议取 protocol 或 proto 字段，转小写，缺省为 tcp；其他结果返回 0 与空串
+func stageResultPort(attributes string) (int, string) {
+	var attr struct {
+		Port     int    `json:"port"`
+		Protocol string `json:"protocol"`
+		Proto    string `json:"proto"`
+	}
+	content := strings.TrimSpace(attributes)
+	if !strings.HasPrefix(content, "{") || json.Unmarshal([]byte(content), &attr) != nil || attr.Port <= 0 || attr.Port > 65535 {
+		return 0, ""
+	}
+	proto := attr.Protocol
+	if proto == "" {
+		proto = attr.Proto
+	}
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if proto == "" {
+		proto = "tcp"
+	}
+	return attr.Port, proto
+}
+
+// setFindingIdentity 写入结果的端口、协议、发现标识与属性指纹
+// 调用方未指定端口时从结构化属性中提取
+func setFindingIdentity(result *orcmodel.StageResult) {
+	if result.Port == 0 {
+		result.Port, result.Proto = stageResultPort(result.Attributes)
+	}
+	result.FindingKey = stageResultFindingKey(result)
+	result.ContentHash = stageResultContentHash(result.Attributes)
+}
+
+// stageResultContentHash 结构化属性指纹
+// 先按 JSON 重新编码 (对象键排序、去除空白)，避免键顺序不同被误判为变化；非法 JSON 按原文计算
+func stageResultContentHash(attributes string) string {
+	content := strings.TrimSpace(attributes)
+	var v interface{}
+	if content != "" && json.Unmarshal([]byte(content), &v) == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			content = string(canonical)
+		}
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// DiffProjectRuns 比对项目两轮执行的结果
+// 集合运算 (新增/消失/变化) 在数据库中完成，每类最多返回 scanDiffMaxItems 条
+func (s *StageResultService) DiffProjectRuns(ctx context.Context, projectID uint64, runA, runB int64) (*orcmodel.ScanDiff, error) {
+	project, err := s.projectRepo.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+	if runA <= 0 || runB <= 0 || runA == runB || runA > project.RunSeq || runB > project.RunSeq {
+		return nil, fmt.Errorf("%w: run_a=%d run_b=%d (project has %d runs)", ErrInvalidRunID, runA, runB, project.RunSeq)
+	}
+
+	logFields := map[string]interface{}{
+		"operation":  "diff_project_runs",
+		"project_id": projectID,
+		"run_a":      runA,
+		"run_b":      runB,
+	}
+	diff := &orcmodel.ScanDiff{ProjectID: projectID, RunA: runA, RunB: runB}
+
+	added, addedTotal, err := s.repo.ListFindingsOnlyInRun(ctx, projectID, runB, runA, scanDiffMaxItems)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "diff_project_runs", "SERVICE", logFields)
+		return nil, err
+	}
+	removed, removedTotal, err := s.repo.ListFindingsOnlyInRun(ctx, projectID, runA, runB, scanDiffMaxItems)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "diff_project_runs", "SERVICE", logFields)
+		return nil, err
+	}
+	changed, changedTotal, err := s.repo.ListChangedFindings(ctx, projectID, runA, runB, scanDiffMaxItems)
+	if err != nil {
+		logger.LogBusinessError(err, "", 0, "", "diff_project_runs", "SERVICE", logFields)
+		return nil, err
+	}
+
+	diff.Summary = orcmodel.ScanDiffCounts{Added: addedTotal, Removed: removedTotal, Changed: changedTotal}
+	diff.Truncated = addedTotal > int64(len(added)) || removedTotal > int64(len(removed)) || changedTotal > int64(len(changed))
+	diff.Added = make([]*orcmodel.FindingDiff, 0, len(added))
+	for _, r := range added {
+		diff.Added = append(diff.Added, newFindingDiff(nil, r))
+	}
+	diff.Removed = make([]*orcmodel.FindingDiff, 0, len(removed))
+	for _, r := range removed {
+		diff.Removed = append(diff.Removed, newFindingDiff(r, nil))
+	}
+	diff.Changed = make([]*orcmodel.FindingDiff, 0, len(changed))
+	for _, c := range changed {
+		diff.Changed = append(diff.Changed, newFindingDiff(c.Before, c.After))
+	}
+	return diff, nil
+}
+
+// newFindingDiff 由两轮中的结果构造差异项，before/after 至少一个不为空
+func newFindingDiff(before, after *orcmodel.StageResult) *orcmodel.FindingDiff {
+	ref := after
+	if ref == nil {
+		ref = before
+	}
+	d := &orcmodel.FindingDiff{
+		ResultType:  ref.ResultType,
+		TargetType:  ref.TargetType,
+		TargetValue: ref.TargetValue,
+		Port:        ref.Port,
+		Proto:       ref.Proto,
+	}
+	if before != nil {
+		d.Before = before.Attributes
+	}
+	if after != nil {
+		d.After = after.Attributes
+	}
+	return d
+}
@@ -0,0 +1,141 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newScanDiffTestService(t *testing.T) (*StageResultService, *orcrepo.ProjectRepository, orcrepo.TaskRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err = db.AutoMigrate(&orcmodel.Project{}, &orcmodel.AgentTask{}, &orcmodel.StageResult{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	projectRepo := orcrepo.NewProjectRepository(db)
+	taskRepo := orcrepo.NewTaskRepository(db)
+	svc := NewStageResultService(orcrepo.NewStageResultRepository(db), nil, taskRepo, projectRepo)
+	return svc, projectRepo, taskRepo
+}
+
+func TestStageResultContentHash(t *testing.T) {
+	if stageResultContentHash(`{"port":22,"proto":"tcp"}`) != stageResultContentHash(`{ "proto": "tcp", "port": 22 }`) {
+		t.Error("key order and whitespace should not change the content hash")
+	}
+	if stageResultContentHash(`{"port":22}`) == stageResultContentHash(`{"port":23}`) {
+		t.Error("different attributes should have different hashes")
+	}
+}
+
+func TestStageResultPort(t *testing.T) {
+	for attributes, want := range map[string]struct {
+		port  int
+		proto string
+	}{
+		`{"ip":"10.0.0.1","port":53,"protocol":"UDP"}`: {53, "udp"},
+		`{"port":22,"proto":"tcp"}`:                    {22, "tcp"},
+		`{"port":8080}`:                                {8080, "tcp"},
+		`{"ports":[22,80]}`:                            {0, ""},
+		`[{"port":22}]`:                                {0, ""},
+		`{"port":70000}`:                               {0, ""},
+		`not json`:                                     {0, ""},
+	} {
+		if port, proto := stageResultPort(attributes); port != want.port || proto != want.proto {
+			t.Errorf("stageResultPort(%s) = %d/%q, want %d/%q", attributes, port, proto, want.port, want.proto)
+		}
+	}
+}
+
+func TestDiffProjectRuns(t *testing.T) {
+	ctx := context.Background()
+	svc, projectRepo, taskRepo := newScanDiffTestService(t)
+
+	project := &orcmodel.Project{Name: "weekly", Status: orcmodel.ProjectStatusIdle}
+	if err := projectRepo.CreateProject(ctx, project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	// 两轮执行，每轮一个任务，结果按任务归属轮次
+	record := func(taskID, resultType, target, attributes string) {
+		t.Helper()
+		err := svc.CreateResult(ctx, &orcmodel.StageResult{
+			ProjectID: project.ID, WorkflowID: 1, StageID: 1, TaskID: taskID,
+			ResultType: resultType, TargetType: "ip", TargetValue: target, Attributes: attributes,
+		})
+		if err != nil {
+			t.Fatalf("create result: %v", err)
+		}
+	}
+	for run, taskID := range map[int64]string{1: "run1-task", 2: "run2-task"} {
+		task := &orcmodel.AgentTask{TaskID: taskID, ProjectID: project.ID, WorkflowID: 1, StageID: 1, RunID: run}
+		if err := taskRepo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+	if started, err := projectRepo.StartProjectRun(ctx, project.ID, orcmodel.ProjectStatusIdle, time.Now()); err != nil || !started {
+		t.Fatalf("start run 1: %v %v", started, err)
+	}
+	if _, err := projectRepo.UpdateProjectStatus(ctx, project.ID, orcmodel.ProjectStatusRunning, orcmodel.ProjectStatusFinished); err != nil {
+		t.Fatalf("finish run 1: %v", err)
+	}
+	if _, err := projectRepo.StartProjectRun(ctx, project.ID, orcmodel.ProjectStatusFinished, time.Now()); err != nil {
+		t.Fatalf("start run 2: %v", err)
+	}
+
+	record("run1-task", "ip_alive", "10.0.0.1", `{"alive":true}`)
+	record("run1-task", "fast_port_scan", "10.0.0.1", `{"ports":[22]}`)
+	record("run1-task", "ip_alive", "10.0.0.2", `{"alive":true}`)
+	record("run2-task", "ip_alive", "10.0.0.1", `{ "alive": true }`)
+	record("run2-task", "fast_port_scan", "10.0.0.1", `{"ports":[22]}`)
+	// 同一轮次内重复产生的结果只取最新一条
+	record("run2-task", "fast_port_scan", "10.0.0.1", `{"ports":[22,3389]}`)
+	record("run2-task", "ip_alive", "10.0.0.3", `{"alive":true}`)
+	// 端口级结果按端口区分: 已知主机上新开放 443、关闭 80
+	record("run1-task", "port_scan", "10.0.0.5", `{"ip":"10.0.0.5","port":22,"protocol":"tcp","status":"Open"}`)
+	record("run1-task", "port_scan", "10.0.0.5", `{"ip":"10.0.0.5","port":80,"protocol":"tcp","status":"Open"}`)
+	record("run2-task", "port_scan", "10.0.0.5", `{"ip":"10.0.0.5","port":22,"protocol":"tcp","status":"Open"}`)
+	record("run2-task", "port_scan", "10.0.0.5", `{"ip":"10.0.0.5","port":443,"protocol":"tcp","status":"Open"}`)
+
+	diff, err := svc.DiffProjectRuns(ctx, project.ID, 1, 2)
+	if err != nil {
+		t.Fatalf("DiffProjectRuns: %v", err)
+	}
+	if diff.Summary != (orcmodel.ScanDiffCounts{Added: 2, Removed: 2, Changed: 1}) {
+		t.Fatalf("summary = %+v, want 2/2/1", diff.Summary)
+	}
+	if diff.Added[0].TargetValue != "10.0.0.3" || diff.Removed[0].TargetValue != "10.0.0.2" {
+		t.Errorf("added = %+v, removed = %+v", diff.Added[0], diff.Removed[0])
+	}
+	if opened := diff.Added[1]; opened.TargetValue != "10.0.0.5" || opened.Port != 443 || opened.Proto != "tcp" {
+		t.Errorf("opened port = %+v, want 10.0.0.5 443/tcp", opened)
+	}
+	if closed := diff.Removed[1]; closed.TargetValue != "10.0.0.5" || closed.Port != 80 || closed.Proto != "tcp" {
+		t.Errorf("closed port = %+v, want 10.0.0.5 80/tcp", closed)
+	}
+	changed := diff.Changed[0]
+	if changed.ResultType != "fast_port_scan" || changed.Before != `{"ports":[22]}` || changed.After != `{"ports":[22,3389]}` {
+		t.Errorf("changed = %+v", changed)
+	}
+	if diff.Truncated {
+		t.Error("diff should not be truncated")
+	}
+
+	for _, runs := range [][2]int64{{1, 1}, {0, 2}, {1, 3}} {
+		if _, err = svc.DiffProjectRuns(ctx, project.ID, runs[0], runs[1]); !errors.Is(err, ErrInvalidRunID) {
+			t.Errorf("DiffProjectRuns(%v) error = %v, want ErrInvalidRunID", runs, err)
+		}
+	}
+	if _, err = svc.DiffProjectRuns(ctx, project.ID+1, 1, 2); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("missing project error = %v, want ErrProjectNotFound", err)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/model/system"
 	"neomaster/internal/pkg/logger"
 	orcrepo "neomaster/internal/repo/mysql/orchestrator"
 )
 
+// errTemplateNotFound 模板不存在
+var errTemplateNotFound = system.NewCodedError(system.ErrCodeTemplateNotFound, errors.New("template not found"))
+
 // ScanToolTemplateService 扫描工具模板服务
 // 负责处理扫描工具模板的业务逻辑
 type ScanToolTemplateService struct {
@@ -24,6 +28,9 @@ func (s *ScanToolTemplateService) CreateTemplate(ctx context.Context, tmpl *orcm
 	if tmpl == nil {
 		return errors.New("template data cannot be nil")
 	}
+	if err := ValidateToolTemplate(tmpl); err != nil {
+		return err
+	}
 	err := s.repo.CreateTemplate(ctx, tmpl)
 	if err != nil {
 		logger.LogBusinessError(err, "", 0, "", "create_template", "SERVICE", map[string]interface{}{
@@ -46,7 +53,7 @@ func (s *ScanToolTemplateService) GetTemplate(ctx context.Context, id uint64) (*
 		return nil, err
 	}
 	if tmpl == nil {
-		return nil, errors.New("template not found")
+		return nil, errTemplateNotFound
 	}
 	return tmpl, nil
 }
@@ -56,12 +63,15 @@ func (s *ScanToolTemplateService) UpdateTemplate(ctx context.Context, tmpl *orcm
 	if tmpl == nil {
 		return errors.New("template data cannot be nil")
 	}
+	if err := ValidateToolTemplate(tmpl); err != nil {
+		return err
+	}
 	existing, err := s.repo.GetTemplateByID(ctx, tmpl.ID)
 	if err != nil {
 		return err
 	}
 	if existing == nil {
-		return errors.New("template not found")
+		return errTemplateNotFound
 	}
 
 	err = s.repo.UpdateTemplate(ctx, tmpl)
@@ -82,7 +92,7 @@ func (s *ScanToolTemplateService) DeleteTemplate(ctx context.Context, id uint64)
 		return err
 	}
 	if existing == nil {
-		return errors.New("template not found")
+		return errTemplateNotFound
 	}
 
 	err = s.repo.DeleteTemplate(ctx, id)
@@ -0,0 +1,141 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+)
+
+// StageCycleError 阶段依赖成环错误
+// Cycle 按依赖方向列出成环的阶段ID，首尾相同，例如 [3 5 7 3] 表示 3->5->7->3
+type StageCycleError struct {
+	Cycle []uint64
+}
+
+// Error 实现error接口
+func (e *StageCycleError) Error() string {
+	parts := make([]string, 0, len(e.Cycle))
+	for _, id := range e.Cycle {
+		parts = append(parts, strconv.FormatUint(id, 10))
+	}
+	return fmt.Sprintf("stage dependency cycle detected: %s", strings.Join(parts, " -> "))
+}
+
+// BuildStageExecutionPlan 校验阶段依赖构成 DAG，并计算分层拓扑执行顺序
+// 返回值中每一层(group)内的阶段互不依赖，可并行执行；层与层之间必须按顺序执行
+// 依赖关系来自 ScanStage.Predecessors，为空表示起始节点
+// 1. 前置依赖指向不存在(或不属于同一工作流)的阶段时返回错误
+// 2. 存在环时返回 *StageCycleError，包含成环的阶段ID
+func BuildStageExecutionPlan(stages []*orcmodel.ScanStage) ([][]*orcmodel.ScanStage, error) {
+	byID := make(map[uint64]*orcmodel.ScanStage, len(stages))
+	for _, stage := range stages {
+		byID[stage.ID] = stage
+	}
+
+	// 入度表 & 后继表
+	inDegree := make(map[uint64]int, len(stages))
+	successors := make(map[uint64][]uint64, len(stages))
+	for _, stage := range stages {
+		seen := make(map[uint64]struct{}, len(stage.Predecessors))
+		for _, pid := range stage.Predecessors {
+			if pid == stage.ID {
+				return nil, &StageCycleError{Cycle: []uint64{stage.ID, stage.ID}}
+			}
+			if _, ok := byID[pid]; !ok {
+				return nil, fmt.Errorf("stage %d depends on unknown stage %d", stage.ID, pid)
+			}
+			if _, dup := seen[pid]; dup {
+				continue
+			}
+			seen[pid] = struct{}{}
+			inDegree[stage.ID]++
+			successors[pid] = append(successors[pid], stage.ID)
+		}
+	}
+
+	// Kahn 算法按层剥离入度为 0 的节点
+	var current []uint64
+	for _, stage := range stages {
+		if inDegree[stage.ID] == 0 {
+			current = append(current, stage.ID)
+		}
+	}
+
+	var plan [][]*orcmodel.ScanStage
+	visited := 0
+	for len(current) > 0 {
+		sort.Slice(current, func(i, j int) bool { return current[i] < current[j] })
+		group := make([]*orcmodel.ScanStage, 0, len(current))
+		var next []uint64
+		for _, id := range current {
+			group = append(group, byID[id])
+			visited++
+			for _, sid := range successors[id] {
+				inDegree[sid]--
+				if inDegree[sid] == 0 {
+					next = append(next, sid)
+				}
+			}
+		}
+		plan = append(plan, group)
+		current = next
+	}
+
+	if visited != len(stages) {
+		return nil, &StageCycleError{Cycle: findStageCycle(stages, inDegree)}
+	}
+	return plan, nil
+}
+
+// findStageCycle 在 Kahn 算法剩余的节点(入度仍大于 0)中查找一个具体的环
+// 剩余节点每个都至少有一个同样剩余的前置依赖，沿前置依赖回溯必然回到已访问节点
+func findStageCycle(stages []*orcmodel.ScanStage, inDegree map[uint64]int) []uint64 {
+	remaining := make(map[uint64]*orcmodel.ScanStage)
+	var start uint64
+	for _, stage := range stages {
+		if inDegree[stage.ID] > 0 {
+			remaining[stage.ID] = stage
+			if start == 0 || stage.ID < start {
+				start = stage.ID
+			}
+		}
+	}
+
+	position := make(map[uint64]int)
+	var path []uint64
+	current := start
+	for {
+		if idx, ok := position[current]; ok {
+			// path[idx:] 沿"前置依赖"方向，反转为依赖执行方向，并以最小ID起始保证输出稳定
+			cycle := make([]uint64, 0, len(path)-idx+1)
+			minPos := 0
+			for i := len(path) - 1; i >= idx; i-- {
+				cycle = append(cycle, path[i])
+				if path[i] < cycle[minPos] {
+					minPos = len(cycle) - 1
+				}
+			}
+			rotated := make([]uint64, 0, len(cycle)+1)
+			rotated = append(rotated, cycle[minPos:]...)
+			rotated = append(rotated, cycle[:minPos]...)
+			return append(rotated, rotated[0])
+		}
+		position[current] = len(path)
+		path = append(path, current)
+
+		next := uint64(0)
+		for _, pid := range remaining[current].Predecessors {
+			if _, ok := remaining[pid]; ok {
+				next = pid
+				break
+			}
+		}
+		if next == 0 {
+			return path
+		}
+		current = next
+	}
+}
@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"neomaster/internal/model/basemodel"
+	orcmodel "neomaster/internal/model/orchestrator"
+)
+
+func newStage(id uint64, predecessors ...uint64) *orcmodel.ScanStage {
+	return &orcmodel.ScanStage{
+		BaseModel:    basemodel.BaseModel{ID: id},
+		Predecessors: predecessors,
+	}
+}
+
+func planIDs(plan [][]*orcmodel.ScanStage) [][]uint64 {
+	ids := make([][]uint64, 0, len(plan))
+	for _, group := range plan {
+		row := make([]uint64, 0, len(group))
+		for _, stage := range group {
+			row = append(row, stage.ID)
+		}
+		ids = append(ids, row)
+	}
+	return ids
+}
+
+func TestBuildStageExecutionPlan_ParallelBranches(t *testing.T) {
+	// 1:ipAlive -> 2:serviceScan -> {3:webScan, 4:dirScan} -> 5:report
+	stages := []*orcmodel.ScanStage{
+		newStage(5, 3, 4),
+		newStage(1),
+		newStage(2, 1),
+		newStage(4, 2),
+		newStage(3, 2),
+	}
+
+	plan, err := BuildStageExecutionPlan(stages)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := [][]uint64{{1}, {2}, {3, 4}, {5}}
+	if got := planIDs(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+}
+
+func TestBuildStageExecutionPlan_Cycle(t *testing.T) {
+	// 1 -> 2 -> 3 -> 4 -> 2
+	stages := []*orcmodel.ScanStage{
+		newStage(1),
+		newStage(2, 1, 4),
+		newStage(3, 2),
+		newStage(4, 3),
+	}
+
+	_, err := BuildStageExecutionPlan(stages)
+	var cycleErr *StageCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("expected StageCycleError, got %v", err)
+	}
+
+	want := []uint64{2, 3, 4, 2}
+	if !reflect.DeepEqual(cycleErr.Cycle, want) {
+		t.Errorf("cycle = %v, want %v", cycleErr.Cycle, want)
+	}
+}
+
+func TestBuildStageExecutionPlan_SelfAndUnknownDependency(t *testing.T) {
+	if _, err := BuildStageExecutionPlan([]*orcmodel.ScanStage{newStage(1, 1)}); err == nil {
+		t.Error("expected error for self dependency")
+	}
+	if _, err := BuildStageExecutionPlan([]*orcmodel.ScanStage{newStage(1, 9)}); err == nil {
+		t.Error("expected error for unknown predecessor")
+	}
+}
@@ -11,12 +11,20 @@ import (
 
 // StageResultService 阶段结果服务
 type StageResultService struct {
-	repo *orcrepo.StageResultRepository
+	repo        *orcrepo.StageResultRepository
+	stageRepo   *orcrepo.ScanStageRepository // 项目汇总: 获取项目关联的阶段
+	taskRepo    orcrepo.TaskRepository       // 项目汇总: 统计阶段任务执行情况
+	projectRepo *orcrepo.ProjectRepository   // 项目进度事件: 获取项目运行状态
 }
 
 // NewStageResultService 创建 StageResultService 实例
-func NewStageResultService(repo *orcrepo.StageResultRepository) *StageResultService {
-	return &StageResultService{repo: repo}
+func NewStageResultService(repo *orcrepo.StageResultRepository, stageRepo *orcrepo.ScanStageRepository, taskRepo orcrepo.TaskRepository, projectRepo *orcrepo.ProjectRepository) *StageResultService {
+	return &StageResultService{
+		repo:        repo,
+		stageRepo:   stageRepo,
+		taskRepo:    taskRepo,
+		projectRepo: projectRepo,
+	}
 }
 
 // CreateResult 记录扫描结果
@@ -27,7 +35,18 @@ func (s *StageResultService) CreateResult(ctx context.Context, result *orcmodel.
 	if result.ProducedAt.IsZero() {
 		result.ProducedAt = time.Now()
 	}
-	
+	// 结果归属的执行轮次以任务为准
+	if result.RunID == 0 && result.TaskID != "" && s.taskRepo != nil {
+		task, err := s.taskRepo.GetTaskByID(ctx, result.TaskID)
+		if err != nil {
+			return err
+		}
+		if task != nil {
+			result.RunID = task.RunID
+		}
+	}
+	setFindingIdentity(result)
+
 	err := s.repo.CreateResult(ctx, result)
 	if err != nil {
 		logger.LogBusinessError(err, "", 0, "", "create_stage_result", "SERVICE", map[string]interface{}{
@@ -0,0 +1,318 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/model/system"
+)
+
+// FlagValueMode 参数取值方式
+type FlagValueMode int
+
+const (
+	FlagNoValue       FlagValueMode = iota // 开关参数，不带值 (如 -sS)
+	FlagRequiresValue                      // 必须带值: "-p 80" / "--rate=1000"，短参数也可直接拼接 "-p80"
+)
+
+// ToolFlagSpec 单个参数的定义
+type ToolFlagSpec struct {
+	Names     []string       // 参数名及别名 (如 "-p", "--ports")
+	Value     FlagValueMode  // 取值方式
+	Pattern   *regexp.Regexp // 可选: 值的格式校验
+	Dangerous string         // 非空表示危险参数，直接拒绝，内容为拒绝原因
+}
+
+// ToolParamSchema 工具参数白名单
+// 未在 Flags 中声明的参数一律视为非法
+type ToolParamSchema struct {
+	Flags []ToolFlagSpec
+	// AllowPositional 是否允许位置参数。扫描目标由 TargetPolicy 提供，模板中默认不允许出现
+	AllowPositional bool
+}
+
+// ToolTemplateValidationError 工具模板参数校验失败
+type ToolTemplateValidationError struct {
+	ToolName string
+	Problems []string
+}
+
+// ErrorCode 错误码，供 APIResponse.ErrorCode 使用
+func (e *ToolTemplateValidationError) ErrorCode() string {
+	return system.ErrCodeInvalidToolParams
+}
+
+// Error 实现error接口
+func (e *ToolTemplateValidationError) Error() string {
+	if e.ToolName == "" {
+		return fmt.Sprintf("invalid tool template: %s", strings.Join(e.Problems, "; "))
+	}
+	return fmt.Sprintf("invalid %s params: %s", e.ToolName, strings.Join(e.Problems, "; "))
+}
+
+var (
+	toolParamSchemasMu sync.RWMutex
+	toolParamSchemas   = map[string]*ToolParamSchema{
+		"nmap":    nmapParamSchema,
+		"masscan": masscanParamSchema,
+	}
+)
+
+// RegisterToolParamSchema 注册(或覆盖)工具参数白名单，工具名不区分大小写
+// 新增工具只需注册对应 schema，无需修改校验逻辑
+func RegisterToolParamSchema(toolName string, schema *ToolParamSchema) {
+	toolParamSchemasMu.Lock()
+	defer toolParamSchemasMu.Unlock()
+	toolParamSchemas[strings.ToLower(toolName)] = schema
+}
+
+func getToolParamSchema(toolName string) *ToolParamSchema {
+	toolParamSchemasMu.RLock()
+	defer toolParamSchemasMu.RUnlock()
+	return toolParamSchemas[strings.ToLower(toolName)]
+}
+
+// ValidateToolTemplate 校验工具模板参数
+// 1. ToolName 必填
+// 2. 已注册 schema 的工具: ToolParams 中的参数必须在白名单内，危险参数直接拒绝
+// 3. 未注册 schema 的工具不做参数校验
+// 校验失败返回 *ToolTemplateValidationError，包含全部问题
+func ValidateToolTemplate(t *orcmodel.ScanToolTemplate) error {
+	if t == nil {
+		return errors.New("template data cannot be nil")
+	}
+	toolName := strings.TrimSpace(t.ToolName)
+	if toolName == "" {
+		return &ToolTemplateValidationError{Problems: []string{"tool name is required"}}
+	}
+	schema := getToolParamSchema(toolName)
+	if schema == nil {
+		return nil
+	}
+
+	args, err := splitToolParams(t.ToolParams)
+	if err != nil {
+		return &ToolTemplateValidationError{ToolName: toolName, Problems: []string{err.Error()}}
+	}
+	if problems := schema.check(args); len(problems) > 0 {
+		return &ToolTemplateValidationError{ToolName: toolName, Problems: problems}
+	}
+	return nil
+}
+
+// check 按白名单逐个检查参数
+func (s *ToolParamSchema) check(args []string) []string {
+	var problems []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// 模板占位符 (如 {{.Target}}) 由命令构建阶段填充，不做校验
+		if strings.Contains(arg, "{{") {
+			continue
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if !s.AllowPositional {
+				problems = append(problems, fmt.Sprintf("unexpected argument %q (targets come from the stage target policy)", arg))
+			}
+			continue
+		}
+
+		name, value, hasValue := arg, "", false
+		if strings.HasPrefix(arg, "--") {
+			if idx := strings.Index(arg, "="); idx > 0 {
+				name, value, hasValue = arg[:idx], arg[idx+1:], true
+			}
+		}
+
+		spec := s.lookup(name)
+		if spec == nil && !strings.HasPrefix(arg, "--") {
+			// 短参数直接拼接值: -p1-1000 / -T4 / -PS22,80
+			if prefixSpec, prefix := s.lookupAttached(arg); prefixSpec != nil {
+				spec, name, value, hasValue = prefixSpec, prefix, arg[len(prefix):], true
+			}
+		}
+		if spec == nil {
+			problems = append(problems, fmt.Sprintf("unknown flag %q", name))
+			continue
+		}
+		if spec.Dangerous != "" {
+			problems = append(problems, fmt.Sprintf("flag %q is not allowed: %s", name, spec.Dangerous))
+			if spec.Value == FlagRequiresValue && !hasValue && i+1 < len(args) {
+				i++ // 跳过其取值，避免被当作位置参数重复报错
+			}
+			continue
+		}
+
+		switch spec.Value {
+		case FlagNoValue:
+			if hasValue {
+				problems = append(problems, fmt.Sprintf("flag %q does not take a value", name))
+			}
+		case FlagRequiresValue:
+			if !hasValue {
+				if i+1 >= len(args) {
+					problems = append(problems, fmt.Sprintf("flag %q requires a value", name))
+					continue
+				}
+				i++
+				value = args[i]
+			}
+			if spec.Pattern != nil && !strings.Contains(value, "{{") && !spec.Pattern.MatchString(value) {
+				problems = append(problems, fmt.Sprintf("invalid value %q for flag %q", value, name))
+			}
+		}
+	}
+	return problems
+}
+
+// lookup 精确匹配参数名
+func (s *ToolParamSchema) lookup(name string) *ToolFlagSpec {
+	for i := range s.Flags {
+		for _, n := range s.Flags[i].Names {
+			if n == name {
+				return &s.Flags[i]
+			}
+		}
+	}
+	return nil
+}
+
+// lookupAttached 匹配值直接拼接在短参数后的写法，取最长前缀
+func (s *ToolParamSchema) lookupAttached(arg string) (*ToolFlagSpec, string) {
+	var best *ToolFlagSpec
+	bestName := ""
+	for i := range s.Flags {
+		spec := &s.Flags[i]
+		if spec.Value != FlagRequiresValue {
+			continue
+		}
+		for _, n := range spec.Names {
+			if strings.HasPrefix(n, "--") || len(n) <= len(bestName) || len(arg) <= len(n) {
+				continue
+			}
+			if strings.HasPrefix(arg, n) {
+				best, bestName = spec, n
+			}
+		}
+	}
+	return best, bestName
+}
+
+// splitToolParams 按空白切分命令行参数，支持单/双引号
+func splitToolParams(params string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+	for _, r := range params {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in tool params")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+var (
+	portListPattern = regexp.MustCompile(`^[0-9TU:,\-]+$`) // 22,80,1-1000,T:80,U:53
+	numberPattern   = regexp.MustCompile(`^[0-9]+$`)
+	durationPattern = regexp.MustCompile(`^[0-9]+(ms|s|m|h)?$`)
+	ratePattern     = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+const (
+	reasonInputFile  = "reads targets or settings from a file on the agent host"
+	reasonOutputFile = "output files are managed by the agent"
+)
+
+// nmapParamSchema nmap 参数白名单
+// 未包含 SCTP 扫描(-sY/-sZ)、空闲扫描等 Agent 不支持的扫描方式
+var nmapParamSchema = &ToolParamSchema{
+	Flags: []ToolFlagSpec{
+		// 扫描方式
+		{Names: []string{"-sS"}}, {Names: []string{"-sT"}}, {Names: []string{"-sU"}},
+		{Names: []string{"-sA"}}, {Names: []string{"-sW"}}, {Names: []string{"-sM"}},
+		{Names: []string{"-sN"}}, {Names: []string{"-sF"}}, {Names: []string{"-sX"}},
+		{Names: []string{"-sn"}}, {Names: []string{"-sV"}}, {Names: []string{"-sC"}},
+		// 主机发现
+		{Names: []string{"-Pn"}}, {Names: []string{"-PE"}}, {Names: []string{"-PP"}}, {Names: []string{"-PM"}},
+		{Names: []string{"-PS", "-PA", "-PU"}, Value: FlagRequiresValue, Pattern: portListPattern},
+		{Names: []string{"-n"}}, {Names: []string{"-R"}}, {Names: []string{"--system-dns"}},
+		{Names: []string{"--traceroute"}},
+		// 端口
+		{Names: []string{"-p"}, Value: FlagRequiresValue, Pattern: portListPattern},
+		{Names: []string{"--exclude-ports"}, Value: FlagRequiresValue, Pattern: portListPattern},
+		{Names: []string{"--top-ports"}, Value: FlagRequiresValue, Pattern: numberPattern},
+		{Names: []string{"-F"}}, {Names: []string{"-r"}},
+		// 服务/系统识别
+		{Names: []string{"-O"}}, {Names: []string{"-A"}},
+		{Names: []string{"--osscan-limit"}}, {Names: []string{"--osscan-guess"}},
+		{Names: []string{"--version-intensity"}, Value: FlagRequiresValue, Pattern: regexp.MustCompile(`^[0-9]$`)},
+		{Names: []string{"--version-light"}}, {Names: []string{"--version-all"}},
+		// 脚本
+		{Names: []string{"--script"}, Value: FlagRequiresValue, Pattern: regexp.MustCompile(`^[A-Za-z0-9_\-,*]+$`)},
+		{Names: []string{"--script-args"}, Value: FlagRequiresValue},
+		{Names: []string{"--script-timeout"}, Value: FlagRequiresValue, Pattern: durationPattern},
+		// 时序与性能
+		{Names: []string{"-T"}, Value: FlagRequiresValue, Pattern: regexp.MustCompile(`^[0-5]$`)},
+		{Names: []string{"--min-rate", "--max-rate"}, Value: FlagRequiresValue, Pattern: ratePattern},
+		{Names: []string{"--max-retries", "--min-hostgroup", "--max-hostgroup", "--min-parallelism", "--max-parallelism"}, Value: FlagRequiresValue, Pattern: numberPattern},
+		{Names: []string{"--host-timeout", "--scan-delay", "--max-scan-delay", "--initial-rtt-timeout", "--min-rtt-timeout", "--max-rtt-timeout"}, Value: FlagRequiresValue, Pattern: durationPattern},
+		{Names: []string{"--defeat-rst-ratelimit"}},
+		// 其他
+		{Names: []string{"-6"}}, {Names: []string{"-v"}}, {Names: []string{"-vv"}},
+		{Names: []string{"--open"}}, {Names: []string{"--reason"}},
+		{Names: []string{"--ttl", "--data-length"}, Value: FlagRequiresValue, Pattern: numberPattern},
+		{Names: []string{"-g", "--source-port"}, Value: FlagRequiresValue, Pattern: numberPattern},
+		// 危险参数
+		{Names: []string{"-iL", "--excludefile"}, Value: FlagRequiresValue, Dangerous: reasonInputFile},
+		{Names: []string{"--resume", "--datadir", "--servicedb", "--versiondb", "--script-args-file", "--stylesheet"}, Value: FlagRequiresValue, Dangerous: reasonInputFile},
+		{Names: []string{"-iR"}, Value: FlagRequiresValue, Dangerous: "scans random internet hosts"},
+		{Names: []string{"-oN", "-oX", "-oG", "-oA", "-oS"}, Value: FlagRequiresValue, Dangerous: reasonOutputFile},
+		{Names: []string{"--append-output"}, Dangerous: reasonOutputFile},
+	},
+}
+
+// masscanParamSchema masscan 参数白名单
+var masscanParamSchema = &ToolParamSchema{
+	Flags: []ToolFlagSpec{
+		{Names: []string{"-p", "--ports"}, Value: FlagRequiresValue, Pattern: portListPattern},
+		{Names: []string{"--top-ports"}, Value: FlagRequiresValue, Pattern: numberPattern},
+		{Names: []string{"--rate", "--max-rate"}, Value: FlagRequiresValue, Pattern: ratePattern},
+		{Names: []string{"--retries", "--wait", "--ttl", "--source-port", "--connection-timeout"}, Value: FlagRequiresValue, Pattern: numberPattern},
+		{Names: []string{"--exclude"}, Value: FlagRequiresValue},
+		{Names: []string{"--banners"}}, {Names: []string{"--ping"}}, {Names: []string{"--open"}}, {Names: []string{"--open-only"}},
+		// 危险参数
+		{Names: []string{"-iL", "--includefile", "--excludefile", "-c", "--conf", "--resume"}, Value: FlagRequiresValue, Dangerous: reasonInputFile},
+		{Names: []string{"-oX", "-oJ", "-oL", "-oG", "-oB", "-oD", "--output-filename"}, Value: FlagRequiresValue, Dangerous: reasonOutputFile},
+	},
+}
@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+)
+
+func TestValidateToolTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      string
+		params    string
+		wantErr   bool
+		errSubstr string
+	}{
+		{"nmap_syn_scan", "nmap", "-sS -T4 -p1-1000", false, ""},
+		{"nmap_version_os", "nmap", "-sV -O --top-ports 100 --max-retries=2", false, ""},
+		{"nmap_ping_probes", "nmap", "-Pn -PS22,80,443 --script 'http-title,ssl-cert'", false, ""},
+		{"nmap_placeholder", "nmap", "-sS -p {{.Ports}}", false, ""},
+		{"masscan_rate", "masscan", "-p1-65535 --rate=1000", false, ""},
+		{"masscan_banners", "MASSCAN", "--ports 80,443 --banners --wait 5", false, ""},
+		{"unregistered_tool", "nuclei", "-anything goes", false, ""},
+		{"nmap_unknown_scan_type", "nmap", "-sQ -p80", true, `unknown flag "-sQ"`},
+		{"nmap_input_file", "nmap", "-sS -iL /etc/passwd", true, `"-iL" is not allowed`},
+		{"nmap_input_file_attached", "nmap", "-iL/etc/hosts", true, `"-iL" is not allowed`},
+		{"nmap_output_file", "nmap", "-sS -oX /tmp/out.xml", true, `"-oX" is not allowed`},
+		{"nmap_bad_timing", "nmap", "-T9", true, `invalid value "9"`},
+		{"nmap_missing_value", "nmap", "-sS -p", true, `"-p" requires a value`},
+		{"nmap_positional_target", "nmap", "-sS 10.0.0.1", true, "unexpected argument"},
+		{"nmap_unterminated_quote", "nmap", "--script 'http-title", true, "unterminated quote"},
+		{"masscan_conf", "masscan", "-c /root/masscan.conf", true, `"-c" is not allowed`},
+		{"empty_tool_name", "", "-sS", true, "tool name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateToolTemplate(&orcmodel.ScanToolTemplate{ToolName: tt.tool, ToolParams: tt.params})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateToolTemplate(%q, %q) error = %v, wantErr %v", tt.tool, tt.params, err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var validationErr *ToolTemplateValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("expected ToolTemplateValidationError, got %T", err)
+			}
+			if !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.errSubstr)
+			}
+		})
+	}
+}
+
+func TestRegisterToolParamSchema(t *testing.T) {
+	RegisterToolParamSchema("httpx-test", &ToolParamSchema{
+		Flags: []ToolFlagSpec{{Names: []string{"-silent"}}},
+	})
+	defer func() {
+		toolParamSchemasMu.Lock()
+		delete(toolParamSchemas, "httpx-test")
+		toolParamSchemasMu.Unlock()
+	}()
+
+	if err := ValidateToolTemplate(&orcmodel.ScanToolTemplate{ToolName: "httpx-test", ToolParams: "-silent"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateToolTemplate(&orcmodel.ScanToolTemplate{ToolName: "httpx-test", ToolParams: "-json"}); err == nil {
+		t.Error("expected error for flag not in registered schema")
+	}
+}
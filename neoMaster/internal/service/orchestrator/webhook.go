@@ -0,0 +1,228 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	orcmodel "neomaster/internal/model/orchestrator"
+	"neomaster/internal/pkg/logger"
+	orcrepo "neomaster/internal/repo/mysql/orchestrator"
+	"neomaster/internal/service/notify/webhook"
+)
+
+// Webhook 订阅相关错误
+var (
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook subscription")
+)
+
+// webhookMinSecretLen 签名密钥最小长度，过短的密钥容易被暴力猜解
+const webhookMinSecretLen = 16
+
+// webhookMaxRecipients email 订阅的收件人上限
+const webhookMaxRecipients = 50
+
+// WebhookService Webhook 订阅管理服务
+// 只负责订阅的增删改查与投递记录查询，事件投递由 notify/webhook.Dispatcher 完成
+type WebhookService struct {
+	repo                 *orcrepo.WebhookRepository
+	allowPrivateNetworks bool // 是否允许订阅地址指向内网/回环地址，与投递配置保持一致
+}
+
+// NewWebhookService 创建 WebhookService 实例
+func NewWebhookService(repo *orcrepo.WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// SetAllowPrivateNetworks 设置是否允许订阅地址指向内网/回环地址 (app.master.webhook.allow_private_networks)
+func (s *WebhookService) SetAllowPrivateNetworks(allow bool) {
+	s.allowPrivateNetworks = allow
+}
+
+// validateWebhookSubscription 校验并规范化订阅配置
+// allowPrivate 为 false 时拒绝指向内网、回环、链路本地及云元数据地址的 URL (投递时拨号层会再次校验)
+func validateWebhookSubscription(ctx context.Context, sub *orcmodel.WebhookSubscription, allowPrivate bool) error {
+	sub.Name = strings.TrimSpace(sub.Name)
+	sub.URL = strings.TrimSpace(sub.URL)
+	sub.Type = strings.ToLower(strings.TrimSpace(sub.Type))
+	if sub.Type == "" {
+		sub.Type = orcmodel.WebhookTypeGeneric
+	}
+	if sub.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWebhook)
+	}
+	// email 类型仅在启用 mail 配置时注册
+	if _, ok := webhook.GetAdapter(sub.Type); !ok {
+		return fmt.Errorf("%w: unsupported type %q (supported: %v)", ErrInvalidWebhook, sub.Type, webhook.AdapterTypes())
+	}
+	if sub.Type == orcmodel.WebhookTypeEmail {
+		if err := normalizeRecipients(sub); err != nil {
+			return err
+		}
+	} else {
+		u, err := url.Parse(sub.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidWebhook)
+		}
+		if !allowPrivate {
+			if err = webhook.CheckURL(ctx, sub.URL); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+			}
+		}
+		sub.Recipients = nil
+		sub.AttachCSV = false
+	}
+	// generic 订阅依赖签名鉴别来源，必须配置密钥；聊天平台的密钥可选(如钉钉加签)
+	if sub.Type == orcmodel.WebhookTypeGeneric && len(sub.Secret) < webhookMinSecretLen {
+		return fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidWebhook, webhookMinSecretLen)
+	}
+
+	seen := make(map[string]bool, len(sub.EventTypes))
+	eventTypes := make([]string, 0, len(sub.EventTypes))
+	for _, t := range sub.EventTypes {
+		t = strings.TrimSpace(t)
+		if seen[t] {
+			continue
+		}
+		known := false
+		for _, supported := range orcmodel.WebhookEventTypes {
+			if t == supported {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: unsupported event type %q (supported: %v)", ErrInvalidWebhook, t, orcmodel.WebhookEventTypes)
+		}
+		seen[t] = true
+		eventTypes = append(eventTypes, t)
+	}
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+	sub.EventTypes = eventTypes
+	return nil
+}
+
+// normalizeRecipients 校验 email 订阅的收件人并去重，email 订阅不使用 URL
+func normalizeRecipients(sub *orcmodel.WebhookSubscription) error {
+	sub.URL = ""
+	seen := make(map[string]bool, len(sub.Recipients))
+	recipients := make([]string, 0, len(sub.Recipients))
+	for _, r := range sub.Recipients {
+		addr, err := mail.ParseAddress(strings.TrimSpace(r))
+		if err != nil {
+			return fmt.Errorf("%w: invalid recipient %q", ErrInvalidWebhook, r)
+		}
+		key := strings.ToLower(addr.Address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		recipients = append(recipients, addr.Address)
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("%w: at least one recipient is required", ErrInvalidWebhook)
+	}
+	if len(recipients) > webhookMaxRecipients {
+		return fmt.Errorf("%w: at most %d recipients are allowed", ErrInvalidWebhook, webhookMaxRecipients)
+	}
+	sub.Recipients = recipients
+	return nil
+}
+
+// CreateSubscription 创建订阅
+func (s *WebhookService) CreateSubscription(ctx context.Context, sub *orcmodel.WebhookSubscription) error {
+	if sub == nil {
+		return fmt.Errorf("%w: subscription data cannot be nil", ErrInvalidWebhook)
+	}
+	if err := validateWebhookSubscription(ctx, sub, s.allowPrivateNetworks); err != nil {
+		return err
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "create_webhook_subscription", "SERVICE", map[string]interface{}{
+			"operation": "create_webhook_subscription",
+			"name":      sub.Name,
+		})
+		return err
+	}
+	return nil
+}
+
+// GetSubscription 获取订阅详情
+func (s *WebhookService) GetSubscription(ctx context.Context, id uint64) (*orcmodel.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, nil
+}
+
+// UpdateSubscription 更新订阅
+// Secret 为空时沿用原密钥，避免每次修改都要重新下发密钥
+func (s *WebhookService) UpdateSubscription(ctx context.Context, sub *orcmodel.WebhookSubscription) error {
+	if sub == nil {
+		return fmt.Errorf("%w: subscription data cannot be nil", ErrInvalidWebhook)
+	}
+	existing, err := s.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		return err
+	}
+	if sub.Secret == "" {
+		sub.Secret = existing.Secret
+	}
+	if err = validateWebhookSubscription(ctx, sub, s.allowPrivateNetworks); err != nil {
+		return err
+	}
+	sub.CreatedAt = existing.CreatedAt
+	sub.CreatedBy = existing.CreatedBy
+
+	if err = s.repo.UpdateSubscription(ctx, sub); err != nil {
+		logger.LogBusinessError(err, "", 0, "", "update_webhook_subscription", "SERVICE", map[string]interface{}{
+			"operation": "update_webhook_subscription",
+			"id":        sub.ID,
+		})
+		return err
+	}
+	return nil
+}
+
+// DeleteSubscription 删除订阅
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uint64) error {
+	if _, err := s.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// ListSubscriptions 获取订阅列表
+func (s *WebhookService) ListSubscriptions(ctx context.Context, page, pageSize int) ([]*orcmodel.WebhookSubscription, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	return s.repo.ListSubscriptions(ctx, page, pageSize)
+}
+
+// ListDeliveries 获取订阅的投递记录
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID uint64, page, pageSize int) ([]*orcmodel.WebhookDelivery, int64, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, page, pageSize)
+}
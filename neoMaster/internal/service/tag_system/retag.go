@@ -0,0 +1,374 @@
+package tag_system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	assetModel "neomaster/internal/model/asset"
+	"neomaster/internal/model/tag_system"
+	"neomaster/internal/pkg/logger"
+)
+
+// retagBatchSize 批量重打标每批读取的实体数
+const retagBatchSize = 100
+
+var (
+	ErrRetagRunning           = errors.New("retag is already running")
+	ErrRetagUnsupportedEntity = errors.New("retag is not supported for entity type")
+	errRetagInterrupted       = errors.New("retag interrupted by master restart")
+)
+
+// RetagEntity 参与批量重打标的实体
+type RetagEntity struct {
+	ID         uint64                 // 主键ID，作为遍历游标 (单调递增)
+	EntityID   string                 // 写入 sys_entity_tags.entity_id 的实体ID
+	Attributes map[string]interface{} // 交给匹配规则评估的属性，需与该实体类型日常 AutoTag 传入的属性一致
+}
+
+// RetagSource 某类实体的遍历数据来源
+// NextBatch 按主键升序返回 ID 大于 afterID 的至多 limit 个实体，返回空表示遍历结束；
+// 只持有一批数据，不会把全部实体读入内存
+type RetagSource interface {
+	NextBatch(ctx context.Context, afterID uint64, limit int) ([]RetagEntity, error)
+}
+
+// modelRetagSource 基于 GORM 模型表的数据来源 (表主键列为 id)
+type modelRetagSource[T any] struct {
+	db     *gorm.DB
+	entity func(*T) RetagEntity
+}
+
+// NewModelRetagSource 创建基于模型表的数据来源，entity 负责把一行记录转换为实体ID与匹配属性
+func NewModelRetagSource[T any](db *gorm.DB, entity func(*T) RetagEntity) RetagSource {
+	return &modelRetagSource[T]{db: db, entity: entity}
+}
+
+func (m *modelRetagSource[T]) NextBatch(ctx context.Context, afterID uint64, limit int) ([]RetagEntity, error) {
+	var rows []T
+	err := m.db.WithContext(ctx).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]RetagEntity, 0, len(rows))
+	for i := range rows {
+		entities = append(entities, m.entity(&rows[i]))
+	}
+	return entities, nil
+}
+
+// retagState 批量重打标状态：数据来源、各实体类型的进度与取消函数
+// 进度同时落库到 sys_retag_jobs (db 为 nil 时仅保存在内存)，master 重启后从库中加载
+type retagState struct {
+	mu       sync.Mutex
+	sources  map[string]RetagSource
+	progress map[string]*tag_system.RetagProgress
+	cancels  map[string]context.CancelFunc
+}
+
+func newRetagState() *retagState {
+	return &retagState{
+		sources:  make(map[string]RetagSource),
+		progress: make(map[string]*tag_system.RetagProgress),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+}
+
+// registerAssetRetagSources 注册资产实体的数据来源
+// 属性与标签传播任务 (LocalAgent) 一致：整行记录按 JSON 字段名展开
+// service 实体的 AutoTag 属性来自指纹识别结果 (含 vendor)，无法从表记录还原，不在此注册
+func (s *tagService) registerAssetRetagSources() {
+	s.RegisterRetagSource("host", NewModelRetagSource(s.db, func(a *assetModel.AssetHost) RetagEntity {
+		return assetRetagEntity(a.ID, a)
+	}))
+	s.RegisterRetagSource("web", NewModelRetagSource(s.db, func(a *assetModel.AssetWeb) RetagEntity {
+		return assetRetagEntity(a.ID, a)
+	}))
+	s.RegisterRetagSource("network", NewModelRetagSource(s.db, func(a *assetModel.AssetNetwork) RetagEntity {
+		return assetRetagEntity(a.ID, a)
+	}))
+}
+
+func assetRetagEntity(id uint64, asset interface{}) RetagEntity {
+	attributes := make(map[string]interface{})
+	if data, err := json.Marshal(asset); err == nil {
+		_ = json.Unmarshal(data, &attributes)
+	}
+	return RetagEntity{ID: id, EntityID: strconv.FormatUint(id, 10), Attributes: attributes}
+}
+
+// RegisterRetagSource 注册某类实体的遍历数据来源，重复注册时覆盖
+func (s *tagService) RegisterRetagSource(entityType string, source RetagSource) {
+	s.retag.mu.Lock()
+	defer s.retag.mu.Unlock()
+	s.retag.sources[entityType] = source
+}
+
+// RetagAllEntities 规则变更后对某类型的全部存量实体重新执行 AutoTag (同步执行)
+// 按主键游标分批遍历，每处理完一个实体推进进度中的 Cursor，每批结束时进度落库；同一实体类型同时只允许一个任务 (否则返回 ErrRetagRunning)。
+// ctx 取消或读取出错时中断，状态记为 cancelled/failed，再次调用从 Cursor 之后续跑，进度计数累计；
+// master 重启后从库中加载进度续跑 (最后一批内已处理的实体会重新评估，AutoTag 幂等)；
+// 上一轮已完成时从头开始。单个实体打标失败只记录日志并计入 Failed，不中断任务。
+// 返回本次调用评估的实体数和 auto 标签发生变化的实体数
+func (s *tagService) RetagAllEntities(ctx context.Context, entityType string) (processed int, changed int, err error) {
+	run, _, err := s.beginRetag(ctx, entityType)
+	if err != nil {
+		return 0, 0, err
+	}
+	return run()
+}
+
+// StartRetag 在后台启动批量重打标，立即返回初始进度；通过 GetRetagProgress 查看、CancelRetag 取消
+func (s *tagService) StartRetag(entityType string) (*tag_system.RetagProgress, error) {
+	run, progress, err := s.beginRetag(context.Background(), entityType)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		_, _, _ = run()
+	}()
+	return progress, nil
+}
+
+// beginRetag 校验并登记一次重打标 (状态置为 running)，返回执行函数与登记时的进度副本
+func (s *tagService) beginRetag(ctx context.Context, entityType string) (func() (int, int, error), *tag_system.RetagProgress, error) {
+	s.retag.mu.Lock()
+	defer s.retag.mu.Unlock()
+	source, ok := s.retag.sources[entityType]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrRetagUnsupportedEntity, entityType)
+	}
+	progress := s.loadRetagProgressLocked(entityType)
+	if progress != nil && progress.Status == tag_system.RetagStatusRunning {
+		return nil, nil, fmt.Errorf("%w: %s", ErrRetagRunning, entityType)
+	}
+	now := time.Now()
+	if progress == nil || progress.Status == tag_system.RetagStatusCompleted {
+		progress = &tag_system.RetagProgress{EntityType: entityType, StartedAt: now}
+		s.retag.progress[entityType] = progress
+	}
+	progress.Status = tag_system.RetagStatusRunning
+	progress.Error = ""
+	progress.FinishedAt = nil
+	progress.UpdatedAt = now
+	ctx, cancel := context.WithCancel(ctx)
+	s.retag.cancels[entityType] = cancel
+
+	snapshot := *progress
+	s.saveRetagProgress(snapshot)
+	run := func() (int, int, error) {
+		defer cancel()
+		return s.runRetag(ctx, entityType, source, progress)
+	}
+	return run, &snapshot, nil
+}
+
+// runRetag 执行已登记的重打标并在结束时更新状态
+func (s *tagService) runRetag(ctx context.Context, entityType string, source RetagSource, progress *tag_system.RetagProgress) (processed int, changed int, err error) {
+	s.retag.mu.Lock()
+	cursor := progress.Cursor
+	s.retag.mu.Unlock()
+
+	err = s.retagFrom(ctx, entityType, source, cursor, func(entityChanged bool, failed bool, id uint64) {
+		processed++
+		if entityChanged {
+			changed++
+		}
+		s.retag.mu.Lock()
+		progress.Processed++
+		if entityChanged {
+			progress.Changed++
+		}
+		if failed {
+			progress.Failed++
+		}
+		progress.Cursor = id
+		progress.UpdatedAt = time.Now()
+		s.retag.mu.Unlock()
+	}, func() {
+		s.retag.mu.Lock()
+		snapshot := *progress
+		s.retag.mu.Unlock()
+		s.saveRetagProgress(snapshot)
+	})
+
+	s.retag.mu.Lock()
+	finishedAt := time.Now()
+	switch {
+	case err == nil:
+		progress.Status = tag_system.RetagStatusCompleted
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		progress.Status = tag_system.RetagStatusCancelled
+		progress.Error = err.Error()
+	default:
+		progress.Status = tag_system.RetagStatusFailed
+		progress.Error = err.Error()
+	}
+	progress.UpdatedAt = finishedAt
+	progress.FinishedAt = &finishedAt
+	delete(s.retag.cancels, entityType)
+	snapshot := *progress
+	s.retag.mu.Unlock()
+	s.saveRetagProgress(snapshot)
+
+	if err != nil {
+		logger.LogError(err, "", 0, "", "service.tag_system.RetagAllEntities", "INTERNAL", map[string]interface{}{
+			"entity_type": entityType,
+			"processed":   processed,
+			"changed":     changed,
+		})
+	}
+	return processed, changed, err
+}
+
+// retagFrom 从游标 cursor 之后分批遍历实体并执行打标，每个实体处理完后回调 done，每批处理完后回调 checkpoint
+func (s *tagService) retagFrom(ctx context.Context, entityType string, source RetagSource, cursor uint64, done func(changed bool, failed bool, id uint64), checkpoint func()) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := source.NextBatch(ctx, cursor, retagBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, entity := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			_, entityChanged, tagErr := s.autoTag(ctx, entityType, entity.EntityID, entity.Attributes)
+			if tagErr != nil {
+				logger.LogError(tagErr, "", 0, "", "service.tag_system.RetagAllEntities", "INTERNAL", map[string]interface{}{
+					"entity_type": entityType,
+					"entity_id":   entity.EntityID,
+					"action":      "auto_tag",
+				})
+			}
+			cursor = entity.ID
+			done(entityChanged, tagErr != nil, entity.ID)
+		}
+		checkpoint()
+	}
+}
+
+// GetRetagProgress 获取某类型实体的重打标进度 (副本)
+func (s *tagService) GetRetagProgress(entityType string) (*tag_system.RetagProgress, bool) {
+	s.retag.mu.Lock()
+	defer s.retag.mu.Unlock()
+	progress := s.loadRetagProgressLocked(entityType)
+	if progress == nil {
+		return nil, false
+	}
+	snapshot := *progress
+	return &snapshot, true
+}
+
+// CancelRetag 取消正在执行的重打标，已处理的进度保留，可再次调用 RetagAllEntities 续跑
+func (s *tagService) CancelRetag(entityType string) bool {
+	s.retag.mu.Lock()
+	defer s.retag.mu.Unlock()
+	cancel, ok := s.retag.cancels[entityType]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// ResumeRetags 恢复 master 重启前未执行完 (库中状态仍为 running) 的重打标任务，在后台从 Cursor 续跑
+// 需在各模块注册完数据来源后调用，返回已恢复的实体类型
+func (s *tagService) ResumeRetags() []string {
+	if s.db == nil {
+		return nil
+	}
+	var jobs []tag_system.SysRetagJob
+	if err := s.db.Where("status = ?", tag_system.RetagStatusRunning).Find(&jobs).Error; err != nil {
+		logger.LogError(err, "", 0, "", "service.tag_system.ResumeRetags", "INTERNAL", nil)
+		return nil
+	}
+	var resumed []string
+	for _, job := range jobs {
+		if _, err := s.StartRetag(job.EntityType); err != nil {
+			logger.LogError(err, "", 0, "", "service.tag_system.ResumeRetags", "INTERNAL", map[string]interface{}{
+				"entity_type": job.EntityType,
+			})
+			continue
+		}
+		resumed = append(resumed, job.EntityType)
+	}
+	return resumed
+}
+
+// loadRetagProgressLocked 获取进度，内存中没有时从库中加载 (master 重启后)，调用方需持有 s.retag.mu
+// 库中仍为 running 的任务说明上一个进程执行中退出，视为失败，可从 Cursor 续跑
+func (s *tagService) loadRetagProgressLocked(entityType string) *tag_system.RetagProgress {
+	if progress, ok := s.retag.progress[entityType]; ok {
+		return progress
+	}
+	if s.db == nil {
+		return nil
+	}
+	var job tag_system.SysRetagJob
+	if err := s.db.Where("entity_type = ?", entityType).First(&job).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.LogError(err, "", 0, "", "service.tag_system.loadRetagProgress", "INTERNAL", map[string]interface{}{
+				"entity_type": entityType,
+			})
+		}
+		return nil
+	}
+	progress := &tag_system.RetagProgress{
+		EntityType: job.EntityType,
+		Status:     job.Status,
+		Processed:  job.Processed,
+		Changed:    job.Changed,
+		Failed:     job.Failed,
+		Cursor:     job.Cursor,
+		Error:      job.Error,
+		StartedAt:  job.StartedAt,
+		UpdatedAt:  job.UpdatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if progress.Status == tag_system.RetagStatusRunning {
+		progress.Status = tag_system.RetagStatusFailed
+		progress.Error = errRetagInterrupted.Error()
+	}
+	s.retag.progress[entityType] = progress
+	return progress
+}
+
+// saveRetagProgress 按实体类型写入或覆盖库中的进度，写入失败只记录日志，不中断任务
+func (s *tagService) saveRetagProgress(progress tag_system.RetagProgress) {
+	if s.db == nil {
+		return
+	}
+	job := &tag_system.SysRetagJob{
+		EntityType: progress.EntityType,
+		Status:     progress.Status,
+		Cursor:     progress.Cursor,
+		Processed:  progress.Processed,
+		Changed:    progress.Changed,
+		Failed:     progress.Failed,
+		Error:      progress.Error,
+		StartedAt:  progress.StartedAt,
+		FinishedAt: progress.FinishedAt,
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "cursor_id", "processed", "changed", "failed", "error", "started_at", "finished_at", "updated_at"}),
+	}).Create(job).Error
+	if err != nil {
+		logger.LogError(err, "", 0, "", "service.tag_system.saveRetagProgress", "INTERNAL", map[string]interface{}{
+			"entity_type": progress.EntityType,
+			"cursor":      progress.Cursor,
+		})
+	}
+}
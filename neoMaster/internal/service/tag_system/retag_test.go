@@ -0,0 +1,224 @@
+package tag_system
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"neomaster/internal/model/basemodel"
+	"neomaster/internal/model/tag_system"
+)
+
+// sliceRetagSource 内存中的实体来源，onBatch 在每批返回前回调 (用于模拟取消)
+type sliceRetagSource struct {
+	entities []RetagEntity
+	batches  int
+	onBatch  func(batch int)
+}
+
+func (s *sliceRetagSource) NextBatch(ctx context.Context, afterID uint64, limit int) ([]RetagEntity, error) {
+	var batch []RetagEntity
+	for _, e := range s.entities {
+		if e.ID > afterID && len(batch) < limit {
+			batch = append(batch, e)
+		}
+	}
+	if len(batch) > 0 {
+		s.batches++
+		if s.onBatch != nil {
+			s.onBatch(s.batches)
+		}
+	}
+	return batch, nil
+}
+
+func TestRetagAllEntities(t *testing.T) {
+	mockRepo := &MockTagRepository{
+		Rules: []tag_system.SysMatchRule{
+			{
+				BaseModel:  basemodel.BaseModel{ID: 1},
+				TagID:      100,
+				EntityType: "host",
+				RuleJSON:   `{"field": "os", "operator": "equals", "value": "linux"}`,
+				IsEnabled:  true,
+			},
+		},
+	}
+	service := NewTagService(mockRepo, nil)
+	ctx := context.Background()
+
+	// 250 个实体，偶数ID为 linux
+	source := &sliceRetagSource{}
+	for i := 1; i <= 250; i++ {
+		os := "windows"
+		if i%2 == 0 {
+			os = "linux"
+		}
+		source.entities = append(source.entities, RetagEntity{
+			ID:         uint64(i),
+			EntityID:   strconv.Itoa(i),
+			Attributes: map[string]interface{}{"os": os},
+		})
+	}
+	service.RegisterRetagSource("host", source)
+
+	if _, _, err := service.RetagAllEntities(ctx, "web"); !errors.Is(err, ErrRetagUnsupportedEntity) {
+		t.Errorf("Expected ErrRetagUnsupportedEntity, got %v", err)
+	}
+	if _, ok := service.GetRetagProgress("host"); ok {
+		t.Error("Expected no progress before first run")
+	}
+
+	// 第一批处理完后取消：状态为 cancelled，游标停在第一批末尾
+	cancelCtx, cancel := context.WithCancel(ctx)
+	source.onBatch = func(batch int) {
+		if batch == 2 {
+			cancel()
+		}
+	}
+	processed, changed, err := service.RetagAllEntities(cancelCtx, "host")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if processed != retagBatchSize || changed != retagBatchSize/2 {
+		t.Errorf("Expected %d processed and %d changed, got %d/%d", retagBatchSize, retagBatchSize/2, processed, changed)
+	}
+	progress, _ := service.GetRetagProgress("host")
+	if progress.Status != tag_system.RetagStatusCancelled || progress.Cursor != retagBatchSize {
+		t.Errorf("Expected cancelled at cursor %d, got %+v", retagBatchSize, progress)
+	}
+
+	// 续跑：从游标之后继续，进度累计
+	source.onBatch = nil
+	processed, changed, err = service.RetagAllEntities(ctx, "host")
+	if err != nil {
+		t.Fatalf("RetagAllEntities failed: %v", err)
+	}
+	if processed != 150 || changed != 75 {
+		t.Errorf("Expected 150 processed and 75 changed on resume, got %d/%d", processed, changed)
+	}
+	progress, _ = service.GetRetagProgress("host")
+	if progress.Status != tag_system.RetagStatusCompleted || progress.Processed != 250 || progress.Changed != 125 || progress.FinishedAt == nil {
+		t.Errorf("Expected completed progress with totals 250/125, got %+v", progress)
+	}
+	if len(mockRepo.EntityTags) != 125 {
+		t.Errorf("Expected 125 entity tags, got %d", len(mockRepo.EntityTags))
+	}
+
+	// 完成后再次执行从头开始，规则未变则无变化
+	processed, changed, err = service.RetagAllEntities(ctx, "host")
+	if err != nil || processed != 250 || changed != 0 {
+		t.Errorf("Expected 250 processed and 0 changed, got %d/%d (%v)", processed, changed, err)
+	}
+
+	// 规则禁用后重打标移除全部 auto 标签
+	mockRepo.Rules = nil
+	if err = service.ReloadMatchRules(); err != nil {
+		t.Fatalf("ReloadMatchRules failed: %v", err)
+	}
+	_, changed, err = service.RetagAllEntities(ctx, "host")
+	if err != nil || changed != 125 || len(mockRepo.EntityTags) != 0 {
+		t.Errorf("Expected all 125 auto tags removed, got changed=%d tags=%d (%v)", changed, len(mockRepo.EntityTags), err)
+	}
+
+	if service.CancelRetag("host") {
+		t.Error("Expected CancelRetag to report no running retag")
+	}
+}
+
+func TestRetagAllEntities_ResumeAfterRestart(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1) // 内存库每个连接独立，后台续跑需使用同一连接
+	if err := db.AutoMigrate(&tag_system.SysRetagJob{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	mockRepo := &MockTagRepository{
+		Rules: []tag_system.SysMatchRule{
+			{
+				BaseModel:  basemodel.BaseModel{ID: 1},
+				TagID:      100,
+				EntityType: "host",
+				RuleJSON:   `{"field": "os", "operator": "equals", "value": "linux"}`,
+				IsEnabled:  true,
+			},
+		},
+	}
+	var entities []RetagEntity
+	for i := 1; i <= 250; i++ {
+		os := "windows"
+		if i%2 == 0 {
+			os = "linux"
+		}
+		entities = append(entities, RetagEntity{ID: uint64(i), EntityID: strconv.Itoa(i), Attributes: map[string]interface{}{"os": os}})
+	}
+
+	// 第一个进程：第一批处理完后进度已落库，随后中断
+	first := NewTagService(mockRepo, db)
+	ctx, cancel := context.WithCancel(context.Background())
+	first.RegisterRetagSource("host", &sliceRetagSource{entities: entities, onBatch: func(batch int) {
+		if batch != 2 {
+			return
+		}
+		var job tag_system.SysRetagJob
+		if err := db.Where("entity_type = ?", "host").First(&job).Error; err != nil {
+			t.Errorf("load persisted job: %v", err)
+		} else if job.Status != tag_system.RetagStatusRunning || job.Cursor != retagBatchSize || job.Processed != retagBatchSize {
+			t.Errorf("persisted job after first batch = %+v, want running at cursor %d", job, retagBatchSize)
+		}
+		cancel()
+	}})
+	if _, _, err := first.RetagAllEntities(ctx, "host"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	// 模拟进程在执行中退出：库中状态停留在 running
+	if err := db.Model(&tag_system.SysRetagJob{}).Where("entity_type = ?", "host").Update("status", tag_system.RetagStatusRunning).Error; err != nil {
+		t.Fatalf("update job status: %v", err)
+	}
+
+	// 重启后的进程：从库中加载进度，视为中断
+	second := NewTagService(mockRepo, db)
+	source := &sliceRetagSource{entities: entities}
+	second.RegisterRetagSource("host", source)
+	progress, ok := second.GetRetagProgress("host")
+	if !ok || progress.Status != tag_system.RetagStatusFailed || progress.Cursor != retagBatchSize || progress.Error != errRetagInterrupted.Error() {
+		t.Fatalf("Expected interrupted progress at cursor %d, got %+v", retagBatchSize, progress)
+	}
+
+	if resumed := second.ResumeRetags(); len(resumed) != 1 || resumed[0] != "host" {
+		t.Fatalf("ResumeRetags() = %v, want [host]", resumed)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if progress, _ = second.GetRetagProgress("host"); progress.Status != tag_system.RetagStatusRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if progress.Status != tag_system.RetagStatusCompleted || progress.Processed != 250 || progress.Changed != 125 {
+		t.Fatalf("Expected completed progress with totals 250/125, got %+v", progress)
+	}
+	// 续跑只遍历游标之后的实体
+	if source.batches != 2 {
+		t.Errorf("Expected 2 batches after cursor, got %d", source.batches)
+	}
+
+	var job tag_system.SysRetagJob
+	if err := db.Where("entity_type = ?", "host").First(&job).Error; err != nil {
+		t.Fatalf("load persisted job: %v", err)
+	}
+	if job.Status != tag_system.RetagStatusCompleted || job.Cursor != 250 || job.Processed != 250 || job.FinishedAt == nil {
+		t.Errorf("persisted job = %+v, want completed at cursor 250", job)
+	}
+	if resumed := second.ResumeRetags(); len(resumed) != 0 {
+		t.Errorf("ResumeRetags() after completion = %v, want none", resumed)
+	}
+}
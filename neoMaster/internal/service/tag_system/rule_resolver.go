@@ -0,0 +1,45 @@
+package tag_system
+
+import "sort"
+
+// RuleMatch 一条命中的匹配规则及其将打上的标签
+type RuleMatch struct {
+	RuleID   uint64
+	TagID    uint64
+	Priority int    // 规则优先级 (越大越优先)
+	Category string // 标签业务分类，为空时不参与冲突裁决
+}
+
+// ResolveRuleConflicts 裁决多条规则命中同一实体时的标签冲突
+// 同一分类下命中了不同标签视为冲突 (例如 "high-value" 与 "low-value")，只保留优先级最高的规则所打的标签；
+// 不同分类、或未设置分类的标签互不冲突，全部保留。多条规则命中同一标签时只保留一次，记在优先级最高的规则上。
+// 排序：优先级降序，优先级相同时规则ID小(创建早)的优先，保证规则集合不变时结果稳定。
+// 纯函数，不修改入参；返回值按上述顺序排列
+func ResolveRuleConflicts(matches []RuleMatch) []RuleMatch {
+	sorted := make([]RuleMatch, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].RuleID < sorted[j].RuleID
+	})
+
+	resolved := make([]RuleMatch, 0, len(sorted))
+	seenTags := make(map[uint64]struct{})
+	categoryWinner := make(map[string]uint64) // Category -> TagID
+	for _, m := range sorted {
+		if _, seen := seenTags[m.TagID]; seen {
+			continue
+		}
+		if m.Category != "" {
+			if winner, claimed := categoryWinner[m.Category]; claimed && winner != m.TagID {
+				continue
+			}
+			categoryWinner[m.Category] = m.TagID
+		}
+		seenTags[m.TagID] = struct{}{}
+		resolved = append(resolved, m)
+	}
+	return resolved
+}
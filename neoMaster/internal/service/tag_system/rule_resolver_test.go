@@ -0,0 +1,46 @@
+package tag_system
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveRuleConflicts(t *testing.T) {
+	matches := []RuleMatch{
+		{RuleID: 5, TagID: 11, Priority: 1, Category: "value"}, // low-value
+		{RuleID: 3, TagID: 10, Priority: 5, Category: "value"}, // high-value
+		{RuleID: 4, TagID: 20, Priority: 0, Category: ""},      // 未分类
+		{RuleID: 6, TagID: 21, Priority: 0, Category: ""},      // 未分类
+		{RuleID: 2, TagID: 30, Priority: 3, Category: "os"},    // linux
+		{RuleID: 1, TagID: 31, Priority: 3, Category: "os"},    // windows，同优先级规则ID小者胜
+		{RuleID: 7, TagID: 10, Priority: 2, Category: "value"}, // 同一标签再次命中
+	}
+	input := append([]RuleMatch(nil), matches...)
+
+	got := ResolveRuleConflicts(matches)
+	want := []RuleMatch{
+		{RuleID: 3, TagID: 10, Priority: 5, Category: "value"},
+		{RuleID: 1, TagID: 31, Priority: 3, Category: "os"},
+		{RuleID: 4, TagID: 20, Priority: 0, Category: ""},
+		{RuleID: 6, TagID: 21, Priority: 0, Category: ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if !reflect.DeepEqual(matches, input) {
+		t.Error("ResolveRuleConflicts should not modify its input")
+	}
+
+	// 输入顺序不影响结果
+	reversed := make([]RuleMatch, len(matches))
+	for i, m := range matches {
+		reversed[len(matches)-1-i] = m
+	}
+	if got := ResolveRuleConflicts(reversed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected stable result regardless of input order, got %+v", got)
+	}
+
+	if got := ResolveRuleConflicts(nil); len(got) != 0 {
+		t.Errorf("Expected empty result, got %+v", got)
+	}
+}
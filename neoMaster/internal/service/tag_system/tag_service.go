@@ -3,6 +3,7 @@ package tag_system
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -25,6 +26,9 @@ const (
 	ToolNameSysTagPropagation = "sys_tag_propagation" // 标签传播任务, 用于自动标签传播
 )
 
+// ErrInvalidMatchRule 匹配规则无法解析或评估失败 (规则测试时返回，便于在启用前发现错误规则)
+var ErrInvalidMatchRule = errors.New("invalid match rule")
+
 // TagPropagationPayload 定义标签传播任务的参数载荷
 type TagPropagationPayload struct {
 	TargetType string            `json:"target_type"` // host, web, network
@@ -54,9 +58,18 @@ type TagService interface {
 	GetRule(ctx context.Context, id uint64) (*tag_system.SysMatchRule, error)                                  // 根据ID获取匹配规则
 	ListRules(ctx context.Context, req *tag_system.ListRulesRequest) ([]tag_system.SysMatchRule, int64, error) // 获取所有匹配规则
 	ReloadMatchRules() error                                                                                   // 从数据库加载所有启用规则到内存中，缓存规则，提高性能
+	TestRule(ctx context.Context, req *tag_system.TestRuleRequest) (*tag_system.TestRuleResponse, error)       // 用样例数据测试匹配规则，不写入任何标签
 
 	// --- Auto Tagging ---
-	AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) error // 添加标签
+	AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) ([]string, error) // 按匹配规则对账实体的 auto 标签，返回命中的标签名称
+
+	// --- 批量重打标 (规则变更后对存量实体重新评估) ---
+	RegisterRetagSource(entityType string, source RetagSource)                                       // 注册某类实体的遍历数据来源
+	RetagAllEntities(ctx context.Context, entityType string) (processed int, changed int, err error) // 分批对该类型全部实体执行 AutoTag，ctx 取消时中断，可续跑
+	StartRetag(entityType string) (*tag_system.RetagProgress, error)                                 // 在后台启动批量重打标，立即返回
+	GetRetagProgress(entityType string) (*tag_system.RetagProgress, bool)                            // 获取重打标进度，从未执行过时返回 false
+	CancelRetag(entityType string) bool                                                              // 取消正在执行的重打标，未在执行时返回 false
+	ResumeRetags() []string                                                                          // 恢复 master 重启前未执行完的重打标任务，返回已恢复的实体类型
 
 	// --- 标签扩散 Propagation ---
 	SubmitPropagationTask(ctx context.Context, ruleID uint64, action string) (string, error)                                             // 提交标签传播任务
@@ -84,9 +97,10 @@ type TagService interface {
 }
 
 type CachedRule struct {
-	RuleID uint64
-	TagID  uint64
-	Rule   matcher.MatchRule
+	RuleID   uint64
+	TagID    uint64
+	Priority int
+	Rule     matcher.MatchRule
 }
 
 type MatchRuleCache struct {
@@ -104,6 +118,7 @@ type tagService struct {
 	repo      repo.TagRepository
 	db        *gorm.DB // 用于直接插入任务，或者需要事务
 	ruleCache *MatchRuleCache
+	retag     *retagState // 批量重打标的数据来源与进度
 }
 
 func NewTagService(repo repo.TagRepository, db *gorm.DB) TagService {
@@ -113,6 +128,10 @@ func NewTagService(repo repo.TagRepository, db *gorm.DB) TagService {
 		ruleCache: &MatchRuleCache{
 			rules: make(map[string][]CachedRule),
 		},
+		retag: newRetagState(),
+	}
+	if db != nil {
+		s.registerAssetRetagSources()
 	}
 	// 初始化时加载规则
 	// 注意：如果数据库连接失败，这里可能会报错，建议在应用启动时处理错误，或者这里记录日志但不panic
@@ -350,9 +369,10 @@ func (s *tagService) ReloadMatchRules() error {
 			continue
 		}
 		cr := CachedRule{
-			RuleID: r.ID,
-			TagID:  r.TagID,
-			Rule:   parsedRule,
+			RuleID:   r.ID,
+			TagID:    r.TagID,
+			Priority: r.Priority,
+			Rule:     parsedRule,
 		}
 		newCache[r.EntityType] = append(newCache[r.EntityType], cr)
 	}
@@ -363,64 +383,126 @@ func (s *tagService) ReloadMatchRules() error {
 	return nil
 }
 
+// TestRule 用样例数据测试匹配规则
+// 测试已保存的规则 (rule_id) 或编辑中的规则 (rule_json)，只评估不打标；
+// 规则解析失败、规则为空或评估出错时返回 ErrInvalidMatchRule，错误信息说明具体原因
+func (s *tagService) TestRule(ctx context.Context, req *tag_system.TestRuleRequest) (*tag_system.TestRuleResponse, error) {
+	if req == nil || req.Data == nil {
+		return nil, fmt.Errorf("data is required")
+	}
+	hasInline := len(req.RuleJSON) > 0 && string(req.RuleJSON) != "null"
+	if req.RuleID != 0 && hasInline {
+		return nil, fmt.Errorf("invalid request: rule_id and rule_json are mutually exclusive")
+	}
+	if req.RuleID == 0 && !hasInline {
+		return nil, fmt.Errorf("rule_id or rule_json is required")
+	}
+
+	// 1. 取得规则原文及关联标签
+	ruleJSON, tagID := string(req.RuleJSON), req.TagID
+	if req.RuleID != 0 {
+		ruleRecord, err := s.repo.GetRuleByID(req.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		ruleJSON, tagID = ruleRecord.RuleJSON, ruleRecord.TagID
+	}
+
+	// 2. 解析并评估 (与 AutoTag 使用同一个 matcher)
+	rule, err := matcher.ParseJSON(ruleJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parse failed: %v", ErrInvalidMatchRule, err)
+	}
+	if matcher.IsEmptyRule(rule) {
+		return nil, fmt.Errorf("%w: rule has no condition and would match every entity", ErrInvalidMatchRule)
+	}
+	matched, err := matcher.Match(req.Data, rule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: evaluation failed: %v", ErrInvalidMatchRule, err)
+	}
+
+	// 3. 命中时返回将打的标签
+	resp := &tag_system.TestRuleResponse{Matched: matched, RuleID: req.RuleID, Tags: []tag_system.SysTag{}}
+	if matched && tagID != 0 {
+		tag, err := s.repo.GetTagByID(tagID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Tags = append(resp.Tags, *tag)
+	}
+	return resp, nil
+}
+
 // --- Auto Tagging Implementation (Moved to auto_tag.go or here) ---
 // 为了保持文件简洁，AutoTag 和 SubmitPropagationTask 可以放在单独文件，或者这里
 // 这里先放这里，如果太长再拆分
-func (s *tagService) AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) error {
+
+// AutoTag 按匹配规则为实体自动打标
+// 用该实体类型的全部启用规则 (缓存，按优先级降序) 评估 attributes，并与实体现有的 auto 标签对账：
+// 新命中的添加，不再命中的移除；同一标签被多条规则命中时记录优先级最高的规则；非 auto 来源 (如 manual) 的同名标签保持不变
+// 同一标签分类下命中多个不同标签时按 ResolveRuleConflicts 裁决，只保留优先级最高的规则所打的标签
+// 返回本次命中的标签名称列表
+func (s *tagService) AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) ([]string, error) {
+	names, _, err := s.autoTag(ctx, entityType, entityID, attributes)
+	return names, err
+}
+
+// autoTag AutoTag 的实现，额外返回实体的 auto 标签是否发生变化 (供批量重打标统计)
+func (s *tagService) autoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) ([]string, bool, error) {
 	// 1. 获取该实体类型的所有启用规则 (FROM CACHE)
 	cachedRules := s.ruleCache.Get(entityType)
 
-	if len(cachedRules) == 0 {
-		return nil
-	}
-
 	// 2. 遍历规则进行匹配
-	var matchedTagIDs []uint64
-	var matchedRuleIDs []uint64
-
+	// 注意：没有任何规则时也要继续对账，规则被删除/禁用后残留的 auto 标签需要移除
+	var matches []RuleMatch
+	var candidateTagIDs []uint64
 	for _, cr := range cachedRules {
-		// 执行匹配
-		matched, err2 := matcher.Match(attributes, cr.Rule)
-		if err2 != nil {
+		matched, err := matcher.Match(attributes, cr.Rule)
+		if err != nil {
+			logger.LogError(err, "", 0, "", "service.tag_system.AutoTag", "INTERNAL", map[string]interface{}{
+				"rule_id":     cr.RuleID,
+				"entity_type": entityType,
+				"entity_id":   entityID,
+				"action":      "match_rule",
+			})
 			continue
 		}
+		if !matched {
+			continue
+		}
+		matches = append(matches, RuleMatch{RuleID: cr.RuleID, TagID: cr.TagID, Priority: cr.Priority})
+		candidateTagIDs = append(candidateTagIDs, cr.TagID)
+	}
 
-		if matched {
-			// 匹配成功,记录标签ID和规则ID成列表
-			matchedTagIDs = append(matchedTagIDs, cr.TagID)
-			matchedRuleIDs = append(matchedRuleIDs, cr.RuleID)
+	// 2.1 按标签分类裁决冲突 (TagID -> RuleID)
+	tagNames := make(map[uint64]string)
+	if len(candidateTagIDs) > 0 {
+		tags, err := s.repo.GetTagsByIDs(candidateTagIDs)
+		if err != nil {
+			return nil, false, err
+		}
+		categories := make(map[uint64]string, len(tags))
+		for _, t := range tags {
+			categories[t.ID] = t.Category
+			tagNames[t.ID] = t.Name
+		}
+		for i := range matches {
+			matches[i].Category = categories[matches[i].TagID]
 		}
 	}
+	matchedRules := make(map[uint64]uint64)
+	var matchedTagIDs []uint64
+	for _, m := range ResolveRuleConflicts(matches) {
+		matchedRules[m.TagID] = m.RuleID
+		matchedTagIDs = append(matchedTagIDs, m.TagID)
+	}
 
 	// 3. 更新实体标签 (State Reconciliation)
-	// 使用通用的 SyncEntityTags 方法
-	// 注意：AutoTag 的 scope 是 "auto"，并且如果是单个规则触发，这里其实有点特殊。
-	// AutoTag 现在的逻辑是基于“所有规则”的一次性全量计算。
-	// 所以我们可以直接把 matchedTagIDs 传给 SyncEntityTags。
-	// 但是 SyncEntityTags 只能接受一个 ruleID，而 AutoTag 不同的标签可能来自不同的 ruleID。
-	// 这是一个设计冲突。
-	//
-	// 修正：AutoTag 场景比较特殊，它是一次计算出多个 Tag 对应 多个 Rule。
-	// 而 SyncEntityTags 假设的是 targetTagIDs 都属于同一个 source 和 rule (或者 rule=0)。
-	//
-	// 方案：
-	// 既然 AutoTag 逻辑已经很完善（支持多 Rule），我们保留 AutoTag 的独立逻辑，
-	// 或者是让 SyncEntityTags 支持 Map[TagID]RuleID？
-	// 考虑到复杂性，我们先实现一个基础版的 SyncEntityTags 用于 Agent Report，
-	// AutoTag 保持现状（因为它是最复杂的场景）。
-
-	// ... Wait, actually we can refactor SyncEntityTags to be more generic.
-	// But for now, to satisfy the immediate requirement (Agent Report),
-	// let's implement SyncEntityTags separately and keep AutoTag as is for now.
-	// We can refactor AutoTag later to use a more advanced version of SyncEntityTags.
-
-	// 保持 AutoTag 原有逻辑不变
-	// ... (原代码)
-
+	// SyncEntityTags 只能接受一个 ruleID，而 AutoTag 不同的标签来自不同的规则，因此单独对账
 	// Step 3.1: 从数据库获取现有 Auto 标签
 	existingTags, err := s.repo.GetEntityTags(entityType, entityID)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 
 	existingAutoTagMap := make(map[uint64]uint64) // TagID -> RuleID
@@ -434,10 +516,10 @@ func (s *tagService) AutoTag(ctx context.Context, entityType string, entityID st
 		}
 	}
 
-	// Step 3.2: 计算差异
-	// 需要添加的
-	for i, tagID := range matchedTagIDs {
-		ruleID := matchedRuleIDs[i]
+	// Step 3.2: 添加/更新命中的标签
+	changed := false
+	for _, tagID := range matchedTagIDs {
+		ruleID := matchedRules[tagID]
 
 		// 检查是否存在非 auto 来源的同名标签 (例如 manual)
 		// 如果存在，则跳过覆盖，保留原有的 manual 状态
@@ -445,15 +527,14 @@ func (s *tagService) AutoTag(ctx context.Context, entityType string, entityID st
 			continue
 		}
 
-		// 如果已存在且RuleID一致，跳过 (已存在标签,且规则ID一致,则无需重复添加)
-		if currRuleID, exists := existingAutoTagMap[tagID]; exists {
-			if currRuleID == ruleID {
-				delete(existingAutoTagMap, tagID) // 标记为已处理
-				continue
-			}
+		// 已存在且RuleID一致，无需重复添加
+		currRuleID, exists := existingAutoTagMap[tagID]
+		delete(existingAutoTagMap, tagID) // 标记为已处理
+		if exists && currRuleID == ruleID {
+			continue
 		}
 
-		// 添加/更新 (不存在或RuleID不一致,更新或添加标签)
+		// 不存在或RuleID不一致,添加或更新标签
 		err := s.repo.AddEntityTag(&tag_system.SysEntityTag{
 			EntityType: entityType,
 			EntityID:   entityID,
@@ -462,22 +543,28 @@ func (s *tagService) AutoTag(ctx context.Context, entityType string, entityID st
 			RuleID:     ruleID,
 		})
 		if err != nil {
-			return err
+			return nil, false, err
 		}
-
-		// 从待删除列表中移除 (如果之前存在)
-		delete(existingAutoTagMap, tagID)
+		changed = true
 	}
 
 	// Step 3.3: 移除不再命中的标签 (剩下的 existingAutoTagMap)
 	for tagID := range existingAutoTagMap {
 		err := s.repo.RemoveEntityTag(entityType, entityID, tagID)
 		if err != nil {
-			return err
+			return nil, false, err
 		}
+		changed = true
 	}
 
-	return nil
+	// 4. 返回命中的标签名称 (按裁决顺序，已删除的标签不返回)
+	names := make([]string, 0, len(matchedTagIDs))
+	for _, tagID := range matchedTagIDs {
+		if name, ok := tagNames[tagID]; ok {
+			names = append(names, name)
+		}
+	}
+	return names, changed, nil
 }
 
 // SyncEntityTags 全量同步实体的标签 (用于 Agent Report 等场景)
@@ -2,8 +2,13 @@ package tag_system
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
 
+	"gorm.io/gorm"
+
 	"neomaster/internal/model/basemodel"
 	"neomaster/internal/model/tag_system"
 )
@@ -12,6 +17,7 @@ import (
 type MockTagRepository struct {
 	Rules      []tag_system.SysMatchRule
 	EntityTags []tag_system.SysEntityTag
+	Tags       []tag_system.SysTag
 }
 
 func (m *MockTagRepository) CreateTag(tag *tag_system.SysTag) error { return nil }
@@ -22,10 +28,20 @@ func (m *MockTagRepository) GetTagByName(name string) (*tag_system.SysTag, error
 func (m *MockTagRepository) GetTagsByParent(parentID uint64) ([]tag_system.SysTag, error) {
 	return nil, nil
 }
-func (m *MockTagRepository) GetTagsByIDs(ids []uint64) ([]tag_system.SysTag, error) { return nil, nil }
-func (m *MockTagRepository) UpdateTag(tag *tag_system.SysTag) error                 { return nil }
-func (m *MockTagRepository) MoveTag(id, targetParentID uint64) error                { return nil }
-func (m *MockTagRepository) DeleteTag(id uint64, force bool) error                  { return nil }
+func (m *MockTagRepository) GetTagsByIDs(ids []uint64) ([]tag_system.SysTag, error) {
+	var res []tag_system.SysTag
+	for _, tag := range m.Tags {
+		for _, id := range ids {
+			if tag.ID == id {
+				res = append(res, tag)
+			}
+		}
+	}
+	return res, nil
+}
+func (m *MockTagRepository) UpdateTag(tag *tag_system.SysTag) error  { return nil }
+func (m *MockTagRepository) MoveTag(id, targetParentID uint64) error { return nil }
+func (m *MockTagRepository) DeleteTag(id uint64, force bool) error   { return nil }
 func (m *MockTagRepository) ListTags(req *tag_system.ListTagsRequest) ([]tag_system.SysTag, int64, error) {
 	return nil, 0, nil
 }
@@ -37,9 +53,16 @@ func (m *MockTagRepository) GetRulesByEntityType(entityType string) ([]tag_syste
 func (m *MockTagRepository) ListRules(req *tag_system.ListRulesRequest) ([]tag_system.SysMatchRule, int64, error) {
 	return m.Rules, int64(len(m.Rules)), nil
 }
-func (m *MockTagRepository) GetRuleByID(id uint64) (*tag_system.SysMatchRule, error) { return nil, nil }
-func (m *MockTagRepository) UpdateRule(rule *tag_system.SysMatchRule) error          { return nil }
-func (m *MockTagRepository) DeleteRule(id uint64) error                              { return nil }
+func (m *MockTagRepository) GetRuleByID(id uint64) (*tag_system.SysMatchRule, error) {
+	for i := range m.Rules {
+		if m.Rules[i].ID == id {
+			return &m.Rules[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (m *MockTagRepository) UpdateRule(rule *tag_system.SysMatchRule) error { return nil }
+func (m *MockTagRepository) DeleteRule(id uint64) error                     { return nil }
 
 func (m *MockTagRepository) AddEntityTag(et *tag_system.SysEntityTag) error {
 	m.EntityTags = append(m.EntityTags, *et)
@@ -100,7 +123,7 @@ func TestAutoTag(t *testing.T) {
 		"open_ports": []int{80, 443},
 	}
 
-	err := service.AutoTag(ctx, "host", "host-1", attrs1)
+	_, err := service.AutoTag(ctx, "host", "host-1", attrs1)
 	if err != nil {
 		t.Fatalf("AutoTag failed: %v", err)
 	}
@@ -121,7 +144,7 @@ func TestAutoTag(t *testing.T) {
 	// Reset repo tags for host-2
 	mockRepo.EntityTags = []tag_system.SysEntityTag{}
 
-	err = service.AutoTag(ctx, "host", "host-2", attrs2)
+	_, err = service.AutoTag(ctx, "host", "host-2", attrs2)
 	if err != nil {
 		t.Fatalf("AutoTag failed: %v", err)
 	}
@@ -144,7 +167,7 @@ func TestAutoTag(t *testing.T) {
 		"open_ports": []int{22},        // Still matches Rule 2
 	}
 
-	err = service.AutoTag(ctx, "host", "host-2", attrs3)
+	_, err = service.AutoTag(ctx, "host", "host-2", attrs3)
 	if err != nil {
 		t.Fatalf("AutoTag failed: %v", err)
 	}
@@ -157,3 +180,163 @@ func TestAutoTag(t *testing.T) {
 		t.Errorf("Expected TagID 200, got %d", mockRepo.EntityTags[0].TagID)
 	}
 }
+
+func TestAutoTag_ReconcileAndResult(t *testing.T) {
+	mockRepo := &MockTagRepository{
+		Rules: []tag_system.SysMatchRule{
+			{
+				BaseModel:  basemodel.BaseModel{ID: 1},
+				TagID:      100,
+				EntityType: "agent",
+				Priority:   10,
+				RuleJSON:   `{"field": "os", "operator": "contains", "value": "linux"}`,
+				IsEnabled:  true,
+			},
+			{
+				BaseModel:  basemodel.BaseModel{ID: 2},
+				TagID:      100,
+				EntityType: "agent",
+				RuleJSON:   `{"field": "task_support", "operator": "list_contains", "value": "portScan"}`,
+				IsEnabled:  true,
+			},
+		},
+		EntityTags: []tag_system.SysEntityTag{
+			{EntityType: "agent", EntityID: "agent-1", TagID: 300, Source: "manual"},
+		},
+		Tags: []tag_system.SysTag{{BaseModel: basemodel.BaseModel{ID: 100}, Name: "linux-scanner"}},
+	}
+	service := NewTagService(mockRepo, nil)
+	ctx := context.Background()
+	attrs := map[string]interface{}{"os": "linux", "task_support": []string{"portScan"}}
+
+	// 两条规则命中同一标签：只记录一次，RuleID 取优先级靠前的规则
+	names, err := service.AutoTag(ctx, "agent", "agent-1", attrs)
+	if err != nil {
+		t.Fatalf("AutoTag failed: %v", err)
+	}
+	if len(names) != 1 || names[0] != "linux-scanner" {
+		t.Errorf("Expected [linux-scanner], got %v", names)
+	}
+	if len(mockRepo.EntityTags) != 2 || mockRepo.EntityTags[1].RuleID != 1 {
+		t.Errorf("Expected manual tag kept and auto tag from rule 1, got %+v", mockRepo.EntityTags)
+	}
+
+	// 重复执行结果不变
+	if _, err = service.AutoTag(ctx, "agent", "agent-1", attrs); err != nil {
+		t.Fatalf("AutoTag failed: %v", err)
+	}
+	if len(mockRepo.EntityTags) != 2 {
+		t.Errorf("Expected 2 tags after rerun, got %d", len(mockRepo.EntityTags))
+	}
+
+	// 规则全部禁用后残留的 auto 标签被移除，手动标签保留
+	mockRepo.Rules = nil
+	if err = service.ReloadMatchRules(); err != nil {
+		t.Fatalf("ReloadMatchRules failed: %v", err)
+	}
+	names, err = service.AutoTag(ctx, "agent", "agent-1", attrs)
+	if err != nil {
+		t.Fatalf("AutoTag failed: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("Expected no matched tags, got %v", names)
+	}
+	if len(mockRepo.EntityTags) != 1 || mockRepo.EntityTags[0].TagID != 300 {
+		t.Errorf("Expected only manual tag 300, got %+v", mockRepo.EntityTags)
+	}
+}
+
+func TestAutoTag_ConflictResolution(t *testing.T) {
+	mockRepo := &MockTagRepository{
+		Rules: []tag_system.SysMatchRule{
+			{BaseModel: basemodel.BaseModel{ID: 1}, TagID: 101, EntityType: "host", Priority: 1, IsEnabled: true,
+				RuleJSON: `{"field": "port", "operator": "equals", "value": 80}`},
+			{BaseModel: basemodel.BaseModel{ID: 2}, TagID: 100, EntityType: "host", Priority: 10, IsEnabled: true,
+				RuleJSON: `{"field": "os", "operator": "contains", "value": "linux"}`},
+			{BaseModel: basemodel.BaseModel{ID: 3}, TagID: 200, EntityType: "host", IsEnabled: true,
+				RuleJSON: `{"field": "port", "operator": "equals", "value": 80}`},
+		},
+		Tags: []tag_system.SysTag{
+			{BaseModel: basemodel.BaseModel{ID: 100}, Name: "high-value", Category: "value"},
+			{BaseModel: basemodel.BaseModel{ID: 101}, Name: "low-value", Category: "value"},
+			{BaseModel: basemodel.BaseModel{ID: 200}, Name: "web"},
+		},
+	}
+	service := NewTagService(mockRepo, nil)
+
+	// 同分类冲突只保留高优先级规则的标签，不同分类的标签全部保留
+	names, err := service.AutoTag(context.Background(), "host", "host-1", map[string]interface{}{"os": "linux", "port": 80})
+	if err != nil {
+		t.Fatalf("AutoTag failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"high-value", "web"}) {
+		t.Errorf("Expected [high-value web], got %v", names)
+	}
+	for _, et := range mockRepo.EntityTags {
+		if et.TagID == 101 {
+			t.Errorf("Conflicting low-priority tag should not be applied: %+v", et)
+		}
+	}
+}
+
+func TestTestRule(t *testing.T) {
+	mockRepo := &MockTagRepository{
+		Rules: []tag_system.SysMatchRule{
+			{
+				BaseModel:  basemodel.BaseModel{ID: 1},
+				TagID:      100,
+				EntityType: "host",
+				RuleJSON:   `{"field": "os", "operator": "contains", "value": "linux"}`,
+				IsEnabled:  false,
+			},
+		},
+	}
+	service := NewTagService(mockRepo, nil)
+	ctx := context.Background()
+	data := map[string]interface{}{"os": "ubuntu linux", "port": 22}
+
+	// 已保存的规则 (未启用也可测试)
+	resp, err := service.TestRule(ctx, &tag_system.TestRuleRequest{RuleID: 1, Data: data})
+	if err != nil {
+		t.Fatalf("TestRule failed: %v", err)
+	}
+	if !resp.Matched || len(resp.Tags) != 1 || resp.Tags[0].ID != 100 {
+		t.Errorf("Expected match with tag 100, got %+v", resp)
+	}
+
+	// 编辑中的规则，未命中时不返回标签
+	resp, err = service.TestRule(ctx, &tag_system.TestRuleRequest{
+		RuleJSON: json.RawMessage(`{"field": "port", "operator": "greater_than", "value": 1024}`),
+		TagID:    100,
+		Data:     data,
+	})
+	if err != nil {
+		t.Fatalf("TestRule failed: %v", err)
+	}
+	if resp.Matched || len(resp.Tags) != 0 {
+		t.Errorf("Expected no match, got %+v", resp)
+	}
+
+	// 错误规则在启用前暴露
+	badRules := []string{
+		`{"field": "os", "operator": }`, // JSON 解析失败
+		`{"and": {"field": "os"}}`,      // 结构不符
+		`{}`,                            // 空规则会命中一切
+		`{"field": "os", "operator": "regex", "value": "(("}`,     // 正则无法编译
+		`{"field": "os", "operator": "approx", "value": "linux"}`, // 未知操作符
+	}
+	for _, raw := range badRules {
+		_, err = service.TestRule(ctx, &tag_system.TestRuleRequest{RuleJSON: json.RawMessage(raw), Data: data})
+		if !errors.Is(err, ErrInvalidMatchRule) {
+			t.Errorf("Expected ErrInvalidMatchRule for %s, got %v", raw, err)
+		}
+	}
+
+	// 参数校验
+	if _, err = service.TestRule(ctx, &tag_system.TestRuleRequest{Data: data}); err == nil {
+		t.Error("Expected error when neither rule_id nor rule_json is given")
+	}
+	if _, err = service.TestRule(ctx, &tag_system.TestRuleRequest{RuleID: 9, Data: data}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected not found for missing rule, got %v", err)
+	}
+}
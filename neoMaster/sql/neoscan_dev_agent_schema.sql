@@ -23,6 +23,7 @@ USE `neoscan_dev`;
 DROP TABLE IF EXISTS `agents`;
 DROP TABLE IF EXISTS `agent_versions`;
 DROP TABLE IF EXISTS `agent_configs`;
+DROP TABLE IF EXISTS `agent_group_configs`;
 DROP TABLE IF EXISTS `agent_metrics`;
 DROP TABLE IF EXISTS `agent_scan_types`;
 DROP TABLE IF EXISTS `agent_tag_types`;
@@ -56,17 +57,24 @@ CREATE TABLE `agents` (
     `token_expiry` datetime DEFAULT NULL COMMENT 'Token过期时间',
     `result_latest_time` datetime DEFAULT NULL COMMENT '最新返回结果时间',
     `last_heartbeat` datetime DEFAULT NULL COMMENT '最后心跳时间',
+    `heartbeat_interval` int NOT NULL DEFAULT 0 COMMENT '心跳间隔(秒)，0使用默认值',
+    `offline_threshold` int NOT NULL DEFAULT 0 COMMENT '离线阈值(秒)，超过该时长无心跳标记为离线，0使用默认值(心跳间隔的3倍)',
+    `running_tasks` json DEFAULT NULL COMMENT '运行中任务进度(心跳上报，与last_heartbeat同时更新)',
     `remark` varchar(500) DEFAULT NULL COMMENT '备注信息',
     `container_id` varchar(100) DEFAULT NULL COMMENT '容器ID',
     `pid` int DEFAULT NULL COMMENT '进程ID',
+    `lock_version` bigint NOT NULL DEFAULT '1' COMMENT '乐观锁版本号',
     `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间，对应BaseModel.CreatedAt',
     `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间，对应BaseModel.UpdatedAt',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
     PRIMARY KEY (`id`),
     UNIQUE KEY `uk_agent_id` (`agent_id`),
+    KEY `idx_agents_deleted_at` (`deleted_at`),
     KEY `idx_agents_status` (`status`),
     KEY `idx_agents_ip_address` (`ip_address`),
     KEY `idx_agents_last_heartbeat` (`last_heartbeat`),
-    KEY `idx_agents_created_at` (`created_at`)
+    KEY `idx_agents_created_at` (`created_at`),
+    FULLTEXT KEY `ft_agents_search` (`agent_id`, `hostname`, `remark`, `os`, `arch`, `version`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Agent基础信息表';
 
 -- 2. Agent版本信息表 (agent_versions)
@@ -101,6 +109,7 @@ CREATE TABLE `agent_configs` (
     `token_expiry_duration` int NOT NULL DEFAULT '86400' COMMENT 'Token过期时间(秒)',
     `token_never_expire` tinyint(1) NOT NULL DEFAULT '0' COMMENT 'Token是否永不过期',
     `is_active` tinyint(1) NOT NULL DEFAULT '1' COMMENT '是否激活',
+    `sources` json DEFAULT NULL COMMENT '配置项各来源(default/group/agent)写入的值，按写入先后排列，键为配置项名，插件配置为plugin_config.<插件名>',
     `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间，对应BaseModel.CreatedAt',
     `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间，对应BaseModel.UpdatedAt',
     PRIMARY KEY (`id`),
@@ -109,6 +118,18 @@ CREATE TABLE `agent_configs` (
     KEY `idx_agent_configs_version` (`version`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Agent配置表';
 
+-- 3.1 Agent分组配置表 (agent_group_configs)
+CREATE TABLE `agent_group_configs` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID，对应BaseModel.ID(uint64)',
+    `group_tag_id` bigint unsigned NOT NULL COMMENT '分组标签ID(sys_tags.id，分类为agent_group)',
+    `patch` json DEFAULT NULL COMMENT '分组配置补丁(仅包含已设置字段)，之后加入分组的Agent继承',
+    `version` int NOT NULL DEFAULT '1' COMMENT '分组配置版本号，每次批量更新 +1',
+    `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间，对应BaseModel.CreatedAt',
+    `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间，对应BaseModel.UpdatedAt',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_agent_group_configs_group_tag_id` (`group_tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Agent分组配置表';
+
 -- 4. Agent指标表 (agent_metrics)
 CREATE TABLE `agent_metrics` (
     `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID，对应BaseModel.ID(uint64)',
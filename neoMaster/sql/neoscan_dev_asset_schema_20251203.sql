@@ -49,12 +49,15 @@ CREATE TABLE `asset_hosts` (
   `updated_at` datetime(3) DEFAULT NULL,
   `deleted_at` datetime(3) DEFAULT NULL,
   `ip` varchar(50) NOT NULL COMMENT 'IP地址',
+  `ip_hex` varchar(32) DEFAULT NULL COMMENT 'IP排序键(16字节十六进制)，用于网段范围查询',
   `hostname` varchar(200) DEFAULT NULL COMMENT '主机名',
   `os` varchar(100) DEFAULT NULL COMMENT '操作系统',
+  `first_seen_at` datetime(3) DEFAULT NULL COMMENT '首次发现时间',
   `last_seen_at` datetime(3) DEFAULT NULL COMMENT '最后发现时间',
   `source_stage_ids` json DEFAULT NULL COMMENT '来源阶段ID列表(JSON)',
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_asset_hosts_ip` (`ip`),
+  KEY `idx_asset_hosts_ip_hex` (`ip_hex`),
   KEY `idx_asset_hosts_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='主机资产表';
 
@@ -77,9 +80,12 @@ CREATE TABLE `asset_services` (
   `cpe` varchar(255) DEFAULT NULL COMMENT 'CPE标识',
   `fingerprint` json DEFAULT NULL COMMENT '指纹信息(JSON)',
   `asset_type` varchar(50) DEFAULT 'service' COMMENT '资产类型',
+  `dedup_key` varchar(64) DEFAULT NULL COMMENT '去重键(IP+端口+服务指纹)',
+  `seen_count` int DEFAULT '1' COMMENT '被扫描发现的次数',
   `last_seen_at` datetime(3) DEFAULT NULL COMMENT '最后发现时间',
   PRIMARY KEY (`id`),
   KEY `idx_asset_services_host_id` (`host_id`),
+  KEY `idx_asset_services_dedup_key` (`dedup_key`),
   KEY `idx_asset_services_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='服务资产表';
 
@@ -365,9 +371,9 @@ CREATE TABLE `asset_etl_errors` (
   `result_type` varchar(64) NOT NULL COMMENT '结果类型',
   `raw_data` text COMMENT '原始 StageResult JSON',
   `error_msg` text COMMENT '错误堆栈信息',
-  `error_stage` varchar(20) DEFAULT NULL COMMENT '出错阶段: mapper, merger',
+  `error_stage` varchar(20) DEFAULT NULL COMMENT '出错阶段: mapper, merger, ingest(Agent结果格式错误)',
   `retry_count` int DEFAULT '0' COMMENT '重试次数',
-  `status` varchar(20) DEFAULT 'new' COMMENT '状态: new, retrying, resolved, ignored',
+  `status` varchar(20) DEFAULT 'new' COMMENT '状态: new, retrying, resolved, ignored, quarantined(不参与重放)',
   PRIMARY KEY (`id`),
   KEY `idx_asset_etl_errors_deleted_at` (`deleted_at`),
   KEY `idx_asset_etl_errors_project_id` (`project_id`),
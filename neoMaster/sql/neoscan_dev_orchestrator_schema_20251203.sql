@@ -22,11 +22,14 @@ CREATE TABLE `projects` (
   -- `tags` json DEFAULT NULL COMMENT '标签列表(JSON)',
   `last_exec_time` datetime(3) DEFAULT NULL COMMENT '最后一次执行开始时间',
   `last_exec_id` varchar(100) DEFAULT NULL COMMENT '最后一次执行的任务ID',
+  `run_seq` bigint DEFAULT '0' COMMENT '执行轮次序号(每次开始新一轮执行时递增)',
+  `next_run_at` datetime(3) DEFAULT NULL COMMENT '下一次定时执行时间(仅cron调度)',
   `created_by` bigint unsigned DEFAULT NULL COMMENT '创建者UserID',
   `updated_by` bigint unsigned DEFAULT NULL COMMENT '更新者UserID',
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_projects_name` (`name`),
-  KEY `idx_projects_deleted_at` (`deleted_at`)
+  KEY `idx_projects_deleted_at` (`deleted_at`),
+  KEY `idx_projects_next_run_at` (`next_run_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='项目主表';
 
 -- ----------------------------
@@ -135,6 +138,7 @@ CREATE TABLE `stage_results` (
   `updated_at` datetime(3) DEFAULT NULL,
   `deleted_at` datetime(3) DEFAULT NULL,
   `project_id` bigint unsigned NOT NULL COMMENT '所属项目ID',
+  `run_id` bigint DEFAULT '0' COMMENT '所属项目执行轮次(Project.RunSeq)',
   `workflow_id` bigint unsigned NOT NULL COMMENT '所属工作流ID',
   `stage_id` bigint unsigned NOT NULL COMMENT '阶段ID',
   `task_id` varchar(64) NOT NULL COMMENT '关联的任务ID',
@@ -142,20 +146,47 @@ CREATE TABLE `stage_results` (
   `result_type` varchar(50) DEFAULT NULL COMMENT '结果类型枚举',
   `target_type` varchar(50) DEFAULT NULL COMMENT '目标类型',
   `target_value` varchar(2048) DEFAULT NULL COMMENT '目标值',
+  `port` bigint DEFAULT '0' COMMENT '发现所在端口(端口级结果，取自结构化属性)',
+  `proto` varchar(10) DEFAULT NULL COMMENT '发现所在端口的传输协议(tcp/udp)',
   `attributes` json DEFAULT NULL COMMENT '结构化属性(JSON)',
   `evidence` json DEFAULT NULL COMMENT '原始证据(JSON)',
   `produced_at` datetime(3) DEFAULT NULL COMMENT '产生时间',
   `producer` varchar(100) DEFAULT NULL COMMENT '工具标识与版本',
   `output_config_hash` varchar(64) DEFAULT NULL COMMENT '输出配置指纹',
   `output_actions` json DEFAULT NULL COMMENT '实际执行的轻量动作摘要(JSON)',
+  `finding_key` varchar(64) DEFAULT NULL COMMENT '发现标识(结果类型+目标+端口+协议)，用于跨轮次比对',
+  `content_hash` varchar(64) DEFAULT NULL COMMENT '结构化属性指纹，用于判断发现是否变化',
   PRIMARY KEY (`id`),
   KEY `idx_stage_results_project_id` (`project_id`),
+  KEY `idx_stage_results_run_finding` (`project_id`,`run_id`,`finding_key`),
   KEY `idx_stage_results_workflow_id` (`workflow_id`),
   KEY `idx_stage_results_stage_id` (`stage_id`),
   KEY `idx_stage_results_agent_id` (`agent_id`),
   KEY `idx_stage_results_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='扫描结果表';
 
+-- ----------------------------
+-- Table structure for agent_result_batches
+-- Agent 结果批次处理记录，与结果入库同一事务写入；batch_id 唯一，重放的批次直接返回 report
+-- ----------------------------
+DROP TABLE IF EXISTS `agent_result_batches`;
+CREATE TABLE `agent_result_batches` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  `batch_id` varchar(64) NOT NULL COMMENT '批次ID(Agent生成，重放时不变)',
+  `agent_id` varchar(100) NOT NULL COMMENT '上报的AgentID',
+  `total` bigint DEFAULT '0' COMMENT '批次内结果条数',
+  `accepted` bigint DEFAULT '0' COMMENT '入库条数',
+  `skipped` bigint DEFAULT '0' COMMENT '跳过条数(执行失败无结果)',
+  `quarantined` bigint DEFAULT '0' COMMENT '隔离条数(格式错误)',
+  `rejected` bigint DEFAULT '0' COMMENT '拒绝条数(任务不存在/不属于该Agent/不在运行中)',
+  `report` text COMMENT '处理报告(JSON)，重放时原样返回',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_agent_result_batches_batch_id` (`batch_id`),
+  KEY `idx_agent_result_batches_agent_id` (`agent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Agent结果批次表';
+
 -- ----------------------------
 -- Table structure for scan_tool_templates
 -- ----------------------------
@@ -190,8 +221,9 @@ CREATE TABLE `agent_tasks` (
   `project_id` bigint unsigned NOT NULL COMMENT '所属项目ID',
   `workflow_id` bigint unsigned NOT NULL COMMENT '所属工作流ID',
   `stage_id` bigint unsigned NOT NULL COMMENT '所属阶段ID',
+  `run_id` bigint DEFAULT '0' COMMENT '所属项目执行轮次(Project.RunSeq)',
   `agent_id` varchar(100) DEFAULT NULL COMMENT '执行Agent的ID',
-  `status` varchar(20) DEFAULT 'pending' COMMENT '任务状态(pending/assigned/running/completed/failed)',
+  `status` varchar(20) DEFAULT 'pending' COMMENT '任务状态(pending/assigned/running/completed/failed/dead/canceling/canceled)',
   `priority` int DEFAULT '0' COMMENT '任务优先级',
   `task_type` varchar(20) DEFAULT 'tool' COMMENT '任务类型',
   `task_category` varchar(20) DEFAULT 'agent' COMMENT '任务分类(agent/system)',
@@ -212,6 +244,63 @@ CREATE TABLE `agent_tasks` (
   KEY `idx_agent_tasks_project_id` (`project_id`),
   KEY `idx_agent_tasks_workflow_id` (`workflow_id`),
   KEY `idx_agent_tasks_stage_id` (`stage_id`),
+  KEY `idx_agent_tasks_run_id` (`run_id`),
   KEY `idx_agent_tasks_agent_id` (`agent_id`),
   KEY `idx_agent_tasks_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Agent任务表';
+
+-- ----------------------------
+-- Table structure for dispatch_locks
+-- 认领任务时 SELECT ... FOR UPDATE 锁住对应行，保证多实例并发分发时不突破并发上限
+-- ----------------------------
+DROP TABLE IF EXISTS `dispatch_locks`;
+CREATE TABLE `dispatch_locks` (
+  `name` varchar(50) NOT NULL COMMENT '锁名称',
+  `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='任务分发锁表';
+
+-- ----------------------------
+-- Table structure for webhook_subscriptions
+-- ----------------------------
+DROP TABLE IF EXISTS `webhook_subscriptions`;
+CREATE TABLE `webhook_subscriptions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+  `name` varchar(100) NOT NULL COMMENT '订阅名称',
+  `type` varchar(20) DEFAULT 'generic' COMMENT '消息格式(generic/slack/dingtalk/email)',
+  `url` varchar(500) NOT NULL COMMENT '推送地址(http/https，email类型为空)',
+  `secret` varchar(200) DEFAULT NULL COMMENT '签名密钥(generic必填；dingtalk为机器人加签密钥)',
+  `event_types` json DEFAULT NULL COMMENT '订阅的事件类型列表(JSON数组)',
+  `recipients` json DEFAULT NULL COMMENT '收件人列表(仅email类型)',
+  `attach_csv` tinyint(1) DEFAULT '0' COMMENT '是否附带漏洞CSV(仅email类型)',
+  `enabled` tinyint(1) DEFAULT '1' COMMENT '是否启用',
+  `created_by` bigint unsigned DEFAULT NULL COMMENT '创建者UserID',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook订阅表';
+
+-- ----------------------------
+-- Table structure for webhook_deliveries
+-- 每次投递尝试(含重试)一行，同一事件的多次尝试共享 event_id；合并投递时 event_id 为第一个事件的 ID
+-- ----------------------------
+DROP TABLE IF EXISTS `webhook_deliveries`;
+CREATE TABLE `webhook_deliveries` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+  `subscription_id` bigint unsigned NOT NULL COMMENT '订阅ID',
+  `event_id` varchar(64) NOT NULL COMMENT '事件ID(接收方可据此去重)',
+  `event_type` varchar(50) DEFAULT NULL COMMENT '事件类型(合并多种事件时为digest)',
+  `event_count` bigint DEFAULT '1' COMMENT '本次投递合并的事件数(限流期间的事件合并为一条消息)',
+  `payload` text COMMENT '推送的请求体(JSON)',
+  `attempt` bigint DEFAULT NULL COMMENT '第几次尝试(从1开始)',
+  `status` varchar(20) DEFAULT NULL COMMENT '投递结果(success/failed)',
+  `response_code` bigint DEFAULT NULL COMMENT 'HTTP响应码(请求未完成时为0)',
+  `error` text COMMENT '失败原因',
+  `duration_ms` bigint DEFAULT NULL COMMENT '请求耗时(毫秒)',
+  `delivered_at` datetime(3) DEFAULT NULL COMMENT '尝试时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_deliveries_subscription_id` (`subscription_id`),
+  KEY `idx_webhook_deliveries_event_id` (`event_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook投递记录表';
@@ -15,6 +15,7 @@ CREATE TABLE `users` (
     `email` varchar(100) NOT NULL COMMENT '邮箱地址，唯一索引，必须符合邮箱格式',
     `password` varchar(255) NOT NULL COMMENT '用户密码，加密存储',
     `password_v` bigint NOT NULL DEFAULT '1' COMMENT '密码版本号,用于使旧token失效',
+    `lock_version` bigint NOT NULL DEFAULT '1' COMMENT '乐观锁版本号',
     `nickname` varchar(50) DEFAULT NULL COMMENT '用户昵称，最大50字符',
     `avatar` varchar(255) DEFAULT NULL COMMENT '用户头像URL，最大255字符',
     `phone` varchar(20) DEFAULT NULL COMMENT '手机号码，最大20字符',
@@ -23,6 +24,8 @@ CREATE TABLE `users` (
     `status` tinyint NOT NULL DEFAULT '1' COMMENT '用户状态:0-禁用,1-启用',
     `last_login_at` datetime DEFAULT NULL COMMENT '最后登录时间',
     `last_login_ip` varchar(45) DEFAULT NULL COMMENT '最后登录IP',
+    `totp_secret` varchar(255) DEFAULT NULL COMMENT 'TOTP密钥(AES-GCM加密)',
+    `totp_enabled` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否启用TOTP两步验证',
     `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
@@ -44,10 +47,12 @@ CREATE TABLE `roles` (
     `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    `parent_role_id` bigint unsigned DEFAULT NULL COMMENT '父角色ID,继承父角色权限',
     PRIMARY KEY (`id`),
     UNIQUE KEY `idx_roles_name` (`name`),
     KEY `idx_roles_deleted_at` (`deleted_at`),
-    KEY `idx_roles_status` (`status`)
+    KEY `idx_roles_status` (`status`),
+    KEY `idx_roles_parent_role_id` (`parent_role_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色表';
 
 -- 3. 权限表 (permissions)
@@ -98,6 +103,48 @@ CREATE TABLE `role_permissions` (
     CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色权限关联表';
 
+-- 6. 审计日志表 (audit_logs)
+CREATE TABLE `audit_logs` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `user_id` bigint unsigned DEFAULT NULL COMMENT '操作用户ID',
+    `username` varchar(50) DEFAULT NULL COMMENT '用户名',
+    `action` varchar(100) DEFAULT NULL COMMENT '操作动作',
+    `resource` varchar(255) DEFAULT NULL COMMENT '操作资源',
+    `result` varchar(20) DEFAULT NULL COMMENT '操作结果',
+    `client_ip` varchar(45) DEFAULT NULL COMMENT '客户端IP',
+    `user_agent` varchar(500) DEFAULT NULL COMMENT '用户代理',
+    `request_id` varchar(64) DEFAULT NULL COMMENT '请求追踪ID',
+    `details` json DEFAULT NULL COMMENT '详情(额外字段)',
+    `timestamp` datetime(3) NOT NULL COMMENT '操作时间',
+    `created_at` datetime(3) DEFAULT NULL COMMENT '入库时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_audit_logs_user_id` (`user_id`),
+    KEY `idx_audit_logs_action` (`action`),
+    KEY `idx_audit_logs_resource` (`resource`),
+    KEY `idx_audit_logs_timestamp` (`timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='审计日志表';
+
+-- 7. API密钥表 (api_keys)
+CREATE TABLE `api_keys` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `name` varchar(100) NOT NULL COMMENT '密钥名称',
+    `key_prefix` varchar(16) NOT NULL COMMENT '密钥前缀(用于识别)',
+    `key_hash` varchar(64) NOT NULL COMMENT '密钥SHA-256哈希',
+    `user_id` bigint unsigned NOT NULL COMMENT '所属用户ID',
+    `scopes` json DEFAULT NULL COMMENT '授权范围',
+    `expires_at` datetime(3) DEFAULT NULL COMMENT '过期时间',
+    `last_used_at` datetime(3) DEFAULT NULL COMMENT '最后使用时间',
+    `last_used_ip` varchar(45) DEFAULT NULL COMMENT '最后使用IP',
+    `revoked_at` datetime(3) DEFAULT NULL COMMENT '吊销时间',
+    `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+    `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_api_keys_key_hash` (`key_hash`),
+    KEY `idx_api_keys_user_id` (`user_id`),
+    KEY `idx_api_keys_revoked_at` (`revoked_at`),
+    CONSTRAINT `fk_api_keys_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='API密钥表';
+
 -- 插入默认数据
 -- 默认角色
 INSERT INTO `roles` (`name`, `display_name`, `description`, `status`) VALUES
@@ -176,5 +223,5 @@ CREATE INDEX `idx_role_permissions_created_at` ON `role_permissions` (`created_a
 -- 显示建表完成信息
 SELECT 'NeoScan数据库表结构创建完成！' as message;
 SELECT 'Database: neoscan_dev' as database_info;
-SELECT 'Tables created: users, roles, permissions, user_roles, role_permissions' as tables_info;
+SELECT 'Tables created: users, roles, permissions, user_roles, role_permissions, audit_logs' as tables_info;
 SELECT 'Default data inserted: admin role and user' as data_info;
\ No newline at end of file
@@ -52,3 +52,23 @@ CREATE TABLE IF NOT EXISTS `sys_entity_tags` (
   KEY `idx_entity` (`entity_type`,`entity_id`),
   KEY `idx_sys_entity_tags_tag_id` (`tag_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='实体-标签关联表';
+
+-- Table: sys_retag_jobs
+DROP TABLE IF EXISTS `sys_retag_jobs`;
+CREATE TABLE IF NOT EXISTS `sys_retag_jobs` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+  `entity_type` varchar(50) NOT NULL COMMENT '实体类型',
+  `status` varchar(20) NOT NULL COMMENT '任务状态',
+  `cursor_id` bigint(20) unsigned DEFAULT '0' COMMENT '最后处理完成的实体ID',
+  `processed` bigint(20) DEFAULT '0' COMMENT '已处理实体数',
+  `changed` bigint(20) DEFAULT '0' COMMENT '标签变化实体数',
+  `failed` bigint(20) DEFAULT '0' COMMENT '打标失败实体数',
+  `error` text COMMENT '中断原因',
+  `started_at` datetime(3) DEFAULT NULL COMMENT '开始时间',
+  `finished_at` datetime(3) DEFAULT NULL COMMENT '结束时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_sys_retag_jobs_entity_type` (`entity_type`),
+  KEY `idx_sys_retag_jobs_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='批量重打标任务进度表';
@@ -15,6 +15,7 @@ CREATE TABLE `users` (
     `email` varchar(100) NOT NULL COMMENT '邮箱地址，唯一索引，必须符合邮箱格式',
     `password` varchar(255) NOT NULL COMMENT '用户密码，加密存储',
     `password_v` bigint NOT NULL DEFAULT '1' COMMENT '密码版本号,用于使旧token失效',
+    `lock_version` bigint NOT NULL DEFAULT '1' COMMENT '乐观锁版本号',
     `nickname` varchar(50) DEFAULT NULL COMMENT '用户昵称，最大50字符',
     `avatar` varchar(255) DEFAULT NULL COMMENT '用户头像URL，最大255字符',
     `phone` varchar(20) DEFAULT NULL COMMENT '手机号码，最大20字符',
@@ -23,6 +24,8 @@ CREATE TABLE `users` (
     `status` tinyint NOT NULL DEFAULT '1' COMMENT '用户状态:0-禁用,1-启用',
     `last_login_at` datetime DEFAULT NULL COMMENT '最后登录时间',
     `last_login_ip` varchar(45) DEFAULT NULL COMMENT '最后登录IP',
+    `totp_secret` varchar(255) DEFAULT NULL COMMENT 'TOTP密钥(AES-GCM加密)',
+    `totp_enabled` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否启用TOTP两步验证',
     `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
@@ -44,10 +47,12 @@ CREATE TABLE `roles` (
     `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    `parent_role_id` bigint unsigned DEFAULT NULL COMMENT '父角色ID,继承父角色权限',
     PRIMARY KEY (`id`),
     UNIQUE KEY `idx_roles_name` (`name`),
     KEY `idx_roles_deleted_at` (`deleted_at`),
-    KEY `idx_roles_status` (`status`)
+    KEY `idx_roles_status` (`status`),
+    KEY `idx_roles_parent_role_id` (`parent_role_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色表';
 
 -- 3. 权限表 (permissions)
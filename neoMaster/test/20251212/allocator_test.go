@@ -10,6 +10,7 @@ import (
 	"neomaster/internal/model/orchestrator"
 	tagSystemModel "neomaster/internal/model/tag_system"
 	"neomaster/internal/service/orchestrator/allocator"
+	tagService "neomaster/internal/service/tag_system"
 )
 
 // MockTagService 用于测试的 Mock TagService
@@ -99,9 +100,24 @@ func (m *MockTagService) ListRules(ctx context.Context, req *tagSystemModel.List
 	return nil, 0, nil
 }
 func (m *MockTagService) ReloadMatchRules() error { return nil }
-func (m *MockTagService) AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) error {
-	return nil
+func (m *MockTagService) TestRule(ctx context.Context, req *tagSystemModel.TestRuleRequest) (*tagSystemModel.TestRuleResponse, error) {
+	return nil, nil
+}
+func (m *MockTagService) AutoTag(ctx context.Context, entityType string, entityID string, attributes map[string]interface{}) ([]string, error) {
+	return nil, nil
+}
+func (m *MockTagService) RegisterRetagSource(entityType string, source tagService.RetagSource) {}
+func (m *MockTagService) RetagAllEntities(ctx context.Context, entityType string) (int, int, error) {
+	return 0, 0, nil
+}
+func (m *MockTagService) StartRetag(entityType string) (*tagSystemModel.RetagProgress, error) {
+	return nil, nil
+}
+func (m *MockTagService) GetRetagProgress(entityType string) (*tagSystemModel.RetagProgress, bool) {
+	return nil, false
 }
+func (m *MockTagService) CancelRetag(entityType string) bool { return false }
+func (m *MockTagService) ResumeRetags() []string             { return nil }
 func (m *MockTagService) SubmitPropagationTask(ctx context.Context, ruleID uint64, action string) (string, error) {
 	return "", nil
 }
@@ -147,7 +147,7 @@ func TestTagSystemIntegration(t *testing.T) {
 		"port": 8080,
 		"ip":   "192.168.1.10",
 	}
-	if err := svc.AutoTag(ctx, "host", hostID_A, attrs_A); err != nil {
+	if _, err := svc.AutoTag(ctx, "host", hostID_A, attrs_A); err != nil {
 		t.Fatalf("AutoTag failed for Case A: %v", err)
 	}
 
@@ -168,7 +168,7 @@ func TestTagSystemIntegration(t *testing.T) {
 		"port": 22,
 		"ip":   "192.168.1.11",
 	}
-	if err1 := svc.AutoTag(ctx, "host", hostID_B, attrs_B); err1 != nil {
+	if _, err1 := svc.AutoTag(ctx, "host", hostID_B, attrs_B); err1 != nil {
 		t.Fatalf("AutoTag failed for Case B: %v", err1)
 	}
 	tagsB, _ := repo.GetEntityTags("host", hostID_B)
@@ -255,7 +255,7 @@ func TestTagSystemIntegration(t *testing.T) {
 
 	// 4.3 再次运行 AutoTag (针对 Host A)
 	// Host A: Port=8080 (Matches Rule 1), IP=192.168.1.10 (Matches Rule 2)
-	if err5 := svc.AutoTag(ctx, "host", hostID_A, attrs_A); err5 != nil {
+	if _, err5 := svc.AutoTag(ctx, "host", hostID_A, attrs_A); err5 != nil {
 		t.Fatalf("AutoTag failed for Case A (Multi): %v", err5)
 	}
 
@@ -327,7 +327,7 @@ func TestTagSystemIntegration(t *testing.T) {
 
 	// 5.4 运行 AutoTag (针对 Host B)
 	// Host B 命中 Rule 3
-	if err11 := svc.AutoTag(ctx, "host", hostID_B, attrs_B); err11 != nil {
+	if _, err11 := svc.AutoTag(ctx, "host", hostID_B, attrs_B); err11 != nil {
 		t.Fatalf("AutoTag failed for Case B (Manual): %v", err11)
 	}
 
@@ -160,7 +160,7 @@ func TestTagService_AutoTag(t *testing.T) {
 	}
 	entityID := "test_host_001"
 
-	if err := tagSvc.AutoTag(ctx, "host", entityID, attributes); err != nil {
+	if _, err := tagSvc.AutoTag(ctx, "host", entityID, attributes); err != nil {
 		t.Fatalf("AutoTag failed: %v", err)
 	}
 
@@ -185,7 +185,7 @@ func TestTagService_AutoTag(t *testing.T) {
 	attributesMismatch := map[string]interface{}{
 		"os_type": "windows",
 	}
-	if err := tagSvc.AutoTag(ctx, "host", entityID, attributesMismatch); err != nil {
+	if _, err := tagSvc.AutoTag(ctx, "host", entityID, attributesMismatch); err != nil {
 		t.Fatalf("AutoTag (mismatch) failed: %v", err)
 	}
 